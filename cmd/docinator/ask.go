@@ -0,0 +1,72 @@
+package docinator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/moseye/docinator/pkg/llm"
+	"github.com/spf13/cobra"
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask <question>",
+	Short: "Answer a question from the cached corpus with citations",
+	Long: `Retrieve the most relevant sections of the cached corpus (vector search when
+embeddings exist, keyword search otherwise), send them with the question to the
+configured LLM endpoint, and print the answer followed by the cited packages and symbols.
+Requires MONGODB_URI and LLM_BASE_URL or LLM_API_KEY.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k, _ := cmd.Flags().GetInt("limit")
+		budget, _ := cmd.Flags().GetInt("context-tokens")
+		ctx := cmd.Context()
+
+		client := llm.NewFromEnv()
+		if client == nil {
+			return withHint(errors.New("ask needs an LLM endpoint"), "set LLM_BASE_URL or LLM_API_KEY")
+		}
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "ask", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		question := strings.Join(args, " ")
+		matches, mode, err := retrieveChunks(ctx, store, client, question, k)
+		if err != nil {
+			return fmt.Errorf("retrieval failed: %w", err)
+		}
+		if len(matches) == 0 {
+			return withHint(errors.New("no relevant documentation found in the cached corpus"),
+				"scrape the packages the question is about, or run docinator chunk --embed for vector search")
+		}
+		log.Printf("Retrieved %d chunk(s) using %s search", len(matches), mode)
+
+		messages, used := llm.AskMessages(question, matches, budget)
+		if len(used) == 0 {
+			// Without excerpts the model could only guess.
+			return withHint(fmt.Errorf("none of the %d retrieved chunk(s) fits in %d context tokens", len(matches), budget), "raise --context-tokens")
+		}
+		answer, err := client.Complete(ctx, messages)
+		if err != nil {
+			return fmt.Errorf("LLM request failed: %w", err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "%s\n\nSources:\n", answer)
+		for i, m := range used {
+			fmt.Fprintf(out, "[%d] %s#%s (%s)\n", i+1, m.Source, m.Anchor, m.Heading)
+		}
+		return nil
+	},
+}
+
+func init() {
+	askCmd.Flags().IntP("limit", "k", 8, "number of chunks to retrieve")
+	askCmd.Flags().Int("context-tokens", 6000, "approximate token budget for retrieved excerpts")
+}
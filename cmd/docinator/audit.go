@@ -0,0 +1,186 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/audit"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the cached corpus",
+}
+
+var auditDeprecationsCmd = &cobra.Command{
+	Use:   "deprecations",
+	Short: "List deprecated symbols of cached packages and where a codebase uses them",
+	Long: `List the deprecated constants, variables, functions, types and methods of every
+cached package and version, and deprecated modules, with the replacement hint
+of each "Deprecated:" paragraph.
+
+--dir checks a local codebase instead: only the cached packages its Go files
+import are audited, at the version its go.mod requires when that version is
+cached as a pinned snapshot (scrape path@version), and only deprecated symbols
+it uses are listed, with file and line. Calls of a deprecated method are
+reported as possible uses, since they are matched by name. The exit status is
+1 when the codebase uses a deprecated API:
+
+  docinator audit deprecations --dir .`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var opts auditOptions
+		opts.Dir, _ = cmd.Flags().GetString("dir")
+		opts.All, _ = cmd.Flags().GetBool("all")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		ctx := cmd.Context()
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "audit", "set MONGODB_URI or BOLT_PATH")
+		}
+		deps, err := runAuditDeprecations(ctx, store, opts)
+		if err != nil {
+			return fmt.Errorf("audit failed: %w", err)
+		}
+		out := cmd.OutOrStdout()
+		if asJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			enc.Encode(deps)
+		} else {
+			printDeprecations(out, deps, opts.Dir != "")
+		}
+		if opts.Dir != "" && usesDeprecated(deps) {
+			stopProfiling()
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	auditDeprecationsCmd.Flags().String("dir", "", "local codebase whose imports to check for deprecated APIs")
+	auditDeprecationsCmd.Flags().Bool("all", false, "with --dir, also list deprecated symbols the codebase does not use")
+	auditDeprecationsCmd.Flags().Bool("json", false, "print the deprecations as JSON")
+	auditCmd.AddCommand(auditDeprecationsCmd)
+}
+
+// auditOptions controls runAuditDeprecations.
+type auditOptions struct {
+	Dir string // local codebase to match against; empty audits the whole corpus
+	All bool   // with Dir, keep deprecations the codebase does not use
+}
+
+// runAuditDeprecations collects the deprecations of the cached packages, or with opts.Dir of the
+// packages the codebase there imports, matched against its uses.
+func runAuditDeprecations(ctx context.Context, store storage.Store, opts auditOptions) ([]audit.Deprecation, error) {
+	docs := map[string]*models.Package{} // document ID → package
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package != nil {
+			docs[doc.ID] = doc.Package
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []audit.Deprecation
+	if opts.Dir == "" {
+		for _, pkg := range docs {
+			deps = append(deps, audit.Deprecations(pkg)...)
+		}
+		audit.Sort(deps)
+		return deps, nil
+	}
+
+	usage, err := audit.ScanUsage(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range usage.Imports() {
+		pkg := docs[path]
+		if v := usage.Version(path); v != "" {
+			if pinned := docs[path+"@"+v]; pinned != nil {
+				pkg = pinned
+			} else if pkg != nil && pkg.Version != v {
+				log.Printf("%s: go.mod requires %s, which is not cached; auditing %s (scrape %s@%s to audit the required version)", path, v, pkg.Version, path, v)
+			}
+		}
+		if pkg == nil {
+			continue
+		}
+		for _, d := range audit.Deprecations(pkg) {
+			usage.Match(&d, pkg.Name)
+			if opts.All || len(d.Uses) > 0 {
+				deps = append(deps, d)
+			}
+		}
+	}
+	audit.Sort(deps)
+	return deps, nil
+}
+
+// usesDeprecated reports whether any deprecation has a use that is not merely possible.
+func usesDeprecated(deps []audit.Deprecation) bool {
+	for _, d := range deps {
+		for _, u := range d.Uses {
+			if !u.Possible {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printDeprecations writes deps grouped by package version; withUses adds where each is used.
+func printDeprecations(out io.Writer, deps []audit.Deprecation, withUses bool) {
+	prev := ""
+	pkgs, used := 0, 0
+	for _, d := range deps {
+		id := d.ImportPath
+		if d.Version != "" {
+			id += "@" + d.Version
+		}
+		if id != prev {
+			fmt.Fprintln(out, id)
+			prev = id
+			pkgs++
+		}
+		line := "  " + d.Kind
+		if d.Symbol != "" {
+			line += " " + d.Symbol
+		}
+		if d.Hint != "" {
+			line += ": " + d.Hint
+		}
+		fmt.Fprintln(out, line)
+		if len(d.Uses) > 0 {
+			used++
+		}
+		for _, u := range d.Uses {
+			verb := "used at"
+			if u.Possible {
+				verb = "possibly used at"
+			}
+			fmt.Fprintf(out, "      %s %s:%d\n", verb, u.File, u.Line)
+		}
+	}
+	fmt.Fprintf(out, "%d deprecation(s) in %d package version(s)", len(deps), pkgs)
+	if withUses {
+		fmt.Fprintf(out, ", %d used by the codebase", used)
+	}
+	fmt.Fprintln(out)
+}
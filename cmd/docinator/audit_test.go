@@ -0,0 +1,50 @@
+package docinator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestRunAuditDeprecations(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	latest := &models.Package{Name: "legacy", ImportPath: "example.com/legacy", Version: "v1.3.0",
+		Functions: []models.Function{{Name: "Parse", Description: "Deprecated: Use ParseContext."}, {Name: "Load", Deprecated: "deprecated"}}}
+	pinned := &models.Package{Name: "legacy", ImportPath: "example.com/legacy", Version: "v1.2.0",
+		Functions: []models.Function{{Name: "Parse", Description: "Deprecated: Use ParseV2."}}}
+	for _, doc := range []*models.Document{
+		{ID: "example.com/legacy", Package: latest},
+		{ID: "example.com/legacy@v1.2.0", Package: pinned},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deps, err := runAuditDeprecations(ctx, store, auditOptions{})
+	if err != nil {
+		t.Fatalf("runAuditDeprecations failed: %v", err)
+	}
+	if len(deps) != 3 || deps[0].Version != "v1.2.0" {
+		t.Errorf("Expected the deprecations of both cached versions, got %+v", deps)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\nrequire example.com/legacy v1.2.0\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nimport \"example.com/legacy\"\n\nvar _ = legacy.Parse\n"), 0644)
+	deps, err = runAuditDeprecations(ctx, store, auditOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("runAuditDeprecations failed: %v", err)
+	}
+	if len(deps) != 1 || deps[0].Version != "v1.2.0" || deps[0].Hint != "Use ParseV2." || len(deps[0].Uses) != 1 {
+		t.Errorf("Expected the used deprecation of the required version, got %+v", deps)
+	}
+	if !usesDeprecated(deps) {
+		t.Error("Expected the codebase to use a deprecated API")
+	}
+}
@@ -0,0 +1,91 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/tui"
+	"github.com/moseye/docinator/pkg/search"
+	"github.com/spf13/cobra"
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "Browse the cached corpus in a terminal UI",
+	Long: `Open an interactive terminal browser listing cached packages with a markdown
+preview and a symbol jump list. Packages can be refreshed from pkg.go.dev,
+diffed against a fresh scrape, or deleted from the cache.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if !loader.store.Enabled() {
+			return needsCache(loader.store, "browse", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		// Logs would corrupt the full-screen UI; keep only what --log-file captures.
+		logFile, _ := rootCmd.PersistentFlags().GetString("log-file")
+		if logFile == "" {
+			log.SetOutput(io.Discard)
+		}
+
+		model := tui.New(cmd.Context(), &browseSource{loader: loader})
+		if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(cmd.Context())).Run(); err != nil {
+			return fmt.Errorf("browser failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// browseSource adapts the loader and its store to the browser.
+type browseSource struct {
+	loader *packageLoader
+}
+
+func (b *browseSource) List(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := b.loader.store.ForEach(ctx, func(doc *models.Document) error {
+		ids = append(ids, doc.ID)
+		return nil
+	})
+	sort.Strings(ids)
+	return ids, err
+}
+
+func (b *browseSource) Load(ctx context.Context, importPath string) (*models.Package, error) {
+	doc, err := b.loader.store.GetByID(ctx, importPath)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	return doc.Package, nil
+}
+
+func (b *browseSource) Scrape(ctx context.Context, importPath string) (*models.Package, error) {
+	pkg, _, err := b.loader.scraper.ScrapePackageWithRaw(ctx, importPath)
+	return pkg, err
+}
+
+func (b *browseSource) Refresh(ctx context.Context, importPath string) (*models.Package, error) {
+	pkg, rawHTML, err := b.loader.scraper.ScrapePackageWithRaw(ctx, importPath)
+	if err != nil {
+		return nil, err
+	}
+	b.loader.enrich(ctx, pkg)
+	return pkg, b.loader.store.Upsert(ctx, &models.Document{ID: importPath, Package: pkg, RawHTML: rawHTML})
+}
+
+func (b *browseSource) Delete(ctx context.Context, importPath string) error {
+	return b.loader.store.Delete(ctx, importPath)
+}
+
+func (b *browseSource) Symbols(ctx context.Context) ([]search.Symbol, error) {
+	return corpusSymbols(ctx, b.loader.store, nil)
+}
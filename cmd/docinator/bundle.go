@@ -0,0 +1,171 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/spf13/cobra"
+)
+
+// bundleIndex is the combined index written at the root of a bundle.
+const bundleIndex = "index.md"
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <package>",
+	Short: "Write a package and its direct dependencies as one cross-linked output set",
+	Long: `Load (from cache) or scrape a package plus the first N packages it imports
+from other modules, as listed on its Imports tab, and write their markdown to
+the output directory (default "bundle") together with index.md, a combined
+index of every package in the bundle. The package's page links to the
+dependencies it was bundled with, and every dependency links back to the index:
+
+  docinator bundle github.com/spf13/cobra --deps 5 -o cobra-docs`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		if outputDir == "" {
+			outputDir = "bundle"
+		}
+		n, _ := cmd.Flags().GetInt("deps")
+
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if err := runBundle(cmd.Context(), loader, args[0], n, outputDir); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	bundleCmd.Flags().Int("deps", 10, "number of direct dependencies to include (0: all)")
+}
+
+// runBundle loads target and up to n of its direct dependencies and writes them to outputDir.
+func runBundle(ctx context.Context, loader *packageLoader, target string, n int, outputDir string) error {
+	// The Imports tab does not depend on the package page; fetch both at once.
+	var imports []string
+	var importsErr error
+	listed := make(chan struct{})
+	go func() {
+		defer close(listed)
+		imports, importsErr = loader.scraper.ScrapeImports(ctx, target)
+	}()
+	pkg, _, err := loader.load(ctx, target)
+	<-listed
+	if err != nil {
+		return err
+	}
+	if importsErr != nil {
+		return fmt.Errorf("listing the imports of %s: %w", target, importsErr)
+	}
+	deps, skipped := directDependencies(pkg, imports, n)
+	log.Printf("Bundling %s with %d of its direct dependencies", pkg.ImportPath, len(deps))
+
+	depPkgs, errs := loader.loadAll(ctx, deps)
+	for _, err := range errs {
+		log.Printf("Scraping error: %v", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output dir: %w", err)
+	}
+	root := renderedPackage{pkg: pkg, version: pinnedVersion(target)}
+	rootPage := outputPage(root)
+	var links strings.Builder
+	links.WriteString("\n## Bundled Dependencies\n\n")
+	for _, dep := range depPkgs {
+		fmt.Fprintf(&links, "- [%s](%s)%s\n", dep.ImportPath, relativeLink(rootPage, outputPage(renderedPackage{pkg: dep})), synopsisSuffix(dep))
+	}
+	root.markdown = markdown.PackageToMarkdown(pkg) + links.String()
+	writeRendered(outputDir, root, outputFormats{formatMarkdown: true}, false)
+
+	for _, dep := range depPkgs {
+		r := renderedPackage{pkg: dep}
+		r.markdown = markdown.PackageToMarkdown(dep) + fmt.Sprintf("\n---\n\nBundled as a dependency of [%s](%s); see the [bundle index](%s).\n",
+			pkg.ImportPath, relativeLink(outputPage(r), rootPage), relativeLink(outputPage(r), bundleIndex))
+		writeRendered(outputDir, r, outputFormats{formatMarkdown: true}, false)
+	}
+
+	index := bundleIndexMarkdown(root, depPkgs, skipped, errs)
+	if err := os.WriteFile(filepath.Join(outputDir, bundleIndex), []byte(index), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", bundleIndex, err)
+	}
+	log.Printf("Wrote %d packages and %s to %s", 1+len(depPkgs), bundleIndex, outputDir)
+	return nil
+}
+
+// directDependencies picks up to n (0: all) of imports outside the standard library and outside
+// pkg's own module, in the Imports tab order, and returns them with the number left out by n.
+func directDependencies(pkg *models.Package, imports []string, n int) ([]string, int) {
+	var deps []string
+	for _, p := range imports {
+		first, _, _ := strings.Cut(p, "/")
+		if !strings.Contains(first, ".") || p == pkg.ImportPath {
+			continue // standard library
+		}
+		if pkg.Module != "" && (p == pkg.Module || strings.HasPrefix(p, pkg.Module+"/")) {
+			continue
+		}
+		deps = append(deps, p)
+	}
+	if n > 0 && len(deps) > n {
+		return deps[:n], len(deps) - n
+	}
+	return deps, 0
+}
+
+// bundleIndexMarkdown lists the packages of a bundle with links to their pages.
+func bundleIndexMarkdown(root renderedPackage, deps []*models.Package, skipped int, errs []error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s bundle\n\n", root.pkg.ImportPath)
+	if root.pkg.Synopsis != "" {
+		b.WriteString(root.pkg.Synopsis + "\n\n")
+	}
+	b.WriteString("| Package | Version | Synopsis |\n|---|---|---|\n")
+	row := func(r renderedPackage) {
+		fmt.Fprintf(&b, "| [%s](%s) | %s | %s |\n", r.pkg.ImportPath, outputPage(r), r.pkg.Version,
+			strings.ReplaceAll(r.pkg.Synopsis, "|", `\|`))
+	}
+	row(root)
+	for _, dep := range deps {
+		row(renderedPackage{pkg: dep})
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&b, "\n%d more direct dependencies were left out; raise --deps to include them.\n", skipped)
+	}
+	if len(errs) > 0 {
+		b.WriteString("\n## Not Bundled\n\n")
+		for _, err := range errs {
+			b.WriteString("- " + err.Error() + "\n")
+		}
+	}
+	return b.String()
+}
+
+// relativeLink returns the link from one output page to another, both relative to the output directory.
+func relativeLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+func synopsisSuffix(pkg *models.Package) string {
+	if pkg.Synopsis == "" {
+		return ""
+	}
+	return " — " + pkg.Synopsis
+}
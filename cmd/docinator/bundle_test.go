@@ -0,0 +1,48 @@
+package docinator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+func TestRunBundle(t *testing.T) {
+	loader, cleanup, err := newLoader(memstore.New(), &scraper.ScrapingConfig{TestMode: true}, false)
+	if err != nil {
+		t.Fatalf("newLoader failed: %v", err)
+	}
+	defer cleanup()
+
+	dir := t.TempDir()
+	if err := runBundle(context.Background(), loader, "github.com/spf13/cobra", 1, dir); err != nil {
+		t.Fatalf("runBundle failed: %v", err)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "github.com", "spf13", "cobra.md"))
+	if err != nil || !strings.Contains(string(page), "## Bundled Dependencies\n\n- [github.com/spf13/pflag](pflag.md) — Commander library\n") {
+		t.Errorf("Expected the package page to link its dependency, got %q (%v)", page, err)
+	}
+	dep, err := os.ReadFile(filepath.Join(dir, "github.com", "spf13", "pflag.md"))
+	if err != nil || !strings.Contains(string(dep), "[github.com/spf13/cobra](cobra.md); see the [bundle index](../../index.md)") {
+		t.Errorf("Expected the dependency to link back, got %q (%v)", dep, err)
+	}
+	index, err := os.ReadFile(filepath.Join(dir, bundleIndex))
+	if err != nil || !strings.Contains(string(index), "| [github.com/spf13/pflag](github.com/spf13/pflag.md) | v1.9.1 |") ||
+		!strings.Contains(string(index), "1 more direct dependencies were left out") {
+		t.Errorf("Expected the combined index, got %q (%v)", index, err)
+	}
+}
+
+func TestDirectDependencies(t *testing.T) {
+	pkg := &models.Package{ImportPath: "github.com/spf13/cobra/doc", Module: "github.com/spf13/cobra"}
+	imports := []string{"fmt", "os/exec", "github.com/spf13/cobra", "github.com/spf13/pflag", "gopkg.in/yaml.v3", "github.com/cpuguy83/go-md2man/v2/md2man"}
+	deps, skipped := directDependencies(pkg, imports, 2)
+	if strings.Join(deps, ",") != "github.com/spf13/pflag,gopkg.in/yaml.v3" || skipped != 1 {
+		t.Errorf("Expected the first two imports of other modules and 1 skipped, got %v and %d", deps, skipped)
+	}
+}
@@ -0,0 +1,68 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+// pinnedCobra is a batch of pinned versions of one import path.
+var pinnedCobra = []string{"github.com/spf13/cobra@v1.6.0", "github.com/spf13/cobra@v1.7.0", "github.com/spf13/cobra@v1.8.0", "github.com/spf13/cobra@v1.9.0", "github.com/spf13/cobra@v1.9.1"}
+
+// onStored is a --progress-json writer calling fn once, when the first package is stored.
+type onStored struct {
+	once sync.Once
+	fn   func()
+}
+
+func (o *onStored) Write(p []byte) (int, error) {
+	if bytes.Contains(p, []byte(`"event":"stored"`)) {
+		o.once.Do(o.fn)
+	}
+	return len(p), nil
+}
+
+// checkCheckpoint checks that every one of the pinned importPaths was either written to dir or
+// left in its checkpoint, and the first one written.
+func checkCheckpoint(t *testing.T, dir string, importPaths []string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	checkpointed := map[string]bool{}
+	for _, p := range strings.Fields(string(data)) {
+		checkpointed[p] = true
+	}
+	for i, p := range importPaths {
+		path, version, _ := strings.Cut(p, "@")
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(outputPage(renderedPackage{pkg: &models.Package{ImportPath: path}, version: version}))))
+		written := err == nil
+		if i == 0 && !written {
+			t.Errorf("Expected %s written before the batch stopped", p)
+		}
+		if written == checkpointed[p] {
+			t.Errorf("Expected %s either written or checkpointed, got written %v, checkpointed %v", p, written, checkpointed[p])
+		}
+	}
+}
+
+func TestRunScrape_InterruptedCheckpointsPinnedVersions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	opts := scrapeOptions{ImportPaths: pinnedCobra, OutputDir: dir, TestMode: true, Progress: &onStored{fn: cancel}}
+
+	if err := runScrape(ctx, opts, memstore.New(), &bytes.Buffer{}); err != nil && !errors.Is(err, errInterrupted) {
+		t.Fatalf("Expected the interrupted batch to stop cleanly, got %v", err)
+	}
+	checkCheckpoint(t, dir, pinnedCobra)
+}
@@ -0,0 +1,123 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/moseye/docinator/internal/models"
+	mongostore "github.com/moseye/docinator/internal/storage/mongo"
+	"github.com/moseye/docinator/pkg/chunker"
+	"github.com/moseye/docinator/pkg/llm"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/spf13/cobra"
+)
+
+var chunkCmd = &cobra.Command{
+	Use:   "chunk [packages...]",
+	Short: "Split rendered docs into JSONL chunks for RAG ingestion",
+	Long: `Render each package to markdown and split it into overlapping chunks at
+heading boundaries. Every chunk is written to stdout as one JSON object per line
+with its source import path, anchor, heading path and approximate token count.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := chunker.DefaultOptions()
+		opts.MaxTokens, _ = cmd.Flags().GetInt("max-tokens")
+		opts.Overlap, _ = cmd.Flags().GetInt("overlap")
+		keepBadges, _ := cmd.Flags().GetBool("keep-badges")
+		patterns, err := badgePatterns(cmd)
+		if err != nil {
+			return err
+		}
+		render := markdown.Options{StripBadges: !keepBadges, BadgePatterns: patterns}
+
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		pkgs, errs := loader.loadAll(cmd.Context(), args)
+		for _, err := range errs {
+			log.Printf("Scraping error: %v", err)
+		}
+		if len(pkgs) == 0 {
+			return errors.New("all scraping attempts failed")
+		}
+
+		var embedder *llm.Client
+		if embed, _ := cmd.Flags().GetBool("embed"); embed {
+			if mongo, ok := loader.store.(*mongostore.Store); !ok || !mongo.Enabled() {
+				return withHint(errors.New("--embed stores vectors in MongoDB"), "set MONGODB_URI")
+			}
+			if embedder = llm.NewFromEnv(); embedder == nil {
+				return withHint(errors.New("--embed requires an embeddings endpoint"), "set LLM_BASE_URL or LLM_API_KEY")
+			}
+		}
+
+		ctx := cmd.Context()
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetEscapeHTML(false)
+		for _, pkg := range pkgs {
+			chunks := chunker.Split(pkg.ImportPath, markdown.PackageToMarkdownWithOptions(pkg, render), opts)
+			if embedder != nil {
+				if err := embedChunks(ctx, embedder, chunks); err != nil {
+					log.Printf("Embedding failed for %s: %v", pkg.ImportPath, err)
+				} else if err := storeChunks(ctx, loader.store.(*mongostore.Store), pkg.ImportPath, chunks); err != nil {
+					log.Printf("Storing chunks failed for %s: %v", pkg.ImportPath, err)
+				} else {
+					log.Printf("Stored %d embedded chunks for %s", len(chunks), pkg.ImportPath)
+				}
+			}
+			for _, chunk := range chunks {
+				if err := enc.Encode(chunk); err != nil {
+					return fmt.Errorf("failed to write chunk: %w", err)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// embedBatchSize bounds the number of texts sent per embeddings request.
+const embedBatchSize = 64
+
+// embedChunks fills in the Embedding field of every chunk.
+func embedChunks(ctx context.Context, client *llm.Client, chunks []models.Chunk) error {
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(chunks))
+		texts := make([]string, 0, end-start)
+		for _, c := range chunks[start:end] {
+			texts = append(texts, c.Text)
+		}
+		vectors, err := client.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i, v := range vectors {
+			chunks[start+i].Embedding = v
+		}
+	}
+	return nil
+}
+
+// storeChunks replaces the stored chunks of source and makes sure the vector index exists.
+func storeChunks(ctx context.Context, store *mongostore.Store, source string, chunks []models.Chunk) error {
+	if len(chunks) > 0 && len(chunks[0].Embedding) > 0 {
+		if err := store.EnsureVectorIndex(ctx, len(chunks[0].Embedding)); err != nil {
+			return fmt.Errorf("failed to ensure vector index: %w", err)
+		}
+	}
+	return store.ReplaceChunks(ctx, source, chunks)
+}
+
+func init() {
+	defaults := chunker.DefaultOptions()
+	chunkCmd.Flags().Int("max-tokens", defaults.MaxTokens, "approximate maximum tokens per chunk")
+	chunkCmd.Flags().Int("overlap", defaults.Overlap, "approximate tokens repeated between consecutive chunks of a split section")
+	chunkCmd.Flags().Bool("embed", false, "embed chunks and store them in MongoDB for vector search (requires MONGODB_URI and an LLM endpoint)")
+	chunkCmd.Flags().Bool("keep-badges", false, "keep CI, coverage and other badge images in READMEs")
+	chunkCmd.Flags().StringArray("badge-pattern", nil, "also treat images whose URL matches this regexp as badges (repeatable)")
+}
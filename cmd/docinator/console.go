@@ -0,0 +1,105 @@
+package docinator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ANSI escape sequences used by the console.
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiDim    = "\033[2m"
+)
+
+// console prints one concise status line per package instead of the full log, for interactive terminals.
+type console struct {
+	w     io.Writer
+	color bool
+
+	mu      sync.Mutex
+	started map[string]time.Time
+	cached  map[string]bool
+}
+
+// newConsole returns a console writing to w, coloring output when color is set.
+func newConsole(w io.Writer, color bool) *console {
+	return &console{w: w, color: color, started: make(map[string]time.Time), cached: make(map[string]bool)}
+}
+
+// isTerminal reports whether f is a character device, i.e. an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether console output should be colored, honoring --no-color, NO_COLOR and TERM=dumb.
+func useColor(noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+}
+
+func (c *console) paint(code, s string) string {
+	if !c.color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// event updates the console from a progress event, printing a line when a package finishes.
+func (c *console) event(e progressEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch e.Event {
+	case eventFetching:
+		c.started[e.ImportPath] = e.Time
+	case eventParsed:
+		c.cached[e.ImportPath] = e.Cached
+	case eventStored:
+		note := ""
+		if c.cached[e.ImportPath] {
+			note = " cached"
+		}
+		fmt.Fprintf(c.w, "%s %s %s\n", c.paint(ansiGreen, "✓"), e.ImportPath, c.paint(ansiDim, c.elapsed(e)+note))
+	case eventFailed:
+		fmt.Fprintf(c.w, "%s %s %s\n", c.paint(ansiRed, "✗"), e.ImportPath, c.paint(ansiRed, e.Error))
+	}
+}
+
+func (c *console) elapsed(e progressEvent) string {
+	start, ok := c.started[e.ImportPath]
+	if !ok {
+		return ""
+	}
+	return e.Time.Sub(start).Round(time.Millisecond).String()
+}
+
+// warningKeywords mark log lines worth showing in console mode.
+var warningKeywords = []string{"error", "fail", "warning", "interrupt", "cannot", "unable", "skipping"}
+
+// logFilter returns a writer for the standard logger that keeps only warnings and errors, highlighted.
+func (c *console) logFilter() io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		lower := strings.ToLower(string(p))
+		for _, k := range warningKeywords {
+			if strings.Contains(lower, k) {
+				c.mu.Lock()
+				fmt.Fprint(c.w, c.paint(ansiYellow, string(bytes.TrimRight(p, "\n")))+"\n")
+				c.mu.Unlock()
+				break
+			}
+		}
+		return len(p), nil
+	})
+}
+
+// writerFunc adapts a function to io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,168 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/coverage"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage [import paths or modules...]",
+	Short: "Measure how much of the cached packages' exported API is documented",
+	Long: `Report, per cached package, the share of exported constants, variables,
+functions, types and methods with a doc comment and the share of functions,
+types and methods with an example, followed by the totals for the corpus. Only
+the most recently scraped version of each package counts. Packages are listed
+worst documented first, so maintainers know where to start writing:
+
+  docinator coverage github.com/acme --missing
+
+Pass import paths or module paths to measure only those packages and the
+packages below them. --by-module adds a rollup per module, --missing lists the
+symbols lacking a doc comment or an example, and --json prints the whole report
+for dashboards.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		byModule, _ := cmd.Flags().GetBool("by-module")
+		missing, _ := cmd.Flags().GetBool("missing")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		tags := tagFilter(cmd)
+		ctx := cmd.Context()
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "coverage", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		reports, err := coverageReports(ctx, store, args, tags)
+		if err != nil {
+			return err
+		}
+		if len(reports) == 0 {
+			return withHint(errors.New("no cached packages to measure"), "scrape the packages first, or check the paths and --tag")
+		}
+		if asJSON {
+			return writeCoverageJSON(cmd.OutOrStdout(), reports)
+		}
+		printCoverage(cmd.OutOrStdout(), reports, byModule, missing)
+		return nil
+	},
+}
+
+func init() {
+	coverageCmd.Flags().Bool("by-module", false, "add the coverage rolled up per module")
+	coverageCmd.Flags().Bool("missing", false, "list the symbols without a doc comment or an example below each package")
+	coverageCmd.Flags().Bool("json", false, "print the report as JSON")
+	coverageCmd.Flags().StringSlice("tag", nil, "measure only packages carrying all of these tags (see docinator tag)")
+}
+
+// coverageReports measures the most recently scraped version of every cached package matching
+// filters and tags, worst documented first.
+func coverageReports(ctx context.Context, store storage.Store, filters, tags []string) ([]coverage.Report, error) {
+	latest := map[string]*models.Package{}
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		pkg := doc.Package
+		if pkg == nil || !selected(pkg, filters) || !hasTags(doc.Tags, tags) {
+			return nil
+		}
+		if cur := latest[pkg.ImportPath]; cur == nil || pkg.ScrapedAt.After(cur.ScrapedAt) {
+			latest[pkg.ImportPath] = pkg
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading packages failed: %w", err)
+	}
+	reports := make([]coverage.Report, 0, len(latest))
+	for _, pkg := range latest {
+		reports = append(reports, coverage.Package(pkg))
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		a, b := reports[i], reports[j]
+		if a.DocRatio() != b.DocRatio() {
+			return a.DocRatio() < b.DocRatio()
+		}
+		if a.ExampleRatio() != b.ExampleRatio() {
+			return a.ExampleRatio() < b.ExampleRatio()
+		}
+		return a.ImportPath < b.ImportPath
+	})
+	return reports, nil
+}
+
+// printCoverage writes reports as a table, optionally with the rollups per module and the
+// symbols lacking documentation, and the corpus totals.
+func printCoverage(w io.Writer, reports []coverage.Report, byModule, missing bool) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tVERSION\tPACKAGE DOC\tDOCUMENTED\tEXAMPLES")
+	for _, r := range reports {
+		packageDoc := "no"
+		if r.PackageDoc {
+			packageDoc = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.ImportPath, r.Version, packageDoc,
+			share(r.Documented, r.Symbols), share(r.WithExamples, r.Examplable))
+	}
+	tw.Flush()
+
+	if missing {
+		for _, r := range reports {
+			if len(r.Undocumented) == 0 && len(r.NoExamples) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "\n%s\n", r.ImportPath)
+			if len(r.Undocumented) > 0 {
+				fmt.Fprintf(w, "  no doc comment: %s\n", strings.Join(r.Undocumented, ", "))
+			}
+			if len(r.NoExamples) > 0 {
+				fmt.Fprintf(w, "  no example:     %s\n", strings.Join(r.NoExamples, ", "))
+			}
+		}
+	}
+
+	if byModule {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "MODULE\tPACKAGES\tDOCUMENTED\tEXAMPLES")
+		for _, m := range coverage.ByModule(reports) {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", m.Module, m.Packages, share(m.Documented, m.Symbols), share(m.WithExamples, m.Examplable))
+		}
+		tw.Flush()
+	}
+
+	total := coverage.Total(reports)
+	fmt.Fprintf(w, "\nTotal: %d packages, %s symbols documented, %s functions, types and methods with examples\n",
+		len(reports), share(total.Documented, total.Symbols), share(total.WithExamples, total.Examplable))
+}
+
+// share formats n of total with the percentage.
+func share(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%.0f%%)", n, total, 100*float64(n)/float64(total))
+}
+
+// writeCoverageJSON writes reports with the rollups per module and the corpus totals.
+func writeCoverageJSON(w io.Writer, reports []coverage.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Packages []coverage.Report `json:"packages"`
+		Modules  []coverage.Rollup `json:"modules"`
+		Total    coverage.Counts   `json:"total"`
+	}{reports, coverage.ByModule(reports), coverage.Total(reports)})
+}
@@ -0,0 +1,53 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestCoverage(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC) }
+	store := memstore.New()
+	for _, doc := range []*models.Document{
+		{ID: "example.com/gear@v1.0.0", Package: &models.Package{ImportPath: "example.com/gear", Module: "example.com/gear", Version: "v1.0.0", ScrapedAt: day(1),
+			Functions: []models.Function{{Name: "Turn"}}}},
+		{ID: "example.com/gear@v1.1.0", Package: &models.Package{ImportPath: "example.com/gear", Module: "example.com/gear", Version: "v1.1.0", ScrapedAt: day(2),
+			Functions: []models.Function{{Name: "Turn", Description: "Turn turns.", Examples: []models.Example{{Name: "Turn"}}}}}},
+		{ID: "example.com/gear/chain", Package: &models.Package{ImportPath: "example.com/gear/chain", Module: "example.com/gear", Version: "v1.1.0", ScrapedAt: day(2),
+			Synopsis: "Package chain links gears.",
+			Types:    []models.Type{{Name: "Chain", Description: "Chain links gears.", Methods: []models.Function{{Name: "Len"}}}}}},
+		{ID: "example.com/other", Package: &models.Package{ImportPath: "example.com/other", ScrapedAt: day(1)}},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports, err := coverageReports(ctx, store, []string{"example.com/gear"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0].ImportPath != "example.com/gear/chain" || reports[1].Version != "v1.1.0" {
+		t.Fatalf("Expected the latest gear packages, worst documented first, got %+v", reports)
+	}
+
+	var out bytes.Buffer
+	printCoverage(&out, reports, true, true)
+	for _, want := range []string{
+		"example.com/gear/chain  v1.1.0   yes          1/2 (50%)   0/2 (0%)",
+		"  no doc comment: Chain.Len\n",
+		"example.com/gear  2         2/3 (67%)   1/3 (33%)\n",
+		"Total: 2 packages, 2/3 (67%) symbols documented, 1/3 (33%) functions, types and methods with examples\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Error formats accepted by --error-format.
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+// hintError is an error with a remediation hint: what to check or change so the command
+// succeeds. reportError prints the hint below the error.
+type hintError struct {
+	err  error
+	hint string
+}
+
+func (e *hintError) Error() string { return e.err.Error() }
+
+func (e *hintError) Unwrap() error { return e.err }
+
+// withHint attaches hint to err; a nil err stays nil.
+func withHint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &hintError{err: err, hint: hint}
+}
+
+// errorHint returns the remediation hint for err: the one attached with withHint, or one derived
+// from the kind of failure, or "" when there is nothing more useful to say than the error itself.
+func errorHint(err error) string {
+	var h *hintError
+	if errors.As(err, &h) {
+		return h.hint
+	}
+	var netErr net.Error
+	switch msg := err.Error(); {
+	case errors.Is(err, context.Canceled):
+		return ""
+	case errors.Is(err, scraper.ErrLayoutChanged):
+		return "pkg.go.dev's markup changed: run docinator doctor, and override the broken selectors with --selectors (see docinator selectors)"
+	case errors.Is(err, scraper.ErrResponseTooLarge):
+		return "raise --max-response-size, or pass 0 to disable the limit"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "the request timed out: check the network, VPN or proxy, or retry later"
+	case errors.As(err, &netErr):
+		return "check the network, VPN or proxy settings"
+	case strings.Contains(msg, "Too Many Requests"):
+		return "pkg.go.dev is rate limiting requests: lower --rate-limit, or retry later"
+	case strings.Contains(msg, "Not Found"):
+		return "check the import path and version; pkg.go.dev has no page for it"
+	case errors.Is(err, os.ErrPermission):
+		return "check the permissions of the file or directory"
+	case errors.Is(err, os.ErrNotExist):
+		return "check that the file or directory exists"
+	}
+	return ""
+}
+
+// storeHint returns the remediation hint for err, the failure to open the backend store.
+func storeHint(backend string, err error) string {
+	switch {
+	case errors.Is(err, bolt.ErrTimeout):
+		return "another process holds the bbolt file at BOLT_PATH open: wait for it to finish, or point BOLT_PATH at another file"
+	case mongo.IsTimeout(err) || mongo.IsNetworkError(err):
+		return "MONGODB_URI is set but the server is unreachable: check the network or VPN, and that MongoDB is running"
+	case backend == "mongo" || (backend == "auto" && os.Getenv("MONGODB_URI") != ""):
+		return "check MONGODB_URI and the MONGODB_* settings; docinator doctor tests the connection"
+	case backend == "bolt" || (backend == "auto" && os.Getenv("BOLT_PATH") != ""):
+		return "check that BOLT_PATH names a writable file"
+	}
+	return ""
+}
+
+// needsCache returns the error of a command run without the cache it needs, store being the
+// disabled one it got. hint says how to configure one; when the configured store failed to open
+// instead, the error says why.
+func needsCache(store storage.Store, command, hint string) error {
+	if s, ok := store.(unopenedStore); ok {
+		return fmt.Errorf("%s needs the cache, which failed to open: %w", command, s.err)
+	}
+	return withHint(fmt.Errorf("%s needs the cache", command), hint)
+}
+
+// errorReport is an error as --error-format json prints it.
+type errorReport struct {
+	Command string `json:"command"`
+	Error   string `json:"error"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// reportError writes err, the error cmd failed with, and its hint to w in format.
+func reportError(w io.Writer, cmd *cobra.Command, err error, format string) {
+	hint := errorHint(err)
+	if format == errorFormatJSON {
+		json.NewEncoder(w).Encode(errorReport{Command: cmd.CommandPath(), Error: err.Error(), Hint: hint})
+		return
+	}
+	fmt.Fprintf(w, "Error: %v\n", err)
+	if hint != "" {
+		fmt.Fprintf(w, "Hint: %s\n", hint)
+	}
+}
@@ -0,0 +1,68 @@
+package docinator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestErrorHint(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{withHint(fmt.Errorf("render needs an output directory"), "pass --output"), "pass --output"},
+		{fmt.Errorf("wrapped: %w", withHint(errors.New("inner"), "the inner hint")), "the inner hint"},
+		{&scraper.PathError{ImportPath: "example.com/x", Err: scraper.ErrLayoutChanged}, "--selectors"},
+		{&net.DNSError{Err: "no such host", Name: "pkg.go.dev"}, "network, VPN or proxy"},
+		{&scraper.PathError{ImportPath: "example.com/x", Err: errors.New("Not Found")}, "check the import path"},
+		{errors.New("something else"), ""},
+	}
+	for _, tt := range tests {
+		if got := errorHint(tt.err); (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("errorHint(%v) = %q, want it to contain %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNeedsCache(t *testing.T) {
+	err := needsCache(storage.Disabled(), "xref", "set MONGODB_URI or BOLT_PATH")
+	if err.Error() != "xref needs the cache" || errorHint(err) != "set MONGODB_URI or BOLT_PATH" {
+		t.Errorf("Expected how to configure a store, got %v (hint %q)", err, errorHint(err))
+	}
+
+	store := unopenedStore{Store: storage.Disabled(), err: withHint(fmt.Errorf("store initialization failed: %w", bolt.ErrTimeout), storeHint("bolt", bolt.ErrTimeout))}
+	err = needsCache(store, "xref", "set MONGODB_URI or BOLT_PATH")
+	if !errors.Is(err, bolt.ErrTimeout) || !strings.Contains(errorHint(err), "another process holds the bbolt file") {
+		t.Errorf("Expected why the configured store failed, got %v (hint %q)", err, errorHint(err))
+	}
+}
+
+func TestReportError(t *testing.T) {
+	cmd := &cobra.Command{Use: "render"}
+	err := withHint(errors.New("render needs an output directory"), "pass --output")
+
+	var text strings.Builder
+	reportError(&text, cmd, err, errorFormatText)
+	if want := "Error: render needs an output directory\nHint: pass --output\n"; text.String() != want {
+		t.Errorf("Expected %q, got %q", want, text.String())
+	}
+
+	var out strings.Builder
+	reportError(&out, cmd, err, errorFormatJSON)
+	var report errorReport
+	if err := json.Unmarshal([]byte(out.String()), &report); err != nil {
+		t.Fatalf("Expected one JSON object, got %q: %v", out.String(), err)
+	}
+	if report != (errorReport{Command: "render", Error: "render needs an output directory", Hint: "pass --output"}) {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
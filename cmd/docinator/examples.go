@@ -0,0 +1,131 @@
+package docinator
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/examples"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+var examplesCmd = &cobra.Command{
+	Use:   "examples <package>",
+	Short: "Write a package's examples as runnable example test files",
+	Long: `Load (from cache) or scrape a package and write each of its examples to the
+output directory (default "examples") as example_<name>_test.go, a compilable
+example function in the external test package, with the expected output as its
+"// Output:" comment. Examples pkg.go.dev shows as a complete program keep its
+imports; for the others, imports of the package itself and of the standard
+library packages they use are inferred, and other packages are reported.
+
+A go.mod requiring the package's module at the scraped version is written
+unless the directory has one, so the examples run against your Go version:
+
+  docinator examples github.com/spf13/cobra@v1.8.0 -o cobra-examples
+  cd cobra-examples && go mod tidy && go test
+
+--list prints the examples instead: their name, the symbol they belong to, the
+example function they would be written as, their length in lines and whether
+they have expected output.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		if outputDir == "" {
+			outputDir = "examples"
+		}
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		pkg, _, err := loader.load(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if list {
+			return listExamples(cmd.OutOrStdout(), pkg)
+		}
+		if err := writeExamples(pkg, outputDir); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	examplesCmd.Flags().Bool("list", false, "list the examples instead of writing them")
+}
+
+// listExamples writes a table of the examples of pkg to w.
+func listExamples(w io.Writer, pkg *models.Package) error {
+	entries := examples.List(pkg)
+	if len(entries) == 0 {
+		return fmt.Errorf("%s has no examples", pkg.ImportPath)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSYMBOL\tFUNCTION\tLINES\tOUTPUT")
+	for _, e := range entries {
+		output := "no"
+		if e.HasOutput {
+			output = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", e.Name, e.Symbol, e.Func, e.Lines, output)
+	}
+	return tw.Flush()
+}
+
+// writeExamples writes the example test files of pkg, and a go.mod when outputDir has none.
+func writeExamples(pkg *models.Package, outputDir string) error {
+	files, errs := examples.TestFiles(pkg)
+	for _, err := range errs {
+		log.Printf("Skipped %v", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s has no examples that could be written", pkg.ImportPath)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output dir: %w", err)
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(outputDir, f.Name), f.Content, 0644); err != nil {
+			return err
+		}
+		if len(f.Unresolved) > 0 {
+			log.Printf("%s: add the imports of %s", f.Name, strings.Join(f.Unresolved, ", "))
+		}
+	}
+	log.Printf("Wrote %d example(s) of %s to %s", len(files), pkg.ImportPath, outputDir)
+
+	modPath := filepath.Join(outputDir, "go.mod")
+	if _, err := os.Stat(modPath); err == nil {
+		return nil
+	}
+	return os.WriteFile(modPath, []byte(examplesGoMod(pkg)), 0644)
+}
+
+// examplesGoMod returns a go.mod for the examples of pkg, requiring its module at the scraped
+// version when that is a release.
+func examplesGoMod(pkg *models.Package) string {
+	var b strings.Builder
+	b.WriteString("module examples\n")
+	if pkg.GoVersion != "" {
+		fmt.Fprintf(&b, "\ngo %s\n", pkg.GoVersion)
+	}
+	module := pkg.Module
+	if module == "" {
+		module = pkg.ImportPath
+	}
+	if semver.IsValid(pkg.Version) && strings.Contains(module, ".") {
+		fmt.Fprintf(&b, "\nrequire %s %s\n", module, pkg.Version)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package docinator
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/obsidian"
+	"github.com/moseye/docinator/pkg/parquet"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [packages...]",
+	Short: "Export cached packages to Parquet for analytics or to an Obsidian vault",
+	Long: `Write one row per exported constant, variable, function, type and method of
+every cached package and version to symbols.parquet in the output directory
+(default the current directory), with the columns package, version, kind,
+name, signature, deprecated and added_in. Query the file with DuckDB, Spark,
+pandas or any other Parquet reader:
+
+  docinator export -o /data/go
+  duckdb -c "SELECT kind, count(*) FROM '/data/go/symbols.parquet' GROUP BY kind"
+
+With --format obsidian the output directory is an Obsidian vault instead: a
+note per package (its most recently scraped version) at its import path, with
+frontmatter properties and tags, wikilinks to its symbols, to the other
+packages of its module and to symbols of other exported packages its
+declarations use. --index adds a note linking every package by module, and
+--canvas a canvas of all packages:
+
+  docinator export --format obsidian --index --canvas -o ~/vault/go
+
+Pass import paths or module paths to export only those packages and the
+packages below them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		if outputDir == "" {
+			outputDir = "."
+		}
+		format, _ := cmd.Flags().GetString("format")
+		tags := tagFilter(cmd)
+		ctx := cmd.Context()
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "export", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		switch format {
+		case "parquet":
+		case "obsidian":
+			index, _ := cmd.Flags().GetBool("index")
+			canvas, _ := cmd.Flags().GetBool("canvas")
+			var pkgs []*models.Package
+			err := store.ForEach(ctx, func(doc *models.Document) error {
+				if doc.Package != nil && selected(doc.Package, args) && hasTags(doc.Tags, tags) {
+					pkgs = append(pkgs, doc.Package)
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("loading packages failed: %w", err)
+			}
+			notes, err := obsidian.Build(outputDir, pkgs, obsidian.Options{Index: index, Canvas: canvas})
+			if err != nil {
+				return fmt.Errorf("export failed: %w", err)
+			}
+			log.Printf("Wrote %d notes to the vault in %s", notes, outputDir)
+			return nil
+		default:
+			return fmt.Errorf("unknown export format %q; use parquet or obsidian", format)
+		}
+
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		path := filepath.Join(outputDir, "symbols.parquet")
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		defer f.Close()
+
+		w := parquet.NewWriter(f)
+		var pkgs, symbols int
+		err = store.ForEach(ctx, func(doc *models.Document) error {
+			if doc.Package == nil || !selected(doc.Package, args) || !hasTags(doc.Tags, tags) {
+				return nil
+			}
+			records := parquet.Records(doc.Package)
+			pkgs++
+			symbols += len(records)
+			return w.Write(records...)
+		})
+		if err == nil {
+			err = w.Close()
+		}
+		if err == nil {
+			err = f.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		log.Printf("Exported %d symbols of %d documents to %s", symbols, pkgs, path)
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("format", "parquet", "export format: parquet or obsidian")
+	exportCmd.Flags().StringSlice("tag", nil, "export only packages carrying all of these tags (see docinator tag)")
+	exportCmd.Flags().Bool("index", false, "with --format obsidian, also write an index note of all packages")
+	exportCmd.Flags().Bool("canvas", false, "with --format obsidian, also write a canvas of all packages and their references")
+}
@@ -0,0 +1,320 @@
+package docinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/storage/retention"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Reclaim cache space from orphaned snapshots, stale raw HTML and unused chunks",
+	Long: `Remove what the cache keeps but no longer needs, and report the space
+reclaimed:
+
+  - history snapshots (path@version scrapes) of packages whose latest scrape
+    was deleted, once they have not been requested for --days
+  - the raw HTML of packages not requested for --days; the structured package,
+    and so its markdown, is kept, so only re-parsing with new selectors needs
+    a fresh scrape
+  - with MongoDB, stored chunks and embeddings of packages no longer cached
+  - what the retention policy does not keep: snapshots of a package beyond
+    the --keep-snapshots most recently scraped, adoption points beyond as many
+    per package, and the raw HTML of snapshots scraped more than
+    --raw-html-days ago. The latest scrape of a package keeps its page, and
+    tagged snapshots are kept and not counted
+
+The policy defaults to DOCINATOR_KEEP_SNAPSHOTS and DOCINATOR_RAW_HTML_DAYS,
+which the stores also enforce on every write; 0 keeps everything.
+
+A document counts as requested when a command loads it, which is recorded once
+a day. Documents written before that was recorded count from their scrape time.
+--dry-run reports what would be removed without removing it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if days < 1 {
+			return errors.New("--days must be at least 1")
+		}
+		policy, err := retentionPolicy(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "gc", "set MONGODB_URI or BOLT_PATH")
+		}
+		now := time.Now()
+		opts := gcOptions{Cutoff: now.AddDate(0, 0, -days), DryRun: dryRun, Retention: policy, Now: now}
+		report, err := runGC(ctx, store, opts)
+		if err != nil {
+			return fmt.Errorf("gc failed: %w", err)
+		}
+		report.print(cmd.OutOrStdout(), dryRun)
+		return nil
+	},
+}
+
+func init() {
+	gcCmd.Flags().Int("days", 30, "age in days since a package was last requested before its raw HTML and orphaned snapshots are removed")
+	gcCmd.Flags().Bool("dry-run", false, "report what would be removed without removing anything")
+	gcCmd.Flags().Int("keep-snapshots", 0, "snapshots and adoption points kept per package (default DOCINATOR_KEEP_SNAPSHOTS, else all)")
+	gcCmd.Flags().Int("raw-html-days", 0, "age in days after which snapshots lose their raw HTML (default DOCINATOR_RAW_HTML_DAYS, else never)")
+}
+
+// retentionPolicy returns the retention policy from the environment, overridden by the
+// --keep-snapshots and --raw-html-days flags of cmd when set.
+func retentionPolicy(cmd *cobra.Command) (retention.Policy, error) {
+	policy, err := retention.FromEnv()
+	if err != nil {
+		return policy, err
+	}
+	if cmd.Flags().Changed("keep-snapshots") {
+		n, _ := cmd.Flags().GetInt("keep-snapshots")
+		if n < 0 {
+			return policy, fmt.Errorf("--keep-snapshots must be at least 0")
+		}
+		policy.Snapshots = n
+	}
+	if cmd.Flags().Changed("raw-html-days") {
+		n, _ := cmd.Flags().GetInt("raw-html-days")
+		if n < 0 {
+			return policy, fmt.Errorf("--raw-html-days must be at least 0")
+		}
+		policy.RawHTML = time.Duration(n) * 24 * time.Hour
+	}
+	return policy, nil
+}
+
+// gcOptions controls runGC.
+type gcOptions struct {
+	Cutoff    time.Time // documents last requested before it are stale
+	DryRun    bool
+	Retention retention.Policy
+	Now       time.Time // the time Retention is applied at
+}
+
+// chunkCollector is a store that keeps chunks and embeddings apart from its documents.
+type chunkCollector interface {
+	ChunkSources(ctx context.Context) ([]models.DocumentSize, error)
+	DeleteChunks(ctx context.Context, source string) error
+}
+
+// gcReport counts what a collection removed, and the bytes it took up.
+type gcReport struct {
+	Snapshots     int
+	SnapshotBytes int64
+	RawHTML       int
+	RawHTMLBytes  int64
+	ChunkSources  int
+	ChunkBytes    int64
+	Chunked       bool // the store keeps chunks, so they were checked
+	Pruned        int  // snapshots beyond the retention policy
+	PrunedBytes   int64
+	Trimmed       int // documents whose adoption history was trimmed
+}
+
+// Bytes returns the total space reclaimed.
+func (r gcReport) Bytes() int64 {
+	return r.SnapshotBytes + r.PrunedBytes + r.RawHTMLBytes + r.ChunkBytes
+}
+
+// print writes the report; dryRun words it as what would be removed.
+func (r gcReport) print(out io.Writer, dryRun bool) {
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Fprintf(out, "%s %d orphaned snapshot(s): %.1f KiB\n", verb, r.Snapshots, float64(r.SnapshotBytes)/1024)
+	if r.Pruned > 0 || r.Trimmed > 0 {
+		fmt.Fprintf(out, "%s %d snapshot(s) beyond the retention policy: %.1f KiB\n", verb, r.Pruned, float64(r.PrunedBytes)/1024)
+		fmt.Fprintf(out, "%s old adoption points of %d package(s)\n", verb, r.Trimmed)
+	}
+	fmt.Fprintf(out, "%s the raw HTML of %d package(s): %.1f KiB\n", verb, r.RawHTML, float64(r.RawHTMLBytes)/1024)
+	if r.Chunked {
+		fmt.Fprintf(out, "%s the chunks of %d uncached package(s): %.1f KiB\n", verb, r.ChunkSources, float64(r.ChunkBytes)/1024)
+	}
+	fmt.Fprintf(out, "Reclaimed: %.1f KiB\n", float64(r.Bytes())/1024)
+}
+
+// lastRequested returns when doc was last requested, or scraped when that is later or the
+// request time was never recorded.
+func lastRequested(doc *models.Document) time.Time {
+	t := doc.RequestedAt
+	if doc.Package != nil && doc.Package.ScrapedAt.After(t) {
+		t = doc.Package.ScrapedAt
+	}
+	return t
+}
+
+// runGC removes snapshots the retention policy does not keep, orphaned snapshots, stale raw HTML
+// and, when the store keeps them, chunks of packages no longer cached, then applies the rest of
+// the retention policy.
+func runGC(ctx context.Context, store storage.Store, opts gcOptions) (gcReport, error) {
+	var report gcReport
+	ids := map[string]bool{}
+	sources := map[string]bool{}
+	snapshots := map[string][]*models.Document{}
+	var stale, retained []*models.Document
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		ids[doc.ID] = true
+		if doc.Package != nil && doc.Package.ImportPath != "" {
+			sources[doc.Package.ImportPath] = true
+		}
+		if path, ok := retention.Snapshot(doc.ID); ok {
+			snapshots[path] = append(snapshots[path], doc)
+		}
+		if lastRequested(doc).Before(opts.Cutoff) {
+			stale = append(stale, doc)
+		}
+		if opts.Retention.Affects(doc, opts.Now) {
+			retained = append(retained, doc)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// removed holds the documents deleted, and dropped those that lost their raw HTML, so neither
+	// is counted twice.
+	removed, dropped := map[string]bool{}, map[string]bool{}
+	var pruned []string
+	for _, group := range snapshots {
+		pruned = append(pruned, opts.Retention.Prune(group)...)
+	}
+	slices.Sort(pruned)
+	for _, id := range pruned {
+		doc, err := store.GetByID(ctx, id)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", id, err)
+		}
+		if doc == nil {
+			continue
+		}
+		removed[id] = true
+		report.Pruned++
+		report.PrunedBytes += documentBytes(doc)
+		if !opts.DryRun {
+			if err := store.Delete(ctx, id); err != nil {
+				return report, fmt.Errorf("deleting %s: %w", id, err)
+			}
+		}
+	}
+
+	for _, meta := range stale {
+		if removed[meta.ID] {
+			continue
+		}
+		path, _, pinned := strings.Cut(meta.ID, "@")
+		orphaned := pinned && !ids[path] && len(meta.Tags) == 0
+		doc, err := store.GetByID(ctx, meta.ID)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", meta.ID, err)
+		}
+		if doc == nil {
+			continue
+		}
+		switch {
+		case orphaned:
+			removed[doc.ID] = true
+			report.Snapshots++
+			report.SnapshotBytes += documentBytes(doc)
+			if !opts.DryRun {
+				if err := store.Delete(ctx, doc.ID); err != nil {
+					return report, fmt.Errorf("deleting %s: %w", doc.ID, err)
+				}
+			}
+		case doc.RawHTML != "":
+			dropped[doc.ID] = true
+			report.RawHTML++
+			report.RawHTMLBytes += int64(len(doc.RawHTML))
+			if !opts.DryRun {
+				doc.RawHTML = ""
+				if err := store.Upsert(ctx, doc); err != nil {
+					return report, fmt.Errorf("dropping the raw HTML of %s: %w", doc.ID, err)
+				}
+			}
+		}
+	}
+
+	for _, meta := range retained {
+		if removed[meta.ID] {
+			continue
+		}
+		doc, err := store.GetByID(ctx, meta.ID)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", meta.ID, err)
+		}
+		if doc == nil {
+			continue
+		}
+		if dropped[doc.ID] {
+			doc.RawHTML = "" // as the pass above left it, also on a dry run
+		}
+		kept := opts.Retention.Apply(doc, opts.Now)
+		if kept == doc {
+			continue
+		}
+		if kept.RawHTML != doc.RawHTML {
+			report.RawHTML++
+			report.RawHTMLBytes += int64(len(doc.RawHTML))
+		}
+		if len(kept.Adoption) != len(doc.Adoption) {
+			report.Trimmed++
+		}
+		if !opts.DryRun {
+			if err := store.Upsert(ctx, kept); err != nil {
+				return report, fmt.Errorf("applying the retention policy to %s: %w", doc.ID, err)
+			}
+		}
+	}
+
+	chunks, ok := store.(chunkCollector)
+	if !ok {
+		return report, nil
+	}
+	report.Chunked = true
+	sizes, err := chunks.ChunkSources(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, s := range sizes {
+		if sources[s.ID] {
+			continue
+		}
+		report.ChunkSources++
+		report.ChunkBytes += s.Bytes
+		if !opts.DryRun {
+			if err := chunks.DeleteChunks(ctx, s.ID); err != nil {
+				return report, fmt.Errorf("deleting the chunks of %s: %w", s.ID, err)
+			}
+		}
+	}
+	return report, nil
+}
+
+// documentBytes returns the encoded size of doc, as the stores keep it.
+func documentBytes(doc *models.Document) int64 {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return int64(len(doc.RawHTML))
+	}
+	return int64(len(data))
+}
@@ -0,0 +1,128 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/internal/storage/retention"
+)
+
+func TestRunGC(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, -3, 0)
+	for _, doc := range []*models.Document{
+		// snapshot of a package whose latest scrape is gone
+		{ID: "example.com/gone@v1.0.0", Package: &models.Package{ImportPath: "example.com/gone", ScrapedAt: old}, RawHTML: "<html>gone</html>"},
+		// snapshot of a cached package: kept, but its stale raw HTML is dropped
+		{ID: "example.com/m@v1.0.0", Package: &models.Package{ImportPath: "example.com/m", ScrapedAt: old}, RawHTML: "<html>v1</html>"},
+		// scraped long ago but requested recently: kept whole
+		{ID: "example.com/m", Package: &models.Package{ImportPath: "example.com/m", ScrapedAt: old}, RawHTML: "<html>m</html>", RequestedAt: now.AddDate(0, 0, -1)},
+		// a pinned-only package requested recently is not orphaned
+		{ID: "example.com/pinned@v2.0.0", Package: &models.Package{ImportPath: "example.com/pinned", ScrapedAt: now}, RawHTML: "<html>p</html>"},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := gcOptions{Cutoff: now.AddDate(0, 0, -30)}
+
+	opts.DryRun = true
+	report, err := runGC(ctx, store, opts)
+	if err != nil {
+		t.Fatalf("runGC failed: %v", err)
+	}
+	if report.Snapshots != 1 || report.RawHTML != 1 || report.RawHTMLBytes != int64(len("<html>v1</html>")) || report.SnapshotBytes == 0 {
+		t.Errorf("Unexpected dry-run report: %+v", report)
+	}
+	if store.Len() != 4 {
+		t.Errorf("Expected a dry run to remove nothing, %d documents left", store.Len())
+	}
+
+	opts.DryRun = false
+	if _, err := runGC(ctx, store, opts); err != nil {
+		t.Fatalf("runGC failed: %v", err)
+	}
+	if doc, _ := store.GetByID(ctx, "example.com/gone@v1.0.0"); doc != nil {
+		t.Error("Expected the orphaned snapshot to be deleted")
+	}
+	if doc, _ := store.GetByID(ctx, "example.com/m@v1.0.0"); doc == nil || doc.RawHTML != "" || doc.Package == nil {
+		t.Errorf("Expected the snapshot kept without raw HTML, got %+v", doc)
+	}
+	for _, id := range []string{"example.com/m", "example.com/pinned@v2.0.0"} {
+		if doc, _ := store.GetByID(ctx, id); doc == nil || doc.RawHTML == "" {
+			t.Errorf("Expected %s kept whole, got %+v", id, doc)
+		}
+	}
+
+	var out bytes.Buffer
+	report.print(&out, true)
+	if !strings.Contains(out.String(), "Would remove 1 orphaned snapshot(s)") || strings.Contains(out.String(), "chunks") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
+
+func TestRunGC_Retention(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, doc := range []*models.Document{
+		{ID: "example.com/m", RawHTML: "<html>m</html>", Adoption: []models.AdoptionPoint{{ImportedBy: 1}, {ImportedBy: 2}, {ImportedBy: 3}}},
+		{ID: "example.com/m@v1.0.0", RawHTML: "<html>v1.0</html>", Tags: []string{"lts"}},
+		{ID: "example.com/m@v1.1.0", RawHTML: "<html>v1.1</html>"},
+		{ID: "example.com/m@v1.2.0", RawHTML: "<html>v1.2</html>"},
+		{ID: "example.com/m@v1.3.0", RawHTML: "<html>v1.3</html>"},
+	} {
+		// Scraped a day apart, the latest last, and all requested recently.
+		doc.Package = &models.Package{ImportPath: "example.com/m", ScrapedAt: now.AddDate(0, 0, i-10)}
+		doc.RequestedAt = now
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := gcOptions{Cutoff: now.AddDate(0, 0, -30), Now: now, Retention: retention.Policy{Snapshots: 2, RawHTML: 6 * 24 * time.Hour}}
+
+	opts.DryRun = true
+	report, err := runGC(ctx, store, opts)
+	if err != nil {
+		t.Fatalf("runGC failed: %v", err)
+	}
+	// v1.1.0 is pruned; the tagged v1.0.0 and v1.2.0 keep their documents but, scraped 9 and 7
+	// days ago, not their pages, which the policy keeps for 6.
+	if report.Pruned != 1 || report.PrunedBytes == 0 || report.RawHTML != 2 || report.Trimmed != 1 || store.Len() != 5 {
+		t.Errorf("Unexpected dry-run report: %+v", report)
+	}
+
+	opts.DryRun = false
+	if _, err := runGC(ctx, store, opts); err != nil {
+		t.Fatalf("runGC failed: %v", err)
+	}
+	if doc, _ := store.GetByID(ctx, "example.com/m@v1.1.0"); doc != nil {
+		t.Error("Expected the oldest untagged snapshot pruned")
+	}
+	for id, page := range map[string]bool{"example.com/m@v1.0.0": false, "example.com/m@v1.2.0": false, "example.com/m@v1.3.0": true, "example.com/m": true} {
+		if doc, _ := store.GetByID(ctx, id); doc == nil || (doc.RawHTML != "") != page {
+			t.Errorf("Expected %s kept with its page %v, got %+v", id, page, doc)
+		}
+	}
+	if doc, _ := store.GetByID(ctx, "example.com/m"); doc == nil || len(doc.Adoption) != 2 {
+		t.Errorf("Expected the adoption history trimmed to 2 points, got %+v", doc)
+	}
+}
+
+func TestTouchRequested(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	doc := &models.Document{RequestedAt: now.Add(-time.Hour)}
+	if touchRequested(doc, now) || !doc.RequestedAt.Equal(now.Add(-time.Hour)) {
+		t.Error("Expected a request within the day not to be recorded again")
+	}
+	if !touchRequested(doc, now.AddDate(0, 0, 2)) || !doc.RequestedAt.Equal(now.AddDate(0, 0, 2)) {
+		t.Error("Expected a request a day later to be recorded")
+	}
+}
@@ -0,0 +1,110 @@
+package docinator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ghaEscaper encodes annotation messages as GitHub Actions workflow commands require.
+var ghaEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// ghaPropertyEscaper additionally encodes the separators of annotation properties.
+var ghaPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// ghaAnnotate writes one ::error or ::warning workflow command.
+func ghaAnnotate(w io.Writer, level, title, message string) {
+	fmt.Fprintf(w, "::%s title=%s::%s\n", level, ghaPropertyEscaper.Replace(title), ghaEscaper.Replace(message))
+}
+
+// writeGHAAnnotations reports failures and license violations as errors, and deprecated modules,
+// retracted versions and deprecated symbols as warnings, so they show on the workflow run.
+func writeGHAAnnotations(w io.Writer, s *runSummary) {
+	for _, f := range s.Failed {
+		ghaAnnotate(w, "error", "Scrape failed", f.ImportPath+": "+f.Error)
+	}
+	for _, v := range s.LicenseViolations {
+		ghaAnnotate(w, "error", "License policy", violationMessage(v))
+	}
+	for _, p := range s.Deprecated {
+		ghaAnnotate(w, "warning", "Deprecated module", p+" belongs to a deprecated module")
+	}
+	for _, p := range s.Retracted {
+		ghaAnnotate(w, "warning", "Retracted version", p+" is at a retracted version")
+	}
+	for _, p := range sortedKeys(s.DeprecatedSymbols) {
+		names := s.DeprecatedSymbols[p]
+		ghaAnnotate(w, "warning", "Deprecated symbols", fmt.Sprintf("%s has %d deprecated symbol(s): %s", p, len(names), strings.Join(names, ", ")))
+	}
+}
+
+// appendStepSummary appends the markdown job summary of a batch to the GITHUB_STEP_SUMMARY file.
+func appendStepSummary(path string, s *runSummary) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(f, stepSummary(s))
+	return f.Close()
+}
+
+// stepSummary renders the job summary: the batch counts, then one section per kind of finding.
+func stepSummary(s *runSummary) string {
+	var b strings.Builder
+	b.WriteString("## docinator scrape\n\n")
+	b.WriteString("| Attempted | Succeeded | Failed | Duration |\n|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %.1fs |\n", s.Attempted, s.Succeeded, len(s.Failed), s.DurationSeconds)
+	section := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for _, item := range items {
+			b.WriteString("- " + item + "\n")
+		}
+	}
+	var items []string
+	for _, f := range s.Failed {
+		items = append(items, fmt.Sprintf("`%s`: %s", f.ImportPath, f.Error))
+	}
+	section("Failures", items)
+	items = nil
+	for _, v := range s.LicenseViolations {
+		item := fmt.Sprintf("`%s`: %s", v.ImportPath, licenseName(v.License))
+		if v.Rule != "" {
+			item += " (denied by " + v.Rule + ")"
+		}
+		items = append(items, item)
+	}
+	section("License policy violations", items)
+	items = nil
+	for _, p := range s.Deprecated {
+		items = append(items, fmt.Sprintf("`%s`: deprecated module", p))
+	}
+	for _, p := range s.Retracted {
+		items = append(items, fmt.Sprintf("`%s`: retracted version", p))
+	}
+	for _, p := range sortedKeys(s.DeprecatedSymbols) {
+		items = append(items, fmt.Sprintf("`%s`: %s", p, strings.Join(s.DeprecatedSymbols[p], ", ")))
+	}
+	section("Deprecations", items)
+	return b.String()
+}
+
+func licenseName(license string) string {
+	if license == "" {
+		return "(none detected)"
+	}
+	return license
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+package docinator
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/graph"
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [packages...]",
+	Short: "Print the import graph of the cached corpus as Graphviz DOT or Mermaid",
+	Long: `Draw which cached packages import which, from the imports tab recorded by
+scrape --imports, for architecture reviews:
+
+  docinator scrape --imports $(cat deps.txt)
+  docinator graph --format mermaid > imports.mmd    # embed in markdown
+  docinator graph | dot -Tsvg > imports.svg         # render with Graphviz
+
+Only the most recently scraped version of each package is drawn. Imports of the
+standard library are left out unless --std is given, and --corpus-only drops
+imports that are not cached themselves; the others are drawn dashed. Pass
+import paths or module paths to draw only those packages and the packages
+below them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		var opts graph.Options
+		opts.Std, _ = cmd.Flags().GetBool("std")
+		opts.Corpus, _ = cmd.Flags().GetBool("corpus-only")
+		tags := tagFilter(cmd)
+		ctx := cmd.Context()
+
+		write := (*graph.Graph).WriteDOT
+		switch format {
+		case "dot":
+		case "mermaid":
+			write = (*graph.Graph).WriteMermaid
+		default:
+			return fmt.Errorf("unknown graph format %q; use dot or mermaid", format)
+		}
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "graph", "set MONGODB_URI or BOLT_PATH")
+		}
+		var pkgs []*models.Package
+		err = store.ForEach(ctx, func(doc *models.Document) error {
+			if doc.Package != nil && selected(doc.Package, args) && hasTags(doc.Tags, tags) {
+				pkgs = append(pkgs, doc.Package)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("loading packages failed: %w", err)
+		}
+		g := graph.Build(pkgs, opts)
+		if len(g.Edges) == 0 {
+			log.Printf("No imports recorded for the selected packages; scrape them with --imports")
+		}
+		if err := write(g, cmd.OutOrStdout()); err != nil {
+			return fmt.Errorf("writing the graph failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	graphCmd.Flags().String("format", "dot", "output format: dot (Graphviz) or mermaid")
+	graphCmd.Flags().Bool("std", false, "include imports of the standard library")
+	graphCmd.Flags().Bool("corpus-only", false, "draw only imports that are themselves cached")
+	graphCmd.Flags().StringSlice("tag", nil, "draw only packages carrying all of these tags (see docinator tag)")
+}
@@ -0,0 +1,68 @@
+package docinator
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/siteprofile"
+)
+
+// readTheDocsURL matches links to ReadTheDocs-hosted documentation.
+var readTheDocsURL = regexp.MustCompile(`https?://[A-Za-z0-9.-]+\.(?:readthedocs\.io|readthedocs-hosted\.com)(?:/[^\s"'<>()\[\]]*)?`)
+
+// readTheDocsLinks returns up to limit distinct ReadTheDocs pages linked from the package's README
+// or Links panel, without queries and fragments so badge links lead to the docs they advertise.
+func readTheDocsLinks(pkg *models.Package, limit int) []string {
+	texts := []string{pkg.Readme, pkg.ProcessedReadme}
+	for _, l := range pkg.Links {
+		texts = append(texts, l.URL)
+	}
+	var links []string
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, match := range readTheDocsURL.FindAllString(text, -1) {
+			u, err := url.Parse(strings.TrimRight(match, ".,;:"))
+			if err != nil {
+				continue
+			}
+			u.RawQuery, u.Fragment = "", ""
+			if u.Path == "" {
+				u.Path = "/"
+			}
+			link := u.String()
+			if seen[link] {
+				continue
+			}
+			seen[link] = true
+			if links = append(links, link); len(links) == limit {
+				return links
+			}
+		}
+	}
+	return links
+}
+
+// guidesEnricher scrapes up to limit ReadTheDocs pages linked from the README with the built-in
+// readthedocs site profile and adds them as guides.
+func guidesEnricher(s *scraper.Scraper, limit int) enricher {
+	site := siteprofile.Builtin("readthedocs")
+	return func(ctx context.Context, pkg *models.Package) bool {
+		if len(pkg.Guides) > 0 {
+			return false
+		}
+		for _, link := range readTheDocsLinks(pkg, limit) {
+			page, err := s.ScrapePage(ctx, site, link)
+			if err != nil {
+				log.Printf("Guide %s of %s failed: %v", link, pkg.ImportPath, err)
+				continue
+			}
+			pkg.Guides = append(pkg.Guides, models.Guide{Title: page.Name, URL: link, Content: page.ProcessedReadme})
+		}
+		return len(pkg.Guides) > 0
+	}
+}
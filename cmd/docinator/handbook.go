@@ -0,0 +1,114 @@
+package docinator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/handbook"
+	"github.com/moseye/docinator/pkg/site"
+	"github.com/spf13/cobra"
+)
+
+var handbookCmd = &cobra.Command{
+	Use:   "handbook",
+	Short: "Assemble a curated document of several packages from a YAML spec",
+	Long: `Load (from cache) or scrape the packages listed in a spec and assemble them,
+in the spec's order, into one document with a table of contents, custom
+introductory sections and a chapter per package:
+
+  title: Platform Handbook
+  intro:
+    - title: Welcome
+      content: These are the libraries every service uses.
+    - title: Conventions
+      file: conventions.md      # markdown, relative to the spec
+  chapters:
+    - package: github.com/spf13/cobra
+      title: Command-line interfaces
+      intro: Every CLI is built with cobra.
+      no_readme: true
+      kinds: [func, type]
+      exclude: ^Deprecated
+    - package: golang.org/x/sync/errgroup@v0.7.0
+
+Chapters take the options of the markdown output: no_metadata, no_readme,
+no_index, no_examples, skip_deprecated, kinds, include and exclude.
+
+--format html writes a standalone page that starts every chapter on a new page
+when printed; print it to PDF from a browser for a PDF handbook. The handbook
+goes to stdout, or to handbook.md or handbook.html in --output.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		specPath, _ := cmd.Flags().GetString("spec")
+		format, _ := cmd.Flags().GetString("format")
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		if specPath == "" {
+			return errors.New("handbook needs --spec")
+		}
+		switch format {
+		case "md", "html":
+		case "pdf":
+			return withHint(errors.New("--format pdf is not supported"), "write --format html and print it to PDF")
+		default:
+			return errors.New("--format must be md or html")
+		}
+		spec, err := handbook.LoadSpec(specPath)
+		if err != nil {
+			return err
+		}
+
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		pkgs := map[string]*models.Package{}
+		failed := 0
+		for _, path := range spec.Packages() {
+			pkg, _, err := loader.load(cmd.Context(), path)
+			if err != nil {
+				log.Printf("Scraping error: %v", err)
+				failed++
+				continue
+			}
+			pkgs[path] = pkg
+		}
+
+		data := []byte(handbook.Build(spec, pkgs))
+		if format == "html" {
+			title := spec.Title
+			if title == "" {
+				title = "Handbook"
+			}
+			if data, err = site.MarkdownHTML(title, string(data)); err != nil {
+				return fmt.Errorf("render the handbook as HTML: %w", err)
+			}
+		}
+		if outputDir == "" {
+			cmd.OutOrStdout().Write(data)
+		} else {
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+			file := filepath.Join(outputDir, "handbook."+format)
+			if err := os.WriteFile(file, data, 0644); err != nil {
+				return err
+			}
+			log.Printf("Wrote %s with %d chapter(s)", file, len(spec.Chapters))
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d package(s) could not be loaded", failed, len(spec.Chapters))
+		}
+		return nil
+	},
+}
+
+func init() {
+	handbookCmd.Flags().String("spec", "", "YAML spec listing the handbook's packages, sections and chapter options")
+	handbookCmd.Flags().String("format", "md", "output format: md or html")
+}
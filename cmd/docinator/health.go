@@ -0,0 +1,113 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moseye/docinator/pkg/health"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// healthEndpoint is requested to check that pkg.go.dev is reachable and not rate limiting us.
+const healthEndpoint = "https://pkg.go.dev/"
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Check pkg.go.dev, the store and disk space",
+	Long: `Check that pkg.go.dev is reachable (with its latency and whether it is rate
+limiting us), that the store selected by --store can be opened, and how much
+disk space is left at the output path.
+
+The exit status is 0 when every check passed or only warned and 1 when any
+check failed, so the command can back a Kubernetes exec probe. serve-static
+serves the same disk check at /healthz for HTTP probes.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		minFree, _ := cmd.Flags().GetInt("min-free-mb")
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		backend, _ := rootCmd.PersistentFlags().GetString("store")
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+
+		checks := health.CheckEndpoint(ctx, &http.Client{Timeout: timeout}, "pkg.go.dev", healthEndpoint)
+		checks = append(checks, checkStore(ctx, backend))
+		checks = append(checks, health.CheckDisk(diskPath(outputDir), uint64(minFree)<<20))
+		report := health.NewReport(checks...)
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			enc.Encode(report)
+		} else {
+			printHealth(cmd.OutOrStdout(), report)
+		}
+		if !report.Healthy() {
+			stopProfiling()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	healthCmd.Flags().Bool("json", false, "print the report as JSON")
+	healthCmd.Flags().Duration("timeout", 10*time.Second, "time allowed for all checks")
+	healthCmd.Flags().Int("min-free-mb", 100, "warn when less disk space than this is free at the output path")
+}
+
+// checkStore opens the store backend and reports whether it is reachable. A disabled store is
+// healthy: caching is optional.
+func checkStore(ctx context.Context, backend string) health.Check {
+	c := health.Check{Name: "store", Status: health.StatusOK}
+	start := time.Now()
+	store, err := storage.Open(ctx, backend)
+	c.LatencyMs = float64(time.Since(start)) / float64(time.Millisecond)
+	switch {
+	case err != nil:
+		c.Status, c.Message = health.StatusFail, fmt.Sprintf("%s: %v", backend, err)
+	case !store.Enabled():
+		c.Message = "disabled; packages are scraped on every run"
+	default:
+		c.Message = backend + " connected"
+		store.Close(ctx)
+	}
+	return c
+}
+
+// diskPath returns the directory whose filesystem output is written to: outputDir or its nearest
+// existing parent, or the working directory when output goes to stdout.
+func diskPath(outputDir string) string {
+	if outputDir == "" {
+		return "."
+	}
+	for dir := outputDir; ; {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+func printHealth(w io.Writer, r *health.Report) {
+	for _, c := range r.Checks {
+		latency := ""
+		if c.LatencyMs > 0 {
+			latency = fmt.Sprintf(" (%.0fms)", c.LatencyMs)
+		}
+		fmt.Fprintf(w, "%-4s  %-10s %s%s\n", c.Status, c.Name, c.Message, latency)
+	}
+	fmt.Fprintf(w, "\nOverall: %s\n", r.Status)
+}
@@ -0,0 +1,185 @@
+package docinator
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/apidiff"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/moseye/docinator/pkg/textdiff"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <import path> [snapshot [snapshot]]",
+	Short: "List, print or diff the cached scrapes of a package",
+	Long: `List the scrapes of a package kept in the cache, newest first: one per version
+scraped with a pinned version (path@version) plus the latest unpinned scrape.
+Each line shows the version, when it was scraped, a hash of its markdown (equal
+hashes mean equal documentation) and how complete it is: the share of
+functions, types and methods that carry a doc comment.
+
+Name a snapshot by its number in the list or by its version to print its
+markdown, or name two to diff them, older first:
+
+  docinator history github.com/spf13/cobra
+  docinator history github.com/spf13/cobra v1.7.0
+  docinator history github.com/spf13/cobra v1.7.0 v1.8.0
+
+With --changelog, two snapshots are compared symbol by symbol instead, as a
+markdown upgrade changelog of the constants, variables, functions, types and
+methods added, removed, renamed or changed. A removed and an added symbol with
+the same signature or doc comment apart from the name are listed as a rename.`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, closeStore, err := openStore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "history", "set MONGODB_URI or BOLT_PATH")
+		}
+		changelog, _ := cmd.Flags().GetBool("changelog")
+		if changelog && len(args) != 3 {
+			return withHint(errors.New("--changelog compares two snapshots"), "name the older and the newer one, e.g. v1.7.0 v1.8.0")
+		}
+		return runHistory(cmd.Context(), store, args[0], args[1:], changelog, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	historyCmd.Flags().Bool("changelog", false, "compare two snapshots symbol by symbol, reporting renames, instead of diffing their markdown")
+}
+
+// snapshot is a cached scrape of a package.
+type snapshot struct {
+	ID       string
+	Package  *models.Package
+	Markdown string
+}
+
+// runHistory lists the snapshots of importPath in store, or prints the snapshot named by one ref
+// or the diff between the snapshots named by two: of their markdown, or with changelog of their
+// symbols.
+func runHistory(ctx context.Context, store storage.Store, importPath string, refs []string, changelog bool, out io.Writer) error {
+	snaps, err := packageSnapshots(ctx, store, importPath)
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		return fmt.Errorf("no cached scrapes of %s", importPath)
+	}
+
+	picked := make([]*snapshot, len(refs))
+	for i, ref := range refs {
+		if picked[i] = findSnapshot(snaps, ref); picked[i] == nil {
+			return fmt.Errorf("no snapshot %q of %s; run docinator history %s to list them", ref, importPath, importPath)
+		}
+	}
+	switch len(picked) {
+	case 1:
+		_, err := io.WriteString(out, picked[0].Markdown)
+		return err
+	case 2:
+		from, to := picked[0], picked[1]
+		if changelog {
+			title := fmt.Sprintf("%s %s → %s", importPath, snapshotVersion(from), snapshotVersion(to))
+			return apidiff.Compare(from.Package, to.Package).WriteMarkdown(out, title)
+		}
+		diff := textdiff.Unified(snapshotLabel(from), snapshotLabel(to), from.Markdown, to.Markdown, 3)
+		if diff == "" {
+			fmt.Fprintf(out, "%s and %s are identical\n", snapshotLabel(from), snapshotLabel(to))
+			return nil
+		}
+		_, err := io.WriteString(out, diff)
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tVERSION\tSCRAPED AT\tHASH\tCOMPLETE")
+	for i, s := range snaps {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d%%\n", i+1, cmp.Or(s.Package.Version, "-"), s.Package.ScrapedAt.Format("2006-01-02 15:04:05"),
+			contentHash(s.Markdown), completeness(s.Package))
+	}
+	return w.Flush()
+}
+
+// packageSnapshots returns the cached documents of importPath, pinned or not, newest first.
+func packageSnapshots(ctx context.Context, store storage.Store, importPath string) ([]*snapshot, error) {
+	var snaps []*snapshot
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package == nil || (doc.ID != importPath && !strings.HasPrefix(doc.ID, importPath+"@")) {
+			return nil
+		}
+		snaps = append(snaps, &snapshot{ID: doc.ID, Package: doc.Package, Markdown: markdown.PackageToMarkdown(doc.Package)})
+		return nil
+	})
+	slices.SortStableFunc(snaps, func(a, b *snapshot) int { return b.Package.ScrapedAt.Compare(a.Package.ScrapedAt) })
+	return snaps, err
+}
+
+// findSnapshot returns the snapshot numbered ref in the listing, or else the newest one of version ref.
+func findSnapshot(snaps []*snapshot, ref string) *snapshot {
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(snaps) {
+		return snaps[n-1]
+	}
+	for _, s := range snaps {
+		if s.Package.Version == ref || pinnedVersion(s.ID) == ref {
+			return s
+		}
+	}
+	return nil
+}
+
+// snapshotVersion names a snapshot by its version, or its scrape time when it has none.
+func snapshotVersion(s *snapshot) string {
+	return cmp.Or(s.Package.Version, pinnedVersion(s.ID), s.Package.ScrapedAt.Format("2006-01-02 15:04:05"))
+}
+
+// snapshotLabel names a snapshot in diff headers.
+func snapshotLabel(s *snapshot) string {
+	return fmt.Sprintf("%s (%s)", s.ID, s.Package.ScrapedAt.Format("2006-01-02 15:04:05"))
+}
+
+// contentHash returns a short hash of a snapshot's markdown.
+func contentHash(md string) string {
+	sum := sha256.Sum256([]byte(md))
+	return hex.EncodeToString(sum[:6])
+}
+
+// completeness returns the percentage of functions, types and methods of pkg with a doc comment,
+// or 100 for a package without any.
+func completeness(pkg *models.Package) int {
+	total, documented := 0, 0
+	count := func(description string) {
+		total++
+		if strings.TrimSpace(description) != "" {
+			documented++
+		}
+	}
+	for _, f := range pkg.Functions {
+		count(f.Description)
+	}
+	for _, t := range pkg.Types {
+		count(t.Description)
+		for _, m := range t.Methods {
+			count(m.Description)
+		}
+	}
+	if total == 0 {
+		return 100
+	}
+	return documented * 100 / total
+}
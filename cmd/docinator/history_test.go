@@ -0,0 +1,65 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestRunHistory(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, doc := range []*models.Document{
+		{ID: "example.com/m@v1.0.0", Package: &models.Package{Name: "m", ImportPath: "example.com/m", Version: "v1.0.0", ScrapedAt: day,
+			Functions: []models.Function{{Name: "Do"}, {Name: "Undo", Description: "Undo reverts it."}}}},
+		{ID: "example.com/m", Package: &models.Package{Name: "m", ImportPath: "example.com/m", Version: "v1.1.0", ScrapedAt: day.AddDate(0, 1, 0),
+			Functions: []models.Function{{Name: "Do", Description: "Do does a thing."}, {Name: "Undo", Description: "Undo reverts it."}}}},
+		{ID: "example.com/m/sub", Package: &models.Package{Name: "sub", ImportPath: "example.com/m/sub", ScrapedAt: day}},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runHistory(ctx, store, "example.com/m", nil, false, &out); err != nil {
+		t.Fatalf("runHistory failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1  v1.1.0   2025-04-01") || !strings.HasSuffix(lines[1], "100%") ||
+		!strings.HasPrefix(lines[2], "2  v1.0.0   2025-03-01") || !strings.HasSuffix(lines[2], "50%") {
+		t.Errorf("Unexpected listing:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runHistory(ctx, store, "example.com/m", []string{"v1.0.0"}, false, &out); err != nil || !strings.HasPrefix(out.String(), "# m package - example.com/m") {
+		t.Errorf("Expected the snapshot's markdown, got %v:\n%s", err, out.String())
+	}
+
+	out.Reset()
+	if err := runHistory(ctx, store, "example.com/m", []string{"2", "1"}, false, &out); err != nil {
+		t.Fatalf("runHistory failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "--- example.com/m@v1.0.0 (2025-03-01 12:00:00)\n+++ example.com/m (2025-04-01 12:00:00)\n") ||
+		!strings.Contains(out.String(), "+Do does a thing.") {
+		t.Errorf("Unexpected diff:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runHistory(ctx, store, "example.com/m", []string{"2", "1"}, true, &out); err != nil {
+		t.Fatalf("runHistory failed: %v", err)
+	}
+	if want := "## example.com/m v1.0.0 → v1.1.0\n\nNo changes to the exported API."; out.String() != want {
+		t.Errorf("Expected changelog %q, got %q", want, out.String())
+	}
+
+	if err := runHistory(ctx, store, "example.com/m", []string{"v9.9.9"}, false, &out); err == nil {
+		t.Error("Expected an unknown snapshot to fail")
+	}
+}
@@ -0,0 +1,87 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <spill file>",
+	Short: "Store the documents a batch spilled while the cache was unavailable",
+	Long: `Upsert the documents of a spill file into the cache. scrape and warm queue
+upserts that fail during a batch, retry them at its end and append those the
+store still refuses to docinator.spill.jsonl, in the output directory or the
+working directory. Once the store is back:
+
+  docinator import docs/docinator.spill.jsonl
+
+Imported documents are removed from the file, and the file once all are in;
+the exit status is 1 when any document could not be stored.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "import", "set MONGODB_URI or BOLT_PATH")
+		}
+		left, err := runImport(ctx, store, args[0], cmd.OutOrStdout())
+		if err != nil {
+			return fmt.Errorf("import failed: %w", err)
+		}
+		if left > 0 {
+			closeStore()
+			stopProfiling()
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// runImport upserts the documents of the spill file at path into store and rewrites the file with
+// the ones that failed, or removes it when none did. It returns how many are left.
+func runImport(ctx context.Context, store storage.Store, path string, out io.Writer) (int, error) {
+	docs, err := readSpill(path)
+	if err != nil {
+		return 0, err
+	}
+	imported := 0
+	left := docs[:0:0]
+	for _, doc := range docs {
+		if err := store.Upsert(ctx, doc); err != nil {
+			log.Printf("Upsert of %s failed: %v", doc.ID, err)
+			left = append(left, doc)
+			continue
+		}
+		imported++
+	}
+	if len(left) == 0 {
+		if err := os.Remove(path); err != nil {
+			return 0, err
+		}
+	} else {
+		// Write the rest beside the file and swap it in, so an interrupted import loses nothing.
+		tmp := path + ".tmp"
+		os.Remove(tmp)
+		if err := appendSpill(tmp, left); err != nil {
+			return len(left), err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return len(left), err
+		}
+	}
+	fmt.Fprintf(out, "Imported %d of %d document(s) from %s\n", imported, len(docs), path)
+	if len(left) > 0 {
+		fmt.Fprintf(out, "%d document(s) left in %s\n", len(left), path)
+	}
+	return len(left), nil
+}
@@ -0,0 +1,133 @@
+package docinator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exitLicensePolicy is the exit status of a scrape whose packages violate the license policy, so
+// CI can tell a compliance failure from a scrape failure.
+const exitLicensePolicy = 3
+
+// errLicensePolicy is returned by runScrape when packages violate an enforced license policy.
+var errLicensePolicy = errors.New("license policy violated")
+
+// licenseViolation is a package whose license is outside the license policy.
+type licenseViolation struct {
+	ImportPath string `json:"import_path"`
+	License    string `json:"license"`        // as shown on pkg.go.dev; empty when none was detected
+	Rule       string `json:"rule,omitempty"` // deny entry the license matched; empty when it is just not allowed
+}
+
+// licensePolicy is the license policy of --license-policy and --allow-licenses:
+//
+//	allow: [MIT, Apache-2.0, BSD-*]
+//	deny: [AGPL-*, GPL-*]
+//	allow_unknown: false   # packages without a detected license
+//	report_only: false     # report violations without failing the run
+//
+// Entries are SPDX identifiers or path.Match patterns, compared case-insensitively. A license
+// matching a deny entry is a violation; otherwise, when allow is not empty, so is one matching
+// no allow entry.
+type licensePolicy struct {
+	Allow        []string `yaml:"allow"`
+	Deny         []string `yaml:"deny"`
+	AllowUnknown bool     `yaml:"allow_unknown"`
+	ReportOnly   bool     `yaml:"report_only"`
+}
+
+// loadLicensePolicy reads and validates the policy file at path.
+func loadLicensePolicy(path string) (*licensePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p licensePolicy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// validate checks that every entry is a valid pattern and that the policy restricts something.
+func (p *licensePolicy) validate() error {
+	if len(p.Allow) == 0 && len(p.Deny) == 0 {
+		return errors.New("the license policy has neither allow nor deny entries")
+	}
+	for _, e := range append(append([]string{}, p.Allow...), p.Deny...) {
+		if _, err := path.Match(e, ""); err != nil {
+			return fmt.Errorf("license pattern %q: %w", e, err)
+		}
+	}
+	return nil
+}
+
+// check reports whether license, a comma-separated list as pkg.go.dev shows it, complies with the
+// policy. Every license of the list must comply. For a denied license, rule is the deny entry
+// it matched.
+func (p *licensePolicy) check(license string) (rule string, ok bool) {
+	if strings.TrimSpace(license) == "" {
+		return "", p.AllowUnknown
+	}
+	for _, l := range strings.Split(license, ",") {
+		l = strings.TrimSpace(l)
+		if deny := matchLicense(l, p.Deny); deny != "" {
+			return deny, false
+		}
+		if len(p.Allow) > 0 && matchLicense(l, p.Allow) == "" {
+			return "", false
+		}
+	}
+	return "", true
+}
+
+// matchLicense returns the first of patterns matching license, or "".
+func matchLicense(license string, patterns []string) string {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(strings.TrimSpace(pattern)), strings.ToLower(license)); ok {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// licenseAllowed reports whether every license of a package (pkg.go.dev lists several separated
+// by commas) is in allowed, compared case-insensitively. Packages without a detected license
+// are never allowed.
+func licenseAllowed(license string, allowed []string) bool {
+	_, ok := (&licensePolicy{Allow: allowed}).check(license)
+	return ok
+}
+
+// scrapeLicensePolicy returns the policy of a scrape: the --license-policy file with the
+// --allow-licenses entries added, or nil when neither is set.
+func scrapeLicensePolicy(opts scrapeOptions) *licensePolicy {
+	if opts.LicensePolicy == nil && len(opts.AllowLicenses) == 0 {
+		return nil
+	}
+	p := licensePolicy{}
+	if opts.LicensePolicy != nil {
+		p = *opts.LicensePolicy
+	}
+	p.Allow = append(append([]string{}, p.Allow...), opts.AllowLicenses...)
+	return &p
+}
+
+// violationMessage describes v for logs and annotations.
+func violationMessage(v licenseViolation) string {
+	if v.Rule != "" {
+		return fmt.Sprintf("%s: license %s is denied (%s)", v.ImportPath, licenseName(v.License), v.Rule)
+	}
+	return fmt.Sprintf("%s: license %s is not allowed", v.ImportPath, licenseName(v.License))
+}
@@ -0,0 +1,80 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestLicensePolicy(t *testing.T) {
+	p := &licensePolicy{Allow: []string{"MIT", "BSD-*", "Apache-2.0"}, Deny: []string{"bsd-4-clause"}}
+	for license, want := range map[string]string{
+		"MIT":                      "ok",
+		"bsd-3-clause":             "ok",
+		"BSD-4-Clause":             "bsd-4-clause",
+		"MIT, GPL-3.0":             "not allowed",
+		"":                         "not allowed",
+		"Apache-2.0, BSD-2-Clause": "ok",
+	} {
+		rule, ok := p.check(license)
+		got := rule
+		switch {
+		case ok:
+			got = "ok"
+		case rule == "":
+			got = "not allowed"
+		}
+		if got != want {
+			t.Errorf("check(%q) = %s, want %s", license, got, want)
+		}
+	}
+
+	denyOnly := &licensePolicy{Deny: []string{"AGPL-*"}, AllowUnknown: true}
+	if _, ok := denyOnly.check("GPL-3.0"); !ok {
+		t.Error("Expected a deny-only policy to allow other licenses")
+	}
+	if _, ok := denyOnly.check(""); !ok {
+		t.Error("Expected allow_unknown to allow packages without a license")
+	}
+
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"empty.yaml":   "report_only: true\n",
+		"unknown.yaml": "allow: [MIT]\nallowed: [BSD]\n",
+		"pattern.yaml": "deny: ['GPL-[']\n",
+	} {
+		os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if _, err := loadLicensePolicy(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRunScrape_LicensePolicy(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	os.WriteFile(policyPath, []byte("deny: [apache-*]\n"), 0644)
+	policy, err := loadLicensePolicy(policyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true, GHA: true, LicensePolicy: policy}
+	if err := runScrape(context.Background(), opts, memstore.New(), &out); !errors.Is(err, errLicensePolicy) {
+		t.Errorf("Expected a license policy error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "::error title=License policy::github.com/spf13/cobra: license Apache-2.0 is denied (apache-*)\n") {
+		t.Errorf("Expected the deny rule in the annotation, got %q", out.String())
+	}
+
+	policy.ReportOnly = true
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Errorf("Expected a report-only policy to pass, got %v", err)
+	}
+}
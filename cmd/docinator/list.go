@@ -0,0 +1,105 @@
+package docinator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached packages by module or import path",
+	Long: `List what the MongoDB cache holds, either every package of a module
+(--module) or every cached version of one import path (--versions). --tag lists
+only packages carrying all of the given tags (see docinator tag), and on its own
+lists every such package.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		module, _ := cmd.Flags().GetString("module")
+		versionsOf, _ := cmd.Flags().GetString("versions")
+		tags := tagFilter(cmd)
+		if module != "" && versionsOf != "" || module == "" && versionsOf == "" && len(tags) == 0 {
+			return errors.New("specify exactly one of --module or --versions, or --tag")
+		}
+
+		ctx := cmd.Context()
+		store, closeStore := openMongoStore(ctx)
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "list", "set MONGODB_URI")
+		}
+
+		var docs []models.DocumentSummary
+		var err error
+		switch {
+		case module != "":
+			docs, err = store.FindByModule(ctx, module)
+		case versionsOf != "":
+			docs, err = store.FindVersions(ctx, versionsOf)
+		default:
+			docs, err = store.FindTagged(ctx, tags)
+		}
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		docs = slices.DeleteFunc(docs, func(d models.DocumentSummary) bool { return !hasTags(d.Tags, tags) })
+
+		now := time.Now()
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tIMPORT PATH\tVERSION\tPUBLISHED\tSCRAPED AT\tTAGS")
+		for _, d := range docs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", d.ID, d.ImportPath, d.Version, publishedColumn(d, now), d.ScrapedAt.Format("2006-01-02 15:04:05"), strings.Join(d.Tags, ","))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	listCmd.Flags().String("module", "", "list cached packages belonging to this module path")
+	listCmd.Flags().String("versions", "", "list cached versions of this import path")
+	listCmd.Flags().StringSlice("tag", nil, "list only packages carrying all of these tags")
+}
+
+// publishedAt returns when the package of d was published: its parsed date or, for documents
+// cached before dates were parsed, its displayed date parsed relative to the scrape. ok is false
+// when the date is unknown.
+func publishedAt(d models.DocumentSummary) (time.Time, bool) {
+	if !d.PublishedAt.IsZero() {
+		return d.PublishedAt, true
+	}
+	return utils.ParseDate(d.Published, d.ScrapedAt)
+}
+
+// publishedColumn formats the publication date of d with its age at now, e.g. "2024-03-01 (1y)",
+// or the displayed date when it cannot be parsed.
+func publishedColumn(d models.DocumentSummary, now time.Time) string {
+	t, ok := publishedAt(d)
+	if !ok {
+		return d.Published
+	}
+	return fmt.Sprintf("%s (%s)", t.Format("2006-01-02"), age(now.Sub(t)))
+}
+
+// age formats a duration coarsely, in the largest whole unit: "5h", "3d", "7mo" or "2y".
+func age(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch {
+	case d < 0:
+		return "future"
+	case days < 1:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case days < 31:
+		return fmt.Sprintf("%dd", days)
+	case days < 365:
+		return fmt.Sprintf("%dmo", days/30)
+	}
+	return fmt.Sprintf("%dy", days/365)
+}
@@ -0,0 +1,26 @@
+package docinator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestPublishedColumn(t *testing.T) {
+	now := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		doc  models.DocumentSummary
+		want string
+	}{
+		{models.DocumentSummary{PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)}, "2025-03-01 (9d)"},
+		// Cached before dates were parsed: the displayed date is parsed relative to the scrape.
+		{models.DocumentSummary{Published: "Mar 1, 2023"}, "2023-03-01 (2y)"},
+		{models.DocumentSummary{Published: "2 days ago", ScrapedAt: now.AddDate(0, -5, 0)}, "2024-10-08 (5mo)"},
+		{models.DocumentSummary{Published: "unknown"}, "unknown"},
+	} {
+		if got := publishedColumn(tc.doc, now); got != tc.want {
+			t.Errorf("publishedColumn(%+v) = %q, want %q", tc.doc, got, tc.want)
+		}
+	}
+}
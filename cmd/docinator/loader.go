@@ -0,0 +1,562 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	mongostore "github.com/moseye/docinator/internal/storage/mongo"
+	"github.com/moseye/docinator/pkg/llm"
+	"github.com/moseye/docinator/pkg/localdoc"
+	"github.com/moseye/docinator/pkg/parser"
+	"github.com/moseye/docinator/pkg/playground"
+	"github.com/moseye/docinator/pkg/ratelimit"
+	"github.com/moseye/docinator/pkg/readme"
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/siteprofile"
+	"github.com/moseye/docinator/pkg/source"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// enricher adds optional data to a loaded package and reports whether it changed anything.
+type enricher func(ctx context.Context, pkg *models.Package) bool
+
+// packageLoader resolves import paths to packages, consulting the cache before scraping.
+type packageLoader struct {
+	scraper     *scraper.Scraper
+	store       storage.Store
+	enrichers   []enricher // run on every loaded package, cached or scraped
+	verbose     bool
+	noCache     bool              // always scrape, without looking packages up in the store
+	noStore     bool              // do not persist scraped packages to the store
+	private     *privateRouter    // documents GOPRIVATE packages without pkg.go.dev; nil disables it
+	cacheHits   atomic.Int64      // packages served from the store
+	cacheMisses atomic.Int64      // store lookups that found nothing, so the package was scraped
+	progress    *progressReporter // optional lifecycle events; nil disables them
+
+	history bool // record the adoption counts of every scrape on the stored document (--history)
+
+	pending *upsertQueue // failed upserts kept for flushPending; nil logs and drops them
+
+	tabs scraper.TabRequest // tabs fetched concurrently with every scraped page
+
+	// profile is the page sections the scraper parses; cached packages parsed with fewer are
+	// scraped again, and those parsed with more are trimmed to it.
+	profile parser.Profile
+}
+
+// newPackageLoader builds a loader from the global flags and the --store backend. The returned cleanup func must be called when done.
+func newPackageLoader(cmd *cobra.Command) (*packageLoader, func(), error) {
+	selectors, err := loadSelectors()
+	if err != nil {
+		return nil, nil, err
+	}
+	site, err := loadSite()
+	if err != nil {
+		return nil, nil, err
+	}
+	store, closeStore, err := openStore(cmd.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	rate, _ := rootCmd.PersistentFlags().GetFloat64("rate-limit")
+	limiter, closeLimiter, err := newRateLimiter(cmd.Context(), rate)
+	if err != nil {
+		closeStore()
+		return nil, nil, err
+	}
+	config := scraperConfig()
+	config.Limiter = limiter
+	config.Selectors = selectors
+	config.Site = site
+	loader, closeLoader, err := newLoader(store, config, verbosity() >= 1)
+	if err != nil {
+		closeLimiter()
+		closeStore()
+		return nil, nil, err
+	}
+	if site == nil {
+		privateSite, err := loadSiteFlags("private-site", "private-site-base")
+		if err != nil {
+			closeLoader()
+			closeLimiter()
+			closeStore()
+			return nil, nil, err
+		}
+		loader.private = newPrivateRouter(privatePatterns(), privateSite, defaultPrivateFinder("vendor"))
+	}
+	loader.noCache, _ = rootCmd.PersistentFlags().GetBool("no-cache")
+	loader.noStore, _ = rootCmd.PersistentFlags().GetBool("no-store")
+	loader.history, _ = rootCmd.PersistentFlags().GetBool("history")
+	return loader, func() {
+		closeLoader()
+		closeLimiter()
+		closeStore()
+	}, nil
+}
+
+// newRateLimiter returns the limiter for --rate-limit requests per second, or nil when rate is 0.
+// With REDIS_URL set the bucket lives in Redis (under REDIS_RATE_KEY, default
+// ratelimit.DefaultKey), so every worker sharing it stays within rate together; otherwise it is
+// local to this process. The returned func releases it.
+func newRateLimiter(ctx context.Context, rate float64) (scraper.RateLimiter, func(), error) {
+	if rate <= 0 {
+		return nil, func() {}, nil
+	}
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		return ratelimit.NewLocal(rate, 1), func() {}, nil
+	}
+	key := os.Getenv("REDIS_RATE_KEY")
+	if key == "" {
+		key = ratelimit.DefaultKey
+	}
+	limiter, err := ratelimit.Dial(ctx, url, key, rate, 1)
+	if err != nil {
+		return nil, nil, withHint(err, "REDIS_URL is set but Redis is unreachable: check the network or VPN, or unset REDIS_URL to limit the rate locally")
+	}
+	return limiter, func() {
+		if err := limiter.Close(); err != nil {
+			log.Printf("Redis close error: %v", err)
+		}
+	}, nil
+}
+
+// requestPriority returns the --priority of this command's requests for the rate limiter, or def
+// when the flag is not set.
+func requestPriority(def ratelimit.Priority) (ratelimit.Priority, error) {
+	flag, _ := rootCmd.PersistentFlags().GetString("priority")
+	if flag == "" {
+		return def, nil
+	}
+	p, err := ratelimit.ParsePriority(flag)
+	if err != nil {
+		return def, fmt.Errorf("--priority: %w", err)
+	}
+	return p, nil
+}
+
+// loadSelectors reads the --selectors profile, or returns nil for the embedded defaults.
+func loadSelectors() (*parser.Selectors, error) {
+	path, _ := rootCmd.PersistentFlags().GetString("selectors")
+	if path == "" {
+		return nil, nil
+	}
+	return parser.LoadSelectors(path)
+}
+
+// loadSite reads the --site profile, a built-in profile name or a file, moved to --site-base when
+// set, or returns nil to scrape pkg.go.dev.
+func loadSite() (*siteprofile.Profile, error) {
+	return loadSiteFlags("site", "site-base")
+}
+
+// loadSiteFlags reads the site profile named by the persistent flag siteFlag, moved to the URL in
+// baseFlag when set, or returns nil when siteFlag is empty.
+func loadSiteFlags(siteFlag, baseFlag string) (*siteprofile.Profile, error) {
+	path, _ := rootCmd.PersistentFlags().GetString(siteFlag)
+	base, _ := rootCmd.PersistentFlags().GetString(baseFlag)
+	if path == "" {
+		if base != "" {
+			return nil, fmt.Errorf("--%s needs --%s", baseFlag, siteFlag)
+		}
+		return nil, nil
+	}
+	site := siteprofile.Builtin(path)
+	if site == nil {
+		var err error
+		if site, err = siteprofile.Load(path); err != nil {
+			return nil, err
+		}
+	}
+	if base == "" {
+		return site, nil
+	}
+	return site.Rebase(base)
+}
+
+// siteName returns the name of the --site profile, or "" when scraping pkg.go.dev.
+func siteName(site *siteprofile.Profile) string {
+	if site == nil {
+		return ""
+	}
+	return site.Name
+}
+
+// scraperConfig builds the scraper configuration from the global flags.
+func scraperConfig() *scraper.ScrapingConfig {
+	testMode, _ := rootCmd.PersistentFlags().GetBool("test-mode")
+	cacheDir, _ := rootCmd.PersistentFlags().GetString("http-cache-dir")
+	randomDelay, _ := rootCmd.PersistentFlags().GetDuration("random-delay")
+	api, _ := rootCmd.PersistentFlags().GetString("pkgsite-api")
+	return &scraper.ScrapingConfig{
+		Debug:           verbosity() >= 2,
+		TestMode:        testMode,
+		CacheDir:        cacheDir,
+		MaxRetries:      scraper.DefaultConfig().MaxRetries,
+		RandomDelay:     randomDelay,
+		MaxResponseSize: maxResponseSize(),
+		API:             api,
+	}
+}
+
+// maxResponseSize converts --max-response-size to ScrapingConfig.MaxResponseSize: bytes, or
+// negative to disable the limit.
+func maxResponseSize() int64 {
+	mb, _ := rootCmd.PersistentFlags().GetInt("max-response-size")
+	if mb <= 0 {
+		return -1
+	}
+	return int64(mb) << 20
+}
+
+// newLoader builds a loader around store; verbose logs cache activity. The returned cleanup func closes the scraper but not the store.
+func newLoader(store storage.Store, config *scraper.ScrapingConfig, verbose bool) (*packageLoader, func(), error) {
+	s, err := scraper.New(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create scraper: %w", err)
+	}
+	return &packageLoader{scraper: s, store: store, verbose: verbose, profile: config.Profile}, func() { s.Close() }, nil
+}
+
+// openStore initializes the document cache selected by --store and returns a func that closes it.
+// An explicitly requested backend that cannot be opened is an error; with "auto", failures disable
+// caching: the store returned is an unopenedStore.
+func openStore(ctx context.Context) (storage.Store, func(), error) {
+	backend, _ := rootCmd.PersistentFlags().GetString("store")
+	store, err := storage.Open(ctx, backend)
+	if err != nil {
+		err = withHint(fmt.Errorf("store initialization failed: %w", err), storeHint(storage.Canonical(backend), err))
+		if backend != "auto" {
+			return nil, nil, err
+		}
+		log.Printf("Store initialization error (disabled): %v", err)
+		store = unopenedStore{Store: storage.Disabled(), err: err}
+	}
+	return store, func() {
+		if store.Enabled() {
+			if err := store.Close(ctx); err != nil {
+				log.Printf("Store close error: %v", err)
+			}
+		}
+	}, nil
+}
+
+// unopenedStore is the disabled store openStore carries on with when the one --store auto selected
+// failed to open; err is why, which needsCache reports.
+type unopenedStore struct {
+	storage.Store
+	err error
+}
+
+// openMongoStore initializes the MongoDB store (disabled if MONGODB_URI is not set) for features
+// only MongoDB provides, and returns a func that closes it.
+func openMongoStore(ctx context.Context) (*mongostore.Store, func()) {
+	store, err := mongostore.NewFromEnv(ctx)
+	if err != nil {
+		log.Printf("MongoDB store initialization error (disabled): %v", err)
+		store = nil
+	}
+	return store, func() {
+		if store.Enabled() {
+			if err := store.Close(ctx); err != nil {
+				log.Printf("MongoDB disconnect error: %v", err)
+			}
+		}
+	}
+}
+
+// load returns the package and its raw HTML, from the cache when available, scraping and persisting otherwise.
+// noCache skips the lookup and noStore the write.
+func (l *packageLoader) load(ctx context.Context, importPath string) (*models.Package, string, error) {
+	pkg, rawHTML, _, err := l.loadTimed(ctx, importPath)
+	return pkg, rawHTML, err
+}
+
+// loadTimed is load that also reports where the time went. Cache lookups and enrichers count as
+// fetching, cache writes as storing.
+func (l *packageLoader) loadTimed(ctx context.Context, importPath string) (*models.Package, string, packageTiming, error) {
+	timing := packageTiming{ImportPath: importPath}
+	start := time.Now()
+	l.progress.emit(progressEvent{Event: eventFetching, ImportPath: importPath})
+	// 1) Check the cache first
+	if l.store.Enabled() && !l.noCache {
+		doc, err := l.store.GetByID(ctx, importPath)
+		if err != nil {
+			log.Printf("MongoDB lookup error for %s: %v", importPath, err)
+		} else if current, reparsed := l.currentParse(importPath, doc); current {
+			enriched := l.enrich(ctx, doc.Package)
+			if touchRequested(doc, time.Now()) || enriched || reparsed {
+				l.upsert(ctx, doc)
+			}
+			l.cacheHits.Add(1)
+			if l.private.matches(importPath) {
+				l.private.note(importPath, "cache")
+			}
+			l.progress.emit(progressEvent{Event: eventParsed, ImportPath: importPath, Cached: true})
+			if l.verbose {
+				log.Printf("Loaded from cache: %s", importPath)
+				logWarnings(importPath, doc.Package)
+			}
+			timing.Cached = true
+			timing.Fetch = time.Since(start)
+			if pkg := l.profile.Trim(doc.Package); pkg != doc.Package {
+				return pkg, "", timing, nil
+			}
+			return doc.Package, doc.RawHTML, timing, nil
+		}
+		l.cacheMisses.Add(1)
+	}
+
+	// 2) Not cached → scrape
+	return l.scrapeTimed(ctx, importPath, start)
+}
+
+// currentParse reports whether doc, cached for importPath, can be served: it holds a package
+// extracted by the current parser and selector profile, or one extracted by another that could be
+// parsed again from doc's raw HTML, in which case doc.Package is replaced and reparsed is true.
+// A stale package without its page (dropped by docinator gc, say) is not current and gets scraped
+// again, as is one parsed with fewer page sections than the loader's profile. Private packages
+// and sites other than pkg.go.dev are not checked.
+func (l *packageLoader) currentParse(importPath string, doc *models.Document) (current, reparsed bool) {
+	if doc == nil || doc.Package == nil {
+		return false, false
+	}
+	if cached := parser.Profile(doc.Package.Profile); !cached.Covers(l.profile) {
+		if l.verbose {
+			log.Printf("Cached %s holds the %s profile only; scraping it again for %s", importPath, cached, l.profile)
+		}
+		return false, false
+	}
+	fingerprint := l.scraper.ParserFingerprint()
+	if fingerprint == "" || doc.Package.Parser == fingerprint || l.private.matches(importPath) {
+		return true, false
+	}
+	pkg, err := l.scraper.Reparse(doc.Package, doc.RawHTML)
+	if err != nil {
+		if l.verbose {
+			log.Printf("Cached %s was parsed by parser %q, not %s, and cannot be re-parsed (%v); scraping it again", importPath, doc.Package.Parser, fingerprint, err)
+		}
+		return false, false
+	}
+	if l.verbose {
+		log.Printf("Re-parsed cached %s (parser %q, now %s)", importPath, doc.Package.Parser, fingerprint)
+	}
+	doc.Package = pkg
+	return true, true
+}
+
+// scrapeTimed scrapes importPath regardless of the cache, runs the enrichers and persists the
+// result. Private packages are documented by l.private instead. Times are measured from start.
+func (l *packageLoader) scrapeTimed(ctx context.Context, importPath string, start time.Time) (*models.Package, string, packageTiming, error) {
+	timing := packageTiming{ImportPath: importPath}
+	var pkg *models.Package
+	var rawHTML string
+	var scraped scraper.Timing
+	var err error
+	if l.private.matches(importPath) {
+		pkg, err = l.private.load(ctx, l.scraper, importPath)
+	} else {
+		pkg, rawHTML, _, scraped, err = l.scraper.ScrapePackageTabs(ctx, importPath, l.tabs)
+	}
+	if err != nil {
+		return nil, "", timing, &scraper.PathError{ImportPath: importPath, Err: err}
+	}
+	l.progress.emit(progressEvent{Event: eventParsed, ImportPath: importPath})
+	l.enrich(ctx, pkg)
+	if l.verbose {
+		logWarnings(importPath, pkg)
+	}
+	timing.Parse = scraped.Parse
+	timing.Fetch = time.Since(start) - timing.Parse
+
+	// 3) Persist to the cache (upsert) for future runs
+	if l.store.Enabled() && !l.noStore {
+		id := importPath
+		if pkg != nil && pkg.ImportPath != "" {
+			id = pkg.ImportPath
+			if v := pinnedVersion(importPath); v != "" {
+				id += "@" + v
+			}
+		}
+		doc := &models.Document{
+			ID:          id,
+			Package:     pkg,
+			RawHTML:     rawHTML,
+			RequestedAt: time.Now(),
+		}
+		storeStart := time.Now()
+		carryCuration(ctx, l.store, doc)
+		if l.history && pkg != nil {
+			doc.Adoption = recordAdoption(doc.Adoption, pkg, time.Now())
+		}
+		if err := l.upsert(ctx, doc); err == nil && l.verbose {
+			log.Printf("Upserted into cache: %s", id)
+		}
+		timing.Store = time.Since(storeStart)
+	}
+	return pkg, rawHTML, timing, nil
+}
+
+// touchRequested records that doc was requested at now. It reports whether RequestedAt changed,
+// which happens at most once a day so cache hits rarely cost a write.
+func touchRequested(doc *models.Document, now time.Time) bool {
+	if now.Sub(doc.RequestedAt) < 24*time.Hour {
+		return false
+	}
+	doc.RequestedAt = now
+	return true
+}
+
+// logWarnings logs the data quality warnings recorded on pkg.
+func logWarnings(importPath string, pkg *models.Package) {
+	for _, w := range pkg.Warnings {
+		log.Printf("Warning for %s: %s", importPath, w)
+	}
+}
+
+// loadAll loads every import path, collecting per-path errors instead of stopping at the first one.
+// Only the parsed packages are kept; each raw page is dropped as soon as its package is loaded.
+func (l *packageLoader) loadAll(ctx context.Context, importPaths []string) ([]*models.Package, []error) {
+	var pkgs []*models.Package
+	var errs []error
+	for _, importPath := range importPaths {
+		pkg, _, err := l.load(ctx, importPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, errs
+}
+
+// enrich runs every enricher on pkg and reports whether any of them changed it.
+func (l *packageLoader) enrich(ctx context.Context, pkg *models.Package) bool {
+	changed := false
+	for _, e := range l.enrichers {
+		if e(ctx, pkg) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// summarizeEnricher attaches a generated summary to packages that do not have one yet.
+func summarizeEnricher(summarizer llm.Summarizer) enricher {
+	return func(ctx context.Context, pkg *models.Package) bool {
+		if pkg.Summary != nil {
+			return false
+		}
+		summary, err := summarizer.Summarize(ctx, pkg)
+		if err != nil {
+			log.Printf("Summarization failed for %s: %v", pkg.ImportPath, err)
+			return false
+		}
+		pkg.Summary = summary
+		return true
+	}
+}
+
+// playgroundEnricher shares examples without a Playground link and records the permalink.
+func playgroundEnricher(client *http.Client) enricher {
+	return func(ctx context.Context, pkg *models.Package) bool {
+		changed := false
+		for _, ex := range pkg.AllExamples() {
+			if ex.PlaygroundURL != "" || ex.Code == "" {
+				continue
+			}
+			url, err := playground.Share(ctx, client, ex.Code)
+			if err != nil {
+				log.Printf("Playground share failed for %s example %s: %v", pkg.ImportPath, ex.Name, err)
+				continue
+			}
+			ex.PlaygroundURL = url
+			changed = true
+		}
+		return changed
+	}
+}
+
+// sourceEnricher downloads declaration bodies from the repository for symbols that have a source link.
+func sourceEnricher(fetcher *source.Fetcher) enricher {
+	return func(ctx context.Context, pkg *models.Package) bool {
+		n, err := fetcher.Enrich(ctx, pkg)
+		if err != nil {
+			log.Printf("Source fetch incomplete for %s: %v", pkg.ImportPath, err)
+		}
+		return n > 0
+	}
+}
+
+// readmeLangEnricher makes the README translation in language lang, when the README links one,
+// the package's README.
+func readmeLangEnricher(client *http.Client, lang string) enricher {
+	return func(ctx context.Context, pkg *models.Package) bool {
+		changed, err := readme.Prefer(ctx, client, pkg, lang)
+		if err != nil {
+			log.Printf("README in %s for %s failed: %v", lang, pkg.ImportPath, err)
+		}
+		return changed
+	}
+}
+
+// localSourceEnricher merges doc comments and struct field docs from the package's source in the
+// module cache or vendor directory, when a copy at the scraped version is there.
+func localSourceEnricher(finder localdoc.Finder) enricher {
+	return func(ctx context.Context, pkg *models.Package) bool {
+		if pkg.LocalSource != "" {
+			return false
+		}
+		dir := finder.Find(pkg)
+		if dir == "" {
+			return false
+		}
+		src, err := localdoc.Read(dir, pkg.ImportPath)
+		if err != nil {
+			log.Printf("Reading local source of %s failed: %v", pkg.ImportPath, err)
+			return false
+		}
+		n := localdoc.Merge(pkg, src)
+		log.Printf("Merged %d declarations from %s into %s", n, dir, pkg.ImportPath)
+		return true
+	}
+}
+
+// importsEnricher records the import paths listed on the imports tab.
+func importsEnricher(s *scraper.Scraper) enricher {
+	return func(ctx context.Context, pkg *models.Package) bool {
+		if len(pkg.ImportList) > 0 {
+			return false
+		}
+		imports, err := s.ScrapeImports(ctx, pkg.ImportPath)
+		if err != nil {
+			log.Printf("Import lookup failed for %s: %v", pkg.ImportPath, err)
+			return false
+		}
+		pkg.ImportList = imports
+		return len(imports) > 0
+	}
+}
+
+// importersEnricher records a sample of up to limit importing packages from the importedby tab.
+func importersEnricher(s *scraper.Scraper, limit int) enricher {
+	return func(ctx context.Context, pkg *models.Package) bool {
+		if len(pkg.Importers) > 0 {
+			return false
+		}
+		importers, err := s.ScrapeImporters(ctx, pkg.ImportPath, limit)
+		if err != nil {
+			log.Printf("Importer lookup failed for %s: %v", pkg.ImportPath, err)
+			return false
+		}
+		pkg.Importers = importers
+		return len(importers) > 0
+	}
+}
@@ -0,0 +1,54 @@
+package docinator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+func TestLoad_ReparsesStaleParser(t *testing.T) {
+	ctx := context.Background()
+	page, err := os.ReadFile(filepath.Join("..", "..", "pkg", "parser", "testdata", "widget.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scrapedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := memstore.New()
+	stale := &models.Package{Name: "gear", ImportPath: "example.com/widget/gear", Parser: "0/000000000000", ScrapedAt: scrapedAt, Importers: []string{"example.com/app"}}
+	if err := store.Upsert(ctx, &models.Document{ID: stale.ImportPath, Package: stale, RawHTML: string(page)}); err != nil {
+		t.Fatal(err)
+	}
+	loader, cleanup, err := newLoader(store, &scraper.ScrapingConfig{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	pkg, _, err := loader.load(ctx, stale.ImportPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if pkg.Parser != loader.scraper.ParserFingerprint() || len(pkg.Functions) != 1 || pkg.ImportedBy != 1204 {
+		t.Errorf("Expected the cached page parsed again, got %+v", pkg)
+	}
+	if !pkg.ScrapedAt.Equal(scrapedAt) || len(pkg.Importers) != 1 {
+		t.Errorf("Expected the scrape time and importers kept, got %v %v", pkg.ScrapedAt, pkg.Importers)
+	}
+	if doc, _ := store.GetByID(ctx, stale.ImportPath); doc == nil || doc.Package.Parser != pkg.Parser {
+		t.Error("Expected the re-parsed package stored")
+	}
+	if loader.cacheHits.Load() != 1 {
+		t.Errorf("Expected a re-parse to count as a cache hit, got %d", loader.cacheHits.Load())
+	}
+
+	// Without its page a stale package cannot be re-parsed and has to be scraped again.
+	if current, _ := loader.currentParse(stale.ImportPath, &models.Document{ID: stale.ImportPath, Package: stale}); current {
+		t.Error("Expected a stale package without raw HTML not to be current")
+	}
+}
@@ -0,0 +1,173 @@
+package docinator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// lockFile is where docinator lock writes the lockfile and scrape --locked reads it by default.
+const lockFile = "docs.lock"
+
+// docsLock pins an output set: every package at the version pkg.go.dev served, the hashes of its
+// files and the time they say it was scraped, and the flags it was rendered with. Its only
+// timestamps are those in the locked files, so locking the same output twice writes the same file.
+type docsLock struct {
+	Flags    map[string][]string `json:"flags,omitempty"`
+	Packages []manifestPackage   `json:"packages"`
+}
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Record the versions and content hashes of the output directory in docs.lock",
+	Long: `Write a lockfile (default docs.lock) pinning the packages of the output
+directory, as its last scrape recorded them in ` + manifestFile + `: the exact
+version of every package, the SHA-256 of every file written for it, the scrape
+time those files show and the flags it was rendered with. Commit it, and have
+CI scrape with --locked, which scrapes exactly the locked versions with the
+locked flags, renders them with the locked scrape times and refuses to write
+any package whose files deviate:
+
+  docinator scrape -o docs github.com/spf13/cobra github.com/spf13/pflag
+  docinator lock -o docs
+  docinator scrape -o docs --locked
+
+Run docinator lock again after a deliberate change to accept the new output.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		path, _ := cmd.Flags().GetString("lockfile")
+		if outputDir == "" {
+			return withHint(errors.New("lock needs the output directory to lock"), "pass --output")
+		}
+		m, err := readManifest(filepath.Join(outputDir, manifestFile))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return withHint(fmt.Errorf("%s has no %s", outputDir, manifestFile), "scrape into it first")
+			}
+			return err
+		}
+		lock := newDocsLock(m)
+		if err := writeLock(path, lock); err != nil {
+			return fmt.Errorf("writing %s failed: %w", path, err)
+		}
+		log.Printf("Locked %d package(s) of %s in %s", len(lock.Packages), outputDir, path)
+		return nil
+	},
+}
+
+func init() {
+	lockCmd.Flags().String("lockfile", lockFile, "lockfile to write")
+}
+
+// newDocsLock pins the packages and flags recorded in m, sorted so the lockfile is stable.
+func newDocsLock(m *scrapeManifest) *docsLock {
+	lock := &docsLock{Flags: m.Flags, Packages: append([]manifestPackage(nil), m.Packages...)}
+	sort.Slice(lock.Packages, func(i, j int) bool { return lock.Packages[i].replayPath() < lock.Packages[j].replayPath() })
+	return lock
+}
+
+// readLock reads a lockfile written by docinator lock.
+func readLock(path string) (*docsLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock docsLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(lock.Packages) == 0 {
+		return nil, fmt.Errorf("%s locks no packages", path)
+	}
+	return &lock, nil
+}
+
+// writeLock writes lock to path.
+func writeLock(path string, lock *docsLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// pin returns the import paths args name, pinned to their locked versions, or those of every
+// locked package when args is empty. A package that is not locked, or asked for at another
+// version than the locked one, is an error.
+func (l *docsLock) pin(args []string) ([]string, error) {
+	var paths []string
+	if len(args) == 0 {
+		for _, p := range l.Packages {
+			paths = append(paths, p.replayPath())
+		}
+		return paths, nil
+	}
+	for _, arg := range args {
+		path, version, _ := strings.Cut(normalizeImportPath(arg), "@")
+		found := false
+		for _, p := range l.Packages {
+			if p.ImportPath == path && (version == "" || version == p.Version || version == p.Pinned) {
+				paths = append(paths, p.replayPath())
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not locked", arg)
+		}
+	}
+	return paths, nil
+}
+
+// unpinned reports whether the lock recorded importPath at version as scraped unpinned, so a
+// locked scrape pinned to that version writes it where the locked run did.
+func (l *docsLock) unpinned(importPath, version string) bool {
+	return recordedUnpinned(l.Packages, importPath, version)
+}
+
+// check returns how the rendered package r deviates from the lock, or nil when its version and
+// the files it renders in formats are the locked ones.
+func (l *docsLock) check(r renderedPackage, formats outputFormats) error {
+	got := newManifestPackage(r, formats)
+	var versions []string
+	for _, p := range l.Packages {
+		if p.ImportPath != got.ImportPath {
+			continue
+		}
+		if p.Version == got.Version {
+			return p.deviation(got)
+		}
+		versions = append(versions, p.Version)
+	}
+	if len(versions) == 0 {
+		return errors.New("not in the lockfile")
+	}
+	return fmt.Errorf("served version %s, locked at %s", got.Version, strings.Join(versions, ", "))
+}
+
+// deviation returns how got differs from the locked p, or nil when its markdown and every file
+// are the locked ones.
+func (p manifestPackage) deviation(got manifestPackage) error {
+	if p.SHA256 != got.SHA256 {
+		return fmt.Errorf("markdown deviates from the lockfile (sha256 %.12s, locked %.12s)", got.SHA256, p.SHA256)
+	}
+	for _, format := range formatOrder {
+		want, locked := p.Files[format]
+		switch sum, written := got.Files[format]; {
+		case written && !locked:
+			return fmt.Errorf("the lockfile has no %s file", format)
+		case locked && !written:
+			return fmt.Errorf("the %s file of the lockfile was not written", format)
+		case sum != want:
+			return fmt.Errorf("%s file deviates from the lockfile (sha256 %.12s, locked %.12s)", format, sum, want)
+		}
+	}
+	return nil
+}
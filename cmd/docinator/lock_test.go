@@ -0,0 +1,84 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestLockedScrape(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := memstore.New()
+	formats := outputFormats{formatMarkdown: true, formatHTML: true}
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra", "github.com/spf13/cobra@v1.8.0"}, OutputDir: dir, TestMode: true, Formats: formats}
+	if err := runScrape(ctx, opts, store, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runScrape failed: %v", err)
+	}
+	m, err := readManifest(filepath.Join(dir, manifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), lockFile)
+	if err := writeLock(path, newDocsLock(m)); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := readLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := lock.pin(nil)
+	if want := []string{"github.com/spf13/cobra@v1.8.0", "github.com/spf13/cobra@v1.9.1"}; err != nil || !reflect.DeepEqual(all, want) {
+		t.Fatalf("Expected every locked package at its version %v, got %v (%v)", want, all, err)
+	}
+	if _, err := lock.pin([]string{"github.com/spf13/cobra@v1.7.0"}); err == nil || !strings.Contains(err.Error(), "not locked") {
+		t.Errorf("Expected an unlocked version to be refused, got %v", err)
+	}
+
+	// Served from a cache that scraped them a day earlier, the packages still render the locked
+	// scrape time, so every file comes out byte for byte as locked.
+	err = store.ForEach(ctx, func(doc *models.Document) error {
+		doc.Package.ScrapedAt = doc.Package.ScrapedAt.Add(-24 * time.Hour)
+		return store.Upsert(ctx, doc)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockedDir := t.TempDir()
+	opts = scrapeOptions{ImportPaths: all, OutputDir: lockedDir, TestMode: true, Formats: formats, Lock: lock, LockPath: path}
+	if err := runScrape(ctx, opts, store, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Expected the locked scrape to reproduce the lock, got %v", err)
+	}
+	for _, file := range []string{"github.com/spf13/cobra.md", "github.com/spf13/cobra.html", "github.com/spf13/cobra/v1.8.0/cobra.md", "github.com/spf13/cobra/v1.8.0/cobra.html"} {
+		locked, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := os.ReadFile(filepath.Join(lockedDir, file)); err != nil || !bytes.Equal(got, locked) {
+			t.Errorf("Expected the locked scrape to write %s byte for byte as locked (%v)", file, err)
+		}
+	}
+
+	lock.Packages[0].Files[formatHTML] = strings.Repeat("0", 64)
+	deviatingDir := t.TempDir()
+	opts = scrapeOptions{ImportPaths: all, OutputDir: deviatingDir, TestMode: true, Formats: formats, Lock: lock, LockPath: path}
+	err = runScrape(ctx, opts, store, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 locked package(s) failed or deviate") {
+		t.Fatalf("Expected the package with a deviating HTML file to fail the run, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(deviatingDir, "github.com/spf13/cobra/v1.8.0/cobra.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected the deviating package not to be written, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(deviatingDir, "github.com/spf13/cobra.md")); err != nil {
+		t.Errorf("Expected the matching package to be written: %v", err)
+	}
+}
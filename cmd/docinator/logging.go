@@ -0,0 +1,44 @@
+package docinator
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/moseye/docinator/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// closeLogging is set by setupLogging and closes the log file, if any.
+var closeLogging = func() {}
+
+// verbosity returns the number of -v flags given.
+func verbosity() int {
+	n, _ := rootCmd.PersistentFlags().GetCount("verbose")
+	return n
+}
+
+// setupLogging sets the slog level from -v and redirects the standard logger (and with it
+// slog's default handler) to --log-file.
+func setupLogging(cmd *cobra.Command) error {
+	slog.SetLogLoggerLevel(logging.LevelForVerbosity(verbosity()))
+
+	path, _ := rootCmd.PersistentFlags().GetString("log-file")
+	if path == "" {
+		return nil
+	}
+	maxSize, _ := rootCmd.PersistentFlags().GetInt("log-max-size")
+	backups, _ := rootCmd.PersistentFlags().GetInt("log-backups")
+
+	file, err := logging.OpenRotating(path, int64(maxSize)<<20, backups)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	log.SetOutput(file)
+	closeLogging = func() {
+		log.SetOutput(os.Stderr)
+		file.Close()
+	}
+	return nil
+}
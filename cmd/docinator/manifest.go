@@ -0,0 +1,266 @@
+package docinator
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/moseye/docinator/pkg/parser"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// manifestFile describes the last scrape written to an output directory, so it can be replayed
+// with --from-manifest.
+const manifestFile = "docinator.manifest.json"
+
+// manifestSkipFlags are flags that do not change what a scrape produces, and are not replayed.
+var manifestSkipFlags = map[string]bool{
+	"output": true, "from-manifest": true, "verbose": true, "no-color": true, "log-file": true,
+	"log-max-size": true, "log-backups": true, "pprof": true, "cpuprofile": true, "memprofile": true,
+	"progress-json": true, "summary-json": true, "locked": true, "lockfile": true, "time-budget": true,
+	"schedule": true, "priority-tag": true,
+}
+
+// scrapeManifest records what a scrape was run with and what it wrote.
+type scrapeManifest struct {
+	DocinatorVersion string              `json:"docinator_version"`
+	CreatedAt        time.Time           `json:"created_at"`
+	Inputs           []string            `json:"inputs"`          // import paths as given, after normalization
+	Flags            map[string][]string `json:"flags,omitempty"` // flags set on the command line, by name
+	SelectorsVersion int                 `json:"selectors_version"`
+	SelectorsSHA256  string              `json:"selectors_sha256,omitempty"` // of the --selectors file; empty for the embedded profile
+	Packages         []manifestPackage   `json:"packages"`
+}
+
+// manifestPackage is one package a scrape wrote.
+type manifestPackage struct {
+	ImportPath string            `json:"import_path"`
+	Pinned     string            `json:"pinned,omitempty"`  // version requested with path@version
+	Version    string            `json:"version,omitempty"` // version pkg.go.dev served
+	Module     string            `json:"module,omitempty"`
+	ScrapedAt  time.Time         `json:"scraped_at"`      // as rendered into the package's files
+	SHA256     string            `json:"sha256"`          // of the package's markdown
+	Files      map[string]string `json:"files,omitempty"` // SHA-256 of every file written, by format
+}
+
+// newManifestPackage records a package written in formats.
+func newManifestPackage(r renderedPackage, formats outputFormats) manifestPackage {
+	p := manifestPackage{
+		ImportPath: r.pkg.ImportPath,
+		Pinned:     r.version,
+		Version:    r.pkg.Version,
+		Module:     r.pkg.Module,
+		ScrapedAt:  r.pkg.ScrapedAt,
+		SHA256:     sha256Hex(r.markdown),
+		Files:      map[string]string{},
+	}
+	contents := r.contents()
+	for _, format := range formats.names() {
+		p.Files[format] = sha256Hex(contents[format])
+	}
+	return p
+}
+
+// sha256Hex returns the hex SHA-256 of s.
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// replayPath returns the import path that scrapes exactly what p recorded: pinned to the version
+// pkg.go.dev served, even when the original run asked for the latest.
+func (p manifestPackage) replayPath() string {
+	if v := cmp.Or(p.Pinned, p.Version); v != "" {
+		return p.ImportPath + "@" + v
+	}
+	return p.ImportPath
+}
+
+// unpinned reports whether m recorded importPath at version as scraped unpinned, so a replay
+// pinned to that version writes it where the original run did.
+func (m *scrapeManifest) unpinned(importPath, version string) bool {
+	return recordedUnpinned(m.Packages, importPath, version)
+}
+
+// recordedUnpinned reports whether pkgs hold importPath at version as scraped unpinned.
+func recordedUnpinned(pkgs []manifestPackage, importPath, version string) bool {
+	return slices.ContainsFunc(pkgs, func(p manifestPackage) bool {
+		return p.ImportPath == importPath && p.Pinned == "" && p.Version == version
+	})
+}
+
+// docinatorVersion returns the module version of the running binary, with the VCS revision it
+// was built from when known.
+func docinatorVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			version += " " + s.Value
+		}
+	}
+	return version
+}
+
+// selectorsIdentity returns the format version and content hash of the selector profile at path,
+// or the embedded profile's version and no hash when path is empty.
+func selectorsIdentity(path string) (int, string, error) {
+	if path == "" {
+		return parser.SelectorsVersion, "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", err
+	}
+	s, err := parser.ParseSelectors(data)
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return s.Version, hex.EncodeToString(sum[:]), nil
+}
+
+// changedFlags returns the flags set on the command line that affect the output, for the manifest.
+func changedFlags(cmd *cobra.Command) map[string][]string {
+	flags := map[string][]string{}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if manifestSkipFlags[f.Name] {
+			return
+		}
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			flags[f.Name] = s.GetSlice()
+		} else {
+			flags[f.Name] = []string{f.Value.String()}
+		}
+	})
+	return flags
+}
+
+// applyManifestFlags sets the recorded flags that were not given on the command line, so a replay
+// renders with the options of the original run.
+func applyManifestFlags(cmd *cobra.Command, recorded map[string][]string) error {
+	names := make([]string, 0, len(recorded))
+	for name := range recorded {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		f := cmd.Flags().Lookup(name)
+		if f == nil {
+			log.Printf("Manifest flag --%s is unknown to this docinator; ignoring it", name)
+			continue
+		}
+		if f.Changed || manifestSkipFlags[name] {
+			continue
+		}
+		values := recorded[name]
+		var err error
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			err = s.Replace(values)
+			f.Changed = true
+		} else if len(values) > 0 {
+			err = cmd.Flags().Set(name, values[0])
+		}
+		if err != nil {
+			return fmt.Errorf("manifest flag --%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readManifest reads a manifest written by a previous scrape.
+func readManifest(path string) (*scrapeManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m scrapeManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(m.Packages) == 0 {
+		return nil, fmt.Errorf("%s lists no packages", path)
+	}
+	return &m, nil
+}
+
+// writeManifest writes m to outputDir.
+func writeManifest(outputDir string, m *scrapeManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, manifestFile), append(data, '\n'), 0644)
+}
+
+// stampScrapedAt passes the loaded packages on with the scrape time pkgs, as a manifest or lock
+// recorded them, give their version, so a package renders into the very bytes that were recorded
+// rather than with a new "Scraped at" time.
+func stampScrapedAt(ctx context.Context, in <-chan loadResult, pkgs []manifestPackage) <-chan loadResult {
+	out := make(chan loadResult, pipelineBuffer)
+	go func() {
+		defer close(out)
+		for res := range in {
+			if res.pkg != nil {
+				i := slices.IndexFunc(pkgs, func(p manifestPackage) bool {
+					return p.ImportPath == res.pkg.ImportPath && p.Version == res.pkg.Version && !p.ScrapedAt.IsZero()
+				})
+				if i >= 0 {
+					pkg := *res.pkg
+					pkg.ScrapedAt = pkgs[i].ScrapedAt
+					res.pkg = &pkg
+				}
+			}
+			select {
+			case out <- res:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// compareManifest logs how a replay's packages compare with those recorded in the replayed
+// manifest and returns the import paths whose markdown differs.
+func compareManifest(replayed, written *scrapeManifest) []string {
+	want := map[string]string{}
+	for _, p := range replayed.Packages {
+		want[p.replayPath()] = p.SHA256
+	}
+	var differ []string
+	reproduced := 0
+	for _, p := range written.Packages {
+		sum, ok := want[p.replayPath()]
+		switch {
+		case ok && sum == p.SHA256:
+			reproduced++
+		case ok:
+			differ = append(differ, p.replayPath())
+		}
+	}
+	if replayed.SelectorsSHA256 != written.SelectorsSHA256 || replayed.SelectorsVersion != written.SelectorsVersion {
+		log.Printf("WARNING: the selector profile differs from the manifest's")
+	}
+	if replayed.DocinatorVersion != written.DocinatorVersion {
+		log.Printf("WARNING: the manifest was written by docinator %s, this is %s", replayed.DocinatorVersion, written.DocinatorVersion)
+	}
+	log.Printf("Reproduced %d of %d package(s) of the manifest", reproduced, len(replayed.Packages))
+	if len(differ) > 0 {
+		log.Printf("WARNING: %d package(s) differ from the manifest: %v", len(differ), differ)
+	}
+	return differ
+}
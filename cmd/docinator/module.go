@@ -0,0 +1,73 @@
+package docinator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var moduleCmd = &cobra.Command{
+	Use:   "module <module>@<version>",
+	Short: "Show every cached package of a module version",
+	Long: `Read every cached package of a module version — the module root and its
+subpackages — in one go and list them with their synopsis and API size.
+--json prints the whole module document instead: the index followed by every
+package, as the serve API answers GET /modules/<module>@<version>.
+
+  docinator module github.com/spf13/cobra@v1.9.1
+  docinator module github.com/spf13/cobra@v1.9.1 --json > cobra.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		module, version, ok := strings.Cut(args[0], "@")
+		if !ok || module == "" || version == "" {
+			return fmt.Errorf("module needs a version: %s@<version>", module)
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+		store, closeStore, err := openStore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "module", "set MONGODB_URI or BOLT_PATH")
+		}
+		m, err := storage.GetModule(cmd.Context(), store, module, version)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return fmt.Errorf("no package of %s is cached", args[0])
+		}
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(m); err != nil {
+				return err
+			}
+			return nil
+		}
+		printModule(cmd.OutOrStdout(), m)
+		return nil
+	},
+}
+
+func init() {
+	moduleCmd.Flags().Bool("json", false, "print the module document, packages included, as JSON")
+}
+
+// printModule writes the index of m as a table.
+func printModule(out io.Writer, m *models.ModuleDocument) {
+	fmt.Fprintf(out, "%s: %d package(s), last scraped %s\n\n", m.ID, len(m.Index), m.ScrapedAt.Format("2006-01-02 15:04"))
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tFUNCS\tTYPES\tSYNOPSIS")
+	for _, p := range m.Index {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", p.ImportPath, p.Functions, p.Types, p.Synopsis)
+	}
+	w.Flush()
+}
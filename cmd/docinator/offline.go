@@ -0,0 +1,286 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	boltstore "github.com/moseye/docinator/internal/storage/bolt"
+	"github.com/moseye/docinator/pkg/search"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/moseye/docinator/pkg/symdoc"
+	"github.com/spf13/cobra"
+)
+
+// bundleFormat is the version of the offline bundle layout; install refuses other versions.
+const bundleFormat = 1
+
+// Meta keys of an offline bundle.
+const (
+	bundleManifestKey = "bundle.manifest"
+	bundleSymbolsKey  = "bundle.symbols" // the prebuilt symbol index of sym, as JSON
+)
+
+// bundleManifest describes an offline bundle.
+type bundleManifest struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Packages  int       `json:"packages"`
+	Symbols   int       `json:"symbols"`
+}
+
+var bundleExportCmd = &cobra.Command{
+	Use:   "export <file> [import paths or modules...]",
+	Short: "Write the cached corpus and its search index to one file for offline use",
+	Long: `Copy the cached packages, or those below the given import or module paths,
+into a single bundle file together with a prebuilt symbol index. Copy the file
+to a machine without network access and query it there with --bundle, or
+install it with "docinator bundle install":
+
+  docinator bundle export corpus.docinator --tag approved
+  docinator sym --bundle corpus.docinator NewRequest
+  docinator snippet --bundle corpus.docinator net/http.Client
+
+Raw HTML is left out; everything else a package holds is kept.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "bundle export", "set MONGODB_URI or BOLT_PATH")
+		}
+		m, err := exportBundle(ctx, store, args[0], args[1:], tagFilter(cmd), time.Now())
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d package(s) and %d indexed symbol(s) to %s\n", m.Packages, m.Symbols, args[0])
+		return nil
+	},
+}
+
+var bundleInstallCmd = &cobra.Command{
+	Use:   "install <file>",
+	Short: "Install an offline bundle as the default for sym and snippet",
+	Long: `Check an offline bundle written by "docinator bundle export" and copy it to
+docinator/bundle.db in the user configuration directory, or to
+DOCINATOR_BUNDLE when set. sym and snippet then read it whenever no store is
+configured, so lookups work without --bundle, a store or network access.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dest, err := installedBundlePath()
+		if err != nil {
+			return err
+		}
+		m, err := installBundle(args[0], dest)
+		if err != nil {
+			return fmt.Errorf("install failed: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Installed %d package(s) exported %s to %s\n", m.Packages, m.CreatedAt.Format("2006-01-02"), dest)
+		return nil
+	},
+}
+
+func init() {
+	bundleExportCmd.Flags().StringSlice("tag", nil, "export only packages carrying all of these tags (see docinator tag)")
+	bundleCmd.AddCommand(bundleExportCmd)
+	bundleCmd.AddCommand(bundleInstallCmd)
+}
+
+// exportBundle writes the documents of store selected by filters and tags, with their symbol index,
+// to a bundle at path. The file is written beside path and renamed into place once complete.
+func exportBundle(ctx context.Context, store storage.Store, path string, filters, tags []string, now time.Time) (bundleManifest, error) {
+	m := bundleManifest{Format: bundleFormat, CreatedAt: now.UTC()}
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	bundle, err := boltstore.Open(tmp)
+	if err != nil {
+		return m, err
+	}
+	defer os.Remove(tmp)
+	var symbols []search.Symbol
+	err = store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package == nil || !selected(doc.Package, filters) || !hasTags(doc.Tags, tags) {
+			return nil
+		}
+		doc.RawHTML = ""
+		if err := bundle.Upsert(ctx, doc); err != nil {
+			return fmt.Errorf("%s: %w", doc.ID, err)
+		}
+		m.Packages++
+		symbols = append(symbols, search.PackageSymbols(doc.Package)...)
+		return nil
+	})
+	if err == nil && m.Packages == 0 {
+		err = errors.New("no cached package matches")
+	}
+	if err == nil {
+		m.Symbols = len(symbols)
+		err = putBundleMeta(ctx, bundle, m, symbols)
+	}
+	if closeErr := bundle.Close(ctx); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return m, err
+	}
+	return m, os.Rename(tmp, path)
+}
+
+func putBundleMeta(ctx context.Context, bundle *boltstore.Store, m bundleManifest, symbols []search.Symbol) error {
+	data, err := json.Marshal(symbols)
+	if err != nil {
+		return err
+	}
+	if err := bundle.PutMeta(ctx, bundleSymbolsKey, data); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(m); err != nil {
+		return err
+	}
+	return bundle.PutMeta(ctx, bundleManifestKey, data)
+}
+
+// openBundle opens the bundle at path read-only and returns it with its manifest.
+func openBundle(path string) (*boltstore.Store, bundleManifest, error) {
+	var m bundleManifest
+	bundle, err := boltstore.OpenReadOnly(path)
+	if err != nil {
+		return nil, m, err
+	}
+	data, err := bundle.Meta(context.Background(), bundleManifestKey)
+	if err == nil && data == nil {
+		err = fmt.Errorf("%s is not a docinator bundle", path)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &m)
+	}
+	if err == nil && m.Format != bundleFormat {
+		err = fmt.Errorf("%s has bundle format %d; this docinator reads format %d", path, m.Format, bundleFormat)
+	}
+	if err != nil {
+		bundle.Close(context.Background())
+		return nil, m, err
+	}
+	return bundle, m, nil
+}
+
+// bundleSymbols returns the prebuilt symbol index of bundle, keeping the packages carrying all of
+// tags when tags are given, which the index does not record.
+func bundleSymbols(ctx context.Context, bundle *boltstore.Store, tags []string) ([]search.Symbol, error) {
+	if len(tags) > 0 {
+		return corpusSymbols(ctx, bundle, tags)
+	}
+	data, err := bundle.Meta(ctx, bundleSymbolsKey)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []search.Symbol
+	return symbols, json.Unmarshal(data, &symbols)
+}
+
+// bundleSnippet returns symbol of the package at importPath in bundle, from the symbol index
+// when it holds it.
+func bundleSnippet(ctx context.Context, bundle *boltstore.Store, importPath, symbol string) (*symdoc.Snippet, error) {
+	if s, err := bundle.GetSymbol(ctx, importPath, symbol); err != nil || s != nil {
+		return s, err
+	}
+	doc, err := bundle.GetByID(ctx, importPath)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.Package == nil {
+		return nil, fmt.Errorf("%s is not in the bundle", importPath)
+	}
+	return symdoc.Find(doc.Package, symbol)
+}
+
+// installBundle checks the bundle at src and copies it to dest.
+func installBundle(src, dest string) (bundleManifest, error) {
+	bundle, m, err := openBundle(src)
+	if err != nil {
+		return m, err
+	}
+	bundle.Close(context.Background())
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return m, err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return m, err
+	}
+	defer in.Close()
+	tmp := dest + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return m, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return m, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return m, err
+	}
+	return m, os.Rename(tmp, dest)
+}
+
+// installedBundlePath returns where bundle install puts a bundle: DOCINATOR_BUNDLE, or
+// docinator/bundle.db in the user configuration directory.
+func installedBundlePath() (string, error) {
+	if path := os.Getenv("DOCINATOR_BUNDLE"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "docinator", "bundle.db"), nil
+}
+
+// lookupBundle opens the bundle a lookup command reads instead of the store: --bundle when given,
+// otherwise the installed bundle when no store is configured and one is installed. It returns nil
+// to use the store.
+func lookupBundle(cmd *cobra.Command) (*boltstore.Store, error) {
+	path, _ := cmd.Flags().GetString("bundle")
+	if path == "" {
+		if storeConfigured() {
+			return nil, nil
+		}
+		installed, err := installedBundlePath()
+		if err != nil {
+			return nil, nil
+		}
+		if _, err := os.Stat(installed); err != nil {
+			return nil, nil
+		}
+		path = installed
+	}
+	bundle, _, err := openBundle(path)
+	if err != nil {
+		return nil, fmt.Errorf("--bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+// storeConfigured reports whether --store, or for auto the environment, selects a store.
+func storeConfigured() bool {
+	switch backend, _ := rootCmd.PersistentFlags().GetString("store"); backend {
+	case "auto":
+		return os.Getenv("MONGODB_URI") != "" || os.Getenv("BOLT_PATH") != ""
+	case "none":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package docinator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestBundleExportInstall(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	for _, doc := range []*models.Document{
+		{ID: "example.com/cli", Tags: []string{"approved"}, RawHTML: "<html></html>", Package: &models.Package{
+			ImportPath: "example.com/cli", Module: "example.com/cli",
+			Functions: []models.Function{{Name: "NewCommand", Signature: "func NewCommand() *Command", Description: "NewCommand returns a command."}},
+			Types:     []models.Type{{Name: "Command", Definition: "type Command struct{}"}},
+		}},
+		{ID: "example.com/other", Package: &models.Package{ImportPath: "example.com/other", Functions: []models.Function{{Name: "Other"}}}},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "corpus.docinator")
+	if _, err := exportBundle(ctx, store, path, nil, []string{"missing"}, time.Now()); err == nil {
+		t.Error("Expected an export matching nothing to fail")
+	}
+	m, err := exportBundle(ctx, store, path, nil, []string{"approved"}, time.Now())
+	if err != nil {
+		t.Fatalf("exportBundle failed: %v", err)
+	}
+	if m.Packages != 1 || m.Symbols != 2 {
+		t.Errorf("Expected 1 package with 2 symbols, got %+v", m)
+	}
+
+	dest := filepath.Join(dir, "installed", "bundle.db")
+	if _, err := installBundle(path, dest); err != nil {
+		t.Fatalf("installBundle failed: %v", err)
+	}
+	bundle, _, err := openBundle(dest)
+	if err != nil {
+		t.Fatalf("openBundle failed: %v", err)
+	}
+	defer bundle.Close(ctx)
+	symbols, err := bundleSymbols(ctx, bundle, nil)
+	if err != nil || len(symbols) != 2 || symbols[0].ImportPath != "example.com/cli" {
+		t.Errorf("Expected the prebuilt symbol index, got %v (%v)", symbols, err)
+	}
+	s, err := bundleSnippet(ctx, bundle, "example.com/cli", "NewCommand")
+	if err != nil || s.Description != "NewCommand returns a command." {
+		t.Errorf("Expected the snippet from the bundle, got %+v (%v)", s, err)
+	}
+	if _, err := bundleSnippet(ctx, bundle, "example.com/other", "Other"); err == nil {
+		t.Error("Expected a package left out of the bundle to be missing")
+	}
+	if doc, _ := bundle.GetByID(ctx, "example.com/cli"); doc == nil || doc.RawHTML != "" {
+		t.Error("Expected the package without its raw HTML")
+	}
+
+	notBundle := filepath.Join(dir, "notes.txt")
+	os.WriteFile(notBundle, []byte("hello"), 0644)
+	if _, err := installBundle(notBundle, filepath.Join(dir, "x.db")); err == nil {
+		t.Error("Expected a file that is not a bundle to be rejected")
+	}
+}
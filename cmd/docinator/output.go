@@ -0,0 +1,393 @@
+package docinator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+	"github.com/moseye/docinator/pkg/archive"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/postprocess"
+	"github.com/moseye/docinator/pkg/raw"
+	"github.com/moseye/docinator/pkg/site"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// latestLink names the pointer to the newest version directory of a pinned package.
+const latestLink = "latest"
+
+// Output formats accepted by scrape --format.
+const (
+	formatMarkdown = "md"
+	formatRaw      = "raw"
+	formatJSON     = "json"
+	formatHTML     = "html"
+)
+
+// outputFormats is the set of files written per package.
+type outputFormats map[string]bool
+
+// defaultFormats are written when --format is not given, and by watch.
+var defaultFormats = outputFormats{formatMarkdown: true, formatRaw: true}
+
+// formatSuffixes maps each format to the suffix replacing ".md" in the package's output page.
+var formatSuffixes = map[string]string{
+	formatMarkdown: ".md",
+	formatRaw:      "_raw.txt",
+	formatJSON:     ".json",
+	formatHTML:     ".html",
+}
+
+// parseFormats parses --format values such as "md,json,html".
+func parseFormats(names []string) (outputFormats, error) {
+	formats := outputFormats{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := formatSuffixes[name]; !ok {
+			return nil, fmt.Errorf("unknown format %q; use md, raw, json or html", name)
+		}
+		formats[name] = true
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no output format given; use md, raw, json or html")
+	}
+	return formats, nil
+}
+
+// formatOrder is the order formats are written in.
+var formatOrder = []string{formatMarkdown, formatRaw, formatJSON, formatHTML}
+
+// names lists the formats in the order they are written.
+func (f outputFormats) names() []string {
+	var names []string
+	for _, format := range formatOrder {
+		if f[format] {
+			names = append(names, format)
+		}
+	}
+	return names
+}
+
+// addRenderFlags adds the markdown renderer flags shared by scrape and render.
+func addRenderFlags(cmd *cobra.Command) {
+	cmd.Flags().String("include-symbols", "", "render only symbols whose name matches this regexp, e.g. '^New' (methods match as Type.Method)")
+	cmd.Flags().String("exclude-symbols", "", "leave out symbols whose name matches this regexp")
+	cmd.Flags().Bool("skip-deprecated", false, "leave out deprecated symbols")
+	cmd.Flags().StringSlice("kinds", nil, "render only these symbol kinds: const, var, func, type (default all)")
+	cmd.Flags().Bool("no-readme", false, "leave the README out of the markdown")
+	cmd.Flags().Bool("no-examples", false, "leave all examples out of the markdown")
+	cmd.Flags().Bool("examples-only", false, "render only the examples, grouped by symbol, without the reference sections")
+	cmd.Flags().Bool("no-metadata", false, "leave out the metadata block (import path, version, license, repository, ...)")
+	cmd.Flags().Bool("no-index", false, "leave out the symbol index")
+	cmd.Flags().Int("index-descriptions", 0, "follow each index entry with its description, cut at a word boundary to at most N characters (0 leaves them out)")
+	cmd.Flags().Bool("collapse", false, "fold examples, long READMEs and large constant blocks into <details> elements")
+	cmd.Flags().Bool("strip-badges", false, "drop CI, coverage and other badge images from READMEs")
+	cmd.Flags().StringArray("badge-pattern", nil, "also treat images whose URL matches this regexp as badges (repeatable)")
+	cmd.Flags().Bool("emoji", false, "expand GitHub emoji shortcodes such as :rocket: in READMEs and guides")
+	cmd.Flags().Bool("permalinks", false, "end every function, type and method section with a link to it on pkg.go.dev, at the rendered version")
+	cmd.Flags().Bool("link-types", false, "render signatures and type definitions as HTML with their types linked: the package's own to their sections, other cached packages' to pkg.go.dev")
+	cmd.Flags().Int("heading-offset", 0, "shift every markdown heading down N levels (0-5) to embed the output below a host document's headings")
+	cmd.Flags().String("post-process", "", "YAML file of post-processors run over the rendered markdown, e.g. footer, rewrite-urls, word-filter, link-check")
+	cmd.Flags().String("header-file", "", "put this template above every rendered markdown page; package fields as {{.ImportPath}}, {{.Version}}, ...")
+	cmd.Flags().String("footer-file", "", "put this template below every rendered markdown page, like --header-file")
+}
+
+// postProcessors loads the --post-process chain followed by the --header-file and --footer-file
+// banner, or returns nil without any of them.
+func postProcessors(cmd *cobra.Command) (postprocess.Chain, error) {
+	path, _ := cmd.Flags().GetString("post-process")
+	headerPath, _ := cmd.Flags().GetString("header-file")
+	footerPath, _ := cmd.Flags().GetString("footer-file")
+	var chain postprocess.Chain
+	if path != "" {
+		var err error
+		if chain, err = postprocess.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if headerPath != "" || footerPath != "" {
+		banner, err := postprocess.LoadBanner(headerPath, footerPath)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, banner)
+	}
+	return chain, nil
+}
+
+// renderOptions reads the flags of addRenderFlags into markdown options.
+func renderOptions(cmd *cobra.Command) (markdown.Options, error) {
+	var opts markdown.Options
+	opts.HeadingOffset, _ = cmd.Flags().GetInt("heading-offset")
+	if opts.HeadingOffset < 0 || opts.HeadingOffset > 5 {
+		return opts, fmt.Errorf("--heading-offset must be between 0 and 5, got %d", opts.HeadingOffset)
+	}
+	if err := symbolFilterFlags(cmd, &opts); err != nil {
+		return opts, err
+	}
+	sectionFlags(cmd, &opts)
+	if opts.ExamplesOnly && opts.NoExamples {
+		return opts, errors.New("--examples-only and --no-examples exclude each other")
+	}
+	opts.StripBadges, _ = cmd.Flags().GetBool("strip-badges")
+	opts.ExpandEmoji, _ = cmd.Flags().GetBool("emoji")
+	opts.Permalinks, _ = cmd.Flags().GetBool("permalinks")
+	opts.LinkTypes, _ = cmd.Flags().GetBool("link-types")
+	opts.IndexDescriptions, _ = cmd.Flags().GetInt("index-descriptions")
+	if opts.IndexDescriptions < 0 {
+		return opts, fmt.Errorf("--index-descriptions must be at least 0, got %d", opts.IndexDescriptions)
+	}
+	var err error
+	opts.BadgePatterns, err = badgePatterns(cmd)
+	return opts, err
+}
+
+// symbolFilterFlags reads --include-symbols, --exclude-symbols, --skip-deprecated and --kinds into opts.
+func symbolFilterFlags(cmd *cobra.Command, opts *markdown.Options) error {
+	for flag, re := range map[string]**regexp.Regexp{"include-symbols": &opts.IncludeSymbols, "exclude-symbols": &opts.ExcludeSymbols} {
+		expr, _ := cmd.Flags().GetString(flag)
+		if expr == "" {
+			continue
+		}
+		compiled, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", flag, err)
+		}
+		*re = compiled
+	}
+	opts.SkipDeprecated, _ = cmd.Flags().GetBool("skip-deprecated")
+	kinds, _ := cmd.Flags().GetStringSlice("kinds")
+	var err error
+	if opts.Kinds, err = markdown.ParseKinds(kinds); err != nil {
+		return fmt.Errorf("--kinds: %w", err)
+	}
+	return nil
+}
+
+// sectionFlags reads --no-readme, --no-examples, --examples-only, --no-metadata, --no-index and
+// --collapse into opts.
+func sectionFlags(cmd *cobra.Command, opts *markdown.Options) {
+	opts.NoReadme, _ = cmd.Flags().GetBool("no-readme")
+	opts.NoExamples, _ = cmd.Flags().GetBool("no-examples")
+	opts.ExamplesOnly, _ = cmd.Flags().GetBool("examples-only")
+	opts.NoMetadata, _ = cmd.Flags().GetBool("no-metadata")
+	opts.NoIndex, _ = cmd.Flags().GetBool("no-index")
+	opts.Collapse, _ = cmd.Flags().GetBool("collapse")
+}
+
+// badgePatterns compiles the --badge-pattern regexps, added to the default badge patterns. It
+// returns nil, meaning the defaults, when none were given.
+func badgePatterns(cmd *cobra.Command) ([]*regexp.Regexp, error) {
+	exprs, _ := cmd.Flags().GetStringArray("badge-pattern")
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	patterns := slices.Clone(utils.DefaultBadgePatterns)
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("--badge-pattern: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// renderFormats renders pkg in every format but markdown, which the caller always has; the HTML
+// page is built from that markdown rather than rendering it again.
+func renderFormats(r *renderedPackage, rawHTML string, formats outputFormats) error {
+	if formats[formatRaw] {
+		r.raw = raw.PackageToRaw(r.pkg, rawHTML)
+	}
+	if formats[formatJSON] {
+		data, err := json.MarshalIndent(r.pkg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("render %s as JSON: %w", r.pkg.ImportPath, err)
+		}
+		r.json = string(data) + "\n"
+	}
+	if formats[formatHTML] {
+		data, err := site.RenderedPackageHTML(r.pkg, r.markdown)
+		if err != nil {
+			return fmt.Errorf("render %s as HTML: %w", r.pkg.ImportPath, err)
+		}
+		r.html = string(data)
+	}
+	return nil
+}
+
+// writePackageFiles writes the markdown and raw versions of pkg below outputDir, logging failures.
+// version is the pinned version the package was requested at, or empty.
+func writePackageFiles(outputDir string, pkg *models.Package, rawHTML, version string, verbose bool) writeCounts {
+	r := renderedPackage{pkg: pkg, markdown: markdown.PackageToMarkdown(pkg), version: version}
+	renderFormats(&r, rawHTML, defaultFormats)
+	return writeRendered(outputDir, r, defaultFormats, verbose)
+}
+
+// writeCounts counts the output files written and the ones left alone because their content was
+// already there.
+type writeCounts struct {
+	Changed   int
+	Unchanged int
+}
+
+func (c *writeCounts) add(o writeCounts) {
+	c.Changed += o.Changed
+	c.Unchanged += o.Unchanged
+}
+
+// writeRendered writes an already rendered package below outputDir in each of formats, logging
+// failures. Unpinned packages are written to <importPath>.md (and .json, .html, _raw.txt); pinned
+// versions to <importPath>/<version>/, keeping <importPath>/latest pointed at the highest version
+// written so far. Paths are encoded with utils.EncodePath; the search index maps them back to
+// import paths. Files that already hold the rendered content are not rewritten.
+func writeRendered(outputDir string, r renderedPackage, formats outputFormats, verbose bool) writeCounts {
+	var counts writeCounts
+	base := strings.TrimSuffix(filepath.Join(outputDir, filepath.FromSlash(outputPage(r))), ".md")
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		log.Printf("Failed to create output dir %s: %v", filepath.Dir(base), err)
+	}
+	contents := r.contents()
+	for _, format := range formats.names() {
+		filename := base + formatSuffixes[format]
+		changed, err := writeIfChanged(filename, []byte(contents[format]))
+		switch {
+		case err != nil:
+			log.Printf("Failed to write %s file %s: %v", format, filename, err)
+		case !changed:
+			counts.Unchanged++
+			if verbose {
+				log.Printf("Unchanged %s: %s", format, filename)
+			}
+		default:
+			counts.Changed++
+			if verbose {
+				log.Printf("Wrote %s: %s", format, filename)
+			}
+		}
+	}
+
+	if r.version != "" {
+		pkgDir := filepath.Join(outputDir, filepath.FromSlash(utils.EncodePath(r.pkg.ImportPath)))
+		if err := updateLatest(pkgDir, utils.EncodePath(r.version)); err != nil {
+			log.Printf("Failed to update %s: %v", filepath.Join(pkgDir, latestLink), err)
+		}
+	}
+	return counts
+}
+
+// contents maps every format to the package rendered in it, empty for formats not rendered.
+func (r renderedPackage) contents() map[string]string {
+	return map[string]string{formatMarkdown: r.markdown, formatRaw: r.raw, formatJSON: r.json, formatHTML: r.html}
+}
+
+// writeIfChanged writes data to filename unless the file already holds exactly that content, so
+// unchanged files keep their modification time and rsync or git see nothing to publish. It
+// reports whether the file was written.
+func writeIfChanged(filename string, data []byte) (bool, error) {
+	if info, err := os.Stat(filename); err == nil && info.Mode().IsRegular() && info.Size() == int64(len(data)) {
+		if f, err := os.Open(filename); err == nil {
+			h := sha256.New()
+			_, err := io.Copy(h, f)
+			f.Close()
+			if sum := sha256.Sum256(data); err == nil && bytes.Equal(h.Sum(nil), sum[:]) {
+				return false, nil
+			}
+		}
+	}
+	return true, os.WriteFile(filename, data, 0644)
+}
+
+// outputPage returns the slash-separated path of the package's markdown file relative to the
+// output directory, encoded to be safe on every filesystem (see utils.EncodePath).
+func outputPage(r renderedPackage) string {
+	if r.version == "" {
+		return utils.EncodePath(r.pkg.ImportPath) + ".md"
+	}
+	return utils.EncodePath(fmt.Sprintf("%s/%s/%s", r.pkg.ImportPath, r.version, path.Base(r.pkg.ImportPath))) + ".md"
+}
+
+// pinnedVersion returns the version of an "importPath@version" argument or cache ID, or "".
+func pinnedVersion(id string) string {
+	_, version, _ := strings.Cut(id, "@")
+	return version
+}
+
+// updateLatest points pkgDir/latest at the highest release directory in pkgDir, or the highest
+// prerelease or pseudo-version when there is no release, or at written when no directory holds a
+// semantic version. The pointer is a relative symlink, or a copy of the directory where symlinks
+// are unavailable (Windows without developer mode).
+func updateLatest(pkgDir, written string) error {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return err
+	}
+	target, targetVersion := "", ""
+	for _, e := range entries {
+		// Directory names escape upper case as the module cache does: v1.0.0-!r!c.1.
+		v, err := module.UnescapeVersion(e.Name())
+		if !e.IsDir() || err != nil || !semver.IsValid(v) {
+			continue
+		}
+		if target == "" || newerForLatest(v, targetVersion) {
+			target, targetVersion = e.Name(), v
+		}
+	}
+	if target == "" {
+		target = written
+	}
+
+	link := filepath.Join(pkgDir, latestLink)
+	if current, err := os.Readlink(link); err == nil && current == target {
+		return nil
+	}
+	if err := os.RemoveAll(link); err != nil {
+		return err
+	}
+	if err := os.Symlink(target, link); err == nil {
+		return nil
+	}
+	return os.CopyFS(link, os.DirFS(filepath.Join(pkgDir, target)))
+}
+
+// newerForLatest reports whether version v should replace current as the latest: releases win
+// over prereleases and pseudo-versions, and otherwise the higher version wins.
+func newerForLatest(v, current string) bool {
+	if release, currentRelease := semver.Prerelease(v) == "", semver.Prerelease(current) == ""; release != currentRelease {
+		return release
+	}
+	return semver.Compare(v, current) > 0
+}
+
+// writeArchive packs every file below outputDir into the archive at dest, with a manifest mapping
+// the import paths recorded in the directory's search index to their files.
+func writeArchive(outputDir, dest string) error {
+	entries, err := site.ReadSearchIndex(outputDir)
+	if err != nil {
+		return err
+	}
+	pkgs := make([]archive.Package, 0, len(entries))
+	for _, e := range entries {
+		pkgs = append(pkgs, archive.Package{ImportPath: e.ImportPath, Version: e.Version, Path: e.URL})
+	}
+	m, err := archive.Create(dest, outputDir, pkgs)
+	if err != nil {
+		return err
+	}
+	log.Printf("Archived %d files to %s", len(m.Files), dest)
+	return nil
+}
@@ -0,0 +1,57 @@
+package docinator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/moseye/docinator/pkg/pack"
+	"github.com/spf13/cobra"
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack [packages...]",
+	Short: "Assemble a token-budgeted documentation pack for LLM context",
+	Long: `Load (from cache) or scrape one or more Go packages and assemble a single
+markdown document that fits within an approximate token budget. Signatures and
+short descriptions are kept first; examples and READMEs are trimmed to fit.
+Packages are ordered by import path so the output is deterministic.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		budget, _ := cmd.Flags().GetInt("budget")
+		if budget <= 0 {
+			return fmt.Errorf("--budget must be positive, got %d", budget)
+		}
+
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		paths := append([]string{}, args...)
+		sort.Strings(paths)
+		pkgs, errs := loader.loadAll(cmd.Context(), paths)
+		for _, err := range errs {
+			log.Printf("Scraping error: %v", err)
+		}
+		if len(pkgs) == 0 {
+			return errors.New("all scraping attempts failed")
+		}
+
+		var opts pack.Options
+		opts.KeepBadges, _ = cmd.Flags().GetBool("keep-badges")
+		if opts.BadgePatterns, err = badgePatterns(cmd); err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), pack.BuildWithOptions(pkgs, budget, opts))
+		return nil
+	},
+}
+
+func init() {
+	packCmd.Flags().Int("budget", 32000, "approximate token budget for the whole pack")
+	packCmd.Flags().Bool("keep-badges", false, "keep CI, coverage and other badge images in READMEs")
+	packCmd.Flags().StringArray("badge-pattern", nil, "also treat images whose URL matches this regexp as badges (repeatable)")
+}
@@ -0,0 +1,140 @@
+package docinator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/parser"
+	"github.com/spf13/cobra"
+)
+
+var parseCmd = &cobra.Command{
+	Use:   "parse [file]",
+	Short: "Run the parser over a saved page or pasted HTML and print what it extracts",
+	Long: `Parse a pkg.go.dev page, or any part of one, with the current selector
+profile (see --selectors) and print the extracted package as JSON, without
+fetching anything. Read the HTML from a file, or with --stdin from standard
+input, to paste a snippet copied from the browser's inspector. --field keeps
+only the named fields, by their JSON or cache name in any case:
+
+  pbpaste | docinator parse --stdin --field functions
+  docinator parse page.html --field name,version --selectors selectors.yaml --explain
+
+A single field prints its value alone. --explain lists on stderr which
+selector of each chain matched, to check an edited profile picks up the
+intended element.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stdin, _ := cmd.Flags().GetBool("stdin")
+		fields, _ := cmd.Flags().GetStringSlice("field")
+		explain, _ := cmd.Flags().GetBool("explain")
+		if stdin == (len(args) == 1) {
+			return errors.New("parse needs a file or --stdin, not both")
+		}
+		var r io.Reader = cmd.InOrStdin()
+		if !stdin {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		selectors, err := loadSelectors()
+		if err != nil {
+			return fmt.Errorf("--selectors: %w", err)
+		}
+		pkg, extraction, err := parser.NewWithSelectors(selectors).ParseHTMLWithExtraction(r)
+		if err != nil {
+			return fmt.Errorf("parse failed: %w", err)
+		}
+		out, err := packageFields(pkg, fields)
+		if err != nil {
+			return fmt.Errorf("--field: %w", err)
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+		if explain {
+			printExtraction(cmd.ErrOrStderr(), extraction)
+		}
+		return nil
+	},
+}
+
+func init() {
+	parseCmd.Flags().Bool("stdin", false, "read the HTML from standard input")
+	parseCmd.Flags().StringSlice("field", nil, "comma-separated fields to print, e.g. functions or name,version (default the whole package)")
+	parseCmd.Flags().Bool("explain", false, "list the selector that matched for each profile key on stderr")
+}
+
+// packageFields returns pkg as JSON, or only the named fields of it: the value of a single field
+// on its own, several as an object keyed by their JSON names. Names match the JSON name ("Functions")
+// or cache name ("processed_readme") of a field in any case.
+func packageFields(pkg *models.Package, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return pkg, nil
+	}
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	byKey := map[string]string{}
+	for name := range all {
+		byKey[fieldKey(name)] = name
+	}
+	out := map[string]json.RawMessage{}
+	for _, f := range fields {
+		name, ok := byKey[fieldKey(f)]
+		if !ok {
+			names := make([]string, 0, len(all))
+			for name := range all {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return nil, fmt.Errorf("no field %q; fields are %s", f, strings.Join(names, ", "))
+		}
+		out[name] = all[name]
+	}
+	if len(fields) == 1 {
+		for _, v := range out {
+			return v, nil
+		}
+	}
+	return out, nil
+}
+
+// fieldKey folds a field name for matching: "ProcessedReadme", "processed_readme" and
+// "processedreadme" are the same field.
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", ""))
+}
+
+// printExtraction writes the winning selector of each profile key, sorted by key, marking fallbacks.
+func printExtraction(w io.Writer, extraction parser.Extraction) {
+	keys := make([]string, 0, len(extraction))
+	for key := range extraction {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		st := extraction[key]
+		if st.Index > 0 {
+			fmt.Fprintf(w, "%s: %s (fallback %d)\n", key, st.Selector, st.Index)
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", key, st.Selector)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package docinator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/pkg/parser"
+)
+
+func TestPackageFields(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "pkg", "parser", "testdata", "widget.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pkg, extraction, err := parser.NewWithSelectors(nil).ParseHTMLWithExtraction(f)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, ok := extraction["name"]; !ok {
+		t.Errorf("Expected the name strategy recorded, got %v", extraction)
+	}
+
+	out, err := packageFields(pkg, []string{"functions"})
+	if err != nil {
+		t.Fatalf("packageFields failed: %v", err)
+	}
+	data, _ := json.Marshal(out)
+	if !strings.HasPrefix(string(data), `[{"Name":"Turn"`) {
+		t.Errorf("Expected the functions alone, got %s", data)
+	}
+
+	out, err = packageFields(pkg, []string{"name", "imported_by"})
+	if err != nil {
+		t.Fatalf("packageFields failed: %v", err)
+	}
+	if data, _ = json.Marshal(out); string(data) != `{"ImportedBy":1204,"Name":"gear"}` {
+		t.Errorf("Unexpected fields %s", data)
+	}
+
+	if _, err := packageFields(pkg, []string{"nope"}); err == nil || !strings.Contains(err.Error(), "Functions") {
+		t.Errorf("Expected an unknown field listing the fields, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package docinator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/postprocess"
+)
+
+// pipelineBuffer bounds how many packages may wait between two pipeline stages.
+const pipelineBuffer = 4
+
+// defaultMaxPending is the default of --max-pending.
+const defaultMaxPending = 8
+
+// Values of scrape --order.
+const (
+	orderInput      = "input"
+	orderCompletion = "completion"
+)
+
+// payloadBudget bounds how many loaded packages, raw HTML included, are held between fetching and
+// writing: fetching the next package waits until a written or failed one releases its slot. A nil
+// budget is unbounded.
+type payloadBudget chan struct{}
+
+// newPayloadBudget returns a budget of n packages; n <= 0 returns nil.
+func newPayloadBudget(n int) payloadBudget {
+	if n <= 0 {
+		return nil
+	}
+	return make(payloadBudget, n)
+}
+
+// acquire takes a slot, waiting for one to be released. It reports false if ctx is done first.
+func (b payloadBudget) acquire(ctx context.Context) bool {
+	if b == nil {
+		return true
+	}
+	select {
+	case b <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release returns a slot taken by acquire.
+func (b payloadBudget) release() {
+	if b != nil {
+		<-b
+	}
+}
+
+// loadResult is one import path after the fetch/parse stage.
+type loadResult struct {
+	importPath string
+	pkg        *models.Package
+	rawHTML    string
+	timing     packageTiming
+	err        error
+	budget     payloadBudget // released once the package is written or dropped
+}
+
+// renderedPackage is a loaded package after the render stage.
+type renderedPackage struct {
+	pkg        *models.Package
+	importPath string // as requested, path@version for a pinned version; empty outside scrape batches
+	markdown   string
+	raw        string // empty unless raw output was requested
+	json       string // empty unless JSON output was requested
+	html       string // empty unless HTML output was requested
+	version    string // version pinned as importPath@version; empty when unpinned
+	timing     packageTiming
+	budget     payloadBudget // released by the writer once the package is on disk or stdout
+}
+
+// stream loads import paths on background goroutines, so callers can render and write earlier
+// packages while later ones are still being fetched. jobs packages are loaded at once (at least
+// one); with ordered, results come out in the order of importPaths, a finished package waiting
+// for the ones before it, and otherwise as soon as they are loaded. Once stop is done no new
+// package is started; the packages in flight keep running until ctx is done. Each package takes
+// a slot of budget before it is fetched, so a slow writer holds back fetching instead of letting
+// loaded pages pile up in memory.
+func (l *packageLoader) stream(ctx, stop context.Context, importPaths []string, budget payloadBudget, jobs int, ordered bool) <-chan loadResult {
+	type job struct {
+		index      int
+		importPath string
+	}
+	type loaded struct {
+		index int
+		res   loadResult
+	}
+	queue := make(chan job)
+	go func() {
+		defer close(queue)
+		for i, importPath := range importPaths {
+			if stop.Err() != nil || !budget.acquire(stop) {
+				return
+			}
+			select {
+			case queue <- job{index: i, importPath: importPath}:
+			case <-stop.Done():
+				budget.release()
+				return
+			}
+		}
+	}()
+
+	results := make(chan loaded)
+	var wg sync.WaitGroup
+	for range max(jobs, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				pkg, rawHTML, timing, err := l.loadTimed(ctx, j.importPath)
+				select {
+				case results <- loaded{index: j.index, res: loadResult{importPath: j.importPath, pkg: pkg, rawHTML: rawHTML, timing: timing, err: err, budget: budget}}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	out := make(chan loadResult, pipelineBuffer)
+	go func() {
+		defer close(out)
+		next := 0
+		held := map[int]loadResult{}
+		for r := range results {
+			index := r.index
+			if !ordered {
+				index = next // hand it out right away
+			}
+			held[index] = r.res
+			for res, ok := held[next]; ok; res, ok = held[next] {
+				delete(held, next)
+				next++
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// renderStage turns loaded packages into markdown rendered with mdOpts and run through post, plus the other formats in
+// formats, on a background goroutine. Every format is rendered from the same parsed package. Failed loads and
+// renders are passed to onError and dropped, releasing their budget; order is preserved.
+func renderStage(ctx context.Context, in <-chan loadResult, formats outputFormats, mdOpts markdown.Options, post postprocess.Chain, onError func(importPath string, err error)) <-chan renderedPackage {
+	out := make(chan renderedPackage, pipelineBuffer)
+	go func() {
+		defer close(out)
+		for res := range in {
+			if res.err != nil {
+				res.budget.release()
+				onError(res.importPath, res.err)
+				continue
+			}
+			start := time.Now()
+			md, err := post.Process(res.pkg, markdown.PackageToMarkdownWithOptions(res.pkg, mdOpts))
+			if err != nil {
+				res.budget.release()
+				onError(res.importPath, err)
+				continue
+			}
+			r := renderedPackage{pkg: res.pkg, importPath: res.importPath, markdown: md, version: pinnedVersion(res.importPath), timing: res.timing, budget: res.budget}
+			if err := renderFormats(&r, res.rawHTML, formats); err != nil {
+				res.budget.release()
+				onError(res.importPath, err)
+				continue
+			}
+			r.timing.Render = time.Since(start)
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,88 @@
+package docinator
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+func TestStream_Budget(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	paths := []string{"example.com/a", "example.com/b", "example.com/c"}
+	for _, path := range paths {
+		if err := store.Upsert(ctx, &models.Document{ID: path, Package: &models.Package{ImportPath: path}, RawHTML: "<html></html>"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader, cleanup, err := newLoader(store, &scraper.ScrapingConfig{TestMode: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	budget := newPayloadBudget(2)
+	loaded := loader.stream(ctx, ctx, paths, budget, 1, true)
+	first, second := <-loaded, <-loaded
+	select {
+	case res := <-loaded:
+		t.Fatalf("Expected fetching to wait for a written package, got %s", res.importPath)
+	case <-time.After(50 * time.Millisecond):
+	}
+	first.budget.release()
+	if res := <-loaded; res.importPath != "example.com/c" {
+		t.Errorf("Expected example.com/c after a release, got %q", res.importPath)
+	}
+	second.budget.release()
+	if _, ok := <-loaded; ok {
+		t.Error("Expected the stream to end")
+	}
+}
+
+// slowStore delays reading the document with the ID slow.
+type slowStore struct {
+	*memstore.Store
+	slow string
+}
+
+func (s *slowStore) GetByID(ctx context.Context, id string) (*models.Document, error) {
+	if id == s.slow {
+		time.Sleep(100 * time.Millisecond)
+	}
+	return s.Store.GetByID(ctx, id)
+}
+
+func TestStream_Order(t *testing.T) {
+	ctx := context.Background()
+	store := &slowStore{Store: memstore.New(), slow: "example.com/a"}
+	paths := []string{"example.com/a", "example.com/b", "example.com/c"}
+	for _, path := range paths {
+		if err := store.Upsert(ctx, &models.Document{ID: path, Package: &models.Package{ImportPath: path}, RawHTML: "<html></html>"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader, cleanup, err := newLoader(store, &scraper.ScrapingConfig{TestMode: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	collect := func(ordered bool) []string {
+		var got []string
+		for res := range loader.stream(ctx, ctx, paths, nil, 3, ordered) {
+			got = append(got, res.importPath)
+		}
+		return got
+	}
+	if got := collect(true); !slices.Equal(got, paths) {
+		t.Errorf("Expected argument order, got %v", got)
+	}
+	// The slow package comes out last once the others are ready.
+	if got := collect(false); len(got) != 3 || got[2] != "example.com/a" {
+		t.Errorf("Expected completion order, got %v", got)
+	}
+}
@@ -0,0 +1,110 @@
+package docinator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/build"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/localdoc"
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/siteprofile"
+	"golang.org/x/mod/module"
+)
+
+// privatePatterns returns the glob patterns of module paths that are private, as the go command
+// reads them: GONOPROXY, which defaults to GOPRIVATE.
+func privatePatterns() string {
+	return cmp.Or(os.Getenv("GONOPROXY"), os.Getenv("GOPRIVATE"))
+}
+
+// privateRouter documents packages matching the private patterns without asking pkg.go.dev,
+// which never has them: from a private documentation site when one is configured, otherwise from
+// their source in the vendor directory or module cache.
+type privateRouter struct {
+	patterns string
+	site     *siteprofile.Profile // private godoc or pkgsite; nil reads local source
+	finder   localdoc.Finder
+
+	mu      sync.Mutex
+	sources map[string]string // import path → where its documentation came from
+}
+
+// newPrivateRouter returns a router for the comma-separated glob patterns, or nil when there are
+// none.
+func newPrivateRouter(patterns string, site *siteprofile.Profile, finder localdoc.Finder) *privateRouter {
+	if strings.TrimSpace(patterns) == "" {
+		return nil
+	}
+	return &privateRouter{patterns: patterns, site: site, finder: finder, sources: map[string]string{}}
+}
+
+// defaultPrivateFinder searches the vendor directory vendorDir and the module cache.
+func defaultPrivateFinder(vendorDir string) localdoc.Finder {
+	return localdoc.Finder{ModCache: localdoc.DefaultModCache(), VendorDir: vendorDir, GOROOT: build.Default.GOROOT}
+}
+
+// matches reports whether importPath, pinned or not, is private. A nil router matches nothing.
+func (r *privateRouter) matches(importPath string) bool {
+	if r == nil {
+		return false
+	}
+	path, _, _ := strings.Cut(importPath, "@")
+	return module.MatchPrefixPatterns(r.patterns, path)
+}
+
+// load documents the private package importPath from the private site or its local source.
+func (r *privateRouter) load(ctx context.Context, s *scraper.Scraper, importPath string) (*models.Package, error) {
+	path, version, _ := strings.Cut(importPath, "@")
+	if r.site != nil {
+		pkg, err := s.ScrapePage(ctx, r.site, path)
+		if err != nil {
+			return nil, fmt.Errorf("private package, from site %s: %w", r.site.Name, err)
+		}
+		r.note(importPath, "site "+r.site.Name)
+		return pkg, nil
+	}
+	dir, modulePath, modVersion := r.finder.Locate(path, version)
+	if dir == "" {
+		return nil, fmt.Errorf("private package (GOPRIVATE) with no copy in the vendor directory or module cache; run go mod download, or set --private-site")
+	}
+	src, err := localdoc.Read(dir, path)
+	if err != nil {
+		return nil, fmt.Errorf("private package, reading %s: %w", dir, err)
+	}
+	r.note(importPath, "local source "+dir)
+	return localdoc.Package(src, path, modulePath, modVersion), nil
+}
+
+// note records where the documentation of importPath came from.
+func (r *privateRouter) note(importPath, source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[importPath] = source
+}
+
+// privateSource says where the documentation of a private package came from.
+type privateSource struct {
+	ImportPath string `json:"import_path"`
+	Source     string `json:"source"` // "local source <dir>", "site <profile>" or "cache"
+}
+
+// report returns the recorded sources, sorted by import path. A nil router reports none.
+func (r *privateRouter) report() []privateSource {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []privateSource
+	for path, source := range r.sources {
+		out = append(out, privateSource{ImportPath: path, Source: source})
+	}
+	slices.SortFunc(out, func(a, b privateSource) int { return strings.Compare(a.ImportPath, b.ImportPath) })
+	return out
+}
@@ -0,0 +1,78 @@
+package docinator
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+
+	"github.com/spf13/cobra"
+)
+
+// stopProfiling is set by startProfiling and flushes any file profiles.
+var stopProfiling = func() {}
+
+// startProfiling starts the pprof server and CPU profile requested by the global flags.
+func startProfiling(cmd *cobra.Command, args []string) error {
+	addr, _ := rootCmd.PersistentFlags().GetString("pprof")
+	cpuProfile, _ := rootCmd.PersistentFlags().GetString("cpuprofile")
+	memProfile, _ := rootCmd.PersistentFlags().GetString("memprofile")
+
+	if addr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		go func() {
+			log.Printf("pprof listening on http://%s/debug/pprof/", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Printf("pprof server stopped: %v", err)
+			}
+		}()
+	}
+
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := runtimepprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		cpuFile = f
+	}
+
+	stopProfiling = func() {
+		if cpuFile != nil {
+			runtimepprof.StopCPUProfile()
+			cpuFile.Close()
+			log.Printf("Wrote CPU profile: %s", cpuProfile)
+		}
+		if memProfile != "" {
+			if err := writeHeapProfile(memProfile); err != nil {
+				log.Printf("Failed to write memory profile: %v", err)
+			} else {
+				log.Printf("Wrote memory profile: %s", memProfile)
+			}
+		}
+	}
+	return nil
+}
+
+// writeHeapProfile writes a heap profile reflecting live objects after a GC.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	runtime.GC()
+	return runtimepprof.WriteHeapProfile(f)
+}
@@ -0,0 +1,54 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/parser"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+func TestScrapeProfile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := memstore.New()
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, OutputDir: dir, TestMode: true, Profile: parser.ProfileMetadata}
+	if err := runScrape(ctx, opts, store, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runScrape failed: %v", err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "github.com/spf13/cobra.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(md), "github.com/spf13/cobra") || strings.Contains(string(md), "## Documentation") {
+		t.Errorf("Expected the metadata without documentation, got:\n%s", md)
+	}
+	doc, _ := store.GetByID(ctx, "github.com/spf13/cobra")
+	if doc == nil || doc.Package.Profile != "metadata" || len(doc.Package.Functions) != 0 || doc.RawHTML != "" {
+		t.Fatalf("Expected the trimmed package stored without its page, got %+v", doc)
+	}
+
+	full, cleanup, err := newLoader(store, &scraper.ScrapingConfig{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if current, _ := full.currentParse(doc.ID, doc); current {
+		t.Error("Expected a metadata-only package not to serve a full scrape")
+	}
+	limited, cleanup, err := newLoader(store, &scraper.ScrapingConfig{Profile: parser.ProfileReadme}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	fullDoc := &models.Document{ID: "example.com/gear", Package: &models.Package{ImportPath: "example.com/gear", Parser: limited.scraper.ParserFingerprint()}}
+	if current, _ := limited.currentParse(fullDoc.ID, fullDoc); !current {
+		t.Error("Expected a fully parsed package to serve a readme scrape")
+	}
+}
@@ -0,0 +1,63 @@
+package docinator
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress event names, in lifecycle order.
+const (
+	eventQueued   = "queued"
+	eventFetching = "fetching"
+	eventParsed   = "parsed"
+	eventRendered = "rendered"
+	eventStored   = "stored"
+	eventFailed   = "failed"
+)
+
+// progressEvent is one NDJSON line emitted with --progress-json.
+type progressEvent struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	ImportPath string    `json:"import_path"`
+	Cached     bool      `json:"cached,omitempty"` // parsed: loaded from the store instead of scraped
+	Error      string    `json:"error,omitempty"`  // failed: the reason
+}
+
+// progressReporter writes progress events as NDJSON and/or feeds them to the console.
+// A nil reporter discards events.
+type progressReporter struct {
+	mu      sync.Mutex
+	enc     *json.Encoder // nil unless --progress-json
+	console *console      // nil unless console mode is active
+}
+
+// newProgressReporter returns a reporter writing NDJSON to w and status lines to con, or nil when both are nil.
+func newProgressReporter(w io.Writer, con *console) *progressReporter {
+	if w == nil && con == nil {
+		return nil
+	}
+	p := &progressReporter{console: con}
+	if w != nil {
+		p.enc = json.NewEncoder(w)
+	}
+	return p
+}
+
+// emit writes one event; it is safe for concurrent use.
+func (p *progressReporter) emit(event progressEvent) {
+	if p == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	if p.console != nil {
+		p.console.event(event)
+	}
+	if p.enc != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		_ = p.enc.Encode(event)
+	}
+}
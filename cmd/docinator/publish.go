@@ -0,0 +1,148 @@
+package docinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/confluence"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/spf13/cobra"
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Publish cached packages to documentation platforms",
+}
+
+var publishConfluenceCmd = &cobra.Command{
+	Use:   "confluence [import paths or modules...]",
+	Short: "Create or update a Confluence page for each cached package",
+	Long: `Publish the most recently scraped version of every cached package as a page
+in a Confluence space, titled with its import path and converted from the
+markdown output to Confluence storage format (code blocks become code
+macros). Pages that already exist are updated to a new version, so running
+it after every scrape keeps the space current. Pass import paths or module
+paths to publish only those packages and the packages below them.
+
+The site and credentials come from the environment: CONFLUENCE_URL (with
+the /wiki path on Confluence Cloud), CONFLUENCE_USER (the account email on
+Cloud; leave it unset to use a Data Center personal access token) and
+CONFLUENCE_TOKEN.
+
+  docinator publish confluence --space DEV --parent "Go Dependencies"
+
+The exit status is 1 when any page could not be published.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		space, _ := cmd.Flags().GetString("space")
+		parent, _ := cmd.Flags().GetString("parent")
+		ctx := cmd.Context()
+		if space == "" {
+			return errors.New("publish confluence needs --space")
+		}
+
+		client := confluence.NewFromEnv()
+		if client == nil {
+			return errors.New("publish confluence needs CONFLUENCE_URL")
+		}
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "publish", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		latest := map[string]*models.Package{}
+		var paths []string
+		err = store.ForEach(ctx, func(doc *models.Document) error {
+			pkg := doc.Package
+			if pkg == nil || !selected(pkg, args) {
+				return nil
+			}
+			if cur, ok := latest[pkg.ImportPath]; !ok {
+				paths = append(paths, pkg.ImportPath)
+				latest[pkg.ImportPath] = pkg
+			} else if pkg.ScrapedAt.After(cur.ScrapedAt) {
+				latest[pkg.ImportPath] = pkg
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("loading packages failed: %w", err)
+		}
+		if len(paths) == 0 {
+			return errors.New("no cached packages to publish")
+		}
+		pkgs := make([]*models.Package, len(paths))
+		for i, p := range paths {
+			pkgs[i] = latest[p]
+		}
+
+		failed, err := runPublishConfluence(ctx, client, pkgs, space, parent, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			stopProfiling()
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	publishConfluenceCmd.Flags().String("space", "", "key of the Confluence space to publish to (required)")
+	publishConfluenceCmd.Flags().String("parent", "", "ID or title of the page to publish below (default the space root)")
+	publishCmd.AddCommand(publishConfluenceCmd)
+}
+
+// runPublishConfluence publishes pkgs below the parent page, given by ID or title, of space and
+// writes a line per page to out. It returns how many pages failed; the error is set when the
+// parent page cannot be resolved.
+func runPublishConfluence(ctx context.Context, client *confluence.Client, pkgs []*models.Package, space, parent string, out io.Writer) (int, error) {
+	parentID := parent
+	if parent != "" && strings.Trim(parent, "0123456789") != "" {
+		page, err := client.FindPage(ctx, space, parent)
+		if err != nil {
+			return 0, err
+		}
+		if page == nil {
+			return 0, fmt.Errorf("parent page %q not found in space %s", parent, space)
+		}
+		parentID = page.ID
+	}
+
+	failed := 0
+	for _, pkg := range pkgs {
+		page, created, err := publishConfluencePage(ctx, client, pkg, space, parentID)
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "failed %s: %v\n", pkg.ImportPath, err)
+			continue
+		}
+		action := "updated"
+		if created {
+			action = "created"
+		}
+		fmt.Fprintf(out, "%s %s: page %s, version %d\n", action, pkg.ImportPath, page.ID, page.Version.Number)
+	}
+	fmt.Fprintf(out, "\nPublished %d of %d packages to space %s\n", len(pkgs)-failed, len(pkgs), space)
+	return failed, nil
+}
+
+// publishConfluencePage converts the markdown of pkg and publishes it as the page titled with
+// its import path. The markdown title heading is dropped, since the page title names the package.
+func publishConfluencePage(ctx context.Context, client *confluence.Client, pkg *models.Package, space, parentID string) (*confluence.Page, bool, error) {
+	_, body, _ := strings.Cut(markdown.PackageToMarkdown(pkg), "\n")
+	storage, err := confluence.ToStorage(body)
+	if err != nil {
+		return nil, false, err
+	}
+	return client.Publish(ctx, confluence.PageContent{Space: space, ParentID: parentID, Title: pkg.ImportPath, Body: storage})
+}
@@ -0,0 +1,92 @@
+package docinator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete every cached document of a namespace",
+	Long: `Delete every document of the current namespace (--namespace or
+MONGODB_NAMESPACE) from the cache; with MongoDB its chunks and run records go
+too. Other namespaces sharing the database or bbolt file are left alone.
+--yes is required, since nothing can be recovered:
+
+  docinator purge --namespace team-a --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			return fmt.Errorf("purge deletes every document of namespace %q; pass --yes to confirm", namespaceName())
+		}
+		ctx := cmd.Context()
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "purge", "set MONGODB_URI or BOLT_PATH")
+		}
+		if err := runPurge(ctx, store, cmd.OutOrStdout()); err != nil {
+			return fmt.Errorf("purge failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	purgeCmd.Flags().Bool("yes", false, "confirm deleting every document of the namespace")
+}
+
+// setupNamespace exports --namespace for the stores to read, after checking it or the namespace
+// already in the environment.
+func setupNamespace(cmd *cobra.Command) error {
+	if f := cmd.Flags().Lookup("namespace"); f != nil && f.Changed {
+		os.Setenv(storage.NamespaceEnv, f.Value.String())
+	}
+	return storage.CheckNamespace(os.Getenv(storage.NamespaceEnv))
+}
+
+// namespaceName names the current namespace for messages.
+func namespaceName() string {
+	return cmp.Or(os.Getenv(storage.NamespaceEnv), "default")
+}
+
+// purger is a store that can drop a whole namespace at once.
+type purger interface {
+	Purge(ctx context.Context) error
+}
+
+// runPurge deletes every document of store, at once when the backend supports it.
+func runPurge(ctx context.Context, store storage.Store, out io.Writer) error {
+	var ids []string
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		ids = append(ids, doc.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if p, ok := store.(purger); ok {
+		err = p.Purge(ctx)
+	} else {
+		for _, id := range ids {
+			if err = store.Delete(ctx, id); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Purged %d document(s) from namespace %s\n", len(ids), namespaceName())
+	return nil
+}
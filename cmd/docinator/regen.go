@@ -0,0 +1,236 @@
+package docinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/site"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var regenCmd = &cobra.Command{
+	Use:   "regen [import paths or modules...]",
+	Short: "Re-parse every cached page with the current parser and re-render the outputs",
+	Long: `Parse the raw HTML of every cached package again with the current parser and
+selector profile (see --selectors), store the result and, with --output,
+render it as docinator render would. Nothing is fetched. Run it after
+upgrading docinator or changing the selector profile so the whole corpus
+follows at once instead of package by package as they are loaded:
+
+  docinator regen --workers 8 -o docs
+
+Pass import or module paths to regenerate only those packages and the
+packages below them. Packages without their page (dropped by docinator gc)
+and private packages are skipped. Every document that fails to parse is
+listed at the end, and the exit status is 1 when any did.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		opts := regenRun{renderRun: renderRun{Filters: args, Tags: tagFilter(cmd)}, OutputDir: outputDir}
+		opts.Workers, _ = cmd.Flags().GetInt("workers")
+		if opts.Workers < 1 {
+			return fmt.Errorf("--workers must be at least 1, got %d", opts.Workers)
+		}
+		opts.Verbose = verbosity() >= 1
+		if outputDir != "" {
+			formats, _ := cmd.Flags().GetStringSlice("format")
+			var err error
+			if opts.Formats, err = parseFormats(formats); err != nil {
+				return fmt.Errorf("--format: %w", err)
+			}
+			if opts.Markdown, err = renderOptions(cmd); err != nil {
+				return err
+			}
+			if opts.PostProcess, err = postProcessors(cmd); err != nil {
+				return fmt.Errorf("post-processing: %w", err)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if !loader.store.Enabled() {
+			return needsCache(loader.store, "regen", "set MONGODB_URI or BOLT_PATH")
+		}
+		if err := linkCorpusTypes(ctx, loader.store, &opts.Markdown); err != nil {
+			return err
+		}
+		if loader.scraper.ParserFingerprint() == "" {
+			return withHint(errors.New("regen cannot run with --site or --test-mode"), "it re-parses pkg.go.dev pages; drop --site and --test-mode")
+		}
+
+		report, err := regenStored(ctx, loader.scraper, loader.store, opts, time.Now())
+		if err != nil {
+			return fmt.Errorf("regeneration failed: %w", err)
+		}
+		printRegen(cmd.OutOrStdout(), report)
+		if len(report.Failures) > 0 {
+			stopProfiling()
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	regenCmd.Flags().Int("workers", 4, "number of documents parsed and rendered in parallel")
+	regenCmd.Flags().StringSlice("format", []string{formatMarkdown, formatRaw}, "comma-separated formats written per package with --output: md, raw, json, html")
+	regenCmd.Flags().StringSlice("tag", nil, "regenerate only packages carrying all of these tags (see docinator tag)")
+	addRenderFlags(regenCmd)
+}
+
+// regenRun selects the cached documents docinator regen parses again and how it renders them.
+type regenRun struct {
+	renderRun
+	OutputDir string // "" only stores the re-parsed packages
+	Workers   int
+}
+
+// regenFailure is a document that could not be regenerated.
+type regenFailure struct {
+	ID  string
+	Err error
+}
+
+// regenReport is the outcome of docinator regen.
+type regenReport struct {
+	Parsed   int // re-parsed and stored
+	Changed  int // of Parsed, the ones whose content changed
+	Skipped  int // without raw HTML
+	Files    writeCounts
+	Failures []regenFailure
+}
+
+// regenStored parses the raw HTML of the stored documents opts selects again with s, on
+// opts.Workers goroutines, stores the packages and renders them to opts.OutputDir when set.
+// A document that fails is recorded in the report and does not stop the others; err is only
+// set when the documents cannot be listed.
+func regenStored(ctx context.Context, s *scraper.Scraper, store storage.Store, opts regenRun, now time.Time) (regenReport, error) {
+	var ids []string
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package != nil && selected(doc.Package, opts.Filters) && hasTags(doc.Tags, opts.Tags) {
+			ids = append(ids, doc.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return regenReport{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		report regenReport
+		index  []site.SearchEntry
+		wg     sync.WaitGroup
+	)
+	queue := make(chan string)
+	for range min(opts.Workers, max(len(ids), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				changed, files, entry, err := regenDocument(ctx, s, store, id, opts, now)
+				mu.Lock()
+				switch {
+				case errors.Is(err, scraper.ErrNoReparse):
+					report.Skipped++
+				case err != nil:
+					report.Failures = append(report.Failures, regenFailure{ID: id, Err: err})
+				default:
+					report.Parsed++
+					if changed {
+						report.Changed++
+					}
+					report.Files.add(files)
+					if entry != nil {
+						index = append(index, *entry)
+					}
+				}
+				mu.Unlock()
+				if opts.Verbose && err == nil {
+					log.Printf("Regenerated %s", id)
+				}
+			}
+		}()
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- id
+	}
+	close(queue)
+	wg.Wait()
+
+	slices.SortFunc(report.Failures, func(a, b regenFailure) int { return strings.Compare(a.ID, b.ID) })
+	if opts.OutputDir != "" {
+		finishRender(opts.OutputDir, index)
+	}
+	return report, ctx.Err()
+}
+
+// regenDocument re-parses, stores and renders the document id. It reports whether its content
+// changed; entry is nil when nothing was rendered. Documents without raw HTML, private ones among
+// them, return scraper.ErrNoReparse. A page parsing to a package that lacks critical fields fails
+// with scraper.ErrLayoutChanged and leaves the document as it was.
+func regenDocument(ctx context.Context, s *scraper.Scraper, store storage.Store, id string, opts regenRun, now time.Time) (changed bool, files writeCounts, entry *site.SearchEntry, err error) {
+	// ForEach leaves out raw HTML.
+	doc, err := store.GetByID(ctx, id)
+	if err != nil {
+		return false, files, nil, err
+	}
+	if doc == nil || doc.Package == nil {
+		return false, files, nil, scraper.ErrNoReparse // deleted meanwhile
+	}
+	pkg, err := s.Reparse(doc.Package, doc.RawHTML)
+	if err != nil {
+		return false, files, nil, err
+	}
+	// Keep the stored package rather than replace it with what a broken selector left of it.
+	if missing := scraper.MissingCriticalFields(pkg); len(missing) > 0 {
+		return false, files, nil, fmt.Errorf("%w: no %s found", scraper.ErrLayoutChanged, strings.Join(missing, ", "))
+	}
+	prev := *doc
+	doc.Package = pkg
+	stampContent(doc, &prev, now)
+	if err := store.Upsert(ctx, doc); err != nil {
+		return false, files, nil, fmt.Errorf("storing: %w", err)
+	}
+	changed = doc.ContentHash != prev.ContentHash
+	if opts.OutputDir == "" {
+		return changed, files, nil, nil
+	}
+	files, e, err := renderDocument(opts.OutputDir, doc, opts.renderRun)
+	if err != nil {
+		return changed, files, nil, fmt.Errorf("rendering: %w", err)
+	}
+	return changed, files, &e, nil
+}
+
+// printRegen writes the summary of report followed by one line per failed document.
+func printRegen(out io.Writer, report regenReport) {
+	fmt.Fprintf(out, "Re-parsed %d document(s), %d changed; %d without a cached page skipped; %d failed\n",
+		report.Parsed, report.Changed, report.Skipped, len(report.Failures))
+	if report.Files.Changed+report.Files.Unchanged > 0 {
+		fmt.Fprintf(out, "Files: %d changed, %d unchanged\n", report.Files.Changed, report.Files.Unchanged)
+	}
+	for _, f := range report.Failures {
+		fmt.Fprintf(out, "FAILED %s: %v\n", f.ID, f.Err)
+	}
+}
@@ -0,0 +1,57 @@
+package docinator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+func TestRegenStored(t *testing.T) {
+	ctx := context.Background()
+	page, err := os.ReadFile(filepath.Join("..", "..", "pkg", "parser", "testdata", "widget.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memstore.New()
+	stale := &models.Package{Name: "gear", ImportPath: "example.com/widget/gear", Parser: "0/000000000000"}
+	for _, doc := range []*models.Document{
+		{ID: stale.ImportPath, Package: stale, RawHTML: string(page)},
+		{ID: "example.com/broken", Package: &models.Package{Name: "broken", ImportPath: "example.com/broken"}, RawHTML: "<html><body></body></html>"},
+		{ID: "example.com/collected", Package: &models.Package{Name: "collected", ImportPath: "example.com/collected"}},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := scraper.New(&scraper.ScrapingConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	outputDir := t.TempDir()
+	opts := regenRun{renderRun: renderRun{Formats: outputFormats{formatMarkdown: true}}, OutputDir: outputDir, Workers: 3}
+	report, err := regenStored(ctx, s, store, opts, time.Now())
+	if err != nil {
+		t.Fatalf("regenStored failed: %v", err)
+	}
+	if report.Parsed != 1 || report.Changed != 1 || report.Skipped != 1 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].ID != "example.com/broken" {
+		t.Errorf("Expected the broken page reported, got %+v", report.Failures)
+	}
+	doc, _ := store.GetByID(ctx, stale.ImportPath)
+	if doc == nil || doc.Package.Parser != s.ParserFingerprint() || len(doc.Package.Functions) != 1 {
+		t.Errorf("Expected the re-parsed package stored, got %+v", doc)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "example.com", "widget", "gear.md")); err != nil {
+		t.Errorf("Expected the package rendered: %v", err)
+	}
+}
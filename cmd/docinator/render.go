@@ -0,0 +1,204 @@
+package docinator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/checksum"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/postprocess"
+	"github.com/moseye/docinator/pkg/site"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render [import paths or modules...]",
+	Short: "Re-render cached documents to the output directory without scraping",
+	Long: `Run only the render and write stages of scrape over cached documents, so a
+renderer upgrade or new rendering flags reach the whole corpus quickly and
+offline. Every document whose content changed in the --changed-since window
+(e.g. 24h) is rendered, or every document without it; pass import or module
+paths to render only those packages and the packages below them.
+
+A document's content is hashed whenever it is scraped, and its change time
+only moves when the hash does, so re-scrapes of unchanged pages are not
+rendered again. Documents cached before hashing count as changed when scraped.
+
+Files are written as by scrape -o, in --format and with the same markdown
+flags; files already holding the rendered content are left alone.
+
+  docinator render -o docs --changed-since 24h
+  docinator render -o docs --no-readme github.com/spf13/cobra`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		if outputDir == "" {
+			return withHint(errors.New("render needs an output directory"), "pass --output")
+		}
+		opts := renderRun{Filters: args, Tags: tagFilter(cmd)}
+		opts.ChangedSince, _ = cmd.Flags().GetDuration("changed-since")
+		verbosity, _ := rootCmd.PersistentFlags().GetCount("verbose")
+		opts.Verbose = verbosity >= 1
+		formats, _ := cmd.Flags().GetStringSlice("format")
+		var err error
+		if opts.Formats, err = parseFormats(formats); err != nil {
+			return fmt.Errorf("--format: %w", err)
+		}
+		if opts.Markdown, err = renderOptions(cmd); err != nil {
+			return err
+		}
+		if opts.PostProcess, err = postProcessors(cmd); err != nil {
+			return fmt.Errorf("post-processing: %w", err)
+		}
+		ctx := cmd.Context()
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "render", "set MONGODB_URI or BOLT_PATH")
+		}
+		if err := linkCorpusTypes(ctx, store, &opts.Markdown); err != nil {
+			return err
+		}
+
+		rendered, counts, err := renderStored(ctx, store, outputDir, opts, time.Now())
+		if err != nil {
+			return fmt.Errorf("render failed: %w", err)
+		}
+		log.Printf("Rendered %d documents to %s: %d files changed, %d unchanged", rendered, outputDir, counts.Changed, counts.Unchanged)
+		return nil
+	},
+}
+
+func init() {
+	renderCmd.Flags().Duration("changed-since", 0, "render only documents whose content changed this long ago or later, e.g. 24h (default all)")
+	renderCmd.Flags().StringSlice("format", []string{formatMarkdown, formatRaw}, "comma-separated formats written per package: md, raw, json, html")
+	renderCmd.Flags().StringSlice("tag", nil, "render only packages carrying all of these tags (see docinator tag)")
+	addRenderFlags(renderCmd)
+}
+
+// renderRun selects and renders the cached documents of docinator render.
+type renderRun struct {
+	ChangedSince time.Duration // 0 renders every selected document
+	Filters      []string      // import or module paths; see selected
+	Tags         []string
+	Formats      outputFormats
+	Markdown     markdown.Options
+	Verbose      bool
+
+	PostProcess postprocess.Chain // run over the rendered markdown; nil keeps it as rendered
+}
+
+// renderStored renders the stored documents opts selects to outputDir, as of now, and updates the
+// search index and checksums of the directory. It returns the number of documents rendered.
+func renderStored(ctx context.Context, store storage.Store, outputDir string, opts renderRun, now time.Time) (int, writeCounts, error) {
+	var ids []string
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package == nil || !selected(doc.Package, opts.Filters) || !hasTags(doc.Tags, opts.Tags) {
+			return nil
+		}
+		if opts.ChangedSince > 0 && changedAt(doc).Before(now.Add(-opts.ChangedSince)) {
+			return nil
+		}
+		ids = append(ids, doc.ID)
+		return nil
+	})
+	if err != nil {
+		return 0, writeCounts{}, err
+	}
+
+	var counts writeCounts
+	var index []site.SearchEntry
+	for _, id := range ids {
+		// ForEach leaves out raw HTML, which the raw format needs.
+		doc, err := store.GetByID(ctx, id)
+		if err != nil {
+			return 0, counts, err
+		}
+		if doc == nil || doc.Package == nil {
+			continue // deleted meanwhile
+		}
+		c, entry, err := renderDocument(outputDir, doc, opts)
+		if err != nil {
+			log.Printf("Failed to render %s: %v", id, err)
+			continue
+		}
+		counts.add(c)
+		index = append(index, entry)
+	}
+	finishRender(outputDir, index)
+	return len(index), counts, nil
+}
+
+// renderDocument renders doc, which has to hold its raw HTML for the raw format, to outputDir and
+// returns the file counts and its search index entry.
+func renderDocument(outputDir string, doc *models.Document, opts renderRun) (writeCounts, site.SearchEntry, error) {
+	md, err := opts.PostProcess.Process(doc.Package, markdown.PackageToMarkdownWithOptions(doc.Package, opts.Markdown))
+	if err != nil {
+		return writeCounts{}, site.SearchEntry{}, err
+	}
+	r := renderedPackage{pkg: doc.Package, markdown: md, version: pinnedVersion(doc.ID)}
+	if err := renderFormats(&r, doc.RawHTML, opts.Formats); err != nil {
+		return writeCounts{}, site.SearchEntry{}, err
+	}
+	return writeRendered(outputDir, r, opts.Formats, opts.Verbose), site.NewSearchEntry(r.pkg, outputPage(r)), nil
+}
+
+// finishRender updates the search index and checksums of outputDir after the packages of index
+// were rendered to it.
+func finishRender(outputDir string, index []site.SearchEntry) {
+	if len(index) == 0 {
+		return
+	}
+	if err := site.UpdateSearchIndex(outputDir, index); err != nil {
+		log.Printf("Failed to update the search index: %v", err)
+	}
+	if _, err := checksum.Update(outputDir); err != nil {
+		log.Printf("Failed to write %s: %v", checksum.File, err)
+	}
+}
+
+// changedAt returns when the content of doc last changed, or when it was scraped for documents
+// stored before content hashing.
+func changedAt(doc *models.Document) time.Time {
+	if !doc.ChangedAt.IsZero() {
+		return doc.ChangedAt
+	}
+	return doc.Package.ScrapedAt
+}
+
+// stampContent sets the content hash of doc, which replaces prev (nil when there is none), and
+// moves its change time to now only when the hash differs from prev's.
+func stampContent(doc, prev *models.Document, now time.Time) {
+	if doc.Package == nil {
+		return
+	}
+	doc.ContentHash = packageHash(doc.Package)
+	if prev != nil && prev.ContentHash == doc.ContentHash && !prev.ChangedAt.IsZero() {
+		doc.ChangedAt = prev.ChangedAt
+		return
+	}
+	doc.ChangedAt = now
+}
+
+// packageHash returns the hex SHA-256 of pkg's JSON without its scrape time, which changes on
+// every scrape.
+func packageHash(pkg *models.Package) string {
+	p := *pkg
+	p.ScrapedAt = time.Time{}
+	data, err := json.Marshal(&p)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package docinator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestStampContent(t *testing.T) {
+	day1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	prev := &models.Document{Package: &models.Package{ImportPath: "github.com/spf13/cobra", Version: "v1.9.0", ScrapedAt: day1}}
+	stampContent(prev, nil, day1)
+	if prev.ContentHash == "" || !prev.ChangedAt.Equal(day1) {
+		t.Fatalf("Expected a new document stamped, got %q %v", prev.ContentHash, prev.ChangedAt)
+	}
+
+	same := &models.Document{Package: &models.Package{ImportPath: "github.com/spf13/cobra", Version: "v1.9.0", ScrapedAt: day2}}
+	stampContent(same, prev, day2)
+	if same.ContentHash != prev.ContentHash || !same.ChangedAt.Equal(day1) {
+		t.Errorf("Expected a re-scrape with the same content to keep its change time, got %v", same.ChangedAt)
+	}
+
+	changed := &models.Document{Package: &models.Package{ImportPath: "github.com/spf13/cobra", Version: "v1.9.1", ScrapedAt: day2}}
+	stampContent(changed, prev, day2)
+	if changed.ContentHash == prev.ContentHash || !changed.ChangedAt.Equal(day2) {
+		t.Errorf("Expected changed content to move the change time, got %v", changed.ChangedAt)
+	}
+}
+
+func TestRenderStored(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	store := memstore.New()
+	for id, changed := range map[string]time.Time{
+		"github.com/spf13/cobra":        now.Add(-time.Hour),
+		"github.com/spf13/pflag":        now.Add(-72 * time.Hour),
+		"github.com/spf13/cobra@v1.8.0": now.Add(-2 * time.Hour),
+	} {
+		path, _, _ := strings.Cut(id, "@")
+		doc := &models.Document{ID: id, Package: &models.Package{Name: filepath.Base(path), ImportPath: path}, ChangedAt: changed}
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	opts := renderRun{ChangedSince: 24 * time.Hour, Formats: outputFormats{formatMarkdown: true}}
+	rendered, counts, err := renderStored(ctx, store, dir, opts, now)
+	if err != nil {
+		t.Fatalf("renderStored failed: %v", err)
+	}
+	if rendered != 2 || counts.Changed != 2 {
+		t.Errorf("Expected the 2 recently changed documents rendered, got %d (%+v)", rendered, counts)
+	}
+	for _, name := range []string{"github.com/spf13/cobra.md", "github.com/spf13/cobra/v1.8.0/cobra.md"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected %s written: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "github.com", "spf13", "pflag.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected the unchanged package left alone, got %v", err)
+	}
+
+	// A second run rewrites nothing.
+	if _, counts, _ = renderStored(ctx, store, dir, opts, now); counts.Changed != 0 || counts.Unchanged != 2 {
+		t.Errorf("Expected the files unchanged on a second run, got %+v", counts)
+	}
+}
+
+func TestRenderLinkTypes(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	for _, pkg := range []*models.Package{
+		{Name: "pflag", ImportPath: "github.com/spf13/pflag", Types: []models.Type{{Name: "FlagSet"}}},
+		{Name: "cobra", ImportPath: "github.com/spf13/cobra", Types: []models.Type{{Name: "Command", Methods: []models.Function{
+			{Name: "Flags", Signature: "func (c *Command) Flags() *pflag.FlagSet"},
+		}}}},
+	} {
+		if err := store.Upsert(ctx, &models.Document{ID: pkg.ImportPath, Package: pkg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	opts := renderRun{Filters: []string{"github.com/spf13/cobra"}, Formats: outputFormats{formatMarkdown: true}}
+	opts.Markdown.LinkTypes = true
+	if err := linkCorpusTypes(ctx, store, &opts.Markdown); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := renderStored(ctx, store, dir, opts, time.Now()); err != nil {
+		t.Fatalf("renderStored failed: %v", err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "github.com/spf13/cobra.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<pre><code>func (c *<a href="#Command">Command</a>) Flags() *<a href="https://pkg.go.dev/github.com/spf13/pflag#FlagSet">pflag.FlagSet</a></code></pre>`
+	if !strings.Contains(string(md), want) {
+		t.Errorf("Expected the signature with its types linked, got:\n%s", md)
+	}
+}
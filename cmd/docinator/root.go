@@ -1,8 +1,12 @@
 package docinator
 
 import (
+	"fmt"
 	"log"
+	"os"
 
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -16,18 +20,118 @@ and converting it to markdown format.`,
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// An error a command fails with is reported, with its remediation hint, on stderr in the
+// --error-format and returned.
 func Execute() error {
-	return rootCmd.Execute()
+	rootCmd.SilenceErrors = true
+	cmd, err := rootCmd.ExecuteC()
+	if err == nil {
+		return nil
+	}
+	// A failed command skips PersistentPostRun.
+	stopProfiling()
+	if path, _ := rootCmd.PersistentFlags().GetString("log-file"); path != "" {
+		log.Printf("Error: %v", err)
+	}
+	closeLogging()
+	format, _ := rootCmd.PersistentFlags().GetString("error-format")
+	reportError(os.Stderr, cmd, err, format)
+	return err
 }
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "enable verbose output")
+	rootCmd.PersistentFlags().CountP("verbose", "v", "increase verbosity: -v info, -vv debug with request/response details, -vvv parsed symbol traces")
 	rootCmd.PersistentFlags().StringP("output", "o", "", "output directory (default stdout)")
 	rootCmd.PersistentFlags().Bool("test-mode", false, "enable test mode for mock data")
+	rootCmd.PersistentFlags().String("store", "auto", "cache backend: auto, mongo, bolt (or fs), memory or none (auto picks MongoDB or bbolt from env)")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "scrape every package live instead of reading it from the store")
+	rootCmd.PersistentFlags().Bool("no-store", false, "do not write scraped packages to the store")
+	rootCmd.PersistentFlags().Bool("history", false, "history mode: keep the imported-by and imports counts of every scrape on the cached document, for docinator trend")
+	rootCmd.PersistentFlags().Float64("rate-limit", 0, "maximum pkg.go.dev requests per second (0: no limit beyond the built-in delay); shared by all workers through Redis when REDIS_URL is set")
+	rootCmd.PersistentFlags().String("priority", "", "priority of this command's requests for the --rate-limit bucket: background, normal or interactive (default background for warm, normal otherwise)")
+	rootCmd.PersistentFlags().Duration("random-delay", 0, "wait up to this long at random before each pkg.go.dev request, e.g. 1s, so batch traffic has no fixed rhythm")
+	rootCmd.PersistentFlags().Int("max-response-size", scraper.DefaultMaxResponseSize>>20, "skip pages larger than this many megabytes instead of parsing them (0 disables the limit)")
+	rootCmd.PersistentFlags().String("selectors", "", "YAML selector profile overriding how pkg.go.dev pages are parsed (see docinator selectors)")
+	rootCmd.PersistentFlags().String("site", "", "site profile for scraping another documentation site instead of pkg.go.dev: a YAML file or a built-in name (readthedocs, godoc); arguments are then page paths or URLs on it")
+	rootCmd.PersistentFlags().String("site-base", "", "URL the --site profile's site is served at, e.g. http://godoc.internal:6060 for a godoc server")
+	rootCmd.PersistentFlags().String("private-site", "", "site profile (a YAML file or a built-in name such as godoc) documenting packages that match GOPRIVATE; without it they are read from local source")
+	rootCmd.PersistentFlags().String("private-site-base", "", "URL the --private-site profile's site is served at, e.g. http://godoc.internal:6060")
+	rootCmd.PersistentFlags().String("pkgsite-api", "", "base URL of a pkgsite JSON API, e.g. https://pkg.go.dev or a private pkgsite, preferred over HTML for module, version, license and imports")
+	rootCmd.PersistentFlags().String("http-cache-dir", "", "cache pkg.go.dev responses in this directory so repeated requests skip the network")
+	rootCmd.PersistentFlags().String("namespace", "", "keep cached documents, chunks and runs apart for this team or project in a shared store (also: "+storage.NamespaceEnv+")")
+	rootCmd.PersistentFlags().String("error-format", errorFormatText, "how a failed command reports its error and remediation hint on stderr: text or json (one object with command, error and hint)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// Flags and arguments are valid; usage no longer helps with an error.
+		cmd.SilenceUsage = true
+		if format, _ := rootCmd.PersistentFlags().GetString("error-format"); format != errorFormatText && format != errorFormatJSON {
+			return fmt.Errorf("--error-format must be text or json, got %q", format)
+		}
+		if err := setupLogging(cmd); err != nil {
+			return err
+		}
+		if err := setupNamespace(cmd); err != nil {
+			return err
+		}
+		return startProfiling(cmd, args)
+	}
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		stopProfiling()
+		closeLogging()
+	}
+	rootCmd.PersistentFlags().Bool("no-color", false, "disable colored terminal output (also honored: NO_COLOR)")
+	rootCmd.PersistentFlags().String("log-file", "", "write logs to this file instead of stderr, keeping stdout and the terminal clean")
+	rootCmd.PersistentFlags().Int("log-max-size", 10, "rotate the log file after this many megabytes (0 disables rotation)")
+	rootCmd.PersistentFlags().Int("log-backups", 3, "number of rotated log files to keep")
+	rootCmd.PersistentFlags().String("pprof", "", "serve net/http/pprof on this address, e.g. :6060")
+	rootCmd.PersistentFlags().String("cpuprofile", "", "write a CPU profile of the run to this file")
+	rootCmd.PersistentFlags().String("memprofile", "", "write a heap profile to this file when the command finishes")
 	if err := rootCmd.MarkPersistentFlagDirname("output"); err != nil {
 		log.Fatal(err)
 	}
+	if err := rootCmd.MarkPersistentFlagDirname("http-cache-dir"); err != nil {
+		log.Fatal(err)
+	}
 
 	rootCmd.AddCommand(scrapeCmd)
-}
\ No newline at end of file
+	rootCmd.AddCommand(packCmd)
+	rootCmd.AddCommand(chunkCmd)
+	rootCmd.AddCommand(semsearchCmd)
+	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(browseCmd)
+	rootCmd.AddCommand(symCmd)
+	rootCmd.AddCommand(serveStaticCmd)
+	rootCmd.AddCommand(siteCmd)
+	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(warmCmd)
+	rootCmd.AddCommand(selectorsCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(publishCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(selftestCmd)
+	rootCmd.AddCommand(gcCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(purgeCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(handbookCmd)
+	rootCmd.AddCommand(xrefCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(coverageCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(examplesCmd)
+	rootCmd.AddCommand(snippetCmd)
+	rootCmd.AddCommand(renderCmd)
+	rootCmd.AddCommand(trendCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(moduleCmd)
+	rootCmd.AddCommand(regenCmd)
+	rootCmd.AddCommand(parseCmd)
+}
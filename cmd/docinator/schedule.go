@@ -0,0 +1,95 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/coverage"
+	"github.com/moseye/docinator/pkg/storage"
+)
+
+// Schedules of a time-boxed batch (--schedule), deciding which packages get done before
+// --time-budget runs out.
+const (
+	scheduleMissesFirst   = "misses-first"   // packages the cache cannot serve first, then cached ones smallest first
+	scheduleSmallestFirst = "smallest-first" // smallest first by their cached copy; packages never scraped last
+	scheduleInput         = "input"          // argument order
+)
+
+// scheduledPackage is an import path of the batch and what the cache knows about it.
+type scheduledPackage struct {
+	importPath string
+	index      int  // position in the arguments, the last tie-breaker
+	priority   bool // carries one of the --priority-tag tags
+	cached     bool // the cache can serve it
+	size       int  // exported symbols of its most recently cached copy; -1 when never scraped
+}
+
+// scheduleBatch orders importPaths for a time-boxed batch: packages carrying one of priorityTags
+// first, then as schedule says. With noCache every package counts as a cache miss, so
+// misses-first keeps the argument order.
+func scheduleBatch(ctx context.Context, store storage.Store, importPaths []string, schedule string, priorityTags []string, noCache bool) ([]string, error) {
+	pkgs := make([]scheduledPackage, len(importPaths))
+	byPath := map[string][]*scheduledPackage{}
+	for i, p := range importPaths {
+		pkgs[i] = scheduledPackage{importPath: p, index: i, size: -1}
+		path, _, _ := strings.Cut(p, "@")
+		byPath[path] = append(byPath[path], &pkgs[i])
+	}
+	if store.Enabled() && (schedule != scheduleInput || len(priorityTags) > 0) {
+		latest := map[string]*models.Package{}
+		err := store.ForEach(ctx, func(doc *models.Document) error {
+			if doc.Package == nil || byPath[doc.Package.ImportPath] == nil {
+				return nil
+			}
+			for _, sp := range byPath[doc.Package.ImportPath] {
+				sp.cached = sp.cached || doc.ID == sp.importPath && !noCache
+				sp.priority = sp.priority || hasAnyTag(doc.Tags, priorityTags)
+			}
+			if cur := latest[doc.Package.ImportPath]; cur == nil || doc.Package.ScrapedAt.After(cur.ScrapedAt) {
+				latest[doc.Package.ImportPath] = doc.Package
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scheduling the batch failed: %w", err)
+		}
+		for path, pkg := range latest {
+			for _, sp := range byPath[path] {
+				sp.size = coverage.Package(pkg).Symbols
+			}
+		}
+	}
+
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		a, b := pkgs[i], pkgs[j]
+		if a.priority != b.priority {
+			return a.priority
+		}
+		switch schedule {
+		case scheduleMissesFirst:
+			if a.cached != b.cached {
+				return !a.cached
+			}
+			if a.cached && a.size != b.size {
+				return a.size < b.size
+			}
+		case scheduleSmallestFirst:
+			if (a.size < 0) != (b.size < 0) {
+				return b.size < 0
+			}
+			if a.size != b.size {
+				return a.size < b.size
+			}
+		}
+		return a.index < b.index
+	})
+	ordered := make([]string, len(pkgs))
+	for i, sp := range pkgs {
+		ordered[i] = sp.importPath
+	}
+	return ordered, nil
+}
@@ -0,0 +1,101 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestScheduleBatch(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	funcs := func(n int) []models.Function { return make([]models.Function, n) }
+	for _, doc := range []*models.Document{
+		{ID: "example.com/big", Package: &models.Package{ImportPath: "example.com/big", Functions: funcs(5)}},
+		{ID: "example.com/small", Package: &models.Package{ImportPath: "example.com/small", Functions: funcs(1)}},
+		{ID: "example.com/urgent", Package: &models.Package{ImportPath: "example.com/urgent", Functions: funcs(9)}, Tags: []string{"release"}},
+		{ID: "example.com/old@v1.0.0", Package: &models.Package{ImportPath: "example.com/old", Functions: funcs(2)}},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := []string{"example.com/big", "example.com/new", "example.com/small", "example.com/old", "example.com/urgent"}
+
+	for _, tc := range []struct {
+		schedule string
+		noCache  bool
+		want     []string
+	}{
+		// example.com/old is only cached at another version: a miss, but its size is known.
+		{scheduleMissesFirst, false, []string{"example.com/urgent", "example.com/new", "example.com/old", "example.com/small", "example.com/big"}},
+		{scheduleSmallestFirst, false, []string{"example.com/urgent", "example.com/small", "example.com/old", "example.com/big", "example.com/new"}},
+		{scheduleInput, false, []string{"example.com/urgent", "example.com/big", "example.com/new", "example.com/small", "example.com/old"}},
+		{scheduleMissesFirst, true, []string{"example.com/urgent", "example.com/big", "example.com/new", "example.com/small", "example.com/old"}},
+	} {
+		got, err := scheduleBatch(ctx, store, paths, tc.schedule, []string{"release"}, tc.noCache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s (no cache %v): got %v, want %v", tc.schedule, tc.noCache, got, tc.want)
+		}
+	}
+}
+
+func TestScrapeTimeBudgetSpent(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{"github.com/spf13/cobra", "github.com/spf13/pflag"}
+	opts := scrapeOptions{ImportPaths: paths, OutputDir: dir, TestMode: true, TimeBudget: time.Nanosecond, Schedule: scheduleMissesFirst}
+	err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "ran out before any package was written") {
+		t.Fatalf("Expected the spent budget to stop the batch, got %v", err)
+	}
+	checkpoint, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if err != nil {
+		t.Fatalf("Expected a checkpoint: %v", err)
+	}
+	if got := strings.Fields(string(checkpoint)); !reflect.DeepEqual(got, paths) {
+		t.Errorf("Expected every package checkpointed, got %v", got)
+	}
+
+	// A budget the batch fits in changes nothing.
+	opts.TimeBudget = time.Hour
+	opts.OutputDir = t.TempDir()
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("runScrape failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(opts.OutputDir, checkpointFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no checkpoint for a finished batch, got %v", err)
+	}
+}
+
+func TestScrapeTimeBudgetCheckpointsPinnedVersions(t *testing.T) {
+	var spend func()
+	defer func(orig func(time.Duration, func()) *time.Timer) { afterFunc = orig }(afterFunc)
+	afterFunc = func(_ time.Duration, f func()) *time.Timer {
+		spend = f
+		return time.AfterFunc(time.Hour, func() {})
+	}
+	dir := t.TempDir()
+	// The budget runs out as the first package is stored; with one package pending at a time no
+	// other is in flight, so the rest are checkpointed.
+	opts := scrapeOptions{ImportPaths: pinnedCobra, OutputDir: dir, TestMode: true, TimeBudget: time.Hour,
+		Schedule: scheduleInput, Progress: &onStored{fn: func() { spend() }}, MaxPending: 1}
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Expected the time-boxed batch to stop cleanly, got %v", err)
+	}
+	checkCheckpoint(t, dir, pinnedCobra)
+	checkpoint, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if got := strings.Fields(string(checkpoint)); err != nil || !reflect.DeepEqual(got, pinnedCobra[1:]) {
+		t.Errorf("Expected the packages not started checkpointed, got %v (%v)", got, err)
+	}
+}
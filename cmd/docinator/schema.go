@@ -0,0 +1,39 @@
+package docinator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/moseye/docinator/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [package|document]",
+	Short: "Print the JSON Schema of the data model",
+	Long: `Print the JSON Schema (draft 2020-12) of a package, as written by
+scrape --format json, or of a cached document (package plus raw HTML). The
+schema is derived from the Go types and versioned by the SchemaVersion field
+every package carries, so consumers of the JSON output can validate it and
+generate code from it:
+
+  docinator schema package > package.schema.json`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"package", "document"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := "package"
+		if len(args) > 0 {
+			name = args[0]
+		}
+		s, err := schema.For(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			return fmt.Errorf("writing the schema failed: %w", err)
+		}
+		return nil
+	},
+}
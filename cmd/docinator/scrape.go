@@ -1,169 +1,767 @@
 package docinator
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"go/build"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
-	"github.com/moseye/docinator/internal/models"
 	mongostore "github.com/moseye/docinator/internal/storage/mongo"
+	"github.com/moseye/docinator/pkg/checksum"
+	"github.com/moseye/docinator/pkg/llm"
+	"github.com/moseye/docinator/pkg/localdoc"
 	"github.com/moseye/docinator/pkg/markdown"
-	"github.com/moseye/docinator/pkg/raw"
+	"github.com/moseye/docinator/pkg/parser"
+	"github.com/moseye/docinator/pkg/postprocess"
+	"github.com/moseye/docinator/pkg/ratelimit"
 	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/site"
+	"github.com/moseye/docinator/pkg/siteprofile"
+	"github.com/moseye/docinator/pkg/source"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/moseye/docinator/pkg/webhook"
 	"github.com/spf13/cobra"
 )
 
+// shutdownGrace bounds how long an interrupted scrape waits for the package in flight.
+const shutdownGrace = 30 * time.Second
+
+// exitInterrupted is the exit status of a scrape stopped by SIGINT or SIGTERM.
+const exitInterrupted = 130
+
+// checkpointFile lists the import paths an interrupted scrape did not finish, one per line.
+const checkpointFile = "docinator.checkpoint"
+
+// errInterrupted is returned by runScrape when its context was cancelled before all packages finished.
+var errInterrupted = errors.New("scrape interrupted")
+
+// afterFunc starts the --time-budget timer; tests replace it to spend the budget on cue.
+var afterFunc = time.AfterFunc
+
+// scrapeOptions holds the flags of the scrape command.
+type scrapeOptions struct {
+	ImportPaths   []string
+	OutputDir     string           // empty writes markdown to the output writer
+	Formats       outputFormats    // files written per package to OutputDir; nil means defaultFormats
+	StdoutFormat  string           // how packages are written without OutputDir: md (default), mdmulti or jsonl
+	Markdown      markdown.Options // renderer options such as --heading-offset
+	Verbosity     int              // number of -v flags: 1 logs progress details, 2 adds request/response logging
+	TestMode      bool
+	HTTPCacheDir  string // on-disk cache for pkg.go.dev responses; empty disables it
+	PkgsiteAPI    string // base URL of a pkgsite JSON API preferred over HTML for metadata; empty disables it
+	NoCache       bool   // scrape every package instead of reading it from the store
+	NoStore       bool   // do not write scraped packages to the store
+	Summarize     bool
+	SummaryPrompt string
+	Importers     int
+	Imports       bool   // record the import paths of the imports tab, for docinator graph
+	Guides        int    // ReadTheDocs pages linked from the README added as guides; 0 disables them
+	ReadmeLang    string // preferred README language; a linked translation in it replaces the README
+	FetchSource   bool
+	LocalSource   bool   // merge doc comments from the module cache or VendorDir
+	VendorDir     string // vendor directory searched with LocalSource
+	ShareExamples bool
+	FailFast      bool                 // abort the batch on the first failed package instead of continuing
+	StrictLayout  bool                 // fail packages and the run on a probable pkg.go.dev layout change
+	SummaryJSON   string               // path of the machine-readable run summary; empty skips it
+	BaseURL       string               // where OutputDir is published; set to write sitemap.xml and robots.txt
+	Archive       string               // .tar.gz or .zip receiving everything in OutputDir; empty skips it
+	PostTo        string               // webhook URL receiving each scraped package as JSON; empty disables it
+	AllowLicenses []string             // license policy; packages with other licenses fail the run. Empty allows all
+	LicensePolicy *licensePolicy       // --license-policy file, extended by AllowLicenses; nil allows all
+	GHA           bool                 // emit GitHub Actions annotations and a job summary
+	Slowest       int                  // number of slowest packages reported at the end of the batch; 0 disables it
+	RateLimit     float64              // pkg.go.dev requests per second, shared through REDIS_URL when set; 0 disables it
+	RandomDelay   time.Duration        // up to this much random delay before each request; 0 disables it
+	MaxPageSize   int64                // pages larger than this many bytes are skipped; 0 uses the scraper default, negative disables it
+	Selectors     *parser.Selectors    // selector profile from --selectors; nil uses the embedded one
+	Profile       parser.Profile       // page sections parsed and stored (--profile); ProfileFull parses them all
+	Site          *siteprofile.Profile // site profile from --site; nil scrapes pkg.go.dev
+	Private       string               // GOPRIVATE-style patterns of packages documented without pkg.go.dev
+	PrivateSite   *siteprofile.Profile // site profile from --private-site; nil reads private packages from local source
+	Progress      io.Writer            // receives NDJSON progress events; nil disables them
+	Flags         map[string][]string  // flags set on the command line, recorded in the manifest
+	SelectorsPath string               // --selectors file, identified in the manifest; empty for the embedded profile
+	Replay        *scrapeManifest      // manifest replayed with --from-manifest; its hashes are checked
+	Lock          *docsLock            // lockfile of --locked; packages deviating from it are not written
+	LockPath      string               // where Lock was read from, for messages
+	Console       *console             // prints a status line per package; nil disables it
+
+	PostProcess postprocess.Chain // run over the rendered markdown of every package (--post-process); nil keeps it as rendered
+
+	MaxPending int // packages held in memory between fetching and writing (--max-pending); 0 is unbounded
+
+	Jobs  int    // packages loaded at once (--jobs); 0 and 1 load one at a time
+	Order string // orderInput (default) writes packages in argument order, orderCompletion as each is ready
+
+	// TimeBudget stops starting packages once the batch has run this long, writing the checkpoint;
+	// 0 runs the whole batch. Schedule and PriorityTags order a time-boxed batch (see scheduleBatch).
+	TimeBudget   time.Duration
+	Schedule     string
+	PriorityTags []string
+}
+
 var scrapeCmd = &cobra.Command{
 	Use:   "scrape [packages...]",
 	Short: "Scrape documentation from Go packages",
 	Long: `Scrape the documentation from one or more Go packages on pkg.go.dev,
 parse the content, and generate markdown files.`,
-	Args: cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		verbose, _ := rootCmd.PersistentFlags().GetBool("verbose")
-		testMode, _ := rootCmd.PersistentFlags().GetBool("test-mode")
-		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
-		log.Printf("TestMode: %v", testMode)
-		log.Printf("Starting scrape command with args: %v, verbose: %v, outputDir: %v", args, verbose, outputDir)
-
-		config := &scraper.ScrapingConfig{
-			Debug:    verbose,
-			TestMode: testMode,
-		}
-		s, err := scraper.New(config)
-		if err != nil {
-			log.Fatalf("Failed to create scraper: %v", err)
+	Args: func(cmd *cobra.Command, args []string) error {
+		if fromManifest, _ := cmd.Flags().GetString("from-manifest"); fromManifest != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("--from-manifest replaces the package arguments")
+			}
+			return nil
 		}
-		defer s.Close()
-		log.Printf("Scraper created successfully")
-
-		ctx := cmd.Context()
-
-		// Initialize MongoDB store (disabled if MONGODB_URI is not set)
-		store, err := mongostore.NewFromEnv(ctx)
-		if err != nil {
-			log.Printf("MongoDB store initialization error (disabled): %v", err)
-			store = nil
+		if locked, _ := cmd.Flags().GetBool("locked"); locked {
+			return nil
 		}
-		if store != nil && store.Enabled() {
-			defer func() {
-				if err := store.Close(ctx); err != nil {
-					log.Printf("MongoDB disconnect error: %v", err)
-				}
-			}()
-		}
-
-		// Scrape packages with both structured data and raw HTML
-		var pkgs []*models.Package
-		var rawHTMLs []string
-		var scrapeErrors []error
-
-		for _, importPath := range args {
-			// 1) Check MongoDB cache first
-			if store != nil && store.Enabled() {
-				doc, err := store.GetByID(ctx, importPath)
-				if err != nil {
-					log.Printf("MongoDB lookup error for %s: %v", importPath, err)
-				} else if doc != nil && doc.Package != nil {
-					pkgs = append(pkgs, doc.Package)
-					rawHTMLs = append(rawHTMLs, doc.RawHTML)
-					if verbose {
-						log.Printf("Loaded from MongoDB cache: %s", importPath)
-					}
-					continue
-				}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var replay *scrapeManifest
+		if fromManifest, _ := cmd.Flags().GetString("from-manifest"); fromManifest != "" {
+			var err error
+			if replay, err = readManifest(fromManifest); err != nil {
+				return fmt.Errorf("--from-manifest: %w", err)
 			}
-
-			// 2) Not cached → scrape
-			pkg, rawHTML, err := s.ScrapePackageWithRaw(ctx, importPath)
-			if err != nil {
-				scrapeErrors = append(scrapeErrors, fmt.Errorf("failed to scrape %s: %w", importPath, err))
-				continue
+			if err := applyManifestFlags(cmd, replay.Flags); err != nil {
+				return fmt.Errorf("--from-manifest: %w", err)
 			}
-			pkgs = append(pkgs, pkg)
-			rawHTMLs = append(rawHTMLs, rawHTML)
-
-			// 3) Persist to MongoDB (upsert) for future runs
-			if store != nil && store.Enabled() {
-				id := importPath
-				if pkg != nil && pkg.ImportPath != "" {
-					id = pkg.ImportPath
-				}
-				doc := &models.Document{
-					ID:      id,
-					Package: pkg,
-					RawHTML: rawHTML,
-				}
-				if err := store.Upsert(ctx, doc); err != nil {
-					log.Printf("MongoDB upsert failed for %s: %v", id, err)
-				} else if verbose {
-					log.Printf("Upserted into MongoDB: %s", id)
+			for _, p := range replay.Packages {
+				args = append(args, p.replayPath())
+			}
+		}
+		var lock *docsLock
+		lockPath, _ := cmd.Flags().GetString("lockfile")
+		if locked, _ := cmd.Flags().GetBool("locked"); locked {
+			if replay != nil {
+				return errors.New("--locked and --from-manifest exclude each other")
+			}
+			var err error
+			if lock, err = readLock(lockPath); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return withHint(fmt.Errorf("--locked: %w", err), "write the lockfile with docinator lock")
 				}
+				return fmt.Errorf("--locked: %w", err)
+			}
+			if err := applyManifestFlags(cmd, lock.Flags); err != nil {
+				return fmt.Errorf("--locked: %w", err)
+			}
+			if args, err = lock.pin(args); err != nil {
+				return withHint(fmt.Errorf("--locked: %w", err), "scrape without --locked and run docinator lock to add packages")
 			}
 		}
-
-		if len(scrapeErrors) > 0 {
-			for _, err := range scrapeErrors {
-				log.Printf("Scraping error: %v", err)
+		opts := scrapeOptions{ImportPaths: args, Replay: replay, Lock: lock, LockPath: lockPath, Flags: changedFlags(cmd)}
+		opts.SelectorsPath, _ = rootCmd.PersistentFlags().GetString("selectors")
+		opts.Verbosity = verbosity()
+		opts.TestMode, _ = rootCmd.PersistentFlags().GetBool("test-mode")
+		opts.OutputDir, _ = rootCmd.PersistentFlags().GetString("output")
+		opts.HTTPCacheDir, _ = rootCmd.PersistentFlags().GetString("http-cache-dir")
+		opts.PkgsiteAPI, _ = rootCmd.PersistentFlags().GetString("pkgsite-api")
+		opts.NoCache, _ = rootCmd.PersistentFlags().GetBool("no-cache")
+		opts.NoStore, _ = rootCmd.PersistentFlags().GetBool("no-store")
+		opts.Summarize, _ = cmd.Flags().GetBool("summarize")
+		opts.SummaryPrompt, _ = cmd.Flags().GetString("summary-prompt")
+		opts.Importers, _ = cmd.Flags().GetInt("importers")
+		opts.Imports, _ = cmd.Flags().GetBool("imports")
+		opts.Guides, _ = cmd.Flags().GetInt("guides")
+		opts.ReadmeLang, _ = cmd.Flags().GetString("readme-lang")
+		opts.FetchSource, _ = cmd.Flags().GetBool("fetch-source")
+		opts.LocalSource, _ = cmd.Flags().GetBool("local-source")
+		opts.VendorDir, _ = cmd.Flags().GetString("vendor-dir")
+		opts.ShareExamples, _ = cmd.Flags().GetBool("share-examples")
+		opts.FailFast, _ = cmd.Flags().GetBool("fail-fast")
+		opts.StrictLayout, _ = cmd.Flags().GetBool("strict-layout")
+		opts.SummaryJSON, _ = cmd.Flags().GetString("summary-json")
+		opts.BaseURL, _ = cmd.Flags().GetString("base-url")
+		opts.Archive, _ = cmd.Flags().GetString("archive")
+		opts.PostTo, _ = cmd.Flags().GetString("post-to")
+		opts.AllowLicenses, _ = cmd.Flags().GetStringSlice("allow-licenses")
+		if policyFile, _ := cmd.Flags().GetString("license-policy"); policyFile != "" {
+			policy, err := loadLicensePolicy(policyFile)
+			if err != nil {
+				return fmt.Errorf("--license-policy: %w", err)
 			}
-			if len(pkgs) == 0 {
-				log.Fatalf("All scraping attempts failed")
+			opts.LicensePolicy = policy
+		}
+		opts.GHA, _ = cmd.Flags().GetBool("gha")
+		opts.Slowest, _ = cmd.Flags().GetInt("slowest")
+		opts.MaxPending, _ = cmd.Flags().GetInt("max-pending")
+		opts.Jobs, _ = cmd.Flags().GetInt("jobs")
+		opts.Order, _ = cmd.Flags().GetString("order")
+		if opts.Order != orderInput && opts.Order != orderCompletion {
+			return fmt.Errorf("--order must be %s or %s, got %q", orderInput, orderCompletion, opts.Order)
+		}
+		opts.TimeBudget, _ = cmd.Flags().GetDuration("time-budget")
+		opts.Schedule, _ = cmd.Flags().GetString("schedule")
+		priorityTags, _ := cmd.Flags().GetStringSlice("priority-tag")
+		opts.PriorityTags = normalizeTags(priorityTags)
+		switch {
+		case opts.TimeBudget < 0:
+			return fmt.Errorf("--time-budget must not be negative, got %s", opts.TimeBudget)
+		case opts.Schedule != scheduleMissesFirst && opts.Schedule != scheduleSmallestFirst && opts.Schedule != scheduleInput:
+			return fmt.Errorf("--schedule must be %s, %s or %s, got %q", scheduleMissesFirst, scheduleSmallestFirst, scheduleInput, opts.Schedule)
+		case opts.TimeBudget == 0 && (cmd.Flags().Changed("schedule") || len(opts.PriorityTags) > 0):
+			return withHint(errors.New("--schedule and --priority-tag order a time-boxed batch"), "pass --time-budget, e.g. --time-budget 15m")
+		}
+		opts.RateLimit, _ = rootCmd.PersistentFlags().GetFloat64("rate-limit")
+		opts.RandomDelay, _ = rootCmd.PersistentFlags().GetDuration("random-delay")
+		opts.MaxPageSize = maxResponseSize()
+		formats, _ := cmd.Flags().GetStringSlice("format")
+		var err error
+		if opts.Formats, err = parseFormats(formats); err != nil {
+			return fmt.Errorf("--format: %w", err)
+		}
+		if opts.Selectors, err = loadSelectors(); err != nil {
+			return fmt.Errorf("--selectors: %w", err)
+		}
+		profile, _ := cmd.Flags().GetString("profile")
+		if opts.Profile, err = parser.ParseProfile(profile); err != nil {
+			return fmt.Errorf("--profile: %w", err)
+		}
+		if opts.Site, err = loadSite(); err != nil {
+			return fmt.Errorf("--site: %w", err)
+		}
+		if opts.Site == nil {
+			opts.ImportPaths = normalizedImportPaths(opts.ImportPaths)
+			opts.Private = privatePatterns()
+			if opts.PrivateSite, err = loadSiteFlags("private-site", "private-site-base"); err != nil {
+				return fmt.Errorf("--private-site: %w", err)
+			}
+		}
+		opts.StdoutFormat, _ = cmd.Flags().GetString("stdout-format")
+		if opts.Markdown, err = renderOptions(cmd); err != nil {
+			return err
+		}
+		if opts.PostProcess, err = postProcessors(cmd); err != nil {
+			return fmt.Errorf("post-processing: %w", err)
+		}
+		if !slices.Contains(stdoutFormats, opts.StdoutFormat) {
+			return fmt.Errorf("--stdout-format must be one of %s, got %q", strings.Join(stdoutFormats, ", "), opts.StdoutFormat)
+		}
+		if opts.Archive != "" && opts.OutputDir == "" {
+			return withHint(errors.New("--archive needs an output directory"), "pass --output")
+		}
+		if opts.Lock != nil && opts.OutputDir == "" {
+			return withHint(errors.New("--locked needs an output directory"), "pass --output")
+		}
+		if progressJSON, _ := cmd.Flags().GetBool("progress-json"); progressJSON {
+			opts.Progress = os.Stderr
+		} else if isTerminal(os.Stderr) {
+			noColor, _ := rootCmd.PersistentFlags().GetBool("no-color")
+			opts.Console = newConsole(os.Stderr, useColor(noColor))
+			// Replace the log stream with status lines, keeping warnings, unless more detail was asked for.
+			logFile, _ := rootCmd.PersistentFlags().GetString("log-file")
+			if opts.Verbosity == 0 && logFile == "" {
+				log.SetOutput(opts.Console.logFilter())
+				defer log.SetOutput(os.Stderr)
 			}
 		}
+		log.Printf("TestMode: %v", opts.TestMode)
+		log.Printf("Starting scrape command with args: %v, verbosity: %d, outputDir: %v", args, opts.Verbosity, opts.OutputDir)
 
-		log.Printf("Successfully scraped %d packages", len(pkgs))
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		priority, err := requestPriority(ratelimit.Normal)
+		if err != nil {
+			return err
+		}
+		ctx = ratelimit.WithPriority(ctx, priority)
 
-		if outputDir == "" {
-			// Output to stdout (markdown only for readability)
-			for _, pkg := range pkgs {
-				log.Printf("Generating markdown for package: %s", pkg.ImportPath)
-				cmd.Print(markdown.PackageToMarkdown(pkg))
-			}
+		store, closeStore, err := openStore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		err = runScrape(ctx, opts, store, cmd.OutOrStdout())
+		closeStore()
+		if errors.Is(err, errInterrupted) {
+			log.Printf("%v; resume with the import paths in %s", err, checkpointPath(opts.OutputDir))
+			stopProfiling()
+			os.Exit(exitInterrupted)
+		}
+		if errors.Is(err, errLicensePolicy) {
+			log.Print(err)
+			stopProfiling()
+			os.Exit(exitLicensePolicy)
+		}
+		return err
+	},
+}
+
+// runScrape loads opts.ImportPaths through store and writes markdown to out, or markdown and raw files to opts.OutputDir.
+// By default failed packages are skipped and reported together at the end, and runScrape fails only when no
+// package could be loaded; with opts.FailFast the first failure aborts the batch. When ctx is cancelled, no new package is started, the one in flight
+// gets up to shutdownGrace to finish, completed packages are still written, and errInterrupted is returned after
+// the unfinished import paths are saved to the checkpoint file.
+func runScrape(ctx context.Context, opts scrapeOptions, store storage.Store, out io.Writer) error {
+	start := time.Now()
+	verbose := opts.Verbosity >= 1
+	limiter, closeLimiter, err := newRateLimiter(ctx, opts.RateLimit)
+	if err != nil {
+		return err
+	}
+	defer closeLimiter()
+	// One connection pool serves pkg.go.dev and every other download of the batch.
+	transport := scraper.NewTransport(&scraper.ScrapingConfig{})
+	defer transport.CloseIdleConnections()
+	loader, cleanup, err := newLoader(store, &scraper.ScrapingConfig{
+		Debug:           opts.Verbosity >= 2,
+		TestMode:        opts.TestMode,
+		CacheDir:        opts.HTTPCacheDir,
+		MaxRetries:      scraper.DefaultConfig().MaxRetries,
+		RandomDelay:     opts.RandomDelay,
+		Transport:       transport,
+		MaxResponseSize: opts.MaxPageSize,
+		Limiter:         limiter,
+		Selectors:       opts.Selectors,
+		Profile:         opts.Profile,
+		Site:            opts.Site,
+		StrictLayout:    opts.StrictLayout,
+		API:             opts.PkgsiteAPI,
+	}, verbose)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	loader.noCache, loader.noStore = opts.NoCache, opts.NoStore
+	loader.pending = &upsertQueue{}
+	// The tabs are fetched alongside each page; the enrichers below still fill in cache hits.
+	loader.tabs.Importers = opts.Importers
+	loader.tabs.Imports = opts.Imports
+	if opts.Site == nil {
+		loader.private = newPrivateRouter(opts.Private, opts.PrivateSite, defaultPrivateFinder(opts.VendorDir))
+	}
+	log.Printf("Scraper created successfully")
+
+	progress := newProgressReporter(opts.Progress, opts.Console)
+	loader.progress = progress
+	for _, importPath := range opts.ImportPaths {
+		progress.emit(progressEvent{Event: eventQueued, ImportPath: importPath})
+	}
+
+	// Optional LLM summarization pass (requires LLM_BASE_URL or LLM_API_KEY)
+	if opts.Summarize {
+		client := llm.NewFromEnv()
+		if client == nil {
+			log.Printf("Summarization requested but no LLM endpoint configured (set LLM_BASE_URL or LLM_API_KEY); skipping")
 		} else {
-			// Output to files - both markdown and raw versions
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				log.Fatalf("Failed to create output dir: %v", err)
-			}
+			loader.enrichers = append(loader.enrichers, summarizeEnricher(llm.NewSummarizer(client, opts.SummaryPrompt)))
+		}
+	}
+	if opts.Importers > 0 {
+		loader.enrichers = append(loader.enrichers, importersEnricher(loader.scraper, opts.Importers))
+	}
+	if opts.Imports {
+		loader.enrichers = append(loader.enrichers, importsEnricher(loader.scraper))
+	}
+	if opts.Guides > 0 {
+		loader.enrichers = append(loader.enrichers, guidesEnricher(loader.scraper, opts.Guides))
+	}
+	if opts.ReadmeLang != "" {
+		loader.enrichers = append(loader.enrichers, readmeLangEnricher(&http.Client{Timeout: 30 * time.Second, Transport: transport}, opts.ReadmeLang))
+	}
+	if opts.FetchSource {
+		loader.enrichers = append(loader.enrichers, sourceEnricher(source.NewFetcher(&http.Client{Timeout: 30 * time.Second, Transport: transport})))
+	}
+	if opts.LocalSource {
+		loader.enrichers = append(loader.enrichers, localSourceEnricher(localdoc.Finder{
+			ModCache: localdoc.DefaultModCache(), VendorDir: opts.VendorDir, GOROOT: build.Default.GOROOT}))
+	}
+	if opts.ShareExamples {
+		loader.enrichers = append(loader.enrichers, playgroundEnricher(&http.Client{Timeout: 30 * time.Second, Transport: transport}))
+	}
 
-			for i, pkg := range pkgs {
-				log.Printf("Generating both formats for package: %s", pkg.ImportPath)
+	if opts.OutputDir != "" {
+		if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output dir: %w", err)
+		}
+	}
 
-				// Generate markdown file
-				markdownFilename := fmt.Sprintf("%s/%s.md", outputDir, pkg.ImportPath)
-				markdownContent := markdown.PackageToMarkdown(pkg)
+	if err := linkCorpusTypes(ctx, store, &opts.Markdown); err != nil {
+		return err
+	}
 
-				markdownDir := filepath.Dir(markdownFilename)
-				if err := os.MkdirAll(markdownDir, 0755); err != nil {
-					log.Printf("Failed to create markdown dir %s: %v", markdownDir, err)
-				}
+	// Fetch, render and write in overlapping stages: markdown for earlier packages is
+	// generated and written while later ones are still being scraped.
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Printf("Interrupted; finishing the package in flight (up to %s)", shutdownGrace)
+			select {
+			case <-time.After(shutdownGrace):
+				cancelWork()
+			case <-workCtx.Done():
+			}
+		case <-workCtx.Done():
+		}
+	}()
 
-				if err := os.WriteFile(markdownFilename, []byte(markdownContent), 0644); err != nil {
-					log.Printf("Failed to write markdown file %s: %v", markdownFilename, err)
-				} else if verbose {
-					log.Printf("Wrote markdown: %s", markdownFilename)
-				}
+	// stopBatch ends the batch early for --fail-fast without counting as an interruption.
+	stopCtx, stopBatch := context.WithCancel(ctx)
+	defer stopBatch()
 
-				// Generate raw HTML file
-				rawFilename := fmt.Sprintf("%s/%s_raw.txt", outputDir, pkg.ImportPath)
-				rawContent := raw.PackageToRaw(pkg, rawHTMLs[i])
+	// With --time-budget the batch runs in schedule order and stops starting packages once the
+	// budget is spent; the packages left are checkpointed like an interruption's.
+	importPaths := opts.ImportPaths
+	var budgetSpent atomic.Bool
+	if opts.TimeBudget > 0 {
+		if importPaths, err = scheduleBatch(ctx, store, opts.ImportPaths, opts.Schedule, opts.PriorityTags, opts.NoCache); err != nil {
+			return err
+		}
+		spend := func() {
+			budgetSpent.Store(true)
+			log.Printf("Time budget of %s spent; finishing the packages in flight", opts.TimeBudget)
+			stopBatch()
+		}
+		// Setup and scheduling count against the budget too.
+		if remaining := opts.TimeBudget - time.Since(start); remaining > 0 {
+			budget := afterFunc(remaining, spend)
+			defer budget.Stop()
+		} else {
+			spend()
+		}
+	}
 
-				rawDir := filepath.Dir(rawFilename)
-				if err := os.MkdirAll(rawDir, 0755); err != nil {
-					log.Printf("Failed to create raw dir %s: %v", rawDir, err)
-				}
+	var failed []packageFailure
+	var firstErr error
+	loaded := loader.stream(workCtx, stopCtx, importPaths, newPayloadBudget(opts.MaxPending), opts.Jobs, opts.Order != orderCompletion)
+	// Rendered with the recorded scrape times, unchanged packages come out byte for byte as locked
+	// or replayed.
+	switch {
+	case opts.Lock != nil:
+		loaded = stampScrapedAt(workCtx, loaded, opts.Lock.Packages)
+	case opts.Replay != nil:
+		loaded = stampScrapedAt(workCtx, loaded, opts.Replay.Packages)
+	}
+	var formats outputFormats
+	if opts.OutputDir != "" {
+		formats = opts.Formats
+		if formats == nil {
+			formats = defaultFormats
+		}
+	}
+	rendered := renderStage(workCtx, loaded, formats, opts.Markdown, opts.PostProcess, func(importPath string, err error) {
+		if verbose {
+			log.Printf("Scraping error: %v", err)
+		}
+		failure := newPackageFailure(importPath, err)
+		failed = append(failed, failure)
+		progress.emit(progressEvent{Event: eventFailed, ImportPath: importPath, Error: failure.Error})
+		if opts.FailFast && firstErr == nil {
+			firstErr = err
+			stopBatch()
+			cancelWork()
+		}
+	})
 
-				if err := os.WriteFile(rawFilename, []byte(rawContent), 0644); err != nil {
-					log.Printf("Failed to write raw file %s: %v", rawFilename, err)
-				} else if verbose {
-					log.Printf("Wrote raw version: %s", rawFilename)
-				}
+	var hookClient *http.Client
+	if opts.PostTo != "" {
+		hookClient = &http.Client{Timeout: 30 * time.Second, Transport: transport}
+	}
+	secret := []byte(os.Getenv("WEBHOOK_SECRET"))
+
+	written := 0
+	var files writeCounts
+	done := make(map[string]bool) // by import path as requested, so pinned versions count apart
+	var index []site.SearchEntry
+	var timings []packageTiming
+	var deprecated, retracted []string
+	deprecatedSyms := map[string][]string{}
+	var violations []licenseViolation
+	policy := scrapeLicensePolicy(opts)
+	var manifestPkgs []manifestPackage
+	var deviations []packageFailure
+	for r := range rendered {
+		storeStart := time.Now()
+		if opts.Replay != nil && r.version != "" && opts.Replay.unpinned(r.pkg.ImportPath, r.version) {
+			r.version = ""
+		}
+		if opts.Lock != nil {
+			if r.version != "" && opts.Lock.unpinned(r.pkg.ImportPath, r.version) {
+				r.version = ""
+			}
+			if err := opts.Lock.check(r, formats); err != nil {
+				// Written output must be byte-identical to the locked one; a deviating package is left out.
+				failure := newPackageFailure(r.importPath, err)
+				deviations = append(deviations, failure)
+				progress.emit(progressEvent{Event: eventFailed, ImportPath: r.importPath, Error: failure.Error})
+				done[r.importPath] = true
+				r.budget.release()
+				continue
+			}
+		}
+		done[r.importPath] = true
+		progress.emit(progressEvent{Event: eventRendered, ImportPath: r.pkg.ImportPath})
+		if opts.OutputDir == "" {
+			// Output to stdout (markdown only for readability)
+			log.Printf("Generating markdown for package: %s", r.pkg.ImportPath)
+			if err := writeStdoutDocument(out, opts.StdoutFormat, r); err != nil {
+				log.Printf("Failed to write %s: %v", r.pkg.ImportPath, err)
+			}
+		} else {
+			// Output to files in every requested format
+			log.Printf("Writing %s for package: %s", strings.Join(formats.names(), ", "), r.pkg.ImportPath)
+			files.add(writeRendered(opts.OutputDir, r, formats, verbose))
+			if formats[formatMarkdown] {
+				index = append(index, site.NewSearchEntry(r.pkg, outputPage(r)))
+			}
+		}
+		if hookClient != nil {
+			if err := webhook.Post(workCtx, hookClient, opts.PostTo, secret, r.pkg); err != nil {
+				log.Printf("Failed to post %s: %v", r.pkg.ImportPath, err)
+			}
+		}
+		progress.emit(progressEvent{Event: eventStored, ImportPath: r.pkg.ImportPath})
+		r.timing.Store += time.Since(storeStart)
+		r.budget.release()
+		timings = append(timings, r.timing)
+		if r.pkg.ModuleDeprecated {
+			deprecated = append(deprecated, r.pkg.ImportPath)
+		}
+		if r.pkg.Retracted {
+			retracted = append(retracted, r.pkg.ImportPath)
+		}
+		if names := deprecatedSymbols(r.pkg); len(names) > 0 {
+			deprecatedSyms[r.pkg.ImportPath] = names
+		}
+		if policy != nil {
+			if rule, ok := policy.check(r.pkg.License); !ok {
+				violations = append(violations, licenseViolation{ImportPath: r.pkg.ImportPath, License: r.pkg.License, Rule: rule})
 			}
 		}
+		if opts.OutputDir != "" {
+			manifestPkgs = append(manifestPkgs, newManifestPackage(r, formats))
+		}
+		written++
+	}
+	// The render stage is done, so failed can take the deviations without racing its onError.
+	failed = append(failed, deviations...)
+	// Upserts that failed during the batch get another chance now; what the store still refuses is spilled.
+	stored, spilled, err := loader.flushPending(context.WithoutCancel(ctx), spillPath(opts.OutputDir))
+	if err != nil {
+		log.Printf("%v", err)
+	}
+	if stored > 0 {
+		log.Printf("Stored %d document(s) whose upsert failed earlier in the batch", stored)
+	}
+	if spilled > 0 {
+		log.Printf("WARNING: the store is unavailable; %d document(s) spilled to %s, load them with: docinator import %s", spilled, spillPath(opts.OutputDir), spillPath(opts.OutputDir))
+	}
+	if opts.OutputDir != "" && written > 0 {
+		manifest := &scrapeManifest{DocinatorVersion: docinatorVersion(), CreatedAt: start.UTC(), Inputs: opts.ImportPaths, Flags: opts.Flags, Packages: manifestPkgs}
+		if manifest.SelectorsVersion, manifest.SelectorsSHA256, err = selectorsIdentity(opts.SelectorsPath); err != nil {
+			log.Printf("Failed to identify the selector profile: %v", err)
+		}
+		if err := writeManifest(opts.OutputDir, manifest); err != nil {
+			log.Printf("Failed to write %s: %v", manifestFile, err)
+		}
+		if opts.Replay != nil {
+			compareManifest(opts.Replay, manifest)
+		}
+	}
+	if len(index) > 0 {
+		if err := site.UpdateSearchIndex(opts.OutputDir, index); err != nil {
+			log.Printf("Failed to update the search index: %v", err)
+		}
+		if opts.BaseURL != "" {
+			if err := site.WriteSitemap(opts.OutputDir, opts.BaseURL); err != nil {
+				log.Printf("Failed to write the sitemap: %v", err)
+			}
+		}
+		if _, err := checksum.Update(opts.OutputDir); err != nil {
+			log.Printf("Failed to write %s: %v", checksum.File, err)
+		}
+	}
+	if opts.Archive != "" && written > 0 {
+		if err := writeArchive(opts.OutputDir, opts.Archive); err != nil {
+			log.Printf("Failed to write archive %s: %v", opts.Archive, err)
+		}
+	}
+	// failed is complete: the render stage closed rendered after its last onError call.
+	stats := loader.scraper.GetStats()
+	slowest := slowestPackages(timings, opts.Slowest)
+	summary := &runSummary{
+		Attempted:       len(opts.ImportPaths),
+		Succeeded:       written,
+		Failed:          failed,
+		CacheHits:       int(loader.cacheHits.Load()),
+		CacheMisses:     int(loader.cacheMisses.Load()),
+		BytesDownloaded: stats.BytesDownloaded,
+		DurationSeconds: time.Since(start).Seconds(),
+		Interrupted:     ctx.Err() != nil,
+		BudgetSpent:     budgetSpent.Load(),
+		StartedAt:       start,
+		Scraper:         newScraperSummary(stats),
+		Slowest:         newTimingSummaries(slowest),
+		Deprecated:      deprecated,
+		Retracted:       retracted,
+		FilesChanged:    files.Changed,
+		FilesUnchanged:  files.Unchanged,
+		Spilled:         spilled,
+	}
+	if len(deprecatedSyms) > 0 {
+		summary.DeprecatedSymbols = deprecatedSyms
+	}
+	summary.LicenseViolations = violations
+	summary.Private = loader.private.report()
+	if opts.SummaryJSON != "" {
+		if err := writeSummary(opts.SummaryJSON, summary); err != nil {
+			log.Printf("Failed to write summary %s: %v", opts.SummaryJSON, err)
+		}
+	}
+	if opts.GHA {
+		writeGHAAnnotations(out, summary)
+		if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+			if err := appendStepSummary(path, summary); err != nil {
+				log.Printf("Failed to write the job summary: %v", err)
+			}
+		}
+	}
+	if mongo, ok := store.(*mongostore.Store); ok && mongo.Enabled() {
+		// The batch context may be cancelled by now; the record is still worth keeping.
+		if err := mongo.InsertRun(context.WithoutCancel(ctx), newRunRecord(summary, opts)); err != nil {
+			log.Printf("Failed to record the run: %v", err)
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("batch aborted (--fail-fast) after %d package(s): %w", written, firstErr)
+	}
+	if len(failed) > 0 {
+		log.Printf("%d of %d package(s) failed:", len(failed), len(opts.ImportPaths))
+		for _, f := range failed {
+			log.Printf("  %s: %s", f.ImportPath, f.Error)
+		}
+	}
+	logSlowest(slowest)
+	for _, p := range summary.Private {
+		log.Printf("%s is private (GOPRIVATE); documented from %s", p.ImportPath, p.Source)
+	}
+	if ctx.Err() != nil && len(done) < len(opts.ImportPaths) {
+		if err := writeCheckpoint(opts.OutputDir, opts.ImportPaths, done); err != nil {
+			log.Printf("Failed to write checkpoint: %v", err)
+		}
+		log.Printf("Wrote %d of %d packages before the interruption", written, len(opts.ImportPaths))
+		return errInterrupted
+	}
+	if budgetSpent.Load() && len(done) < len(opts.ImportPaths) {
+		if err := writeCheckpoint(opts.OutputDir, importPaths, done); err != nil {
+			log.Printf("Failed to write checkpoint: %v", err)
+		}
+		log.Printf("Wrote %d of %d packages within the time budget; continue with the import paths in %s", written, len(opts.ImportPaths), checkpointPath(opts.OutputDir))
+		if written == 0 {
+			return withHint(fmt.Errorf("the time budget of %s ran out before any package was written", opts.TimeBudget), "raise --time-budget")
+		}
+	}
+	if opts.Lock != nil && len(failed) > 0 {
+		return withHint(fmt.Errorf("%d of %d locked package(s) failed or deviate from %s", len(failed), len(opts.ImportPaths), opts.LockPath),
+			"run docinator lock after a deliberate change to accept the new output")
+	}
+	if written == 0 {
+		return errors.New("all scraping attempts failed")
+	}
+	log.Printf("Successfully scraped %d packages", written)
+	if opts.OutputDir != "" {
+		log.Printf("Output files: %d changed, %d unchanged", files.Changed, files.Unchanged)
+	}
+	if len(deprecated) > 0 {
+		log.Printf("WARNING: %d package(s) belong to a deprecated module: %s", len(deprecated), strings.Join(deprecated, ", "))
+	}
+	if len(retracted) > 0 {
+		log.Printf("WARNING: %d package(s) are at a retracted version: %s", len(retracted), strings.Join(retracted, ", "))
+	}
+	if len(violations) > 0 {
+		for _, v := range violations {
+			log.Printf("License policy violation: %s", violationMessage(v))
+		}
+		if !policy.ReportOnly {
+			return fmt.Errorf("%d package(s) violate the license policy: %w", len(violations), errLicensePolicy)
+		}
+	}
+	if n := len(stats.LayoutWarnings); n > 0 {
+		log.Printf("WARNING: %d page(s) looked like a pkg.go.dev layout change: %s", n, strings.Join(stats.LayoutWarnings, ", "))
+		if opts.StrictLayout {
+			return fmt.Errorf("%w on %d page(s)", scraper.ErrLayoutChanged, n)
+		}
+	}
 
-		if verbose {
-			stats := s.GetStats()
-			log.Printf("Scraped %d packages, %d requests, %d errors", stats.PackagesScraped, stats.RequestsMade, stats.Errors)
+	if verbose {
+		log.Printf("Scraped %d packages, %d requests, %d errors %v, %d retries, %d bytes",
+			stats.PackagesScraped, stats.RequestsMade, stats.Errors, stats.ErrorsByClass, stats.Retries, stats.BytesDownloaded)
+		log.Printf("Cache: store %s, HTTP %s, %d not modified (304)",
+			hitRate(int(loader.cacheHits.Load()), int(loader.cacheMisses.Load())), hitRate(stats.CacheHits, stats.CacheMisses), stats.NotModified)
+		log.Printf("Request latency: avg %v, p50 %v, p95 %v, max %v", stats.LatencyAvg, stats.LatencyP50, stats.LatencyP95, stats.LatencyMax)
+		log.Printf("Connections: %d opened, %d reused", stats.ConnsOpened, stats.ConnsReused)
+	}
+	return nil
+}
+
+// checkpointPath returns where the checkpoint is written: the output directory, or the working directory for stdout output.
+func checkpointPath(outputDir string) string {
+	if outputDir == "" {
+		return checkpointFile
+	}
+	return filepath.Join(outputDir, checkpointFile)
+}
+
+// writeCheckpoint saves the import paths not in done, so an interrupted batch can be resumed with
+// "docinator scrape $(cat docinator.checkpoint)".
+func writeCheckpoint(outputDir string, importPaths []string, done map[string]bool) error {
+	var b strings.Builder
+	for _, p := range importPaths {
+		if !done[p] {
+			b.WriteString(p + "\n")
 		}
-	},
+	}
+	return os.WriteFile(checkpointPath(outputDir), []byte(b.String()), 0644)
+}
+
+func init() {
+	scrapeCmd.Flags().Bool("summarize", false, "generate an LLM summary of each package (requires LLM_BASE_URL or LLM_API_KEY)")
+	scrapeCmd.Flags().Int("importers", 0, "capture up to N importing packages from the importedby tab (0 disables)")
+	scrapeCmd.Flags().String("profile", "full", "page sections parsed and stored: full, readme (metadata, overview and README) or metadata (header metadata and synopsis only)")
+	scrapeCmd.Flags().Bool("imports", false, "capture the import paths of the imports tab, for docinator graph")
+	scrapeCmd.Flags().Int("guides", 0, "add up to N ReadTheDocs pages linked from the README as guide sections (0 disables)")
+	scrapeCmd.Flags().String("readme-lang", "", "prefer the README in this language, e.g. en or zh-CN, when the README links a translation in it")
+	scrapeCmd.Flags().Bool("local-source", false, "merge full doc comments and struct field docs from the package source in the module cache or --vendor-dir, when it matches the scraped version")
+	scrapeCmd.Flags().String("vendor-dir", "vendor", "vendor directory searched by --local-source before the module cache")
+	scrapeCmd.Flags().Bool("fetch-source", false, "download declaration source from the repository and embed it under collapsible Source sections")
+	scrapeCmd.Flags().Bool("share-examples", false, "upload examples without a Playground link to the Go Playground and store the permalink")
+	scrapeCmd.Flags().Bool("fail-fast", false, "abort the batch on the first failed package (default: continue and report failures at the end)")
+	scrapeCmd.Flags().Bool("strict-layout", false, "fail packages whose page looks like a pkg.go.dev layout change (name, version or all documentation missing) and exit non-zero")
+	scrapeCmd.Flags().String("summary-json", "", "write a machine-readable summary of the batch (counts, failures, cache hits, bytes, duration) to this file")
+	scrapeCmd.Flags().Bool("progress-json", false, "emit one JSON event per package lifecycle step (queued, fetching, parsed, rendered, stored, failed) to stderr")
+	scrapeCmd.Flags().String("base-url", "", "URL the output directory is published at; writes sitemap.xml and robots.txt covering all its pages")
+	scrapeCmd.Flags().String("from-manifest", "", "replay the scrape recorded in a "+manifestFile+" file: same packages at the versions it recorded, same flags unless given again, and check the output hashes")
+	scrapeCmd.Flags().Bool("locked", false, "scrape the packages of the lockfile (or the given ones) at their locked versions and flags, refusing to write any whose files deviate from it")
+	scrapeCmd.Flags().String("lockfile", lockFile, "lockfile read by --locked (see docinator lock)")
+	scrapeCmd.Flags().String("archive", "", "also pack the output directory and a manifest into this .tar.gz or .zip file")
+	scrapeCmd.Flags().StringSlice("allow-licenses", nil, "license policy: fail the run when a package has a license not in this list, e.g. MIT,Apache-2.0,BSD-3-Clause")
+	scrapeCmd.Flags().String("license-policy", "", "YAML license policy with allow and deny lists of SPDX identifiers or patterns; violations exit with status 3")
+	scrapeCmd.Flags().Bool("gha", false, "emit GitHub Actions ::error/::warning annotations for failures, deprecations and license violations, and a job summary to $GITHUB_STEP_SUMMARY")
+	scrapeCmd.Flags().String("post-to", "", "POST the JSON of every scraped package to this URL, signed with HMAC-SHA256 when WEBHOOK_SECRET is set")
+	scrapeCmd.Flags().StringSlice("format", []string{formatMarkdown, formatRaw}, "comma-separated formats written per package with --output: md, raw, json, html")
+	addRenderFlags(scrapeCmd)
+	scrapeCmd.Flags().String("stdout-format", stdoutMarkdown, "how packages are written to stdout without --output: md (concatenated), mdmulti (framed by header and end lines) or jsonl")
+	scrapeCmd.Flags().Int("jobs", 1, "load up to N packages at once; --rate-limit and the request delay still pace pkg.go.dev")
+	scrapeCmd.Flags().String("order", orderInput, "order packages are written in: input (argument order) or completion (each as soon as it is ready)")
+	scrapeCmd.Flags().Duration("time-budget", 0, "stop starting packages after this long, e.g. 15m, finishing those in flight and writing the checkpoint (default no limit)")
+	scrapeCmd.Flags().String("schedule", scheduleMissesFirst, "order of a --time-budget batch: misses-first (cache misses, then cached packages smallest first), smallest-first or input")
+	scrapeCmd.Flags().StringSlice("priority-tag", nil, "with --time-budget, start with the packages carrying any of these tags (see docinator tag)")
+	scrapeCmd.Flags().Int("max-pending", defaultMaxPending, "hold at most N fetched packages, raw HTML included, in memory until they are written; fetching pauses while N wait (0 for no limit)")
+	scrapeCmd.Flags().Int("slowest", 5, "report the N packages that took longest (fetch, parse, render, store) at the end of the batch; 0 disables it")
+	scrapeCmd.Flags().String("summary-prompt", os.Getenv("LLM_SUMMARY_PROMPT"), "system prompt used for summaries (default built-in prompt)")
 }
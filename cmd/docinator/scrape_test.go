@@ -2,7 +2,26 @@ package docinator
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/checksum"
+	"github.com/moseye/docinator/pkg/health"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/postprocess"
+	"github.com/moseye/docinator/pkg/webhook"
 )
 
 func TestScrapeCommand(t *testing.T) {
@@ -19,7 +38,7 @@ func TestScrapeCommand(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var buf bytes.Buffer
-			rootCmd.SetArgs(append([]string{"scrape", "--test-mode"}, tt.args...) )
+			rootCmd.SetArgs(append([]string{"scrape", "--test-mode", "--store", "memory"}, tt.args...) )
 			scrapeCmd.SetOut(&buf)
 			err := rootCmd.Execute()
 			if err != nil {
@@ -30,4 +49,666 @@ func TestScrapeCommand(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestRunScrape_UsesStore(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true}
+
+	var buf bytes.Buffer
+	if err := runScrape(ctx, opts, store, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("Expected markdown output, got empty")
+	}
+	doc, err := store.GetByID(ctx, "github.com/spf13/cobra")
+	if err != nil || doc == nil || doc.Package == nil {
+		t.Fatalf("Expected the scraped package to be stored, got %v, %v", doc, err)
+	}
+
+	// A cached package is served from the store, so mutations show up in the output.
+	doc.Package.Synopsis = "Served from the cache."
+	if err := store.Upsert(ctx, doc); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	buf.Reset()
+	if err := runScrape(ctx, opts, store, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "Served from the cache.") {
+		t.Error("Expected the second run to load the package from the store")
+	}
+}
+
+func TestRunScrape_NoCacheNoStore(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true, NoStore: true}
+
+	var buf bytes.Buffer
+	if err := runScrape(ctx, opts, store, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if doc, _ := store.GetByID(ctx, "github.com/spf13/cobra"); doc != nil {
+		t.Error("Expected --no-store to leave the store untouched")
+	}
+
+	opts.NoStore = false
+	if err := runScrape(ctx, opts, store, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	doc, err := store.GetByID(ctx, "github.com/spf13/cobra")
+	if err != nil || doc == nil {
+		t.Fatalf("Expected the package to be stored, got %v, %v", doc, err)
+	}
+	doc.Package.Synopsis = "Served from the cache."
+	if err := store.Upsert(ctx, doc); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	opts.NoCache = true
+	buf.Reset()
+	if err := runScrape(ctx, opts, store, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(buf.String(), "Served from the cache.") {
+		t.Error("Expected --no-cache to scrape the package again")
+	}
+	if doc, _ := store.GetByID(ctx, "github.com/spf13/cobra"); doc == nil || doc.Package.Synopsis == "Served from the cache." {
+		t.Error("Expected the fresh scrape to replace the cached package")
+	}
+}
+
+func TestRenderStage_PreservesOrder(t *testing.T) {
+	in := make(chan loadResult, 3)
+	in <- loadResult{importPath: "a", pkg: &models.Package{Name: "a", ImportPath: "a"}}
+	in <- loadResult{importPath: "b", err: errors.New("boom")}
+	in <- loadResult{importPath: "c", pkg: &models.Package{Name: "c", ImportPath: "c"}}
+	close(in)
+
+	var errs []error
+	var got []string
+	for r := range renderStage(context.Background(), in, defaultFormats, markdown.Options{}, nil, func(_ string, err error) { errs = append(errs, err) }) {
+		if r.markdown == "" || r.raw == "" {
+			t.Errorf("Expected markdown and raw output for %s", r.pkg.ImportPath)
+		}
+		got = append(got, r.pkg.ImportPath)
+	}
+	if strings.Join(got, ",") != "a,c" {
+		t.Errorf("Expected rendered packages a,c in order, got %v", got)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 load error, got %d", len(errs))
+	}
+}
+
+func TestRunScrape_Formats(t *testing.T) {
+	formats, err := parseFormats([]string{"md", "json", "HTML"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := parseFormats([]string{"pdf"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+
+	dir := t.TempDir()
+	banner, _ := postprocess.Banner("CONFIDENTIAL", "")
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true, OutputDir: dir, Formats: formats, PostProcess: postprocess.Chain{banner}}
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	base := filepath.Join(dir, "github.com", "spf13", "cobra")
+	for _, suffix := range []string{".md", ".json", ".html"} {
+		if _, err := os.Stat(base + suffix); err != nil {
+			t.Errorf("Expected %s to be written: %v", base+suffix, err)
+		}
+	}
+	if _, err := os.Stat(base + "_raw.txt"); err == nil {
+		t.Error("Expected no raw file when raw was not requested")
+	}
+	var pkg models.Package
+	data, _ := os.ReadFile(base + ".json")
+	if err := json.Unmarshal(data, &pkg); err != nil || pkg.ImportPath != "github.com/spf13/cobra" {
+		t.Errorf("Expected the package as JSON, got %v (%v)", pkg.ImportPath, err)
+	}
+	// The page is built from the post-processed markdown.
+	if page, _ := os.ReadFile(base + ".html"); !strings.Contains(string(page), "CONFIDENTIAL") {
+		t.Error("Expected the HTML page to show the rendered markdown")
+	}
+}
+
+func TestRunScrape_StdoutFormats(t *testing.T) {
+	ctx := context.Background()
+	paths := []string{"github.com/spf13/cobra@v1.9.1", "github.com/spf13/cobra@v1.8.0"}
+
+	var buf bytes.Buffer
+	opts := scrapeOptions{ImportPaths: paths, TestMode: true, StdoutFormat: stdoutMultiMarkdown}
+	if err := runScrape(ctx, opts, memstore.New(), &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n := strings.Count(buf.String(), "<!-- docinator:document "); n != 2 {
+		t.Errorf("Expected 2 document headers, got %d", n)
+	}
+	if n := strings.Count(buf.String(), "\n<!-- docinator:end -->\n"); n != 2 {
+		t.Errorf("Expected 2 end lines, got %d", n)
+	}
+	if !strings.HasPrefix(buf.String(), `<!-- docinator:document import_path="github.com/spf13/cobra" version="v1.9.1" bytes=`) {
+		t.Errorf("Expected the first header to name the package and version, got %q", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+
+	buf.Reset()
+	opts.StdoutFormat = stdoutJSONL
+	if err := runScrape(ctx, opts, memstore.New(), &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines, got %d", len(lines))
+	}
+	var doc stdoutDocument
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatalf("Expected a JSON document, got %v", err)
+	}
+	if doc.ImportPath != "github.com/spf13/cobra" || doc.Version != "v1.8.0" || doc.Markdown == "" {
+		t.Errorf("Expected cobra v1.8.0 with markdown, got %q %q (%d bytes)", doc.ImportPath, doc.Version, len(doc.Markdown))
+	}
+}
+
+func TestRunScrape_InterruptedWritesCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dir := t.TempDir()
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra", "github.com/gocolly/colly/v2"}, OutputDir: dir, TestMode: true}
+
+	err := runScrape(ctx, opts, memstore.New(), &bytes.Buffer{})
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("Expected errInterrupted, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if err != nil {
+		t.Fatalf("Expected a checkpoint file, got %v", err)
+	}
+	if string(data) != "github.com/spf13/cobra\ngithub.com/gocolly/colly/v2\n" {
+		t.Errorf("Expected both unfinished import paths in the checkpoint, got %q", data)
+	}
+}
+
+func TestRunScrape_FailFast(t *testing.T) {
+	ctx := context.Background()
+	opts := scrapeOptions{ImportPaths: []string{" ", "github.com/spf13/cobra"}, TestMode: true}
+
+	var buf bytes.Buffer
+	if err := runScrape(ctx, opts, memstore.New(), &buf); err != nil {
+		t.Errorf("Expected the batch to continue past a failed package, got %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("Expected output for the package that succeeded")
+	}
+
+	opts.FailFast = true
+	if err := runScrape(ctx, opts, memstore.New(), &bytes.Buffer{}); err == nil {
+		t.Error("Expected --fail-fast to abort the batch on the first failure")
+	}
+}
+
+func TestRunScrape_SummaryJSON(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	path := filepath.Join(t.TempDir(), "reports", "summary.json")
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra", " "}, TestMode: true, SummaryJSON: path}
+
+	for i := 0; i < 2; i++ {
+		if err := runScrape(ctx, opts, store, &bytes.Buffer{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected a summary file, got %v", err)
+	}
+	var summary runSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if summary.Attempted != 2 || summary.Succeeded != 1 || summary.CacheHits != 1 || summary.CacheMisses != 1 {
+		t.Errorf("Unexpected counts in %s", data)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0].ImportPath != " " || summary.Failed[0].Error == "" ||
+		strings.HasPrefix(summary.Failed[0].Error, "failed to scrape") {
+		t.Errorf("Expected the failed import path with its reason, got %+v", summary.Failed)
+	}
+}
+
+func TestRunScrape_PrivatePackages(t *testing.T) {
+	vendor := t.TempDir()
+	dir := filepath.Join(vendor, "git.corp.example", "team", "auth")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := "// Package auth checks team credentials.\npackage auth\n\n// Check reports whether token is valid.\nfunc Check(token string) bool { return token != \"\" }\n"
+	if err := os.WriteFile(filepath.Join(dir, "auth.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "summary.json")
+	opts := scrapeOptions{
+		ImportPaths: []string{"git.corp.example/team/auth", "git.corp.example/team/missing", "github.com/spf13/cobra"},
+		TestMode:    true,
+		VendorDir:   vendor,
+		Private:     "git.corp.example/team",
+		SummaryJSON: path,
+	}
+
+	var buf bytes.Buffer
+	if err := runScrape(context.Background(), opts, memstore.New(), &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "Check reports whether token is valid.") {
+		t.Errorf("Expected the private package documented from its vendored source, got:\n%s", buf.String())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected a summary file, got %v", err)
+	}
+	var summary runSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	want := []privateSource{{ImportPath: "git.corp.example/team/auth", Source: "local source " + dir}}
+	if !reflect.DeepEqual(summary.Private, want) {
+		t.Errorf("Expected private sources %+v, got %+v", want, summary.Private)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0].ImportPath != "git.corp.example/team/missing" || !strings.Contains(summary.Failed[0].Error, "GOPRIVATE") {
+		t.Errorf("Expected the private package without a local copy to fail with a hint, got %+v", summary.Failed)
+	}
+}
+
+func TestNewRunRecord(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	summary := &runSummary{
+		Attempted: 2,
+		Succeeded: 1,
+		Failed:    []packageFailure{{ImportPath: "example.com/bad", Error: "not found"}},
+		StartedAt: start,
+		Scraper:   scraperSummary{Requests: 3, Errors: 1, ErrorsByClass: map[string]int{"http_4xx": 1}},
+	}
+	opts := scrapeOptions{ImportPaths: []string{"example.com/good", "example.com/bad"}, OutputDir: "out", FailFast: true}
+
+	run := newRunRecord(summary, opts)
+	if run.ID != "2026-10-01T12:00:00Z" || !run.StartedAt.Equal(start) {
+		t.Errorf("Expected the start time as ID, got %q", run.ID)
+	}
+	if run.Attempted != 2 || run.Succeeded != 1 || run.Requests != 3 || run.ErrorsByClass["http_4xx"] != 1 {
+		t.Errorf("Unexpected counts: %+v", run)
+	}
+	if len(run.Failures) != 1 || run.Failures[0].ImportPath != "example.com/bad" {
+		t.Errorf("Expected the failure to be recorded, got %+v", run.Failures)
+	}
+	if run.Config["output"] != "out" || run.Config["fail_fast"] != "true" || len(run.Args) != 2 {
+		t.Errorf("Expected args and config to be recorded, got %v %v", run.Args, run.Config)
+	}
+}
+
+func TestSlowestPackages(t *testing.T) {
+	timings := []packageTiming{
+		{ImportPath: "a", Fetch: 10 * time.Millisecond},
+		{ImportPath: "b", Fetch: 5 * time.Millisecond, Render: 30 * time.Millisecond},
+		{ImportPath: "c", Parse: 20 * time.Millisecond},
+	}
+	slowest := slowestPackages(timings, 2)
+	if len(slowest) != 2 || slowest[0].ImportPath != "b" || slowest[1].ImportPath != "c" {
+		t.Errorf("Expected b and c, got %+v", slowest)
+	}
+	if got := slowestPackages(timings, 0); got != nil {
+		t.Errorf("Expected no report for n=0, got %+v", got)
+	}
+	if timings[0].ImportPath != "a" {
+		t.Errorf("Expected the input order to be kept, got %+v", timings)
+	}
+}
+
+func TestRunScrape_ProgressEvents(t *testing.T) {
+	var progress bytes.Buffer
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true, Progress: &progress}
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var events []string
+	dec := json.NewDecoder(&progress)
+	for dec.More() {
+		var e progressEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("Expected NDJSON events, got %v", err)
+		}
+		events = append(events, e.Event)
+	}
+	if got := strings.Join(events, ","); got != "queued,fetching,parsed,rendered,stored" {
+		t.Errorf("Unexpected event sequence %q", got)
+	}
+}
+
+func TestRunScrape_ConsoleStatus(t *testing.T) {
+	var status bytes.Buffer
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra", " "}, TestMode: true, Console: newConsole(&status, false)}
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(status.String(), "✓ github.com/spf13/cobra ") {
+		t.Errorf("Expected a success line for cobra, got %q", status.String())
+	}
+	if !strings.Contains(status.String(), "✗") || strings.Contains(status.String(), "\033[") {
+		t.Errorf("Expected an uncolored failure line, got %q", status.String())
+	}
+}
+
+func TestRunScrape_PinnedVersions(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	dir := t.TempDir()
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra@v1.9.1", "github.com/spf13/cobra@v1.8.0"}, OutputDir: dir, TestMode: true}
+
+	if err := runScrape(ctx, opts, store, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runScrape failed: %v", err)
+	}
+	for _, v := range []string{"v1.8.0", "v1.9.1"} {
+		if _, err := os.Stat(filepath.Join(dir, "github.com/spf13/cobra", v, "cobra.md")); err != nil {
+			t.Errorf("Expected the %s markdown in its version directory: %v", v, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "github.com/spf13/cobra.md")); err == nil {
+		t.Error("Expected pinned versions not to write the unversioned file")
+	}
+	if sums, err := checksum.Read(dir); err != nil || sums["github.com/spf13/cobra/v1.8.0/cobra.md"] == "" {
+		t.Errorf("Expected SHA256SUMS to cover the written files, got %v (%v)", sums, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "github.com/spf13/cobra", latestLink)); err != nil || target != "v1.9.1" {
+		t.Errorf("Expected latest to point at v1.9.1, got %q (%v)", target, err)
+	}
+
+	doc, err := store.GetByID(ctx, "github.com/spf13/cobra@v1.8.0")
+	if err != nil || doc == nil {
+		t.Fatalf("Expected the pinned version to be cached under its own ID, got %v", err)
+	}
+	if doc.Package.ImportPath != "github.com/spf13/cobra" || doc.Package.Version != "v1.8.0" {
+		t.Errorf("Expected a clean import path and the pinned version, got %q %q", doc.Package.ImportPath, doc.Package.Version)
+	}
+}
+
+func TestRunScrape_PrereleaseAndPseudoVersions(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	dir := t.TempDir()
+	pseudo := "v1.9.2-0.20250102150405-abcdef012345"
+	opts := scrapeOptions{
+		ImportPaths: []string{"github.com/spf13/cobra@v1.10.0-rc.1", "github.com/spf13/cobra@" + pseudo, "github.com/spf13/cobra@v1.9.1", "github.com/spf13/cobra@v1.9"},
+		OutputDir:   dir,
+		TestMode:    true,
+		SummaryJSON: filepath.Join(t.TempDir(), "summary.json"),
+	}
+
+	if err := runScrape(ctx, opts, store, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runScrape failed: %v", err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "github.com/spf13/cobra", pseudo, "cobra.md"))
+	if err != nil || !strings.Contains(string(md), "pseudo-version of commit abcdef012345, 2025-01-02 15:04 UTC") {
+		t.Errorf("Expected the pseudo-version markdown to name its commit, got %v:\n%s", err, md)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "github.com/spf13/cobra", latestLink)); err != nil || target != "v1.9.1" {
+		t.Errorf("Expected latest to prefer the release v1.9.1 over newer prereleases, got %q (%v)", target, err)
+	}
+	for _, id := range []string{"github.com/spf13/cobra@v1.10.0-rc.1", "github.com/spf13/cobra@" + pseudo} {
+		if doc, err := store.GetByID(ctx, id); err != nil || doc == nil {
+			t.Errorf("Expected %s to be cached under its exact version, got %v", id, err)
+		}
+	}
+	data, _ := os.ReadFile(opts.SummaryJSON)
+	if !strings.Contains(string(data), `"import_path": "github.com/spf13/cobra@v1.9"`) || !strings.Contains(string(data), "not canonical") {
+		t.Errorf("Expected the non-canonical version to fail, got %s", data)
+	}
+}
+
+func TestRunScrape_Manifest(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	opts := scrapeOptions{
+		ImportPaths: []string{"github.com/spf13/cobra", "github.com/spf13/cobra@v1.8.0"},
+		OutputDir:   dir,
+		TestMode:    true,
+		Flags:       map[string][]string{"no-examples": {"true"}},
+	}
+	if err := runScrape(ctx, opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("runScrape failed: %v", err)
+	}
+	m, err := readManifest(filepath.Join(dir, manifestFile))
+	if err != nil {
+		t.Fatalf("Expected a manifest, got %v", err)
+	}
+	if len(m.Packages) != 2 || m.Packages[0].Version != "v1.9.1" || m.Packages[0].SHA256 == "" || m.Packages[1].Pinned != "v1.8.0" {
+		t.Fatalf("Expected both packages with versions and hashes, got %+v", m.Packages)
+	}
+	if !reflect.DeepEqual(m.Inputs, opts.ImportPaths) || m.Flags["no-examples"][0] != "true" || m.SelectorsVersion == 0 || m.DocinatorVersion == "" {
+		t.Errorf("Expected inputs, flags and versions to be recorded, got %+v", m)
+	}
+
+	var replay []string
+	for _, p := range m.Packages {
+		replay = append(replay, p.replayPath())
+	}
+	if want := []string{"github.com/spf13/cobra@v1.9.1", "github.com/spf13/cobra@v1.8.0"}; !reflect.DeepEqual(replay, want) {
+		t.Errorf("Expected the replay pinned to the recorded versions %v, got %v", want, replay)
+	}
+	replayDir := t.TempDir()
+	opts = scrapeOptions{ImportPaths: replay, OutputDir: replayDir, TestMode: true, Replay: m}
+	if err := runScrape(ctx, opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	for _, file := range []string{"github.com/spf13/cobra.md", "github.com/spf13/cobra/v1.8.0/cobra.md"} {
+		if _, err := os.Stat(filepath.Join(replayDir, file)); err != nil {
+			t.Errorf("Expected the replay to write %s like the original run: %v", file, err)
+		}
+	}
+	replayed, err := readManifest(filepath.Join(replayDir, manifestFile))
+	if err != nil {
+		t.Fatalf("Expected a manifest from the replay, got %v", err)
+	}
+	if differ := compareManifest(m, replayed); len(differ) > 0 {
+		t.Errorf("Expected the replay to reproduce every package, got differences in %v", differ)
+	}
+}
+
+func TestDiagnoseEnv(t *testing.T) {
+	env := map[string]string{"MONGODB_DB": "docs", "BOLT_PATH": "/tmp/cache.db", "LLM_MODEL": "gpt"}
+	findings := diagnoseEnv(func(name string) string { return env[name] }, "auto")
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, string(f.Status)+" "+f.Topic)
+		if f.Status != health.StatusOK && f.Fix == "" {
+			t.Errorf("Expected a fix for %q", f.Message)
+		}
+	}
+	want := []string{"ok store", "warn mongo", "warn llm"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	findings = diagnoseEnv(func(string) string { return "" }, "mongo")
+	if len(findings) != 1 || findings[0].Status != health.StatusFail {
+		t.Errorf("Expected --store mongo without MONGODB_URI to fail, got %+v", findings)
+	}
+}
+
+func TestRedactURI(t *testing.T) {
+	if got := redactURI("mongodb://admin:secret@db:27017/?authSource=admin"); got != "mongodb://admin:***@db:27017/?authSource=admin" {
+		t.Errorf("Expected the password to be hidden, got %s", got)
+	}
+	if got := redactURI("mongodb://localhost:27017"); got != "mongodb://localhost:27017" {
+		t.Errorf("Expected a URI without credentials unchanged, got %s", got)
+	}
+}
+
+func TestReadImportList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "toplist.txt")
+	content := "# nightly warm list\ngithub.com/spf13/cobra\n\n  github.com/spf13/viper  # config\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := readImportList(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(paths, ",") != "github.com/spf13/cobra,github.com/spf13/viper" {
+		t.Errorf("Expected two import paths, got %q", paths)
+	}
+}
+
+func TestDedupeImportPaths(t *testing.T) {
+	paths, changes := dedupeImportPaths([]string{
+		"github.com/spf13/cobra",
+		"GitHub.com/spf13/cobra/",
+		"https://pkg.go.dev/github.com/spf13/cobra",
+		"github.com/go-chi/chi/V5",
+		"github.com/go-chi/chi//v5",
+		"github.com/BurntSushi/toml@V1.4.0",
+		"github.com/burntsushi/toml",
+		"github.com/spf13/cobra",
+	})
+	want := "github.com/spf13/cobra,github.com/go-chi/chi/v5,github.com/BurntSushi/toml@v1.4.0,github.com/burntsushi/toml"
+	if strings.Join(paths, ",") != want {
+		t.Errorf("Expected %s, got %q", want, paths)
+	}
+	wantChanges := []string{
+		"GitHub.com/spf13/cobra/ -> github.com/spf13/cobra (duplicate)",
+		"https://pkg.go.dev/github.com/spf13/cobra -> github.com/spf13/cobra (duplicate)",
+		"github.com/go-chi/chi/V5 -> github.com/go-chi/chi/v5",
+		"github.com/go-chi/chi//v5 -> github.com/go-chi/chi/v5 (duplicate)",
+		"github.com/BurntSushi/toml@V1.4.0 -> github.com/BurntSushi/toml@v1.4.0",
+		"github.com/spf13/cobra (duplicate)",
+	}
+	if strings.Join(changes, "\n") != strings.Join(wantChanges, "\n") {
+		t.Errorf("Unexpected changes:\n%s", strings.Join(changes, "\n"))
+	}
+}
+
+func TestRunScrape_PostTo(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRET", "s3cret")
+	var posted []models.Package
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify([]byte("s3cret"), body, r.Header.Get(webhook.SignatureHeader)) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		var pkg models.Package
+		if err := json.Unmarshal(body, &pkg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		posted = append(posted, pkg)
+	}))
+	defer srv.Close()
+
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true, PostTo: srv.URL}
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(posted) != 1 || posted[0].ImportPath != "github.com/spf13/cobra" {
+		t.Errorf("Expected one signed package posted, got %+v", posted)
+	}
+}
+
+func TestRunScrape_GHA(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "step_summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+
+	var out bytes.Buffer
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true, GHA: true, AllowLicenses: []string{"MIT"}}
+	if err := runScrape(context.Background(), opts, memstore.New(), &out); err == nil {
+		t.Error("Expected the license policy violation to fail the run")
+	}
+	if !strings.Contains(out.String(), "::error title=License policy::github.com/spf13/cobra: license Apache-2.0 is not allowed\n") {
+		t.Errorf("Expected a license annotation, got %q", out.String())
+	}
+	data, err := os.ReadFile(summaryPath)
+	if err != nil || !strings.Contains(string(data), "### License policy violations\n\n- `github.com/spf13/cobra`: Apache-2.0\n") {
+		t.Errorf("Expected the violation in the job summary, got %q (%v)", data, err)
+	}
+
+	opts.AllowLicenses = []string{"mit", "apache-2.0"}
+	if err := runScrape(context.Background(), opts, memstore.New(), &bytes.Buffer{}); err != nil {
+		t.Errorf("Expected allowed licenses to pass, got %v", err)
+	}
+}
+
+func TestGHAAnnotate(t *testing.T) {
+	var b bytes.Buffer
+	ghaAnnotate(&b, "warning", "a:b,c", "50% done\nnext")
+	if got := b.String(); got != "::warning title=a%3Ab%2Cc::50%25 done%0Anext\n" {
+		t.Errorf("Expected escaped annotation, got %q", got)
+	}
+	if licenseAllowed("", []string{"MIT"}) || !licenseAllowed("Apache-2.0, BSD-3-Clause", []string{"BSD-3-Clause", "Apache-2.0"}) || licenseAllowed("MIT, GPL-3.0", []string{"MIT"}) {
+		t.Error("Expected every listed license to be checked")
+	}
+}
+
+func TestReadTheDocsLinks(t *testing.T) {
+	pkg := &models.Package{
+		Readme: `<a href="https://cobra.readthedocs.io/en/latest/?badge=latest"><img src="https://readthedocs.org/projects/cobra/badge/"></a>
+<a href="https://cobra.readthedocs.io/en/latest/#install">Install</a> <a href="https://github.com/spf13/cobra">GitHub</a>`,
+		ProcessedReadme: "See the [user guide](https://cobra.readthedocs.io/en/latest/user_guide/).",
+		Links:           []models.Link{{Label: "Docs", URL: "https://docs.example.readthedocs-hosted.com"}},
+	}
+	want := []string{"https://cobra.readthedocs.io/en/latest/", "https://cobra.readthedocs.io/en/latest/user_guide/", "https://docs.example.readthedocs-hosted.com/"}
+	if got := readTheDocsLinks(pkg, 5); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := readTheDocsLinks(pkg, 1); len(got) != 1 {
+		t.Errorf("Expected the limit to apply, got %v", got)
+	}
+}
+
+func TestRunScrape_SkipsUnchangedFiles(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	dir := t.TempDir()
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	opts := scrapeOptions{ImportPaths: []string{"github.com/spf13/cobra"}, TestMode: true, OutputDir: dir, SummaryJSON: summaryPath}
+	page := filepath.Join(dir, "github.com", "spf13", "cobra.md")
+	// Backdate the page after the first run, so a rewrite shows even with coarse timestamps.
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	var counts [][2]int
+	for i := 0; i < 2; i++ {
+		if err := runScrape(ctx, opts, store, &bytes.Buffer{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		data, err := os.ReadFile(summaryPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var summary runSummary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, [2]int{summary.FilesChanged, summary.FilesUnchanged})
+		if i == 0 {
+			if err := os.Chtimes(page, past, past); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if counts[0] != [2]int{2, 0} || counts[1] != [2]int{0, 2} {
+		t.Errorf("Expected 2 files written, then 2 left alone; got %v", counts)
+	}
+	info, err := os.Stat(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("Expected the unchanged page to keep its modification time, got %v", info.ModTime())
+	}
+}
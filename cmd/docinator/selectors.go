@@ -0,0 +1,33 @@
+package docinator
+
+import (
+	"fmt"
+
+	"github.com/moseye/docinator/pkg/parser"
+	"github.com/spf13/cobra"
+)
+
+var selectorsCmd = &cobra.Command{
+	Use:   "selectors [file]",
+	Short: "Print the default selector profile or check an override",
+	Long: `Without arguments, print the embedded selector profile: the CSS selectors used
+to find each part of a pkg.go.dev page. When pkg.go.dev changes its markup,
+save it, fix the affected selectors and pass the file to --selectors on any
+command; keys left out keep their defaults.
+
+  docinator selectors > selectors.yaml
+  docinator selectors selectors.yaml   # check the edited profile
+  docinator scrape --selectors selectors.yaml github.com/spf13/cobra`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			cmd.OutOrStdout().Write(parser.DefaultSelectorsYAML())
+			return nil
+		}
+		if _, err := parser.LoadSelectors(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s is a valid selector profile\n", args[0])
+		return nil
+	},
+}
@@ -0,0 +1,221 @@
+package docinator
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/health"
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/spf13/cobra"
+)
+
+// selftestPackage is scraped by selftest: small, documented in every way docinator parses, and
+// as stable as pkg.go.dev pages get.
+const selftestPackage = "sort"
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Scrape a known package and check every extracted field",
+	Long: `Scrape the standard library package sort from pkg.go.dev, bypassing the
+cache, and check each extracted field against what the package is known to
+contain: its name, synopsis and overview, version and license, functions with
+signatures and since versions, types with methods and interface methods,
+examples, source links, files and the identifier index. A failing field points
+at the selector that no longer matches, so run it when output looks wrong and
+pkg.go.dev may have changed its markup. --selectors is honored, so it also
+checks a selector profile.
+
+The exit status is 1 when any field failed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		config := scraperConfig()
+		var err error
+		if config.Selectors, err = loadSelectors(); err != nil {
+			return fmt.Errorf("--selectors: %w", err)
+		}
+		s, err := scraper.New(config)
+		if err != nil {
+			return fmt.Errorf("failed to create scraper: %w", err)
+		}
+		defer s.Close()
+
+		var checks []health.Check
+		pkg, err := s.ScrapePackage(cmd.Context(), selftestPackage)
+		if err != nil {
+			checks = []health.Check{{Name: "scrape", Status: health.StatusFail, Message: err.Error()}}
+		} else {
+			checks = selftestChecks(pkg)
+		}
+		report := health.NewReport(checks...)
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			enc.Encode(report)
+		} else {
+			printHealth(cmd.OutOrStdout(), report)
+		}
+		if !report.Healthy() {
+			stopProfiling()
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	selftestCmd.Flags().Bool("json", false, "print the report as JSON")
+}
+
+// selftestField checks one extracted field of the selftest package; it returns a description of
+// what was found, or an error saying what is wrong.
+type selftestField struct {
+	name  string
+	check func(pkg *models.Package) (string, error)
+}
+
+var selftestFields = []selftestField{
+	{"name", func(pkg *models.Package) (string, error) { return wantEqual(pkg.Name, "sort") }},
+	{"import_path", func(pkg *models.Package) (string, error) { return wantEqual(pkg.ImportPath, selftestPackage) }},
+	{"synopsis", func(pkg *models.Package) (string, error) {
+		return wantMention(cmp.Or(pkg.Synopsis, pkg.Description), "sort")
+	}},
+	{"overview", func(pkg *models.Package) (string, error) { return wantMention(pkg.Overview, "sort") }},
+	{"version", func(pkg *models.Package) (string, error) {
+		if !strings.HasPrefix(pkg.Version, "go") {
+			return "", fmt.Errorf("got %q, want a Go release such as go1.22.0", pkg.Version)
+		}
+		return pkg.Version, nil
+	}},
+	{"license", func(pkg *models.Package) (string, error) { return wantMention(pkg.License, "BSD") }},
+	{"functions", func(pkg *models.Package) (string, error) {
+		for _, name := range []string{"Ints", "Search", "Slice"} {
+			f := findFunction(pkg.Functions, name)
+			if f == nil {
+				return "", fmt.Errorf("%s missing among %d functions", name, len(pkg.Functions))
+			}
+			if !strings.HasPrefix(f.Signature, "func "+name+"(") {
+				return "", fmt.Errorf("%s has signature %q", name, f.Signature)
+			}
+		}
+		return fmt.Sprintf("%d functions", len(pkg.Functions)), nil
+	}},
+	{"since", func(pkg *models.Package) (string, error) {
+		f := findFunction(pkg.Functions, "Slice")
+		if f == nil || f.AddedIn == "" {
+			return "", fmt.Errorf("no since version on Slice, added in go1.8")
+		}
+		return "Slice added in " + f.AddedIn, nil
+	}},
+	{"types", func(pkg *models.Package) (string, error) {
+		for _, name := range []string{"IntSlice", "Interface", "StringSlice"} {
+			if findType(pkg.Types, name) == nil {
+				return "", fmt.Errorf("%s missing among %d types", name, len(pkg.Types))
+			}
+		}
+		return fmt.Sprintf("%d types", len(pkg.Types)), nil
+	}},
+	{"methods", func(pkg *models.Package) (string, error) {
+		t := findType(pkg.Types, "IntSlice")
+		if t == nil || !slices.ContainsFunc(t.Methods, func(m models.Function) bool { return strings.HasSuffix(m.Name, "Len") }) {
+			return "", fmt.Errorf("IntSlice.Len missing")
+		}
+		return fmt.Sprintf("%d methods on IntSlice", len(t.Methods)), nil
+	}},
+	{"interface", func(pkg *models.Package) (string, error) {
+		t := findType(pkg.Types, "Interface")
+		if t == nil || findFunction(t.InterfaceMethods, "Less") == nil {
+			return "", fmt.Errorf("Interface.Less missing from the method set")
+		}
+		return fmt.Sprintf("%d methods in Interface", len(t.InterfaceMethods)), nil
+	}},
+	{"examples", func(pkg *models.Package) (string, error) {
+		examples := pkg.AllExamples()
+		if len(examples) == 0 || examples[0].Code == "" {
+			return "", fmt.Errorf("no examples with code")
+		}
+		return fmt.Sprintf("%d examples", len(examples)), nil
+	}},
+	{"source", func(pkg *models.Package) (string, error) {
+		f := findFunction(pkg.Functions, "Ints")
+		if f == nil || f.SourceURL == "" {
+			return "", fmt.Errorf("no source link on Ints")
+		}
+		return f.SourceURL, nil
+	}},
+	{"files", func(pkg *models.Package) (string, error) {
+		if !slices.ContainsFunc(pkg.Files, func(f models.SourceFile) bool { return f.Name == "sort.go" }) {
+			return "", fmt.Errorf("sort.go missing among %d files", len(pkg.Files))
+		}
+		return fmt.Sprintf("%d files", len(pkg.Files)), nil
+	}},
+	{"identifiers", func(pkg *models.Package) (string, error) {
+		if !slices.Contains(pkg.Identifiers, "Ints") {
+			return "", fmt.Errorf("Ints missing among %d identifiers", len(pkg.Identifiers))
+		}
+		return fmt.Sprintf("%d identifiers", len(pkg.Identifiers)), nil
+	}},
+}
+
+// selftestChecks checks every field of the scraped selftest package.
+func selftestChecks(pkg *models.Package) []health.Check {
+	checks := make([]health.Check, 0, len(selftestFields))
+	for _, f := range selftestFields {
+		c := health.Check{Name: f.name, Status: health.StatusOK}
+		msg, err := f.check(pkg)
+		if err != nil {
+			c.Status, msg = health.StatusFail, err.Error()
+		}
+		c.Message = msg
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// wantEqual and wantMention check a string field: for want exactly, or for mentioning want.
+func wantEqual(got, want string) (string, error) {
+	if got != want {
+		return "", fmt.Errorf("got %q, want %q", got, want)
+	}
+	return got, nil
+}
+
+func wantMention(got, want string) (string, error) {
+	if !strings.Contains(strings.ToLower(got), strings.ToLower(want)) {
+		return "", fmt.Errorf("got %q, want it to mention %q", firstLine(got, 60), want)
+	}
+	return firstLine(got, 60), nil
+}
+
+// firstLine returns the first line of s, cut to n bytes.
+func firstLine(s string, n int) string {
+	s, _, _ = strings.Cut(s, "\n")
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+func findFunction(funcs []models.Function, name string) *models.Function {
+	for i, f := range funcs {
+		if f.Name == name {
+			return &funcs[i]
+		}
+	}
+	return nil
+}
+
+func findType(types []models.Type, name string) *models.Type {
+	for i, t := range types {
+		if t.Name == name {
+			return &types[i]
+		}
+	}
+	return nil
+}
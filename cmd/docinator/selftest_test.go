@@ -0,0 +1,50 @@
+package docinator
+
+import (
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/health"
+)
+
+func TestSelftestChecks(t *testing.T) {
+	pkg := &models.Package{
+		Name:        "sort",
+		ImportPath:  "sort",
+		Synopsis:    "Package sort provides primitives for sorting slices and user-defined collections.",
+		Overview:    "Package sort provides primitives for sorting slices and user-defined collections.",
+		Version:     "go1.24.3",
+		License:     "BSD-3-Clause",
+		Identifiers: []string{"Find", "Ints"},
+		Files:       []models.SourceFile{{Name: "search.go"}, {Name: "sort.go"}},
+		Functions: []models.Function{
+			{Name: "Ints", Signature: "func Ints(x []int)", SourceURL: "https://cs.opensource.google/go/go/+/go1.24.3:src/sort/sort.go;l=169"},
+			{Name: "Search", Signature: "func Search(n int, f func(int) bool) int"},
+			{Name: "Slice", Signature: "func Slice(x any, less func(i, j int) bool)", AddedIn: "go1.8"},
+		},
+		Types: []models.Type{
+			{Name: "IntSlice", Methods: []models.Function{{Name: "IntSlice.Len"}}},
+			{Name: "Interface", InterfaceMethods: []models.Function{{Name: "Len"}, {Name: "Less"}, {Name: "Swap"}}},
+			{Name: "StringSlice"},
+		},
+		Examples: []models.Example{{Name: "Package", Code: "sort.Ints(s)"}},
+	}
+	for _, c := range selftestChecks(pkg) {
+		if c.Status != health.StatusOK {
+			t.Errorf("Expected %s to pass, got %s: %s", c.Name, c.Status, c.Message)
+		}
+	}
+
+	// A markup change that loses the since versions and the interface method sets fails those fields only.
+	pkg.Functions[2].AddedIn = ""
+	pkg.Types[1].InterfaceMethods = nil
+	failed := map[string]bool{}
+	for _, c := range selftestChecks(pkg) {
+		if c.Status == health.StatusFail {
+			failed[c.Name] = true
+		}
+	}
+	if len(failed) != 2 || !failed["since"] || !failed["interface"] {
+		t.Errorf("Expected since and interface to fail, got %v", failed)
+	}
+}
@@ -0,0 +1,111 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	mongostore "github.com/moseye/docinator/internal/storage/mongo"
+	"github.com/moseye/docinator/pkg/chunker"
+	"github.com/moseye/docinator/pkg/llm"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/search"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var semsearchCmd = &cobra.Command{
+	Use:   "semsearch <query>",
+	Short: "Search the cached corpus by meaning",
+	Long: `Embed the query and return the most relevant sections of the cached corpus
+(requires MONGODB_URI, an LLM endpoint and chunks stored with "chunk --embed").
+When no embeddings are available, falls back to keyword search over the cached
+packages, which works with any cache backend.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k, _ := cmd.Flags().GetInt("limit")
+		ctx := cmd.Context()
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "semsearch", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		matches, mode, err := retrieveChunks(ctx, store, llm.NewFromEnv(), strings.Join(args, " "), k)
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		log.Printf("Search mode: %s, %d result(s)", mode, len(matches))
+
+		out := cmd.OutOrStdout()
+		for i, m := range matches {
+			fmt.Fprintf(out, "%d. %s#%s (score %.3f)\n", i+1, m.Source, m.Anchor, m.Score)
+			if m.Heading != "" {
+				fmt.Fprintf(out, "   %s\n", m.Heading)
+			}
+			fmt.Fprintf(out, "   %s\n\n", snippet(m.Text, 200))
+		}
+		return nil
+	},
+}
+
+func init() {
+	semsearchCmd.Flags().IntP("limit", "k", 10, "number of results to return")
+}
+
+// retrieveChunks returns the k most relevant chunks for query and the retrieval mode used.
+// Vector search is used when MongoDB holds embedded chunks and an embeddings client is configured;
+// otherwise the cached packages are chunked on the fly and ranked by keyword relevance.
+func retrieveChunks(ctx context.Context, store storage.Store, client *llm.Client, query string, k int) ([]models.ChunkMatch, string, error) {
+	if mongo, ok := store.(*mongostore.Store); ok && client != nil {
+		hasEmbeddings, err := mongo.HasEmbeddings(ctx)
+		if err != nil {
+			log.Printf("Embedding lookup failed, falling back to keyword search: %v", err)
+		} else if hasEmbeddings {
+			vectors, err := client.Embed(ctx, []string{query})
+			if err != nil {
+				log.Printf("Query embedding failed, falling back to keyword search: %v", err)
+			} else {
+				matches, err := mongo.SimilaritySearch(ctx, vectors[0], k)
+				if err == nil {
+					return matches, "vector", nil
+				}
+				log.Printf("Vector search failed, falling back to keyword search: %v", err)
+			}
+		}
+	}
+
+	ranker := search.NewKeywordRanker(query, k)
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package == nil {
+			return nil
+		}
+		for _, chunk := range chunker.Split(doc.ID, markdown.PackageToMarkdown(doc.Package), chunker.DefaultOptions()) {
+			ranker.Add(chunk)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return ranker.Results(), "keyword", nil
+}
+
+// snippet flattens text onto one line and truncates it to roughly n characters at a word boundary.
+func snippet(text string, n int) string {
+	flat := strings.Join(strings.Fields(text), " ")
+	if len(flat) <= n {
+		return flat
+	}
+	cut := flat[:n]
+	if i := strings.LastIndex(cut, " "); i > n/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
@@ -0,0 +1,118 @@
+package docinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/api"
+	"github.com/moseye/docinator/pkg/ratelimit"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the cached corpus as a JSON and markdown API",
+	Long: `Serve the packages in the cache over HTTP:
+
+  GET    /packages                 cached packages (?module=, ?tag=)
+  GET    /packages/<path>          a package as JSON, or ?format=markdown
+  GET    /packages/<path>/symbols/<name>
+                                   one symbol as JSON, e.g. Command.Execute
+  POST   /refresh/<path>           scrape a package again
+  DELETE /packages/<path>          remove a package from the cache
+  GET    /healthz                  store reachability
+
+Packages missing from the cache are scraped on demand and stored. --read-only
+serves only the pre-warmed corpus: no scraper is started, missing packages are
+404 and the refresh and delete endpoints answer 403, so the API can be exposed
+widely while scraping stays on a controlled worker (see docinator warm).
+
+--tokens names a YAML file of API tokens; every request but /healthz must then
+send one as "Authorization: Bearer <token>", and each token is limited to its
+own rate:
+
+  tokens:
+    - name: team-a
+      sha256: <hex SHA-256 of the token>
+      rate: 2        # requests per second
+      burst: 10`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		readOnly, _ := cmd.Flags().GetBool("read-only")
+		tokensFile, _ := cmd.Flags().GetString("tokens")
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		server := &api.Server{ReadOnly: readOnly}
+		if tokensFile != "" {
+			tokens, err := api.LoadTokens(tokensFile)
+			if err != nil {
+				return fmt.Errorf("--tokens: %w", err)
+			}
+			server.Tokens = tokens
+		} else if !readOnly {
+			log.Printf("WARNING: anyone reaching %s can make docinator scrape pkg.go.dev; pass --tokens or --read-only", displayAddr(addr))
+		}
+		if readOnly {
+			store, closeStore, err := openStore(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+			server.Store = store
+		} else {
+			loader, cleanup, err := newPackageLoader(cmd)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			server.Store = loader.store
+			// Someone is waiting on every on-demand scrape, so it goes ahead of background jobs
+			// sharing the rate limit.
+			priority, err := requestPriority(ratelimit.Interactive)
+			if err != nil {
+				return err
+			}
+			server.Load = func(ctx context.Context, importPath string) (*models.Package, error) {
+				pkg, _, _, err := loader.scrapeTimed(ratelimit.WithPriority(ctx, priority), importPath, time.Now())
+				return pkg, err
+			}
+		}
+		if !server.Store.Enabled() {
+			return needsCache(server.Store, "serve", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		srv := &http.Server{Addr: addr, Handler: server.Handler()}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		mode := "scraping on demand"
+		if readOnly {
+			mode = "read-only"
+		}
+		log.Printf("Serving the corpus (%s) on http://%s", mode, displayAddr(addr))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", ":8080", "address to listen on")
+	serveCmd.Flags().Bool("read-only", false, "serve only the cached corpus: no on-demand scraping, refresh or delete")
+	serveCmd.Flags().String("tokens", "", "YAML file of API tokens and their rate limits; requests without a valid token are rejected")
+}
@@ -0,0 +1,80 @@
+package docinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/moseye/docinator/pkg/health"
+	"github.com/moseye/docinator/pkg/site"
+	"github.com/spf13/cobra"
+)
+
+var serveStaticCmd = &cobra.Command{
+	Use:   "serve-static <dir>",
+	Short: "Preview a generated output directory in the browser",
+	Long: `Serve a directory written by scrape (or any markdown/HTML tree) over HTTP.
+Markdown files are rendered to HTML, directories get a navigation index, and
+open pages reload automatically when files change. /healthz reports free disk
+space as JSON, with status 503 when the directory is unreadable, for
+Kubernetes liveness and readiness probes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		noReload, _ := cmd.Flags().GetBool("no-reload")
+
+		dir := args[0]
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		mux := http.NewServeMux()
+		mux.Handle("/", site.Handler(dir, !noReload))
+		mux.Handle("/healthz", health.Handler(func(ctx context.Context) *health.Report {
+			return health.NewReport(checkServedDir(dir), health.CheckDisk(dir, 100<<20))
+		}))
+		srv := &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		log.Printf("Serving %s on http://%s", dir, displayAddr(addr))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	serveStaticCmd.Flags().String("addr", ":8080", "address to listen on")
+	serveStaticCmd.Flags().Bool("no-reload", false, "disable live reload of open pages")
+}
+
+// checkServedDir reports whether dir can still be listed.
+func checkServedDir(dir string) health.Check {
+	if _, err := os.ReadDir(dir); err != nil {
+		return health.Check{Name: "directory", Status: health.StatusFail, Message: err.Error()}
+	}
+	return health.Check{Name: "directory", Status: health.StatusOK, Message: dir + " is readable"}
+}
+
+// displayAddr turns a listen address like ":8080" into one that can be opened in a browser.
+func displayAddr(addr string) string {
+	if len(addr) > 0 && addr[0] == ':' {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,95 @@
+package docinator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/site"
+	"github.com/spf13/cobra"
+)
+
+var siteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Build static documentation sites from the cache",
+}
+
+var siteBuildCmd = &cobra.Command{
+	Use:   "build [import paths or modules...]",
+	Short: "Turn the cached corpus into a self-contained static website",
+	Long: `Write an offline, pkg.go.dev-style website for the cached packages to the
+output directory (default "site"): one page per package and cached version,
+packages grouped by module on a searchable index, and a version switcher.
+Pass import paths or module paths to include only those packages and the
+packages below them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		if outputDir == "" {
+			outputDir = "site"
+		}
+		title, _ := cmd.Flags().GetString("title")
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		archivePath, _ := cmd.Flags().GetString("archive")
+		tags := tagFilter(cmd)
+		ctx := cmd.Context()
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "site build", "set MONGODB_URI or BOLT_PATH")
+		}
+
+		var pkgs []*models.Package
+		err = store.ForEach(ctx, func(doc *models.Document) error {
+			if doc.Package != nil && selected(doc.Package, args) && hasTags(doc.Tags, tags) {
+				pkgs = append(pkgs, doc.Package)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("loading packages failed: %w", err)
+		}
+		if len(pkgs) == 0 {
+			return errors.New("no cached packages to build a site from")
+		}
+
+		pages, err := site.Build(outputDir, pkgs, site.BuildOptions{Title: title, BaseURL: baseURL})
+		if err != nil {
+			return fmt.Errorf("site build failed: %w", err)
+		}
+		log.Printf("Wrote %d pages for %d documents to %s", pages, len(pkgs), outputDir)
+		if archivePath != "" {
+			if err := writeArchive(outputDir, archivePath); err != nil {
+				return fmt.Errorf("archiving the site failed: %w", err)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	siteBuildCmd.Flags().String("title", "Go Packages", "site title shown on every page")
+	siteBuildCmd.Flags().String("base-url", "", "URL the site is published at; writes sitemap.xml and robots.txt")
+	siteBuildCmd.Flags().String("archive", "", "also pack the site and a manifest into this .tar.gz or .zip file")
+	siteBuildCmd.Flags().StringSlice("tag", nil, "include only packages carrying all of these tags (see docinator tag)")
+	siteCmd.AddCommand(siteBuildCmd)
+}
+
+// selected reports whether pkg matches one of the import or module paths in filters, or whether
+// filters is empty.
+func selected(pkg *models.Package, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if pkg.Module == f || pkg.ImportPath == f || strings.HasPrefix(pkg.ImportPath, f+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package docinator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/moseye/docinator/pkg/symdoc"
+	"github.com/spf13/cobra"
+)
+
+var snippetCmd = &cobra.Command{
+	Use:   "snippet <import path>.<symbol>",
+	Short: "Print the signature, doc and examples of a single symbol",
+	Long: `Load (from cache) or scrape a package and print just one of its symbols in a
+compact format: the signature, the package, version and source link, the doc
+comment, the methods of a type, and the examples. Name methods and struct
+fields as Type.Method and Type.Field, and pin a version with @:
+
+  docinator snippet github.com/spf13/cobra.Command.Execute
+  docinator snippet net/http.Client
+  docinator snippet gopkg.in/yaml.v3@v3.0.1.Unmarshal
+
+With --bundle, or an installed bundle and no store, the symbol is read from an
+offline bundle and nothing is scraped.
+
+The exit status is 1 when the package declares no such symbol.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		noExamples, _ := cmd.Flags().GetBool("no-examples")
+		importPath, symbol, ok := symdoc.Split(args[0])
+		if !ok {
+			return fmt.Errorf("%q names no symbol; use <import path>.<Symbol>, e.g. github.com/spf13/cobra.Command.Execute", args[0])
+		}
+
+		var s *symdoc.Snippet
+		bundle, err := lookupBundle(cmd)
+		if err != nil {
+			return err
+		}
+		if bundle != nil {
+			defer bundle.Close(cmd.Context())
+			s, err = bundleSnippet(cmd.Context(), bundle, importPath, symbol)
+		} else {
+			s, err = loadSnippet(cmd, importPath, symbol)
+		}
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if asJSON {
+			if noExamples {
+				s.Examples = nil
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			enc.Encode(s)
+			return nil
+		}
+		fmt.Fprint(out, s.Text(!noExamples))
+		return nil
+	},
+}
+
+func init() {
+	snippetCmd.Flags().Bool("json", false, "print the snippet as JSON")
+	snippetCmd.Flags().Bool("no-examples", false, "leave out the examples")
+	snippetCmd.Flags().String("bundle", "", "read the symbol from this offline bundle (see docinator bundle export) instead of the store or pkg.go.dev")
+}
+
+// loadSnippet loads (from cache) or scrapes the package at importPath and returns its symbol.
+func loadSnippet(cmd *cobra.Command, importPath, symbol string) (*symdoc.Snippet, error) {
+	loader, cleanup, err := newPackageLoader(cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	pkg, _, err := loader.load(cmd.Context(), importPath)
+	if err != nil {
+		return nil, err
+	}
+	return symdoc.Find(pkg, symbol)
+}
@@ -0,0 +1,170 @@
+package docinator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// spillFile receives the documents a batch could not store, one extended JSON document per
+// line, for "docinator import".
+const spillFile = "docinator.spill.jsonl"
+
+// spillRetries is how often a queued upsert is retried at the end of a batch before it is spilled.
+const spillRetries = 3
+
+// spillBackoff is the wait before the first retry; it doubles with every further one.
+var spillBackoff = 2 * time.Second
+
+// upsertQueue holds documents whose upsert failed during a batch, so a store outage (a network
+// blip, a primary election) delays them instead of losing them.
+type upsertQueue struct {
+	mu   sync.Mutex
+	docs []*models.Document
+}
+
+// add queues doc, replacing an earlier failed upsert of the same ID.
+func (q *upsertQueue) add(doc *models.Document) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, queued := range q.docs {
+		if queued.ID == doc.ID {
+			q.docs[i] = doc
+			return
+		}
+	}
+	q.docs = append(q.docs, doc)
+}
+
+// take empties the queue and returns what it held.
+func (q *upsertQueue) take() []*models.Document {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	docs := q.docs
+	q.docs = nil
+	return docs
+}
+
+// upsert stores doc. A failure is queued for flushPending when the loader has a queue and logged
+// either way.
+func (l *packageLoader) upsert(ctx context.Context, doc *models.Document) error {
+	err := l.store.Upsert(ctx, doc)
+	if err == nil {
+		return nil
+	}
+	if l.pending != nil {
+		l.pending.add(doc)
+		log.Printf("Cache upsert failed for %s: %v; retrying at the end of the batch", doc.ID, err)
+	} else {
+		log.Printf("Cache upsert failed for %s: %v", doc.ID, err)
+	}
+	return err
+}
+
+// flushPending retries the queued upserts, waiting spillBackoff, then twice as long, and so on
+// between attempts. Once a document still fails after spillRetries retries the store is taken to
+// be down: it and every document after it are appended to the spill file at path. It returns how
+// many documents were stored and spilled.
+func (l *packageLoader) flushPending(ctx context.Context, path string) (stored, spilled int, err error) {
+	if l.pending == nil {
+		return 0, 0, nil
+	}
+	docs := l.pending.take()
+	for i, doc := range docs {
+		if err := retryUpsert(ctx, l.store, doc); err != nil {
+			log.Printf("Cache upsert of %s failed again: %v", doc.ID, err)
+			if err := appendSpill(path, docs[i:]); err != nil {
+				return stored, 0, fmt.Errorf("%d document(s) could not be stored or spilled to %s: %w", len(docs)-i, path, err)
+			}
+			return stored, len(docs) - i, nil
+		}
+		stored++
+	}
+	return stored, 0, nil
+}
+
+// retryUpsert upserts doc, retrying with a doubling backoff.
+func retryUpsert(ctx context.Context, store storage.Store, doc *models.Document) error {
+	wait := spillBackoff
+	var err error
+	for attempt := 0; attempt <= spillRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			wait *= 2
+		}
+		if err = store.Upsert(ctx, doc); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// spillPath returns where documents are spilled: the output directory, or the working directory for stdout output.
+func spillPath(outputDir string) string {
+	if outputDir == "" {
+		return spillFile
+	}
+	return filepath.Join(outputDir, spillFile)
+}
+
+// appendSpill appends docs to the spill file at path, one canonical extended JSON document per
+// line so every BSON type survives the round trip.
+func appendSpill(path string, docs []*models.Document) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, doc := range docs {
+		line, err := bson.MarshalExtJSON(doc, true, false)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("%s: %w", doc.ID, err)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readSpill reads the documents of a spill file.
+func readSpill(path string) ([]*models.Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var docs []*models.Document
+	for n, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		doc := &models.Document{}
+		if err := bson.UnmarshalExtJSON(line, true, doc); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n+1, err)
+		}
+		if doc.ID == "" {
+			return nil, fmt.Errorf("%s:%d: %w", path, n+1, errors.New("document without an _id"))
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,68 @@
+package docinator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+// flakyStore fails every upsert while down is set.
+type flakyStore struct {
+	*memstore.Store
+	down bool
+}
+
+func (s *flakyStore) Upsert(ctx context.Context, doc *models.Document) error {
+	if s.down {
+		return errors.New("server selection timeout")
+	}
+	return s.Store.Upsert(ctx, doc)
+}
+
+func TestFlushPending(t *testing.T) {
+	ctx := context.Background()
+	defer func(d time.Duration) { spillBackoff = d }(spillBackoff)
+	spillBackoff = time.Millisecond
+	store := &flakyStore{Store: memstore.New(), down: true}
+	loader := &packageLoader{store: store, pending: &upsertQueue{}}
+	scrapedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, id := range []string{"example.com/a", "example.com/b", "example.com/a"} {
+		loader.upsert(ctx, &models.Document{ID: id, Package: &models.Package{ImportPath: id, ScrapedAt: scrapedAt}, Tags: []string{"core"}})
+	}
+
+	// The store comes back before the end of the batch.
+	store.down = false
+	loader.upsert(ctx, &models.Document{ID: "example.com/c", Package: &models.Package{ImportPath: "example.com/c"}})
+	store.down = true
+	path := filepath.Join(t.TempDir(), spillFile)
+	if stored, spilled, err := loader.flushPending(ctx, path); err != nil || stored != 0 || spilled != 2 {
+		t.Fatalf("Expected both queued documents spilled, got %d stored, %d spilled, %v", stored, spilled, err)
+	}
+
+	store.down = false
+	left, err := runImport(ctx, store, path, io.Discard)
+	if err != nil || left != 0 {
+		t.Fatalf("Expected the spill file imported, got %d left, %v", left, err)
+	}
+	doc, _ := store.GetByID(ctx, "example.com/a")
+	if doc == nil || !doc.Package.ScrapedAt.Equal(scrapedAt) || len(doc.Tags) != 1 {
+		t.Errorf("Expected the spilled document to round-trip, got %+v", doc)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the imported spill file removed, got %v", err)
+	}
+
+	store.down = true
+	loader.upsert(ctx, &models.Document{ID: "example.com/d"})
+	store.down = false
+	if stored, spilled, err := loader.flushPending(ctx, path); err != nil || stored != 1 || spilled != 0 {
+		t.Errorf("Expected the retry to store the document, got %d stored, %d spilled, %v", stored, spilled, err)
+	}
+}
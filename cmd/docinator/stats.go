@@ -0,0 +1,166 @@
+package docinator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show aggregate statistics about the cached corpus",
+	Long: `Compute corpus statistics inside MongoDB: packages per license, average
+symbols per package, the largest documents, the most stale entries, the
+oldest releases by publication date and the most recent scrape runs, recorded in the runs collection after every
+scrape. With --run, show the scraping statistics of a batch from its --summary-json
+file instead: requests, HTTP cache hits, retries, errors by class and latency
+percentiles.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		top, _ := cmd.Flags().GetInt("top")
+		runFile, _ := cmd.Flags().GetString("run")
+		ctx := cmd.Context()
+
+		if runFile != "" {
+			summary, err := readSummary(runFile)
+			if err != nil {
+				return fmt.Errorf("reading %s failed: %w", runFile, err)
+			}
+			printRunStats(cmd.OutOrStdout(), summary)
+			return nil
+		}
+
+		store, closeStore := openMongoStore(ctx)
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "stats", "set MONGODB_URI")
+		}
+
+		out := cmd.OutOrStdout()
+
+		symbols, err := store.AverageSymbols(ctx)
+		if err != nil {
+			return fmt.Errorf("symbol statistics failed: %w", err)
+		}
+		fmt.Fprintf(out, "Packages: %d\n", symbols.Packages)
+		fmt.Fprintf(out, "Average symbols per package: %.1f (functions %.1f, types %.1f, methods %.1f)\n\n",
+			symbols.AvgSymbols, symbols.AvgFunctions, symbols.AvgTypes, symbols.AvgMethods)
+
+		licenses, err := store.LicenseCounts(ctx)
+		if err != nil {
+			return fmt.Errorf("license statistics failed: %w", err)
+		}
+		fmt.Fprintln(out, "Packages per license:")
+		for _, l := range licenses {
+			fmt.Fprintf(out, "  %-20s %d\n", l.License, l.Count)
+		}
+
+		largest, err := store.LargestDocuments(ctx, top)
+		if err != nil {
+			return fmt.Errorf("size statistics failed: %w", err)
+		}
+		fmt.Fprintf(out, "\nLargest documents:\n")
+		for _, d := range largest {
+			fmt.Fprintf(out, "  %-50s %8.1f KiB\n", d.ID, float64(d.Bytes)/1024)
+		}
+
+		stalest, err := store.StalestDocuments(ctx, top)
+		if err != nil {
+			return fmt.Errorf("staleness statistics failed: %w", err)
+		}
+		fmt.Fprintf(out, "\nMost stale entries:\n")
+		for _, d := range stalest {
+			fmt.Fprintf(out, "  %-50s %s\n", d.ID, d.ScrapedAt.Format("2006-01-02 15:04:05"))
+		}
+
+		oldest, err := store.OldestReleases(ctx, top)
+		if err != nil {
+			return fmt.Errorf("release age statistics failed: %w", err)
+		}
+		fmt.Fprintf(out, "\nOldest releases:\n")
+		now := time.Now()
+		for _, d := range oldest {
+			fmt.Fprintf(out, "  %-50s %s\n", d.ID, publishedColumn(d, now))
+		}
+
+		runs, err := store.RecentRuns(ctx, top)
+		if err != nil {
+			return fmt.Errorf("run history failed: %w", err)
+		}
+		fmt.Fprintf(out, "\nRecent runs:\n")
+		for _, r := range runs {
+			fmt.Fprintf(out, "  %s  %4d/%-4d ok  %7.1fs  %5d requests  %4d errors  p95 %6.0fms  store %s\n",
+				r.StartedAt.Local().Format("2006-01-02 15:04:05"), r.Succeeded, r.Attempted,
+				r.DurationSeconds, r.Requests, r.Errors, r.LatencyP95Ms, hitRate(r.CacheHits, r.CacheMisses))
+		}
+		return nil
+	},
+}
+
+func init() {
+	statsCmd.Flags().Int("top", 10, "number of entries in the largest and most stale lists")
+	statsCmd.Flags().String("run", "", "show scraping statistics from a scrape --summary-json file")
+}
+
+// hitRate formats cache hits and misses with the hit ratio.
+func hitRate(hits, misses int) string {
+	if hits+misses == 0 {
+		return "no lookups"
+	}
+	return fmt.Sprintf("%d hits, %d misses (%.0f%% hit rate)", hits, misses, 100*float64(hits)/float64(hits+misses))
+}
+
+// printRunStats prints the outcome and network statistics of one scrape batch.
+func printRunStats(w io.Writer, s *runSummary) {
+	fmt.Fprintf(w, "Run started %s, %.1fs\n", s.StartedAt.Format("2006-01-02 15:04:05"), s.DurationSeconds)
+	fmt.Fprintf(w, "Packages: %d attempted, %d succeeded, %d failed\n", s.Attempted, s.Succeeded, len(s.Failed))
+	fmt.Fprintf(w, "Requests: %d, %d retries, %.1f KiB downloaded\n", s.Scraper.Requests, s.Scraper.Retries, float64(s.BytesDownloaded)/1024)
+	if s.FilesChanged+s.FilesUnchanged > 0 {
+		fmt.Fprintf(w, "Output files: %d changed, %d unchanged\n", s.FilesChanged, s.FilesUnchanged)
+	}
+	if len(s.Deprecated) > 0 {
+		fmt.Fprintf(w, "Deprecated modules: %s\n", strings.Join(s.Deprecated, ", "))
+	}
+	if len(s.Retracted) > 0 {
+		fmt.Fprintf(w, "Retracted versions: %s\n", strings.Join(s.Retracted, ", "))
+	}
+	if len(s.LicenseViolations) > 0 {
+		var names []string
+		for _, v := range s.LicenseViolations {
+			names = append(names, fmt.Sprintf("%s (%s)", v.ImportPath, licenseName(v.License)))
+		}
+		fmt.Fprintf(w, "License violations: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(w, "Store cache: %s\n", hitRate(s.CacheHits, s.CacheMisses))
+	fmt.Fprintf(w, "HTTP cache: %s, %d not modified (304)\n", hitRate(s.Scraper.HTTPCacheHits, s.Scraper.HTTPCacheMisses), s.Scraper.NotModified)
+	fmt.Fprintf(w, "Latency: avg %.0fms, p50 %.0fms, p95 %.0fms, max %.0fms\n",
+		s.Scraper.LatencyAvgMs, s.Scraper.LatencyP50Ms, s.Scraper.LatencyP95Ms, s.Scraper.LatencyMaxMs)
+
+	classes := make([]string, 0, len(s.Scraper.ErrorsByClass))
+	for class := range s.Scraper.ErrorsByClass {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	fmt.Fprintf(w, "Request errors: %d\n", s.Scraper.Errors)
+	for _, class := range classes {
+		fmt.Fprintf(w, "  %-20s %d\n", class, s.Scraper.ErrorsByClass[class])
+	}
+	fallbacks := make([]string, 0, len(s.Scraper.SelectorFallbacks))
+	for key := range s.Scraper.SelectorFallbacks {
+		fallbacks = append(fallbacks, key)
+	}
+	sort.Strings(fallbacks)
+	if len(fallbacks) > 0 {
+		fmt.Fprintln(w, "Selector fallbacks (pages):")
+		for _, key := range fallbacks {
+			fmt.Fprintf(w, "  %-40s %d\n", key, s.Scraper.SelectorFallbacks[key])
+		}
+	}
+	if len(s.Scraper.LayoutWarnings) > 0 {
+		fmt.Fprintf(w, "Probable layout changes: %s\n", strings.Join(s.Scraper.LayoutWarnings, ", "))
+	}
+}
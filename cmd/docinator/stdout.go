@@ -0,0 +1,54 @@
+package docinator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Values of scrape --stdout-format.
+const (
+	stdoutMarkdown      = "md"      // documents concatenated as they are
+	stdoutMultiMarkdown = "mdmulti" // each document framed by begin and end comment lines
+	stdoutJSONL         = "jsonl"   // one JSON object per document and line
+)
+
+var stdoutFormats = []string{stdoutMarkdown, stdoutMultiMarkdown, stdoutJSONL}
+
+// stdoutDocument is one package written with --stdout-format jsonl.
+type stdoutDocument struct {
+	ImportPath string `json:"import_path"`
+	Version    string `json:"version,omitempty"` // pinned version; empty when unpinned
+	Name       string `json:"name"`
+	Synopsis   string `json:"synopsis,omitempty"`
+	Cached     bool   `json:"cached,omitempty"`
+	Markdown   string `json:"markdown"`
+}
+
+// writeStdoutDocument writes the markdown of r to out in format. mdmulti frames every document as
+//
+//	<!-- docinator:document import_path="..." version="..." bytes=N -->
+//	...N bytes of markdown...
+//	<!-- docinator:end -->
+//
+// so consumers can split the stream by the header, or read exactly N bytes after it.
+func writeStdoutDocument(out io.Writer, format string, r renderedPackage) error {
+	switch format {
+	case stdoutMultiMarkdown:
+		_, err := fmt.Fprintf(out, "<!-- docinator:document import_path=%s version=%s bytes=%d -->\n%s\n<!-- docinator:end -->\n",
+			strconv.Quote(r.pkg.ImportPath), strconv.Quote(r.version), len(r.markdown), r.markdown)
+		return err
+	case stdoutJSONL:
+		return json.NewEncoder(out).Encode(stdoutDocument{
+			ImportPath: r.pkg.ImportPath,
+			Version:    r.version,
+			Name:       r.pkg.Name,
+			Synopsis:   r.pkg.Synopsis,
+			Cached:     r.timing.Cached,
+			Markdown:   r.markdown,
+		})
+	}
+	_, err := fmt.Fprint(out, r.markdown)
+	return err
+}
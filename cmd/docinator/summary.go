@@ -0,0 +1,202 @@
+package docinator
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+// runSummary is the machine-readable outcome of a scrape batch, written with --summary-json.
+type runSummary struct {
+	Attempted       int              `json:"attempted"`
+	Succeeded       int              `json:"succeeded"`
+	Failed          []packageFailure `json:"failed"`
+	CacheHits       int              `json:"cache_hits"`   // packages served from the store
+	CacheMisses     int              `json:"cache_misses"` // store lookups that found nothing
+	BytesDownloaded int64            `json:"bytes_downloaded"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	Interrupted     bool             `json:"interrupted"`
+	BudgetSpent     bool             `json:"budget_spent,omitempty"` // the batch outlasted --time-budget; packages not started by then were checkpointed
+	StartedAt       time.Time        `json:"started_at"`
+	Scraper         scraperSummary   `json:"scraper"`
+	Slowest         []timingSummary  `json:"slowest,omitempty"`
+	Deprecated      []string         `json:"deprecated,omitempty"` // packages of modules deprecated in their go.mod
+	Retracted       []string         `json:"retracted,omitempty"`  // packages scraped at a retracted version
+	FilesChanged    int              `json:"files_changed"`        // output files written because their content changed
+	FilesUnchanged  int              `json:"files_unchanged"`      // output files left alone because they already held the content
+	// DeprecatedSymbols maps import paths to their functions, types and methods marked deprecated.
+	DeprecatedSymbols map[string][]string `json:"deprecated_symbols,omitempty"`
+	LicenseViolations []licenseViolation  `json:"license_violations,omitempty"` // packages outside the license policy
+	Private           []privateSource     `json:"private,omitempty"`            // packages matching GOPRIVATE and where they were documented from
+
+	// Spilled counts documents the store still refused at the end of the batch; they were written
+	// to the spill file for docinator import.
+	Spilled int `json:"spilled,omitempty"`
+}
+
+// scraperSummary is the network side of a batch, from scraper.ScrapingStats.
+type scraperSummary struct {
+	Requests        int            `json:"requests"`
+	HTTPCacheHits   int            `json:"http_cache_hits"`
+	HTTPCacheMisses int            `json:"http_cache_misses"`
+	NotModified     int            `json:"not_modified"`
+	Retries         int            `json:"retries"`
+	Errors          int            `json:"errors"`
+	ErrorsByClass   map[string]int `json:"errors_by_class"`
+	LayoutWarnings  []string       `json:"layout_warnings,omitempty"` // import paths whose page looked like a layout change
+	// SkippedResponses lists the URLs of pages dropped unparsed for their size or content type.
+	SkippedResponses []string `json:"skipped_responses,omitempty"`
+	// SelectorFallbacks counts pages where a selector chain fell back past its primary selector, by "key=selector".
+	SelectorFallbacks map[string]int `json:"selector_fallbacks,omitempty"`
+	ConnsOpened       int            `json:"conns_opened"` // requests that dialed a new connection
+	ConnsReused       int            `json:"conns_reused"` // requests over a pooled keep-alive connection
+	LatencyAvgMs      float64        `json:"latency_avg_ms"`
+	LatencyP50Ms      float64        `json:"latency_p50_ms"`
+	LatencyP95Ms      float64        `json:"latency_p95_ms"`
+	LatencyMaxMs      float64        `json:"latency_max_ms"`
+}
+
+func newScraperSummary(stats scraper.ScrapingStats) scraperSummary {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return scraperSummary{
+		Requests:          stats.RequestsMade,
+		HTTPCacheHits:     stats.CacheHits,
+		HTTPCacheMisses:   stats.CacheMisses,
+		NotModified:       stats.NotModified,
+		Retries:           stats.Retries,
+		Errors:            stats.Errors,
+		ErrorsByClass:     stats.ErrorsByClass,
+		LayoutWarnings:    stats.LayoutWarnings,
+		SkippedResponses:  stats.SkippedResponses,
+		SelectorFallbacks: stats.SelectorFallbacks,
+		ConnsOpened:       stats.ConnsOpened,
+		ConnsReused:       stats.ConnsReused,
+		LatencyAvgMs:      ms(stats.LatencyAvg),
+		LatencyP50Ms:      ms(stats.LatencyP50),
+		LatencyP95Ms:      ms(stats.LatencyP95),
+		LatencyMaxMs:      ms(stats.LatencyMax),
+	}
+}
+
+// deprecatedSymbols lists the functions, types and methods of a package marked deprecated.
+func deprecatedSymbols(pkg *models.Package) []string {
+	var names []string
+	for _, f := range pkg.Functions {
+		if f.Deprecated != "" {
+			names = append(names, f.Name)
+		}
+	}
+	for _, t := range pkg.Types {
+		if t.Deprecated != "" {
+			names = append(names, t.Name)
+		}
+		for _, m := range t.Methods {
+			if m.Deprecated != "" {
+				names = append(names, m.Name)
+			}
+		}
+	}
+	return names
+}
+
+// packageFailure records why one import path failed.
+type packageFailure struct {
+	ImportPath string `json:"import_path"`
+	Error      string `json:"error"`
+}
+
+// newPackageFailure records err for importPath. The error of a failed scrape is stored without
+// its "failed to scrape <path>" prefix, since every report already names the path.
+func newPackageFailure(importPath string, err error) packageFailure {
+	var pathErr *scraper.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return packageFailure{ImportPath: importPath, Error: err.Error()}
+}
+
+// newRunRecord converts the summary of a batch run with opts into the record kept in the runs collection.
+func newRunRecord(s *runSummary, opts scrapeOptions) *models.Run {
+	run := &models.Run{
+		ID:              s.StartedAt.UTC().Format(time.RFC3339Nano),
+		StartedAt:       s.StartedAt,
+		Args:            opts.ImportPaths,
+		DurationSeconds: s.DurationSeconds,
+		Interrupted:     s.Interrupted,
+		Attempted:       s.Attempted,
+		Succeeded:       s.Succeeded,
+		CacheHits:       s.CacheHits,
+		CacheMisses:     s.CacheMisses,
+		HTTPCacheHits:   s.Scraper.HTTPCacheHits,
+		HTTPCacheMisses: s.Scraper.HTTPCacheMisses,
+		NotModified:     s.Scraper.NotModified,
+		BytesDownloaded: s.BytesDownloaded,
+		Requests:        s.Scraper.Requests,
+		Retries:         s.Scraper.Retries,
+		Errors:          s.Scraper.Errors,
+		ErrorsByClass:   s.Scraper.ErrorsByClass,
+		LayoutWarnings:  len(s.Scraper.LayoutWarnings),
+		LatencyP50Ms:    s.Scraper.LatencyP50Ms,
+		LatencyP95Ms:    s.Scraper.LatencyP95Ms,
+		Config: map[string]string{
+			"output":         opts.OutputDir,
+			"http_cache_dir": opts.HTTPCacheDir,
+			"test_mode":      strconv.FormatBool(opts.TestMode),
+			"fail_fast":      strconv.FormatBool(opts.FailFast),
+			"strict_layout":  strconv.FormatBool(opts.StrictLayout),
+			"locked":         strconv.FormatBool(opts.Lock != nil),
+			"summarize":      strconv.FormatBool(opts.Summarize),
+			"fetch_source":   strconv.FormatBool(opts.FetchSource),
+			"local_source":   strconv.FormatBool(opts.LocalSource),
+			"share_examples": strconv.FormatBool(opts.ShareExamples),
+			"importers":      strconv.Itoa(opts.Importers),
+			"imports":        strconv.FormatBool(opts.Imports),
+			"profile":        opts.Profile.String(),
+			"time_budget":    opts.TimeBudget.String(),
+			"schedule":       opts.Schedule,
+			"guides":         strconv.Itoa(opts.Guides),
+			"post_to":        redactURI(opts.PostTo),
+			"allow_licenses": strings.Join(opts.AllowLicenses, ","),
+			"site":           siteName(opts.Site),
+		},
+	}
+	for _, f := range s.Failed {
+		run.Failures = append(run.Failures, models.RunFailure{ImportPath: f.ImportPath, Error: f.Error})
+	}
+	return run
+}
+
+// readSummary reads a summary written by writeSummary.
+func readSummary(path string) (*runSummary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var summary runSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// writeSummary writes summary as indented JSON to path, creating parent directories.
+func writeSummary(path string, summary *runSummary) error {
+	if summary.Failed == nil {
+		summary.Failed = []packageFailure{}
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
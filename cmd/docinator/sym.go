@@ -0,0 +1,77 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/search"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var symCmd = &cobra.Command{
+	Use:     "sym <query>",
+	Aliases: []string{"search"},
+	Short:   "Fuzzy-find exported symbols across the cached corpus",
+	Long: `Search exported constants, variables, functions, types and methods of every
+cached package by fuzzy name match, printing each symbol's signature and import
+path, like a corpus-wide "godoc -q". With --bundle, or an installed bundle and
+no store, the prebuilt index of an offline bundle is searched instead.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k, _ := cmd.Flags().GetInt("limit")
+		ctx := cmd.Context()
+
+		var symbols []search.Symbol
+		bundle, err := lookupBundle(cmd)
+		if err != nil {
+			return err
+		}
+		if bundle != nil {
+			defer bundle.Close(ctx)
+			symbols, err = bundleSymbols(ctx, bundle, tagFilter(cmd))
+		} else {
+			var store storage.Store
+			var closeStore func()
+			if store, closeStore, err = openStore(ctx); err != nil {
+				return err
+			}
+			defer closeStore()
+			if !store.Enabled() {
+				return needsCache(store, "sym", "set MONGODB_URI or BOLT_PATH, or pass --bundle")
+			}
+			symbols, err = corpusSymbols(ctx, store, tagFilter(cmd))
+		}
+		if err != nil {
+			return fmt.Errorf("loading symbols failed: %w", err)
+		}
+		out := cmd.OutOrStdout()
+		for _, m := range search.FuzzyFind(strings.Join(args, " "), symbols, k) {
+			fmt.Fprintf(out, "%s.%s  (%s)\n", m.ImportPath, m.Name, m.Kind)
+			if m.Signature != "" {
+				fmt.Fprintf(out, "    %s\n", m.Signature)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	symCmd.Flags().IntP("limit", "k", 20, "number of results to return")
+	symCmd.Flags().StringSlice("tag", nil, "search only packages carrying all of these tags (see docinator tag)")
+	symCmd.Flags().String("bundle", "", "search this offline bundle (see docinator bundle export) instead of the store")
+}
+
+// corpusSymbols collects the exported symbols of every cached package carrying all of tags.
+func corpusSymbols(ctx context.Context, store storage.Store, tags []string) ([]search.Symbol, error) {
+	var symbols []search.Symbol
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package != nil && hasTags(doc.Tags, tags) {
+			symbols = append(symbols, search.PackageSymbols(doc.Package)...)
+		}
+		return nil
+	})
+	return symbols, err
+}
@@ -0,0 +1,158 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <import path> [tags...]",
+	Short: "Attach curation tags and a note to a cached package",
+	Long: `Add tags to a cached package, or with --remove take them off, and set a
+free-form note with --note. Without tags or flags, print the package's tags and
+note. Tags are lower-cased, kept across re-scrapes, and filter list, sym, export
+and site build with --tag, for example to build a portal of approved
+dependencies:
+
+  docinator tag github.com/spf13/cobra cli approved --note "Use for all CLIs"
+  docinator site build --tag approved
+
+Name a pinned snapshot as path@version to tag only that version.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var change tagChange
+		change.Remove, _ = cmd.Flags().GetBool("remove")
+		change.Clear, _ = cmd.Flags().GetBool("clear")
+		if cmd.Flags().Changed("note") {
+			note, _ := cmd.Flags().GetString("note")
+			change.Note = &note
+		}
+		change.Tags = args[1:]
+		ctx := cmd.Context()
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "tag", "set MONGODB_URI or BOLT_PATH")
+		}
+		if err := runTag(ctx, store, args[0], change, cmd.OutOrStdout()); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	tagCmd.Flags().Bool("remove", false, "remove the given tags instead of adding them")
+	tagCmd.Flags().Bool("clear", false, "remove every tag and the note before applying the others")
+	tagCmd.Flags().String("note", "", "set the note; an empty note removes it")
+}
+
+// tagChange is what tag does to a document's curation metadata.
+type tagChange struct {
+	Tags   []string
+	Remove bool
+	Clear  bool
+	Note   *string // nil leaves the note alone
+}
+
+// empty reports whether c changes nothing, so tag only prints.
+func (c tagChange) empty() bool {
+	return len(c.Tags) == 0 && !c.Clear && c.Note == nil
+}
+
+// runTag applies change to the document stored under id and prints its tags and note.
+func runTag(ctx context.Context, store storage.Store, id string, change tagChange, out io.Writer) error {
+	doc, err := store.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", id, err)
+	}
+	if doc == nil {
+		return fmt.Errorf("%s is not cached; scrape it first", id)
+	}
+	if !change.empty() {
+		if change.Clear {
+			doc.Tags, doc.Note = nil, ""
+		}
+		for _, tag := range normalizeTags(change.Tags) {
+			if change.Remove {
+				doc.Tags = slices.DeleteFunc(doc.Tags, func(t string) bool { return t == tag })
+			} else if !slices.Contains(doc.Tags, tag) {
+				doc.Tags = append(doc.Tags, tag)
+			}
+		}
+		slices.Sort(doc.Tags)
+		if len(doc.Tags) == 0 {
+			doc.Tags = nil
+		}
+		if change.Note != nil {
+			doc.Note = strings.TrimSpace(*change.Note)
+		}
+		if err := store.Upsert(ctx, doc); err != nil {
+			return fmt.Errorf("saving %s: %w", id, err)
+		}
+	}
+	fmt.Fprintf(out, "%s: %s\n", doc.ID, strings.Join(doc.Tags, ", "))
+	if doc.Note != "" {
+		fmt.Fprintf(out, "  %s\n", doc.Note)
+	}
+	return nil
+}
+
+// normalizeTags lower-cases and trims tags, dropping empty ones. Commas separate tags too.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		for _, t := range strings.Split(tag, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// hasTags reports whether tags holds every one of want, or whether want is empty.
+func hasTags(tags, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(tags, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAnyTag reports whether tags holds at least one of want.
+func hasAnyTag(tags, want []string) bool {
+	return slices.ContainsFunc(want, func(w string) bool { return slices.Contains(tags, w) })
+}
+
+// tagFilter returns the normalized --tag values of cmd.
+func tagFilter(cmd *cobra.Command) []string {
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	return normalizeTags(tags)
+}
+
+// carryCuration copies the tags, note and adoption history of the document stored under doc.ID,
+// if any, to doc before it replaces it, and stamps doc's content hash for docinator render.
+func carryCuration(ctx context.Context, store storage.Store, doc *models.Document) {
+	prev, err := store.GetByID(ctx, doc.ID)
+	if err != nil {
+		return
+	}
+	stampContent(doc, prev, time.Now())
+	if prev != nil {
+		doc.Tags, doc.Note, doc.Adoption = prev.Tags, prev.Note, prev.Adoption
+	}
+}
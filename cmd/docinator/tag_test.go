@@ -0,0 +1,50 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestRunTag(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	if err := store.Upsert(ctx, &models.Document{ID: "github.com/spf13/cobra", Package: &models.Package{ImportPath: "github.com/spf13/cobra"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	note := "Use for all CLIs"
+	var out bytes.Buffer
+	if err := runTag(ctx, store, "github.com/spf13/cobra", tagChange{Tags: []string{"CLI", "approved,cli"}, Note: &note}, &out); err != nil {
+		t.Fatalf("runTag failed: %v", err)
+	}
+	if out.String() != "github.com/spf13/cobra: approved, cli\n  Use for all CLIs\n" {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if err := runTag(ctx, store, "github.com/spf13/cobra", tagChange{Tags: []string{"cli"}, Remove: true}, &out); err != nil {
+		t.Fatalf("runTag failed: %v", err)
+	}
+	doc, _ := store.GetByID(ctx, "github.com/spf13/cobra")
+	if !slices.Equal(doc.Tags, []string{"approved"}) || doc.Note != note {
+		t.Errorf("Expected tag approved and the note, got %v %q", doc.Tags, doc.Note)
+	}
+
+	// A re-scrape replaces the document but keeps its curation.
+	fresh := &models.Document{ID: "github.com/spf13/cobra", Package: &models.Package{ImportPath: "github.com/spf13/cobra", Version: "v1.9.0"}}
+	carryCuration(ctx, store, fresh)
+	if !slices.Equal(fresh.Tags, []string{"approved"}) || fresh.Note != note {
+		t.Errorf("Expected the curation carried over, got %v %q", fresh.Tags, fresh.Note)
+	}
+
+	if !hasTags(fresh.Tags, nil) || !hasTags(fresh.Tags, []string{"approved"}) || hasTags(fresh.Tags, []string{"approved", "cli"}) {
+		t.Error("Expected hasTags to require every wanted tag")
+	}
+	if err := runTag(ctx, store, "example.com/missing", tagChange{Tags: []string{"x"}}, &out); err == nil || !strings.Contains(err.Error(), "not cached") {
+		t.Errorf("Expected an error for an uncached package, got %v", err)
+	}
+}
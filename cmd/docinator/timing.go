@@ -0,0 +1,80 @@
+package docinator
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// packageTiming is where the time went for one package of a batch.
+type packageTiming struct {
+	ImportPath string
+	Cached     bool          // loaded from the store instead of scraped
+	Fetch      time.Duration // store lookup or download, plus enrichers
+	Parse      time.Duration // extracting the package from the page
+	Render     time.Duration // markdown and raw text
+	Store      time.Duration // cache write and output files
+}
+
+// Total returns the time spent on the package across all stages.
+func (t packageTiming) Total() time.Duration {
+	return t.Fetch + t.Parse + t.Render + t.Store
+}
+
+// timingSummary is a packageTiming in the run summary, in milliseconds.
+type timingSummary struct {
+	ImportPath string  `json:"import_path"`
+	Cached     bool    `json:"cached,omitempty"`
+	FetchMs    float64 `json:"fetch_ms"`
+	ParseMs    float64 `json:"parse_ms"`
+	RenderMs   float64 `json:"render_ms"`
+	StoreMs    float64 `json:"store_ms"`
+	TotalMs    float64 `json:"total_ms"`
+}
+
+func newTimingSummaries(timings []packageTiming) []timingSummary {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	out := make([]timingSummary, 0, len(timings))
+	for _, t := range timings {
+		out = append(out, timingSummary{
+			ImportPath: t.ImportPath,
+			Cached:     t.Cached,
+			FetchMs:    ms(t.Fetch),
+			ParseMs:    ms(t.Parse),
+			RenderMs:   ms(t.Render),
+			StoreMs:    ms(t.Store),
+			TotalMs:    ms(t.Total()),
+		})
+	}
+	return out
+}
+
+// slowestPackages returns the n timings with the largest total, slowest first.
+func slowestPackages(timings []packageTiming, n int) []packageTiming {
+	if n <= 0 {
+		return nil
+	}
+	sorted := append([]packageTiming(nil), timings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Total() > sorted[j].Total() })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// logSlowest logs a breakdown of the given timings, one package per line.
+func logSlowest(timings []packageTiming) {
+	if len(timings) == 0 {
+		return
+	}
+	log.Printf("Slowest packages (fetch / parse / render / store):")
+	for _, t := range timings {
+		cached := ""
+		if t.Cached {
+			cached = " (cached)"
+		}
+		log.Printf("  %-40s %8v  %v / %v / %v / %v%s", t.ImportPath, t.Total().Round(time.Millisecond),
+			t.Fetch.Round(time.Millisecond), t.Parse.Round(time.Millisecond),
+			t.Render.Round(time.Millisecond), t.Store.Round(time.Millisecond), cached)
+	}
+}
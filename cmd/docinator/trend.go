@@ -0,0 +1,149 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var trendCmd = &cobra.Command{
+	Use:   "trend <import path>",
+	Short: "Show how a package's imported-by count evolved across scrapes",
+	Long: `Show the adoption of a cached package over time: the imported-by and imports
+counts pkg.go.dev showed on every day the package was scraped, oldest first,
+with the change in importers since the previous day and a sparkline.
+
+Counts are kept on the cached document when scrapes run in history mode
+(--history on scrape, warm or watch); without it only the counts of the cached
+snapshots, one per version and the latest, are known.
+
+  docinator scrape --history github.com/spf13/cobra   # e.g. daily from cron
+  docinator trend github.com/spf13/cobra`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		store, closeStore, err := openStore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "trend", "set MONGODB_URI or BOLT_PATH")
+		}
+		points, err := adoptionTrend(cmd.Context(), store, args[0])
+		if err != nil {
+			return err
+		}
+		if len(points) == 0 {
+			return fmt.Errorf("no adoption counts of %s are cached; scrape it with --history", args[0])
+		}
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(points); err != nil {
+				return err
+			}
+			return nil
+		}
+		printTrend(cmd.OutOrStdout(), args[0], points)
+		return nil
+	},
+}
+
+func init() {
+	trendCmd.Flags().Bool("json", false, "print the data points as JSON")
+}
+
+// recordAdoption returns points with the adoption of pkg at now added, replacing a point of the
+// same day so daily and more frequent scrapes keep one point per day.
+func recordAdoption(points []models.AdoptionPoint, pkg *models.Package, now time.Time) []models.AdoptionPoint {
+	p := models.AdoptionPoint{At: now.UTC(), ImportedBy: pkg.ImportedBy, Imports: pkg.Imports}
+	if n := len(points); n > 0 && sameDay(points[n-1].At, p.At) {
+		return append(points[:n-1:n-1], p)
+	}
+	return append(slices.Clip(points), p)
+}
+
+// adoptionTrend returns the adoption of importPath, one point per day oldest first, from the
+// history of its cached documents, pinned or not, and the counts of the snapshots themselves.
+func adoptionTrend(ctx context.Context, store storage.Store, importPath string) ([]models.AdoptionPoint, error) {
+	var points []models.AdoptionPoint
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package == nil || (doc.ID != importPath && !strings.HasPrefix(doc.ID, importPath+"@")) {
+			return nil
+		}
+		points = append(points, doc.Adoption...)
+		if pkg := doc.Package; !pkg.ScrapedAt.IsZero() && (pkg.ImportedBy > 0 || pkg.Imports > 0) {
+			points = append(points, models.AdoptionPoint{At: pkg.ScrapedAt.UTC(), ImportedBy: pkg.ImportedBy, Imports: pkg.Imports})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(points, func(a, b models.AdoptionPoint) int { return a.At.Compare(b.At) })
+	// Keep the last point of each day.
+	var daily []models.AdoptionPoint
+	for _, p := range points {
+		if n := len(daily); n > 0 && sameDay(daily[n-1].At, p.At) {
+			daily[n-1] = p
+			continue
+		}
+		daily = append(daily, p)
+	}
+	return daily, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.UTC().Format(time.DateOnly) == b.UTC().Format(time.DateOnly)
+}
+
+// printTrend writes the points as a table followed by a summary line with a sparkline.
+func printTrend(out io.Writer, importPath string, points []models.AdoptionPoint) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "DATE\tIMPORTED BY\tCHANGE\tIMPORTS\t")
+	for i, p := range points {
+		change := "-"
+		if i > 0 {
+			change = fmt.Sprintf("%+d", p.ImportedBy-points[i-1].ImportedBy)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t\n", p.At.Format(time.DateOnly), p.ImportedBy, change, p.Imports)
+	}
+	w.Flush()
+
+	first, last := points[0], points[len(points)-1]
+	days := int(last.At.Sub(first.At).Hours() / 24)
+	fmt.Fprintf(out, "\n%s: imported by %d → %d", importPath, first.ImportedBy, last.ImportedBy)
+	if first.ImportedBy > 0 {
+		fmt.Fprintf(out, " (%+.1f%%)", 100*float64(last.ImportedBy-first.ImportedBy)/float64(first.ImportedBy))
+	}
+	fmt.Fprintf(out, " over %d days  %s\n", days, sparkline(points))
+}
+
+// sparkline draws the imported-by counts of points with block characters scaled to their range.
+func sparkline(points []models.AdoptionPoint) string {
+	const bars = "▁▂▃▄▅▆▇█"
+	levels := []rune(bars)
+	lo, hi := points[0].ImportedBy, points[0].ImportedBy
+	for _, p := range points {
+		lo, hi = min(lo, p.ImportedBy), max(hi, p.ImportedBy)
+	}
+	var b strings.Builder
+	for _, p := range points {
+		i := 0
+		if hi > lo {
+			i = (p.ImportedBy - lo) * (len(levels) - 1) / (hi - lo)
+		}
+		b.WriteRune(levels[i])
+	}
+	return b.String()
+}
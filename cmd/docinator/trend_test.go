@@ -0,0 +1,64 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestRecordAdoption(t *testing.T) {
+	day1 := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	var points []models.AdoptionPoint
+	points = recordAdoption(points, &models.Package{ImportedBy: 100, Imports: 3}, day1)
+	points = recordAdoption(points, &models.Package{ImportedBy: 101, Imports: 3}, day1.Add(4*time.Hour))
+	points = recordAdoption(points, &models.Package{ImportedBy: 110, Imports: 4}, day1.AddDate(0, 0, 1))
+	if len(points) != 2 || points[0].ImportedBy != 101 || points[1].ImportedBy != 110 {
+		t.Errorf("Expected one point per day, the latest winning, got %+v", points)
+	}
+}
+
+func TestAdoptionTrend(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC) }
+	store := memstore.New()
+	for _, doc := range []*models.Document{
+		{
+			ID:      "github.com/spf13/cobra",
+			Package: &models.Package{ImportPath: "github.com/spf13/cobra", ImportedBy: 130, Imports: 2, ScrapedAt: day(5)},
+			Adoption: []models.AdoptionPoint{
+				{At: day(3), ImportedBy: 120, Imports: 2},
+				{At: day(5), ImportedBy: 130, Imports: 2},
+			},
+		},
+		{ID: "github.com/spf13/cobra@v1.8.0", Package: &models.Package{ImportPath: "github.com/spf13/cobra", ImportedBy: 100, Imports: 2, ScrapedAt: day(1)}},
+		{ID: "github.com/spf13/cobrax", Package: &models.Package{ImportPath: "github.com/spf13/cobrax", ImportedBy: 1, ScrapedAt: day(2)}},
+	} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	points, err := adoptionTrend(ctx, store, "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, p := range points {
+		got = append(got, p.ImportedBy)
+	}
+	if len(got) != 3 || got[0] != 100 || got[1] != 120 || got[2] != 130 {
+		t.Fatalf("Expected 100, 120, 130 oldest first, got %v", got)
+	}
+
+	var out bytes.Buffer
+	printTrend(&out, "github.com/spf13/cobra", points)
+	for _, want := range []string{"2025-01-03", "+20", "+10", "100 → 130 (+30.0%) over 4 days  ▁▅█"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/localdoc"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <module dir>",
+	Short: "Compare a module's local docs with the ones published on pkg.go.dev",
+	Long: `Read the doc comments of every package in a module directory with go/doc and
+compare them with the packages scraped from pkg.go.dev, listing symbols whose
+published docs are stale, missing (not published yet) or removed locally.
+Published pages are scraped fresh unless --cached is set. Run it before
+tagging a release to see what the new version will change, or after to check
+that pkg.go.dev picked it up:
+
+  docinator verify .
+  docinator verify --version v1.4.0 ./
+
+The exit status is 1 when any package differs or could not be loaded.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, _ := cmd.Flags().GetString("version")
+		cached, _ := cmd.Flags().GetBool("cached")
+
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		differ, err := runVerify(cmd.Context(), loader, args[0], version, cached, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		if differ {
+			stopProfiling()
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	verifyCmd.Flags().String("version", "", "compare with this published version instead of the latest")
+	verifyCmd.Flags().Bool("cached", false, "use published packages from the cache instead of scraping them again")
+}
+
+// runVerify compares the packages of the module in dir with their published docs at version (the
+// latest when empty) and writes the differences to out. It reports whether any package differed
+// or failed to load.
+func runVerify(ctx context.Context, loader *packageLoader, dir, version string, cached bool, out io.Writer) (bool, error) {
+	module, pkgs, err := localdoc.ModulePackages(dir)
+	if err != nil {
+		return false, err
+	}
+	if len(pkgs) == 0 {
+		return false, fmt.Errorf("no packages found in module %s", module)
+	}
+
+	var drifted, failed, symbols int
+	for _, p := range pkgs {
+		src, err := localdoc.Read(p.Dir, p.ImportPath)
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "%s: reading local source failed: %v\n", p.ImportPath, err)
+			continue
+		}
+		id := p.ImportPath
+		if version != "" {
+			id += "@" + version
+		}
+		load := loader.load
+		if !cached {
+			load = func(ctx context.Context, id string) (*models.Package, string, error) {
+				pkg, rawHTML, _, err := loader.scrapeTimed(ctx, id, time.Now())
+				return pkg, rawHTML, err
+			}
+		}
+		pkg, _, err := load(ctx, id)
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "%s: not published: %v\n", p.ImportPath, err)
+			continue
+		}
+
+		drifts := localdoc.Compare(pkg, src)
+		if len(drifts) == 0 {
+			fmt.Fprintf(out, "%s (%s): up to date\n", p.ImportPath, pkg.Version)
+			continue
+		}
+		drifted++
+		symbols += len(drifts)
+		fmt.Fprintf(out, "%s (%s):\n", p.ImportPath, pkg.Version)
+		for _, d := range drifts {
+			fmt.Fprintf(out, "  %-8s %s\n", d.Kind, d.Symbol)
+		}
+	}
+	fmt.Fprintf(out, "\n%d of %d packages differ (%d symbols), %d could not be compared\n", drifted, len(pkgs), symbols, failed)
+	return drifted+failed > 0, nil
+}
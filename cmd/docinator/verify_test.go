@@ -0,0 +1,61 @@
+package docinator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+func TestRunVerify(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":   "module example.com/m\n\ngo 1.24\n",
+		"m.go":     "// Package m does things.\npackage m\n\n// Do does a thing.\nfunc Do() {}\n\n// Undo reverts it.\nfunc Undo() {}\n",
+		"sub/s.go": "// Package sub helps.\npackage sub\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	store := memstore.New()
+	for _, pkg := range []*models.Package{
+		{ImportPath: "example.com/m", Version: "v1.0.0", Description: "Package m does things.", Functions: []models.Function{{Name: "Do", Description: "Do does something."}}},
+		{ImportPath: "example.com/m/sub", Version: "v1.0.0", Description: "Package sub helps."},
+	} {
+		if err := store.Upsert(ctx, &models.Document{ID: pkg.ImportPath, Package: pkg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader, cleanup, err := newLoader(store, &scraper.ScrapingConfig{TestMode: true}, false)
+	if err != nil {
+		t.Fatalf("newLoader failed: %v", err)
+	}
+	defer cleanup()
+
+	var out bytes.Buffer
+	differ, err := runVerify(ctx, loader, dir, "", true, &out)
+	if err != nil {
+		t.Fatalf("runVerify failed: %v", err)
+	}
+	for _, want := range []string{"example.com/m (v1.0.0):\n  stale    Do\n  missing  Undo\n", "example.com/m/sub (v1.0.0): up to date\n", "1 of 2 packages differ (2 symbols)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the report, got:\n%s", want, out.String())
+		}
+	}
+	if !differ {
+		t.Error("Expected the run to report differences")
+	}
+}
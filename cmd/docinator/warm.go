@@ -0,0 +1,223 @@
+package docinator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/moseye/docinator/pkg/ratelimit"
+	"github.com/spf13/cobra"
+)
+
+var warmCmd = &cobra.Command{
+	Use:   "warm [packages...]",
+	Short: "Prefetch a list of packages into the cache",
+	Long: `Fill the cache for a curated list of packages so interactive commands hit the
+cache instead of pkg.go.dev. Import paths come from the arguments and from
+--file (one per line, "#" starts a comment, "-" reads stdin). Packages
+scraped less than --max-age ago are skipped; the others are scraped again at
+the pace set by --rate-limit. Intended to run nightly:
+
+  docinator warm -f toplist.txt --max-age 24h --rate-limit 1
+
+The exit status is 1 when any package failed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		maxAge, _ := cmd.Flags().GetDuration("max-age")
+		progressJSON, _ := cmd.Flags().GetBool("progress-json")
+
+		importPaths := args
+		if file != "" {
+			listed, err := readImportList(file)
+			if err != nil {
+				return fmt.Errorf("reading %s failed: %w", file, err)
+			}
+			importPaths = append(importPaths, listed...)
+		}
+		if len(importPaths) == 0 {
+			return withHint(errors.New("warm needs import paths"), "pass them as arguments or with --file")
+		}
+		importPaths = normalizedImportPaths(importPaths)
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		priority, err := requestPriority(ratelimit.Background)
+		if err != nil {
+			return err
+		}
+		ctx = ratelimit.WithPriority(ctx, priority)
+
+		loader, cleanup, err := newPackageLoader(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if !loader.store.Enabled() {
+			return needsCache(loader.store, "warm", "set MONGODB_URI or BOLT_PATH, or pass --store")
+		}
+		if progressJSON {
+			loader.progress = newProgressReporter(os.Stderr, nil)
+		}
+		loader.pending = &upsertQueue{}
+
+		var warmed, fresh, failed int
+		for i, importPath := range importPaths {
+			if ctx.Err() != nil {
+				log.Printf("Interrupted after %d of %d packages", i, len(importPaths))
+				break
+			}
+			prefix := fmt.Sprintf("[%d/%d] %s", i+1, len(importPaths), importPath)
+
+			doc, err := loader.store.GetByID(ctx, importPath)
+			if err != nil {
+				log.Printf("%s: cache lookup failed: %v", prefix, err)
+			} else if doc != nil && doc.Package != nil && time.Since(doc.Package.ScrapedAt) < maxAge {
+				fresh++
+				loader.progress.emit(progressEvent{Event: eventStored, ImportPath: importPath, Cached: true})
+				log.Printf("%s: fresh (scraped %s ago)", prefix, time.Since(doc.Package.ScrapedAt).Round(time.Minute))
+				continue
+			}
+
+			loader.progress.emit(progressEvent{Event: eventFetching, ImportPath: importPath})
+			start := time.Now()
+			if _, _, _, err := loader.scrapeTimed(ctx, importPath, start); err != nil {
+				failed++
+				loader.progress.emit(progressEvent{Event: eventFailed, ImportPath: importPath, Error: err.Error()})
+				log.Printf("%s: %v", prefix, err)
+				continue
+			}
+			warmed++
+			loader.progress.emit(progressEvent{Event: eventStored, ImportPath: importPath})
+			log.Printf("%s: cached in %v", prefix, time.Since(start).Round(time.Millisecond))
+		}
+
+		if _, spilled, err := loader.flushPending(context.WithoutCancel(ctx), spillFile); err != nil {
+			log.Printf("%v", err)
+		} else if spilled > 0 {
+			log.Printf("WARNING: the store is unavailable; %d package(s) spilled to %s, load them with: docinator import %s", spilled, spillFile, spillFile)
+		}
+		log.Printf("Warmed %d packages, %d already fresh, %d failed", warmed, fresh, failed)
+		if failed > 0 {
+			stopProfiling()
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	warmCmd.Flags().StringP("file", "f", "", "file listing import paths, one per line (- for stdin)")
+	warmCmd.Flags().Duration("max-age", 24*time.Hour, "skip packages scraped more recently than this")
+	warmCmd.Flags().Bool("progress-json", false, "emit one JSON event per package (fetching, stored, failed) to stderr")
+}
+
+// readImportList reads import paths from path, or stdin for "-", one per line. Blank lines and
+// text after "#" are ignored.
+func readImportList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// majorVersion matches a major version suffix element such as "v2" in any case.
+var majorVersion = regexp.MustCompile(`^[vV][0-9]+$`)
+
+// normalizeImportPath cleans up an import path as typed or pasted: the pkg.go.dev URL prefix,
+// surrounding and repeated slashes are dropped, the host and a major version suffix ("/V2") are
+// lowercased, as is a pinned version ("@V1.2.0"). The rest of the path is case-sensitive and
+// kept as it is.
+func normalizeImportPath(p string) string {
+	p = strings.TrimSpace(p)
+	for _, prefix := range []string{"https://", "http://", "pkg.go.dev/"} {
+		if len(p) >= len(prefix) && strings.EqualFold(p[:len(prefix)], prefix) {
+			p = p[len(prefix):]
+		}
+	}
+	path, version, pinned := strings.Cut(p, "@")
+	var elems []string
+	for _, elem := range strings.Split(path, "/") {
+		if elem != "" {
+			elems = append(elems, elem)
+		}
+	}
+	if len(elems) == 0 {
+		return p
+	}
+	if strings.Contains(elems[0], ".") {
+		elems[0] = strings.ToLower(elems[0])
+	}
+	for i, elem := range elems[1:] {
+		if majorVersion.MatchString(elem) {
+			elems[i+1] = strings.ToLower(elem)
+		}
+	}
+	path = strings.Join(elems, "/")
+	if pinned {
+		version = strings.TrimSuffix(version, "/")
+		if strings.HasPrefix(version, "V") {
+			version = "v" + version[1:]
+		}
+		path += "@" + version
+	}
+	return path
+}
+
+// normalizedImportPaths is dedupeImportPaths logging what it changed.
+func normalizedImportPaths(paths []string) []string {
+	unique, changes := dedupeImportPaths(paths)
+	for _, c := range changes {
+		log.Printf("Import path %s", c)
+	}
+	if len(unique) < len(paths) {
+		log.Printf("Merged %d duplicate import path(s); %d left", len(paths)-len(unique), len(unique))
+	}
+	return unique
+}
+
+// dedupeImportPaths normalizes paths and drops the ones that normalize to a path seen before,
+// keeping the first occurrence's position. changes describes each input that was rewritten or
+// merged into an earlier one, e.g. "GitHub.com/spf13/cobra/ -> github.com/spf13/cobra (duplicate)".
+func dedupeImportPaths(paths []string) (unique, changes []string) {
+	seen := map[string]bool{}
+	for _, p := range paths {
+		norm := normalizeImportPath(p)
+		change := p
+		if norm != p {
+			change += " -> " + norm
+		}
+		if seen[norm] {
+			changes = append(changes, change+" (duplicate)")
+			continue
+		}
+		if norm != p {
+			changes = append(changes, change)
+		}
+		seen[norm] = true
+		unique = append(unique, norm)
+	}
+	return unique, changes
+}
@@ -0,0 +1,123 @@
+package docinator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/notify"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Regenerate output whenever a cached package changes",
+	Long: `Subscribe to MongoDB change streams on the packages collection and rewrite
+the markdown and raw files in the output directory whenever any process
+inserts or updates a document, keeping published docs in sync with the store.
+With --initial, every cached package is written once before watching.
+
+With --notify, a one-line summary of each change (package, new version, added
+and removed symbols, link to the docs under --base-url) is posted to a Slack
+or Discord incoming webhook.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verbose := verbosity() >= 1
+		outputDir, _ := rootCmd.PersistentFlags().GetString("output")
+		initial, _ := cmd.Flags().GetBool("initial")
+		hooks, _ := cmd.Flags().GetStringSlice("notify")
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		if outputDir == "" {
+			return withHint(errors.New("watch needs an output directory"), "pass --output")
+		}
+		ctx := cmd.Context()
+
+		store, closeStore := openMongoStore(ctx)
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "watch", "set MONGODB_URI")
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output dir: %w", err)
+		}
+
+		// states remembers every package's version and symbols, so changes can be summarized.
+		var states map[string]notify.State
+		client := &http.Client{Timeout: 30 * time.Second}
+		if len(hooks) > 0 {
+			states = map[string]notify.State{}
+			err := store.ForEach(ctx, func(doc *models.Document) error {
+				if doc.Package != nil {
+					states[doc.ID] = notify.NewState(doc.Package)
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("loading packages failed: %w", err)
+			}
+		}
+
+		regenerate := func(doc *models.Document) error {
+			if doc.Package == nil {
+				return nil
+			}
+			log.Printf("Regenerating output for package: %s", doc.Package.ImportPath)
+			version := pinnedVersion(doc.ID)
+			if files := writePackageFiles(outputDir, doc.Package, doc.RawHTML, version, verbose); files.Changed == 0 {
+				log.Printf("Output of %s is unchanged", doc.Package.ImportPath)
+			}
+			if states != nil {
+				var prev *notify.State
+				if s, ok := states[doc.ID]; ok {
+					prev = &s
+				}
+				states[doc.ID] = notify.NewState(doc.Package)
+				change := notify.Compare(prev, doc.Package)
+				if !change.Changed() {
+					return nil
+				}
+				if baseURL != "" {
+					change.URL = strings.TrimSuffix(baseURL, "/") + "/" + outputPage(renderedPackage{pkg: doc.Package, version: version})
+				}
+				for _, hook := range hooks {
+					if err := notify.Post(ctx, client, hook, change); err != nil {
+						log.Printf("Failed to notify about %s: %v", doc.Package.ImportPath, err)
+					}
+				}
+			}
+			return nil
+		}
+
+		if initial {
+			// ForEach omits raw HTML, so load each document in full before writing. These
+			// packages are already in states, so they notify only if they changed meanwhile.
+			err := store.ForEach(ctx, func(summary *models.Document) error {
+				doc, err := store.GetByID(ctx, summary.ID)
+				if err != nil || doc == nil {
+					return err
+				}
+				return regenerate(doc)
+			})
+			if err != nil {
+				return fmt.Errorf("initial generation failed: %w", err)
+			}
+		}
+
+		log.Printf("Watching for package changes; writing to %s", outputDir)
+		if err := store.Watch(ctx, regenerate); err != nil {
+			return fmt.Errorf("watch failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	watchCmd.Flags().Bool("initial", false, "write every cached package before watching for changes")
+	watchCmd.Flags().StringSlice("notify", nil, "Slack or Discord incoming webhook URL that receives a summary of every change (repeatable)")
+	watchCmd.Flags().String("base-url", "", "URL the output directory is published at, linked from notifications")
+}
@@ -0,0 +1,112 @@
+package docinator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/moseye/docinator/pkg/xref"
+	"github.com/spf13/cobra"
+)
+
+var xrefCmd = &cobra.Command{
+	Use:   "xref <type>",
+	Short: "List the cached packages whose declarations use a type",
+	Long: `Cross-reference the cached corpus and list, for an exported type, the other
+cached packages that mention it in the signatures and definitions of their
+exported declarations:
+
+  docinator xref cobra.Command
+  docinator xref github.com/spf13/cobra.Command
+  docinator xref Command          # every cached type named Command
+
+A qualifier resolves when exactly one cached package of that name declares the
+type. site build adds the same cross-reference to every type as a "Used by"
+note.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		ctx := cmd.Context()
+
+		store, closeStore, err := openStore(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		if !store.Enabled() {
+			return needsCache(store, "xref", "set MONGODB_URI or BOLT_PATH")
+		}
+		refs, err := corpusXref(ctx, store, tagFilter(cmd))
+		if err != nil {
+			return fmt.Errorf("loading the corpus failed: %w", err)
+		}
+		types := refs.Find(args[0])
+		if len(types) == 0 {
+			return fmt.Errorf("no cached package declares the type %s", args[0])
+		}
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			enc.Encode(types)
+			return nil
+		}
+		printXref(cmd.OutOrStdout(), types)
+		return nil
+	},
+}
+
+func init() {
+	xrefCmd.Flags().Bool("json", false, "print the cross-reference as JSON")
+	xrefCmd.Flags().StringSlice("tag", nil, "cross-reference only packages carrying all of these tags (see docinator tag)")
+}
+
+// corpusXref cross-references every cached package carrying all of tags.
+func corpusXref(ctx context.Context, store storage.Store, tags []string) (*xref.Index, error) {
+	var pkgs []*models.Package
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if doc.Package != nil && hasTags(doc.Tags, tags) {
+			pkgs = append(pkgs, doc.Package)
+		}
+		return nil
+	})
+	return xref.Build(pkgs), err
+}
+
+// linkCorpusTypes has --link-types link the types of other cached packages, as the corpus
+// cross-reference resolves them, to their documentation on pkg.go.dev. Without --link-types or a
+// cache it leaves opts alone; the package's own types are linked either way.
+func linkCorpusTypes(ctx context.Context, store storage.Store, opts *markdown.Options) error {
+	if !opts.LinkTypes || !store.Enabled() {
+		return nil
+	}
+	refs, err := corpusXref(ctx, store, nil)
+	if err != nil {
+		return fmt.Errorf("loading the corpus for --link-types failed: %w", err)
+	}
+	opts.TypeLinks = func(pkg *models.Package, qualifier, name string) string {
+		if t := refs.Resolve(pkg.ImportPath, qualifier, name); t != nil {
+			return "https://pkg.go.dev/" + t.ImportPath + "#" + name
+		}
+		return ""
+	}
+	return nil
+}
+
+// printXref writes each type and the declarations using it, grouped by package.
+func printXref(out io.Writer, types []*xref.Type) {
+	for _, t := range types {
+		fmt.Fprintf(out, "%s.%s: used by %d declaration(s)\n", t.ImportPath, t.Name, len(t.UsedBy))
+		prev := ""
+		for _, u := range t.UsedBy {
+			if u.ImportPath != prev {
+				fmt.Fprintf(out, "  %s\n", u.ImportPath)
+				prev = u.ImportPath
+			}
+			fmt.Fprintf(out, "    %s\n", u.Decl)
+		}
+	}
+}
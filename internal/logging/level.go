@@ -0,0 +1,20 @@
+package logging
+
+import "log/slog"
+
+// LevelTrace is below slog.LevelDebug and carries per-symbol parser traces.
+const LevelTrace = slog.LevelDebug - 4
+
+// LevelForVerbosity maps a -v count onto a slog level: warnings only by default,
+// -v info, -vv debug, -vvv trace.
+func LevelForVerbosity(v int) slog.Level {
+	switch {
+	case v <= 0:
+		return slog.LevelWarn
+	case v == 1:
+		return slog.LevelInfo
+	case v == 2:
+		return slog.LevelDebug
+	}
+	return LevelTrace
+}
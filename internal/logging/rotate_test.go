@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "docinator.log")
+	r, err := OpenRotating(path, 20, 2)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	r.Close()
+
+	read := func(p string) string {
+		data, _ := os.ReadFile(p)
+		return string(data)
+	}
+	if got := read(path); got != "fourth line\n" {
+		t.Errorf("Expected the current file to hold the last line, got %q", got)
+	}
+	if got := read(path + ".1"); got != "third line\n" {
+		t.Errorf("Expected the newest backup in .1, got %q", got)
+	}
+	if got := read(path + ".2"); !strings.HasPrefix(got, "second") {
+		t.Errorf("Expected the oldest kept backup in .2, got %q", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Expected backups beyond the limit to be removed")
+	}
+}
@@ -1,29 +1,132 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"time"
+)
+
+// SchemaVersion is the version of the data model written into Package.SchemaVersion. Bump it
+// when a field is renamed or removed or changes meaning; docinator schema publishes the JSON
+// Schema of each version.
+const SchemaVersion = 1
 
 type Package struct {
+	SchemaVersion   int        `bson:"schema_version,omitempty"` // data model version; 0 for documents cached before versioning
 	Name            string     `bson:"name,omitempty"`
 	Description     string     `bson:"description,omitempty"`
 	Module          string     `bson:"module,omitempty"`
 	Version         string     `bson:"version,omitempty"`
 	IsLatest        bool       `bson:"is_latest,omitempty"`
 	Published       string     `bson:"published,omitempty"`
+	GoVersion       string     `bson:"go_version,omitempty"` // minimum Go version from the module's go.mod, e.g. "1.21"
 	Synopsis        string     `bson:"synopsis,omitempty"`
 	License         string     `bson:"license,omitempty"`
 	LicenseURL      string     `bson:"license_url,omitempty"`
 	Repository      string     `bson:"repository,omitempty"`
 	ImportPath      string     `bson:"import_path,omitempty"`
 	ScrapedAt       time.Time  `bson:"scraped_at,omitempty"`
+	Overview        string     `bson:"overview,omitempty"` // whole package doc comment as markdown; Description holds its first paragraph
 	Readme          string     `bson:"readme,omitempty"`
 	ProcessedReadme string     `bson:"processed_readme,omitempty"`
 	Imports         int        `bson:"imports,omitempty"`
 	ImportedBy      int        `bson:"imported_by,omitempty"`
+	Importers       []string   `bson:"importers,omitempty"`   // sample of importing package paths from the importedby tab
+	ImportList      []string   `bson:"import_list,omitempty"` // import paths from the imports tab (scrape --imports)
 	Functions       []Function `bson:"functions,omitempty"`
 	Types           []Type     `bson:"types,omitempty"`
 	Variables       []Variable `bson:"variables,omitempty"`
 	Constants       []Constant `bson:"constants,omitempty"`
 	Examples        []Example  `bson:"examples,omitempty"`
+
+	Files []SourceFile `bson:"files,omitempty"`
+
+	PublishedAt time.Time `bson:"published_at,omitempty"` // Published ("Mar 1, 2024") parsed; zero when it is not a date
+
+	Parser  string `bson:"parser,omitempty"`  // parser version and selector profile fingerprint that extracted it, e.g. "1/3f9a0c2b7d41"
+	Profile string `bson:"profile,omitempty"` // page sections parsed, "readme" or "metadata" (scrape --profile); empty for the whole page
+
+	ModuleDeprecated  bool   `bson:"module_deprecated,omitempty"`  // the module's go.mod carries a "Deprecated:" comment
+	DeprecationNotice string `bson:"deprecation_notice,omitempty"` // text of the deprecation banner, with the reason when given
+	Retracted         bool   `bson:"retracted,omitempty"`          // this version is retracted by the module author
+	RetractionNotice  string `bson:"retraction_notice,omitempty"`  // text of the retraction warning, with the rationale when given
+
+	Redistributable      bool   `bson:"redistributable,omitempty"`       // false when pkg.go.dev withholds the docs over the license
+	RedistributionNotice string `bson:"redistribution_notice,omitempty"` // text pkg.go.dev shows in place of withheld docs
+
+	Details *Details `bson:"details,omitempty"` // Details panel checklist; nil when the page had none
+	Links   []Link   `bson:"links,omitempty"`   // Links panel: homepage and other URLs declared by the module
+
+	Identifiers []string `bson:"identifiers,omitempty"` // exported identifiers from the Jump to index, in declaration order
+
+	PlatformNotice string   `bson:"platform_notice,omitempty"` // banner such as "This package is only available for linux/amd64"
+	Platforms      []string `bson:"platforms,omitempty"`       // GOOS/GOARCH pairs the documentation is limited to
+
+	Summary *GeneratedSummary `bson:"summary,omitempty"` // LLM-generated, never scraped content
+
+	LocalSource string `bson:"local_source,omitempty"` // directory whose doc comments were merged in (scrape --local-source)
+
+	Guides []Guide `bson:"guides,omitempty"` // documentation pages linked from the README, e.g. on ReadTheDocs (scrape --guides)
+
+	ReadmeLang       string            `bson:"readme_lang,omitempty"`       // detected language of ProcessedReadme, e.g. "en" or "zh"
+	ReadmeAlternates []ReadmeAlternate `bson:"readme_alternates,omitempty"` // translations and other READMEs linked from the README
+
+	Warnings []Warning `bson:"warnings,omitempty"` // data quality issues found while parsing and converting the page
+}
+
+// Warning codes, stable for consumers filtering on them.
+const (
+	WarningNoLicense          = "no_license"
+	WarningSelectorFallback   = "selector_fallback"
+	WarningReadmeTableDropped = "readme_table_dropped"
+	WarningMissingDeclaration = "missing_declaration"
+)
+
+// Warning is a data quality issue of a scraped package: something the page had that did not make
+// it into the document, or was found in a less reliable way.
+type Warning struct {
+	Code    string `bson:"code"`
+	Field   string `bson:"field,omitempty"` // the field or selector profile key concerned, e.g. "version"
+	Message string `bson:"message"`
+}
+
+// String formats w for logs.
+func (w Warning) String() string {
+	return "[" + w.Code + "] " + w.Message
+}
+
+// Guide is a documentation page kept outside the package docs, converted to markdown.
+type Guide struct {
+	Title   string `bson:"title,omitempty"`
+	URL     string `bson:"url,omitempty"`
+	Content string `bson:"content,omitempty"`
+}
+
+// ReadmeAlternate is another README of the package, usually a translation. Content, as markdown,
+// is filled for the alternates that were fetched, such as the one picked by scrape --readme-lang.
+type ReadmeAlternate struct {
+	Lang    string `bson:"lang,omitempty"` // language tag from the file name, e.g. "zh-CN"; empty when unknown
+	URL     string `bson:"url,omitempty"`
+	Content string `bson:"content,omitempty"`
+}
+
+// Details is the checklist of pkg.go.dev's Details panel: whether each module quality check passed.
+type Details struct {
+	ValidGoMod             bool `bson:"valid_go_mod"`
+	RedistributableLicense bool `bson:"redistributable_license"`
+	TaggedVersion          bool `bson:"tagged_version"`
+	StableVersion          bool `bson:"stable_version"`
+}
+
+// Link is an entry of the Links panel.
+type Link struct {
+	Label string `bson:"label,omitempty"`
+	URL   string `bson:"url,omitempty"`
+}
+
+// SourceFile is one of the package's .go files as listed on the unit page.
+type SourceFile struct {
+	Name string `bson:"name,omitempty"`
+	URL  string `bson:"url,omitempty"`
 }
 
 type Function struct {
@@ -34,6 +137,10 @@ type Function struct {
 	Deprecated  string    `bson:"deprecated,omitempty"`
 	AddedIn     string    `bson:"added_in,omitempty"`
 	Examples    []Example `bson:"examples,omitempty"`
+	SourceURL   string    `bson:"source_url,omitempty"`  // link to the declaration in the repository
+	SourceFile  string    `bson:"source_file,omitempty"` // file path relative to the module root, e.g. "command.go"
+	SourceLine  int       `bson:"source_line,omitempty"` // 1-based line of the declaration
+	Source      string    `bson:"source,omitempty"`      // declaration body fetched from the repository (optional enrichment)
 }
 
 type Type struct {
@@ -45,6 +152,25 @@ type Type struct {
 	AddedIn     string     `bson:"added_in,omitempty"`
 	Methods     []Function `bson:"methods,omitempty"`
 	Examples    []Example  `bson:"examples,omitempty"`
+	SourceURL   string     `bson:"source_url,omitempty"`
+	SourceFile  string     `bson:"source_file,omitempty"`
+	SourceLine  int        `bson:"source_line,omitempty"`
+	Source      string     `bson:"source,omitempty"`
+	Fields      []Field    `bson:"fields,omitempty"` // exported fields of a struct type
+
+	// InterfaceMethods is the method set declared by an interface type, in declaration order:
+	// Name "Read", Signature "Read(p []byte) (n int, err error)" and the method's doc or line
+	// comment as Description. Embedded interfaces and type set terms are listed with their type
+	// expression as both Name and Signature.
+	InterfaceMethods []Function `bson:"interface_methods,omitempty"`
+}
+
+// Field is an exported field of a struct type.
+type Field struct {
+	Name string `bson:"name,omitempty"`
+	Type string `bson:"type,omitempty"`
+	Tag  string `bson:"tag,omitempty"` // struct tag without the backquotes, e.g. json:"name,omitempty"
+	Doc  string `bson:"doc,omitempty"` // doc comment, or the trailing line comment
 }
 
 type Variable struct {
@@ -61,13 +187,207 @@ type Constant struct {
 }
 
 type Example struct {
-	Name   string `bson:"name,omitempty"`
-	Code   string `bson:"code,omitempty"`
-	Output string `bson:"output,omitempty"`
+	Name          string `bson:"name,omitempty"`
+	Code          string `bson:"code,omitempty"`
+	Output        string `bson:"output,omitempty"`
+	PlaygroundURL string `bson:"playground_url,omitempty"` // Go Playground share link, when known
+}
+
+// GeneratedSummary is a machine-generated description of a package, kept apart from scraped fields.
+type GeneratedSummary struct {
+	Text        string    `bson:"text,omitempty"`
+	Model       string    `bson:"model,omitempty"`
+	GeneratedAt time.Time `bson:"generated_at,omitempty"`
 }
 
 type Document struct {
 	ID      string   `bson:"_id"`                // import path as primary key, e.g., "github.com/spf13/cobra"
 	Package *Package `bson:"package"`            // structured package data
 	RawHTML string   `bson:"raw_html,omitempty"` // raw HTML content from the scraped page
+
+	RequestedAt time.Time `bson:"requested_at,omitempty"` // when a command last loaded it, to the day; docinator gc ages raw HTML by it
+
+	Tags []string `bson:"tags,omitempty"` // user-defined curation tags (docinator tag), kept across re-scrapes
+	Note string   `bson:"note,omitempty"` // user-defined curation note
+
+	ContentHash string    `bson:"content_hash,omitempty"` // SHA-256 of the package without its scrape time
+	ChangedAt   time.Time `bson:"changed_at,omitempty"`   // when ContentHash last changed; docinator render --changed-since selects by it
+
+	Adoption []AdoptionPoint `bson:"adoption,omitempty"` // adoption counts per scrape day, recorded in history mode and kept across re-scrapes
+}
+
+// AdoptionPoint is the adoption of a package on one day, as pkg.go.dev counted it.
+type AdoptionPoint struct {
+	At         time.Time `bson:"at" json:"at"`
+	ImportedBy int       `bson:"imported_by" json:"imported_by"`
+	Imports    int       `bson:"imports" json:"imports"`
+}
+
+// ModuleDocument is every cached package of one module version under a single entry with a shared
+// index, so "everything for cobra v1.9.1" is one read instead of a lookup per package.
+type ModuleDocument struct {
+	ID        string          `bson:"_id" json:"id"` // ModuleID(Module, Version), e.g. "github.com/spf13/cobra@v1.9.1"
+	Module    string          `bson:"module" json:"module"`
+	Version   string          `bson:"version" json:"version"`
+	Index     []ModulePackage `bson:"index" json:"index"`           // one entry per package: the module root first, then by import path
+	Packages  []*Package      `bson:"packages" json:"packages"`     // in Index order
+	ScrapedAt time.Time       `bson:"scraped_at" json:"scraped_at"` // when its most recently scraped package was scraped
+}
+
+// ModulePackage is the index entry of a package of a ModuleDocument.
+type ModulePackage struct {
+	ImportPath string `bson:"import_path" json:"import_path"`
+	Name       string `bson:"name" json:"name"`
+	Synopsis   string `bson:"synopsis,omitempty" json:"synopsis,omitempty"`
+	Functions  int    `bson:"functions" json:"functions"`
+	Types      int    `bson:"types" json:"types"`
+}
+
+// ModuleID is the ID of the ModuleDocument of a module version.
+func ModuleID(module, version string) string {
+	return module + "@" + version
+}
+
+// NewModuleDocument gathers pkgs, packages of module at version, into a ModuleDocument. A package
+// given more than once, cached both pinned and unpinned say, is kept in its latest scrape.
+func NewModuleDocument(module, version string, pkgs []*Package) *ModuleDocument {
+	latest := map[string]*Package{}
+	for _, pkg := range pkgs {
+		if prev := latest[pkg.ImportPath]; prev == nil || pkg.ScrapedAt.After(prev.ScrapedAt) {
+			latest[pkg.ImportPath] = pkg
+		}
+	}
+	m := &ModuleDocument{ID: ModuleID(module, version), Module: module, Version: version}
+	for _, pkg := range latest {
+		m.Packages = append(m.Packages, pkg)
+		if pkg.ScrapedAt.After(m.ScrapedAt) {
+			m.ScrapedAt = pkg.ScrapedAt
+		}
+	}
+	sort.Slice(m.Packages, func(i, j int) bool {
+		a, b := m.Packages[i].ImportPath, m.Packages[j].ImportPath
+		if (a == module) != (b == module) {
+			return a == module
+		}
+		return a < b
+	})
+	for _, pkg := range m.Packages {
+		m.Index = append(m.Index, ModulePackage{ImportPath: pkg.ImportPath, Name: pkg.Name, Synopsis: pkg.Synopsis, Functions: len(pkg.Functions), Types: len(pkg.Types)})
+	}
+	return m
+}
+
+// DocumentSummary is a lightweight view of a stored document used for listings.
+type DocumentSummary struct {
+	ID         string    `bson:"_id"`
+	ImportPath string    `bson:"import_path,omitempty"`
+	Module     string    `bson:"module,omitempty"`
+	Version    string    `bson:"version,omitempty"`
+	ScrapedAt  time.Time `bson:"scraped_at,omitempty"`
+	Tags       []string  `bson:"tags,omitempty"`
+
+	Published   string    `bson:"published,omitempty"`
+	PublishedAt time.Time `bson:"published_at,omitempty"`
+}
+
+// LicenseCount is the number of stored packages using one license.
+type LicenseCount struct {
+	License string `bson:"_id"`
+	Count   int    `bson:"count"`
+}
+
+// SymbolStats holds per-package symbol averages across the stored corpus.
+type SymbolStats struct {
+	Packages     int     `bson:"packages"`
+	AvgFunctions float64 `bson:"avg_functions"`
+	AvgTypes     float64 `bson:"avg_types"`
+	AvgMethods   float64 `bson:"avg_methods"`
+	AvgSymbols   float64 `bson:"avg_symbols"` // functions + types + methods + variables + constants
+}
+
+// DocumentSize is the stored BSON size of a document.
+type DocumentSize struct {
+	ID    string `bson:"_id"`
+	Bytes int64  `bson:"bytes"`
+}
+
+// Run records one scrape invocation for trends across runs.
+type Run struct {
+	ID              string            `bson:"_id"` // start time, RFC 3339 with nanoseconds
+	StartedAt       time.Time         `bson:"started_at"`
+	Args            []string          `bson:"args"`
+	Config          map[string]string `bson:"config,omitempty"` // flags that shaped the run
+	DurationSeconds float64           `bson:"duration_seconds"`
+	Interrupted     bool              `bson:"interrupted"`
+	Attempted       int               `bson:"attempted"`
+	Succeeded       int               `bson:"succeeded"`
+	CacheHits       int               `bson:"cache_hits"`   // packages served from the store
+	CacheMisses     int               `bson:"cache_misses"` // packages scraped after a store miss
+	HTTPCacheHits   int               `bson:"http_cache_hits"`
+	HTTPCacheMisses int               `bson:"http_cache_misses"`
+	NotModified     int               `bson:"not_modified"` // HTTP 304 responses
+	BytesDownloaded int64             `bson:"bytes_downloaded"`
+	Requests        int               `bson:"requests"`
+	Retries         int               `bson:"retries"`
+	Errors          int               `bson:"errors"`
+	ErrorsByClass   map[string]int    `bson:"errors_by_class,omitempty"`
+	LayoutWarnings  int               `bson:"layout_warnings"` // pages that looked like a pkg.go.dev layout change
+	LatencyP50Ms    float64           `bson:"latency_p50_ms"`
+	LatencyP95Ms    float64           `bson:"latency_p95_ms"`
+	Failures        []RunFailure      `bson:"failures,omitempty"`
+}
+
+// RunFailure is a package a run could not load.
+type RunFailure struct {
+	ImportPath string `bson:"import_path"`
+	Error      string `bson:"error"`
+}
+
+// Chunk is a heading-bounded slice of rendered documentation prepared for RAG ingestion.
+type Chunk struct {
+	ID      string `bson:"_id" json:"id"`          // "<import path>#<anchor>/<n>"
+	Source  string `bson:"source" json:"source"`   // import path of the package the chunk came from
+	Anchor  string `bson:"anchor" json:"anchor"`   // markdown anchor of the enclosing heading
+	Heading string `bson:"heading" json:"heading"` // heading path, e.g. "Types > Command"
+	Text    string `bson:"text" json:"text"`       // chunk content, including its heading line
+	Tokens  int    `bson:"tokens" json:"tokens"`   // approximate token count of Text
+
+	Embedding []float32 `bson:"embedding,omitempty" json:"embedding,omitempty"` // vector embedding of Text, when computed
+}
+
+// ChunkMatch is a chunk returned by a similarity or keyword search together with its relevance score.
+type ChunkMatch struct {
+	Chunk `bson:",inline"`
+	Score float64 `bson:"score" json:"score"`
+}
+
+// LicenseRestricted reports whether pkg.go.dev withheld the documentation because the package's
+// license does not allow redistribution. Documents cached before this was recorded never are.
+func (p *Package) LicenseRestricted() bool {
+	return !p.Redistributable && p.RedistributionNotice != ""
+}
+
+// AllExamples returns pointers to every example in the package: package-level, function, type and method examples.
+func (p *Package) AllExamples() []*Example {
+	var examples []*Example
+	for i := range p.Examples {
+		examples = append(examples, &p.Examples[i])
+	}
+	for i := range p.Functions {
+		for j := range p.Functions[i].Examples {
+			examples = append(examples, &p.Functions[i].Examples[j])
+		}
+	}
+	for i := range p.Types {
+		t := &p.Types[i]
+		for j := range t.Examples {
+			examples = append(examples, &t.Examples[j])
+		}
+		for k := range t.Methods {
+			for j := range t.Methods[k].Examples {
+				examples = append(examples, &t.Methods[k].Examples[j])
+			}
+		}
+	}
+	return examples
 }
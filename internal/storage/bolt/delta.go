@@ -0,0 +1,179 @@
+package boltstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/storage/delta"
+	"github.com/moseye/docinator/internal/storage/retention"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// deltaDoc is a snapshot (path@version document) kept in the packages bucket as edits of the
+// latest document of its package, the base, instead of in full; see Store.SetDelta. Its raw HTML
+// is kept here too rather than in the raw bucket.
+type deltaDoc struct {
+	Base    string          `bson:"delta_base"`     // ID of the base document
+	Doc     models.Document `bson:"doc"`            // the snapshot without its package and raw HTML
+	Package []delta.Op      `bson:"package_delta"`  // edits of the base package, as packageText
+	RawHTML []delta.Op      `bson:"raw_html_delta"` // edits of the base raw HTML
+}
+
+// SetDelta makes Upsert store snapshots whose package has a latest document as a delta against
+// it, which history mode's many near-identical versions shrink to a fraction of their size.
+// Reads rebuild them transparently, whatever the setting; documents already stored are converted
+// as they are written again. Set it before the store is shared.
+func (s *Store) SetDelta(on bool) {
+	s.delta = on
+}
+
+// isDelta reports whether data, a value of the packages bucket, is a deltaDoc.
+func isDelta(data []byte) bool {
+	_, err := bson.Raw(data).LookupErr("delta_base")
+	return err == nil
+}
+
+// packageText is the form of a package deltas edit: indented JSON, one field or element per line,
+// so a changed declaration changes only its own lines.
+func packageText(pkg *models.Package) (string, error) {
+	data, err := json.MarshalIndent(pkg, "", " ")
+	return string(data), err
+}
+
+// getTx returns the document id within tx, with its raw HTML when raw is set, or nil if it is
+// not stored.
+func (s *Store) getTx(tx *bolt.Tx, id string, raw bool) (*models.Document, error) {
+	data := tx.Bucket(s.packages).Get([]byte(id))
+	if data == nil {
+		return nil, nil
+	}
+	return s.decodeTx(tx, id, data, raw, nil)
+}
+
+// decodeTx decodes data, the packages bucket value of id, rebuilding a delta from its base.
+// bases caches the package text of bases across calls; nil disables it.
+func (s *Store) decodeTx(tx *bolt.Tx, id string, data []byte, raw bool, bases map[string]string) (*models.Document, error) {
+	if !isDelta(data) {
+		doc := &models.Document{}
+		if err := bson.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", id, err)
+		}
+		if raw {
+			doc.RawHTML = string(tx.Bucket(s.raw).Get([]byte(id)))
+		}
+		return doc, nil
+	}
+	var d deltaDoc
+	if err := bson.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", id, err)
+	}
+	baseData := tx.Bucket(s.packages).Get([]byte(d.Base))
+	if baseData == nil || isDelta(baseData) {
+		return nil, fmt.Errorf("failed to decode %s: its base %s is missing", id, d.Base)
+	}
+	text, ok := bases[d.Base]
+	if !ok {
+		var base models.Document
+		if err := bson.Unmarshal(baseData, &base); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", d.Base, err)
+		}
+		var err error
+		if text, err = packageText(base.Package); err != nil {
+			return nil, err
+		}
+		if bases != nil {
+			bases[d.Base] = text
+		}
+	}
+	pkgText, err := delta.Apply(text, d.Package)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild %s: %w", id, err)
+	}
+	doc := d.Doc
+	if err := json.Unmarshal([]byte(pkgText), &doc.Package); err != nil {
+		return nil, fmt.Errorf("failed to rebuild %s: %w", id, err)
+	}
+	if raw {
+		if doc.RawHTML, err = delta.Apply(string(tx.Bucket(s.raw).Get([]byte(d.Base))), d.RawHTML); err != nil {
+			return nil, fmt.Errorf("failed to rebuild the raw HTML of %s: %w", id, err)
+		}
+	}
+	return &doc, nil
+}
+
+// putTx writes doc to the packages and raw buckets within tx: as a delta against the latest
+// document of its package when delta storage is on and doc is a snapshot of one, else in full.
+func (s *Store) putTx(tx *bolt.Tx, doc *models.Document) error {
+	data, raw, err := s.encodeTx(tx, doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", doc.ID, err)
+	}
+	if err := tx.Bucket(s.packages).Put([]byte(doc.ID), data); err != nil {
+		return err
+	}
+	if raw == nil {
+		return tx.Bucket(s.raw).Delete([]byte(doc.ID))
+	}
+	return tx.Bucket(s.raw).Put([]byte(doc.ID), raw)
+}
+
+// encodeTx returns the packages bucket value of doc and its raw bucket value, nil for a delta.
+func (s *Store) encodeTx(tx *bolt.Tx, doc *models.Document) (data, raw []byte, err error) {
+	meta := *doc
+	meta.RawHTML = ""
+	path, pinned := retention.Snapshot(doc.ID)
+	var baseData []byte
+	if s.delta && pinned {
+		baseData = tx.Bucket(s.packages).Get([]byte(path))
+	}
+	if baseData == nil || isDelta(baseData) {
+		data, err = bson.Marshal(&meta)
+		return data, []byte(doc.RawHTML), err
+	}
+	var base models.Document
+	if err := bson.Unmarshal(baseData, &base); err != nil {
+		return nil, nil, err
+	}
+	baseText, err := packageText(base.Package)
+	if err != nil {
+		return nil, nil, err
+	}
+	text, err := packageText(doc.Package)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta.Package = nil
+	d := deltaDoc{
+		Base:    path,
+		Doc:     meta,
+		Package: delta.Diff(baseText, text),
+		RawHTML: delta.Diff(string(tx.Bucket(s.raw).Get([]byte(path))), doc.RawHTML),
+	}
+	data, err = bson.Marshal(&d)
+	return data, nil, err
+}
+
+// detachDeltas rebuilds, within tx, the snapshots stored as deltas against id, so they can be
+// written again with putTx once id changes or is deleted.
+func (s *Store) detachDeltas(tx *bolt.Tx, id string) ([]*models.Document, error) {
+	if _, pinned := retention.Snapshot(id); pinned {
+		return nil, nil
+	}
+	prefix := []byte(id + "@")
+	var docs []*models.Document
+	c := tx.Bucket(s.packages).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if !isDelta(v) {
+			continue
+		}
+		doc, err := s.decodeTx(tx, string(k), v, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,528 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/storage/retention"
+	"github.com/moseye/docinator/pkg/symdoc"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	packagesBucket = []byte("packages") // document without raw HTML, BSON encoded
+	rawBucket      = []byte("raw_html") // raw HTML by document ID
+	symbolsBucket  = []byte("symbols")  // JSON encoded symdoc.Snippet by "<document ID>#<symbol>"
+	modulesBucket  = []byte("modules")  // a bucket per "module@version" holding its BSON encoded packages by document ID
+	metaBucket     = []byte("meta")     // values about the stored corpus as a whole, by key
+)
+
+// Store persists documents in a single bbolt file, for caching without an external service.
+type Store struct {
+	db       *bolt.DB
+	packages []byte // bucket names, prefixed with the namespace
+	raw      []byte
+	symbols  []byte
+	modules  []byte
+	meta     []byte
+
+	retention retention.Policy
+	delta     bool // store snapshots as deltas; see SetDelta
+}
+
+// NewFromEnv opens the store from env:
+// - BOLT_PATH (required to enable; if empty, the returned store is nil and disabled)
+// - MONGODB_NAMESPACE (optional): keep documents in buckets of their own, as with MongoDB
+// - BOLT_DELTA (optional): "true" stores snapshots as deltas against the latest document of their
+// package (see SetDelta)
+// Logging approach: mirror the MongoDB store with operation labels and durations.
+func NewFromEnv(ctx context.Context) (*Store, error) {
+	path := os.Getenv("BOLT_PATH")
+	if path == "" {
+		slog.Debug("bolt: store disabled; no BOLT_PATH", "operation", "bolt_open")
+		return nil, nil
+	}
+	var delta bool
+	if v := os.Getenv("BOLT_DELTA"); v != "" {
+		var err error
+		if delta, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("BOLT_DELTA: want true or false, got %q", v)
+		}
+	}
+	s, err := OpenNamespace(path, os.Getenv("MONGODB_NAMESPACE"))
+	if err != nil {
+		return nil, err
+	}
+	s.SetDelta(delta)
+	return s, nil
+}
+
+// Open opens or creates the bbolt database at path.
+func Open(path string) (*Store, error) {
+	return OpenNamespace(path, "")
+}
+
+// OpenNamespace opens or creates the bbolt database at path, keeping documents in the buckets of
+// namespace, so several teams can share one file without seeing each other's documents.
+func OpenNamespace(path, namespace string) (*Store, error) {
+	start := time.Now()
+	slog.Debug("bolt: opening", "operation", "bolt_open", "path", path, "namespace", namespace)
+	s := newStore(namespace)
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	// A timeout keeps a second process from blocking forever on the file lock.
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		slog.Error("bolt: open failed", "operation", "bolt_open", "path", path, "error", err)
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range s.buckets() {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		slog.Error("bolt: bucket setup failed", "operation", "bolt_open", "path", path, "error", err)
+		return nil, err
+	}
+	slog.Debug("bolt: opened", "operation", "bolt_open", "path", path, "duration", time.Since(start))
+	s.db = db
+	return s, nil
+}
+
+// OpenReadOnly opens the existing bbolt database at path for reading only, such as a copy taken to
+// another machine; writes fail. Several processes may have it open at once.
+func OpenReadOnly(path string) (*Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0444, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(packagesBucket) == nil {
+			return fmt.Errorf("%s holds no docinator documents", path)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	s := newStore("")
+	s.db = db
+	return s, nil
+}
+
+// newStore returns a store, not yet opened, keeping documents in the buckets of namespace.
+func newStore(namespace string) *Store {
+	s := &Store{packages: packagesBucket, raw: rawBucket, symbols: symbolsBucket, modules: modulesBucket, meta: metaBucket}
+	if namespace != "" {
+		for _, name := range []*[]byte{&s.packages, &s.raw, &s.symbols, &s.modules, &s.meta} {
+			*name = []byte(namespace + "/" + string(*name))
+		}
+	}
+	return s
+}
+
+// buckets lists the buckets of the store's namespace.
+func (s *Store) buckets() [][]byte {
+	return [][]byte{s.packages, s.raw, s.symbols, s.modules, s.meta}
+}
+
+// SetRetention makes Upsert enforce p on the documents it writes and on the other snapshots of
+// their package. Set it before the store is shared.
+func (s *Store) SetRetention(p retention.Policy) {
+	s.retention = p
+}
+
+// Enabled reports whether the store is active.
+func (s *Store) Enabled() bool {
+	return s != nil && s.db != nil
+}
+
+// Close closes the database file.
+func (s *Store) Close(ctx context.Context) error {
+	if !s.Enabled() {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// GetByID returns a stored document by its import path or nil if not found.
+func (s *Store) GetByID(ctx context.Context, id string) (*models.Document, error) {
+	if !s.Enabled() {
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	var doc *models.Document
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		doc, err = s.getTx(tx, id, true)
+		return err
+	})
+	if err != nil {
+		slog.Error("bolt: get_by_id failed", "operation", "bolt_get_by_id", "id", id, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("bolt: get_by_id", "operation", "bolt_get_by_id", "id", id, "hit", doc != nil, "duration", time.Since(start))
+	return doc, nil
+}
+
+// Upsert replaces the document by ID or inserts it if missing. With a retention policy set, the
+// document is trimmed to it and, for a snapshot, the snapshots of its package beyond the policy
+// are deleted in the same transaction.
+func (s *Store) Upsert(ctx context.Context, doc *models.Document) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	if doc == nil || doc.ID == "" {
+		return errors.New("invalid document or missing ID")
+	}
+	start := time.Now()
+	doc = s.retention.Apply(doc, start)
+
+	symbols := map[string][]byte{}
+	if doc.Package != nil {
+		for _, sym := range symdoc.All(doc.Package) {
+			v, err := json.Marshal(sym)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s.%s: %w", doc.ID, sym.Name, err)
+			}
+			symbols[sym.Name] = v
+		}
+	}
+	// With delta storage, module buckets refer to the packages bucket instead of holding a copy.
+	var pkgData []byte
+	if doc.Package != nil && !s.delta {
+		var err error
+		if pkgData, err = bson.Marshal(doc.Package); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", doc.ID, err)
+		}
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		// Snapshots stored against the previous version of doc are written again against this one.
+		dependents, err := s.detachDeltas(tx, doc.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.unlinkModule(tx, doc.ID); err != nil {
+			return err
+		}
+		if err := s.putTx(tx, doc); err != nil {
+			return err
+		}
+		if pkg := doc.Package; pkg != nil && pkg.Module != "" && pkg.Version != "" {
+			b, err := tx.Bucket(s.modules).CreateBucketIfNotExists([]byte(models.ModuleID(pkg.Module, pkg.Version)))
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(doc.ID), pkgData); err != nil {
+				return err
+			}
+		}
+		for _, dep := range dependents {
+			if err := s.putTx(tx, dep); err != nil {
+				return err
+			}
+		}
+		if err := s.indexSymbols(tx, doc.ID, symbols); err != nil {
+			return err
+		}
+		return s.pruneSnapshots(tx, doc.ID)
+	})
+	if err != nil {
+		slog.Error("bolt: upsert failed", "operation", "bolt_upsert", "id", doc.ID, "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("bolt: upsert success", "operation", "bolt_upsert", "id", doc.ID, "duration", time.Since(start))
+	return nil
+}
+
+// Delete removes the document and its raw HTML.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		// Snapshots stored against id are kept, in full.
+		dependents, err := s.detachDeltas(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.deleteTx(tx, id); err != nil {
+			return err
+		}
+		for _, dep := range dependents {
+			if err := s.putTx(tx, dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("bolt: delete failed", "operation", "bolt_delete", "id", id, "error", err)
+	}
+	return err
+}
+
+// deleteTx removes the document id, its raw HTML, module entry and symbols within tx.
+func (s *Store) deleteTx(tx *bolt.Tx, id string) error {
+	if err := s.unlinkModule(tx, id); err != nil {
+		return err
+	}
+	if err := tx.Bucket(s.packages).Delete([]byte(id)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(s.raw).Delete([]byte(id)); err != nil {
+		return err
+	}
+	return s.indexSymbols(tx, id, nil)
+}
+
+// pruneSnapshots deletes, within tx, the snapshots of the package of id the retention policy does
+// not keep.
+func (s *Store) pruneSnapshots(tx *bolt.Tx, id string) error {
+	path, ok := retention.Snapshot(id)
+	if !ok || s.retention.Snapshots == 0 {
+		return nil
+	}
+	prefix := []byte(path + "@")
+	var snapshots []*models.Document
+	c := tx.Bucket(s.packages).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		doc, err := s.decodeTx(tx, string(k), v, false, nil)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, doc)
+	}
+	for _, pruned := range s.retention.Prune(snapshots) {
+		if err := s.deleteTx(tx, pruned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSymbol returns a symbol of the document stored under id from the symbol index, or nil if
+// either is not indexed.
+func (s *Store) GetSymbol(ctx context.Context, id, name string) (*symdoc.Snippet, error) {
+	if !s.Enabled() {
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	var sym *symdoc.Snippet
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(s.symbols).Get(symbolKey(id, name))
+		if data == nil {
+			return nil
+		}
+		sym = &symdoc.Snippet{}
+		return json.Unmarshal(data, sym)
+	})
+	if err != nil {
+		slog.Error("bolt: get_symbol failed", "operation", "bolt_get_symbol", "id", id, "name", name, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("bolt: get_symbol", "operation", "bolt_get_symbol", "id", id, "name", name, "hit", sym != nil, "duration", time.Since(start))
+	return sym, nil
+}
+
+// indexSymbols replaces the indexed symbols of the document id with symbols, by name.
+func (s *Store) indexSymbols(tx *bolt.Tx, id string, symbols map[string][]byte) error {
+	b := tx.Bucket(s.symbols)
+	prefix := symbolKey(id, "")
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	for name, v := range symbols {
+		if err := b.Put(symbolKey(id, name), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetModule returns every package of module at version kept in the modules bucket, or nil if none is.
+func (s *Store) GetModule(ctx context.Context, module, version string) (*models.ModuleDocument, error) {
+	if !s.Enabled() {
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	id := models.ModuleID(module, version)
+	var pkgs []*models.Package
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.modules).Bucket([]byte(id))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			if len(v) == 0 {
+				// A reference to the document, written with delta storage on.
+				doc, err := s.getTx(tx, string(k), false)
+				if err != nil || doc == nil || doc.Package == nil {
+					return err
+				}
+				pkgs = append(pkgs, doc.Package)
+				return nil
+			}
+			pkg := &models.Package{}
+			if err := bson.Unmarshal(v, pkg); err != nil {
+				return fmt.Errorf("failed to decode %s in %s: %w", k, id, err)
+			}
+			pkgs = append(pkgs, pkg)
+			return nil
+		})
+	})
+	if err != nil {
+		slog.Error("bolt: get_module failed", "operation", "bolt_get_module", "id", id, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("bolt: get_module", "operation", "bolt_get_module", "id", id, "packages", len(pkgs), "duration", time.Since(start))
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	return models.NewModuleDocument(module, version, pkgs), nil
+}
+
+// unlinkModule removes the document stored under id from the bucket of its module version,
+// dropping the bucket with its last package.
+func (s *Store) unlinkModule(tx *bolt.Tx, id string) error {
+	data := tx.Bucket(s.packages).Get([]byte(id))
+	if data == nil {
+		return nil
+	}
+	var prev struct {
+		Package struct {
+			Module  string `bson:"module"`
+			Version string `bson:"version"`
+		} `bson:"package"`
+	}
+	if isDelta(data) {
+		doc, err := s.decodeTx(tx, id, data, false, nil)
+		if err != nil || doc.Package == nil {
+			return nil // nothing indexed for an undecodable document
+		}
+		prev.Package.Module, prev.Package.Version = doc.Package.Module, doc.Package.Version
+	} else if err := bson.Unmarshal(data, &prev); err != nil {
+		return nil // nothing indexed for an undecodable document
+	}
+	if prev.Package.Module == "" {
+		return nil
+	}
+	modules := tx.Bucket(s.modules)
+	name := []byte(models.ModuleID(prev.Package.Module, prev.Package.Version))
+	b := modules.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	if err := b.Delete([]byte(id)); err != nil {
+		return err
+	}
+	if k, _ := b.Cursor().First(); k == nil {
+		return modules.DeleteBucket(name)
+	}
+	return nil
+}
+
+// symbolKey is the key of a symbol in the symbols bucket. Import paths cannot contain '#', so the
+// keys of one document never prefix those of another.
+func symbolKey(id, name string) []byte {
+	return []byte(id + "#" + name)
+}
+
+// ForEach streams every stored document (without raw HTML) to fn in ID order, stopping at the first error fn returns.
+// fn runs inside a read transaction, so it must not write to the store.
+func (s *Store) ForEach(ctx context.Context, fn func(*models.Document) error) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	return s.db.View(func(tx *bolt.Tx) error {
+		bases := map[string]string{}
+		return tx.Bucket(s.packages).ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			doc, err := s.decodeTx(tx, string(k), v, false, bases)
+			if err != nil {
+				return err
+			}
+			return fn(doc)
+		})
+	})
+}
+
+// PutMeta stores value under key, next to the documents rather than in one of them: data about
+// the corpus as a whole, such as an index built over every package.
+func (s *Store) PutMeta(ctx context.Context, key string, value []byte) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(s.meta)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+}
+
+// Meta returns the value PutMeta stored under key, or nil if there is none.
+func (s *Store) Meta(ctx context.Context, key string) ([]byte, error) {
+	if !s.Enabled() {
+		return nil, errors.New("store disabled")
+	}
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(s.meta); b != nil {
+			value = bytes.Clone(b.Get([]byte(key)))
+		}
+		return nil
+	})
+	return value, err
+}
+
+// Purge removes every document and its raw HTML from the store's namespace.
+func (s *Store) Purge(ctx context.Context) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range s.buckets() {
+			if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("bolt: purge failed", "operation", "bolt_purge", "error", err)
+	}
+	return err
+}
@@ -0,0 +1,325 @@
+package boltstore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/storage/retention"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(filepath.Join(t.TempDir(), "cache", "docinator.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close(ctx)
+
+	if doc, err := store.GetByID(ctx, "github.com/spf13/cobra"); err != nil || doc != nil {
+		t.Fatalf("Expected a miss on an empty store, got %v, %v", doc, err)
+	}
+
+	doc := &models.Document{
+		ID:      "github.com/spf13/cobra",
+		Package: &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra"},
+		RawHTML: "<html>cobra</html>",
+	}
+	if err := store.Upsert(ctx, doc); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	got, err := store.GetByID(ctx, doc.ID)
+	if err != nil || got == nil {
+		t.Fatalf("Expected a hit, got %v, %v", got, err)
+	}
+	if got.Package.Name != "cobra" || got.RawHTML != doc.RawHTML {
+		t.Errorf("Expected stored package and raw HTML, got %+v", got)
+	}
+
+	var seen []*models.Document
+	if err := store.ForEach(ctx, func(d *models.Document) error {
+		seen = append(seen, d)
+		return nil
+	}); err != nil {
+		t.Fatalf("ForEach failed: %v", err)
+	}
+	if len(seen) != 1 || seen[0].RawHTML != "" {
+		t.Errorf("Expected one document without raw HTML, got %+v", seen)
+	}
+}
+
+func TestStore_Namespaces(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "docinator.db")
+	teamA, err := OpenNamespace(path, "team-a")
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	doc := &models.Document{ID: "github.com/spf13/cobra", Package: &models.Package{Name: "cobra"}, RawHTML: "<html>cobra</html>"}
+	if err := teamA.Upsert(ctx, doc); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	teamA.Close(ctx)
+
+	for namespace, want := range map[string]bool{"team-a": true, "team-b": false, "": false} {
+		store, err := OpenNamespace(path, namespace)
+		if err != nil {
+			t.Fatalf("Failed to open store: %v", err)
+		}
+		if got, _ := store.GetByID(ctx, doc.ID); (got != nil) != want {
+			t.Errorf("Namespace %q: expected found=%v, got %+v", namespace, want, got)
+		}
+		store.Close(ctx)
+	}
+
+	teamA, err = OpenNamespace(path, "team-a")
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer teamA.Close(ctx)
+	if err := teamA.Purge(ctx); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if got, _ := teamA.GetByID(ctx, doc.ID); got != nil {
+		t.Errorf("Expected the purged namespace to be empty, got %+v", got)
+	}
+}
+
+func TestStore_GetSymbol(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(filepath.Join(t.TempDir(), "docinator.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close(ctx)
+
+	pkg := &models.Package{
+		ImportPath: "github.com/spf13/cobra",
+		Functions:  []models.Function{{Name: "CheckErr", Signature: "func CheckErr(msg interface{})"}},
+		Types: []models.Type{{Name: "Command", Methods: []models.Function{{
+			Name:      "Execute",
+			Signature: "func (c *Command) Execute() error",
+			Examples:  []models.Example{{Name: "Command.Execute", Code: "cmd.Execute()"}},
+		}}}},
+	}
+	if err := store.Upsert(ctx, &models.Document{ID: pkg.ImportPath, Package: pkg}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	sym, err := store.GetSymbol(ctx, pkg.ImportPath, "Command.Execute")
+	if err != nil || sym == nil || sym.Kind != "method" || len(sym.Examples) != 1 {
+		t.Fatalf("Expected the indexed method with its example, got %+v, %v", sym, err)
+	}
+
+	pkg.Functions = nil
+	if err := store.Upsert(ctx, &models.Document{ID: pkg.ImportPath, Package: pkg}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if sym, _ := store.GetSymbol(ctx, pkg.ImportPath, "CheckErr"); sym != nil {
+		t.Errorf("Expected a removed function to leave the index, got %+v", sym)
+	}
+	if err := store.Delete(ctx, pkg.ImportPath); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if sym, _ := store.GetSymbol(ctx, pkg.ImportPath, "Command"); sym != nil {
+		t.Errorf("Expected a deleted document to leave the index, got %+v", sym)
+	}
+}
+
+func TestStore_OpenReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "docinator.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if err := store.Upsert(ctx, &models.Document{ID: "example.com/a", Package: &models.Package{ImportPath: "example.com/a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutMeta(ctx, "index", []byte("v1")); err != nil {
+		t.Fatalf("PutMeta failed: %v", err)
+	}
+	store.Close(ctx)
+
+	ro, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	defer ro.Close(ctx)
+	if doc, err := ro.GetByID(ctx, "example.com/a"); err != nil || doc == nil {
+		t.Errorf("Expected the document, got %v (%v)", doc, err)
+	}
+	if value, err := ro.Meta(ctx, "index"); err != nil || string(value) != "v1" {
+		t.Errorf("Expected the stored meta value, got %q (%v)", value, err)
+	}
+	if value, _ := ro.Meta(ctx, "missing"); value != nil {
+		t.Errorf("Expected nil for a missing key, got %q", value)
+	}
+	if err := ro.Upsert(ctx, &models.Document{ID: "example.com/b"}); err == nil {
+		t.Error("Expected writes to fail")
+	}
+	if _, err := OpenReadOnly(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("Expected a missing file to fail")
+	}
+}
+
+func TestStore_GetModule(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(filepath.Join(t.TempDir(), "docinator.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close(ctx)
+
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for id, pkg := range map[string]*models.Package{
+		"github.com/spf13/cobra/doc":        {ImportPath: "github.com/spf13/cobra/doc", Module: "github.com/spf13/cobra", Version: "v1.9.1", ScrapedAt: day},
+		"github.com/spf13/cobra":            {ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.9.1", ScrapedAt: day},
+		"github.com/spf13/cobra@v1.9.1":     {ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.9.1", ScrapedAt: day.Add(time.Hour), Synopsis: "newer"},
+		"github.com/spf13/cobra/doc@v1.8.0": {ImportPath: "github.com/spf13/cobra/doc", Module: "github.com/spf13/cobra", Version: "v1.8.0", ScrapedAt: day},
+	} {
+		if err := store.Upsert(ctx, &models.Document{ID: id, Package: pkg}); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+	m, err := store.GetModule(ctx, "github.com/spf13/cobra", "v1.9.1")
+	if err != nil || m == nil {
+		t.Fatalf("Expected the module, got %v, %v", m, err)
+	}
+	if len(m.Index) != 2 || m.Index[0].ImportPath != "github.com/spf13/cobra" || m.Index[0].Synopsis != "newer" || m.Index[1].ImportPath != "github.com/spf13/cobra/doc" {
+		t.Errorf("Expected the root, in its latest scrape, then doc, got %+v", m.Index)
+	}
+
+	// Moving the unpinned document to another version takes it out of v1.9.1.
+	next := &models.Package{ImportPath: "github.com/spf13/cobra/doc", Module: "github.com/spf13/cobra", Version: "v1.10.0"}
+	if err := store.Upsert(ctx, &models.Document{ID: next.ImportPath, Package: next}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if m, _ := store.GetModule(ctx, "github.com/spf13/cobra", "v1.9.1"); m == nil || len(m.Packages) != 1 {
+		t.Errorf("Expected only the root left in v1.9.1, got %+v", m)
+	}
+	if err := store.Delete(ctx, "github.com/spf13/cobra/doc@v1.8.0"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if m, err := store.GetModule(ctx, "github.com/spf13/cobra", "v1.8.0"); m != nil || err != nil {
+		t.Errorf("Expected the deleted module version gone, got %+v, %v", m, err)
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(filepath.Join(t.TempDir(), "docinator.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close(ctx)
+	store.SetRetention(retention.Policy{Snapshots: 2})
+
+	now := time.Now()
+	for i, version := range []string{"v1.0.0", "v1.1.0", "v1.2.0"} {
+		doc := &models.Document{
+			ID:      "github.com/spf13/cobra@" + version,
+			Package: &models.Package{ImportPath: "github.com/spf13/cobra", ScrapedAt: now.Add(time.Duration(i) * time.Hour)},
+		}
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+	latest := &models.Document{
+		ID:       "github.com/spf13/cobra",
+		Package:  &models.Package{ImportPath: "github.com/spf13/cobra", ScrapedAt: now},
+		Adoption: []models.AdoptionPoint{{ImportedBy: 1}, {ImportedBy: 2}, {ImportedBy: 3}},
+	}
+	if err := store.Upsert(ctx, latest); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	if doc, _ := store.GetByID(ctx, "github.com/spf13/cobra@v1.0.0"); doc != nil {
+		t.Error("Expected the oldest snapshot pruned")
+	}
+	for _, id := range []string{"github.com/spf13/cobra@v1.1.0", "github.com/spf13/cobra@v1.2.0"} {
+		if doc, _ := store.GetByID(ctx, id); doc == nil {
+			t.Errorf("Expected %s kept", id)
+		}
+	}
+	if doc, _ := store.GetByID(ctx, latest.ID); doc == nil || len(doc.Adoption) != 2 || len(latest.Adoption) != 3 {
+		t.Errorf("Expected the stored adoption history trimmed to 2 points, got %+v", doc)
+	}
+}
+
+func TestStore_Delta(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(filepath.Join(t.TempDir(), "docinator.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close(ctx)
+	store.SetDelta(true)
+
+	page := strings.Repeat("<p>cobra is a library for CLI applications</p>\n", 200)
+	version := func(v string) *models.Document {
+		return &models.Document{
+			ID: "github.com/spf13/cobra@" + v,
+			Package: &models.Package{
+				Name: "cobra", ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: v,
+				Functions: []models.Function{{Name: "CheckErr"}, {Name: "Eq" + strings.ReplaceAll(v, ".", "")}},
+			},
+			RawHTML: "<h1>" + v + "</h1>\n" + page,
+			Tags:    []string{"keep"},
+		}
+	}
+	latest := version("v1.9.0")
+	latest.ID = "github.com/spf13/cobra"
+	for _, doc := range []*models.Document{latest, version("v1.8.0"), version("v1.7.0")} {
+		if err := store.Upsert(ctx, doc); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+	check := func(v string) {
+		t.Helper()
+		want := version(v)
+		got, err := store.GetByID(ctx, want.ID)
+		if err != nil || got == nil || got.RawHTML != want.RawHTML || got.Package.Version != v ||
+			len(got.Package.Functions) != 2 || got.Package.Functions[1].Name != want.Package.Functions[1].Name || len(got.Tags) != 1 {
+			t.Fatalf("Expected %s rebuilt, got %+v, %v", want.ID, got, err)
+		}
+	}
+	check("v1.8.0")
+	check("v1.7.0")
+
+	err = store.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(store.packages).Get([]byte("github.com/spf13/cobra@v1.8.0")); !isDelta(data) || len(data) > len(page)/4 {
+			t.Errorf("Expected a small delta, got %d bytes", len(data))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod, err := store.GetModule(ctx, "github.com/spf13/cobra", "v1.8.0"); err != nil || mod == nil || len(mod.Packages) != 1 {
+		t.Errorf("Expected the module of a delta, got %+v, %v", mod, err)
+	}
+
+	// A new latest version rebases the deltas; deleting it keeps the snapshots in full.
+	latest.Package.Functions = nil
+	latest.RawHTML = "<html>v2</html>"
+	if err := store.Upsert(ctx, latest); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	check("v1.8.0")
+	if err := store.Delete(ctx, latest.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	check("v1.7.0")
+	var seen int
+	if err := store.ForEach(ctx, func(d *models.Document) error {
+		seen++
+		return nil
+	}); err != nil || seen != 2 {
+		t.Errorf("Expected both snapshots listed, got %d, %v", seen, err)
+	}
+}
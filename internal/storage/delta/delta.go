@@ -0,0 +1,107 @@
+// Package delta encodes a text as line-based edits of another, so the versions of a document,
+// which mostly repeat each other, are stored as what differs between them.
+package delta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is one edit of a delta: copy Len lines of the base from line Start, or insert Lines.
+type Op struct {
+	Start int      `bson:"s,omitempty"`
+	Len   int      `bson:"n,omitempty"`
+	Lines []string `bson:"l,omitempty"`
+}
+
+// Tuning of Diff: copies shorter than minCopy lines are inserted instead, as an Op costs about as
+// much as a short line, and at most maxCandidates base positions of a line are tried, which keeps
+// lines repeated throughout the base, such as closing braces, from making Diff quadratic.
+const (
+	minCopy       = 2
+	maxCandidates = 16
+)
+
+// Diff returns the edits turning base into target, such that Apply(base, Diff(base, target))
+// returns target. Runs of lines found anywhere in base are copied, so moved blocks cost one Op.
+func Diff(base, target string) []Op {
+	b, t := lines(base), lines(target)
+	index := map[string][]int{}
+	for i, l := range b {
+		if len(index[l]) < maxCandidates {
+			index[l] = append(index[l], i)
+		}
+	}
+	var ops []Op
+	var inserted []string
+	next := 0 // the base line after the last copy, tried first since edits are mostly local
+	for i := 0; i < len(t); {
+		start, n := -1, 0
+		if next < len(b) && b[next] == t[i] {
+			start, n = next, matchLen(b, next, t, i)
+		}
+		if n < minCopy {
+			for _, j := range index[t[i]] {
+				if m := matchLen(b, j, t, i); m > n {
+					start, n = j, m
+				}
+			}
+		}
+		if n < minCopy {
+			inserted = append(inserted, t[i])
+			i++
+			continue
+		}
+		if inserted != nil {
+			ops = append(ops, Op{Lines: inserted})
+			inserted = nil
+		}
+		ops = append(ops, Op{Start: start, Len: n})
+		i += n
+		next = start + n
+	}
+	if inserted != nil {
+		ops = append(ops, Op{Lines: inserted})
+	}
+	return ops
+}
+
+// Apply returns the text ops turn base into. It fails when an Op copies lines base does not have,
+// which means ops were computed against another base.
+func Apply(base string, ops []Op) (string, error) {
+	b := lines(base)
+	var out strings.Builder
+	for _, op := range ops {
+		if op.Lines != nil {
+			for _, l := range op.Lines {
+				out.WriteString(l)
+			}
+			continue
+		}
+		if op.Start < 0 || op.Len < 0 || op.Start+op.Len > len(b) {
+			return "", fmt.Errorf("delta copies lines %d-%d of a base of %d lines", op.Start, op.Start+op.Len, len(b))
+		}
+		for _, l := range b[op.Start : op.Start+op.Len] {
+			out.WriteString(l)
+		}
+	}
+	return out.String(), nil
+}
+
+// lines splits s after every newline, so joining the lines gives s back.
+func lines(s string) []string {
+	l := strings.SplitAfter(s, "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
+
+// matchLen returns how many lines of b from i equal the lines of t from j.
+func matchLen(b []string, i int, t []string, j int) int {
+	n := 0
+	for i+n < len(b) && j+n < len(t) && b[i+n] == t[j+n] {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,43 @@
+package delta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffApply(t *testing.T) {
+	base := "package widget\n\nfunc Turn()\nfunc Stop()\n\ntype Gear struct{}\n"
+	for _, target := range []string{
+		base,
+		"",
+		"package widget\n\nfunc Turn()\nfunc Start()\nfunc Stop()\n\ntype Gear struct{}\n",
+		"type Gear struct{}\npackage widget\n\nfunc Turn()\nfunc Stop()\n",
+		"no trailing newline",
+	} {
+		ops := Diff(base, target)
+		got, err := Apply(base, ops)
+		if err != nil || got != target {
+			t.Errorf("Expected %q back, got %q, %v (ops %+v)", target, got, err, ops)
+		}
+	}
+}
+
+func TestDiff_Small(t *testing.T) {
+	var b strings.Builder
+	for i := range 1000 {
+		b.WriteString(strings.Repeat("x", i%50) + "}\n")
+	}
+	base := b.String()
+	target := strings.Replace(base, "xxxxx}\n", "xxxxx changed}\n", 1)
+	ops := Diff(base, target)
+	if len(ops) != 3 || ops[1].Lines == nil {
+		t.Errorf("Expected a copy, the changed line and a copy, got %d ops", len(ops))
+	}
+}
+
+func TestApply_WrongBase(t *testing.T) {
+	ops := Diff("a\nb\nc\n", "a\nb\nc\n")
+	if _, err := Apply("a\n", ops); err == nil {
+		t.Error("Expected copying past the end of the base to fail")
+	}
+}
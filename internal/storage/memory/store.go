@@ -0,0 +1,161 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/storage/retention"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Store keeps documents in process memory. It is safe for concurrent use and loses
+// its contents on exit, which makes it suited to tests and one-off runs.
+type Store struct {
+	mu   sync.RWMutex
+	docs map[string][]byte // BSON encoded, so callers never share state with the store
+
+	retention retention.Policy
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{docs: make(map[string][]byte)}
+}
+
+// SetRetention makes Upsert enforce p on the documents it writes and on the other snapshots of
+// their package. Set it before the store is shared.
+func (s *Store) SetRetention(p retention.Policy) {
+	s.retention = p
+}
+
+// Enabled reports whether the store is active.
+func (s *Store) Enabled() bool {
+	return s != nil
+}
+
+// Close is a no-op; the contents stay readable until the store is garbage collected.
+func (s *Store) Close(ctx context.Context) error {
+	return nil
+}
+
+// GetByID returns a copy of the stored document or nil if not found.
+func (s *Store) GetByID(ctx context.Context, id string) (*models.Document, error) {
+	if !s.Enabled() {
+		return nil, errors.New("store disabled")
+	}
+	s.mu.RLock()
+	data, ok := s.docs[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	return decode(id, data)
+}
+
+// Upsert stores a copy of doc, replacing any document with the same ID. With a retention policy
+// set, the copy is trimmed to it and, for a snapshot, the snapshots of its package beyond the
+// policy are deleted.
+func (s *Store) Upsert(ctx context.Context, doc *models.Document) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	if doc == nil || doc.ID == "" {
+		return errors.New("invalid document or missing ID")
+	}
+	data, err := bson.Marshal(s.retention.Apply(doc, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", doc.ID, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[doc.ID] = data
+	return s.pruneSnapshots(doc.ID)
+}
+
+// pruneSnapshots deletes the snapshots of the package of id the retention policy does not keep.
+// The caller holds s.mu.
+func (s *Store) pruneSnapshots(id string) error {
+	path, ok := retention.Snapshot(id)
+	if !ok || s.retention.Snapshots == 0 {
+		return nil
+	}
+	var snapshots []*models.Document
+	for other, data := range s.docs {
+		if strings.HasPrefix(other, path+"@") {
+			doc, err := decode(other, data)
+			if err != nil {
+				return err
+			}
+			snapshots = append(snapshots, doc)
+		}
+	}
+	for _, pruned := range s.retention.Prune(snapshots) {
+		delete(s.docs, pruned)
+	}
+	return nil
+}
+
+// Delete removes the document with the given ID.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	s.mu.Lock()
+	delete(s.docs, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// ForEach passes a copy of every document (without raw HTML) to fn in ID order, stopping at the first error fn returns.
+func (s *Store) ForEach(ctx context.Context, fn func(*models.Document) error) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.docs))
+	for id := range s.docs {
+		ids = append(ids, id)
+	}
+	snapshot := make(map[string][]byte, len(s.docs))
+	for _, id := range ids {
+		snapshot[id] = s.docs[id]
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		doc, err := decode(id, snapshot[id])
+		if err != nil {
+			return err
+		}
+		doc.RawHTML = ""
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of stored documents.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.docs)
+}
+
+func decode(id string, data []byte) (*models.Document, error) {
+	var doc models.Document
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", id, err)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,63 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestStore_CopiesAndOrder(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	doc := &models.Document{ID: "b.example/pkg", Package: &models.Package{Name: "pkg"}, RawHTML: "<html/>"}
+	if err := store.Upsert(ctx, doc); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	doc.Package.Name = "mutated"
+
+	got, err := store.GetByID(ctx, "b.example/pkg")
+	if err != nil || got == nil {
+		t.Fatalf("Expected a hit, got %v, %v", got, err)
+	}
+	if got.Package.Name != "pkg" || got.RawHTML != "<html/>" {
+		t.Errorf("Expected the stored copy to be unaffected by caller changes, got %+v", got.Package)
+	}
+
+	if err := store.Upsert(ctx, &models.Document{ID: "a.example/pkg", Package: &models.Package{}}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	var ids []string
+	_ = store.ForEach(ctx, func(d *models.Document) error {
+		if d.RawHTML != "" {
+			t.Errorf("Expected ForEach to omit raw HTML for %s", d.ID)
+		}
+		ids = append(ids, d.ID)
+		return nil
+	})
+	if len(ids) != 2 || ids[0] != "a.example/pkg" {
+		t.Errorf("Expected documents in ID order, got %v", ids)
+	}
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("example.com/pkg%d", i%5)
+			_ = store.Upsert(ctx, &models.Document{ID: id, Package: &models.Package{}})
+			_, _ = store.GetByID(ctx, id)
+		}(i)
+	}
+	wg.Wait()
+	if store.Len() != 5 {
+		t.Errorf("Expected 5 documents, got %d", store.Len())
+	}
+}
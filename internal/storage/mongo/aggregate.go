@@ -0,0 +1,126 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// sizeOf builds an expression counting the elements of an optional array field.
+func sizeOf(field string) bson.D {
+	return bson.D{{Key: "$size", Value: bson.D{{Key: "$ifNull", Value: bson.A{field, bson.A{}}}}}}
+}
+
+// LicenseCounts returns the number of stored packages per license, most common first.
+// Packages without a license are grouped under "unknown".
+func (s *Store) LicenseCounts(ctx context.Context) ([]models.LicenseCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$package.license", "unknown"}}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	var out []models.LicenseCount
+	err := s.aggregate(ctx, "mongo_license_counts", pipeline, &out)
+	return out, err
+}
+
+// AverageSymbols returns average symbol counts per stored package.
+func (s *Store) AverageSymbols(ctx context.Context) (*models.SymbolStats, error) {
+	methods := bson.D{{Key: "$sum", Value: bson.D{{Key: "$map", Value: bson.D{
+		{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$package.types", bson.A{}}}}},
+		{Key: "as", Value: "t"},
+		{Key: "in", Value: sizeOf("$$t.methods")},
+	}}}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$project", Value: bson.D{
+			{Key: "functions", Value: sizeOf("$package.functions")},
+			{Key: "types", Value: sizeOf("$package.types")},
+			{Key: "methods", Value: methods},
+			{Key: "others", Value: bson.D{{Key: "$add", Value: bson.A{sizeOf("$package.variables"), sizeOf("$package.constants")}}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "packages", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "avg_functions", Value: bson.D{{Key: "$avg", Value: "$functions"}}},
+			{Key: "avg_types", Value: bson.D{{Key: "$avg", Value: "$types"}}},
+			{Key: "avg_methods", Value: bson.D{{Key: "$avg", Value: "$methods"}}},
+			{Key: "avg_symbols", Value: bson.D{{Key: "$avg", Value: bson.D{{Key: "$add", Value: bson.A{"$functions", "$types", "$methods", "$others"}}}}}},
+		}}},
+	}
+	var out []models.SymbolStats
+	if err := s.aggregate(ctx, "mongo_average_symbols", pipeline, &out); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return &models.SymbolStats{}, nil
+	}
+	return &out[0], nil
+}
+
+// LargestDocuments returns the n largest stored documents by BSON size.
+func (s *Store) LargestDocuments(ctx context.Context, n int) ([]models.DocumentSize, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$project", Value: bson.D{{Key: "bytes", Value: bson.D{{Key: "$bsonSize", Value: "$$ROOT"}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "bytes", Value: -1}}}},
+		{{Key: "$limit", Value: n}},
+	}
+	var out []models.DocumentSize
+	err := s.aggregate(ctx, "mongo_largest_documents", pipeline, &out)
+	return out, err
+}
+
+// StalestDocuments returns the n documents with the oldest scrape time.
+func (s *Store) StalestDocuments(ctx context.Context, n int) ([]models.DocumentSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "package.scraped_at", Value: 1}}}},
+		{{Key: "$limit", Value: n}},
+		{{Key: "$project", Value: summaryProjection}},
+	}
+	var out []models.DocumentSummary
+	err := s.aggregate(ctx, "mongo_stalest_documents", pipeline, &out)
+	return out, err
+}
+
+// OldestReleases returns the n documents with the oldest parsed publication date, skipping
+// documents without one.
+func (s *Store) OldestReleases(ctx context.Context, n int) ([]models.DocumentSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "package.published_at", Value: bson.D{{Key: "$type", Value: "date"}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "package.published_at", Value: 1}}}},
+		{{Key: "$limit", Value: n}},
+		{{Key: "$project", Value: summaryProjection}},
+	}
+	var out []models.DocumentSummary
+	err := s.aggregate(ctx, "mongo_oldest_releases", pipeline, &out)
+	return out, err
+}
+
+// aggregate runs pipeline on the packages collection and decodes all results into out.
+// Logging approach: log start, errors, and timing under the given operation label.
+func (s *Store) aggregate(ctx context.Context, operation string, pipeline mongo.Pipeline, out any) error {
+	if !s.Enabled() {
+		slog.Debug("mongo: aggregate skipped; store disabled", "operation", operation)
+		return errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: aggregate starting", "operation", operation)
+
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		slog.Error("mongo: aggregate failed", "operation", operation, "error", err, "duration", time.Since(start))
+		return err
+	}
+	if err := cursor.All(ctx, out); err != nil {
+		slog.Error("mongo: aggregate decode failed", "operation", operation, "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: aggregate success", "operation", operation, "duration", time.Since(start))
+	return nil
+}
@@ -0,0 +1,70 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// moduleIndexName names the index on module and version that serves GetModule.
+const moduleIndexName = "package_module_version"
+
+// ensureModuleIndex creates the index GetModule reads through, if missing.
+func (s *Store) ensureModuleIndex(ctx context.Context) error {
+	start := time.Now()
+	model := mongo.IndexModel{
+		Keys:    bson.D{{Key: "package.module", Value: 1}, {Key: "package.version", Value: 1}},
+		Options: options.Index().SetName(moduleIndexName),
+	}
+	if _, err := s.coll.Indexes().CreateOne(ctx, model); err != nil {
+		slog.Error("mongo: ensure_module_index failed", "operation", "mongo_ensure_module_index", "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: ensure_module_index success", "operation", "mongo_ensure_module_index", "duration", time.Since(start))
+	return nil
+}
+
+// GetModule returns every stored package of module at version, read with one indexed query, or
+// nil if none is. Documents always belong to the module version they were scraped at, so the
+// result never goes stale.
+// Logging approach: log counts, errors, and timing.
+func (s *Store) GetModule(ctx context.Context, module, version string) (*models.ModuleDocument, error) {
+	if !s.Enabled() {
+		slog.Debug("mongo: get_module skipped; store disabled", "operation", "mongo_get_module", "module", module)
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	filter := bson.M{"package.module": module, "package.version": version}
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"package": 1}))
+	if err != nil {
+		slog.Error("mongo: get_module failed", "operation", "mongo_get_module", "module", module, "version", version, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var pkgs []*models.Package
+	for cursor.Next(ctx) {
+		var doc models.Document
+		if err := cursor.Decode(&doc); err != nil {
+			slog.Error("mongo: get_module decode failed", "operation", "mongo_get_module", "module", module, "error", err)
+			return nil, err
+		}
+		if doc.Package != nil {
+			pkgs = append(pkgs, doc.Package)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		slog.Error("mongo: get_module cursor failed", "operation", "mongo_get_module", "module", module, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("mongo: get_module", "operation", "mongo_get_module", "module", module, "version", version, "packages", len(pkgs), "duration", time.Since(start))
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	return models.NewModuleDocument(module, version, pkgs), nil
+}
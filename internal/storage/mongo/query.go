@@ -0,0 +1,68 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// summaryProjection flattens the fields of a DocumentSummary out of the nested package document.
+var summaryProjection = bson.D{
+	{Key: "import_path", Value: "$package.import_path"},
+	{Key: "module", Value: "$package.module"},
+	{Key: "version", Value: "$package.version"},
+	{Key: "scraped_at", Value: "$package.scraped_at"},
+	{Key: "tags", Value: 1},
+	{Key: "published", Value: "$package.published"},
+	{Key: "published_at", Value: "$package.published_at"},
+}
+
+// FindByModule returns summaries of all stored packages that belong to modulePath, ordered by import path.
+// Logging approach: log start, result count, errors, and timing.
+func (s *Store) FindByModule(ctx context.Context, modulePath string) ([]models.DocumentSummary, error) {
+	return s.findSummaries(ctx, "mongo_find_by_module", bson.M{"package.module": modulePath},
+		bson.D{{Key: "package.import_path", Value: 1}, {Key: "package.version", Value: 1}})
+}
+
+// FindVersions returns summaries of every cached version of importPath, most recently scraped first.
+// Logging approach: log start, result count, errors, and timing.
+func (s *Store) FindVersions(ctx context.Context, importPath string) ([]models.DocumentSummary, error) {
+	return s.findSummaries(ctx, "mongo_find_versions", bson.M{"package.import_path": importPath},
+		bson.D{{Key: "package.scraped_at", Value: -1}})
+}
+
+// FindTagged returns summaries of the stored packages carrying all of tags, ordered by import path.
+// Logging approach: log start, result count, errors, and timing.
+func (s *Store) FindTagged(ctx context.Context, tags []string) ([]models.DocumentSummary, error) {
+	return s.findSummaries(ctx, "mongo_find_tagged", bson.M{"tags": bson.M{"$all": tags}},
+		bson.D{{Key: "package.import_path", Value: 1}, {Key: "package.version", Value: 1}})
+}
+
+// findSummaries runs a projected find so large documents are never decoded in full.
+func (s *Store) findSummaries(ctx context.Context, operation string, filter bson.M, sort bson.D) ([]models.DocumentSummary, error) {
+	if !s.Enabled() {
+		slog.Debug("mongo: query skipped; store disabled", "operation", operation)
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: query starting", "operation", operation, "filter", filter)
+
+	opts := options.Find().SetProjection(summaryProjection).SetSort(sort)
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("mongo: query failed", "operation", operation, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	var out []models.DocumentSummary
+	if err := cursor.All(ctx, &out); err != nil {
+		slog.Error("mongo: query decode failed", "operation", operation, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("mongo: query success", "operation", operation, "results", len(out), "duration", time.Since(start))
+	return out, nil
+}
@@ -0,0 +1,48 @@
+package mongostore
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/storage/retention"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// SetRetention makes Upsert enforce p on the documents it writes and on the other snapshots of
+// their package. Set it before the store is shared.
+func (s *Store) SetRetention(p retention.Policy) {
+	s.retention = p
+}
+
+// pruneSnapshots deletes the snapshots of the package of id the retention policy does not keep.
+// Logging approach: log the pruned count and errors.
+func (s *Store) pruneSnapshots(ctx context.Context, id string) error {
+	path, ok := retention.Snapshot(id)
+	if !ok || s.retention.Snapshots == 0 {
+		return nil
+	}
+	filter := bson.M{"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(path+"@")}}
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"raw_html": 0}))
+	if err != nil {
+		slog.Error("mongo: snapshot scan failed", "operation", "mongo_prune_snapshots", "id", id, "error", err)
+		return err
+	}
+	var snapshots []*models.Document
+	if err := cursor.All(ctx, &snapshots); err != nil {
+		slog.Error("mongo: snapshot scan failed", "operation", "mongo_prune_snapshots", "id", id, "error", err)
+		return err
+	}
+	pruned := s.retention.Prune(snapshots)
+	for _, other := range pruned {
+		if err := s.Delete(ctx, other); err != nil {
+			return err
+		}
+	}
+	if len(pruned) > 0 {
+		slog.Debug("mongo: pruned snapshots", "operation", "mongo_prune_snapshots", "id", id, "count", len(pruned))
+	}
+	return nil
+}
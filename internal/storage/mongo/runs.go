@@ -0,0 +1,57 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// InsertRun records a finished scrape invocation in the runs collection.
+// Logging approach: log start, errors, and timing.
+func (s *Store) InsertRun(ctx context.Context, run *models.Run) error {
+	if !s.Enabled() {
+		slog.Debug("mongo: insert_run skipped; store disabled", "operation", "mongo_insert_run")
+		return errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: insert_run starting", "operation", "mongo_insert_run", "id", run.ID)
+	if _, err := s.runs.InsertOne(ctx, run); err != nil {
+		slog.Error("mongo: insert_run failed", "operation", "mongo_insert_run", "id", run.ID, "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: insert_run success", "operation", "mongo_insert_run", "id", run.ID, "duration", time.Since(start))
+	return nil
+}
+
+// RecentRuns returns the n most recent runs, newest first, without their failure lists.
+// Logging approach: log start, result count, errors, and timing.
+func (s *Store) RecentRuns(ctx context.Context, n int) ([]models.Run, error) {
+	if !s.Enabled() {
+		slog.Debug("mongo: recent_runs skipped; store disabled", "operation", "mongo_recent_runs")
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: recent_runs starting", "operation", "mongo_recent_runs", "limit", n)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetLimit(int64(n)).
+		SetProjection(bson.M{"failures": 0})
+	cursor, err := s.runs.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		slog.Error("mongo: recent_runs failed", "operation", "mongo_recent_runs", "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	var out []models.Run
+	if err := cursor.All(ctx, &out); err != nil {
+		slog.Error("mongo: recent_runs decode failed", "operation", "mongo_recent_runs", "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("mongo: recent_runs success", "operation", "mongo_recent_runs", "results", len(out), "duration", time.Since(start))
+	return out, nil
+}
@@ -1,4 +1,3 @@
-// ... existing code ...
 package mongostore
 
 import (
@@ -9,6 +8,7 @@ import (
 	"time"
 
 	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/storage/retention"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -17,15 +17,27 @@ import (
 
 // Store wraps a MongoDB client and collection for document persistence.
 type Store struct {
-	enabled bool
-	client  *mongo.Client
-	coll    *mongo.Collection
+	enabled     bool
+	client      *mongo.Client
+	coll        *mongo.Collection
+	chunks      *mongo.Collection
+	runs        *mongo.Collection
+	symbols     *mongo.Collection
+	vectorIndex string
+	retention   retention.Policy
 }
 
 // NewFromEnv initializes the store from env:
 // - MONGODB_URI (required to enable; if empty, store is disabled)
 // - MONGODB_DB (default: "docinator")
 // - MONGODB_COLLECTION (default: "packages")
+// - MONGODB_CHUNKS_COLLECTION (default: "chunks")
+// - MONGODB_RUNS_COLLECTION (default: "runs")
+// - MONGODB_SYMBOLS_COLLECTION (default: "symbols")
+// - MONGODB_VECTOR_INDEX (default: "vector_index")
+// - MONGODB_NAMESPACE (optional): prefix every collection name with "<namespace>.", so teams
+// sharing a database each see only their own documents, chunks, runs and symbols
+// - MONGODB_TTL (optional): expire documents this long after scraped_at, e.g. "720h" or "30d"
 // Logging approach: use slog.Debug for start/success paths and slog.Error on errors,
 // include operation label and duration for observability.
 func NewFromEnv(ctx context.Context) (*Store, error) {
@@ -44,6 +56,37 @@ func NewFromEnv(ctx context.Context) (*Store, error) {
 	if collName == "" {
 		collName = "packages"
 	}
+	chunksName := os.Getenv("MONGODB_CHUNKS_COLLECTION")
+	if chunksName == "" {
+		chunksName = "chunks"
+	}
+	runsName := os.Getenv("MONGODB_RUNS_COLLECTION")
+	if runsName == "" {
+		runsName = "runs"
+	}
+	symbolsName := os.Getenv("MONGODB_SYMBOLS_COLLECTION")
+	if symbolsName == "" {
+		symbolsName = "symbols"
+	}
+	if namespace := os.Getenv("MONGODB_NAMESPACE"); namespace != "" {
+		collName = namespaced(namespace, collName)
+		chunksName = namespaced(namespace, chunksName)
+		runsName = namespaced(namespace, runsName)
+		symbolsName = namespaced(namespace, symbolsName)
+	}
+	vectorIndex := os.Getenv("MONGODB_VECTOR_INDEX")
+	if vectorIndex == "" {
+		vectorIndex = "vector_index"
+	}
+	var ttl time.Duration
+	if v := os.Getenv("MONGODB_TTL"); v != "" {
+		d, err := parseTTL(v)
+		if err != nil {
+			slog.Error("mongo: invalid ttl", "operation", "mongo_connect", "error", err)
+			return nil, err
+		}
+		ttl = d
+	}
 
 	// Debug: attempting connection and ping; measure duration for connect flow.
 	start := time.Now()
@@ -66,11 +109,24 @@ func NewFromEnv(ctx context.Context) (*Store, error) {
 
 	coll := client.Database(dbName).Collection(collName)
 	slog.Debug("mongo: connected", "operation", "mongo_connect", "db", dbName, "collection", collName, "duration", time.Since(start))
-	return &Store{
-		enabled: true,
-		client:  client,
-		coll:    coll,
-	}, nil
+	store := &Store{
+		enabled:     true,
+		client:      client,
+		coll:        coll,
+		chunks:      client.Database(dbName).Collection(chunksName),
+		runs:        client.Database(dbName).Collection(runsName),
+		symbols:     client.Database(dbName).Collection(symbolsName),
+		vectorIndex: vectorIndex,
+	}
+	// Without the index GetModule still works, scanning the collection; a read-only user may not create it.
+	_ = store.ensureModuleIndex(ctx)
+	if ttl > 0 {
+		if err := store.EnsureTTLIndex(ctx, ttl); err != nil {
+			_ = client.Disconnect(ctx)
+			return nil, err
+		}
+	}
+	return store, nil
 }
 
 // Enabled reports whether the store is active.
@@ -119,7 +175,9 @@ func (s *Store) GetByID(ctx context.Context, id string) (*models.Document, error
 	return &doc, nil
 }
 
-// Upsert replaces the document by _id or inserts it if missing.
+// Upsert replaces the document by _id or inserts it if missing. With a retention policy set, the
+// document is trimmed to it and, for a snapshot, the snapshots of its package beyond the policy
+// are deleted afterwards.
 // Logging approach: log start, success (with doc ID), errors, and timing.
 func (s *Store) Upsert(ctx context.Context, doc *models.Document) error {
 	if !s.Enabled() {
@@ -131,6 +189,7 @@ func (s *Store) Upsert(ctx context.Context, doc *models.Document) error {
 		return errors.New("invalid document or missing ID")
 	}
 
+	doc = s.retention.Apply(doc, time.Now())
 	filter := bson.M{"_id": doc.ID}
 
 	// Pass the v2 options builder directly (implements options.Lister)
@@ -141,6 +200,91 @@ func (s *Store) Upsert(ctx context.Context, doc *models.Document) error {
 		slog.Error("mongo: upsert failed", "operation", "mongo_upsert", "id", doc.ID, "error", err, "duration", time.Since(start))
 		return err
 	}
+	if err := s.indexSymbols(ctx, doc); err != nil {
+		return err
+	}
+	if err := s.pruneSnapshots(ctx, doc.ID); err != nil {
+		return err
+	}
 	slog.Debug("mongo: upsert success", "operation", "mongo_upsert", "id", doc.ID, "duration", time.Since(start))
 	return nil
 }
+
+// Delete removes the document by _id; a missing document is not an error.
+// Logging approach: log start, errors, and timing.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if !s.Enabled() {
+		slog.Debug("mongo: delete skipped; store disabled", "operation", "mongo_delete", "id", id)
+		return errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: delete starting", "operation", "mongo_delete", "id", id)
+	if _, err := s.coll.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		slog.Error("mongo: delete failed", "operation", "mongo_delete", "id", id, "error", err, "duration", time.Since(start))
+		return err
+	}
+	if _, err := s.symbols.DeleteMany(ctx, symbolsOf(id)); err != nil {
+		slog.Error("mongo: delete symbols failed", "operation", "mongo_delete", "id", id, "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: delete success", "operation", "mongo_delete", "id", id, "duration", time.Since(start))
+	return nil
+}
+
+// ForEach streams every stored document (without raw HTML) to fn, stopping at the first error fn returns.
+// Logging approach: log start, count, errors, and timing.
+func (s *Store) ForEach(ctx context.Context, fn func(*models.Document) error) error {
+	if !s.Enabled() {
+		slog.Debug("mongo: for_each skipped; store disabled", "operation", "mongo_for_each")
+		return errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: for_each starting", "operation", "mongo_for_each")
+
+	cursor, err := s.coll.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"raw_html": 0}))
+	if err != nil {
+		slog.Error("mongo: for_each failed", "operation", "mongo_for_each", "error", err, "duration", time.Since(start))
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		var doc models.Document
+		if err := cursor.Decode(&doc); err != nil {
+			slog.Error("mongo: for_each decode failed", "operation", "mongo_for_each", "error", err)
+			return err
+		}
+		count++
+		if err := fn(&doc); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		slog.Error("mongo: for_each cursor failed", "operation", "mongo_for_each", "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: for_each done", "operation", "mongo_for_each", "count", count, "duration", time.Since(start))
+	return nil
+}
+
+// namespaced returns the name of collection name in namespace.
+func namespaced(namespace, name string) string {
+	return namespace + "." + name
+}
+
+// Purge drops the documents, chunks, runs and symbols collections of the store's namespace.
+func (s *Store) Purge(ctx context.Context) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	start := time.Now()
+	for _, coll := range []*mongo.Collection{s.coll, s.chunks, s.runs, s.symbols} {
+		if err := coll.Drop(ctx); err != nil {
+			slog.Error("mongo: purge failed", "operation", "mongo_purge", "collection", coll.Name(), "error", err, "duration", time.Since(start))
+			return err
+		}
+	}
+	slog.Debug("mongo: purge success", "operation", "mongo_purge", "duration", time.Since(start))
+	return nil
+}
@@ -0,0 +1,77 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/symdoc"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// symbolDoc is a symbol of a stored document, kept in the symbols collection under
+// "<document ID>#<symbol>". Import paths cannot contain '#', so the IDs of one document's
+// symbols never prefix those of another.
+type symbolDoc struct {
+	ID        string         `bson:"_id"`
+	ScrapedAt time.Time      `bson:"scraped_at"` // for the TTL index of MONGODB_TTL
+	Snippet   symdoc.Snippet `bson:"snippet"`
+}
+
+// symbolsOf matches the symbols of the document id, using the _id index.
+func symbolsOf(id string) bson.M {
+	return bson.M{"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(id+"#")}}
+}
+
+// indexSymbols replaces the indexed symbols of doc.
+// Logging approach: log counts, errors, and timing.
+func (s *Store) indexSymbols(ctx context.Context, doc *models.Document) error {
+	start := time.Now()
+	if _, err := s.symbols.DeleteMany(ctx, symbolsOf(doc.ID)); err != nil {
+		slog.Error("mongo: index_symbols delete failed", "operation", "mongo_index_symbols", "id", doc.ID, "error", err, "duration", time.Since(start))
+		return err
+	}
+	if doc.Package == nil {
+		return nil
+	}
+	var docs []any
+	for _, sym := range symdoc.All(doc.Package) {
+		docs = append(docs, symbolDoc{ID: doc.ID + "#" + sym.Name, ScrapedAt: doc.Package.ScrapedAt, Snippet: sym})
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	if _, err := s.symbols.InsertMany(ctx, docs); err != nil {
+		slog.Error("mongo: index_symbols insert failed", "operation", "mongo_index_symbols", "id", doc.ID, "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: index_symbols success", "operation", "mongo_index_symbols", "id", doc.ID, "count", len(docs), "duration", time.Since(start))
+	return nil
+}
+
+// GetSymbol returns a symbol of the document stored under id from the symbols collection, or nil
+// if either is not indexed.
+// Logging approach: log hit/miss, errors, and timing.
+func (s *Store) GetSymbol(ctx context.Context, id, name string) (*symdoc.Snippet, error) {
+	if !s.Enabled() {
+		slog.Debug("mongo: get_symbol skipped; store disabled", "operation", "mongo_get_symbol", "id", id)
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	var sym symbolDoc
+	err := s.symbols.FindOne(ctx, bson.M{"_id": id + "#" + name}).Decode(&sym)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			slog.Debug("mongo: get_symbol miss", "operation", "mongo_get_symbol", "id", id, "name", name, "duration", time.Since(start))
+			return nil, nil
+		}
+		slog.Error("mongo: get_symbol failed", "operation", "mongo_get_symbol", "id", id, "name", name, "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("mongo: get_symbol hit", "operation", "mongo_get_symbol", "id", id, "name", name, "duration", time.Since(start))
+	return &sym.Snippet, nil
+}
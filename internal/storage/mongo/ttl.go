@@ -0,0 +1,95 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ttlIndexName names the index created for MONGODB_TTL.
+const ttlIndexName = "scraped_at_ttl"
+
+// parseTTL accepts a Go duration ("720h"), a number of days ("30d") or plain seconds ("86400").
+func parseTTL(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	var ttl time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid MONGODB_TTL %q: %w", value, err)
+		}
+		ttl = time.Duration(n) * 24 * time.Hour
+	} else if n, err := strconv.Atoi(value); err == nil {
+		ttl = time.Duration(n) * time.Second
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid MONGODB_TTL %q: %w", value, err)
+		}
+		ttl = d
+	}
+	if ttl < time.Second {
+		return 0, fmt.Errorf("invalid MONGODB_TTL %q: must be at least one second", value)
+	}
+	return ttl, nil
+}
+
+// EnsureTTLIndex creates a TTL index on package.scraped_at so MongoDB evicts documents
+// older than ttl, and one on the scraped_at of indexed symbols so they go with their documents.
+// An existing index with a different expiry is updated in place.
+// Logging approach: log start, create/update outcome, errors, and timing.
+func (s *Store) EnsureTTLIndex(ctx context.Context, ttl time.Duration) error {
+	if !s.Enabled() {
+		slog.Debug("mongo: ensure_ttl_index skipped; store disabled", "operation", "mongo_ensure_ttl_index")
+		return errors.New("store disabled")
+	}
+	seconds := int32(ttl / time.Second)
+	if err := ensureTTL(ctx, s.coll, "package.scraped_at", seconds); err != nil {
+		return err
+	}
+	return ensureTTL(ctx, s.symbols, "scraped_at", seconds)
+}
+
+// ensureTTL creates or updates the TTL index of coll on key.
+func ensureTTL(ctx context.Context, coll *mongo.Collection, key string, seconds int32) error {
+	start := time.Now()
+	slog.Debug("mongo: ensure_ttl_index starting", "operation", "mongo_ensure_ttl_index", "collection", coll.Name(), "ttl_seconds", seconds)
+
+	model := mongo.IndexModel{
+		Keys:    bson.D{{Key: key, Value: 1}},
+		Options: options.Index().SetName(ttlIndexName).SetExpireAfterSeconds(seconds),
+	}
+	_, err := coll.Indexes().CreateOne(ctx, model)
+	if err == nil {
+		slog.Debug("mongo: ensure_ttl_index success", "operation", "mongo_ensure_ttl_index", "collection", coll.Name(), "duration", time.Since(start))
+		return nil
+	}
+
+	// IndexOptionsConflict: the index exists with another expiry, so change it with collMod.
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != 85 {
+		slog.Error("mongo: ensure_ttl_index failed", "operation", "mongo_ensure_ttl_index", "collection", coll.Name(), "error", err, "duration", time.Since(start))
+		return err
+	}
+	cmd := bson.D{
+		{Key: "collMod", Value: coll.Name()},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: ttlIndexName},
+			{Key: "expireAfterSeconds", Value: seconds},
+		}},
+	}
+	if err := coll.Database().RunCommand(ctx, cmd).Err(); err != nil {
+		slog.Error("mongo: ensure_ttl_index update failed", "operation", "mongo_ensure_ttl_index", "collection", coll.Name(), "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: ensure_ttl_index updated", "operation", "mongo_ensure_ttl_index", "collection", coll.Name(), "duration", time.Since(start))
+	return nil
+}
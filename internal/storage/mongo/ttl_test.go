@@ -0,0 +1,27 @@
+package mongostore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTTL(t *testing.T) {
+	cases := map[string]time.Duration{
+		"720h":  720 * time.Hour,
+		"30d":   30 * 24 * time.Hour,
+		"86400": 24 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := parseTTL(in)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got error %v", in, err)
+		} else if got != want {
+			t.Errorf("Expected %q to be %v, got %v", in, want, got)
+		}
+	}
+	for _, in := range []string{"", "soon", "0", "500ms", "xd"} {
+		if _, err := parseTTL(in); err == nil {
+			t.Errorf("Expected %q to be rejected", in)
+		}
+	}
+}
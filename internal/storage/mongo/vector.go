@@ -0,0 +1,164 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ReplaceChunks swaps all stored chunks of source for the given set.
+// Logging approach: log start, counts, errors, and timing.
+func (s *Store) ReplaceChunks(ctx context.Context, source string, chunks []models.Chunk) error {
+	if !s.Enabled() {
+		slog.Debug("mongo: replace_chunks skipped; store disabled", "operation", "mongo_replace_chunks", "source", source)
+		return errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: replace_chunks starting", "operation", "mongo_replace_chunks", "source", source, "count", len(chunks))
+
+	if _, err := s.chunks.DeleteMany(ctx, bson.M{"source": source}); err != nil {
+		slog.Error("mongo: replace_chunks delete failed", "operation", "mongo_replace_chunks", "source", source, "error", err, "duration", time.Since(start))
+		return err
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	docs := make([]any, len(chunks))
+	for i := range chunks {
+		docs[i] = chunks[i]
+	}
+	if _, err := s.chunks.InsertMany(ctx, docs); err != nil {
+		slog.Error("mongo: replace_chunks insert failed", "operation", "mongo_replace_chunks", "source", source, "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: replace_chunks success", "operation", "mongo_replace_chunks", "source", source, "count", len(chunks), "duration", time.Since(start))
+	return nil
+}
+
+// EnsureVectorIndex creates the Atlas Vector Search index on chunk embeddings if it does not exist yet.
+// Atlas builds the index asynchronously, so new chunks may take a short while to become searchable.
+func (s *Store) EnsureVectorIndex(ctx context.Context, dimensions int) error {
+	if !s.Enabled() {
+		return errors.New("store disabled")
+	}
+	if dimensions <= 0 {
+		return errors.New("vector dimensions must be positive")
+	}
+	start := time.Now()
+	slog.Debug("mongo: ensure_vector_index", "operation", "mongo_ensure_vector_index", "index", s.vectorIndex, "dimensions", dimensions)
+
+	cursor, err := s.chunks.SearchIndexes().List(ctx, options.SearchIndexes().SetName(s.vectorIndex))
+	if err != nil {
+		slog.Error("mongo: ensure_vector_index list failed", "operation", "mongo_ensure_vector_index", "error", err)
+		return err
+	}
+	exists := cursor.Next(ctx)
+	_ = cursor.Close(ctx)
+	if exists {
+		slog.Debug("mongo: ensure_vector_index exists", "operation", "mongo_ensure_vector_index", "index", s.vectorIndex, "duration", time.Since(start))
+		return nil
+	}
+
+	definition := bson.D{{Key: "fields", Value: bson.A{
+		bson.D{{Key: "type", Value: "vector"}, {Key: "path", Value: "embedding"}, {Key: "numDimensions", Value: dimensions}, {Key: "similarity", Value: "cosine"}},
+		bson.D{{Key: "type", Value: "filter"}, {Key: "path", Value: "source"}},
+	}}}
+	model := mongo.SearchIndexModel{
+		Definition: definition,
+		Options:    options.SearchIndexes().SetName(s.vectorIndex).SetType("vectorSearch"),
+	}
+	if _, err := s.chunks.SearchIndexes().CreateOne(ctx, model); err != nil {
+		slog.Error("mongo: ensure_vector_index create failed", "operation", "mongo_ensure_vector_index", "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: ensure_vector_index created", "operation", "mongo_ensure_vector_index", "index", s.vectorIndex, "duration", time.Since(start))
+	return nil
+}
+
+// SimilaritySearch returns the k chunks whose embeddings are closest to vector, using Atlas Vector Search.
+func (s *Store) SimilaritySearch(ctx context.Context, vector []float32, k int) ([]models.ChunkMatch, error) {
+	if !s.Enabled() {
+		return nil, errors.New("store disabled")
+	}
+	if k <= 0 {
+		k = 10
+	}
+	start := time.Now()
+	slog.Debug("mongo: similarity_search", "operation", "mongo_similarity_search", "k", k)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$vectorSearch", Value: bson.D{
+			{Key: "index", Value: s.vectorIndex},
+			{Key: "path", Value: "embedding"},
+			{Key: "queryVector", Value: vector},
+			{Key: "numCandidates", Value: k * 10},
+			{Key: "limit", Value: k},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "embedding", Value: 0},
+			{Key: "score", Value: bson.D{{Key: "$meta", Value: "vectorSearchScore"}}},
+		}}},
+	}
+	cursor, err := s.chunks.Aggregate(ctx, pipeline)
+	if err != nil {
+		slog.Error("mongo: similarity_search failed", "operation", "mongo_similarity_search", "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	var matches []models.ChunkMatch
+	if err := cursor.All(ctx, &matches); err != nil {
+		slog.Error("mongo: similarity_search decode failed", "operation", "mongo_similarity_search", "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("mongo: similarity_search success", "operation", "mongo_similarity_search", "results", len(matches), "duration", time.Since(start))
+	return matches, nil
+}
+
+// HasEmbeddings reports whether at least one stored chunk carries an embedding.
+func (s *Store) HasEmbeddings(ctx context.Context) (bool, error) {
+	if !s.Enabled() {
+		return false, errors.New("store disabled")
+	}
+	n, err := s.chunks.CountDocuments(ctx, bson.M{"embedding": bson.M{"$exists": true}}, options.Count().SetLimit(1))
+	if err != nil {
+		slog.Error("mongo: has_embeddings failed", "operation", "mongo_has_embeddings", "error", err)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ChunkSources returns every source with stored chunks and the total BSON size of its chunks.
+func (s *Store) ChunkSources(ctx context.Context) ([]models.DocumentSize, error) {
+	if !s.Enabled() {
+		return nil, errors.New("store disabled")
+	}
+	start := time.Now()
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$source"},
+			{Key: "bytes", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$bsonSize", Value: "$$ROOT"}}}}},
+		}}},
+	}
+	cursor, err := s.chunks.Aggregate(ctx, pipeline)
+	if err != nil {
+		slog.Error("mongo: chunk_sources failed", "operation", "mongo_chunk_sources", "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	var out []models.DocumentSize
+	if err := cursor.All(ctx, &out); err != nil {
+		slog.Error("mongo: chunk_sources decode failed", "operation", "mongo_chunk_sources", "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Debug("mongo: chunk_sources success", "operation", "mongo_chunk_sources", "sources", len(out), "duration", time.Since(start))
+	return out, nil
+}
+
+// DeleteChunks removes all stored chunks of source.
+func (s *Store) DeleteChunks(ctx context.Context, source string) error {
+	return s.ReplaceChunks(ctx, source, nil)
+}
@@ -0,0 +1,64 @@
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// changeEvent is the subset of a change stream event used by Watch.
+type changeEvent struct {
+	OperationType string           `bson:"operationType"`
+	FullDocument  *models.Document `bson:"fullDocument"`
+}
+
+// Watch subscribes to a change stream on the packages collection and calls fn with the
+// full document after every insert, replace or update, until ctx is cancelled or fn fails.
+// Change streams require MongoDB to run as a replica set or sharded cluster.
+// Logging approach: log subscription, each event, errors, and total watch duration.
+func (s *Store) Watch(ctx context.Context, fn func(*models.Document) error) error {
+	if !s.Enabled() {
+		slog.Debug("mongo: watch skipped; store disabled", "operation", "mongo_watch")
+		return errors.New("store disabled")
+	}
+	start := time.Now()
+	slog.Debug("mongo: watch subscribing", "operation", "mongo_watch")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "operationType", Value: bson.D{{Key: "$in", Value: bson.A{"insert", "replace", "update"}}}}}}},
+	}
+	stream, err := s.coll.Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		slog.Error("mongo: watch failed", "operation", "mongo_watch", "error", err)
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event changeEvent
+		if err := stream.Decode(&event); err != nil {
+			slog.Error("mongo: watch decode failed", "operation", "mongo_watch", "error", err)
+			return err
+		}
+		// Updates of documents deleted before the lookup have no full document.
+		if event.FullDocument == nil {
+			continue
+		}
+		slog.Debug("mongo: watch event", "operation", "mongo_watch", "type", event.OperationType, "id", event.FullDocument.ID)
+		if err := fn(event.FullDocument); err != nil {
+			return err
+		}
+	}
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		slog.Error("mongo: watch stream failed", "operation", "mongo_watch", "error", err, "duration", time.Since(start))
+		return err
+	}
+	slog.Debug("mongo: watch stopped", "operation", "mongo_watch", "duration", time.Since(start))
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package retention bounds what the stores keep of the history of each package: how many
+// pinned-version snapshots and adoption points, and how long snapshots keep their raw HTML. The
+// latest, unpinned document of a package is always kept whole.
+package retention
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Environment variables read by FromEnv.
+const (
+	SnapshotsEnv   = "DOCINATOR_KEEP_SNAPSHOTS"
+	RawHTMLDaysEnv = "DOCINATOR_RAW_HTML_DAYS"
+)
+
+// Policy is a retention policy. The zero Policy keeps everything.
+type Policy struct {
+	// Snapshots is how many pinned snapshots (path@version documents) of a package are kept, the
+	// most recently scraped ones, and how many adoption points each document keeps. Tagged
+	// snapshots are curated and neither count nor go. 0 keeps all.
+	Snapshots int
+	// RawHTML is how long after its scrape a snapshot keeps its raw HTML. 0 keeps it.
+	RawHTML time.Duration
+}
+
+// FromEnv reads the policy from DOCINATOR_KEEP_SNAPSHOTS (a count) and DOCINATOR_RAW_HTML_DAYS
+// (days); unset variables keep everything.
+func FromEnv() (Policy, error) {
+	var p Policy
+	if v := os.Getenv(SnapshotsEnv); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("%s: want a count of at least 0, got %q", SnapshotsEnv, v)
+		}
+		p.Snapshots = n
+	}
+	if v := os.Getenv(RawHTMLDaysEnv); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("%s: want a number of days of at least 0, got %q", RawHTMLDaysEnv, v)
+		}
+		p.RawHTML = time.Duration(n) * 24 * time.Hour
+	}
+	return p, nil
+}
+
+// Enabled reports whether the policy removes anything.
+func (p Policy) Enabled() bool {
+	return p.Snapshots > 0 || p.RawHTML > 0
+}
+
+// Snapshot returns the import path of the package id is a pinned snapshot of, and false for the
+// latest document of a package.
+func Snapshot(id string) (importPath string, ok bool) {
+	importPath, _, ok = strings.Cut(id, "@")
+	return importPath, ok
+}
+
+// Apply returns doc as the policy keeps it at now: with only the last Snapshots adoption points
+// and, for a snapshot scraped more than RawHTML ago, without its raw HTML. doc itself is returned
+// when nothing changes and a shallow copy otherwise.
+func (p Policy) Apply(doc *models.Document, now time.Time) *models.Document {
+	trim := p.trims(doc)
+	drop := doc.RawHTML != "" && p.rawExpired(doc, now)
+	if !trim && !drop {
+		return doc
+	}
+	kept := *doc
+	if trim {
+		kept.Adoption = slices.Clone(doc.Adoption[len(doc.Adoption)-p.Snapshots:])
+	}
+	if drop {
+		kept.RawHTML = ""
+	}
+	return &kept
+}
+
+// Affects reports whether Apply may change doc at now, judging without its raw HTML, as listings
+// such as storage.Store.ForEach leave it out.
+func (p Policy) Affects(doc *models.Document, now time.Time) bool {
+	return p.trims(doc) || p.rawExpired(doc, now)
+}
+
+// trims reports whether doc has more adoption points than the policy keeps.
+func (p Policy) trims(doc *models.Document) bool {
+	return p.Snapshots > 0 && len(doc.Adoption) > p.Snapshots
+}
+
+// rawExpired reports whether doc is a snapshot scraped longer than RawHTML before now.
+func (p Policy) rawExpired(doc *models.Document, now time.Time) bool {
+	_, pinned := Snapshot(doc.ID)
+	return p.RawHTML > 0 && pinned && doc.Package != nil && doc.Package.ScrapedAt.Before(now.Add(-p.RawHTML))
+}
+
+// Prune returns the IDs of the snapshots of one package beyond the Snapshots most recently
+// scraped. Tagged snapshots are never returned and do not count.
+func (p Policy) Prune(snapshots []*models.Document) []string {
+	if p.Snapshots <= 0 {
+		return nil
+	}
+	var untagged []*models.Document
+	for _, doc := range snapshots {
+		if len(doc.Tags) == 0 {
+			untagged = append(untagged, doc)
+		}
+	}
+	if len(untagged) <= p.Snapshots {
+		return nil
+	}
+	slices.SortFunc(untagged, func(a, b *models.Document) int {
+		return cmp.Or(scrapedAt(b).Compare(scrapedAt(a)), strings.Compare(a.ID, b.ID))
+	})
+	var ids []string
+	for _, doc := range untagged[p.Snapshots:] {
+		ids = append(ids, doc.ID)
+	}
+	return ids
+}
+
+func scrapedAt(doc *models.Document) time.Time {
+	if doc.Package == nil {
+		return time.Time{}
+	}
+	return doc.Package.ScrapedAt
+}
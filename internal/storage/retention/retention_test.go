@@ -0,0 +1,65 @@
+package retention
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(SnapshotsEnv, "3")
+	t.Setenv(RawHTMLDaysEnv, "30")
+	p, err := FromEnv()
+	if err != nil || p.Snapshots != 3 || p.RawHTML != 30*24*time.Hour || !p.Enabled() {
+		t.Fatalf("Unexpected policy %+v, %v", p, err)
+	}
+	t.Setenv(RawHTMLDaysEnv, "a month")
+	if _, err := FromEnv(); err == nil {
+		t.Error("Expected an invalid number of days to fail")
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := &models.Package{ScrapedAt: now.AddDate(0, 0, -40)}
+	p := Policy{Snapshots: 2, RawHTML: 30 * 24 * time.Hour}
+
+	latest := &models.Document{ID: "example.com/m", Package: old, RawHTML: "<html/>"}
+	if got := p.Apply(latest, now); got != latest {
+		t.Errorf("Expected the latest document kept whole, got %+v", got)
+	}
+
+	snapshot := &models.Document{ID: "example.com/m@v1.0.0", Package: old, RawHTML: "<html/>", Adoption: []models.AdoptionPoint{{ImportedBy: 1}, {ImportedBy: 2}, {ImportedBy: 3}}}
+	got := p.Apply(snapshot, now)
+	if got.RawHTML != "" || len(got.Adoption) != 2 || got.Adoption[0].ImportedBy != 2 {
+		t.Errorf("Expected the snapshot without raw HTML and with its last 2 adoption points, got %+v", got)
+	}
+	if snapshot.RawHTML == "" || len(snapshot.Adoption) != 3 {
+		t.Error("Expected Apply to leave its argument alone")
+	}
+	if !p.Affects(&models.Document{ID: snapshot.ID, Package: old}, now) {
+		t.Error("Expected a stale snapshot listed without raw HTML to be affected")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := func(version string, age int, tags ...string) *models.Document {
+		return &models.Document{ID: "example.com/m@" + version, Package: &models.Package{ScrapedAt: now.AddDate(0, 0, -age)}, Tags: tags}
+	}
+	snapshots := []*models.Document{
+		snapshot("v1.0.0", 40, "pinned"),
+		snapshot("v1.1.0", 30),
+		snapshot("v1.2.0", 20),
+		snapshot("v1.3.0", 10),
+	}
+	got := Policy{Snapshots: 2}.Prune(snapshots)
+	if !slices.Equal(got, []string{"example.com/m@v1.1.0"}) {
+		t.Errorf("Expected the oldest untagged snapshot pruned, got %v", got)
+	}
+	if got := (Policy{}).Prune(snapshots); got != nil {
+		t.Errorf("Expected the zero policy to keep everything, got %v", got)
+	}
+}
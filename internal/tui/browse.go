@@ -0,0 +1,537 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/search"
+	"github.com/moseye/docinator/pkg/textdiff"
+)
+
+// Source provides the packages shown by the browser.
+type Source interface {
+	// List returns the import paths of all cached packages, sorted.
+	List(ctx context.Context) ([]string, error)
+	// Load returns a cached package.
+	Load(ctx context.Context, importPath string) (*models.Package, error)
+	// Scrape fetches a fresh copy of the package without caching it.
+	Scrape(ctx context.Context, importPath string) (*models.Package, error)
+	// Refresh scrapes the package again and replaces the cached copy.
+	Refresh(ctx context.Context, importPath string) (*models.Package, error)
+	// Delete removes a package from the cache.
+	Delete(ctx context.Context, importPath string) error
+	// Symbols returns the exported symbols of every cached package.
+	Symbols(ctx context.Context) ([]search.Symbol, error)
+}
+
+// paletteSize is the number of matches shown by the symbol palette.
+const paletteSize = 50
+
+// listWidth is the width of the package list pane.
+const listWidth = 40
+
+var (
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	statusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, true, false, false).PaddingRight(1)
+	addedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	removedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// symbol is an entry of the symbol jump list.
+type symbol struct {
+	label string
+	line  int // line of the symbol's heading in the preview
+}
+
+// mode selects what the keys act on.
+type mode int
+
+const (
+	modeList    mode = iota // moving through packages
+	modeSymbols             // moving through the symbol jump list
+	modeDiff                // viewing the cached-vs-fresh diff
+	modeConfirm             // waiting for delete confirmation
+	modePalette             // typing a query into the corpus-wide symbol palette
+)
+
+// Model is the bubbletea model of the corpus browser.
+type Model struct {
+	ctx context.Context
+	src Source
+
+	ids    []string
+	cursor int
+
+	pkg     *models.Package
+	lines   []string // rendered markdown (or diff) of the selected package
+	scroll  int
+	symbols []symbol
+	symIdx  int
+
+	corpus  []search.Symbol // loaded the first time the palette opens
+	query   string
+	matches []search.SymbolMatch
+	palIdx  int
+	jump    string // symbol to scroll to once the selected package has loaded
+
+	mode   mode
+	status string
+	width  int
+	height int
+}
+
+// New returns a browser over src.
+func New(ctx context.Context, src Source) Model {
+	return Model{ctx: ctx, src: src, width: 120, height: 30}
+}
+
+type listMsg struct {
+	ids []string
+	err error
+}
+
+type loadedMsg struct {
+	id  string
+	pkg *models.Package
+	err error
+}
+
+type refreshedMsg loadedMsg
+
+type diffMsg struct {
+	id   string
+	diff string
+	err  error
+}
+
+type deletedMsg struct {
+	id  string
+	err error
+}
+
+type corpusMsg struct {
+	symbols []search.Symbol
+	err     error
+}
+
+// Init loads the package list.
+func (m Model) Init() tea.Cmd {
+	return m.list
+}
+
+func (m Model) list() tea.Msg {
+	ids, err := m.src.List(m.ctx)
+	return listMsg{ids: ids, err: err}
+}
+
+func (m Model) load(id string) tea.Cmd {
+	return func() tea.Msg {
+		pkg, err := m.src.Load(m.ctx, id)
+		return loadedMsg{id: id, pkg: pkg, err: err}
+	}
+}
+
+func (m Model) refresh(id string) tea.Cmd {
+	return func() tea.Msg {
+		pkg, err := m.src.Refresh(m.ctx, id)
+		return refreshedMsg{id: id, pkg: pkg, err: err}
+	}
+}
+
+func (m Model) diff(id string, cached *models.Package) tea.Cmd {
+	return func() tea.Msg {
+		fresh, err := m.src.Scrape(m.ctx, id)
+		if err != nil {
+			return diffMsg{id: id, err: err}
+		}
+		d := textdiff.Unified("cached", "fresh", markdown.PackageToMarkdown(cached), markdown.PackageToMarkdown(fresh), 3)
+		return diffMsg{id: id, diff: d}
+	}
+}
+
+func (m Model) loadCorpus() tea.Msg {
+	symbols, err := m.src.Symbols(m.ctx)
+	return corpusMsg{symbols: symbols, err: err}
+}
+
+func (m Model) remove(id string) tea.Cmd {
+	return func() tea.Msg {
+		return deletedMsg{id: id, err: m.src.Delete(m.ctx, id)}
+	}
+}
+
+// Update handles keys and the results of background commands.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case listMsg:
+		if msg.err != nil {
+			m.status = "List failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.ids = msg.ids
+		m.cursor = min(m.cursor, max(len(m.ids)-1, 0))
+		if len(m.ids) == 0 {
+			m.status = "The cache is empty"
+			m.pkg, m.lines, m.symbols = nil, nil, nil
+			return m, nil
+		}
+		return m, m.load(m.ids[m.cursor])
+
+	case loadedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Load failed for %s: %v", msg.id, msg.err)
+			return m, nil
+		}
+		if m.current() == msg.id {
+			m.show(msg.pkg)
+			m.jumpTo(m.jump)
+			m.jump = ""
+		}
+		return m, nil
+
+	case refreshedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Refresh failed for %s: %v", msg.id, msg.err)
+			return m, nil
+		}
+		m.status = "Refreshed " + msg.id
+		if m.current() == msg.id {
+			m.show(msg.pkg)
+		}
+		return m, nil
+
+	case diffMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Diff failed for %s: %v", msg.id, msg.err)
+			return m, nil
+		}
+		if msg.diff == "" {
+			m.status = "No changes since the cached copy of " + msg.id
+			return m, nil
+		}
+		m.mode = modeDiff
+		m.lines = strings.Split(strings.TrimRight(msg.diff, "\n"), "\n")
+		m.scroll = 0
+		m.status = "Diff of cached vs fresh " + msg.id + " (esc to close)"
+		return m, nil
+
+	case deletedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Delete failed for %s: %v", msg.id, msg.err)
+			return m, nil
+		}
+		m.status = "Deleted " + msg.id
+		m.corpus = nil
+		return m, m.list
+
+	case corpusMsg:
+		if msg.err != nil {
+			m.status = "Loading symbols failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.corpus = msg.symbols
+		m.status = fmt.Sprintf("%d symbols", len(m.corpus))
+		m.search()
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.key(msg)
+	}
+	return m, nil
+}
+
+func (m Model) key(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	if m.mode == modeConfirm {
+		m.mode = modeList
+		if key == "y" && m.current() != "" {
+			m.status = "Deleting " + m.current() + "…"
+			return m, m.remove(m.current())
+		}
+		m.status = "Delete cancelled"
+		return m, nil
+	}
+
+	if m.mode == modePalette {
+		return m.paletteKey(msg)
+	}
+
+	switch key {
+	case "q":
+		return m, tea.Quit
+	case "esc":
+		if m.mode == modeDiff && m.pkg != nil {
+			m.show(m.pkg)
+		}
+		m.mode = modeList
+		return m, nil
+	case "up", "k":
+		return m.move(-1)
+	case "down", "j":
+		return m.move(1)
+	case "pgdown", " ", "ctrl+d":
+		m.scrollBy(m.bodyHeight() / 2)
+	case "pgup", "ctrl+u":
+		m.scrollBy(-m.bodyHeight() / 2)
+	case "g", "home":
+		m.scroll = 0
+	case "s":
+		if m.mode == modeSymbols {
+			m.mode = modeList
+		} else if len(m.symbols) > 0 {
+			m.mode = modeSymbols
+		}
+	case "enter":
+		if m.mode == modeSymbols && m.symIdx < len(m.symbols) {
+			m.scroll = m.symbols[m.symIdx].line
+			m.mode = modeList
+		}
+	case "r":
+		if id := m.current(); id != "" {
+			m.status = "Refreshing " + id + "…"
+			return m, m.refresh(id)
+		}
+	case "d":
+		if id := m.current(); id != "" && m.pkg != nil {
+			m.status = "Scraping " + id + " for a diff…"
+			return m, m.diff(id, m.pkg)
+		}
+	case "x":
+		if m.current() != "" {
+			m.mode = modeConfirm
+			m.status = "Delete " + m.current() + " from the cache? (y/N)"
+		}
+	case "/":
+		m.mode = modePalette
+		m.query, m.matches, m.palIdx = "", nil, 0
+		if m.corpus == nil {
+			m.status = "Loading symbols…"
+			return m, m.loadCorpus
+		}
+	}
+	return m, nil
+}
+
+// paletteKey edits the palette query and opens the chosen symbol.
+func (m Model) paletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeList
+	case tea.KeyUp, tea.KeyCtrlP:
+		m.palIdx = clamp(m.palIdx-1, 0, len(m.matches)-1)
+	case tea.KeyDown, tea.KeyCtrlN:
+		m.palIdx = clamp(m.palIdx+1, 0, len(m.matches)-1)
+	case tea.KeyBackspace:
+		if r := []rune(m.query); len(r) > 0 {
+			m.query = string(r[:len(r)-1])
+			m.search()
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.query += string(msg.Runes)
+		m.search()
+	case tea.KeyEnter:
+		if m.palIdx >= len(m.matches) {
+			return m, nil
+		}
+		sym := m.matches[m.palIdx]
+		m.mode = modeList
+		for i, id := range m.ids {
+			if id != sym.ImportPath {
+				continue
+			}
+			if i == m.cursor && m.pkg != nil {
+				m.jumpTo(sym.Name)
+				return m, nil
+			}
+			m.cursor, m.jump = i, sym.Name
+			return m, m.load(id)
+		}
+		m.status = sym.ImportPath + " is no longer cached"
+	}
+	return m, nil
+}
+
+// search recomputes the palette matches for the current query.
+func (m *Model) search() {
+	m.matches = search.FuzzyFind(m.query, m.corpus, paletteSize)
+	m.palIdx = 0
+}
+
+// jumpTo scrolls to the heading of the named symbol ("Name" or "Type.Method"), if present.
+func (m *Model) jumpTo(name string) {
+	if name == "" {
+		return
+	}
+	for i, s := range m.symbols {
+		label := strings.TrimSpace(s.label)
+		if label == name || strings.HasSuffix(label, " "+name) {
+			m.scroll, m.symIdx = s.line, i
+			return
+		}
+	}
+}
+
+// move changes the selection in the active list by delta.
+func (m Model) move(delta int) (tea.Model, tea.Cmd) {
+	if m.mode == modeSymbols {
+		m.symIdx = clamp(m.symIdx+delta, 0, len(m.symbols)-1)
+		return m, nil
+	}
+	next := clamp(m.cursor+delta, 0, len(m.ids)-1)
+	if next == m.cursor {
+		return m, nil
+	}
+	m.cursor = next
+	m.mode = modeList
+	return m, m.load(m.ids[next])
+}
+
+func (m *Model) show(pkg *models.Package) {
+	m.pkg = pkg
+	m.lines = strings.Split(markdown.PackageToMarkdown(pkg), "\n")
+	m.symbols = symbolIndex(m.lines)
+	m.scroll, m.symIdx = 0, 0
+}
+
+func (m *Model) scrollBy(n int) {
+	m.scroll = clamp(m.scroll+n, 0, max(len(m.lines)-m.bodyHeight(), 0))
+}
+
+func (m Model) current() string {
+	if m.cursor < len(m.ids) {
+		return m.ids[m.cursor]
+	}
+	return ""
+}
+
+// bodyHeight is the number of rows available to the panes.
+func (m Model) bodyHeight() int {
+	return max(m.height-3, 1)
+}
+
+// View renders the list pane, the preview (or symbol list) pane and the status lines.
+func (m Model) View() string {
+	h := m.bodyHeight()
+
+	var left []string
+	if m.mode == modePalette {
+		left = append(left, titleStyle.Render("Find symbol: ")+m.query+"▏")
+		start := clamp(m.palIdx-h/2, 0, max(len(m.matches)-h+1, 0))
+		for i := start; i < len(m.matches) && len(left) < h; i++ {
+			left = append(left, item(m.matches[i].Name+"  "+m.matches[i].ImportPath, i == m.palIdx))
+		}
+	} else if m.mode == modeSymbols {
+		left = append(left, titleStyle.Render("Symbols"))
+		start := clamp(m.symIdx-h/2, 0, max(len(m.symbols)-h+1, 0))
+		for i := start; i < len(m.symbols) && len(left) < h; i++ {
+			left = append(left, item(m.symbols[i].label, i == m.symIdx))
+		}
+	} else {
+		left = append(left, titleStyle.Render(fmt.Sprintf("Cached packages (%d)", len(m.ids))))
+		start := clamp(m.cursor-h/2, 0, max(len(m.ids)-h+1, 0))
+		for i := start; i < len(m.ids) && len(left) < h; i++ {
+			left = append(left, item(m.ids[i], i == m.cursor))
+		}
+	}
+
+	previewWidth := max(m.width-listWidth-3, 10)
+	var right []string
+	for i := m.scroll; i < len(m.lines) && len(right) < h; i++ {
+		right = append(right, m.styleLine(truncate(m.lines[i], previewWidth)))
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		paneStyle.Width(listWidth).Height(h).Render(strings.Join(left, "\n")),
+		" ",
+		lipgloss.NewStyle().Width(previewWidth).Height(h).Render(strings.Join(right, "\n")),
+	)
+	help := dimStyle.Render("j/k move · space/pgup scroll · s symbols · / find symbol · enter jump · r refresh · d diff · x delete · q quit")
+	return body + "\n" + statusStyle.Render(m.status) + "\n" + help
+}
+
+func (m Model) styleLine(line string) string {
+	if m.mode != modeDiff {
+		return line
+	}
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return addedStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return removedStyle.Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return dimStyle.Render(line)
+	}
+	return line
+}
+
+func item(label string, selected bool) string {
+	label = truncate(label, listWidth-2)
+	if selected {
+		return selectedStyle.Render("> " + label)
+	}
+	return "  " + label
+}
+
+// symbolIndex returns the constants, variables, functions, types and methods documented in
+// rendered markdown lines, with the line of each heading.
+func symbolIndex(lines []string) []symbol {
+	var out []symbol
+	section, typeName := "", ""
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "### "):
+			section = strings.TrimPrefix(line, "### ")
+		case strings.HasPrefix(line, "## "):
+			section = ""
+		case strings.HasPrefix(line, "#### "):
+			if section != "Constants" && section != "Variables" && section != "Functions" && section != "Types" {
+				continue
+			}
+			name := strings.TrimPrefix(line, "#### ")
+			typeName = ""
+			kind := map[string]string{"Constants": "const", "Variables": "var", "Functions": "func", "Types": "type"}[section]
+			if section == "Types" {
+				typeName = name
+			}
+			out = append(out, symbol{label: kind + " " + name, line: i})
+		case strings.HasPrefix(line, "###### ") && typeName != "":
+			name := strings.TrimPrefix(line, "###### ")
+			if !strings.Contains(name, ".") {
+				name = typeName + "." + name
+			}
+			out = append(out, symbol{label: "  " + name, line: i})
+		}
+	}
+	return out
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:max(n-1, 0)]) + "…"
+}
+
+func clamp(v, lo, hi int) int {
+	if hi < lo {
+		return lo
+	}
+	return min(max(v, lo), hi)
+}
@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/search"
+)
+
+type fakeSource struct {
+	pkgs map[string]*models.Package
+}
+
+func (f *fakeSource) List(ctx context.Context) ([]string, error) {
+	var ids []string
+	for id := range f.pkgs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (f *fakeSource) Load(ctx context.Context, id string) (*models.Package, error) {
+	return f.pkgs[id], nil
+}
+
+func (f *fakeSource) Scrape(ctx context.Context, id string) (*models.Package, error) {
+	fresh := *f.pkgs[id]
+	fresh.Synopsis = "Fresh synopsis."
+	return &fresh, nil
+}
+
+func (f *fakeSource) Refresh(ctx context.Context, id string) (*models.Package, error) {
+	fresh, _ := f.Scrape(ctx, id)
+	f.pkgs[id] = fresh
+	return fresh, nil
+}
+
+func (f *fakeSource) Delete(ctx context.Context, id string) error {
+	delete(f.pkgs, id)
+	return nil
+}
+
+func (f *fakeSource) Symbols(ctx context.Context) ([]search.Symbol, error) {
+	var symbols []search.Symbol
+	for _, pkg := range f.pkgs {
+		symbols = append(symbols, search.PackageSymbols(pkg)...)
+	}
+	return symbols, nil
+}
+
+// run applies msg and then every resulting command, synchronously.
+func run(t *testing.T, m tea.Model, msg tea.Msg) Model {
+	t.Helper()
+	for msg != nil {
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		msg = nil
+		if cmd != nil {
+			msg = cmd()
+		}
+	}
+	return m.(Model)
+}
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestBrowse(t *testing.T) {
+	src := &fakeSource{pkgs: map[string]*models.Package{
+		"example.com/a": {Name: "a", ImportPath: "example.com/a", Functions: []models.Function{{Name: "Run"}}},
+		"example.com/b": {Name: "b", ImportPath: "example.com/b"},
+	}}
+	m := New(context.Background(), src)
+	m = run(t, m, m.Init()())
+
+	if m.pkg == nil || m.pkg.ImportPath != "example.com/a" {
+		t.Fatalf("Expected the first package to be previewed, got %+v", m.pkg)
+	}
+	if len(m.symbols) != 1 || m.symbols[0].label != "func Run" {
+		t.Errorf("Expected the symbol jump list to hold Run, got %+v", m.symbols)
+	}
+
+	m = run(t, m, key("s"))
+	m = run(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.HasPrefix(m.lines[m.scroll], "#### Run") {
+		t.Errorf("Expected enter to jump to the Run heading, got %q", m.lines[m.scroll])
+	}
+
+	m = run(t, m, key("d"))
+	if m.mode != modeDiff || !strings.Contains(strings.Join(m.lines, "\n"), "+Fresh synopsis.") {
+		t.Errorf("Expected a diff against the fresh copy, got %q", m.status)
+	}
+	m = run(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	m = run(t, m, key("x"))
+	m = run(t, m, key("y"))
+	if _, ok := src.pkgs["example.com/a"]; ok || len(m.ids) != 1 || m.current() != "example.com/b" {
+		t.Errorf("Expected example.com/a to be deleted and b selected, got %v", m.ids)
+	}
+	if !strings.Contains(m.View(), "example.com/b") {
+		t.Error("Expected the view to list the remaining package")
+	}
+}
+
+func TestBrowse_SymbolPalette(t *testing.T) {
+	src := &fakeSource{pkgs: map[string]*models.Package{
+		"example.com/a": {Name: "a", ImportPath: "example.com/a", Functions: []models.Function{{Name: "Run"}}},
+		"example.com/b": {Name: "b", ImportPath: "example.com/b", Types: []models.Type{
+			{Name: "Client", Methods: []models.Function{{Name: "Close", Receiver: "*Client"}}},
+		}},
+	}}
+	m := New(context.Background(), src)
+	m = run(t, m, m.Init()())
+
+	m = run(t, m, key("/"))
+	for _, r := range "clclose" {
+		m = run(t, m, key(string(r)))
+	}
+	if m.mode != modePalette || len(m.matches) == 0 || m.matches[0].Name != "Client.Close" {
+		t.Fatalf("Expected Client.Close to be the best match, got %+v", m.matches)
+	}
+
+	m = run(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.current() != "example.com/b" || m.pkg == nil || m.pkg.ImportPath != "example.com/b" {
+		t.Fatalf("Expected example.com/b to be selected and loaded, got %q", m.current())
+	}
+	if !strings.HasPrefix(m.lines[m.scroll], "###### Client.Close") {
+		t.Errorf("Expected the preview to jump to the Close heading, got %q", m.lines[m.scroll])
+	}
+}
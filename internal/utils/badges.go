@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultBadgePatterns match the image URLs of common CI, coverage, release and report badges.
+var DefaultBadgePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^https?://(img\.shields\.io|badgen\.net|badge\.fury\.io|flat\.badgen\.net|raster\.shields\.io)/`),
+	regexp.MustCompile(`(?i)^https?://(goreportcard\.com|pkg\.go\.dev|sourcegraph\.com|app\.fossa\.com|api\.codeclimate\.com|sonarcloud\.io|snyk\.io|deepsource\.io|bestpractices\.coreinfrastructure\.org|www\.bestpractices\.dev|api\.securityscorecards\.dev|gitter\.im|badges\.gitter\.im)/.*badge`),
+	regexp.MustCompile(`(?i)^https?://(codecov\.io|coveralls\.io|travis-ci\.(org|com)|circleci\.com|ci\.appveyor\.com|dev\.azure\.com|gitlab\.com|godoc\.org)/.*(badge|status|\.svg|\.png)`),
+	regexp.MustCompile(`(?i)^https?://github\.com/[^/]+/[^/]+/(actions/)?workflows/.*badge\.svg`),
+	regexp.MustCompile(`(?i)/badges?(/|\.svg|$|\?)`),
+}
+
+var (
+	// linkedImage matches [![alt](image)](link) and bare ![alt](image).
+	linkedImage = regexp.MustCompile(`\[!\[[^\]]*\]\(([^)\s]+)[^)]*\)\]\([^)]*\)|!\[[^\]]*\]\(([^)\s]+)[^)]*\)`)
+	// badgeLeftover is what a line holds once its badges are gone: separators and whitespace.
+	badgeLeftover = regexp.MustCompile(`^[\s|·•,-]*$`)
+)
+
+// StripBadges drops badge images, and the links wrapping them, from markdown: every image whose
+// URL matches one of patterns (DefaultBadgePatterns when nil). Lines left holding only
+// separators are removed too. Code blocks are left alone.
+func StripBadges(markdown string, patterns []*regexp.Regexp) string {
+	if patterns == nil {
+		patterns = DefaultBadgePatterns
+	}
+	isBadge := func(url string) bool {
+		for _, p := range patterns {
+			if p.MatchString(url) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var out []string
+	inFence, stripped := false, false
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if inFence || !strings.Contains(line, "![") {
+			if line == "" && stripped && len(out) > 0 && out[len(out)-1] == "" {
+				continue // a blank line was already kept before the badges
+			}
+			stripped = false
+			out = append(out, line)
+			continue
+		}
+		rest := linkedImage.ReplaceAllStringFunc(line, func(img string) string {
+			m := linkedImage.FindStringSubmatch(img)
+			if isBadge(m[1] + m[2]) {
+				return ""
+			}
+			return img
+		})
+		if rest != line && badgeLeftover.MatchString(rest) {
+			stripped = true
+			continue
+		}
+		out = append(out, rest)
+	}
+	return strings.TrimLeft(strings.Join(out, "\n"), "\n")
+}
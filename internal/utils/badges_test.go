@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestStripBadges(t *testing.T) {
+	md := "# Cobra\n\n" +
+		"[![Build](https://github.com/spf13/cobra/actions/workflows/test.yml/badge.svg)](https://github.com/spf13/cobra/actions) " +
+		"[![Go Reference](https://pkg.go.dev/badge/github.com/spf13/cobra.svg)](https://pkg.go.dev/github.com/spf13/cobra) | " +
+		"![coverage](https://img.shields.io/codecov/c/github/spf13/cobra)\n\n" +
+		"![cobra logo](https://cloud.githubusercontent.com/assets/173412/10886352/cobra.png)\n\n" +
+		"Cobra is a library ![status](https://travis-ci.org/spf13/cobra.svg?branch=main) for CLIs.\n\n" +
+		"```md\n![badge](https://img.shields.io/x)\n```\n"
+
+	want := "# Cobra\n\n" +
+		"![cobra logo](https://cloud.githubusercontent.com/assets/173412/10886352/cobra.png)\n\n" +
+		"Cobra is a library  for CLIs.\n\n" +
+		"```md\n![badge](https://img.shields.io/x)\n```\n"
+	if got := StripBadges(md, nil); got != want {
+		t.Errorf("StripBadges() = %q, want %q", got, want)
+	}
+
+	custom := []*regexp.Regexp{regexp.MustCompile(`cloud\.githubusercontent\.com`)}
+	if got := StripBadges("![cobra logo](https://cloud.githubusercontent.com/cobra.png)\n\nText", custom); got != "Text" {
+		t.Errorf("Expected custom patterns to apply, got %q", got)
+	}
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// months maps month names and abbreviations, in English and the languages pkg.go.dev mirrors and
+// documentation sites commonly render dates in, to months.
+var months = map[string]time.Month{}
+
+func init() {
+	names := [][]string{
+		// English, German, French, Spanish, Portuguese, Italian, Dutch
+		{"january", "januar", "janvier", "enero", "janeiro", "gennaio", "januari", "jan", "ene", "gen", "janv", "jän"},
+		{"february", "februar", "février", "fevrier", "febrero", "fevereiro", "febbraio", "februari", "feb", "fév", "fev", "févr"},
+		{"march", "märz", "maerz", "mars", "marzo", "março", "marco", "maart", "mar", "mär", "mrt"},
+		{"april", "avril", "abril", "aprile", "apr", "avr", "abr"},
+		{"may", "mai", "mayo", "maio", "maggio", "mei", "mag"},
+		{"june", "juni", "juin", "junio", "junho", "giugno", "jun", "giu"},
+		{"july", "juli", "juillet", "julio", "julho", "luglio", "jul", "juil", "lug"},
+		{"august", "août", "aout", "agosto", "augustus", "aug", "ago"},
+		{"september", "septembre", "septiembre", "setembro", "settembre", "sep", "sept", "set"},
+		{"october", "oktober", "octobre", "octubre", "outubro", "ottobre", "oct", "okt", "out", "ott"},
+		{"november", "novembre", "noviembre", "novembro", "nov"},
+		{"december", "dezember", "décembre", "decembre", "diciembre", "dezembro", "dicembre", "dec", "dez", "déc", "dic"},
+	}
+	for i, list := range names {
+		for _, name := range list {
+			months[name] = time.Month(i + 1)
+		}
+	}
+}
+
+// relativeDate matches relative forms such as "2 days ago", "an hour ago" or "1 month ago".
+var relativeDate = regexp.MustCompile(`^(\d+|an?|one)\s+(second|minute|hour|day|week|month|year)s?\s+ago$`)
+
+// dateToken splits a date into numbers and words.
+var dateToken = regexp.MustCompile(`\d+|[^\d\s.,/-]+`)
+
+// ParseDate parses a date as shown on documentation pages: "Mar 1, 2024", "1 March 2024",
+// "1. März 2024", "2024-03-01", "01/03/2024" (day first, as outside the US, unless the day
+// cannot be a month) and relative forms such as "today", "yesterday" and "2 days ago", which are
+// relative to now. Dates are returned at midnight UTC, relative ones at now minus the amount.
+// ok is false for text that is not a date.
+func ParseDate(s string, now time.Time) (t time.Time, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return time.Time{}, false
+	}
+	switch s {
+	case "today", "just now":
+		return now, true
+	case "yesterday":
+		return now.AddDate(0, 0, -1), true
+	}
+	if m := relativeDate.FindStringSubmatch(s); m != nil {
+		n := 1
+		if v, err := strconv.Atoi(m[1]); err == nil {
+			n = v
+		}
+		switch m[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), true
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), true
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), true
+		case "day":
+			return now.AddDate(0, 0, -n), true
+		case "week":
+			return now.AddDate(0, 0, -7*n), true
+		case "month":
+			return now.AddDate(0, -n, 0), true
+		default:
+			return now.AddDate(-n, 0, 0), true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(s)); err == nil {
+		return t, true
+	}
+
+	var nums []int
+	var month time.Month
+	for _, tok := range dateToken.FindAllString(s, -1) {
+		if n, err := strconv.Atoi(tok); err == nil {
+			nums = append(nums, n)
+			continue
+		}
+		if m, found := months[tok]; found && month == 0 {
+			month = m
+		} else if tok != "de" && tok != "of" && tok != "utc" {
+			return time.Time{}, false
+		}
+	}
+	var year, day int
+	switch {
+	case month != 0 && len(nums) == 2:
+		// "Mar 1, 2024" or "1 March 2024"; the year is the number above 31.
+		day, year = nums[0], nums[1]
+		if day > 31 {
+			day, year = year, day
+		}
+	case month == 0 && len(nums) == 3 && nums[0] > 31:
+		year, month, day = nums[0], time.Month(nums[1]), nums[2]
+	case month == 0 && len(nums) == 3:
+		day, month, year = nums[0], time.Month(nums[1]), nums[2]
+		if month > 12 && day <= 12 {
+			day, month = int(month), time.Month(day)
+		}
+	default:
+		return time.Time{}, false
+	}
+	if year < 1970 || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	t = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != day {
+		return time.Time{}, false // e.g. February 30
+	}
+	return t, true
+}
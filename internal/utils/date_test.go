@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	for in, want := range map[string]time.Time{
+		"Mar 1, 2024":          day(2024, 3, 1),
+		"March 1, 2024":        day(2024, 3, 1),
+		"1 March 2024":         day(2024, 3, 1),
+		"1. März 2024":         day(2024, 3, 1),
+		"1 de marzo de 2024":   day(2024, 3, 1),
+		"1 févr. 2024":         day(2024, 2, 1),
+		"2024-03-01":           day(2024, 3, 1),
+		"01/03/2024":           day(2024, 3, 1),
+		"03/25/2024":           day(2024, 3, 25),
+		"2024-03-01T10:00:00Z": time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		"today":                now,
+		"Yesterday":            now.AddDate(0, 0, -1),
+		"2 days ago":           now.AddDate(0, 0, -2),
+		"an hour ago":          now.Add(-time.Hour),
+		"1 month ago":          now.AddDate(0, -1, 0),
+		"3 years ago":          now.AddDate(-3, 0, 0),
+	} {
+		got, ok := ParseDate(in, now)
+		if !ok || !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "soon", "Feb 30, 2024", "13/13/2024", "v1.2.3", "Mar 2024"} {
+		if got, ok := ParseDate(in, now); ok {
+			t.Errorf("ParseDate(%q) = %v; want no date", in, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// emoji maps the GitHub emoji shortcodes READMEs commonly use to their characters. GitHub expands
+// shortcodes when it renders a README; pkg.go.dev leaves them as typed.
+var emoji = map[string]string{
+	"+1": "👍", "-1": "👎", "100": "💯", "1234": "🔢", "alarm_clock": "⏰", "alien": "👽",
+	"ambulance": "🚑", "anchor": "⚓", "angry": "😠", "apple": "🍎", "arrow_down": "⬇️",
+	"arrow_forward": "▶️", "arrow_left": "⬅️", "arrow_right": "➡️", "arrow_up": "⬆️",
+	"art": "🎨", "astonished": "😲", "atom_symbol": "⚛️", "baby": "👶", "balloon": "🎈",
+	"ballot_box_with_check": "☑️", "bangbang": "‼️", "bar_chart": "📊", "battery": "🔋",
+	"beer": "🍺", "beers": "🍻", "beetle": "🐞", "bell": "🔔", "bento": "🍱", "bird": "🐦",
+	"blush": "😊", "bomb": "💣", "book": "📖", "bookmark": "🔖", "books": "📚", "boom": "💥",
+	"bow": "🙇", "brain": "🧠", "bricks": "🧱", "broken_heart": "💔", "bug": "🐛", "bulb": "💡",
+	"bullettrain_front": "🚅", "bust_in_silhouette": "👤", "busts_in_silhouette": "👥",
+	"cactus": "🌵", "cake": "🍰", "calendar": "📆", "camera": "📷", "card_index": "📇",
+	"cat": "🐱", "chart_with_downwards_trend": "📉", "chart_with_upwards_trend": "📈",
+	"checkered_flag": "🏁", "clap": "👏", "clipboard": "📋", "clock1": "🕐", "closed_book": "📕",
+	"closed_lock_with_key": "🔐", "cloud": "☁️", "coffee": "☕", "computer": "💻",
+	"confetti_ball": "🎊", "confused": "😕", "construction": "🚧", "construction_worker": "👷",
+	"cool": "🆒", "copyright": "©️", "cry": "😢", "crystal_ball": "🔮", "dart": "🎯",
+	"dash": "💨", "date": "📅", "desktop_computer": "🖥️", "dizzy": "💫", "dna": "🧬", "dog": "🐶",
+	"dollar": "💵", "dolphin": "🐬", "door": "🚪", "dragon": "🐉", "droplet": "💧",
+	"earth_africa": "🌍", "earth_americas": "🌎", "earth_asia": "🌏", "egg": "🥚", "eyes": "👀",
+	"email": "📧", "envelope": "✉️", "exclamation": "❗", "eyeglasses": "👓", "factory": "🏭",
+	"fast_forward": "⏩", "file_folder": "📁", "fire": "🔥", "fireworks": "🎆", "fish": "🐟",
+	"fist": "✊", "flashlight": "🔦", "floppy_disk": "💾", "flying_saucer": "🛸", "fork_and_knife": "🍴",
+	"four_leaf_clover": "🍀", "gear": "⚙️", "gem": "💎", "ghost": "👻", "gift": "🎁",
+	"globe_with_meridians": "🌐", "goal_net": "🥅", "grey_exclamation": "❕",
+	"grey_question": "❔", "grimacing": "😬", "grin": "😁", "grinning": "😀", "hammer": "🔨",
+	"hammer_and_wrench": "🛠️", "hamster": "🐹", "hand": "✋", "handshake": "🤝", "hankey": "💩",
+	"hatching_chick": "🐣", "headphones": "🎧", "heart": "❤️", "heart_eyes": "😍",
+	"heavy_check_mark": "✔️", "heavy_minus_sign": "➖", "heavy_multiplication_x": "✖️",
+	"heavy_plus_sign": "➕", "hearts": "♥️", "hibiscus": "🌺", "honey_pot": "🍯", "hook": "🪝",
+	"hourglass": "⌛", "hourglass_flowing_sand": "⏳", "house": "🏠", "hugs": "🤗", "ice_cream": "🍨",
+	"inbox_tray": "📥", "information_source": "ℹ️", "innocent": "😇", "jack_o_lantern": "🎃",
+	"joy": "😂", "key": "🔑", "keyboard": "⌨️", "kissing_heart": "😘", "label": "🏷️",
+	"ladybug": "🐞", "laptop": "💻", "laughing": "😆", "leaves": "🍃", "ledger": "📒",
+	"left_right_arrow": "↔️", "link": "🔗", "lipstick": "💄", "lock": "🔒", "lock_with_ink_pen": "🔏",
+	"loudspeaker": "📢", "love_letter": "💌", "mag": "🔍", "mag_right": "🔎", "magic_wand": "🪄",
+	"mailbox": "📫", "map": "🗺️", "mega": "📣", "memo": "📝", "microphone": "🎤",
+	"microscope": "🔬", "money_with_wings": "💸", "moneybag": "💰", "monkey": "🐒",
+	"monocle_face": "🧐", "mortar_board": "🎓", "mountain": "⛰️", "mouse": "🐭", "muscle": "💪",
+	"mushroom": "🍄", "musical_note": "🎵", "nail_care": "💅", "necktie": "👔", "new": "🆕",
+	"newspaper": "📰", "no_entry": "⛔", "no_entry_sign": "🚫", "notebook": "📓", "notes": "🎶",
+	"nut_and_bolt": "🔩", "o": "⭕", "ok": "🆗", "ok_hand": "👌", "open_book": "📖",
+	"open_file_folder": "📂", "open_mouth": "😮", "outbox_tray": "📤", "package": "📦",
+	"page_facing_up": "📄", "page_with_curl": "📃", "paperclip": "📎", "partly_sunny": "⛅",
+	"pencil": "📝", "pencil2": "✏️", "penguin": "🐧", "pensive": "😔",
+	"phone": "☎️", "pig": "🐷", "pill": "💊", "pineapple": "🍍", "pizza": "🍕", "point_down": "👇",
+	"point_left": "👈", "point_right": "👉", "point_up": "☝️", "point_up_2": "👆", "poop": "💩",
+	"pray": "🙏", "pushpin": "📌", "puzzle_piece": "🧩", "question": "❓", "rabbit": "🐰",
+	"racing_car": "🏎️", "radioactive": "☢️", "rainbow": "🌈", "raised_hands": "🙌",
+	"raising_hand": "🙋", "recycle": "♻️", "red_circle": "🔴", "registered": "®️",
+	"relaxed": "☺️", "relieved": "😌", "repeat": "🔁", "rewind": "⏪", "ribbon": "🎀",
+	"robot": "🤖", "rocket": "🚀", "rofl": "🤣", "rose": "🌹", "rotating_light": "🚨",
+	"round_pushpin": "📍", "runner": "🏃", "running": "🏃", "sailboat": "⛵", "satellite": "📡",
+	"scissors": "✂️", "scream": "😱", "see_no_evil": "🙈", "seedling": "🌱", "shield": "🛡️",
+	"ship": "🚢", "shipit": "🐿️", "shrug": "🤷", "signal_strength": "📶", "skull": "💀",
+	"sleeping": "😴", "slightly_smiling_face": "🙂", "smile": "😄", "smiley": "😃",
+	"smirk": "😏", "snail": "🐌", "snake": "🐍", "snowflake": "❄️", "sob": "😭", "soon": "🔜",
+	"sparkles": "✨", "sparkling_heart": "💖", "speak_no_evil": "🙊", "speech_balloon": "💬",
+	"spider": "🕷️", "spider_web": "🕸️", "squirrel": "🐿️", "star": "⭐", "star2": "🌟",
+	"stars": "🌠", "stop_sign": "🛑", "stopwatch": "⏱️", "straight_ruler": "📏", "sun_with_face": "🌞",
+	"sunflower": "🌻", "sunglasses": "😎", "sunny": "☀️", "sweat_smile": "😅", "tada": "🎉",
+	"test_tube": "🧪", "thinking": "🤔", "thought_balloon": "💭", "thumbsdown": "👎",
+	"thumbsup": "👍", "ticket": "🎫", "timer_clock": "⏲️", "tm": "™️", "toolbox": "🧰",
+	"tools": "🛠️", "tophat": "🎩", "traffic_light": "🚥", "train": "🚋", "triangular_flag_on_post": "🚩",
+	"triangular_ruler": "📐", "trophy": "🏆", "truck": "🚚", "turtle": "🐢", "tv": "📺",
+	"twisted_rightwards_arrows": "🔀", "umbrella": "☔", "unamused": "😒", "unicorn": "🦄",
+	"unlock": "🔓", "up": "🆙", "v": "✌️", "vertical_traffic_light": "🚦", "vhs": "📼",
+	"video_camera": "📹", "video_game": "🎮", "volcano": "🌋", "warning": "⚠️", "watch": "⌚",
+	"wave": "👋", "whale": "🐳", "wheelchair": "♿", "white_check_mark": "✅", "white_circle": "⚪",
+	"wink": "😉", "wrench": "🔧", "x": "❌", "yellow_heart": "💛", "yum": "😋", "zap": "⚡",
+	"zzz": "💤",
+}
+
+var (
+	// shortcode matches :name: emoji shortcodes.
+	shortcode = regexp.MustCompile(`:([a-z0-9_+-]+):`)
+	// inlineCode matches inline code spans, which keep their shortcodes.
+	inlineCode = regexp.MustCompile("`[^`\n]*`")
+)
+
+// ExpandEmoji replaces GitHub emoji shortcodes such as :rocket: in markdown with the emoji they
+// stand for, as GitHub shows them. Unknown shortcodes, code blocks and inline code are left alone.
+func ExpandEmoji(markdown string) string {
+	if !strings.Contains(markdown, ":") {
+		return markdown
+	}
+	lines := strings.Split(markdown, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || !strings.Contains(line, ":") {
+			continue
+		}
+		// Expand between code spans only.
+		var b strings.Builder
+		last := 0
+		for _, span := range inlineCode.FindAllStringIndex(line, -1) {
+			b.WriteString(expandShortcodes(line[last:span[0]]))
+			b.WriteString(line[span[0]:span[1]])
+			last = span[1]
+		}
+		b.WriteString(expandShortcodes(line[last:]))
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func expandShortcodes(s string) string {
+	return shortcode.ReplaceAllStringFunc(s, func(code string) string {
+		if e, ok := emoji[code[1:len(code)-1]]; ok {
+			return e
+		}
+		return code
+	})
+}
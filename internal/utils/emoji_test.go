@@ -0,0 +1,15 @@
+package utils
+
+import "testing"
+
+func TestExpandEmoji(t *testing.T) {
+	md := ":rocket: Fast :sparkles: and :not_an_emoji:, at 12:30:00.\n\n" +
+		"Use `:tada:` for releases.\n\n" +
+		"```yaml\nicon: :bug:\n```\n"
+	want := "🚀 Fast ✨ and :not_an_emoji:, at 12:30:00.\n\n" +
+		"Use `:tada:` for releases.\n\n" +
+		"```yaml\nicon: :bug:\n```\n"
+	if got := ExpandEmoji(md); got != want {
+		t.Errorf("ExpandEmoji() = %q, want %q", got, want)
+	}
+}
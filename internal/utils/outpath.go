@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// windowsReserved are device names Windows refuses as file names, with or without an extension.
+var windowsReserved = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// EncodePath maps a slash-separated import path (or version) to a file path that is valid and
+// collision-free on case-insensitive Windows and macOS filesystems. Like the Go module cache,
+// upper-case letters become "!" and the lower-case letter ("Pkg" → "!pkg"); bytes outside
+// [a-z0-9._~+-], trailing dots and spaces, and the first byte of Windows device names (con, nul,
+// com1, …) are percent-encoded. Lower-case import paths are returned unchanged.
+func EncodePath(p string) string {
+	elems := strings.Split(p, "/")
+	for i, elem := range elems {
+		elems[i] = encodeElem(elem)
+	}
+	return strings.Join(elems, "/")
+}
+
+func encodeElem(elem string) string {
+	var b strings.Builder
+	for i := 0; i < len(elem); i++ {
+		c := elem[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte('!')
+			b.WriteByte(c + 'a' - 'A')
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '~', c == '+':
+			b.WriteByte(c)
+		case c == '.' && i < len(elem)-1:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	out := b.String()
+	base, _, _ := strings.Cut(out, ".")
+	if windowsReserved[base] {
+		out = fmt.Sprintf("%%%02X", out[0]) + out[1:]
+	}
+	return out
+}
+
+// DecodePath reverses EncodePath.
+func DecodePath(p string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '!':
+			if i+1 >= len(p) || p[i+1] < 'a' || p[i+1] > 'z' {
+				return "", fmt.Errorf("invalid case escape in %q", p)
+			}
+			b.WriteByte(p[i+1] - 'a' + 'A')
+			i++
+		case '%':
+			if i+2 >= len(p) {
+				return "", fmt.Errorf("truncated escape in %q", p)
+			}
+			v, err := strconv.ParseUint(p[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape in %q", p)
+			}
+			b.WriteByte(byte(v))
+			i += 2
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
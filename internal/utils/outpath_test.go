@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestEncodePath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"github.com/spf13/cobra", "github.com/spf13/cobra"},
+		{"github.com/PuerkitoBio/goquery", "github.com/!puerkito!bio/goquery"},
+		{"example.com/con/aux.go", "example.com/%63on/%61ux.go"},
+		{"example.com/a:b/trailing.", "example.com/a%3Ab/trailing%2E"},
+		{"v1.0.0+incompatible", "v1.0.0+incompatible"},
+	}
+	for _, tt := range tests {
+		got := EncodePath(tt.in)
+		if got != tt.want {
+			t.Errorf("EncodePath(%q): expected %q, got %q", tt.in, tt.want, got)
+		}
+		back, err := DecodePath(got)
+		if err != nil || back != tt.in {
+			t.Errorf("DecodePath(%q): expected %q, got %q (%v)", got, tt.in, back, err)
+		}
+	}
+
+	if EncodePath("example.com/Pkg") == EncodePath("example.com/pkg") {
+		t.Error("Expected paths differing only in case to encode differently")
+	}
+	if _, err := DecodePath("bad!Escape"); err == nil {
+		t.Error("Expected an invalid case escape to be rejected")
+	}
+}
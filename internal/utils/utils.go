@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"html"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // ConvertHTMLToMarkdown provides a simple, dependency-free HTML → Markdown conversion.
@@ -16,9 +18,6 @@ func ConvertHTMLToMarkdown(html string) string {
 	// Normalize newlines
 	html = strings.ReplaceAll(html, "\r\n", "\n")
 
-	// Unescape a few common entities early to avoid interfering with regex groups
-	html = unescapeCommonEntities(html)
-
 	// 1) Fenced code blocks: <pre><code ...>...</code></pre> → ```go ... ```
 	// Try to capture language if present in class attribute (e.g., class="language-go")
 	preCodeRe := regexp.MustCompile(`(?is)<pre>\s*<code([^>]*)>(.*?)</code>\s*</pre>`)
@@ -30,8 +29,7 @@ func ConvertHTMLToMarkdown(html string) string {
 		if strings.Contains(strings.ToLower(attrs), "language-go") {
 			lang = "go"
 		}
-		// Make sure inner HTML entities are unescaped inside code blocks
-		code = unescapeCommonEntities(code)
+		// Entities stay escaped until the end, so an escaped "<" in code is not taken for a tag
 		// Ensure code content does not contain stray backtick fences; leave as-is best-effort
 		if lang != "" {
 			return "```" + lang + "\n" + code + "\n```\n\n"
@@ -102,8 +100,8 @@ func ConvertHTMLToMarkdown(html string) string {
 	html = strings.ReplaceAll(html, "\n`\n", "\n```\n")
 	html = strings.ReplaceAll(html, "\n`\n\n", "\n```\n\n")
 
-	// 9) Final entity unescape and whitespace cleanup
-	html = unescapeCommonEntities(html)
+	// 9) Entity unescape, once the tags are gone, and whitespace cleanup
+	html = unescapeEntities(html)
 	for strings.Contains(html, "\n\n\n") {
 		html = strings.ReplaceAll(html, "\n\n\n", "\n\n")
 	}
@@ -136,20 +134,18 @@ func stripAllTags(s string) string {
 	return s
 }
 
-// unescapeCommonEntities replaces a subset of common HTML entities for readability.
-func unescapeCommonEntities(s string) string {
-	repl := map[string]string{
-		"&quot;": `"`,
-		"&#34;":  `"`,
-		"&apos;": `'`,
-		"&#39;":  `'`,
-		"&amp;":  "&",
-		"&lt;":   "<",
-		"&gt;":   ">",
-		"&nbsp;": " ",
-	}
-	for k, v := range repl {
-		s = strings.ReplaceAll(s, k, v)
+// unescapeEntities decodes every named and numeric HTML entity. Non-breaking spaces become plain
+// spaces, which markdown renders alike.
+func unescapeEntities(s string) string {
+	return strings.ReplaceAll(html.UnescapeString(s), "\u00a0", " ")
+}
+
+// EstimateTokens returns an approximate LLM token count for s.
+// It uses the common ~4 characters per token heuristic, which is close enough for budgeting English prose and Go code.
+func EstimateTokens(s string) int {
+	n := utf8.RuneCountInString(s)
+	if n == 0 {
+		return 0
 	}
-	return s
+	return (n + 3) / 4
 }
@@ -0,0 +1,15 @@
+package utils
+
+import "testing"
+
+func TestConvertHTMLToMarkdown_Entities(t *testing.T) {
+	html := `<p>Fast &mdash; really&hellip; &copy; 2024 &#8212; caf&eacute; &#x1F680;&nbsp;go</p>` +
+		`<p>Use &lt;T any&gt; &amp;&amp; <code>a &lt; b</code>, literally &amp;lt;</p>` +
+		`<pre><code class="language-go">func Map[T any](s []T) &lt;-chan T</code></pre>`
+	want := "Fast — really… © 2024 — café 🚀 go\n\n" +
+		"Use <T any> && `a < b`, literally &lt;\n\n" +
+		"```go\nfunc Map[T any](s []T) <-chan T\n```"
+	if got := ConvertHTMLToMarkdown(html); got != want {
+		t.Errorf("ConvertHTMLToMarkdown() = %q, want %q", got, want)
+	}
+}
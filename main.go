@@ -1,9 +1,13 @@
 package main
 
 import (
+	"os"
+
 	"github.com/moseye/docinator/cmd/docinator"
 )
 
 func main() {
-	docinator.Execute()
-}
\ No newline at end of file
+	if err := docinator.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,250 @@
+// Package api serves the cached documentation corpus over HTTP as JSON and markdown.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/storage"
+	"github.com/moseye/docinator/pkg/symdoc"
+)
+
+// ErrReadOnly is returned for requests that would scrape or change the corpus on a read-only
+// server.
+var ErrReadOnly = errors.New("read-only mirror: only the pre-warmed corpus is served")
+
+// Server serves the packages of a store:
+//
+//	GET    /packages                  cached packages, filtered by ?module= and ?tag=
+//	GET    /packages/{path}           one package as JSON, or markdown with ?format=markdown;
+//	                                  path may be pinned as path@version
+//	GET    /packages/{path}/symbols/{name}
+//	                                  one constant, variable, function, type or method
+//	                                  ("Command.Execute") with its examples and source link
+//	GET    /modules/{module}@{version}
+//	                                  every cached package of a module version with an index
+//	POST   /refresh/{path}            scrape the package again and store it
+//	DELETE /packages/{path}           remove the package from the store
+//	GET    /healthz                   whether the store is reachable
+//
+// A package missing from the store is scraped on demand with Load. With ReadOnly, nothing is
+// scraped or changed: missing packages are 404 and POST and DELETE answer 403. With Tokens, every
+// request but health checks needs one of them and is limited to its rate. Symbols come from the
+// store's symbol index when it implements storage.SymbolStore, without decoding the package, and
+// modules in one read when it implements storage.ModuleStore. Modules are never scraped on demand.
+type Server struct {
+	Store storage.Store
+	// Load scrapes importPath and stores the result; nil disables on-demand scraping.
+	Load     func(ctx context.Context, importPath string) (*models.Package, error)
+	ReadOnly bool
+	Tokens   []Token // see LoadTokens; none leaves the API open
+}
+
+// packageSummary is one entry of the package listing.
+type packageSummary struct {
+	ID         string    `json:"id"`
+	ImportPath string    `json:"import_path"`
+	Module     string    `json:"module,omitempty"`
+	Version    string    `json:"version,omitempty"`
+	ScrapedAt  time.Time `json:"scraped_at"`
+	Tags       []string  `json:"tags,omitempty"`
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /packages", s.list)
+	mux.HandleFunc("GET /packages/{path...}", s.get)
+	mux.HandleFunc("GET /modules/{module...}", s.getModule)
+	mux.HandleFunc("POST /refresh/{path...}", s.mutating(s.refresh))
+	mux.HandleFunc("DELETE /packages/{path...}", s.mutating(s.delete))
+	mux.HandleFunc("GET /healthz", s.healthz)
+	if len(s.Tokens) > 0 {
+		return authenticate(s.Tokens, mux)
+	}
+	return mux
+}
+
+// mutating guards a handler that scrapes or changes the corpus.
+func (s *Server) mutating(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.ReadOnly {
+			writeError(w, http.StatusForbidden, ErrReadOnly)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	module, tag := r.URL.Query().Get("module"), r.URL.Query().Get("tag")
+	out := []packageSummary{}
+	err := s.Store.ForEach(r.Context(), func(doc *models.Document) error {
+		pkg := doc.Package
+		if pkg == nil || module != "" && pkg.Module != module || tag != "" && !slices.Contains(doc.Tags, tag) {
+			return nil
+		}
+		out = append(out, packageSummary{ID: doc.ID, ImportPath: pkg.ImportPath, Module: pkg.Module, Version: pkg.Version, ScrapedAt: pkg.ScrapedAt, Tags: doc.Tags})
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.PathValue("path"), "/")
+	if path, name, ok := symbolPath(path); ok {
+		s.getSymbol(w, r, path, name)
+		return
+	}
+	pkg, status, err := s.lookup(r.Context(), path)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	s.writePackage(w, r, pkg)
+}
+
+// getSymbol writes the symbol name of the package at path, from the symbol index when the store
+// has one and the symbol is indexed, or else from the package.
+func (s *Server) getSymbol(w http.ResponseWriter, r *http.Request, path, name string) {
+	if index, ok := s.Store.(storage.SymbolStore); ok {
+		sym, err := index.GetSymbol(r.Context(), path, name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if sym != nil {
+			writeJSON(w, http.StatusOK, sym)
+			return
+		}
+	}
+	// Not indexed: a bare method name, or a document stored before the index existed.
+	pkg, status, err := s.lookup(r.Context(), path)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	sym, err := symdoc.Find(pkg, name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sym)
+}
+
+// getModule writes every cached package of a "module@version".
+func (s *Server) getModule(w http.ResponseWriter, r *http.Request) {
+	module, version, ok := strings.Cut(strings.Trim(r.PathValue("module"), "/"), "@")
+	if !ok || module == "" || version == "" {
+		writeError(w, http.StatusBadRequest, errors.New("want /modules/<module>@<version>"))
+		return
+	}
+	m, err := storage.GetModule(r.Context(), s.Store, module, version)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if m == nil {
+		writeError(w, http.StatusNotFound, errors.New(models.ModuleID(module, version)+" is not in the corpus"))
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+// lookup returns the package at path from the store, scraping it when it is missing and the
+// server may. On failure, it returns the HTTP status to answer with.
+func (s *Server) lookup(ctx context.Context, path string) (*models.Package, int, error) {
+	doc, err := s.Store.GetByID(ctx, path)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	switch {
+	case doc != nil && doc.Package != nil:
+		return doc.Package, http.StatusOK, nil
+	case s.ReadOnly || s.Load == nil:
+		return nil, http.StatusNotFound, errors.New(path + " is not in the corpus")
+	}
+	pkg, err := s.Load(ctx, path)
+	if err != nil {
+		return nil, http.StatusBadGateway, err
+	}
+	return pkg, http.StatusOK, nil
+}
+
+// symbolPath splits "<path>/symbols/<name>" into the package path and the symbol. Only exported
+// names are symbols, so packages with a "symbols" path element stay reachable.
+func symbolPath(path string) (string, string, bool) {
+	i := strings.LastIndex(path, "/symbols/")
+	if i <= 0 {
+		return "", "", false
+	}
+	name := path[i+len("/symbols/"):]
+	if name == "" || name[0] < 'A' || name[0] > 'Z' || strings.Contains(name, "/") {
+		return "", "", false
+	}
+	return path[:i], name, true
+}
+
+func (s *Server) refresh(w http.ResponseWriter, r *http.Request) {
+	if s.Load == nil {
+		writeError(w, http.StatusForbidden, ErrReadOnly)
+		return
+	}
+	pkg, err := s.Load(r.Context(), strings.Trim(r.PathValue("path"), "/"))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	s.writePackage(w, r, pkg)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.Delete(r.Context(), strings.Trim(r.PathValue("path"), "/")); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	body := map[string]any{"status": "ok", "read_only": s.ReadOnly}
+	if !s.Store.Enabled() {
+		status = http.StatusServiceUnavailable
+		body["status"] = "store disabled"
+	}
+	writeJSON(w, status, body)
+}
+
+// writePackage writes pkg as JSON, or as markdown with ?format=markdown.
+func (s *Server) writePackage(w http.ResponseWriter, r *http.Request, pkg *models.Package) {
+	if r.URL.Query().Get("format") == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(markdown.PackageToMarkdown(pkg)))
+		return
+	}
+	writeJSON(w, http.StatusOK, pkg)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/symdoc"
+)
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	cobra := &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.9.1"}
+	if err := store.Upsert(ctx, &models.Document{ID: cobra.ImportPath, Package: cobra}); err != nil {
+		t.Fatal(err)
+	}
+	scraped := 0
+	server := &Server{Store: store, Load: func(ctx context.Context, importPath string) (*models.Package, error) {
+		scraped++
+		pkg := &models.Package{Name: "pflag", ImportPath: importPath}
+		return pkg, store.Upsert(ctx, &models.Document{ID: importPath, Package: pkg})
+	}}
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	rec := do("GET", "/packages/github.com/spf13/cobra")
+	var got models.Package
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &got) != nil || got.Version != "v1.9.1" {
+		t.Errorf("Expected the cached package, got %d %s", rec.Code, rec.Body)
+	}
+	if rec = do("GET", "/packages/github.com/spf13/cobra?format=markdown"); !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/markdown") {
+		t.Errorf("Expected markdown, got %s", rec.Header().Get("Content-Type"))
+	}
+	if rec = do("GET", "/packages/github.com/spf13/pflag"); rec.Code != http.StatusOK || scraped != 1 {
+		t.Errorf("Expected a missing package to be scraped on demand, got %d after %d scrapes", rec.Code, scraped)
+	}
+	var list []packageSummary
+	if rec = do("GET", "/packages?module=github.com/spf13/cobra"); json.Unmarshal(rec.Body.Bytes(), &list) != nil || len(list) != 1 {
+		t.Errorf("Expected one package of the module, got %s", rec.Body)
+	}
+
+	server.ReadOnly = true
+	if rec = do("GET", "/packages/golang.org/x/mod"); rec.Code != http.StatusNotFound || scraped != 1 {
+		t.Errorf("Expected a read-only miss to be 404 without scraping, got %d after %d scrapes", rec.Code, scraped)
+	}
+	for _, req := range [][2]string{{"POST", "/refresh/github.com/spf13/cobra"}, {"DELETE", "/packages/github.com/spf13/cobra"}} {
+		if rec = do(req[0], req[1]); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected %s %s to be forbidden when read-only, got %d", req[0], req[1], rec.Code)
+		}
+	}
+	if rec = do("GET", "/packages/github.com/spf13/cobra"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the cached package to stay readable, got %d", rec.Code)
+	}
+
+	server.ReadOnly = false
+	if rec = do("DELETE", "/packages/github.com/spf13/cobra"); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected the delete to succeed, got %d", rec.Code)
+	}
+	if doc, _ := store.GetByID(ctx, cobra.ImportPath); doc != nil {
+		t.Error("Expected the package to be deleted")
+	}
+}
+
+// indexedStore serves symbols from a fixed index, counting the lookups.
+type indexedStore struct {
+	*memstore.Store
+	symbols map[string]*symdoc.Snippet
+	lookups int
+}
+
+func (s *indexedStore) GetSymbol(ctx context.Context, id, name string) (*symdoc.Snippet, error) {
+	s.lookups++
+	return s.symbols[id+"#"+name], nil
+}
+
+func TestServerSymbols(t *testing.T) {
+	ctx := context.Background()
+	store := &indexedStore{Store: memstore.New(), symbols: map[string]*symdoc.Snippet{
+		"github.com/spf13/cobra#Command.Execute": {ImportPath: "github.com/spf13/cobra", Kind: "method", Name: "Command.Execute"},
+	}}
+	cobra := &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra", Functions: []models.Function{{
+		Name:      "CheckErr",
+		Signature: "func CheckErr(msg interface{})",
+		SourceURL: "https://github.com/spf13/cobra/blob/v1.9.1/cobra.go#L221",
+	}}}
+	if err := store.Upsert(ctx, &models.Document{ID: cobra.ImportPath, Package: cobra}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{Store: store, ReadOnly: true}
+	get := func(target string) (*httptest.ResponseRecorder, symdoc.Snippet) {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		var sym symdoc.Snippet
+		json.Unmarshal(rec.Body.Bytes(), &sym)
+		return rec, sym
+	}
+
+	if rec, sym := get("/packages/github.com/spf13/cobra/symbols/Command.Execute"); rec.Code != http.StatusOK || sym.Kind != "method" || store.lookups != 1 {
+		t.Errorf("Expected the method from the index, got %d %s", rec.Code, rec.Body)
+	}
+	if rec, sym := get("/packages/github.com/spf13/cobra/symbols/CheckErr"); rec.Code != http.StatusOK || sym.SourceURL == "" {
+		t.Errorf("Expected an unindexed function from the package, got %d %s", rec.Code, rec.Body)
+	}
+	if rec, _ := get("/packages/github.com/spf13/cobra/symbols/Missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing symbol to be 404, got %d", rec.Code)
+	}
+	if rec, _ := get("/packages/golang.org/x/mod/symbols/Version"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected a symbol of a missing package to be 404, got %d", rec.Code)
+	}
+	for path, want := range map[string]bool{
+		"github.com/spf13/cobra/symbols/Command": true,
+		"example.com/symbols/table":              false,
+		"example.com/symbols":                    false,
+	} {
+		if _, _, ok := symbolPath(path); ok != want {
+			t.Errorf("symbolPath(%q) = %v, want %v", path, ok, want)
+		}
+	}
+}
+
+func TestServerModules(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	for _, pkg := range []*models.Package{
+		{Name: "doc", ImportPath: "github.com/spf13/cobra/doc", Module: "github.com/spf13/cobra", Version: "v1.9.1"},
+		{Name: "cobra", ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.9.1"},
+		{Name: "pflag", ImportPath: "github.com/spf13/pflag", Module: "github.com/spf13/pflag", Version: "v1.0.6"},
+	} {
+		if err := store.Upsert(ctx, &models.Document{ID: pkg.ImportPath, Package: pkg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := &Server{Store: store}
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	rec := get("/modules/github.com/spf13/cobra@v1.9.1")
+	var m models.ModuleDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected the module, got %d %s", rec.Code, rec.Body)
+	}
+	if len(m.Packages) != 2 || len(m.Index) != 2 || m.Index[0].Name != "cobra" || m.Index[1].Name != "doc" {
+		t.Errorf("Expected cobra and cobra/doc, root first, got %+v", m.Index)
+	}
+	if rec := get("/modules/github.com/spf13/cobra@v1.8.0"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected an uncached version to be 404, got %d", rec.Code)
+	}
+	if rec := get("/modules/github.com/spf13/cobra"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a module without version to be 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/moseye/docinator/pkg/ratelimit"
+	"gopkg.in/yaml.v3"
+)
+
+// Token is an API token allowed to use the server, as listed in a tokens file:
+//
+//	tokens:
+//	  - name: team-a
+//	    sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	    rate: 2     # requests per second; 0 for no limit
+//	    burst: 10
+//	  - name: ci
+//	    token: plain-text-token
+type Token struct {
+	Name   string  `yaml:"name"`
+	Token  string  `yaml:"token,omitempty"`  // the token itself
+	SHA256 string  `yaml:"sha256,omitempty"` // or its hex SHA-256, to keep the file free of secrets
+	Rate   float64 `yaml:"rate,omitempty"`
+	Burst  int     `yaml:"burst,omitempty"` // defaults to max(1, rate)
+}
+
+// LoadTokens reads and validates the YAML tokens file at path.
+func LoadTokens(path string) ([]Token, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tokens []Token `yaml:"tokens"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(file.Tokens) == 0 {
+		return nil, fmt.Errorf("%s lists no tokens", path)
+	}
+	names := map[string]bool{}
+	for _, t := range file.Tokens {
+		switch {
+		case t.Name == "":
+			return nil, fmt.Errorf("%s: a token has no name", path)
+		case names[t.Name]:
+			return nil, fmt.Errorf("%s: token %q is listed twice", path, t.Name)
+		case (t.Token == "") == (t.SHA256 == ""):
+			return nil, fmt.Errorf("%s: token %q needs exactly one of token or sha256", path, t.Name)
+		case t.Rate < 0:
+			return nil, fmt.Errorf("%s: token %q has a negative rate", path, t.Name)
+		}
+		if _, err := t.hash(); err != nil {
+			return nil, fmt.Errorf("%s: token %q: %w", path, t.Name, err)
+		}
+		names[t.Name] = true
+	}
+	return file.Tokens, nil
+}
+
+// hash returns the SHA-256 of the token.
+func (t Token) hash() ([]byte, error) {
+	if t.Token != "" {
+		sum := sha256.Sum256([]byte(t.Token))
+		return sum[:], nil
+	}
+	sum, err := hex.DecodeString(t.SHA256)
+	if err != nil || len(sum) != sha256.Size {
+		return nil, errors.New("sha256 must be 64 hex digits")
+	}
+	return sum, nil
+}
+
+// client is an authenticated token and its rate limit.
+type client struct {
+	name    string
+	hash    []byte
+	limiter *ratelimit.Local // nil when unlimited
+}
+
+// authenticate requires a valid token, sent as "Authorization: Bearer <token>", on every request
+// but health checks, and limits each token to its own rate.
+func authenticate(tokens []Token, next http.Handler) http.Handler {
+	clients := make([]*client, 0, len(tokens))
+	for _, t := range tokens {
+		hash, _ := t.hash() // validated by LoadTokens
+		c := &client{name: t.Name, hash: hash}
+		if t.Rate > 0 {
+			burst := t.Burst
+			if burst <= 0 {
+				burst = int(math.Ceil(t.Rate))
+			}
+			c.limiter = ratelimit.NewLocal(t.Rate, burst)
+		}
+		clients = append(clients, c)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		c := findClient(clients, r.Header.Get("Authorization"))
+		if c == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="docinator"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or unknown API token"))
+			return
+		}
+		if c.limiter != nil {
+			if wait := c.limiter.Allow(); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit of token %s exceeded", c.name))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// findClient returns the client whose token is in the Authorization header, comparing hashes in
+// constant time, or nil.
+func findClient(clients []*client, header string) *client {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	var found *client
+	for _, c := range clients {
+		if subtle.ConstantTimeCompare(sum[:], c.hash) == 1 {
+			found = c
+		}
+	}
+	return found
+}
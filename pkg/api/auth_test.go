@@ -0,0 +1,79 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+)
+
+func TestLoadTokens(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "tokens.yaml")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	sum := sha256.Sum256([]byte("secret"))
+	tokens, err := LoadTokens(write("tokens:\n  - name: a\n    sha256: " + hex.EncodeToString(sum[:]) + "\n    rate: 2\n  - name: b\n    token: other\n"))
+	if err != nil || len(tokens) != 2 || tokens[0].Rate != 2 {
+		t.Fatalf("Expected two tokens, got %+v, %v", tokens, err)
+	}
+	for content, want := range map[string]string{
+		"tokens:\n  - token: x\n":                           "no name",
+		"tokens:\n  - name: a\n":                            "exactly one",
+		"tokens:\n  - name: a\n    sha256: abc\n":           "64 hex digits",
+		"tokens:\n  - name: a\n    token: x\n    oops: 1\n": "oops",
+		"tokens: []\n":                                      "no tokens",
+	} {
+		if _, err := LoadTokens(write(content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error mentioning %q for %q, got %v", want, content, err)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := &Server{Store: memstore.New(), ReadOnly: true, Tokens: []Token{
+		{Name: "limited", Token: "slow", Rate: 0.001, Burst: 1},
+		{Name: "open", Token: "fast"},
+	}}
+	handler := server.Handler()
+	do := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("/packages", ""); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("Expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := do("/packages", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown token, got %d", rec.Code)
+	}
+	if rec := do("/healthz", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected health checks without a token, got %d", rec.Code)
+	}
+	if rec := do("/packages", "slow"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the first request of a limited token to pass, got %d", rec.Code)
+	}
+	if rec := do("/packages", "slow"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After once the burst is spent, got %d", rec.Code)
+	}
+	for range 3 {
+		if rec := do("/packages", "fast"); rec.Code != http.StatusOK {
+			t.Errorf("Expected an unlimited token not to be throttled by another's limit, got %d", rec.Code)
+		}
+	}
+}
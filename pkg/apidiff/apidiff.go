@@ -0,0 +1,315 @@
+// Package apidiff compares the exported API of two versions of a package symbol by symbol, for
+// upgrade changelogs: which constants, variables, functions, types and methods were added,
+// removed, renamed or changed.
+package apidiff
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Symbol is an exported declaration of a package.
+type Symbol struct {
+	Kind      string // const, var, func, type or method
+	Name      string // qualified within the package, e.g. "Command.Execute" for methods
+	Signature string // declaration, definition or signature
+	Doc       string // doc comment
+}
+
+// Rename is a symbol removed in favor of an added one of the same kind with the same signature or
+// doc comment, apart from the name.
+type Rename struct {
+	From, To Symbol
+}
+
+// Change is a symbol kept under its name whose signature differs.
+type Change struct {
+	From, To Symbol
+}
+
+// Diff is how the API of a package changed between two versions. Every list is sorted by kind,
+// then name.
+type Diff struct {
+	Added   []Symbol
+	Removed []Symbol
+	Renamed []Rename
+	Changed []Change
+}
+
+// Empty reports whether the API did not change.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Renamed) == 0 && len(d.Changed) == 0
+}
+
+// Symbols lists the exported constants, variables, functions, types and methods of pkg.
+func Symbols(pkg *models.Package) []Symbol {
+	var out []Symbol
+	for _, c := range pkg.Constants {
+		sig := strings.TrimSpace("const " + c.Name + " " + c.Type)
+		if c.Value != "" {
+			sig += " = " + c.Value
+		}
+		out = append(out, Symbol{Kind: "const", Name: c.Name, Signature: sig, Doc: c.Description})
+	}
+	for _, v := range pkg.Variables {
+		out = append(out, Symbol{Kind: "var", Name: v.Name, Signature: strings.TrimSpace("var " + v.Name + " " + v.Type), Doc: v.Description})
+	}
+	for _, f := range pkg.Functions {
+		out = append(out, Symbol{Kind: "func", Name: f.Name, Signature: f.Signature, Doc: f.Description})
+	}
+	for _, t := range pkg.Types {
+		out = append(out, Symbol{Kind: "type", Name: t.Name, Signature: t.Definition, Doc: t.Description})
+		for _, m := range t.Methods {
+			name := m.Name
+			if !strings.Contains(name, ".") {
+				name = t.Name + "." + name
+			}
+			out = append(out, Symbol{Kind: "method", Name: name, Signature: m.Signature, Doc: m.Description})
+		}
+	}
+	return out
+}
+
+// Compare returns how the API of pkg changed from old to cur. A removed and an added symbol are
+// reported as a rename when they are of the same kind and, with their names blanked out, have the
+// same signature or the same doc comment, and neither matches any other symbol that way. Methods
+// also need the same receiver type, or one renamed in the same way; types are paired first so
+// the methods of a renamed type follow it.
+func Compare(old, cur *models.Package) Diff {
+	before, after := index(Symbols(old)), index(Symbols(cur))
+	var d Diff
+	var removed, added []Symbol
+	for key, s := range before {
+		t, ok := after[key]
+		switch {
+		case !ok:
+			removed = append(removed, s)
+		case t.Signature != s.Signature:
+			d.Changed = append(d.Changed, Change{From: s, To: t})
+		}
+	}
+	for key, t := range after {
+		if _, ok := before[key]; !ok {
+			added = append(added, t)
+		}
+	}
+	sortSymbols(removed)
+	sortSymbols(added)
+
+	types := map[string]string{} // renamed types, old name → new name
+	for _, fingerprint := range []func(Symbol) string{signatureOf, docOf} {
+		var pairs []Rename
+		pairs, removed, added = pair(removed, added, []string{"type"}, fingerprint, types)
+		for _, p := range pairs {
+			types[p.From.Name] = p.To.Name
+		}
+		d.Renamed = append(d.Renamed, pairs...)
+	}
+	var pairs []Rename
+	pairs, removed, added = pairMethods(removed, added, types)
+	d.Renamed = append(d.Renamed, pairs...)
+	for _, fingerprint := range []func(Symbol) string{signatureOf, docOf} {
+		pairs, removed, added = pair(removed, added, []string{"const", "var", "func", "method"}, fingerprint, types)
+		d.Renamed = append(d.Renamed, pairs...)
+	}
+	d.Removed, d.Added = removed, added
+	sort.Slice(d.Renamed, func(i, j int) bool { return less(d.Renamed[i].From, d.Renamed[j].From) })
+	sort.Slice(d.Changed, func(i, j int) bool { return less(d.Changed[i].From, d.Changed[j].From) })
+	return d
+}
+
+// pair matches removed and added symbols of kinds whose fingerprints are equal, non-empty and
+// unique on both sides, and returns the pairs and the symbols left over.
+func pair(removed, added []Symbol, kinds []string, fingerprint func(Symbol) string, types map[string]string) ([]Rename, []Symbol, []Symbol) {
+	key := func(s Symbol) string {
+		if !slices.Contains(kinds, s.Kind) {
+			return ""
+		}
+		fp := fingerprint(s)
+		if fp == "" {
+			return ""
+		}
+		return s.Kind + "\x00" + fp
+	}
+	from, to := map[string][]int{}, map[string][]int{}
+	for i, s := range removed {
+		if k := key(s); k != "" {
+			from[k] = append(from[k], i)
+		}
+	}
+	for i, s := range added {
+		if k := key(s); k != "" {
+			to[k] = append(to[k], i)
+		}
+	}
+	var pairs []Rename
+	gone, taken := map[int]bool{}, map[int]bool{}
+	for k, is := range from {
+		js := to[k]
+		if len(is) != 1 || len(js) != 1 {
+			continue
+		}
+		r := Rename{From: removed[is[0]], To: added[js[0]]}
+		if r.From.Kind == "method" {
+			oldType, _, _ := strings.Cut(r.From.Name, ".")
+			newType, _, _ := strings.Cut(r.To.Name, ".")
+			if oldType != newType && types[oldType] != newType {
+				continue
+			}
+		}
+		pairs = append(pairs, r)
+		gone[is[0]], taken[js[0]] = true, true
+	}
+	return pairs, without(removed, gone), without(added, taken)
+}
+
+// pairMethods pairs the removed methods of renamed types with the added methods of the same name
+// of their new types, and returns the pairs and the symbols left over.
+func pairMethods(removed, added []Symbol, types map[string]string) ([]Rename, []Symbol, []Symbol) {
+	if len(types) == 0 {
+		return nil, removed, added
+	}
+	methods := map[string]int{}
+	for j, s := range added {
+		if s.Kind == "method" {
+			methods[s.Name] = j
+		}
+	}
+	var pairs []Rename
+	gone, taken := map[int]bool{}, map[int]bool{}
+	for i, s := range removed {
+		typ, name, _ := strings.Cut(s.Name, ".")
+		if s.Kind != "method" || types[typ] == "" {
+			continue
+		}
+		if j, ok := methods[types[typ]+"."+name]; ok {
+			pairs = append(pairs, Rename{From: s, To: added[j]})
+			gone[i], taken[j] = true, true
+		}
+	}
+	return pairs, without(removed, gone), without(added, taken)
+}
+
+// signatureOf returns the signature of s with its name, and a method's receiver type, blanked out.
+func signatureOf(s Symbol) string {
+	return blankNames(s, s.Signature)
+}
+
+// docOf returns the doc comment of s with its name blanked out, as doc comments begin with it.
+func docOf(s Symbol) string {
+	return blankNames(s, strings.Join(strings.Fields(s.Doc), " "))
+}
+
+// blankNames replaces the names of s in text as whole words.
+func blankNames(s Symbol, text string) string {
+	if text == "" {
+		return ""
+	}
+	typ, name, ok := strings.Cut(s.Name, ".")
+	if !ok {
+		name, typ = typ, ""
+	}
+	text = word(name).ReplaceAllString(text, "${1}_${2}")
+	if typ != "" {
+		text = word(typ).ReplaceAllString(text, "${1}T${2}")
+	}
+	return text
+}
+
+// word matches name as a whole identifier.
+func word(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^\pL\pN_])` + regexp.QuoteMeta(name) + `($|[^\pL\pN_])`)
+}
+
+// WriteMarkdown writes d as a markdown changelog titled title, one section per kind of change.
+func (d Diff) WriteMarkdown(w io.Writer, title string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", title)
+	if d.Empty() {
+		b.WriteString("No changes to the exported API.\n")
+	}
+	if len(d.Renamed) > 0 {
+		b.WriteString("### Renamed\n\n")
+		for _, r := range d.Renamed {
+			fmt.Fprintf(&b, "- %s `%s` → `%s`\n", r.From.Kind, r.From.Name, r.To.Name)
+		}
+		b.WriteString("\n")
+	}
+	writeSymbols(&b, "Added", d.Added)
+	writeSymbols(&b, "Removed", d.Removed)
+	if len(d.Changed) > 0 {
+		b.WriteString("### Changed\n\n")
+		for _, c := range d.Changed {
+			from, to := firstLine(c.From.Signature), firstLine(c.To.Signature)
+			if from == to {
+				// A type whose fields or methods changed; its first line says nothing.
+				fmt.Fprintf(&b, "- %s `%s`: definition changed\n", c.From.Kind, c.From.Name)
+				continue
+			}
+			fmt.Fprintf(&b, "- %s `%s`: `%s` → `%s`\n", c.From.Kind, c.From.Name, from, to)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, strings.TrimSuffix(b.String(), "\n"))
+	return err
+}
+
+// writeSymbols writes a section listing syms with their signatures, if there are any.
+func writeSymbols(b *strings.Builder, heading string, syms []Symbol) {
+	if len(syms) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "### %s\n\n", heading)
+	for _, s := range syms {
+		if sig := firstLine(s.Signature); sig != "" {
+			fmt.Fprintf(b, "- %s `%s`: `%s`\n", s.Kind, s.Name, sig)
+		} else {
+			fmt.Fprintf(b, "- %s `%s`\n", s.Kind, s.Name)
+		}
+	}
+	b.WriteString("\n")
+}
+
+// index keys syms by kind and name. Grouped declarations repeat a symbol; the first one counts.
+func index(syms []Symbol) map[string]Symbol {
+	m := make(map[string]Symbol, len(syms))
+	for _, s := range syms {
+		key := s.Kind + " " + s.Name
+		if _, ok := m[key]; !ok && s.Name != "" {
+			m[key] = s
+		}
+	}
+	return m
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+func sortSymbols(syms []Symbol) {
+	sort.Slice(syms, func(i, j int) bool { return less(syms[i], syms[j]) })
+}
+
+func less(a, b Symbol) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	return a.Name < b.Name
+}
+
+func without(syms []Symbol, drop map[int]bool) []Symbol {
+	var out []Symbol
+	for i, s := range syms {
+		if !drop[i] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package apidiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestCompare(t *testing.T) {
+	old := &models.Package{
+		Functions: []models.Function{
+			{Name: "NewClient", Signature: "func NewClient(addr string) *Client", Description: "NewClient connects to addr."},
+			{Name: "Dial", Signature: "func Dial(addr string) (*Conn, error)"},
+			{Name: "Close", Signature: "func Close() error", Description: "Close releases the pool."},
+			{Name: "Flush", Signature: "func Flush() error"},
+			{Name: "Reset", Signature: "func Reset() error"},
+		},
+		Types: []models.Type{{Name: "Options", Definition: "type Options struct {\n\tAddr string\n}",
+			Methods: []models.Function{
+				{Name: "Validate", Signature: "func (o *Options) Validate() error"},
+				{Name: "Options.Clone", Signature: "func (o *Options) Clone() *Options"},
+			}}},
+		Constants: []models.Constant{{Name: "DefaultPort", Type: "int", Value: "6379"}},
+	}
+	cur := &models.Package{
+		Functions: []models.Function{
+			{Name: "New", Signature: "func New(addr string) *Client", Description: "New connects to addr."},
+			{Name: "Dial", Signature: "func Dial(ctx context.Context, addr string) (*Conn, error)"},
+			{Name: "Shutdown", Signature: "func Shutdown(ctx context.Context) error", Description: "Shutdown releases the pool."},
+			{Name: "Sync", Signature: "func Sync() error"},
+			{Name: "Clear", Signature: "func Clear() error"},
+		},
+		Types: []models.Type{{Name: "Config", Definition: "type Config struct {\n\tAddr string\n}",
+			Methods: []models.Function{
+				{Name: "Validate", Signature: "func (c *Config) Validate() error"},
+				{Name: "Clone", Signature: "func (c *Config) Clone() *Config"},
+			}}},
+		Constants: []models.Constant{{Name: "Port", Type: "int", Value: "6379"}},
+	}
+
+	d := Compare(old, cur)
+	var renames []string
+	for _, r := range d.Renamed {
+		renames = append(renames, r.From.Kind+" "+r.From.Name+"→"+r.To.Name)
+	}
+	want := "const DefaultPort→Port, func Close→Shutdown, func NewClient→New, method Options.Clone→Config.Clone, method Options.Validate→Config.Validate, type Options→Config"
+	if got := strings.Join(renames, ", "); got != want {
+		t.Errorf("Expected renames %s, got %s", want, got)
+	}
+	// Flush and Reset share their signature with Sync and Clear: no way to tell which is which.
+	if len(d.Removed) != 2 || d.Removed[0].Name != "Flush" || d.Removed[1].Name != "Reset" ||
+		len(d.Added) != 2 || d.Added[0].Name != "Clear" || d.Added[1].Name != "Sync" {
+		t.Errorf("Expected ambiguous symbols added and removed, got %+v and %+v", d.Added, d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].To.Name != "Dial" {
+		t.Errorf("Expected Dial changed, got %+v", d.Changed)
+	}
+
+	var b strings.Builder
+	if err := d.WriteMarkdown(&b, "example.com/redis v1.0.0 → v2.0.0"); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	for _, want := range []string{
+		"## example.com/redis v1.0.0 → v2.0.0\n\n### Renamed\n\n- const `DefaultPort` → `Port`\n",
+		"### Added\n\n- func `Clear`: `func Clear() error`\n",
+		"### Changed\n\n- func `Dial`: `func Dial(addr string) (*Conn, error)` → `func Dial(ctx context.Context, addr string) (*Conn, error)`\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected the changelog to contain %q, got:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	pkg := &models.Package{Functions: []models.Function{{Name: "Do", Signature: "func Do()"}}}
+	d := Compare(pkg, pkg)
+	if !d.Empty() {
+		t.Errorf("Expected no changes, got %+v", d)
+	}
+}
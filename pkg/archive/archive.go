@@ -0,0 +1,264 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestFile is the name of the manifest added to the root of every archive.
+const ManifestFile = "manifest.json"
+
+// Manifest describes the contents of an archive.
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	Files     []File    `json:"files"`
+	Packages  []Package `json:"packages,omitempty"`
+}
+
+// File is one regular file in the archive.
+type File struct {
+	Path string `json:"path"` // slash-separated, relative to the archive root
+	Size int64  `json:"size"`
+}
+
+// Package maps an import path to its page in the archive.
+type Package struct {
+	ImportPath string `json:"import_path"`
+	Version    string `json:"version,omitempty"`
+	Path       string `json:"path"`
+}
+
+// Formats lists the archive file extensions Create understands.
+var Formats = []string{".tar.gz", ".tgz", ".zip"}
+
+// Create writes every file below dir into a new archive at dest, whose format is chosen by its
+// extension (see Formats), and adds a manifest listing the files and packages. Symlinks are kept
+// in tarballs and replaced by copies of their target in zip files. dest itself is skipped when it
+// lies inside dir.
+func Create(dest, dir string, packages []Package) (*Manifest, error) {
+	var w writer
+	switch {
+	case strings.HasSuffix(dest, ".tar.gz"), strings.HasSuffix(dest, ".tgz"):
+		w = &tarWriter{}
+	case strings.HasSuffix(dest, ".zip"):
+		w = &zipWriter{}
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q (use %s)", filepath.Base(dest), strings.Join(Formats, ", "))
+	}
+
+	files, err := collect(dir, dest)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{CreatedAt: time.Now().UTC(), Packages: packages}
+	for _, f := range files {
+		if f.link == "" {
+			m.Files = append(m.Files, File{Path: f.name, Size: f.info.Size()})
+		}
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return nil, err
+	}
+	if err := write(w, out, files, manifest, m.CreatedAt); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest)
+		return nil, err
+	}
+	return m, nil
+}
+
+func write(w writer, out io.Writer, files []entry, manifest []byte, created time.Time) error {
+	if err := w.open(out); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := w.add(f); err != nil {
+			return fmt.Errorf("add %s: %w", f.name, err)
+		}
+	}
+	if err := w.addBytes(ManifestFile, manifest, created); err != nil {
+		return err
+	}
+	return w.close()
+}
+
+// entry is a file or symlink to archive.
+type entry struct {
+	name string // slash-separated archive path
+	path string // path on disk
+	info fs.FileInfo
+	link string // symlink target; empty for regular files
+}
+
+// collect returns the regular files and symlinks below dir sorted by name, skipping dest.
+func collect(dir, dest string) ([]entry, error) {
+	absDest, _ := filepath.Abs(dest)
+	var files []entry
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if abs, _ := filepath.Abs(p); abs == absDest {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		e := entry{name: filepath.ToSlash(rel), path: p, info: info}
+		if d.Type()&fs.ModeSymlink != 0 {
+			if e.link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		files = append(files, e)
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, err
+}
+
+type writer interface {
+	open(w io.Writer) error
+	add(e entry) error
+	addBytes(name string, data []byte, modTime time.Time) error
+	close() error
+}
+
+type tarWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (t *tarWriter) open(w io.Writer) error {
+	t.gz = gzip.NewWriter(w)
+	t.tw = tar.NewWriter(t.gz)
+	return nil
+}
+
+func (t *tarWriter) add(e entry) error {
+	hdr, err := tar.FileInfoHeader(e.info, e.link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = e.name
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if e.link != "" {
+		return nil
+	}
+	return copyFile(t.tw, e.path)
+}
+
+func (t *tarWriter) addBytes(name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := t.tw.Write(data)
+	return err
+}
+
+func (t *tarWriter) close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	return t.gz.Close()
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (z *zipWriter) open(w io.Writer) error {
+	z.zw = zip.NewWriter(w)
+	return nil
+}
+
+func (z *zipWriter) add(e entry) error {
+	if e.link == "" {
+		return z.addFile(e.name, e.path, e.info)
+	}
+	// Zip has no portable symlinks; store the files the link points at under its name.
+	target := filepath.Join(filepath.Dir(e.path), e.link)
+	return filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(target, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name := e.name
+		if rel != "." {
+			name = path.Join(e.name, filepath.ToSlash(rel))
+		}
+		return z.addFile(name, p, info)
+	})
+}
+
+func (z *zipWriter) addFile(name, p string, info fs.FileInfo) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name, hdr.Method = name, zip.Deflate
+	w, err := z.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	return copyFile(w, p)
+}
+
+func (z *zipWriter) addBytes(name string, data []byte, modTime time.Time) error {
+	w, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (z *zipWriter) close() error {
+	return z.zw.Close()
+}
+
+func copyFile(w io.Writer, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,106 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func testDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"github.com/spf13/cobra/v1.9.1/cobra.md": "# cobra\n",
+		"github.com/spf13/cobra.md":              "# cobra latest\n",
+		"search-index.json":                      "[]",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte(content), 0644)
+	}
+	if err := os.Symlink("v1.9.1", filepath.Join(dir, "github.com/spf13/cobra/latest")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	return dir
+}
+
+func TestCreate_TarGz(t *testing.T) {
+	dir := testDir(t)
+	dest := filepath.Join(dir, "docs.tar.gz") // inside dir: must not archive itself
+	pkgs := []Package{{ImportPath: "github.com/spf13/cobra", Path: "github.com/spf13/cobra.md"}}
+
+	m, err := Create(dest, dir, pkgs)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(m.Files) != 3 {
+		t.Errorf("Expected 3 regular files in the manifest, got %+v", m.Files)
+	}
+
+	f, _ := os.Open(dest)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected a gzip stream: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	got := map[string]string{}
+	var manifest Manifest
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Reading tar failed: %v", err)
+		}
+		got[hdr.Name] = hdr.Linkname
+		if hdr.Name == ManifestFile {
+			json.NewDecoder(tr).Decode(&manifest)
+		}
+	}
+	if got["github.com/spf13/cobra/latest"] != "v1.9.1" {
+		t.Errorf("Expected the latest symlink to be kept, got %v", got)
+	}
+	if _, ok := got["docs.tar.gz"]; ok {
+		t.Error("Expected the archive not to contain itself")
+	}
+	if len(manifest.Packages) != 1 || manifest.Packages[0].ImportPath != "github.com/spf13/cobra" {
+		t.Errorf("Expected the manifest to list the package, got %+v", manifest)
+	}
+}
+
+func TestCreate_Zip(t *testing.T) {
+	dir := testDir(t)
+	dest := filepath.Join(t.TempDir(), "docs.zip")
+
+	if _, err := Create(dest, dir, nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	zr, err := zip.OpenReader(dest)
+	if err != nil {
+		t.Fatalf("Expected a zip file: %v", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	want := "github.com/spf13/cobra.md,github.com/spf13/cobra/latest/cobra.md,github.com/spf13/cobra/v1.9.1/cobra.md,manifest.json,search-index.json"
+	if strings.Join(names, ",") != want {
+		t.Errorf("Expected %s, got %v", want, names)
+	}
+
+	if _, err := Create(filepath.Join(t.TempDir(), "docs.rar"), dir, nil); err == nil {
+		t.Error("Expected an unsupported extension to be rejected")
+	}
+}
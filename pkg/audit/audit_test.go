@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+var legacy = &models.Package{
+	Name:              "legacy",
+	ImportPath:        "example.com/legacy",
+	Version:           "v1.2.0",
+	ModuleDeprecated:  true,
+	DeprecationNotice: "Deprecated: use example.com/modern instead.",
+	Constants:         []models.Constant{{Name: "Old", Description: "Old is a limit.\n\nDeprecated: Use Limit."}},
+	Functions: []models.Function{
+		{Name: "Parse", Description: "Parse parses.\n\nDeprecated: Use ParseContext,\nwhich can be cancelled.\n\nMore text."},
+		{Name: "Tagged", Deprecated: "deprecated"},
+		{Name: "Fine", Description: "Fine is not Deprecated: at all."},
+	},
+	Types: []models.Type{{Name: "Client", Methods: []models.Function{{Name: "Client.Do", Description: "Deprecated: Use Send."}}}},
+}
+
+func TestDeprecations(t *testing.T) {
+	got := Deprecations(legacy)
+	want := []Deprecation{
+		{ImportPath: "example.com/legacy", Version: "v1.2.0", Kind: KindModule, Hint: "Deprecated: use example.com/modern instead."},
+		{ImportPath: "example.com/legacy", Version: "v1.2.0", Kind: KindConst, Symbol: "Old", Hint: "Use Limit."},
+		{ImportPath: "example.com/legacy", Version: "v1.2.0", Kind: KindFunc, Symbol: "Parse", Hint: "Use ParseContext, which can be cancelled."},
+		{ImportPath: "example.com/legacy", Version: "v1.2.0", Kind: KindFunc, Symbol: "Tagged"},
+		{ImportPath: "example.com/legacy", Version: "v1.2.0", Kind: KindMethod, Symbol: "Client.Do", Hint: "Use Send."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Deprecations =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/app\n\ngo 1.22\n\nrequire example.com/legacy v1.1.0\n")
+	write("main.go", `package main
+
+import (
+	"fmt"
+
+	"example.com/legacy"
+)
+
+func main() {
+	v, _ := legacy.Parse("x")
+	fmt.Println(v, legacy.Fine)
+}
+`)
+	write("client/client.go", `package client
+
+import old "example.com/legacy"
+
+func call(c *old.Client) {
+	c.Do()
+	_ = old.Old
+}
+`)
+	write("vendor/example.com/legacy/legacy.go", "package legacy\n\nfunc Parse() {}\n")
+
+	u, err := ScanUsage(dir)
+	if err != nil {
+		t.Fatalf("ScanUsage failed: %v", err)
+	}
+	if got := u.Imports(); !reflect.DeepEqual(got, []string{"example.com/legacy", "fmt"}) {
+		t.Errorf("Imports = %v", got)
+	}
+	if v := u.Version("example.com/legacy/sub"); v != "v1.1.0" {
+		t.Errorf("Expected the required version of the module, got %q", v)
+	}
+
+	uses := map[string][]Use{}
+	for _, d := range Deprecations(legacy) {
+		u.Match(&d, legacy.Name)
+		uses[d.Kind+" "+d.Symbol] = d.Uses
+	}
+	want := map[string][]Use{
+		"module ":          {{File: "client/client.go", Line: 3}, {File: "main.go", Line: 6}},
+		"const Old":        {{File: "client/client.go", Line: 7}},
+		"func Parse":       {{File: "main.go", Line: 10}},
+		"func Tagged":      nil,
+		"method Client.Do": {{File: "client/client.go", Line: 6, Possible: true}},
+	}
+	if !reflect.DeepEqual(uses, want) {
+		t.Errorf("uses =\n%+v\nwant\n%+v", uses, want)
+	}
+}
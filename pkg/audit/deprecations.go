@@ -0,0 +1,99 @@
+// Package audit checks the cached corpus for APIs to move away from and finds where a local
+// codebase still uses them.
+package audit
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Symbol kinds of a Deprecation.
+const (
+	KindModule = "module" // the whole module is deprecated in its go.mod
+	KindConst  = "const"
+	KindVar    = "var"
+	KindFunc   = "func"
+	KindType   = "type"
+	KindMethod = "method"
+)
+
+// Deprecation is a deprecated symbol of a package.
+type Deprecation struct {
+	ImportPath string `json:"import_path"`
+	Version    string `json:"version,omitempty"`
+	Kind       string `json:"kind"`
+	Symbol     string `json:"symbol,omitempty"` // declaration name or "Type.Method"; empty for a module
+	Hint       string `json:"hint,omitempty"`   // the "Deprecated:" paragraph, usually naming the replacement
+	Uses       []Use  `json:"uses,omitempty"`   // set by Usage.Match
+}
+
+// Deprecations returns the deprecated symbols of pkg, in declaration order, with the module's
+// deprecation first. A symbol counts as deprecated when pkg.go.dev tags it so or its doc comment
+// has a "Deprecated:" paragraph.
+func Deprecations(pkg *models.Package) []Deprecation {
+	var out []Deprecation
+	add := func(kind, symbol, tag, description string) {
+		hint, ok := deprecationHint(description)
+		if !ok && tag == "" {
+			return
+		}
+		out = append(out, Deprecation{ImportPath: pkg.ImportPath, Version: pkg.Version, Kind: kind, Symbol: symbol, Hint: hint})
+	}
+	if pkg.ModuleDeprecated {
+		out = append(out, Deprecation{ImportPath: pkg.ImportPath, Version: pkg.Version, Kind: KindModule, Hint: pkg.DeprecationNotice})
+	}
+	for _, c := range pkg.Constants {
+		add(KindConst, c.Name, "", c.Description)
+	}
+	for _, v := range pkg.Variables {
+		add(KindVar, v.Name, "", v.Description)
+	}
+	for _, f := range pkg.Functions {
+		add(KindFunc, f.Name, f.Deprecated, f.Description)
+	}
+	for _, t := range pkg.Types {
+		add(KindType, t.Name, t.Deprecated, t.Description)
+		for _, m := range t.Methods {
+			name := m.Name
+			if !strings.Contains(name, ".") {
+				name = t.Name + "." + name
+			}
+			add(KindMethod, name, m.Deprecated, m.Description)
+		}
+	}
+	return out
+}
+
+// deprecationHint returns the "Deprecated:" paragraph of a doc comment without its prefix and
+// with its lines joined, and whether there was one.
+func deprecationHint(description string) (string, bool) {
+	i := strings.Index(description, "Deprecated:")
+	if i < 0 || i > 0 && description[i-1] != '\n' {
+		return "", false
+	}
+	para := description[i+len("Deprecated:"):]
+	if end := strings.Index(para, "\n\n"); end >= 0 {
+		para = para[:end]
+	}
+	return strings.Join(strings.Fields(para), " "), true
+}
+
+// Sort orders deprecations by import path and version, then by symbol, keeping module
+// deprecations first.
+func Sort(deps []Deprecation) {
+	sort.SliceStable(deps, func(i, j int) bool {
+		a, b := deps[i], deps[j]
+		if a.ImportPath != b.ImportPath {
+			return a.ImportPath < b.ImportPath
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		if (a.Kind == KindModule) != (b.Kind == KindModule) {
+			return a.Kind == KindModule
+		}
+		return a.Symbol < b.Symbol
+	})
+}
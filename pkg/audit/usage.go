@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Use is a place in a local codebase that uses a deprecated symbol.
+type Use struct {
+	File string `json:"file"` // relative to the scanned directory
+	Line int    `json:"line"`
+	// Possible marks a call of a deprecated method's name on some value of a file importing its
+	// package: without type checking it cannot be told apart from another type's method.
+	Possible bool `json:"possible,omitempty"`
+}
+
+// Usage is what the Go files of a local codebase import and select from their imports.
+type Usage struct {
+	Requires map[string]string // module path → version required by the go.mod, when there is one
+	files    []fileUsage
+}
+
+type fileUsage struct {
+	path      string
+	imports   map[string]importSpec // import path → how the file imports it
+	selectors []selector
+}
+
+type importSpec struct {
+	name string // explicit package name, "" when not renamed
+	line int
+}
+
+// selector is an expression x.Sel with x an identifier, such as a qualified identifier or a method
+// call on a variable.
+type selector struct {
+	x, sel string
+	line   int
+}
+
+// ScanUsage parses the Go files below dir, skipping vendor, testdata and directories starting with
+// "." or "_", and reads the requirements of dir/go.mod when it exists.
+func ScanUsage(dir string) (*Usage, error) {
+	u := &Usage{Requires: map[string]string{}}
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		f, err := modfile.ParseLax(filepath.Join(dir, "go.mod"), data, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range f.Require {
+			u.Requires[r.Mod.Path] = r.Mod.Version
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		u.files = append(u.files, scanFile(fset, filepath.ToSlash(rel), file))
+		return nil
+	})
+	return u, err
+}
+
+func scanFile(fset *token.FileSet, rel string, file *ast.File) fileUsage {
+	fu := fileUsage{path: rel, imports: map[string]importSpec{}}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		spec := importSpec{line: fset.Position(imp.Pos()).Line}
+		if imp.Name != nil {
+			spec.name = imp.Name.Name
+		}
+		fu.imports[path] = spec
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				fu.selectors = append(fu.selectors, selector{x: x.Name, sel: sel.Sel.Name, line: fset.Position(sel.Pos()).Line})
+			}
+		}
+		return true
+	})
+	return fu
+}
+
+// Imports returns the import paths used by the codebase, sorted.
+func (u *Usage) Imports() []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, f := range u.files {
+		for path := range f.imports {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Version returns the version the go.mod requires of the module providing importPath, or "".
+func (u *Usage) Version(importPath string) string {
+	best, version := "", ""
+	for mod, v := range u.Requires {
+		if (importPath == mod || strings.HasPrefix(importPath, mod+"/")) && len(mod) > len(best) {
+			best, version = mod, v
+		}
+	}
+	return version
+}
+
+// Match sets the uses of d in the codebase. pkgName is the name of d's package, the qualifier
+// files use unless they rename the import. A deprecated module is used where it is imported.
+func (u *Usage) Match(d *Deprecation, pkgName string) {
+	d.Uses = nil
+	_, method, isMethod := strings.Cut(d.Symbol, ".")
+	for _, f := range u.files {
+		spec, ok := f.imports[d.ImportPath]
+		if !ok {
+			continue
+		}
+		if d.Kind == KindModule {
+			d.Uses = append(d.Uses, Use{File: f.path, Line: spec.line})
+			continue
+		}
+		qualifier := pkgName
+		if spec.name != "" {
+			qualifier = spec.name
+		}
+		for _, s := range f.selectors {
+			switch {
+			case d.Kind == KindMethod && isMethod:
+				if s.sel == method && s.x != qualifier {
+					d.Uses = append(d.Uses, Use{File: f.path, Line: s.line, Possible: true})
+				}
+			case s.x == qualifier && s.sel == d.Symbol:
+				d.Uses = append(d.Uses, Use{File: f.path, Line: s.line})
+			}
+		}
+	}
+}
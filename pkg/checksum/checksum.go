@@ -0,0 +1,105 @@
+package checksum
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File is the name of the manifest written to the root of an output directory. Its format is
+// that of sha256sum, so "sha256sum -c SHA256SUMS" verifies a published bundle.
+const File = "SHA256SUMS"
+
+// Sums maps slash-separated paths relative to the output directory to hex SHA-256 digests.
+type Sums map[string]string
+
+// Sum returns the hex SHA-256 digest of data.
+func Sum(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// Compute returns the digests of every regular file below dir except the manifest itself.
+// Symlinks (such as the latest version pointers) are skipped; their targets are covered.
+func Compute(dir string) (Sums, error) {
+	sums := Sums{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == File {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		sums[rel] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	return sums, err
+}
+
+// Write writes sums to the manifest of dir, sorted by path.
+func Write(dir string, sums Sums) error {
+	paths := make([]string, 0, len(sums))
+	for p := range sums {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", sums[p], p)
+	}
+	return os.WriteFile(filepath.Join(dir, File), []byte(b.String()), 0644)
+}
+
+// Update recomputes the digests of every file below dir and rewrites its manifest.
+func Update(dir string) (Sums, error) {
+	sums, err := Compute(dir)
+	if err != nil {
+		return nil, err
+	}
+	return sums, Write(dir, sums)
+}
+
+// Read returns the manifest of dir, or empty sums if dir has none.
+func Read(dir string) (Sums, error) {
+	f, err := os.Open(filepath.Join(dir, File))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Sums{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sums := Sums{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		digest, p, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("%s:%d: malformed line", File, line)
+		}
+		sums[p] = digest
+	}
+	return sums, scanner.Err()
+}
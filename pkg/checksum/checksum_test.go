@@ -0,0 +1,43 @@
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateAndRead(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "github.com/spf13"), 0755)
+	os.WriteFile(filepath.Join(dir, "github.com/spf13/cobra.md"), []byte("# cobra\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0644)
+	os.Symlink("index.html", filepath.Join(dir, "latest.html"))
+
+	sums, err := Update(dir)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(sums) != 2 {
+		t.Errorf("Expected digests of the 2 regular files, got %v", sums)
+	}
+	if sums["github.com/spf13/cobra.md"] != Sum([]byte("# cobra\n")) {
+		t.Errorf("Expected the digest of cobra.md, got %q", sums["github.com/spf13/cobra.md"])
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, File))
+	if !strings.HasSuffix(strings.Split(string(data), "\n")[0], "  github.com/spf13/cobra.md") {
+		t.Errorf("Expected sha256sum formatted lines sorted by path, got %q", data)
+	}
+
+	// The manifest must not cover itself when recomputed.
+	again, _ := Update(dir)
+	if len(again) != 2 {
+		t.Errorf("Expected the manifest to be excluded, got %v", again)
+	}
+
+	read, err := Read(dir)
+	if err != nil || len(read) != 2 || read["index.html"] != sums["index.html"] {
+		t.Errorf("Expected Read to return the written sums, got %v (%v)", read, err)
+	}
+}
@@ -0,0 +1,264 @@
+package chunker
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+)
+
+// Options controls chunk sizes
+type Options struct {
+	MaxTokens int // Approximate upper bound of tokens per chunk
+	Overlap   int // Approximate tokens repeated from the previous chunk when a section is split
+}
+
+// DefaultOptions returns sizes that suit most embedding models
+func DefaultOptions() Options {
+	return Options{
+		MaxTokens: 512,
+		Overlap:   64,
+	}
+}
+
+// section is the content between one heading and the next.
+type section struct {
+	title  string   // heading text without the leading #s
+	anchor string   // ID of the HTML anchor written right before the heading, if any
+	path   []string // heading titles from the outermost level down to this one
+	lines  []string // heading line followed by the body
+}
+
+// Split breaks rendered markdown into chunks at heading boundaries. Sections larger than
+// opts.MaxTokens are split on paragraph (then line) boundaries, with each follow-up chunk
+// starting with the section heading and roughly opts.Overlap tokens of the previous chunk.
+func Split(source, markdown string, opts Options) []models.Chunk {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultOptions().MaxTokens
+	}
+	if opts.Overlap < 0 || opts.Overlap >= opts.MaxTokens {
+		opts.Overlap = 0
+	}
+
+	var chunks []models.Chunk
+	anchors := make(map[string]int)
+	for _, sec := range sections(markdown) {
+		body := strings.TrimSpace(strings.Join(sec.lines[1:], "\n"))
+		if body == "" {
+			continue
+		}
+
+		anchor := sec.anchor
+		if anchor == "" {
+			anchor = Slug(sec.title)
+			if n := anchors[anchor]; n > 0 {
+				anchor = fmt.Sprintf("%s-%d", anchor, n)
+			}
+			anchors[Slug(sec.title)]++
+		}
+
+		heading := sec.lines[0]
+		for i, text := range splitSection(heading, body, opts) {
+			chunks = append(chunks, models.Chunk{
+				ID:      fmt.Sprintf("%s#%s/%d", source, anchor, i),
+				Source:  source,
+				Anchor:  anchor,
+				Heading: strings.Join(sec.path, " > "),
+				Text:    text,
+				Tokens:  utils.EstimateTokens(text),
+			})
+		}
+	}
+	return chunks
+}
+
+// anchorLine matches the HTML anchor the markdown renderer writes before symbol headings, whose
+// ID links use instead of the heading's (names such as String repeat within a page).
+var anchorLine = regexp.MustCompile(`^<a id="([^"]+)"></a>$`)
+
+// sections walks the markdown line by line, ignoring headings inside code fences. Anchor lines
+// are left out of the sections and name the heading that follows them.
+func sections(markdown string) []section {
+	var out []section
+	var stack []string
+	current := section{lines: []string{""}}
+	inFence := false
+	pending := ""
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if m := anchorLine.FindStringSubmatch(trimmed); m != nil && !inFence {
+			pending = m[1]
+			continue
+		}
+		level := headingLevel(line)
+		if inFence || level == 0 {
+			current.lines = append(current.lines, line)
+			if trimmed != "" {
+				pending = ""
+			}
+			continue
+		}
+
+		out = append(out, current)
+		title := strings.TrimSpace(line[level:])
+		if len(stack) >= level {
+			stack = stack[:level-1]
+		}
+		for len(stack) < level-1 {
+			stack = append(stack, "")
+		}
+		stack = append(stack, title)
+		current = section{title: title, anchor: pending, path: compact(stack), lines: []string{line}}
+		pending = ""
+	}
+	return append(out, current)
+}
+
+// splitSection packs paragraphs of body into chunks of at most opts.MaxTokens.
+func splitSection(heading, body string, opts Options) []string {
+	whole := strings.TrimSpace(heading + "\n\n" + body)
+	if utils.EstimateTokens(whole) <= opts.MaxTokens {
+		return []string{whole}
+	}
+
+	var chunks []string
+	var current []string
+	size := func(parts []string) int {
+		return utils.EstimateTokens(heading + "\n\n" + strings.Join(parts, "\n\n"))
+	}
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, strings.TrimSpace(heading+"\n\n"+strings.Join(current, "\n\n")))
+		current = overlapTail(current, opts.Overlap)
+	}
+
+	for _, para := range paragraphs(body, opts.MaxTokens-utils.EstimateTokens(heading)-1) {
+		if len(current) > 0 && size(append(current, para)) > opts.MaxTokens {
+			flush()
+			// Drop overlap that would still overflow next to this paragraph.
+			for len(current) > 0 && size(append(current, para)) > opts.MaxTokens {
+				current = current[1:]
+			}
+		}
+		current = append(current, para)
+	}
+	if len(current) > 0 && (len(chunks) == 0 || !isOverlapOnly(current, chunks[len(chunks)-1])) {
+		chunks = append(chunks, strings.TrimSpace(heading+"\n\n"+strings.Join(current, "\n\n")))
+	}
+	return chunks
+}
+
+// paragraphs splits body on blank lines outside code fences, breaking any paragraph above limit into line groups.
+func paragraphs(body string, limit int) []string {
+	var paras []string
+	var buf []string
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.TrimSpace(line) == "" {
+			if len(buf) > 0 {
+				paras = append(paras, strings.Join(buf, "\n"))
+				buf = nil
+			}
+			continue
+		}
+		buf = append(buf, line)
+	}
+	if len(buf) > 0 {
+		paras = append(paras, strings.Join(buf, "\n"))
+	}
+
+	if limit <= 0 {
+		return paras
+	}
+	var out []string
+	for _, p := range paras {
+		if utils.EstimateTokens(p) <= limit {
+			out = append(out, p)
+			continue
+		}
+		var group []string
+		for _, line := range strings.Split(p, "\n") {
+			if len(group) > 0 && utils.EstimateTokens(strings.Join(append(group, line), "\n")) > limit {
+				out = append(out, strings.Join(group, "\n"))
+				group = nil
+			}
+			group = append(group, line)
+		}
+		if len(group) > 0 {
+			out = append(out, strings.Join(group, "\n"))
+		}
+	}
+	return out
+}
+
+// overlapTail returns the trailing paragraphs of parts that fit within the overlap budget.
+func overlapTail(parts []string, overlap int) []string {
+	if overlap <= 0 {
+		return nil
+	}
+	var tail []string
+	total := 0
+	for i := len(parts) - 1; i >= 0; i-- {
+		t := utils.EstimateTokens(parts[i])
+		if total+t > overlap {
+			break
+		}
+		total += t
+		tail = append([]string{parts[i]}, tail...)
+	}
+	return tail
+}
+
+// isOverlapOnly reports whether current holds nothing beyond the overlap copied from the previous chunk.
+func isOverlapOnly(current []string, previous string) bool {
+	return strings.HasSuffix(previous, strings.Join(current, "\n\n"))
+}
+
+// headingLevel returns the ATX heading level of line, or 0 if it is not a heading.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// compact drops empty entries left by skipped heading levels.
+func compact(path []string) []string {
+	out := make([]string, 0, len(path))
+	for _, p := range path {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Slug converts heading text into a GitHub-style markdown anchor.
+func Slug(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
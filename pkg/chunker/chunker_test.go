@@ -0,0 +1,74 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplit_HeadingBoundaries(t *testing.T) {
+	md := "# cobra package\n\nIntro text.\n\n## Types\n\n### Command\n\n```go\n# not a heading\ntype Command struct{}\n```\n\nCommand is a CLI command.\n\n## Empty\n"
+
+	chunks := Split("github.com/spf13/cobra", md, DefaultOptions())
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 non-empty sections, got %d: %+v", len(chunks), chunks)
+	}
+
+	cmd := chunks[1]
+	if cmd.Heading != "cobra package > Types > Command" {
+		t.Errorf("Unexpected heading path %q", cmd.Heading)
+	}
+	if cmd.Anchor != "command" || cmd.ID != "github.com/spf13/cobra#command/0" {
+		t.Errorf("Unexpected anchor/id %q %q", cmd.Anchor, cmd.ID)
+	}
+	if !strings.Contains(cmd.Text, "# not a heading") {
+		t.Error("Lines inside code fences must not start new sections")
+	}
+	if cmd.Tokens == 0 || cmd.Source != "github.com/spf13/cobra" {
+		t.Errorf("Chunk should carry token count and source, got %+v", cmd)
+	}
+}
+
+func TestSplit_LargeSectionOverlaps(t *testing.T) {
+	var paras []string
+	for i := 0; i < 40; i++ {
+		paras = append(paras, strings.Repeat("word ", 20)+string(rune('a'+i%26)))
+	}
+	md := "## README\n\n" + strings.Join(paras, "\n\n")
+
+	opts := Options{MaxTokens: 120, Overlap: 30}
+	chunks := Split("example.com/pkg", md, opts)
+	if len(chunks) < 2 {
+		t.Fatalf("Expected the section to be split, got %d chunk(s)", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.Tokens > opts.MaxTokens {
+			t.Errorf("Chunk %d has %d tokens, above the %d limit", i, c.Tokens, opts.MaxTokens)
+		}
+		if !strings.HasPrefix(c.Text, "## README") {
+			t.Errorf("Chunk %d should start with its section heading", i)
+		}
+	}
+
+	first := strings.Split(chunks[0].Text, "\n\n")
+	last := first[len(first)-1]
+	if !strings.Contains(chunks[1].Text, last) {
+		t.Error("Consecutive chunks should overlap")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	if got := Slug("What This Package Does (Generated)"); got != "what-this-package-does-generated" {
+		t.Errorf("Unexpected slug %q", got)
+	}
+}
+
+func TestSplit_Anchors(t *testing.T) {
+	md := "## Types\n\n<a id=\"First.String\"></a>\n\n###### First.String\n\nFirst text.\n\n<a id=\"Second.String\"></a>\n\n###### String\n\nSecond text.\n"
+	chunks := Split("example.com/names", md, DefaultOptions())
+	if len(chunks) != 2 || chunks[0].Anchor != "First.String" || chunks[1].Anchor != "Second.String" {
+		t.Fatalf("Expected the anchors of the headings, got %+v", chunks)
+	}
+	if strings.Contains(chunks[0].Text, "<a id") {
+		t.Errorf("Expected anchor lines left out of the text, got %q", chunks[0].Text)
+	}
+}
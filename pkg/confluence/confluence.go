@@ -0,0 +1,179 @@
+// Package confluence publishes package documentation as Confluence pages through the REST API
+// shared by Confluence Cloud and Data Center, converting docinator's markdown to the storage
+// format Confluence keeps page bodies in.
+package confluence
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client talks to the Confluence REST API.
+type Client struct {
+	BaseURL    string       // site URL, e.g. https://example.atlassian.net/wiki
+	User       string       // account email for Confluence Cloud; empty for a Data Center personal access token
+	Token      string       // API token (Cloud) or personal access token (Data Center)
+	HTTPClient *http.Client // HTTP client used for requests
+}
+
+// NewFromEnv builds a client from env:
+// - CONFLUENCE_URL (site URL, including the /wiki context path on Confluence Cloud)
+// - CONFLUENCE_USER (account email; with it the token is sent with basic auth, without as a bearer token)
+// - CONFLUENCE_TOKEN
+// It returns nil when CONFLUENCE_URL is unset.
+func NewFromEnv() *Client {
+	baseURL := os.Getenv("CONFLUENCE_URL")
+	if baseURL == "" {
+		return nil
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		User:       os.Getenv("CONFLUENCE_USER"),
+		Token:      os.Getenv("CONFLUENCE_TOKEN"),
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Page is a Confluence page as returned by the API.
+type Page struct {
+	ID      string     `json:"id"`
+	Title   string     `json:"title"`
+	Version versionRef `json:"version"`
+}
+
+type versionRef struct {
+	Number int `json:"number"`
+}
+
+// FindPage returns the current page titled title in space, or nil when there is none.
+func (c *Client) FindPage(ctx context.Context, space, title string) (*Page, error) {
+	query := url.Values{"spaceKey": {space}, "title": {title}, "expand": {"version"}}
+	var resp struct {
+		Results []Page `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/content?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, nil
+	}
+	return &resp.Results[0], nil
+}
+
+// PageContent is what Publish writes.
+type PageContent struct {
+	Space    string // space key, e.g. "DEV"
+	ParentID string // page the page is created or moved under; empty for the space root
+	Title    string // unique within the space; existing pages are found by it
+	Body     string // storage format, see ToStorage
+}
+
+type contentRequest struct {
+	ID        string      `json:"id,omitempty"`
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Space     spaceRef    `json:"space"`
+	Ancestors []pageRef   `json:"ancestors,omitempty"`
+	Body      contentBody `json:"body"`
+	Version   *versionRef `json:"version,omitempty"`
+}
+
+type spaceRef struct {
+	Key string `json:"key"`
+}
+
+type pageRef struct {
+	ID string `json:"id"`
+}
+
+type contentBody struct {
+	Storage struct {
+		Value          string `json:"value"`
+		Representation string `json:"representation"`
+	} `json:"storage"`
+}
+
+// Publish creates the page, or updates the page of the same title in the space to a new
+// version. It reports whether the page was created.
+func (c *Client) Publish(ctx context.Context, content PageContent) (*Page, bool, error) {
+	existing, err := c.FindPage(ctx, content.Space, content.Title)
+	if err != nil {
+		return nil, false, err
+	}
+	req := contentRequest{Type: "page", Title: content.Title, Space: spaceRef{Key: content.Space}}
+	if content.ParentID != "" {
+		req.Ancestors = []pageRef{{ID: content.ParentID}}
+	}
+	req.Body.Storage.Value = content.Body
+	req.Body.Storage.Representation = "storage"
+
+	var page Page
+	if existing == nil {
+		err = c.do(ctx, http.MethodPost, "/rest/api/content", req, &page)
+		return &page, true, err
+	}
+	req.ID = existing.ID
+	req.Version = &versionRef{Number: existing.Version.Number + 1}
+	err = c.do(ctx, http.MethodPut, "/rest/api/content/"+url.PathEscape(existing.ID), req, &page)
+	return &page, false, err
+}
+
+// do sends body, when not nil, as JSON to path and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	if c == nil {
+		return errors.New("confluence client not configured; set CONFLUENCE_URL")
+	}
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode confluence request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.User != "":
+		req.SetBasicAuth(c.User, c.Token)
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("confluence request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read confluence response: %w", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("confluence %s %s returned %d: %s", method, path, res.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode confluence response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeConfluence keeps pages by title and answers the content endpoints Publish uses.
+type fakeConfluence struct {
+	pages map[string]*contentRequest
+	auth  []string
+}
+
+func (f *fakeConfluence) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/wiki/rest/api/content":
+		var results []Page
+		if p := f.pages[r.URL.Query().Get("title")]; p != nil && p.Space.Key == r.URL.Query().Get("spaceKey") {
+			results = append(results, Page{ID: p.ID, Title: p.Title, Version: *p.Version})
+		}
+		json.NewEncoder(w).Encode(map[string][]Page{"results": results})
+	case r.Method == http.MethodPost || r.Method == http.MethodPut:
+		var req contentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			req.ID = strconv.Itoa(100 + len(f.pages))
+			req.Version = &versionRef{Number: 1}
+		} else if cur := f.pages[req.Title]; cur == nil || req.Version.Number != cur.Version.Number+1 || r.URL.Path != "/wiki/rest/api/content/"+cur.ID {
+			http.Error(w, "version conflict", http.StatusConflict)
+			return
+		}
+		f.pages[req.Title] = &req
+		json.NewEncoder(w).Encode(Page{ID: req.ID, Title: req.Title, Version: *req.Version})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	fake := &fakeConfluence{pages: map[string]*contentRequest{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL + "/wiki", User: "me@example.com", Token: "t"}
+	ctx := context.Background()
+
+	content := PageContent{Space: "DEV", ParentID: "42", Title: "github.com/spf13/cobra", Body: "<p>v1</p>"}
+	page, created, err := c.Publish(ctx, content)
+	if err != nil || !created || page.ID != "100" || page.Version.Number != 1 {
+		t.Fatalf("Expected a new page, got %+v, %v (%v)", page, created, err)
+	}
+	if got := fake.pages[content.Title]; got.Ancestors[0].ID != "42" || got.Body.Storage.Representation != "storage" {
+		t.Errorf("Expected the page below the parent in storage format, got %+v", got)
+	}
+
+	content.Body = "<p>v2</p>"
+	page, created, err = c.Publish(ctx, content)
+	if err != nil || created || page.ID != "100" || page.Version.Number != 2 {
+		t.Fatalf("Expected the page updated to version 2, got %+v, %v (%v)", page, created, err)
+	}
+	if fake.pages[content.Title].Body.Storage.Value != "<p>v2</p>" {
+		t.Errorf("Expected the new body, got %+v", fake.pages[content.Title].Body)
+	}
+	if !strings.HasPrefix(fake.auth[0], "Basic ") {
+		t.Errorf("Expected basic auth with a user, got %q", fake.auth[0])
+	}
+
+	c.User = ""
+	if _, err := c.FindPage(ctx, "DEV", "missing"); err != nil || fake.auth[len(fake.auth)-1] != "Bearer t" {
+		t.Errorf("Expected a bearer token without a user, got %q (%v)", fake.auth[len(fake.auth)-1], err)
+	}
+	if _, _, err := (*Client)(nil).Publish(ctx, content); err == nil {
+		t.Error("Expected an error without a client")
+	}
+}
+
+func TestToStorage(t *testing.T) {
+	got, err := ToStorage("## Functions\n\n```go\nfunc Less(a, b T) bool // a < b ]]>\n```\n\n<details>\n<summary>Source</summary>\n\nText<br>\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h2>Functions</h2>",
+		`<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">go</ac:parameter><ac:plain-text-body><![CDATA[func Less(a, b T) bool // a < b ]]]]><![CDATA[>]]></ac:plain-text-body></ac:structured-macro>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<details>") || strings.Contains(got, "<br>") {
+		t.Errorf("Expected raw HTML to be dropped, got:\n%s", got)
+	}
+}
@@ -0,0 +1,42 @@
+package confluence
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	ghtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Raw HTML in the markdown (collapsible source blocks, README leftovers) is dropped rather than
+// passed through: the storage format is strict XHTML and rejects a page with any stray tag.
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(ghtml.WithXHTML()),
+)
+
+var codeBlock = regexp.MustCompile(`(?s)<pre><code(?: class="language-([^"]*)")?>(.*?)</code></pre>`)
+
+// ToStorage converts docinator markdown to Confluence storage format: XHTML with fenced code
+// blocks turned into code macros, which Confluence highlights and lets readers copy.
+func ToStorage(markdown string) (string, error) {
+	var out bytes.Buffer
+	if err := md.Convert([]byte(markdown), &out); err != nil {
+		return "", err
+	}
+	return codeBlock.ReplaceAllStringFunc(out.String(), func(block string) string {
+		m := codeBlock.FindStringSubmatch(block)
+		var b strings.Builder
+		b.WriteString(`<ac:structured-macro ac:name="code">`)
+		if m[1] != "" {
+			b.WriteString(`<ac:parameter ac:name="language">` + m[1] + `</ac:parameter>`)
+		}
+		code := strings.TrimSuffix(html.UnescapeString(m[2]), "\n")
+		b.WriteString("<ac:plain-text-body><![CDATA[" + strings.ReplaceAll(code, "]]>", "]]]]><![CDATA[>") + "]]></ac:plain-text-body>")
+		b.WriteString("</ac:structured-macro>")
+		return b.String()
+	}), nil
+}
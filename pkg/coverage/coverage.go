@@ -0,0 +1,150 @@
+// Package coverage measures how well the exported API of scraped packages is documented: the
+// share of symbols with a doc comment and the share of functions, types and methods with an
+// example, per package and rolled up per module and for the whole corpus.
+package coverage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Counts tallies the documentation of a set of exported symbols.
+type Counts struct {
+	Symbols    int `json:"symbols"`    // constants, variables, functions, types and methods
+	Documented int `json:"documented"` // symbols with a doc comment
+	// Examplable counts the functions, types and methods, the symbols pkg.go.dev shows examples
+	// for; WithExamples those of them that have one.
+	Examplable   int `json:"examplable"`
+	WithExamples int `json:"with_examples"`
+}
+
+// DocRatio returns the share of symbols with a doc comment, 1 when there are none.
+func (c Counts) DocRatio() float64 {
+	return ratio(c.Documented, c.Symbols)
+}
+
+// ExampleRatio returns the share of functions, types and methods with an example, 1 when there
+// are none.
+func (c Counts) ExampleRatio() float64 {
+	return ratio(c.WithExamples, c.Examplable)
+}
+
+func (c *Counts) add(o Counts) {
+	c.Symbols += o.Symbols
+	c.Documented += o.Documented
+	c.Examplable += o.Examplable
+	c.WithExamples += o.WithExamples
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(n) / float64(total)
+}
+
+// Report is the documentation coverage of one package.
+type Report struct {
+	ImportPath string `json:"import_path"`
+	Module     string `json:"module,omitempty"`
+	Version    string `json:"version,omitempty"`
+	// PackageDoc reports whether the package has a package comment.
+	PackageDoc bool `json:"package_doc"`
+	Counts
+	// Undocumented and NoExamples name the symbols lacking a doc comment and the functions,
+	// types and methods lacking an example, methods as "Type.Method".
+	Undocumented []string `json:"undocumented,omitempty"`
+	NoExamples   []string `json:"no_examples,omitempty"`
+}
+
+// Package measures the documentation coverage of pkg.
+func Package(pkg *models.Package) Report {
+	r := Report{
+		ImportPath: pkg.ImportPath,
+		Module:     pkg.Module,
+		Version:    pkg.Version,
+		PackageDoc: pkg.Overview != "" || pkg.Synopsis != "",
+	}
+	symbol := func(name, doc string) {
+		r.Symbols++
+		if strings.TrimSpace(doc) != "" {
+			r.Documented++
+		} else {
+			r.Undocumented = append(r.Undocumented, name)
+		}
+	}
+	example := func(name string, examples []models.Example) {
+		r.Examplable++
+		if len(examples) > 0 {
+			r.WithExamples++
+		} else {
+			r.NoExamples = append(r.NoExamples, name)
+		}
+	}
+	for _, c := range pkg.Constants {
+		symbol(c.Name, c.Description)
+	}
+	for _, v := range pkg.Variables {
+		symbol(v.Name, v.Description)
+	}
+	for _, f := range pkg.Functions {
+		symbol(f.Name, f.Description)
+		example(f.Name, f.Examples)
+	}
+	for _, t := range pkg.Types {
+		symbol(t.Name, t.Description)
+		example(t.Name, t.Examples)
+		for _, m := range t.Methods {
+			name := m.Name
+			if !strings.Contains(name, ".") {
+				name = t.Name + "." + name
+			}
+			symbol(name, m.Description)
+			example(name, m.Examples)
+		}
+	}
+	return r
+}
+
+// Rollup is the documentation coverage of the packages of one module.
+type Rollup struct {
+	Module   string `json:"module"`
+	Packages int    `json:"packages"`
+	Counts
+}
+
+// ByModule rolls reports up per module, sorted by module path. Packages without a recorded
+// module count as their own.
+func ByModule(reports []Report) []Rollup {
+	byModule := map[string]*Rollup{}
+	for _, r := range reports {
+		module := r.Module
+		if module == "" {
+			module = r.ImportPath
+		}
+		m := byModule[module]
+		if m == nil {
+			m = &Rollup{Module: module}
+			byModule[module] = m
+		}
+		m.Packages++
+		m.add(r.Counts)
+	}
+	rollups := make([]Rollup, 0, len(byModule))
+	for _, m := range byModule {
+		rollups = append(rollups, *m)
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Module < rollups[j].Module })
+	return rollups
+}
+
+// Total adds up the counts of reports.
+func Total(reports []Report) Counts {
+	var c Counts
+	for _, r := range reports {
+		c.add(r.Counts)
+	}
+	return c
+}
@@ -0,0 +1,57 @@
+package coverage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestPackage(t *testing.T) {
+	pkg := &models.Package{
+		ImportPath: "example.com/gear",
+		Module:     "example.com/gear",
+		Synopsis:   "Package gear turns things.",
+		Constants:  []models.Constant{{Name: "Teeth", Description: "Teeth per gear."}, {Name: "Max"}},
+		Functions:  []models.Function{{Name: "Turn", Description: "Turn turns.", Examples: []models.Example{{Name: "Turn"}}}},
+		Types: []models.Type{{Name: "Gear", Description: "Gear is a wheel.", Methods: []models.Function{
+			{Name: "Spin", Description: " \n"},
+			{Name: "Gear.Stop", Description: "Stop stops.", Examples: []models.Example{{Name: "Gear.Stop"}}},
+		}}},
+	}
+	r := Package(pkg)
+	want := Counts{Symbols: 6, Documented: 4, Examplable: 4, WithExamples: 2}
+	if r.Counts != want {
+		t.Errorf("Expected %+v, got %+v", want, r.Counts)
+	}
+	if !r.PackageDoc {
+		t.Error("Expected the synopsis to count as package documentation")
+	}
+	if !reflect.DeepEqual(r.Undocumented, []string{"Max", "Gear.Spin"}) || !reflect.DeepEqual(r.NoExamples, []string{"Gear", "Gear.Spin"}) {
+		t.Errorf("Unexpected missing documentation %v and examples %v", r.Undocumented, r.NoExamples)
+	}
+	if got := r.DocRatio(); got < 0.66 || got > 0.67 {
+		t.Errorf("Expected a doc ratio of 4/6, got %f", got)
+	}
+	if got := Package(&models.Package{}).ExampleRatio(); got != 1 {
+		t.Errorf("Expected a package without symbols to be covered, got %f", got)
+	}
+}
+
+func TestByModule(t *testing.T) {
+	reports := []Report{
+		{ImportPath: "example.com/b", Counts: Counts{Symbols: 2, Documented: 1}},
+		{ImportPath: "example.com/a/x", Module: "example.com/a", Counts: Counts{Symbols: 3, Documented: 3, Examplable: 2, WithExamples: 1}},
+		{ImportPath: "example.com/a/y", Module: "example.com/a", Counts: Counts{Symbols: 1, Examplable: 1}},
+	}
+	want := []Rollup{
+		{Module: "example.com/a", Packages: 2, Counts: Counts{Symbols: 4, Documented: 3, Examplable: 3, WithExamples: 1}},
+		{Module: "example.com/b", Packages: 1, Counts: Counts{Symbols: 2, Documented: 1}},
+	}
+	if got := ByModule(reports); !reflect.DeepEqual(got, want) {
+		t.Errorf("ByModule =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := Total(reports); got != (Counts{Symbols: 6, Documented: 4, Examplable: 3, WithExamples: 1}) {
+		t.Errorf("Unexpected total %+v", got)
+	}
+}
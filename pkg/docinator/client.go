@@ -0,0 +1,125 @@
+// Package docinator is the Go API of docinator: it fetches pkg.go.dev documentation through a
+// cache and renders it as markdown, so other programs can embed docinator without wiring the
+// scraper, parser, renderer and store packages together themselves.
+//
+//	client, err := docinator.New(docinator.Options{})
+//	if err != nil { ... }
+//	defer client.Close()
+//	md, err := client.GetMarkdown(ctx, "github.com/spf13/cobra")
+package docinator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/postprocess"
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/storage"
+)
+
+// Options configures a Client.
+type Options struct {
+	// Store caches scraped packages between calls and runs; nil disables caching. Open one with
+	// storage.Open. The client does not close it.
+	Store storage.Store
+	// Scraper configures requests to pkg.go.dev; nil uses scraper.DefaultConfig().
+	Scraper *scraper.ScrapingConfig
+	// PostProcess runs over the markdown GetMarkdown returns; nil returns it as rendered.
+	PostProcess postprocess.Chain
+}
+
+// Client fetches package documentation, serving it from the store when cached. It is safe for
+// concurrent use.
+type Client struct {
+	scraper *scraper.Scraper
+	store   storage.Store
+	post    postprocess.Chain
+}
+
+// New returns a client. Call Close when done with it.
+func New(opts Options) (*Client, error) {
+	s, err := scraper.New(opts.Scraper)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scraper: %w", err)
+	}
+	store := opts.Store
+	if store == nil {
+		store = storage.Disabled()
+	}
+	return &Client{scraper: s, store: store, post: opts.PostProcess}, nil
+}
+
+// GetDocs returns the documentation of importPath, from the store when cached and scraped (then
+// cached) otherwise. "importPath@version" fetches a pinned version.
+func (c *Client) GetDocs(ctx context.Context, importPath string) (*Package, error) {
+	if c.store.Enabled() {
+		doc, err := c.store.GetByID(ctx, importPath)
+		if err != nil {
+			return nil, fmt.Errorf("cache lookup for %s: %w", importPath, err)
+		}
+		if doc != nil && doc.Package != nil {
+			return doc.Package, nil
+		}
+	}
+	return c.Refresh(ctx, importPath)
+}
+
+// GetMarkdown returns the documentation of importPath rendered as markdown and run through
+// Options.PostProcess, fetched like GetDocs.
+func (c *Client) GetMarkdown(ctx context.Context, importPath string) (string, error) {
+	pkg, err := c.GetDocs(ctx, importPath)
+	if err != nil {
+		return "", err
+	}
+	return c.post.Process(pkg, markdown.PackageToMarkdown(pkg))
+}
+
+// Refresh scrapes importPath regardless of the cache and replaces the cached copy. The
+// scraper's BeforeStoreHooks run first; when one fails, the package is returned uncached with its
+// error.
+func (c *Client) Refresh(ctx context.Context, importPath string) (*Package, error) {
+	pkg, rawHTML, err := c.scraper.ScrapePackageWithRaw(ctx, importPath)
+	if err != nil {
+		return nil, err
+	}
+	if c.store.Enabled() {
+		doc := &Document{ID: cacheID(importPath, pkg), Package: pkg, RawHTML: rawHTML}
+		if err := c.scraper.BeforeStore(ctx, doc); err != nil {
+			return pkg, err
+		}
+		if err := c.store.Upsert(ctx, doc); err != nil {
+			return pkg, fmt.Errorf("cache update for %s: %w", doc.ID, err)
+		}
+	}
+	return pkg, nil
+}
+
+// Scraper returns the client's scraper, to register hooks on (see scraper.Scraper.OnBeforeRequest,
+// OnAfterParse and OnBeforeStore) before the client is used.
+func (c *Client) Scraper() *scraper.Scraper {
+	return c.scraper
+}
+
+// Stats returns the scraper's request statistics since the client was created.
+func (c *Client) Stats() scraper.ScrapingStats {
+	return c.scraper.GetStats()
+}
+
+// Close releases the scraper. The store passed in Options stays open.
+func (c *Client) Close() error {
+	return c.scraper.Close()
+}
+
+// cacheID is the store ID of pkg requested as importPath: its import path, with the version
+// appended when the request was pinned, matching the IDs the CLI writes.
+func cacheID(importPath string, pkg *Package) string {
+	if pkg.ImportPath == "" {
+		return importPath
+	}
+	if _, version, ok := strings.Cut(importPath, "@"); ok {
+		return pkg.ImportPath + "@" + version
+	}
+	return pkg.ImportPath
+}
@@ -0,0 +1,89 @@
+package docinator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	"github.com/moseye/docinator/pkg/scraper"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	client, err := New(Options{Store: store, Scraper: &scraper.ScrapingConfig{TestMode: true}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	md, err := client.GetMarkdown(ctx, "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(md, "cobra") {
+		t.Errorf("Expected markdown for cobra, got %q", md)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expected the package to be cached, got %d documents", store.Len())
+	}
+
+	// Cached packages are served from the store, as edited there.
+	doc, _ := store.GetByID(ctx, "github.com/spf13/cobra")
+	doc.Package.Synopsis = "cached"
+	store.Upsert(ctx, doc)
+	pkg, err := client.GetDocs(ctx, "github.com/spf13/cobra")
+	if err != nil || pkg.Synopsis != "cached" {
+		t.Errorf("Expected the cached copy, got %+v (%v)", pkg, err)
+	}
+
+	pkg, err = client.Refresh(ctx, "github.com/spf13/cobra")
+	if err != nil || pkg.Synopsis == "cached" {
+		t.Errorf("Expected Refresh to scrape again, got %+v (%v)", pkg, err)
+	}
+
+	if _, err := client.GetDocs(ctx, "github.com/spf13/cobra@v1.9.1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if doc, _ := store.GetByID(ctx, "github.com/spf13/cobra@v1.9.1"); doc == nil || doc.Package.Version != "v1.9.1" {
+		t.Errorf("Expected the pinned version to be cached under its own ID, got %+v", doc)
+	}
+}
+
+func TestClient_NoStore(t *testing.T) {
+	client, err := New(Options{Scraper: &scraper.ScrapingConfig{TestMode: true}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer client.Close()
+	if _, err := client.GetDocs(context.Background(), "github.com/spf13/cobra"); err != nil {
+		t.Errorf("Expected scraping without a store to work, got %v", err)
+	}
+}
+
+func TestClient_BeforeStore(t *testing.T) {
+	store := memstore.New()
+	client, err := New(Options{Store: store, Scraper: &scraper.ScrapingConfig{TestMode: true}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer client.Close()
+	client.Scraper().OnBeforeStore(func(ctx context.Context, doc *models.Document) error {
+		if strings.HasPrefix(doc.ID, "internal.example.com/") {
+			return errors.New("not mirrored")
+		}
+		return nil
+	})
+	if _, err := client.Refresh(context.Background(), "internal.example.com/secret"); err == nil {
+		t.Error("Expected the hook's error")
+	}
+	if _, err := client.Refresh(context.Background(), "github.com/spf13/cobra"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expected only the unfiltered package cached, got %d documents", store.Len())
+	}
+}
@@ -0,0 +1,30 @@
+package docinator
+
+import "github.com/moseye/docinator/internal/models"
+
+// The documentation model, as GetDocs returns it and a storage.Store keeps it. The types alias
+// those of the internal model, so values pass between this package, pkg/scraper and pkg/storage
+// as they are, and programs outside the module can name them, e.g. to implement storage.Store or
+// write scraper hooks.
+type (
+	// Package is the documentation of one package at one version.
+	Package = models.Package
+	// Document is a cached Package with its raw HTML and curation data, keyed by import path
+	// (with "@version" appended for pinned versions).
+	Document = models.Document
+
+	Function         = models.Function
+	Type             = models.Type
+	Field            = models.Field
+	Variable         = models.Variable
+	Constant         = models.Constant
+	Example          = models.Example
+	SourceFile       = models.SourceFile
+	Details          = models.Details
+	Link             = models.Link
+	Guide            = models.Guide
+	ReadmeAlternate  = models.ReadmeAlternate
+	Warning          = models.Warning
+	GeneratedSummary = models.GeneratedSummary
+	AdoptionPoint    = models.AdoptionPoint
+)
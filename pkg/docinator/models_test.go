@@ -0,0 +1,55 @@
+package docinator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moseye/docinator/pkg/docinator"
+	"github.com/moseye/docinator/pkg/scraper"
+	"github.com/moseye/docinator/pkg/storage"
+)
+
+// mapStore is a storage.Store written against the public model only, as a program outside the
+// module would.
+type mapStore map[string]*docinator.Document
+
+var _ storage.Store = mapStore{}
+
+func (s mapStore) Enabled() bool                   { return true }
+func (s mapStore) Close(ctx context.Context) error { return nil }
+func (s mapStore) GetByID(ctx context.Context, id string) (*docinator.Document, error) {
+	return s[id], nil
+}
+func (s mapStore) Upsert(ctx context.Context, doc *docinator.Document) error {
+	s[doc.ID] = doc
+	return nil
+}
+func (s mapStore) Delete(ctx context.Context, id string) error {
+	delete(s, id)
+	return nil
+}
+func (s mapStore) ForEach(ctx context.Context, fn func(*docinator.Document) error) error {
+	for _, doc := range s {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestClient_PublicModel(t *testing.T) {
+	store := mapStore{}
+	client, err := docinator.New(docinator.Options{Store: store, Scraper: &scraper.ScrapingConfig{TestMode: true}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	var pkg *docinator.Package
+	if pkg, err = client.GetDocs(context.Background(), "github.com/spf13/cobra@v1.9.1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if doc := store["github.com/spf13/cobra@v1.9.1"]; doc == nil || doc.Package != pkg {
+		t.Errorf("Expected the package cached in the store under its pinned ID, got %v", store)
+	}
+}
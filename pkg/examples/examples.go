@@ -0,0 +1,272 @@
+// Package examples turns the examples of a scraped package back into example test files that
+// go test compiles and runs.
+package examples
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// File is a generated example test file.
+type File struct {
+	Name       string   // e.g. "example_command_execute_test.go"
+	Func       string   // example function, e.g. "ExampleCommand_Execute"
+	Content    []byte   // gofmt-formatted source
+	Unresolved []string // package names the code uses that no import could be inferred for
+}
+
+// stdlib maps the names of commonly used standard library packages to their import paths, for
+// examples shown without their imports.
+var stdlib = map[string]string{
+	"bufio": "bufio", "bytes": "bytes", "context": "context", "errors": "errors", "flag": "flag",
+	"fmt": "fmt", "io": "io", "log": "log", "math": "math", "os": "os", "path": "path",
+	"reflect": "reflect", "regexp": "regexp", "sort": "sort", "strconv": "strconv",
+	"strings": "strings", "sync": "sync", "time": "time", "unicode": "unicode",
+	"atomic": "sync/atomic", "base64": "encoding/base64", "csv": "encoding/csv",
+	"exec": "os/exec", "filepath": "path/filepath", "fs": "io/fs", "hex": "encoding/hex",
+	"http": "net/http", "httptest": "net/http/httptest", "json": "encoding/json",
+	"maps": "maps", "rand": "math/rand", "signal": "os/signal", "slices": "slices",
+	"slog": "log/slog", "sha256": "crypto/sha256", "tabwriter": "text/tabwriter",
+	"template": "text/template", "url": "net/url", "utf8": "unicode/utf8", "xml": "encoding/xml",
+	"net": "net", "big": "math/big", "gzip": "compress/gzip", "zip": "archive/zip",
+	"tar": "archive/tar", "heap": "container/heap", "list": "container/list",
+}
+
+// TestFiles returns an example test file per example of pkg, in the external test package
+// <name>_test. Examples shown as a complete program keep its imports and declarations, with
+// main renamed to the example function; examples shown as a function body are wrapped in one,
+// importing pkg itself and the standard library packages they use. Expected output becomes the
+// "// Output:" comment, so go test checks it. Examples that cannot be parsed are returned as
+// errors.
+func TestFiles(pkg *models.Package) ([]File, []error) {
+	var files []File
+	var errs []error
+	seen := map[string]bool{}
+	for _, ex := range pkg.AllExamples() {
+		fn := FuncName(ex.Name)
+		if seen[fn] {
+			errs = append(errs, fmt.Errorf("example %s: %s is already written", ex.Name, fn))
+			continue
+		}
+		seen[fn] = true
+		f, err := testFile(pkg, ex, fn)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("example %s: %w", ex.Name, err))
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, errs
+}
+
+// Entry describes an example for listings.
+type Entry struct {
+	Name      string // pkg.go.dev example name, e.g. "Command.Execute-withArgs"
+	Symbol    string // declaration it belongs to: a function, type, Type.Method, or "package"
+	Func      string // example function, as FuncName returns it
+	Lines     int    // lines of code
+	HasOutput bool
+}
+
+// List describes every example of pkg in the order pkg.go.dev shows them: package examples
+// first, then those of functions, types and methods.
+func List(pkg *models.Package) []Entry {
+	var entries []Entry
+	add := func(symbol string, examples []models.Example) {
+		for _, ex := range examples {
+			lines := 0
+			if code := strings.TrimSpace(ex.Code); code != "" {
+				lines = strings.Count(code, "\n") + 1
+			}
+			entries = append(entries, Entry{Name: ex.Name, Symbol: symbol, Func: FuncName(ex.Name), Lines: lines, HasOutput: ex.Output != ""})
+		}
+	}
+	add("package", pkg.Examples)
+	for _, f := range pkg.Functions {
+		add(f.Name, f.Examples)
+	}
+	for _, t := range pkg.Types {
+		add(t.Name, t.Examples)
+		for _, m := range t.Methods {
+			name := m.Name
+			if !strings.Contains(name, ".") {
+				name = t.Name + "." + name
+			}
+			add(name, m.Examples)
+		}
+	}
+	return entries
+}
+
+// FuncName returns the Go example function for a pkg.go.dev example name: "Command.Execute"
+// becomes ExampleCommand_Execute, "New-withOptions" ExampleNew_withOptions and "package" Example.
+// Names already in Go form are kept.
+func FuncName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "Example") && !strings.ContainsAny(name, ".-") {
+		return name
+	}
+	symbol, suffix, _ := strings.Cut(name, "-")
+	fn := "Example"
+	if symbol != "package" && symbol != "" {
+		fn += strings.ReplaceAll(symbol, ".", "_")
+	}
+	if suffix != "" {
+		// Suffixes must start with a lower-case letter for go vet to accept them.
+		r := []rune(suffix)
+		r[0] = unicode.ToLower(r[0])
+		fn += "_" + string(r)
+	}
+	return fn
+}
+
+// fileName returns the file name of the example function fn.
+func fileName(fn string) string {
+	name := strings.TrimPrefix(fn, "Example")
+	name = strings.Trim(strings.ToLower(name), "_")
+	if name == "" {
+		name = "package"
+	}
+	return "example_" + name + "_test.go"
+}
+
+func testFile(pkg *models.Package, ex *models.Example, fn string) (File, error) {
+	code := strings.TrimSpace(ex.Code)
+	var src string
+	var unresolved []string
+	var err error
+	if strings.HasPrefix(code, "package ") {
+		src, err = fromProgram(pkg, code, fn, ex.Output)
+	} else {
+		src, unresolved, err = fromBody(pkg, code, fn, ex.Output)
+	}
+	if err != nil {
+		return File{}, err
+	}
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		return File{}, err
+	}
+	return File{Name: fileName(fn), Func: fn, Content: out, Unresolved: unresolved}, nil
+}
+
+// outputComment returns the "// Output:" comment checking output, or "" without one.
+func outputComment(output string) string {
+	if output == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\t// Output:\n")
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			b.WriteString("\t//\n")
+		} else {
+			b.WriteString("\t// " + line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// fromProgram rewrites a complete example program: package main becomes the external test
+// package and func main the example function, which gets the output comment.
+func fromProgram(pkg *models.Package, code, fn, output string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "example.go", code, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+	type edit struct {
+		from, to int
+		text     string
+	}
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+	edits := []edit{{offset(file.Name.Pos()), offset(file.Name.End()), pkg.Name + "_test"}}
+	found := false
+	for _, d := range file.Decls {
+		if f, ok := d.(*ast.FuncDecl); ok && f.Recv == nil && f.Name.Name == "main" {
+			found = true
+			edits = append(edits, edit{offset(f.Name.Pos()), offset(f.Name.End()), fn})
+			rbrace := offset(f.Body.Rbrace)
+			edits = append(edits, edit{rbrace, rbrace, "\n" + outputComment(output)})
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("the program has no main function")
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].from > edits[j].from })
+	for _, e := range edits {
+		code = code[:e.from] + e.text + code[e.to:]
+	}
+	return code, nil
+}
+
+// fromBody wraps an example body in the example function and infers its imports from the
+// package names it uses but does not declare.
+func fromBody(pkg *models.Package, code, fn, output string) (string, []string, error) {
+	// format.Source indents the body; adding tabs here would change multi-line raw strings.
+	decl := fmt.Sprintf("func %s() {\n%s\n%s}\n", fn, code, outputComment(output))
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "example.go", "package "+pkg.Name+"_test\n\n"+decl, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				for _, u := range file.Unresolved {
+					if u == x {
+						used[x.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	var std, unresolved []string
+	self := false
+	for name := range used {
+		switch {
+		case name == pkg.Name && !strings.Contains(strings.Split(pkg.ImportPath, "/")[0], "."):
+			std = append(std, pkg.ImportPath) // a standard library package
+		case name == pkg.Name:
+			self = true
+		case stdlib[name] != "":
+			std = append(std, stdlib[name])
+		default:
+			unresolved = append(unresolved, name)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(unresolved)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "package %s_test\n\n", pkg.Name)
+	if len(std) > 0 || self {
+		// Standard library first, then the package itself, as goimports groups them.
+		b.WriteString("import (\n")
+		for _, path := range std {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		if self {
+			if len(std) > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "\t%q\n", pkg.ImportPath)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(decl)
+	return b.String(), unresolved, nil
+}
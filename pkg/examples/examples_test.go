@@ -0,0 +1,95 @@
+package examples
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestFuncName(t *testing.T) {
+	for name, want := range map[string]string{
+		"Command.Execute":  "ExampleCommand_Execute",
+		"New-WithOptions":  "ExampleNew_withOptions",
+		"package":          "Example",
+		"package-advanced": "Example_advanced",
+		"ExampleNew_basic": "ExampleNew_basic",
+	} {
+		if got := FuncName(name); got != want {
+			t.Errorf("FuncName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTestFiles(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "strutil",
+		ImportPath: "example.com/strutil",
+		Functions: []models.Function{{Name: "Reverse", Examples: []models.Example{{
+			Name:   "Reverse",
+			Code:   "s := strutil.Reverse(\"abc\")\nfmt.Println(strings.ToUpper(s))\nfmt.Println()",
+			Output: "CBA\n",
+		}}}},
+		Examples: []models.Example{
+			{
+				Name:   "package",
+				Code:   "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/strutil\"\n)\n\nfunc main() {\n\tfmt.Println(strutil.Reverse(\"ab\"))\n}\n",
+				Output: "ba",
+			},
+			{Name: "package-yaml", Code: "var v map[string]any\nyaml.Unmarshal(nil, &v)"},
+			{Name: "package-broken", Code: "func {"},
+		},
+	}
+
+	files, errs := TestFiles(pkg)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "package-broken") {
+		t.Errorf("Expected the unparsable example reported, got %v", errs)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %d", len(files))
+	}
+	byName := map[string]File{}
+	for _, f := range files {
+		byName[f.Name] = f
+	}
+
+	program := string(byName["example_package_test.go"].Content)
+	want := "package strutil_test\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/strutil\"\n)\n\nfunc Example() {\n\tfmt.Println(strutil.Reverse(\"ab\"))\n\n\t// Output:\n\t// ba\n}\n"
+	if program != want {
+		t.Errorf("Unexpected program example:\n%s\nwant:\n%s", program, want)
+	}
+
+	body := string(byName["example_reverse_test.go"].Content)
+	want = "package strutil_test\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n\n\t\"example.com/strutil\"\n)\n\nfunc ExampleReverse() {\n\ts := strutil.Reverse(\"abc\")\n\tfmt.Println(strings.ToUpper(s))\n\tfmt.Println()\n\t// Output:\n\t// CBA\n\t//\n}\n"
+	if body != want {
+		t.Errorf("Unexpected body example:\n%s\nwant:\n%s", body, want)
+	}
+
+	if f := byName["example_yaml_test.go"]; f.Func != "Example_yaml" || len(f.Unresolved) != 1 || f.Unresolved[0] != "yaml" {
+		t.Errorf("Expected yaml reported as unresolved, got %+v", f)
+	}
+}
+
+func TestList(t *testing.T) {
+	pkg := &models.Package{
+		Examples:  []models.Example{{Name: "package", Code: "fmt.Println(1)\nfmt.Println(2)", Output: "1\n2"}},
+		Functions: []models.Function{{Name: "New", Examples: []models.Example{{Name: "New-withOptions", Code: "New()"}}}},
+		Types: []models.Type{{Name: "Client", Methods: []models.Function{
+			{Name: "Do", Examples: []models.Example{{Name: "Client.Do", Code: "c.Do()", Output: "ok"}}},
+		}}},
+	}
+	want := []Entry{
+		{Name: "package", Symbol: "package", Func: "Example", Lines: 2, HasOutput: true},
+		{Name: "New-withOptions", Symbol: "New", Func: "ExampleNew_withOptions", Lines: 1},
+		{Name: "Client.Do", Symbol: "Client.Do", Func: "ExampleClient_Do", Lines: 1, HasOutput: true},
+	}
+	got := List(pkg)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
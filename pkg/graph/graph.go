@@ -0,0 +1,127 @@
+// Package graph builds the import graph of the corpus from the imports tab of every package and
+// writes it as Graphviz DOT or as a Mermaid flowchart.
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Options selects the imports drawn.
+type Options struct {
+	Std    bool // also draw imports of the standard library
+	Corpus bool // draw only imports that are themselves in the corpus
+}
+
+// Edge is an import of one package by another.
+type Edge struct {
+	From, To string
+}
+
+// Graph is the import graph of a set of packages.
+type Graph struct {
+	Nodes []string // import paths, sorted: the packages given and the imports drawn
+	Edges []Edge   // sorted by From, then To
+
+	cached map[string]bool // import paths of the packages given
+}
+
+// Build returns the import graph of pkgs, using the most recently scraped version of each import
+// path and the imports recorded from its imports tab. Packages without recorded imports are still
+// nodes, so the corpus' imports of them show.
+func Build(pkgs []*models.Package, opts Options) *Graph {
+	latest := map[string]*models.Package{}
+	for _, pkg := range pkgs {
+		if pkg == nil || pkg.ImportPath == "" {
+			continue
+		}
+		if cur := latest[pkg.ImportPath]; cur == nil || pkg.ScrapedAt.After(cur.ScrapedAt) {
+			latest[pkg.ImportPath] = pkg
+		}
+	}
+	g := &Graph{cached: map[string]bool{}}
+	for path := range latest {
+		g.cached[path] = true
+	}
+	nodes := map[string]bool{}
+	for path, pkg := range latest {
+		nodes[path] = true
+		seen := map[string]bool{}
+		for _, imp := range pkg.ImportList {
+			if imp == path || seen[imp] || (!opts.Std && isStd(imp)) || (opts.Corpus && !g.cached[imp]) {
+				continue
+			}
+			seen[imp] = true
+			nodes[imp] = true
+			g.Edges = append(g.Edges, Edge{From: path, To: imp})
+		}
+	}
+	for n := range nodes {
+		g.Nodes = append(g.Nodes, n)
+	}
+	sort.Strings(g.Nodes)
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// WriteDOT writes g as a Graphviz digraph, for dot -Tsvg and friends. Imports outside the corpus
+// are drawn dashed.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph imports {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		if g.cached[n] {
+			fmt.Fprintf(&b, "\t%s;\n", strconv.Quote(n))
+		} else {
+			fmt.Fprintf(&b, "\t%s [style=dashed];\n", strconv.Quote(n))
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes g as a Mermaid flowchart, which GitHub, GitLab and most markdown viewers
+// render inside a ```mermaid block. Nodes get short IDs, as import paths are not valid ones, and
+// imports outside the corpus are drawn dashed.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := make(map[string]string, len(g.Nodes))
+	var external []string
+	for i, n := range g.Nodes {
+		ids[n] = "n" + strconv.Itoa(i)
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", ids[n], strings.ReplaceAll(n, `"`, "#quot;"))
+		if !g.cached[n] {
+			external = append(external, ids[n])
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "    %s --> %s\n", ids[e.From], ids[e.To])
+	}
+	if len(external) > 0 {
+		b.WriteString("    classDef external stroke-dasharray: 5 5\n")
+		fmt.Fprintf(&b, "    class %s external\n", strings.Join(external, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// isStd reports whether path is in the standard library: its first element has no dot.
+func isStd(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func corpus() []*models.Package {
+	now := time.Now()
+	return []*models.Package{
+		{ImportPath: "github.com/spf13/cobra", ScrapedAt: now,
+			ImportList: []string{"fmt", "github.com/spf13/pflag", "github.com/inconshreveable/mousetrap"}},
+		{ImportPath: "github.com/spf13/pflag", ScrapedAt: now, ImportList: []string{"flag", "fmt"}},
+		{ImportPath: "github.com/spf13/cobra/doc", ScrapedAt: now,
+			ImportList: []string{"github.com/spf13/cobra", "github.com/spf13/pflag", "github.com/spf13/cobra"}},
+		// An older snapshot must not add its imports.
+		{ImportPath: "github.com/spf13/cobra/doc", ScrapedAt: now.Add(-time.Hour), ImportList: []string{"example.com/gone"}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	g := Build(corpus(), Options{})
+	wantNodes := []string{"github.com/inconshreveable/mousetrap", "github.com/spf13/cobra", "github.com/spf13/cobra/doc", "github.com/spf13/pflag"}
+	if !reflect.DeepEqual(g.Nodes, wantNodes) {
+		t.Errorf("Expected nodes %v, got %v", wantNodes, g.Nodes)
+	}
+	wantEdges := []Edge{
+		{"github.com/spf13/cobra", "github.com/inconshreveable/mousetrap"},
+		{"github.com/spf13/cobra", "github.com/spf13/pflag"},
+		{"github.com/spf13/cobra/doc", "github.com/spf13/cobra"},
+		{"github.com/spf13/cobra/doc", "github.com/spf13/pflag"},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("Expected edges %v, got %v", wantEdges, g.Edges)
+	}
+
+	if g := Build(corpus(), Options{Std: true}); len(g.Edges) != 7 {
+		t.Errorf("Expected the standard library imports with Std, got %v", g.Edges)
+	}
+	if g := Build(corpus(), Options{Corpus: true}); len(g.Nodes) != 3 || len(g.Edges) != 3 {
+		t.Errorf("Expected only imports within the corpus, got %v and %v", g.Nodes, g.Edges)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	g := Build(corpus()[:2], Options{})
+
+	var dot strings.Builder
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	want := `digraph imports {
+	rankdir=LR;
+	node [shape=box];
+	"github.com/inconshreveable/mousetrap" [style=dashed];
+	"github.com/spf13/cobra";
+	"github.com/spf13/pflag";
+	"github.com/spf13/cobra" -> "github.com/inconshreveable/mousetrap";
+	"github.com/spf13/cobra" -> "github.com/spf13/pflag";
+}
+`
+	if dot.String() != want {
+		t.Errorf("Expected DOT\n%s\ngot\n%s", want, dot.String())
+	}
+
+	var mermaid strings.Builder
+	if err := g.WriteMermaid(&mermaid); err != nil {
+		t.Fatalf("WriteMermaid failed: %v", err)
+	}
+	want = `flowchart LR
+    n0["github.com/inconshreveable/mousetrap"]
+    n1["github.com/spf13/cobra"]
+    n2["github.com/spf13/pflag"]
+    n1 --> n0
+    n1 --> n2
+    classDef external stroke-dasharray: 5 5
+    class n0 external
+`
+	if mermaid.String() != want {
+		t.Errorf("Expected Mermaid\n%s\ngot\n%s", want, mermaid.String())
+	}
+}
@@ -0,0 +1,197 @@
+// Package handbook assembles the documentation of several packages into one curated document,
+// following a YAML spec that orders them into chapters and adds sections of its own.
+package handbook
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes a handbook:
+//
+//	title: Onboarding Handbook
+//	intro:
+//	  - title: Welcome
+//	    content: |
+//	      These are the libraries every service uses.
+//	  - title: Conventions
+//	    file: conventions.md      # relative to the spec
+//	chapters:
+//	  - package: github.com/acme/log
+//	    title: Logging
+//	    intro: Use this instead of the standard log package.
+//	    no_readme: true
+//	    kinds: [func, type]
+//	  - package: github.com/acme/config@v1.4.0
+type Spec struct {
+	Title    string    `yaml:"title"`
+	NoTOC    bool      `yaml:"no_toc"` // leave out the table of contents
+	Intro    []Section `yaml:"intro"`  // sections before the first chapter
+	Chapters []Chapter `yaml:"chapters"`
+}
+
+// Section is a custom section of markdown, given inline or read from a file.
+type Section struct {
+	Title   string `yaml:"title"`
+	Content string `yaml:"content"`
+	File    string `yaml:"file"`
+}
+
+// Chapter is one package of the handbook and how to render it.
+type Chapter struct {
+	Package string `yaml:"package"` // import path, optionally pinned as path@version
+	Title   string `yaml:"title"`   // defaults to the package name and import path
+	Intro   string `yaml:"intro"`   // markdown shown before the package documentation
+
+	NoMetadata     bool     `yaml:"no_metadata"`
+	NoReadme       bool     `yaml:"no_readme"`
+	NoIndex        bool     `yaml:"no_index"`
+	NoExamples     bool     `yaml:"no_examples"`
+	SkipDeprecated bool     `yaml:"skip_deprecated"`
+	Kinds          []string `yaml:"kinds"`   // symbol kinds to keep, e.g. [func, type]
+	Include        string   `yaml:"include"` // keep only symbols matching this regexp
+	Exclude        string   `yaml:"exclude"` // drop symbols matching this regexp
+}
+
+// LoadSpec reads the YAML spec at path. Section files are read relative to its directory.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ParseSpec(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseSpec parses and validates a spec, reading section files relative to dir.
+func ParseSpec(data []byte, dir string) (*Spec, error) {
+	var spec Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+	if len(spec.Chapters) == 0 {
+		return nil, fmt.Errorf("the spec lists no chapters")
+	}
+	for i := range spec.Intro {
+		s := &spec.Intro[i]
+		if s.File == "" {
+			continue
+		}
+		if s.Content != "" {
+			return nil, fmt.Errorf("section %q has both content and file", s.Title)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, s.File))
+		if err != nil {
+			return nil, fmt.Errorf("section %q: %w", s.Title, err)
+		}
+		s.Content = string(data)
+	}
+	for _, c := range spec.Chapters {
+		if c.Package == "" {
+			return nil, fmt.Errorf("a chapter names no package")
+		}
+		if _, err := c.options(); err != nil {
+			return nil, fmt.Errorf("chapter %s: %w", c.Package, err)
+		}
+	}
+	return &spec, nil
+}
+
+// Packages returns the import paths of the chapters, in order.
+func (s *Spec) Packages() []string {
+	paths := make([]string, len(s.Chapters))
+	for i, c := range s.Chapters {
+		paths[i] = c.Package
+	}
+	return paths
+}
+
+// options returns the rendering options of the chapter. Headings are shifted below the chapter's
+// own.
+func (c Chapter) options() (markdown.Options, error) {
+	opts := markdown.Options{
+		HeadingOffset:  2,
+		NoMetadata:     c.NoMetadata,
+		NoReadme:       c.NoReadme,
+		NoIndex:        c.NoIndex,
+		NoExamples:     c.NoExamples,
+		SkipDeprecated: c.SkipDeprecated,
+	}
+	var err error
+	if opts.Kinds, err = markdown.ParseKinds(c.Kinds); err != nil {
+		return opts, err
+	}
+	if c.Include != "" {
+		if opts.IncludeSymbols, err = regexp.Compile(c.Include); err != nil {
+			return opts, fmt.Errorf("include: %w", err)
+		}
+	}
+	if c.Exclude != "" {
+		if opts.ExcludeSymbols, err = regexp.Compile(c.Exclude); err != nil {
+			return opts, fmt.Errorf("exclude: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// Build renders the handbook as markdown. pkgs maps each chapter's Package to its documentation;
+// chapters without one are listed as missing rather than failing the whole handbook.
+func Build(spec *Spec, pkgs map[string]*models.Package) string {
+	var b strings.Builder
+	if spec.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", spec.Title)
+	}
+	if !spec.NoTOC {
+		b.WriteString("## Contents\n\n")
+		for i, s := range spec.Intro {
+			fmt.Fprintf(&b, "- [%s](#intro-%d)\n", s.Title, i+1)
+		}
+		for i, c := range spec.Chapters {
+			fmt.Fprintf(&b, "- [%d. %s](#chapter-%d)\n", i+1, chapterTitle(c, pkgs[c.Package]), i+1)
+		}
+		b.WriteString("\n")
+	}
+	for i, s := range spec.Intro {
+		fmt.Fprintf(&b, "<a id=\"intro-%d\"></a>\n\n## %s\n\n%s\n\n", i+1, s.Title, strings.TrimSpace(s.Content))
+	}
+	for i, c := range spec.Chapters {
+		// The page break only matters when the HTML handbook is printed, e.g. to PDF.
+		fmt.Fprintf(&b, "<div style=\"break-before: page\"></div>\n<a id=\"chapter-%d\"></a>\n\n## %d. %s\n\n", i+1, i+1, chapterTitle(c, pkgs[c.Package]))
+		if intro := strings.TrimSpace(c.Intro); intro != "" {
+			b.WriteString(intro + "\n\n")
+		}
+		pkg := pkgs[c.Package]
+		if pkg == nil {
+			fmt.Fprintf(&b, "_The documentation of %s could not be loaded._\n\n", c.Package)
+			continue
+		}
+		opts, _ := c.options() // validated by ParseSpec
+		b.WriteString(markdown.PackageToMarkdownWithOptions(pkg, opts))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// chapterTitle returns the title of c, defaulting to the package name and import path.
+func chapterTitle(c Chapter, pkg *models.Package) string {
+	switch {
+	case c.Title != "":
+		return c.Title
+	case pkg != nil && pkg.Name != "":
+		return pkg.Name + " (" + pkg.ImportPath + ")"
+	}
+	return c.Package
+}
@@ -0,0 +1,95 @@
+package handbook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestLoadSpec(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "conventions.md"), []byte("Wrap every error.\n"), 0644)
+	spec := filepath.Join(dir, "handbook.yaml")
+	os.WriteFile(spec, []byte(`title: Platform Handbook
+intro:
+  - title: Welcome
+    content: These are the libraries every service uses.
+  - title: Conventions
+    file: conventions.md
+chapters:
+  - package: github.com/spf13/cobra
+    title: Command-line interfaces
+    kinds: [func]
+  - package: github.com/PuerkitoBio/goquery@v1.9.0
+`), 0644)
+
+	s, err := LoadSpec(spec)
+	if err != nil {
+		t.Fatalf("LoadSpec failed: %v", err)
+	}
+	if s.Intro[1].Content != "Wrap every error.\n" {
+		t.Errorf("Expected the section file to be read, got %q", s.Intro[1].Content)
+	}
+	if got := s.Packages(); len(got) != 2 || got[1] != "github.com/PuerkitoBio/goquery@v1.9.0" {
+		t.Errorf("Unexpected packages %v", got)
+	}
+
+	for name, data := range map[string]string{
+		"no chapters":   "title: Empty\n",
+		"unknown field": "chapters:\n  - package: a\n    colour: red\n",
+		"bad kind":      "chapters:\n  - package: a\n    kinds: [widget]\n",
+		"bad regexp":    "chapters:\n  - package: a\n    include: \"(\"\n",
+		"no package":    "chapters:\n  - title: Nothing\n",
+	} {
+		if _, err := ParseSpec([]byte(data), dir); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	spec := &Spec{
+		Title: "Platform Handbook",
+		Intro: []Section{{Title: "Welcome", Content: "Read this first."}},
+		Chapters: []Chapter{
+			{Package: "github.com/spf13/cobra", Title: "CLIs", Intro: "Every CLI uses cobra.", Kinds: []string{"func"}},
+			{Package: "github.com/PuerkitoBio/goquery"},
+			{Package: "example.com/missing"},
+		},
+	}
+	pkgs := map[string]*models.Package{
+		"github.com/spf13/cobra": {Name: "cobra", ImportPath: "github.com/spf13/cobra",
+			Functions: []models.Function{{Name: "OnInitialize"}},
+			Types:     []models.Type{{Name: "Command"}}},
+		"github.com/PuerkitoBio/goquery": {Name: "goquery", ImportPath: "github.com/PuerkitoBio/goquery"},
+	}
+
+	md := Build(spec, pkgs)
+	for _, want := range []string{
+		"# Platform Handbook\n",
+		"- [Welcome](#intro-1)\n",
+		"- [1. CLIs](#chapter-1)\n",
+		"- [2. goquery (github.com/PuerkitoBio/goquery)](#chapter-2)\n",
+		"## Welcome\n\nRead this first.",
+		"<a id=\"chapter-1\"></a>\n\n## 1. CLIs\n\nEvery CLI uses cobra.",
+		"### cobra package - github.com/spf13/cobra",
+		"OnInitialize",
+		"_The documentation of example.com/missing could not be loaded._",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected the handbook to contain %q, got:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "type Command") {
+		t.Errorf("Expected kinds to drop the types of the chapter")
+	}
+	if strings.Index(md, "## 1. CLIs") > strings.Index(md, "## 2. goquery") {
+		t.Errorf("Expected chapters in spec order")
+	}
+	if n := strings.Count(md, "break-before: page"); n != 3 {
+		t.Errorf("Expected a page break before each of 3 chapters, got %d", n)
+	}
+}
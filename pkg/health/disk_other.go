@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || windows)
+
+package health
+
+import (
+	"errors"
+	"runtime"
+)
+
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.New("free space is not available on " + runtime.GOOS)
+}
@@ -0,0 +1,156 @@
+// Package health runs the checks behind "docinator health" and the /healthz endpoint of
+// serve-static.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Status is the outcome of a check. A report is as bad as its worst check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn" // degraded but usable, e.g. low disk space or rate limiting
+	StatusFail Status = "fail"
+)
+
+func (s Status) rank() int {
+	switch s {
+	case StatusFail:
+		return 2
+	case StatusWarn:
+		return 1
+	}
+	return 0
+}
+
+// Check is the result of one health check.
+type Check struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	Message   string  `json:"message"`
+	LatencyMs float64 `json:"latency_ms,omitempty"`
+}
+
+// Report collects the checks of one health run.
+type Report struct {
+	Status    Status    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Check   `json:"checks"`
+}
+
+// NewReport returns a report over checks whose status is the worst of theirs.
+func NewReport(checks ...Check) *Report {
+	r := &Report{Status: StatusOK, CheckedAt: time.Now().UTC(), Checks: checks}
+	for _, c := range checks {
+		if c.Status.rank() > r.Status.rank() {
+			r.Status = c.Status
+		}
+	}
+	return r
+}
+
+// Healthy reports whether no check failed; warnings still count as healthy.
+func (r *Report) Healthy() bool {
+	return r.Status != StatusFail
+}
+
+// CheckEndpoint requests url and returns two checks: name, for reachability and latency, and
+// "rate_limit", which warns when the server answers 429 or reports no remaining requests.
+func CheckEndpoint(ctx context.Context, client *http.Client, name, url string) []Check {
+	reach := Check{Name: name}
+	limit := Check{Name: "rate_limit", Status: StatusOK, Message: "not rate limited"}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		reach.Status, reach.Message = StatusFail, err.Error()
+		return []Check{reach}
+	}
+	req.Header.Set("User-Agent", "docinator-health")
+	start := time.Now()
+	resp, err := client.Do(req)
+	reach.LatencyMs = float64(time.Since(start)) / float64(time.Millisecond)
+	if err != nil {
+		reach.Status, reach.Message = StatusFail, err.Error()
+		limit.Status, limit.Message = StatusWarn, "unknown; "+name+" is unreachable"
+		return []Check{reach, limit}
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		reach.Status, reach.Message = StatusOK, "reachable"
+		limit.Status, limit.Message = StatusWarn, "rate limited (HTTP 429)"
+		if after := resp.Header.Get("Retry-After"); after != "" {
+			limit.Message += "; retry after " + retryAfter(after)
+		}
+	case resp.StatusCode >= 500:
+		reach.Status, reach.Message = StatusFail, "HTTP "+strconv.Itoa(resp.StatusCode)
+	default:
+		reach.Status, reach.Message = StatusOK, "HTTP "+strconv.Itoa(resp.StatusCode)
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			limit.Status, limit.Message = StatusWarn, "no requests left in the current window"
+		}
+	}
+	return []Check{reach, limit}
+}
+
+// retryAfter formats a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(v string) string {
+	if secs, err := strconv.Atoi(v); err == nil {
+		return (time.Duration(secs) * time.Second).String()
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t).Round(time.Second).String()
+	}
+	return v
+}
+
+// CheckDisk reports the free space of the filesystem holding dir, warning below minFree bytes.
+func CheckDisk(dir string, minFree uint64) Check {
+	c := Check{Name: "disk"}
+	free, err := freeSpace(dir)
+	if err != nil {
+		c.Status, c.Message = StatusFail, err.Error()
+		return c
+	}
+	c.Status, c.Message = StatusOK, fmt.Sprintf("%s free at %s", formatBytes(free), dir)
+	if free < minFree {
+		c.Status = StatusWarn
+		c.Message += fmt.Sprintf(" (below %s)", formatBytes(minFree))
+	}
+	return c
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Handler serves the report of run as JSON, with status 200 when healthy and 503 otherwise,
+// for Kubernetes liveness and readiness probes.
+func Handler(run func(ctx context.Context) *Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !report.Healthy() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckEndpoint(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "30")
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	checks := CheckEndpoint(context.Background(), srv.Client(), "upstream", srv.URL)
+	if len(checks) != 2 || checks[0].Status != StatusOK || checks[1].Status != StatusOK {
+		t.Fatalf("Expected two passing checks, got %+v", checks)
+	}
+
+	status = http.StatusTooManyRequests
+	checks = CheckEndpoint(context.Background(), srv.Client(), "upstream", srv.URL)
+	if checks[0].Status != StatusOK || checks[1].Status != StatusWarn || checks[1].Message != "rate limited (HTTP 429); retry after 30s" {
+		t.Errorf("Expected a rate limit warning, got %+v", checks)
+	}
+
+	status = http.StatusBadGateway
+	checks = CheckEndpoint(context.Background(), srv.Client(), "upstream", srv.URL)
+	if checks[0].Status != StatusFail {
+		t.Errorf("Expected a 5xx to fail, got %+v", checks[0])
+	}
+
+	srv.Close()
+	checks = CheckEndpoint(context.Background(), srv.Client(), "upstream", srv.URL)
+	if checks[0].Status != StatusFail {
+		t.Errorf("Expected an unreachable server to fail, got %+v", checks[0])
+	}
+}
+
+func TestCheckDisk(t *testing.T) {
+	if c := CheckDisk(t.TempDir(), 0); c.Status != StatusOK {
+		t.Errorf("Expected free space to be reported, got %+v", c)
+	}
+	if c := CheckDisk(t.TempDir(), 1<<62); c.Status != StatusWarn {
+		t.Errorf("Expected a warning below the minimum, got %+v", c)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	report := NewReport(Check{Name: "a", Status: StatusOK}, Check{Name: "b", Status: StatusWarn})
+	if report.Status != StatusWarn || !report.Healthy() {
+		t.Errorf("Expected a healthy report with warnings, got %s", report.Status)
+	}
+
+	h := Handler(func(ctx context.Context) *Report {
+		return NewReport(Check{Name: "a", Status: StatusOK}, Check{Name: "b", Status: StatusFail})
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", rec.Code)
+	}
+	var got Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Status != StatusFail || len(got.Checks) != 2 {
+		t.Errorf("Expected the failing report as JSON, got %s (%v)", rec.Body, err)
+	}
+}
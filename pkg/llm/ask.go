@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+)
+
+// AskSystemPrompt instructs the model to answer from retrieved excerpts only and to cite them.
+const AskSystemPrompt = `You answer questions about Go packages using only the numbered documentation excerpts provided.
+Cite the excerpts you rely on inline as [n]. If the excerpts do not contain the answer, say so instead of guessing.`
+
+// AskMessages assembles a retrieval-augmented prompt for question from matches, best first,
+// keeping the excerpts within roughly budget tokens. It returns the messages and the matches that
+// made it into the prompt, numbered in citation order.
+func AskMessages(question string, matches []models.ChunkMatch, budget int) ([]Message, []models.ChunkMatch) {
+	var used []models.ChunkMatch
+	var b strings.Builder
+	remaining := budget
+	for _, m := range matches {
+		excerpt := fmt.Sprintf("[%d] %s#%s (%s)\n%s\n\n", len(used)+1, m.Source, m.Anchor, m.Heading, strings.TrimSpace(m.Text))
+		cost := utils.EstimateTokens(excerpt)
+		if cost > remaining {
+			continue
+		}
+		remaining -= cost
+		b.WriteString(excerpt)
+		used = append(used, m)
+	}
+
+	user := fmt.Sprintf("Question: %s\n\nDocumentation excerpts:\n\n%s", question, b.String())
+	return []Message{
+		{Role: "system", Content: AskSystemPrompt},
+		{Role: "user", Content: user},
+	}, used
+}
@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestAskMessages(t *testing.T) {
+	matches := []models.ChunkMatch{
+		{Chunk: models.Chunk{Source: "github.com/spf13/cobra", Anchor: "Command", Heading: "type Command", Text: "  Command is just that, a command for your application.  "}},
+		{Chunk: models.Chunk{Source: "github.com/spf13/cobra", Anchor: "overview", Heading: "Overview", Text: strings.Repeat("Cobra is a library. ", 100)}},
+		{Chunk: models.Chunk{Source: "github.com/spf13/pflag", Anchor: "FlagSet", Heading: "type FlagSet", Text: "A FlagSet represents a set of defined flags."}},
+	}
+
+	messages, used := AskMessages("What is a Command?", matches, 100)
+	if len(messages) != 2 || messages[0].Role != "system" || messages[0].Content != AskSystemPrompt || messages[1].Role != "user" {
+		t.Fatalf("Expected the system prompt and one user message, got %+v", messages)
+	}
+	// The overview does not fit in the budget; the excerpt after it still does.
+	if len(used) != 2 || used[0].Anchor != "Command" || used[1].Anchor != "FlagSet" {
+		t.Fatalf("Expected the excerpts within the budget, got %+v", used)
+	}
+	user := messages[1].Content
+	for _, want := range []string{
+		"Question: What is a Command?",
+		"[1] github.com/spf13/cobra#Command (type Command)\nCommand is just that, a command for your application.\n",
+		"[2] github.com/spf13/pflag#FlagSet (type FlagSet)\n",
+	} {
+		if !strings.Contains(user, want) {
+			t.Errorf("Expected the user message to contain %q, got:\n%s", want, user)
+		}
+	}
+	if strings.Contains(user, "Cobra is a library.") {
+		t.Error("Expected the excerpt over the budget to be left out")
+	}
+
+	if _, used := AskMessages("What is a Command?", matches, 5); len(used) != 0 {
+		t.Errorf("Expected no excerpt to fit in 5 tokens, got %+v", used)
+	}
+}
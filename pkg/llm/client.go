@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Default values used when the corresponding environment variables are unset.
+const (
+	DefaultBaseURL        = "https://api.openai.com/v1"
+	DefaultModel          = "gpt-4o-mini"
+	DefaultEmbeddingModel = "text-embedding-3-small"
+)
+
+// Message is a single chat message sent to an OpenAI-compatible endpoint.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Client talks to an OpenAI-compatible chat completions and embeddings API
+type Client struct {
+	BaseURL        string       // API base URL, e.g. https://api.openai.com/v1
+	APIKey         string       // Bearer token; optional for local endpoints
+	Model          string       // Chat model name
+	EmbeddingModel string       // Embedding model name
+	HTTPClient     *http.Client // HTTP client used for requests
+}
+
+// NewFromEnv builds a client from env:
+// - LLM_BASE_URL (default: DefaultBaseURL when LLM_API_KEY is set)
+// - LLM_API_KEY (optional for self-hosted endpoints)
+// - LLM_MODEL (default: DefaultModel)
+// - LLM_EMBEDDING_MODEL (default: DefaultEmbeddingModel)
+// It returns nil when neither LLM_BASE_URL nor LLM_API_KEY is set, meaning LLM features are disabled.
+func NewFromEnv() *Client {
+	baseURL := os.Getenv("LLM_BASE_URL")
+	apiKey := os.Getenv("LLM_API_KEY")
+	if baseURL == "" && apiKey == "" {
+		return nil
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	model := os.Getenv("LLM_MODEL")
+	if model == "" {
+		model = DefaultModel
+	}
+	embeddingModel := os.Getenv("LLM_EMBEDDING_MODEL")
+	if embeddingModel == "" {
+		embeddingModel = DefaultEmbeddingModel
+	}
+	return &Client{
+		BaseURL:        strings.TrimSuffix(baseURL, "/"),
+		APIKey:         apiKey,
+		Model:          model,
+		EmbeddingModel: embeddingModel,
+		HTTPClient:     &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+// Complete sends the messages to the chat completions endpoint and returns the first choice's content.
+func (c *Client) Complete(ctx context.Context, messages []Message) (string, error) {
+	if c == nil {
+		return "", errors.New("llm client not configured")
+	}
+	var resp chatResponse
+	if err := c.post(ctx, "/chat/completions", chatRequest{Model: c.Model, Messages: messages, Temperature: 0.2}, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("llm response contained no choices")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns one embedding vector per input text, in input order.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if c == nil {
+		return nil, errors.New("llm client not configured")
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	var resp embeddingResponse
+	if err := c.post(ctx, "/embeddings", embeddingRequest{Model: c.EmbeddingModel, Input: texts}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("llm returned %d embeddings for %d inputs", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("llm returned embedding with out-of-range index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// post encodes body as JSON, sends it to path and decodes the JSON response into out.
+func (c *Client) post(ctx context.Context, path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode llm request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("llm request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read llm response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("llm endpoint returned %d: %s", res.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode llm response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// DefaultSummaryPrompt is the system prompt used when no custom prompt is configured.
+const DefaultSummaryPrompt = `You are a senior Go engineer writing for other developers.
+Using only the package documentation provided, write 3 to 5 sentences explaining what the package does and when to use it.
+Do not invent APIs that are not listed. Answer in plain prose without headings or lists.`
+
+// maxSummaryInput caps the amount of documentation sent to the model.
+const maxSummaryInput = 12000
+
+// Summarizer generates a short "what this package does and when to use it" summary.
+type Summarizer interface {
+	Summarize(ctx context.Context, pkg *models.Package) (*models.GeneratedSummary, error)
+}
+
+// ChatSummarizer implements Summarizer on top of a chat completions Client
+type ChatSummarizer struct {
+	client *Client
+	prompt string
+}
+
+// NewSummarizer creates a ChatSummarizer; an empty prompt selects DefaultSummaryPrompt.
+func NewSummarizer(client *Client, prompt string) *ChatSummarizer {
+	if strings.TrimSpace(prompt) == "" {
+		prompt = DefaultSummaryPrompt
+	}
+	return &ChatSummarizer{client: client, prompt: prompt}
+}
+
+// Summarize asks the model for a summary of the package's scraped documentation.
+func (s *ChatSummarizer) Summarize(ctx context.Context, pkg *models.Package) (*models.GeneratedSummary, error) {
+	if pkg == nil {
+		return nil, fmt.Errorf("package cannot be nil")
+	}
+	text, err := s.client.Complete(ctx, []Message{
+		{Role: "system", Content: s.prompt},
+		{Role: "user", Content: summaryInput(pkg)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize %s: %w", pkg.ImportPath, err)
+	}
+	return &models.GeneratedSummary{
+		Text:        text,
+		Model:       s.client.Model,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// summaryInput flattens the most informative parts of a package into model input.
+func summaryInput(pkg *models.Package) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Package: %s\nImport path: %s\n", pkg.Name, pkg.ImportPath)
+	if pkg.Synopsis != "" {
+		fmt.Fprintf(&b, "Synopsis: %s\n", pkg.Synopsis)
+	}
+	if pkg.Description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", pkg.Description)
+	}
+
+	if len(pkg.Functions) > 0 {
+		b.WriteString("\nFunctions:\n")
+		for _, f := range pkg.Functions {
+			fmt.Fprintf(&b, "- %s\n", f.Signature)
+		}
+	}
+	if len(pkg.Types) > 0 {
+		b.WriteString("\nTypes:\n")
+		for _, t := range pkg.Types {
+			fmt.Fprintf(&b, "- %s: %s\n", t.Name, t.Description)
+		}
+	}
+
+	readme := pkg.ProcessedReadme
+	if readme == "" {
+		readme = pkg.Readme
+	}
+	if readme != "" {
+		b.WriteString("\nREADME:\n")
+		b.WriteString(readme)
+	}
+
+	input := b.String()
+	if len(input) > maxSummaryInput {
+		input = input[:maxSummaryInput]
+	}
+	return input
+}
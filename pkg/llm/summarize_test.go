@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestChatSummarizer_Summarize(t *testing.T) {
+	var got chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  Cobra builds CLIs.  "}}]}`))
+	}))
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, Model: "test-model", HTTPClient: srv.Client()}
+	pkg := &models.Package{
+		Name:       "cobra",
+		ImportPath: "github.com/spf13/cobra",
+		Functions:  []models.Function{{Name: "Execute", Signature: "func Execute() error"}},
+	}
+
+	summary, err := NewSummarizer(client, "").Summarize(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if summary.Text != "Cobra builds CLIs." {
+		t.Errorf("Unexpected summary text %q", summary.Text)
+	}
+	if summary.Model != "test-model" || summary.GeneratedAt.IsZero() {
+		t.Errorf("Summary should record model and generation time, got %+v", summary)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Content != DefaultSummaryPrompt {
+		t.Errorf("Expected default system prompt, got %+v", got.Messages)
+	}
+	if !strings.Contains(got.Messages[1].Content, "func Execute() error") {
+		t.Error("User message should include function signatures")
+	}
+}
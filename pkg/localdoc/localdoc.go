@@ -0,0 +1,359 @@
+// Package localdoc reads package documentation from local source with go/doc and merges it into
+// scraped packages: pkg.go.dev keeps the metadata only it knows (importers, publication date,
+// license checks), the source supplies complete doc comments and struct field docs.
+package localdoc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Finder locates the source of a package in the module cache or a vendor directory.
+type Finder struct {
+	ModCache  string // module cache root, e.g. $HOME/go/pkg/mod; empty skips it
+	VendorDir string // vendor directory of a module that depends on the package; empty skips it
+	GOROOT    string // Go installation whose src directory holds the standard library; empty skips it
+}
+
+// DefaultModCache returns $GOMODCACHE, or $GOPATH/pkg/mod with the default GOPATH.
+func DefaultModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+// Find returns the directory holding the source of pkg at its scraped version, or "" when no
+// local copy is known to match it. Vendored copies match when vendor/modules.txt lists the same
+// version, or when either version is unknown; the standard library matches the running
+// toolchain's version only.
+func (f Finder) Find(pkg *models.Package) string {
+	if pkg.Module == "std" {
+		if f.GOROOT == "" || pkg.Version != "" && pkg.Version != runtime.Version() {
+			return ""
+		}
+		return existingDir(filepath.Join(f.GOROOT, "src", filepath.FromSlash(pkg.ImportPath)))
+	}
+	if f.VendorDir != "" {
+		if version, listed := vendoredVersion(f.VendorDir, pkg.Module); listed && (version == "" || pkg.Version == "" || version == pkg.Version) {
+			if dir := existingDir(filepath.Join(f.VendorDir, filepath.FromSlash(pkg.ImportPath))); dir != "" {
+				return dir
+			}
+		}
+	}
+	if f.ModCache == "" || pkg.Module == "" || pkg.Version == "" {
+		return ""
+	}
+	escPath, err := module.EscapePath(pkg.Module)
+	if err != nil {
+		return ""
+	}
+	escVersion, err := module.EscapeVersion(pkg.Version)
+	if err != nil {
+		return ""
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(pkg.ImportPath, pkg.Module), "/")
+	return existingDir(filepath.Join(f.ModCache, filepath.FromSlash(escPath)+"@"+escVersion, filepath.FromSlash(rel)))
+}
+
+// Locate finds the source of importPath when its module is not known, as for private packages
+// pkg.go.dev cannot see: in the vendor directory, or in the module cache under the longest module
+// path prefix of importPath, at version when set and else the highest version cached. It returns
+// the directory with the module path and version it belongs to, or "" when there is no copy.
+func (f Finder) Locate(importPath, version string) (dir, modulePath, modVersion string) {
+	if f.VendorDir != "" {
+		if dir := existingDir(filepath.Join(f.VendorDir, filepath.FromSlash(importPath))); dir != "" {
+			return dir, "", ""
+		}
+	}
+	if f.ModCache == "" {
+		return "", "", ""
+	}
+	for prefix := importPath; prefix != "." && prefix != "/"; prefix = path.Dir(prefix) {
+		escPath, err := module.EscapePath(prefix)
+		if err != nil {
+			continue
+		}
+		roots, _ := filepath.Glob(filepath.Join(f.ModCache, filepath.FromSlash(escPath)+"@*"))
+		best, bestRoot := "", ""
+		for _, root := range roots {
+			_, escVersion, _ := strings.Cut(filepath.Base(root), "@")
+			v, err := module.UnescapeVersion(escVersion)
+			if err != nil || !semver.IsValid(v) || (version != "" && v != version) {
+				continue
+			}
+			if best == "" || semver.Compare(v, best) > 0 {
+				best, bestRoot = v, root
+			}
+		}
+		if bestRoot == "" {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, prefix), "/")
+		if dir := existingDir(filepath.Join(bestRoot, filepath.FromSlash(rel))); dir != "" {
+			return dir, prefix, best
+		}
+	}
+	return "", "", ""
+}
+
+func existingDir(dir string) string {
+	if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+		return dir
+	}
+	return ""
+}
+
+// vendoredVersion looks modulePath up in vendorDir/modules.txt ("# path version" lines) and
+// returns its version and whether it is listed.
+func vendoredVersion(vendorDir, modulePath string) (string, bool) {
+	f, err := os.Open(filepath.Join(vendorDir, "modules.txt"))
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "#" && fields[1] == modulePath {
+			if len(fields) >= 3 {
+				return fields[2], true
+			}
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// Source is a package read from local source.
+type Source struct {
+	Dir  string
+	Fset *token.FileSet
+	Doc  *doc.Package
+}
+
+// Read parses the non-test Go files in dir that build for the current platform and computes
+// their exported documentation.
+func Read(dir, importPath string) (*Source, error) {
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	p, err := doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return &Source{Dir: dir, Fset: fset, Doc: p}, nil
+}
+
+// Package builds a package from local source alone, for packages pkg.go.dev cannot document.
+// Metadata only pkg.go.dev knows, such as the license and importers, is left empty.
+func Package(src *Source, importPath, modulePath, version string) *models.Package {
+	pkg := &models.Package{
+		Name:       src.Doc.Name,
+		ImportPath: importPath,
+		Module:     modulePath,
+		Version:    version,
+		Synopsis:   src.Doc.Synopsis(src.Doc.Doc),
+		Overview:   strings.TrimSpace(string(src.Doc.Markdown(src.Doc.Doc))),
+	}
+	Merge(pkg, src)
+	return pkg
+}
+
+// Merge fills pkg from src: doc comments replace shorter scraped descriptions, struct types gain
+// their documented fields, and exported declarations the scrape missed are added. Everything
+// else pkg.go.dev recorded is kept. It returns the number of declarations changed or added.
+func Merge(pkg *models.Package, src *Source) int {
+	changed := 0
+	longer := func(dst *string, text string) {
+		if text = strings.TrimSpace(text); len(text) > len(strings.TrimSpace(*dst)) {
+			*dst = text
+			changed++
+		}
+	}
+	longer(&pkg.Description, src.Doc.Doc)
+
+	for _, v := range src.Doc.Consts {
+		if i := findBlock(v.Names, func(i int) (string, string) { return pkg.Constants[i].Name, pkg.Constants[i].Value }, len(pkg.Constants)); i >= 0 {
+			longer(&pkg.Constants[i].Description, v.Doc)
+			continue
+		}
+		pkg.Constants = append(pkg.Constants, models.Constant{Name: v.Names[0], Value: src.node(v.Decl), Description: strings.TrimSpace(v.Doc)})
+		changed++
+	}
+	for _, v := range src.Doc.Vars {
+		if i := findBlock(v.Names, func(i int) (string, string) { return pkg.Variables[i].Name, pkg.Variables[i].Type }, len(pkg.Variables)); i >= 0 {
+			longer(&pkg.Variables[i].Description, v.Doc)
+			continue
+		}
+		pkg.Variables = append(pkg.Variables, models.Variable{Name: v.Names[0], Type: src.node(v.Decl), Description: strings.TrimSpace(v.Doc)})
+		changed++
+	}
+
+	funcs := append([]*doc.Func(nil), src.Doc.Funcs...)
+	for _, t := range src.Doc.Types {
+		funcs = append(funcs, t.Funcs...)
+	}
+	for _, f := range funcs {
+		if i := indexFunc(pkg.Functions, f.Name); i >= 0 {
+			longer(&pkg.Functions[i].Description, f.Doc)
+			continue
+		}
+		pkg.Functions = append(pkg.Functions, src.function(f, f.Name))
+		changed++
+	}
+
+	for _, t := range src.Doc.Types {
+		i := -1
+		for j := range pkg.Types {
+			if pkg.Types[j].Name == t.Name {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			pkg.Types = append(pkg.Types, models.Type{Name: t.Name, Definition: src.node(t.Decl)})
+			i = len(pkg.Types) - 1
+			changed++
+		}
+		typ := &pkg.Types[i]
+		longer(&typ.Description, t.Doc)
+		if fields := src.fields(t); len(fields) > 0 && len(typ.Fields) == 0 {
+			typ.Fields = fields
+			changed++
+		}
+		for _, m := range t.Methods {
+			name := t.Name + "." + m.Name
+			if j := indexFunc(typ.Methods, name); j >= 0 {
+				longer(&typ.Methods[j].Description, m.Doc)
+				continue
+			}
+			method := src.function(m, name)
+			method.Receiver = t.Name
+			typ.Methods = append(typ.Methods, method)
+			changed++
+		}
+	}
+	pkg.LocalSource = src.Dir
+	return changed
+}
+
+// findBlock returns the index of the scraped declaration block that declares any of names, or -1.
+// Blocks are matched by their name first, then by the identifiers in their code.
+func findBlock(names []string, block func(int) (name, code string), n int) int {
+	for _, byCode := range []bool{false, true} {
+		for i := range n {
+			name, code := block(i)
+			for _, want := range names {
+				if name == want || byCode && containsWord(code, want) {
+					return i
+				}
+			}
+		}
+	}
+	return -1
+}
+
+func containsWord(code, word string) bool {
+	for _, w := range strings.FieldsFunc(code, func(r rune) bool {
+		return r != '_' && !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+func indexFunc(funcs []models.Function, name string) int {
+	for i := range funcs {
+		if funcs[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// function converts a go/doc function, printing its signature without the body.
+func (s *Source) function(f *doc.Func, name string) models.Function {
+	decl := *f.Decl
+	decl.Doc, decl.Body = nil, nil
+	return models.Function{Name: name, Signature: s.node(&decl), Description: strings.TrimSpace(f.Doc)}
+}
+
+// fields lists the exported fields of a struct type with their doc or line comments.
+func (s *Source) fields(t *doc.Type) []models.Field {
+	var fields []models.Field
+	for _, spec := range t.Decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != t.Name {
+			continue
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return nil
+		}
+		for _, f := range st.Fields.List {
+			field := models.Field{Type: s.node(f.Type)}
+			if f.Tag != nil {
+				field.Tag, _ = strconv.Unquote(f.Tag.Value)
+			}
+			if f.Doc != nil {
+				field.Doc = strings.TrimSpace(f.Doc.Text())
+			} else if f.Comment != nil {
+				field.Doc = strings.TrimSpace(f.Comment.Text())
+			}
+			names := f.Names
+			if len(names) == 0 { // embedded field
+				names = []*ast.Ident{ast.NewIdent(strings.TrimPrefix(field.Type[strings.LastIndex(field.Type, ".")+1:], "*"))}
+			}
+			for _, n := range names {
+				if ast.IsExported(n.Name) {
+					field.Name = n.Name
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// node prints an AST node as gofmt would.
+func (s *Source) node(n any) string {
+	var buf bytes.Buffer
+	if err := (&printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}).Fprint(&buf, s.Fset, n); err != nil {
+		return ""
+	}
+	return buf.String()
+}
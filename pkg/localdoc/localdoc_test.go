@@ -0,0 +1,204 @@
+package localdoc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+const sample = `// Package cobra is a library for creating powerful modern CLI applications.
+//
+// It is used by Kubernetes, Hugo and the GitHub CLI.
+package cobra
+
+// Command is just that, a command for your application.
+type Command struct {
+	// Use is the one-line usage message.
+	Use string ` + "`json:\"use\"`" + `
+	Short string // short description shown in help output
+	hidden bool
+}
+
+// Execute uses the args (os.Args[1:] by default) and runs the command.
+func (c *Command) Execute() error { return nil }
+
+// Eq is used by templates.
+func Eq(a, b any) bool { return a == b }
+
+func helper() {}
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	modCache := filepath.Join(root, "mod")
+	vendor := filepath.Join(root, "vendor")
+	writeFile(t, filepath.Join(modCache, "github.com", "!puerkito!bio", "goquery@v1.10.0", "goquery.go"), "package goquery\n")
+	writeFile(t, filepath.Join(vendor, "github.com", "spf13", "cobra", "cobra.go"), "package cobra\n")
+	writeFile(t, filepath.Join(vendor, "modules.txt"), "# github.com/spf13/cobra v1.9.1\n## explicit\ngithub.com/spf13/cobra\n")
+
+	f := Finder{ModCache: modCache, VendorDir: vendor}
+	pkg := &models.Package{ImportPath: "github.com/PuerkitoBio/goquery", Module: "github.com/PuerkitoBio/goquery", Version: "v1.10.0"}
+	if got, want := f.Find(pkg), filepath.Join(modCache, "github.com", "!puerkito!bio", "goquery@v1.10.0"); got != want {
+		t.Errorf("Expected the module cache copy %s, got %q", want, got)
+	}
+	pkg.Version = "v1.9.0"
+	if got := f.Find(pkg); got != "" {
+		t.Errorf("Expected no match for another version, got %q", got)
+	}
+
+	pkg = &models.Package{ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.9.1"}
+	if got := f.Find(pkg); got != filepath.Join(vendor, "github.com", "spf13", "cobra") {
+		t.Errorf("Expected the vendored copy, got %q", got)
+	}
+	pkg.Version = "v1.8.0"
+	if got := f.Find(pkg); got != "" {
+		t.Errorf("Expected a vendored copy at another version to be skipped, got %q", got)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	modCache := t.TempDir()
+	for _, v := range []string{"v1.2.0", "v1.10.0", "v1.9.0"} {
+		writeFile(t, filepath.Join(modCache, "git.corp.example", "!team", "lib@"+v, "sub", "sub.go"), "package sub\n")
+	}
+
+	f := Finder{ModCache: modCache}
+	dir, mod, version := f.Locate("git.corp.example/Team/lib/sub", "")
+	if want := filepath.Join(modCache, "git.corp.example", "!team", "lib@v1.10.0", "sub"); dir != want || mod != "git.corp.example/Team/lib" || version != "v1.10.0" {
+		t.Errorf("Expected the highest cached version %s of git.corp.example/Team/lib, got %q %q %q", want, dir, mod, version)
+	}
+	if _, _, version := f.Locate("git.corp.example/Team/lib/sub", "v1.2.0"); version != "v1.2.0" {
+		t.Errorf("Expected the pinned version v1.2.0, got %q", version)
+	}
+	if dir, _, _ := f.Locate("git.corp.example/Team/lib/missing", ""); dir != "" {
+		t.Errorf("Expected no copy of a package missing from the module, got %q", dir)
+	}
+}
+
+func TestPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cobra.go"), sample)
+	src, err := Read(dir, "git.corp.example/cobra")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	pkg := Package(src, "git.corp.example/cobra", "git.corp.example/cobra", "v1.0.0")
+	if pkg.Name != "cobra" || pkg.Version != "v1.0.0" || pkg.LocalSource != dir {
+		t.Errorf("Expected name, version and source dir to be set, got %q %q %q", pkg.Name, pkg.Version, pkg.LocalSource)
+	}
+	if pkg.Synopsis != "Package cobra is a library for creating powerful modern CLI applications." {
+		t.Errorf("Expected the first sentence as synopsis, got %q", pkg.Synopsis)
+	}
+	if len(pkg.Types) != 1 || len(pkg.Types[0].Methods) != 1 || len(pkg.Functions) != 1 {
+		t.Errorf("Expected Command with Execute and the function Eq, got %+v %+v", pkg.Types, pkg.Functions)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cobra.go"), sample)
+	src, err := Read(dir, "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	pkg := &models.Package{
+		ImportPath:  "github.com/spf13/cobra",
+		Description: "Package cobra is a library for creating powerful modern CLI applications.",
+		ImportedBy:  177680,
+		Types: []models.Type{{
+			Name:    "Command",
+			Methods: []models.Function{{Name: "Command.Execute", Description: "Execute uses the args."}},
+		}},
+	}
+	if n := Merge(pkg, src); n == 0 {
+		t.Error("Expected Merge to report changes")
+	}
+
+	if pkg.Description != "Package cobra is a library for creating powerful modern CLI applications.\n\nIt is used by Kubernetes, Hugo and the GitHub CLI." {
+		t.Errorf("Expected the full package doc, got %q", pkg.Description)
+	}
+	if pkg.ImportedBy != 177680 || pkg.LocalSource != dir {
+		t.Errorf("Expected scraped metadata to stay and the source to be recorded, got %d %q", pkg.ImportedBy, pkg.LocalSource)
+	}
+	if got := pkg.Types[0].Methods[0].Description; got != "Execute uses the args (os.Args[1:] by default) and runs the command." {
+		t.Errorf("Expected the longer method doc, got %q", got)
+	}
+	want := []models.Field{
+		{Name: "Use", Type: "string", Tag: `json:"use"`, Doc: "Use is the one-line usage message."},
+		{Name: "Short", Type: "string", Doc: "short description shown in help output"},
+	}
+	if fields := pkg.Types[0].Fields; len(fields) != len(want) || fields[0] != want[0] || fields[1] != want[1] {
+		t.Errorf("Expected fields %+v, got %+v", want, fields)
+	}
+	if len(pkg.Functions) != 1 || pkg.Functions[0].Signature != "func Eq(a, b any) bool" {
+		t.Errorf("Expected the missing function Eq to be added with its signature, got %+v", pkg.Functions)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "cobra.go"), sample)
+	src, err := Read(dir, "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	pkg := &models.Package{
+		ImportPath:  "github.com/spf13/cobra",
+		Description: "Package cobra is a library for creating powerful modern CLI applications.",
+		Functions: []models.Function{
+			{Name: "Eq", Description: "Eq is used by templates."},
+			{Name: "Gt", Description: "Gt compares."},
+		},
+		Types: []models.Type{{
+			Name:        "Command",
+			Description: "Command is just that, a command for your application.",
+			Methods:     []models.Function{{Name: "Command.Execute", Description: "Execute runs the command."}},
+		}},
+	}
+	got := map[string]string{}
+	for _, d := range Compare(pkg, src) {
+		got[d.Symbol] = d.Kind
+	}
+	want := map[string]string{"Command.Execute": DriftStale, "Gt": DriftRemoved}
+	if len(got) != len(want) || got["Command.Execute"] != want["Command.Execute"] || got["Gt"] != want["Gt"] {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	pkg.Functions = pkg.Functions[:0]
+	if drifts := Compare(pkg, src); len(drifts) != 2 || drifts[0].Symbol != "Eq" || drifts[0].Kind != DriftMissing {
+		t.Errorf("Expected Eq to be reported missing, got %+v", drifts)
+	}
+}
+
+func TestModulePackages(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "m.go"), "package m\n")
+	writeFile(t, filepath.Join(root, "sub", "sub.go"), "package sub\n")
+	writeFile(t, filepath.Join(root, "sub", "testdata", "x.go"), "package x\n")
+	writeFile(t, filepath.Join(root, "nested", "go.mod"), "module example.com/nested\n")
+	writeFile(t, filepath.Join(root, "nested", "n.go"), "package nested\n")
+	writeFile(t, filepath.Join(root, "docs", "README.md"), "no Go here\n")
+
+	module, pkgs, err := ModulePackages(root)
+	if err != nil {
+		t.Fatalf("ModulePackages failed: %v", err)
+	}
+	if module != "example.com/m" || len(pkgs) != 2 || pkgs[0].ImportPath != "example.com/m" || pkgs[1].ImportPath != "example.com/m/sub" {
+		t.Errorf("Expected example.com/m and its sub package, got %s %+v", module, pkgs)
+	}
+}
@@ -0,0 +1,193 @@
+package localdoc
+
+import (
+	"errors"
+	"go/build"
+	"go/doc"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"golang.org/x/mod/modfile"
+)
+
+// ModulePackage is a package directory of a module on disk.
+type ModulePackage struct {
+	ImportPath string
+	Dir        string
+}
+
+// ModulePackages returns the module path declared in root/go.mod and the packages below root
+// that build for the current platform. Nested modules, vendor, testdata and directories starting
+// with "." or "_" are skipped, as the go command does.
+func ModulePackages(root string) (string, []ModulePackage, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", nil, err
+	}
+	modulePath := modfile.ModulePath(data)
+	if modulePath == "" {
+		return "", nil, errors.New(filepath.Join(root, "go.mod") + ": no module path")
+	}
+
+	var pkgs []ModulePackage
+	err = filepath.WalkDir(root, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if dir != root {
+			name := d.Name()
+			if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		if _, err := build.Default.ImportDir(dir, 0); err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+		pkgs = append(pkgs, ModulePackage{ImportPath: path.Join(modulePath, filepath.ToSlash(rel)), Dir: dir})
+		return nil
+	})
+	return modulePath, pkgs, err
+}
+
+// Drift kinds reported by Compare.
+const (
+	DriftMissing = "missing" // declared locally but absent from the published docs
+	DriftStale   = "stale"   // the published doc comment differs from the local one
+	DriftRemoved = "removed" // published but no longer declared locally
+)
+
+// Drift is a symbol whose published documentation does not match the local source.
+type Drift struct {
+	Symbol    string // "package", a declaration name, or "Type.Method"
+	Kind      string // DriftMissing, DriftStale or DriftRemoved
+	Local     string // local doc comment as plain text
+	Published string // published description
+}
+
+// Compare reports how the published pkg differs from src, the same package read from local
+// source: exported declarations missing on either side, and doc comments whose published text is
+// not the start of the local one (pkg.go.dev pages may show only the first paragraph). Constants
+// and variables are compared by block, like Merge.
+func Compare(pkg *models.Package, src *Source) []Drift {
+	var drifts []Drift
+	check := func(symbol, published, local string) {
+		text := string(src.Doc.Text(local))
+		if l, p := normalize(text), normalize(published); l != p && (p == "" || !strings.HasPrefix(l, p)) {
+			drifts = append(drifts, Drift{Symbol: symbol, Kind: DriftStale, Local: strings.TrimSpace(text), Published: published})
+		}
+	}
+	missing := func(symbol, local string) {
+		drifts = append(drifts, Drift{Symbol: symbol, Kind: DriftMissing, Local: strings.TrimSpace(string(src.Doc.Text(local)))})
+	}
+	removed := func(symbol, published string) {
+		drifts = append(drifts, Drift{Symbol: symbol, Kind: DriftRemoved, Published: published})
+	}
+	check("package", pkg.Description, src.Doc.Doc)
+
+	seen := map[int]bool{}
+	for _, v := range src.Doc.Consts {
+		i := findBlock(v.Names, func(i int) (string, string) { return pkg.Constants[i].Name, pkg.Constants[i].Value }, len(pkg.Constants))
+		if i < 0 {
+			missing(v.Names[0], v.Doc)
+			continue
+		}
+		seen[i] = true
+		check(v.Names[0], pkg.Constants[i].Description, v.Doc)
+	}
+	for i, c := range pkg.Constants {
+		if !seen[i] {
+			removed(c.Name, c.Description)
+		}
+	}
+
+	seen = map[int]bool{}
+	for _, v := range src.Doc.Vars {
+		i := findBlock(v.Names, func(i int) (string, string) { return pkg.Variables[i].Name, pkg.Variables[i].Type }, len(pkg.Variables))
+		if i < 0 {
+			missing(v.Names[0], v.Doc)
+			continue
+		}
+		seen[i] = true
+		check(v.Names[0], pkg.Variables[i].Description, v.Doc)
+	}
+	for i, v := range pkg.Variables {
+		if !seen[i] {
+			removed(v.Name, v.Description)
+		}
+	}
+
+	seen = map[int]bool{}
+	funcs := append([]*doc.Func(nil), src.Doc.Funcs...)
+	for _, t := range src.Doc.Types {
+		funcs = append(funcs, t.Funcs...)
+	}
+	for _, f := range funcs {
+		i := indexFunc(pkg.Functions, f.Name)
+		if i < 0 {
+			missing(f.Name, f.Doc)
+			continue
+		}
+		seen[i] = true
+		check(f.Name, pkg.Functions[i].Description, f.Doc)
+	}
+	for i, f := range pkg.Functions {
+		if !seen[i] {
+			removed(f.Name, f.Description)
+		}
+	}
+
+	published := map[string]*models.Type{}
+	for i := range pkg.Types {
+		published[pkg.Types[i].Name] = &pkg.Types[i]
+	}
+	for _, t := range src.Doc.Types {
+		typ := published[t.Name]
+		if typ == nil {
+			missing(t.Name, t.Doc)
+			for _, m := range t.Methods {
+				missing(t.Name+"."+m.Name, m.Doc)
+			}
+			continue
+		}
+		delete(published, t.Name)
+		check(t.Name, typ.Description, t.Doc)
+		seen := map[int]bool{}
+		for _, m := range t.Methods {
+			name := t.Name + "." + m.Name
+			i := indexFunc(typ.Methods, name)
+			if i < 0 {
+				missing(name, m.Doc)
+				continue
+			}
+			seen[i] = true
+			check(name, typ.Methods[i].Description, m.Doc)
+		}
+		for i, m := range typ.Methods {
+			if !seen[i] {
+				removed(m.Name, m.Description)
+			}
+		}
+	}
+	for _, typ := range pkg.Types {
+		if published[typ.Name] != nil {
+			removed(typ.Name, typ.Description)
+		}
+	}
+	return drifts
+}
+
+// normalize collapses the whitespace of text, so line wrapping does not count as a difference.
+func normalize(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
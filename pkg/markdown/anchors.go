@@ -0,0 +1,64 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Section anchors, as pkg.go.dev names them.
+const (
+	anchorConstants = "pkg-constants"
+	anchorVariables = "pkg-variables"
+)
+
+// symbolAnchors holds the anchor of every function, type and method of a package, by position,
+// so the index and the headings agree on them. Anchors are pkg.go.dev's IDs, the name of a
+// function or type and "Type.Method" for a method, with "-2", "-3", ... appended to names the
+// package data repeats.
+type symbolAnchors struct {
+	funcs   []string   // by index into pkg.Functions
+	types   []string   // by index into pkg.Types
+	methods [][]string // by type, then by index into its Methods
+}
+
+func newSymbolAnchors(pkg *models.Package) *symbolAnchors {
+	used := map[string]int{anchorConstants: 1, anchorVariables: 1}
+	unique := func(id string) string {
+		used[id]++
+		if n := used[id]; n > 1 {
+			return fmt.Sprintf("%s-%d", id, n)
+		}
+		return id
+	}
+	a := &symbolAnchors{}
+	for _, f := range pkg.Functions {
+		a.funcs = append(a.funcs, unique(f.Name))
+	}
+	for _, t := range pkg.Types {
+		a.types = append(a.types, unique(t.Name))
+	}
+	for _, t := range pkg.Types {
+		var methods []string
+		for _, m := range t.Methods {
+			methods = append(methods, unique(methodName(t.Name, m.Name)))
+		}
+		a.methods = append(a.methods, methods)
+	}
+	return a
+}
+
+// methodName qualifies a method name with its type, unless the parser already did.
+func methodName(typeName, name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return typeName + "." + name
+}
+
+// writeAnchor writes an HTML anchor for the heading that follows, which links of the index use
+// instead of the heading's own ID: those collide for repeated names such as String.
+func writeAnchor(b *strings.Builder, id string) {
+	fmt.Fprintf(b, "<a id=\"%s\"></a>\n\n", id)
+}
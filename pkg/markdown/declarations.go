@@ -0,0 +1,110 @@
+package markdown
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// typeLinker resolves the types a declaration of pkg refers to, for Options.LinkTypes.
+type typeLinker struct {
+	pkg   *models.Package
+	local map[string]string // type name → anchor of its section
+	other func(pkg *models.Package, qualifier, name string) string
+}
+
+// newTypeLinker links the rendered types of pkg to their sections, and qualified types to the URLs
+// opts.TypeLinks returns. It returns nil unless opts.LinkTypes is set.
+func newTypeLinker(pkg *models.Package, anchors *symbolAnchors, opts Options) *typeLinker {
+	if !opts.LinkTypes {
+		return nil
+	}
+	l := &typeLinker{pkg: pkg, local: map[string]string{}, other: opts.TypeLinks}
+	for i, t := range pkg.Types {
+		if _, ok := l.local[t.Name]; !ok {
+			l.local[t.Name] = anchors.types[i]
+		}
+	}
+	return l
+}
+
+// writeDeclaration writes a signature or type definition as a Go code block or, when l links
+// types, as an HTML <pre> block with its type references linked, as pkg.go.dev shows declarations.
+func writeDeclaration(b *strings.Builder, l *typeLinker, code string) {
+	if l == nil {
+		b.WriteString("```go\n")
+		b.WriteString(code)
+		b.WriteString("\n```\n\n")
+		return
+	}
+	b.WriteString("<pre><code>")
+	b.WriteString(l.link(code))
+	b.WriteString("</code></pre>\n\n")
+}
+
+// typeLink is a type reference to link, as byte offsets into a declaration.
+type typeLink struct {
+	start, end int
+	url        string
+}
+
+// link returns code, HTML-escaped, with the type references it can resolve linked. Declarations
+// that do not parse as Go are escaped only.
+func (l *typeLinker) link(code string) string {
+	const prefix = "package p\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", prefix+code, parser.SkipObjectResolution)
+	if err != nil {
+		return html.EscapeString(code)
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset - len(prefix) }
+
+	// Names a declaration introduces are not references, even when a type shares them.
+	declared := map[*ast.Ident]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			for _, name := range n.Names {
+				declared[name] = true
+			}
+		case *ast.TypeSpec:
+			declared[n.Name] = true
+		case *ast.FuncDecl:
+			declared[n.Name] = true
+		}
+		return true
+	})
+	var links []typeLink
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && l.other != nil {
+				if url := l.other(l.pkg, x.Name, n.Sel.Name); url != "" {
+					links = append(links, typeLink{offset(n.Pos()), offset(n.End()), url})
+				}
+			}
+			return false
+		case *ast.Ident:
+			if anchor, ok := l.local[n.Name]; ok && !declared[n] {
+				links = append(links, typeLink{offset(n.Pos()), offset(n.End()), "#" + anchor})
+			}
+		}
+		return true
+	})
+	sort.Slice(links, func(i, j int) bool { return links[i].start < links[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, link := range links {
+		b.WriteString(html.EscapeString(code[last:link.start]))
+		b.WriteString(`<a href="` + html.EscapeString(link.url) + `">` + html.EscapeString(code[link.start:link.end]) + "</a>")
+		last = link.end
+	}
+	b.WriteString(html.EscapeString(code[last:]))
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package markdown
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Symbol kinds accepted in Options.Kinds.
+const (
+	KindConst = "const"
+	KindVar   = "var"
+	KindFunc  = "func"
+	KindType  = "type" // types with their methods
+)
+
+// SymbolKinds lists every kind in index order.
+var SymbolKinds = []string{KindConst, KindVar, KindFunc, KindType}
+
+// ParseKinds validates kind names such as "func,type" given on the command line.
+func ParseKinds(names []string) ([]string, error) {
+	var kinds []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(SymbolKinds, name) {
+			return nil, fmt.Errorf("unknown symbol kind %q; use %s", name, strings.Join(SymbolKinds, ", "))
+		}
+		kinds = append(kinds, name)
+	}
+	return kinds, nil
+}
+
+// filtersSymbols reports whether opts drop any symbols.
+func (opts Options) filtersSymbols() bool {
+	return opts.IncludeSymbols != nil || opts.ExcludeSymbols != nil || opts.SkipDeprecated || len(opts.Kinds) > 0
+}
+
+// FilterSymbols returns a copy of pkg keeping only the symbols selected by opts; pkg itself is
+// not modified. Methods are matched by their "Type.Method" name: a type is kept when its own name
+// matches, with all of its methods, or when any of its methods does, with just those.
+func FilterSymbols(pkg *models.Package, opts Options) *models.Package {
+	if !opts.filtersSymbols() {
+		return pkg
+	}
+	out := *pkg
+	out.Constants, out.Variables, out.Functions, out.Types = nil, nil, nil, nil
+	if opts.kind(KindConst) {
+		for _, c := range pkg.Constants {
+			if opts.keep(c.Name, "", c.Description) {
+				out.Constants = append(out.Constants, c)
+			}
+		}
+	}
+	if opts.kind(KindVar) {
+		for _, v := range pkg.Variables {
+			if opts.keep(v.Name, "", v.Description) {
+				out.Variables = append(out.Variables, v)
+			}
+		}
+	}
+	if opts.kind(KindFunc) {
+		for _, f := range pkg.Functions {
+			if opts.keep(f.Name, f.Deprecated, f.Description) {
+				out.Functions = append(out.Functions, f)
+			}
+		}
+	}
+	if opts.kind(KindType) {
+		for _, t := range pkg.Types {
+			if opts.SkipDeprecated && deprecated(t.Deprecated, t.Description) || opts.excluded(t.Name) {
+				continue
+			}
+			typeMatches := opts.IncludeSymbols == nil || opts.IncludeSymbols.MatchString(t.Name)
+			var methods []models.Function
+			for _, m := range t.Methods {
+				if (typeMatches || opts.IncludeSymbols.MatchString(m.Name)) && !opts.excluded(m.Name) &&
+					!(opts.SkipDeprecated && deprecated(m.Deprecated, m.Description)) {
+					methods = append(methods, m)
+				}
+			}
+			if typeMatches || len(methods) > 0 {
+				t.Methods = methods
+				out.Types = append(out.Types, t)
+			}
+		}
+	}
+	return &out
+}
+
+func (opts Options) kind(kind string) bool {
+	return len(opts.Kinds) == 0 || slices.Contains(opts.Kinds, kind)
+}
+
+func (opts Options) excluded(name string) bool {
+	return opts.ExcludeSymbols != nil && opts.ExcludeSymbols.MatchString(name)
+}
+
+func (opts Options) keep(name, deprecatedTag, description string) bool {
+	if opts.IncludeSymbols != nil && !opts.IncludeSymbols.MatchString(name) {
+		return false
+	}
+	return !opts.excluded(name) && !(opts.SkipDeprecated && deprecated(deprecatedTag, description))
+}
+
+// deprecated reports whether a symbol is marked deprecated on pkg.go.dev or by a "Deprecated:"
+// paragraph in its doc comment.
+func deprecated(tag, description string) bool {
+	return tag != "" || strings.HasPrefix(description, "Deprecated:") || strings.Contains(description, "\nDeprecated:")
+}
@@ -0,0 +1,171 @@
+package markdown
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"slices"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// writeIndex writes the symbol index in pkg.go.dev's order: the Constants and Variables links,
+// the package-level functions, then the types, each followed by its constructors and methods
+// indented below it. Functions, types and methods are sorted by name within their group and link
+// to the anchors in a. With descLen above 0, each entry is followed by the start of its
+// description, up to descLen characters.
+func writeIndex(b *strings.Builder, pkg *models.Package, a *symbolAnchors, descLen int) {
+	if len(pkg.Constants) > 0 {
+		b.WriteString("- [Constants](#" + anchorConstants + ")\n")
+	}
+	if len(pkg.Variables) > 0 {
+		b.WriteString("- [Variables](#" + anchorVariables + ")\n")
+	}
+
+	typeNames := map[string]bool{}
+	for _, t := range pkg.Types {
+		typeNames[t.Name] = true
+	}
+	constructors := map[string][]int{}
+	var funcs []int
+	for i, f := range pkg.Functions {
+		if typ := constructedType(f.Signature, typeNames); typ != "" {
+			constructors[typ] = append(constructors[typ], i)
+		} else {
+			funcs = append(funcs, i)
+		}
+	}
+	byName := func(fs []models.Function) func(i, j int) int {
+		return func(i, j int) int { return strings.Compare(fs[i].Name, fs[j].Name) }
+	}
+
+	slices.SortStableFunc(funcs, byName(pkg.Functions))
+	for _, i := range funcs {
+		f := pkg.Functions[i]
+		writeIndexEntry(b, "", indexSignature(f.Signature, "func "+f.Name), a.funcs[i], indexDescription(f.Description, descLen))
+	}
+
+	types := make([]int, len(pkg.Types))
+	for i := range types {
+		types[i] = i
+	}
+	slices.SortStableFunc(types, func(i, j int) int { return strings.Compare(pkg.Types[i].Name, pkg.Types[j].Name) })
+	for _, ti := range types {
+		t := pkg.Types[ti]
+		writeIndexEntry(b, "", "type "+t.Name, a.types[ti], indexDescription(t.Description, descLen))
+		ctors := constructors[t.Name]
+		slices.SortStableFunc(ctors, byName(pkg.Functions))
+		for _, i := range ctors {
+			f := pkg.Functions[i]
+			writeIndexEntry(b, "  ", indexSignature(f.Signature, "func "+f.Name), a.funcs[i], indexDescription(f.Description, descLen))
+		}
+		methods := make([]int, len(t.Methods))
+		for i := range methods {
+			methods[i] = i
+		}
+		slices.SortStableFunc(methods, byName(t.Methods))
+		for _, i := range methods {
+			m := t.Methods[i]
+			writeIndexEntry(b, "  ", indexSignature(m.Signature, "func "+m.Name), a.methods[ti][i], indexDescription(m.Description, descLen))
+		}
+	}
+	b.WriteString("\n")
+}
+
+func writeIndexEntry(b *strings.Builder, indent, label, anchor, desc string) {
+	if desc != "" {
+		b.WriteString(fmt.Sprintf("%s- [`%s`](#%s) — %s\n", indent, label, anchor, desc))
+		return
+	}
+	b.WriteString(fmt.Sprintf("%s- [`%s`](#%s)\n", indent, label, anchor))
+}
+
+// indexDescription returns the first paragraph of desc on one line, cut at the last word boundary
+// within n characters and marked with an ellipsis when cut. It returns "" when n is 0.
+func indexDescription(desc string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	para, _, _ := strings.Cut(strings.TrimSpace(desc), "\n\n")
+	flat := []rune(strings.Join(strings.Fields(para), " "))
+	if len(flat) <= n {
+		return string(flat)
+	}
+	cut := string(flat[:n])
+	if i := strings.LastIndex(cut, " "); i > 0 && flat[n] != ' ' {
+		cut = cut[:i] // mid-word
+	}
+	return strings.TrimRight(cut, " ,;:.") + "…"
+}
+
+// indexSignature returns the signature on one line, as the pkg.go.dev index shows it, or
+// fallback when the signature is unknown.
+func indexSignature(sig, fallback string) string {
+	sig = strings.Join(strings.Fields(sig), " ")
+	sig = strings.ReplaceAll(sig, "( ", "(")
+	sig = strings.ReplaceAll(sig, ", )", ")")
+	return cmp.Or(sig, fallback)
+}
+
+// constructedType returns the package type a function constructs, following go/doc: a function
+// whose results include exactly one type declared in the package (T, *T, []T or []*T) is listed
+// with that type. It returns "" for other functions and signatures that do not parse.
+func constructedType(sig string, typeNames map[string]bool) string {
+	if sig == "" {
+		return ""
+	}
+	file, err := goparser.ParseFile(token.NewFileSet(), "", "package p\n"+sig, 0)
+	if err != nil || len(file.Decls) != 1 {
+		return ""
+	}
+	fn, ok := file.Decls[0].(*ast.FuncDecl)
+	if !ok || fn.Recv != nil || fn.Type.Results == nil {
+		return ""
+	}
+	typeParams := map[string]bool{}
+	if fn.Type.TypeParams != nil {
+		for _, f := range fn.Type.TypeParams.List {
+			for _, n := range f.Names {
+				typeParams[n.Name] = true
+			}
+		}
+	}
+
+	found := ""
+	for _, res := range fn.Type.Results.List {
+		expr := res.Type
+		if arr, ok := expr.(*ast.ArrayType); ok {
+			expr = arr.Elt
+		}
+		name := baseTypeName(expr)
+		if name == "" || typeParams[name] || !typeNames[name] {
+			continue
+		}
+		if found != "" {
+			return ""
+		}
+		found = name
+	}
+	return found
+}
+
+// baseTypeName returns the name of a local named type, through pointers and type arguments,
+// or "" for imported and unnamed types.
+func baseTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexListExpr:
+		return baseTypeName(t.X)
+	case *ast.ParenExpr:
+		return baseTypeName(t.X)
+	}
+	return ""
+}
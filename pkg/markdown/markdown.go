@@ -1,78 +1,136 @@
 package markdown
 
 import (
+	"cmp"
 	"fmt"
+	"html"
 	"strings"
 
 	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 // PackageToMarkdown converts a Package struct to a professional markdown formatted string matching pkg.go.dev style.
 func PackageToMarkdown(pkg *models.Package) string {
+	return render(pkg, Options{})
+}
+
+// render writes the markdown of pkg, leaving out the sections opts turns off.
+func render(pkg *models.Package, opts Options) string {
 	var b strings.Builder
 
 	// Professional header with import path (expected format)
 	header := fmt.Sprintf("# %s package - %s", pkg.Name, pkg.ImportPath)
 	b.WriteString(header + "\n\n")
 
+	// Notices that change how the documentation applies
+	writeNotices(&b, pkg)
+
 	// Package metadata section
-	b.WriteString("## Package Documentation\n\n")
+	if !opts.NoMetadata {
+		b.WriteString("## Package Documentation\n\n")
 
-	// Import Path
-	if pkg.ImportPath != "" {
-		b.WriteString(fmt.Sprintf("**Import Path:** `%s`\n\n", pkg.ImportPath))
-	}
+		// Import Path
+		if pkg.ImportPath != "" {
+			b.WriteString(fmt.Sprintf("**Import Path:** `%s`\n\n", pkg.ImportPath))
+		}
 
-	// Module
-	if pkg.Module != "" {
-		b.WriteString(fmt.Sprintf("**Module:** %s\n\n", pkg.Module))
-	}
+		// Module
+		if pkg.Module != "" {
+			b.WriteString(fmt.Sprintf("**Module:** %s\n\n", pkg.Module))
+		}
 
-	// Version with status
-	if pkg.Version != "" {
-		versionText := pkg.Version
-		if pkg.IsLatest {
-			versionText += " (Latest)"
+		// Version with status
+		if pkg.Version != "" {
+			versionText := pkg.Version
+			if note := versionNote(pkg.Version); note != "" {
+				versionText += " (" + note + ")"
+			}
+			if pkg.IsLatest {
+				versionText += " (Latest)"
+			}
+			b.WriteString(fmt.Sprintf("**Version:** %s\n\n", versionText))
 		}
-		b.WriteString(fmt.Sprintf("**Version:** %s\n\n", versionText))
-	}
 
-	// Published date
-	if pkg.Published != "" {
-		b.WriteString(fmt.Sprintf("**Published:** %s\n\n", pkg.Published))
-	}
+		// Published date
+		if pkg.Published != "" {
+			b.WriteString(fmt.Sprintf("**Published:** %s\n\n", pkg.Published))
+		}
 
-	// Imports
-	if pkg.Imports > 0 {
-		b.WriteString(fmt.Sprintf("**Imports:** %d\n\n", pkg.Imports))
-	}
+		// Minimum Go version
+		if pkg.GoVersion != "" {
+			b.WriteString(fmt.Sprintf("**Go Version:** %s\n\n", pkg.GoVersion))
+		}
 
-	// Imported By (comma formatting for readability)
-	if pkg.ImportedBy > 0 {
-		b.WriteString(fmt.Sprintf("**Imported By:** %s\n\n", formatNumber(pkg.ImportedBy)))
-	}
+		// Imports
+		if pkg.Imports > 0 {
+			b.WriteString(fmt.Sprintf("**Imports:** %d\n\n", pkg.Imports))
+		}
 
-	// License with link
-	if pkg.License != "" && pkg.LicenseURL != "" {
-		b.WriteString(fmt.Sprintf("**License:** [%s](%s)\n\n", pkg.License, pkg.LicenseURL))
-	} else if pkg.License != "" {
-		b.WriteString(fmt.Sprintf("**License:** %s\n\n", pkg.License))
-	}
+		// Imported By (comma formatting for readability)
+		if pkg.ImportedBy > 0 {
+			b.WriteString(fmt.Sprintf("**Imported By:** %s\n\n", formatNumber(pkg.ImportedBy)))
+		}
+
+		// Sample of known importers
+		if len(pkg.Importers) > 0 {
+			b.WriteString(fmt.Sprintf("**Known Importers (sample of %d):**\n\n", len(pkg.Importers)))
+			for _, imp := range pkg.Importers {
+				b.WriteString(fmt.Sprintf("- [`%s`](https://pkg.go.dev/%s)\n", imp, imp))
+			}
+			b.WriteString("\n")
+		}
+
+		// License with link
+		if pkg.License != "" && pkg.LicenseURL != "" {
+			b.WriteString(fmt.Sprintf("**License:** [%s](%s)\n\n", pkg.License, pkg.LicenseURL))
+		} else if pkg.License != "" {
+			b.WriteString(fmt.Sprintf("**License:** %s\n\n", pkg.License))
+		}
+
+		// Repository link
+		if pkg.Repository != "" {
+			// Display a clean label (strip scheme) but keep full URL for the link
+			label := pkg.Repository
+			if strings.HasPrefix(label, "https://") {
+				label = strings.TrimPrefix(label, "https://")
+			} else if strings.HasPrefix(label, "http://") {
+				label = strings.TrimPrefix(label, "http://")
+			}
+			b.WriteString(fmt.Sprintf("**Repository:** [%s](%s)\n\n", label, pkg.Repository))
+		}
+
+		// Details checklist as a quality table
+		if d := pkg.Details; d != nil {
+			b.WriteString("| Details | |\n|---|---|\n")
+			b.WriteString(fmt.Sprintf("| Valid go.mod file | %s |\n", checkmark(d.ValidGoMod)))
+			b.WriteString(fmt.Sprintf("| Redistributable license | %s |\n", checkmark(d.RedistributableLicense)))
+			b.WriteString(fmt.Sprintf("| Tagged version | %s |\n", checkmark(d.TaggedVersion)))
+			b.WriteString(fmt.Sprintf("| Stable version | %s |\n\n", checkmark(d.StableVersion)))
+		}
 
-	// Repository link
-	if pkg.Repository != "" {
-		// Display a clean label (strip scheme) but keep full URL for the link
-		label := pkg.Repository
-		if strings.HasPrefix(label, "https://") {
-			label = strings.TrimPrefix(label, "https://")
-		} else if strings.HasPrefix(label, "http://") {
-			label = strings.TrimPrefix(label, "http://")
+		// Links declared by the module, often including the real docs site
+		if len(pkg.Links) > 0 {
+			b.WriteString("## Links\n\n")
+			for _, l := range pkg.Links {
+				b.WriteString(fmt.Sprintf("- [%s](%s)\n", l.Label, l.URL))
+			}
+			b.WriteString("\n")
 		}
-		b.WriteString(fmt.Sprintf("**Repository:** [%s](%s)\n\n", label, pkg.Repository))
 	}
 
-	// Overview/Synopsis
-	if pkg.Synopsis != "" {
+	// Withheld documentation: the metadata is all there is
+	if pkg.LicenseRestricted() {
+		return b.String()
+	}
+
+	// Overview: the whole package doc when parsed, else the synopsis or first paragraph
+	if pkg.Overview != "" {
+		b.WriteString("## Overview\n\n")
+		b.WriteString(pkg.Overview + "\n\n")
+	} else if pkg.Synopsis != "" {
 		b.WriteString("## Overview\n\n")
 		b.WriteString(pkg.Synopsis + "\n\n")
 	} else if pkg.Description != "" {
@@ -80,63 +138,78 @@ func PackageToMarkdown(pkg *models.Package) string {
 		b.WriteString(pkg.Description + "\n\n")
 	}
 
-	// README section with processed markdown
-	b.WriteString("## README\n\n")
-	if pkg.ProcessedReadme != "" {
-		b.WriteString(pkg.ProcessedReadme)
-	} else if pkg.Readme != "" {
-		// Fallback to raw HTML if not processed
-		b.WriteString(pkg.Readme)
+	// Generated summary, clearly separated from upstream documentation
+	if pkg.Summary != nil && pkg.Summary.Text != "" {
+		b.WriteString("## What This Package Does (Generated)\n\n")
+		b.WriteString(fmt.Sprintf("> _Generated by %s on %s. This section is not part of the upstream documentation._\n\n",
+			pkg.Summary.Model, pkg.Summary.GeneratedAt.Format("2006-01-02")))
+		b.WriteString(pkg.Summary.Text + "\n\n")
 	}
-	b.WriteString("\n\n")
 
-	// Documentation Index
-	b.WriteString("## Documentation\n\n")
-	b.WriteString("### Index\n\n")
-
-	// Index entries for constants, variables, functions, types
-	if len(pkg.Constants) > 0 {
-		b.WriteString("#### Constants\n")
-		for _, c := range pkg.Constants {
-			b.WriteString(fmt.Sprintf("- [`%s`](#pkg-constants)\n", c.Name))
+	// README section with processed markdown; a partial scrape may not have parsed one
+	if !opts.NoReadme && (pkg.Profile == "" || cmp.Or(pkg.ProcessedReadme, pkg.Readme) != "") {
+		b.WriteString("## README\n\n")
+		writeReadmeAlternates(&b, pkg.ReadmeAlternates)
+		// Fallback to raw HTML if not processed
+		readme := cmp.Or(pkg.ProcessedReadme, pkg.Readme)
+		if opts.StripBadges {
+			readme = utils.StripBadges(readme, opts.BadgePatterns)
+		}
+		if opts.ExpandEmoji {
+			readme = utils.ExpandEmoji(readme)
+		}
+		if opts.Collapse && strings.Count(readme, "\n") >= CollapseReadmeLines {
+			writeDetails(&b, "README", strings.TrimSpace(readme))
+		} else {
+			b.WriteString(readme)
+			b.WriteString("\n\n")
 		}
-		b.WriteString("\n")
 	}
 
-	if len(pkg.Variables) > 0 {
-		b.WriteString("#### Variables\n")
-		for _, v := range pkg.Variables {
-			b.WriteString(fmt.Sprintf("- [`%s`](#pkg-variables)\n", v.Name))
+	// Guides linked from the README, with their headings nested below the guide title
+	if len(pkg.Guides) > 0 {
+		b.WriteString("## Guides\n\n")
+		for _, g := range pkg.Guides {
+			b.WriteString(fmt.Sprintf("### %s\n\n", cmp.Or(g.Title, g.URL)))
+			b.WriteString(fmt.Sprintf("_From [%s](%s)_\n\n", g.URL, g.URL))
+			if content := g.Content; content != "" {
+				if opts.StripBadges {
+					content = utils.StripBadges(content, opts.BadgePatterns)
+				}
+				if opts.ExpandEmoji {
+					content = utils.ExpandEmoji(content)
+				}
+				b.WriteString(ShiftHeadings(strings.TrimSpace(content), 3) + "\n\n")
+			}
 		}
-		b.WriteString("\n")
 	}
 
-	if len(pkg.Functions) > 0 {
-		b.WriteString("#### Functions\n")
-		for _, f := range pkg.Functions {
-			// Use exact id-based anchor produced by pkg.go.dev (case-sensitive)
-			b.WriteString(fmt.Sprintf("- [`%s`](#%s)\n", f.Name, f.Name))
-		}
-		b.WriteString("\n")
+	// Packages scraped with a limited profile (scrape --profile) have no API documentation
+	if pkg.Profile != "" {
+		writeScrapedAt(&b, pkg)
+		return b.String()
 	}
 
-	if len(pkg.Types) > 0 {
-		b.WriteString("#### Types\n")
-		for _, t := range pkg.Types {
-			// Use exact id-based anchor for types
-			b.WriteString(fmt.Sprintf("- [`%s`](#%s)\n", t.Name, t.Name))
-		}
-		b.WriteString("\n")
+	// Documentation Index
+	b.WriteString("## Documentation\n\n")
+	anchors := newSymbolAnchors(pkg)
+	links := newTypeLinker(pkg, anchors, opts)
+	if !opts.NoIndex {
+		b.WriteString("### Index\n\n")
+		writeIndex(&b, pkg, anchors, opts.IndexDescriptions)
 	}
 
 	// Constants section
 	if len(pkg.Constants) > 0 {
+		writeAnchor(&b, anchorConstants)
 		b.WriteString("### Constants\n\n")
 		for _, c := range pkg.Constants {
 			b.WriteString(fmt.Sprintf("#### %s\n\n", c.Name))
 			// Prefer rendering constant declaration as fenced code if multi-line or looks like code
 			if c.Value != "" {
-				if strings.Contains(c.Value, "\n") || strings.Contains(c.Value, "const ") {
+				if opts.Collapse && strings.Count(c.Value, "\n") >= CollapseConstLines {
+					writeDetails(&b, fmt.Sprintf("Declaration (%d lines)", strings.Count(c.Value, "\n")+1), "```go\n"+c.Value+"\n```")
+				} else if strings.Contains(c.Value, "\n") || strings.Contains(c.Value, "const ") {
 					b.WriteString("```go\n")
 					b.WriteString(c.Value)
 					b.WriteString("\n```\n\n")
@@ -155,6 +228,7 @@ func PackageToMarkdown(pkg *models.Package) string {
 
 	// Variables section
 	if len(pkg.Variables) > 0 {
+		writeAnchor(&b, anchorVariables)
 		b.WriteString("### Variables\n\n")
 		for _, v := range pkg.Variables {
 			b.WriteString(fmt.Sprintf("#### %s\n\n", v.Name))
@@ -177,12 +251,11 @@ func PackageToMarkdown(pkg *models.Package) string {
 	// Functions section
 	if len(pkg.Functions) > 0 {
 		b.WriteString("### Functions\n\n")
-		for _, f := range pkg.Functions {
+		for i, f := range pkg.Functions {
+			writeAnchor(&b, anchors.funcs[i])
 			b.WriteString(fmt.Sprintf("#### %s\n\n", f.Name))
 			if f.Signature != "" {
-				b.WriteString("```go\n")
-				b.WriteString(f.Signature)
-				b.WriteString("\n```\n\n")
+				writeDeclaration(&b, links, f.Signature)
 			}
 			if f.Description != "" {
 				b.WriteString(f.Description)
@@ -195,20 +268,24 @@ func PackageToMarkdown(pkg *models.Package) string {
 			if f.Deprecated != "" {
 				b.WriteString("**deprecated**\n")
 			}
+			writeSource(&b, f.SourceURL, f.SourceFile, f.SourceLine)
+			if opts.Permalinks {
+				writePermalink(&b, pkg, f.Name)
+			}
 			b.WriteString("\n")
-			addExamples(&b, f.Examples)
+			writeSourceCode(&b, f.Source)
+			addExamples(&b, opts, f.Examples)
 		}
 	}
 
 	// Types section
 	if len(pkg.Types) > 0 {
 		b.WriteString("### Types\n\n")
-		for _, t := range pkg.Types {
+		for ti, t := range pkg.Types {
+			writeAnchor(&b, anchors.types[ti])
 			b.WriteString(fmt.Sprintf("#### %s\n\n", t.Name))
 			if t.Definition != "" {
-				b.WriteString("```go\n")
-				b.WriteString(t.Definition)
-				b.WriteString("\n```\n\n")
+				writeDeclaration(&b, links, t.Definition)
 			}
 			if t.Kind != "" {
 				b.WriteString(fmt.Sprintf("**Kind:** %s\n\n", t.Kind))
@@ -224,16 +301,23 @@ func PackageToMarkdown(pkg *models.Package) string {
 			if t.Deprecated != "" {
 				b.WriteString("**deprecated**\n")
 			}
+			writeSource(&b, t.SourceURL, t.SourceFile, t.SourceLine)
+			if opts.Permalinks {
+				writePermalink(&b, pkg, t.Name)
+			}
 			b.WriteString("\n")
+			writeUsedBy(&b, opts.UsedBy[t.Name])
+			writeSourceCode(&b, t.Source)
+			writeFields(&b, t.Fields)
+			writeInterfaceMethods(&b, t.InterfaceMethods)
 			// Methods
 			if len(t.Methods) > 0 {
 				b.WriteString("##### Methods\n\n")
-				for _, m := range t.Methods {
-					b.WriteString(fmt.Sprintf("###### %s\n\n", m.Name))
+				for i, m := range t.Methods {
+					writeAnchor(&b, anchors.methods[ti][i])
+					b.WriteString(fmt.Sprintf("###### %s\n\n", methodName(t.Name, m.Name)))
 					if m.Signature != "" {
-						b.WriteString("```go\n")
-						b.WriteString(m.Signature)
-						b.WriteString("\n```\n\n")
+						writeDeclaration(&b, links, m.Signature)
 					}
 					if m.Description != "" {
 						b.WriteString(m.Description)
@@ -245,26 +329,47 @@ func PackageToMarkdown(pkg *models.Package) string {
 					if m.Deprecated != "" {
 						b.WriteString("**deprecated**\n")
 					}
+					writeSource(&b, m.SourceURL, m.SourceFile, m.SourceLine)
+					if opts.Permalinks {
+						writePermalink(&b, pkg, methodName(t.Name, m.Name))
+					}
 					b.WriteString("\n")
-					addExamples(&b, m.Examples)
+					writeSourceCode(&b, m.Source)
+					addExamples(&b, opts, m.Examples)
 				}
 			}
-			addExamples(&b, t.Examples)
+			addExamples(&b, opts, t.Examples)
 		}
 	}
 
 	// Package-level examples
-	if len(pkg.Examples) > 0 {
+	if len(pkg.Examples) > 0 && !opts.NoExamples {
 		b.WriteString("### Examples\n\n")
-		addExamples(&b, pkg.Examples)
+		addExamples(&b, opts, pkg.Examples)
 	}
 
-	// Footer with scraped timestamp
-	b.WriteString(fmt.Sprintf("\n*Scraped at: %s*\n", pkg.ScrapedAt.Format("2006-01-02 15:04:05")))
+	// Source files
+	if len(pkg.Files) > 0 {
+		b.WriteString("## Source Files\n\n")
+		for _, f := range pkg.Files {
+			if f.URL != "" {
+				b.WriteString(fmt.Sprintf("- [%s](%s)\n", f.Name, f.URL))
+			} else {
+				b.WriteString(fmt.Sprintf("- %s\n", f.Name))
+			}
+		}
+		b.WriteString("\n")
+	}
 
+	writeScrapedAt(&b, pkg)
 	return b.String()
 }
 
+// writeScrapedAt writes the footer with the time pkg was scraped.
+func writeScrapedAt(b *strings.Builder, pkg *models.Package) {
+	b.WriteString(fmt.Sprintf("\n*Scraped at: %s*\n", pkg.ScrapedAt.Format("2006-01-02 15:04:05")))
+}
+
 // formatNumber formats large numbers with commas
 func formatNumber(n int) string {
 	if n < 1000 {
@@ -290,25 +395,215 @@ func addCommas(n int) string {
 	return result.String()
 }
 
-// addExamples appends example markdown to the builder
-func addExamples(b *strings.Builder, examples []models.Example) {
-	if len(examples) == 0 {
+// writeSource appends a "file:line" link to the declaration's source, when known.
+func writeSource(b *strings.Builder, url, file string, line int) {
+	if url == "" {
 		return
 	}
-	for _, ex := range examples {
-		if ex.Name != "" {
-			b.WriteString(fmt.Sprintf("###### %s\n\n", ex.Name))
+	label := file
+	if label == "" {
+		label = "source"
+	}
+	if line > 0 {
+		label = fmt.Sprintf("%s:%d", label, line)
+	}
+	b.WriteString(fmt.Sprintf("_Source: [%s](%s)_\n", label, url))
+}
+
+// writePermalink appends a link to the declaration id on the pkg.go.dev page of pkg, at its
+// version, so the link keeps pointing at the documentation rendered here.
+func writePermalink(b *strings.Builder, pkg *models.Package, id string) {
+	if pkg.ImportPath == "" {
+		return
+	}
+	page := "https://pkg.go.dev/" + pkg.ImportPath
+	if pkg.Version != "" {
+		page += "@" + pkg.Version
+	}
+	b.WriteString(fmt.Sprintf("_[View on pkg.go.dev](%s#%s)_\n", page, id))
+}
+
+// writeSourceCode appends fetched declaration source in a collapsible block.
+func writeSourceCode(b *strings.Builder, code string) {
+	if code == "" {
+		return
+	}
+	b.WriteString("<details>\n<summary>Source</summary>\n\n```go\n")
+	b.WriteString(code)
+	b.WriteString("\n```\n\n</details>\n\n")
+}
+
+// renderExamples writes the title of pkg and its examples, each group of examples headed by the
+// symbol it belongs to.
+func renderExamples(pkg *models.Package, opts Options) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s package - %s\n\n", pkg.Name, pkg.ImportPath))
+	if pkg.LicenseRestricted() {
+		writeNotices(&b, pkg)
+		return b.String()
+	}
+	group := func(heading string, examples []models.Example) {
+		if len(examples) == 0 {
+			return
+		}
+		b.WriteString(fmt.Sprintf("## %s\n\n", heading))
+		writeExamples(&b, opts, examples, 3)
+	}
+	group("Package", pkg.Examples)
+	for _, f := range pkg.Functions {
+		group(f.Name, f.Examples)
+	}
+	for _, t := range pkg.Types {
+		group(t.Name, t.Examples)
+		for _, m := range t.Methods {
+			group(methodName(t.Name, m.Name), m.Examples)
 		}
+	}
+	return b.String()
+}
+
+// addExamples appends example markdown to the builder, unless opts turns examples off
+func addExamples(b *strings.Builder, opts Options, examples []models.Example) {
+	if opts.NoExamples {
+		return
+	}
+	writeExamples(b, opts, examples, 6)
+}
+
+// writeExamples appends examples to the builder, their names as headings of the given level.
+func writeExamples(b *strings.Builder, opts Options, examples []models.Example, level int) {
+	for _, ex := range examples {
+		var body strings.Builder
 		if ex.Code != "" {
-			b.WriteString("```go\n")
-			b.WriteString(ex.Code)
-			b.WriteString("\n```\n\n")
+			body.WriteString("```go\n")
+			body.WriteString(ex.Code)
+			body.WriteString("\n```\n\n")
+		}
+		if ex.PlaygroundURL != "" {
+			body.WriteString(fmt.Sprintf("[▶ Try it on the Go Playground](%s)\n\n", ex.PlaygroundURL))
 		}
 		if ex.Output != "" {
-			b.WriteString("**Output:**\n")
-			b.WriteString("```\n")
-			b.WriteString(ex.Output)
-			b.WriteString("\n```\n\n")
+			body.WriteString("**Output:**\n")
+			body.WriteString("```\n")
+			body.WriteString(ex.Output)
+			body.WriteString("\n```\n\n")
+		}
+		if opts.Collapse {
+			writeDetails(b, "Example: "+cmp.Or(ex.Name, "package"), strings.TrimSpace(body.String()))
+			continue
+		}
+		if ex.Name != "" {
+			b.WriteString(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", level), ex.Name))
 		}
+		b.WriteString(body.String())
+	}
+}
+
+// writeDetails appends body, which is markdown, in a <details> element folded under summary.
+func writeDetails(b *strings.Builder, summary, body string) {
+	b.WriteString(fmt.Sprintf("<details>\n<summary>%s</summary>\n\n", html.EscapeString(summary)))
+	b.WriteString(body)
+	b.WriteString("\n\n</details>\n\n")
+}
+
+// writeNotices writes the pkg.go.dev banners recorded for pkg as blockquotes below the title.
+func writeNotices(b *strings.Builder, pkg *models.Package) {
+	if pkg.ModuleDeprecated {
+		b.WriteString(fmt.Sprintf("> **Warning: deprecated module.** %s\n\n", cmp.Or(pkg.DeprecationNotice, "The module is deprecated.")))
+	}
+	if pkg.Retracted {
+		b.WriteString(fmt.Sprintf("> **Warning: retracted version.** %s\n\n", cmp.Or(pkg.RetractionNotice, "The module author retracted this version.")))
+	}
+	if pkg.LicenseRestricted() {
+		b.WriteString(fmt.Sprintf("> **License restriction:** %s Only metadata is included below; see https://pkg.go.dev/%s for details.\n\n",
+			pkg.RedistributionNotice, pkg.ImportPath))
+	}
+	if pkg.PlatformNotice != "" || len(pkg.Platforms) > 0 {
+		notice := pkg.PlatformNotice
+		if notice == "" {
+			notice = "Documented for " + strings.Join(pkg.Platforms, ", ") + " only."
+		}
+		b.WriteString(fmt.Sprintf("> **Platform:** %s\n\n", notice))
+	}
+}
+
+// checkmark renders a Details check result.
+func checkmark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
+
+// writeReadmeAlternates links the README translations linked from the README, when there are any.
+func writeReadmeAlternates(b *strings.Builder, alts []models.ReadmeAlternate) {
+	var links []string
+	for _, a := range alts {
+		if a.URL != "" {
+			links = append(links, fmt.Sprintf("[%s](%s)", cmp.Or(a.Lang, "other"), a.URL))
+		}
+	}
+	if len(links) > 0 {
+		b.WriteString(fmt.Sprintf("_Also available in: %s_\n\n", strings.Join(links, ", ")))
+	}
+}
+
+// writeFields renders the exported fields of a struct type as a table.
+// writeUsedBy lists the packages using a type, from Options.UsedBy.
+func writeUsedBy(b *strings.Builder, importPaths []string) {
+	if len(importPaths) == 0 {
+		return
+	}
+	quoted := make([]string, len(importPaths))
+	for i, p := range importPaths {
+		quoted[i] = "`" + p + "`"
+	}
+	fmt.Fprintf(b, "**Used by:** %s\n\n", strings.Join(quoted, ", "))
+}
+
+func writeFields(b *strings.Builder, fields []models.Field) {
+	if len(fields) == 0 {
+		return
+	}
+	b.WriteString("##### Fields\n\n| Field | Type | Description |\n|---|---|---|\n")
+	for _, f := range fields {
+		doc := strings.ReplaceAll(strings.ReplaceAll(f.Doc, "|", "\\|"), "\n", " ")
+		if f.Tag != "" {
+			doc = strings.TrimSpace(fmt.Sprintf("%s `%s`", doc, f.Tag))
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", f.Name, strings.NewReplacer("|", "\\|", "\n", " ").Replace(f.Type), doc))
+	}
+	b.WriteString("\n")
+}
+
+// writeInterfaceMethods appends the method set of an interface type as a table.
+func writeInterfaceMethods(b *strings.Builder, methods []models.Function) {
+	if len(methods) == 0 {
+		return
+	}
+	b.WriteString("##### Interface Methods\n\n| Method | Description |\n|---|---|\n")
+	escape := strings.NewReplacer("|", "\\|", "\n", " ")
+	for _, m := range methods {
+		b.WriteString(fmt.Sprintf("| `%s` | %s |\n", escape.Replace(m.Signature), escape.Replace(m.Description)))
+	}
+	b.WriteString("\n")
+}
+
+// versionNote says what kind of version v is, for the metadata block: the commit and time of a
+// pseudo-version, "prerelease", or "" for releases.
+func versionNote(v string) string {
+	if module.IsPseudoVersion(v) {
+		rev, err := module.PseudoVersionRev(v)
+		if err != nil {
+			return "pseudo-version"
+		}
+		if t, err := module.PseudoVersionTime(v); err == nil {
+			return fmt.Sprintf("pseudo-version of commit %s, %s", rev, t.UTC().Format("2006-01-02 15:04 UTC"))
+		}
+		return "pseudo-version of commit " + rev
+	}
+	if semver.Prerelease(v) != "" {
+		return "prerelease"
 	}
+	return ""
 }
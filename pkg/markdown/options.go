@@ -0,0 +1,135 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Options adjusts how PackageToMarkdownWithOptions renders a package. The zero value renders
+// exactly like PackageToMarkdown.
+type Options struct {
+	// HeadingOffset shifts every heading down by this many levels, including the README and the
+	// index, so the output can be embedded below a host document's own headings. Levels are
+	// capped at 6, the deepest markdown has; anchors do not depend on the level and keep working.
+	HeadingOffset int
+
+	// IncludeSymbols keeps only constants, variables, functions, types and methods whose name
+	// matches; nil keeps all. ExcludeSymbols drops the ones whose name matches.
+	IncludeSymbols *regexp.Regexp
+	ExcludeSymbols *regexp.Regexp
+	// SkipDeprecated drops symbols tagged deprecated or documented with "Deprecated:".
+	SkipDeprecated bool
+	// Kinds limits the output to these symbol kinds (KindConst, KindVar, KindFunc, KindType);
+	// empty keeps all. Filters apply to the index and the body alike.
+	Kinds []string
+
+	// NoMetadata, NoReadme, NoIndex and NoExamples leave out the package metadata block (import
+	// path, version, license, ...), the README, the symbol index and all examples.
+	NoMetadata bool
+	NoReadme   bool
+	NoIndex    bool
+	NoExamples bool
+	// ExamplesOnly renders nothing but the title and the examples, grouped under the symbol they
+	// belong to, for harvesting usage examples; the symbol filters still choose which symbols.
+	ExamplesOnly bool
+	// IndexDescriptions follows each function, type and method of the index with the first
+	// paragraph of its description, cut at a word boundary to at most this many characters, so the
+	// index reads as an API overview; 0 lists names and signatures only.
+	IndexDescriptions int
+
+	// Collapse wraps examples, READMEs longer than CollapseReadmeLines and constant declarations
+	// longer than CollapseConstLines in <details> elements, so GitHub shows them folded and long
+	// pages stay scannable.
+	Collapse bool
+
+	// StripBadges drops CI, coverage and other badge images from the README and guides: images
+	// whose URL matches BadgePatterns, or utils.DefaultBadgePatterns when nil.
+	StripBadges   bool
+	BadgePatterns []*regexp.Regexp
+
+	// ExpandEmoji replaces GitHub emoji shortcodes such as :rocket: in the README and guides with
+	// the emoji GitHub shows for them.
+	ExpandEmoji bool
+
+	// Permalinks ends every function, type and method section with a "View on pkg.go.dev" link
+	// to the declaration on the live page of the rendered version, for readers of offline copies.
+	Permalinks bool
+
+	// LinkTypes renders function and method signatures and type definitions as HTML <pre> blocks
+	// whose type references are links, like pkg.go.dev's declarations: the package's own types
+	// link to their sections, types of other packages to the URL TypeLinks returns.
+	LinkTypes bool
+	// TypeLinks returns the URL documenting the type pkg refers to as qualifier.name, or "" to
+	// leave it unlinked; nil links the package's own types only.
+	TypeLinks func(pkg *models.Package, qualifier, name string) string
+
+	// UsedBy maps a type name to the import paths of other packages whose declarations use it,
+	// as a cross-reference of the corpus finds them; each type gets a "Used by" note.
+	UsedBy map[string][]string
+}
+
+// Size thresholds above which Options.Collapse folds a README or constant declaration.
+const (
+	CollapseReadmeLines = 40
+	CollapseConstLines  = 10
+)
+
+// PackageToMarkdownWithOptions is PackageToMarkdown with rendering options.
+func PackageToMarkdownWithOptions(pkg *models.Package, opts Options) string {
+	var md string
+	if opts.ExamplesOnly {
+		md = renderExamples(FilterSymbols(pkg, opts), opts)
+	} else {
+		md = render(FilterSymbols(pkg, opts), opts)
+	}
+	if opts.HeadingOffset > 0 {
+		md = ShiftHeadings(md, opts.HeadingOffset)
+	}
+	return md
+}
+
+// ShiftHeadings moves every ATX heading ("# Title") in md down by offset levels, up to level 6.
+// Lines inside fenced code blocks are left alone.
+func ShiftHeadings(md string, offset int) string {
+	if offset <= 0 {
+		return md
+	}
+	lines := strings.SplitAfter(md, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if marker := fenceMarker(trimmed); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(marker, fence) && strings.TrimSpace(strings.TrimLeft(trimmed, marker[:1])) == "":
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" || len(line)-len(trimmed) > 3 {
+			continue
+		}
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		if level == 0 || level > 6 {
+			continue
+		}
+		if rest := trimmed[level:]; rest != "" && rest[0] != ' ' && rest[0] != '\t' && rest[0] != '\n' {
+			continue
+		}
+		lines[i] = line[:len(line)-len(trimmed)] + strings.Repeat("#", min(level+offset, 6)) + trimmed[level:]
+	}
+	return strings.Join(lines, "")
+}
+
+// fenceMarker returns the run of backticks or tildes opening a fenced code block line, or "".
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if n := len(line) - len(strings.TrimLeft(line, c)); n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
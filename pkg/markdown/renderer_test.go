@@ -1,6 +1,7 @@
 package markdown
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -163,4 +164,426 @@ func TestConvertToMarkdown(t *testing.T) {
 	}
 
 	t.Log("TestConvertToMarkdown passed")
-}
\ No newline at end of file
+}
+func TestShiftHeadings(t *testing.T) {
+	md := "# Title\n\n## Section\n\n```go\n# not a heading\n```\n\n###### Deep\n\n#hashtag\n"
+	got := ShiftHeadings(md, 2)
+	want := "### Title\n\n#### Section\n\n```go\n# not a heading\n```\n\n###### Deep\n\n#hashtag\n"
+	if got != want {
+		t.Errorf("ShiftHeadings(md, 2) = %q, want %q", got, want)
+	}
+	if ShiftHeadings(md, 0) != md {
+		t.Error("Expected an offset of 0 to leave the markdown unchanged")
+	}
+
+	pkg := &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra", Functions: []models.Function{{Name: "New", Signature: "func New()"}}}
+	shifted := PackageToMarkdownWithOptions(pkg, Options{HeadingOffset: 1})
+	if !strings.HasPrefix(shifted, "## cobra package") || !strings.Contains(shifted, "\n#### Index\n") || !strings.Contains(shifted, "](#New)") {
+		t.Errorf("Expected headings one level down with index links intact, got:\n%s", shifted)
+	}
+}
+
+func TestFilterSymbols(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "cobra",
+		ImportPath: "github.com/spf13/cobra",
+		Constants:  []models.Constant{{Name: "BashCompFilenameExt"}},
+		Functions: []models.Function{
+			{Name: "NewCommand", Signature: "func NewCommand()"},
+			{Name: "OldHelper", Signature: "func OldHelper()", Description: "Deprecated: use NewCommand."},
+			{Name: "Eq", Signature: "func Eq()"},
+		},
+		Types: []models.Type{
+			{Name: "Command", Methods: []models.Function{{Name: "Command.Execute"}, {Name: "Command.NewFlagSet"}}},
+			{Name: "Old", Deprecated: "deprecated"},
+		},
+	}
+
+	got := FilterSymbols(pkg, Options{IncludeSymbols: regexp.MustCompile(`^New|\.New`)})
+	if len(got.Functions) != 1 || got.Functions[0].Name != "NewCommand" {
+		t.Errorf("Expected only NewCommand, got %v", got.Functions)
+	}
+	if len(got.Types) != 1 || len(got.Types[0].Methods) != 1 || got.Types[0].Methods[0].Name != "Command.NewFlagSet" {
+		t.Errorf("Expected Command with only its matching method, got %v", got.Types)
+	}
+	if len(got.Constants) != 0 {
+		t.Errorf("Expected non-matching constants to be dropped, got %v", got.Constants)
+	}
+	if len(pkg.Functions) != 3 {
+		t.Error("Expected the original package to be left alone")
+	}
+
+	got = FilterSymbols(pkg, Options{SkipDeprecated: true, Kinds: []string{KindFunc, KindType}})
+	if len(got.Functions) != 2 || len(got.Types) != 1 || len(got.Constants) != 0 {
+		t.Errorf("Expected 2 functions and 1 type without deprecated symbols or constants, got %d, %d, %d",
+			len(got.Functions), len(got.Types), len(got.Constants))
+	}
+
+	md := PackageToMarkdownWithOptions(pkg, Options{ExcludeSymbols: regexp.MustCompile(`^Eq$`)})
+	if strings.Contains(md, "Eq") {
+		t.Error("Expected excluded symbols to be missing from the index and the body")
+	}
+	if _, err := ParseKinds([]string{"func", "method"}); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+}
+
+func TestSectionToggles(t *testing.T) {
+	pkg := &models.Package{
+		Name:            "cobra",
+		ImportPath:      "github.com/spf13/cobra",
+		License:         "Apache-2.0",
+		ProcessedReadme: "# Cobra\n\nA huge README.",
+		Functions:       []models.Function{{Name: "Eq", Signature: "func Eq()", Examples: []models.Example{{Name: "Eq", Code: "cobra.Eq()"}}}},
+		Examples:        []models.Example{{Name: "Package", Code: "cobra.Execute()"}},
+	}
+
+	full := PackageToMarkdownWithOptions(pkg, Options{})
+	for _, want := range []string{"## README", "**License:**", "### Index", "cobra.Eq()", "cobra.Execute()"} {
+		if !strings.Contains(full, want) {
+			t.Errorf("Expected %q in the default output", want)
+		}
+	}
+
+	md := PackageToMarkdownWithOptions(pkg, Options{NoReadme: true, NoExamples: true, NoMetadata: true, NoIndex: true})
+	for _, unwanted := range []string{"## README", "A huge README", "**License:**", "### Index", "cobra.Eq()", "cobra.Execute()"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("Expected %q to be left out, got:\n%s", unwanted, md)
+		}
+	}
+	if !strings.Contains(md, "func Eq()") {
+		t.Error("Expected the API reference to stay")
+	}
+}
+
+func TestLicenseRestricted(t *testing.T) {
+	pkg := &models.Package{
+		Name:                 "secret",
+		ImportPath:           "example.com/secret",
+		License:              "None detected",
+		RedistributionNotice: "Documentation not displayed due to license restrictions.",
+		ProcessedReadme:      "should not appear",
+	}
+	md := PackageToMarkdown(pkg)
+	if !strings.Contains(md, "> **License restriction:** Documentation not displayed") || !strings.Contains(md, "**License:** None detected") {
+		t.Errorf("Expected the notice and the metadata, got:\n%s", md)
+	}
+	if strings.Contains(md, "## README") || strings.Contains(md, "## Documentation") {
+		t.Errorf("Expected metadata-only output, got:\n%s", md)
+	}
+
+	pkg.Redistributable = true
+	if md := PackageToMarkdown(pkg); strings.Contains(md, "License restriction") || !strings.Contains(md, "## README") {
+		t.Errorf("Expected full output for redistributable packages, got:\n%s", md)
+	}
+}
+
+func TestGuides(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "cobra",
+		ImportPath: "github.com/spf13/cobra",
+		Guides:     []models.Guide{{Title: "User Guide", URL: "https://cobra.readthedocs.io/en/latest/", Content: "# User Guide\n\n## Install\n\nRun go get."}},
+	}
+	md := PackageToMarkdown(pkg)
+	for _, want := range []string{"## Guides\n\n### User Guide\n\n_From [https://cobra.readthedocs.io/en/latest/]", "#### User Guide", "##### Install", "Run go get."} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in the output, got:\n%s", want, md)
+		}
+	}
+}
+
+func TestOverview(t *testing.T) {
+	pkg := &models.Package{
+		Name:        "gear",
+		ImportPath:  "example.com/gear",
+		Synopsis:    "Package gear turns widgets.",
+		Description: "Package gear turns widgets.",
+		Overview:    "Package gear turns widgets.\n\n### Usage\n\n```\ngear.Turn(3)\n```",
+	}
+	md := PackageToMarkdown(pkg)
+	if !strings.Contains(md, "## Overview\n\nPackage gear turns widgets.\n\n### Usage\n\n```\ngear.Turn(3)\n```\n\n") {
+		t.Errorf("Expected the whole overview, got:\n%s", md)
+	}
+	if strings.Count(md, "Package gear turns widgets.") != 1 {
+		t.Errorf("Expected the overview to replace the synopsis, got:\n%s", md)
+	}
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "io",
+		ImportPath: "io",
+		Types: []models.Type{{
+			Name:             "ReadCloser",
+			Definition:       "type ReadCloser interface {\n\tReader\n\tClose() error\n}",
+			InterfaceMethods: []models.Function{{Name: "Reader", Signature: "Reader"}, {Name: "Close", Signature: "Close() error", Description: "Close releases | frees."}},
+		}},
+	}
+	md := PackageToMarkdown(pkg)
+	if !strings.Contains(md, "##### Interface Methods\n\n| Method | Description |\n|---|---|\n| `Reader` |  |\n| `Close() error` | Close releases \\| frees. |\n") {
+		t.Errorf("Expected the interface method table, got:\n%s", md)
+	}
+}
+
+func TestIndexOrder(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "cobra",
+		ImportPath: "github.com/spf13/cobra",
+		Constants:  []models.Constant{{Name: "FlagSetByCobraAnnotation"}},
+		Functions: []models.Function{
+			{Name: "NoArgs", Signature: "func NoArgs(cmd *Command, args []string) error"},
+			{Name: "NewCommand", Signature: "func NewCommand(\n\tuse string,\n) *Command"},
+			{Name: "AddTemplateFunc", Signature: "func AddTemplateFunc(name string, tmplFunc interface{})"},
+			{Name: "Commands", Signature: "func Commands() ([]*Command, error)"},
+			{Name: "Pair", Signature: "func Pair() (*Command, *Group)"},
+		},
+		Types: []models.Type{
+			{Name: "Group", Definition: "type Group struct{}"},
+			{Name: "Command", Methods: []models.Function{
+				{Name: "Command.Execute", Signature: "func (c *Command) Execute() error"},
+				{Name: "Command.AddCommand", Signature: "func (c *Command) AddCommand(cmds ...*Command)"},
+			}},
+		},
+	}
+
+	md := PackageToMarkdown(pkg)
+	index := md[strings.Index(md, "### Index\n"):strings.Index(md, `<a id="pkg-constants">`)]
+	want := "### Index\n\n" +
+		"- [Constants](#pkg-constants)\n" +
+		"- [`func AddTemplateFunc(name string, tmplFunc interface{})`](#AddTemplateFunc)\n" +
+		"- [`func NoArgs(cmd *Command, args []string) error`](#NoArgs)\n" +
+		"- [`func Pair() (*Command, *Group)`](#Pair)\n" +
+		"- [`type Command`](#Command)\n" +
+		"  - [`func Commands() ([]*Command, error)`](#Commands)\n" +
+		"  - [`func NewCommand(use string) *Command`](#NewCommand)\n" +
+		"  - [`func (c *Command) AddCommand(cmds ...*Command)`](#Command.AddCommand)\n" +
+		"  - [`func (c *Command) Execute() error`](#Command.Execute)\n" +
+		"- [`type Group`](#Group)\n\n"
+	if index != want {
+		t.Errorf("Unexpected index:\n%s\nwant:\n%s", index, want)
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	pkg := &models.Package{
+		Name:            "cobra",
+		ImportPath:      "github.com/spf13/cobra",
+		ProcessedReadme: strings.Repeat("A line of the README.\n", CollapseReadmeLines),
+		Constants: []models.Constant{
+			{Name: "Big", Value: "const (\n" + strings.Repeat("\tA = iota\n", CollapseConstLines) + ")"},
+			{Name: "Small", Value: "const Small = 1"},
+		},
+		Functions: []models.Function{{Name: "Eq", Signature: "func Eq()", Examples: []models.Example{{Name: "Eq", Code: "cobra.Eq()", Output: "true"}}}},
+	}
+
+	md := PackageToMarkdownWithOptions(pkg, Options{Collapse: true})
+	for _, want := range []string{
+		"## README\n\n<details>\n<summary>README</summary>\n\nA line of the README.",
+		"<details>\n<summary>Declaration (12 lines)</summary>\n\n```go\nconst (",
+		"<details>\n<summary>Example: Eq</summary>\n\n```go\ncobra.Eq()\n```\n\n**Output:**\n```\ntrue\n```\n\n</details>\n",
+		"```go\nconst Small = 1\n```",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in the collapsed output, got:\n%s", want, md)
+		}
+	}
+	if strings.Count(md, "<details>") != 3 {
+		t.Errorf("Expected 3 folded sections, got %d", strings.Count(md, "<details>"))
+	}
+
+	pkg.ProcessedReadme = "Short README."
+	if strings.Contains(PackageToMarkdownWithOptions(pkg, Options{}), "<details>") {
+		t.Error("Expected nothing folded without Collapse")
+	}
+}
+
+func TestReadmeAlternates(t *testing.T) {
+	pkg := &models.Package{
+		Name:             "cobra",
+		ImportPath:       "github.com/spf13/cobra",
+		ProcessedReadme:  "# Cobra",
+		ReadmeAlternates: []models.ReadmeAlternate{{Lang: "zh-CN", URL: "https://github.com/spf13/cobra/blob/main/README.zh-CN.md"}, {Lang: "en", Content: "# Cobra"}},
+	}
+	md := PackageToMarkdown(pkg)
+	if !strings.Contains(md, "## README\n\n_Also available in: [zh-CN](https://github.com/spf13/cobra/blob/main/README.zh-CN.md)_\n\n# Cobra") {
+		t.Errorf("Expected the translation linked below the README heading, got:\n%s", md)
+	}
+}
+
+func TestStripBadges(t *testing.T) {
+	pkg := &models.Package{
+		Name:            "cobra",
+		ImportPath:      "github.com/spf13/cobra",
+		ProcessedReadme: "![coverage](https://img.shields.io/codecov/c/github/spf13/cobra)\n\nCobra is a library.",
+	}
+	if md := PackageToMarkdownWithOptions(pkg, Options{StripBadges: true}); strings.Contains(md, "shields.io") || !strings.Contains(md, "## README\n\nCobra is a library.") {
+		t.Errorf("Expected the badge dropped, got:\n%s", md)
+	}
+	if !strings.Contains(PackageToMarkdown(pkg), "shields.io") {
+		t.Error("Expected badges kept by default")
+	}
+}
+
+func TestExpandEmoji(t *testing.T) {
+	pkg := &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra", ProcessedReadme: ":rocket: Cobra is a library."}
+	if md := PackageToMarkdownWithOptions(pkg, Options{ExpandEmoji: true}); !strings.Contains(md, "## README\n\n🚀 Cobra is a library.") {
+		t.Errorf("Expected the shortcode expanded, got:\n%s", md)
+	}
+	if !strings.Contains(PackageToMarkdown(pkg), ":rocket:") {
+		t.Error("Expected shortcodes kept by default")
+	}
+}
+
+func TestUsedBy(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "cobra",
+		ImportPath: "github.com/spf13/cobra",
+		Types:      []models.Type{{Name: "Command"}, {Name: "Group"}},
+	}
+	md := PackageToMarkdownWithOptions(pkg, Options{UsedBy: map[string][]string{"Command": {"github.com/spf13/cobra/doc", "example.com/cli"}}})
+	if !strings.Contains(md, "**Used by:** `github.com/spf13/cobra/doc`, `example.com/cli`") {
+		t.Errorf("Expected a Used by note on Command, got:\n%s", md)
+	}
+	if strings.Count(md, "Used by") != 1 {
+		t.Errorf("Expected no note on unused types, got:\n%s", md)
+	}
+}
+
+func TestAnchors(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "names",
+		ImportPath: "example.com/names",
+		Functions:  []models.Function{{Name: "Parse"}, {Name: "Parse"}},
+		Types: []models.Type{
+			{Name: "First", Methods: []models.Function{{Name: "String", Signature: "func (First) String() string"}}},
+			{Name: "Second", Methods: []models.Function{{Name: "String", Signature: "func (Second) String() string"}}},
+		},
+	}
+	md := PackageToMarkdown(pkg)
+	for _, want := range []string{
+		"(#First.String)", "(#Second.String)", "(#Parse)", "(#Parse-2)",
+		"<a id=\"First.String\"></a>\n\n###### First.String\n",
+		"<a id=\"Second.String\"></a>\n\n###### Second.String\n",
+		"<a id=\"Parse-2\"></a>\n\n#### Parse\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in:\n%s", want, md)
+		}
+	}
+	// Every index link lands on an anchor.
+	for _, m := range regexp.MustCompile(`\]\(#([^)]+)\)`).FindAllStringSubmatch(md, -1) {
+		if strings.Count(md, `<a id="`+m[1]+`">`) != 1 {
+			t.Errorf("Expected exactly one anchor %q", m[1])
+		}
+	}
+}
+
+func TestPermalinks(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "names",
+		ImportPath: "example.com/names",
+		Version:    "v1.4.0",
+		Functions:  []models.Function{{Name: "Parse"}},
+		Types:      []models.Type{{Name: "First", Methods: []models.Function{{Name: "String"}}}},
+	}
+	md := PackageToMarkdownWithOptions(pkg, Options{Permalinks: true})
+	for _, want := range []string{
+		"_[View on pkg.go.dev](https://pkg.go.dev/example.com/names@v1.4.0#Parse)_",
+		"_[View on pkg.go.dev](https://pkg.go.dev/example.com/names@v1.4.0#First)_",
+		"_[View on pkg.go.dev](https://pkg.go.dev/example.com/names@v1.4.0#First.String)_",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in:\n%s", want, md)
+		}
+	}
+	if strings.Contains(PackageToMarkdown(pkg), "View on pkg.go.dev") {
+		t.Error("Expected no permalinks by default")
+	}
+}
+
+func TestIndexDescriptions(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "gear",
+		ImportPath: "example.com/gear",
+		Functions:  []models.Function{{Name: "Turn", Signature: "func Turn()", Description: "Turn rotates every gear\nof the train by one tooth.\n\nIt blocks."}},
+		Types:      []models.Type{{Name: "Gear", Description: "Gear is a toothed wheel."}},
+	}
+	md := PackageToMarkdownWithOptions(pkg, Options{IndexDescriptions: 30})
+	for _, want := range []string{
+		"- [`func Turn()`](#Turn) — Turn rotates every gear of the…\n",
+		"- [`type Gear`](#Gear) — Gear is a toothed wheel.\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in:\n%s", want, md)
+		}
+	}
+	if strings.Contains(PackageToMarkdown(pkg), " — Turn") {
+		t.Error("Expected no index descriptions by default")
+	}
+}
+
+func TestExamplesOnly(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "gear",
+		ImportPath: "example.com/gear",
+		Readme:     "Gears for everyone.",
+		Examples:   []models.Example{{Name: "package", Code: "gear.Turn()"}},
+		Functions:  []models.Function{{Name: "Turn", Signature: "func Turn()", Examples: []models.Example{{Name: "Turn", Code: "gear.Turn()", Output: "click"}}}},
+		Types: []models.Type{{Name: "Gear", Methods: []models.Function{
+			{Name: "Spin", Signature: "func (g *Gear) Spin()", Examples: []models.Example{{Name: "Gear.Spin", Code: "g.Spin()"}}},
+		}}},
+		Constants: []models.Constant{{Name: "Teeth", Value: "12"}},
+	}
+	md := PackageToMarkdownWithOptions(pkg, Options{ExamplesOnly: true})
+	want := "# gear package - example.com/gear\n\n" +
+		"## Package\n\n### package\n\n```go\ngear.Turn()\n```\n\n" +
+		"## Turn\n\n### Turn\n\n```go\ngear.Turn()\n```\n\n**Output:**\n```\nclick\n```\n\n" +
+		"## Gear.Spin\n\n### Gear.Spin\n\n```go\ng.Spin()\n```\n\n"
+	if md != want {
+		t.Errorf("Unexpected examples-only markdown:\n%s\nwant:\n%s", md, want)
+	}
+
+	md = PackageToMarkdownWithOptions(pkg, Options{ExamplesOnly: true, Kinds: []string{KindType}})
+	if strings.Contains(md, "## Turn") || !strings.Contains(md, "## Gear.Spin") {
+		t.Errorf("Expected symbol filters to choose the examples, got:\n%s", md)
+	}
+}
+
+func TestLinkTypes(t *testing.T) {
+	pkg := &models.Package{
+		Name:       "gear",
+		ImportPath: "example.com/gear",
+		Functions:  []models.Function{{Name: "New", Signature: "func New(r io.Reader, opts ...Option) (*Gear, error)"}},
+		Types: []models.Type{
+			{Name: "Gear", Definition: "type Gear struct {\n\tOption Option // set by New\n\tnext   *Gear\n}", Methods: []models.Function{
+				{Name: "Mesh", Signature: "func (g *Gear) Mesh(other cog.Tooth) bool"},
+			}},
+			{Name: "Option", Definition: "type Option func(*Gear)"},
+		},
+	}
+	links := func(pkg *models.Package, qualifier, name string) string {
+		if qualifier == "cog" {
+			return "https://pkg.go.dev/example.com/cog#" + name
+		}
+		return ""
+	}
+	md := PackageToMarkdownWithOptions(pkg, Options{LinkTypes: true, TypeLinks: links})
+	for _, want := range []string{
+		`<pre><code>func New(r io.Reader, opts ...<a href="#Option">Option</a>) (*<a href="#Gear">Gear</a>, error)</code></pre>`,
+		"<pre><code>type Gear struct {\n\tOption <a href=\"#Option\">Option</a> // set by New\n\tnext   *<a href=\"#Gear\">Gear</a>\n}</code></pre>",
+		`<pre><code>func (g *<a href="#Gear">Gear</a>) Mesh(other <a href="https://pkg.go.dev/example.com/cog#Tooth">cog.Tooth</a>) bool</code></pre>`,
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %s in:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "```go\nfunc") {
+		t.Error("Expected no plain signature blocks with LinkTypes")
+	}
+
+	// Types the filters leave out have no section to link to.
+	md = PackageToMarkdownWithOptions(pkg, Options{LinkTypes: true, ExcludeSymbols: regexp.MustCompile("^Option$")})
+	if strings.Contains(md, `href="#Option"`) || !strings.Contains(md, `<a href="#Gear">Gear</a>`) {
+		t.Errorf("Expected only rendered types linked, got:\n%s", md)
+	}
+}
@@ -0,0 +1,138 @@
+// Package notify posts short change summaries of cached packages to Slack or Discord incoming
+// webhooks: the new version, how many symbols were added and removed, and a link to the docs.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/search"
+)
+
+// State is what is remembered of a package between changes.
+type State struct {
+	Version string
+	Symbols map[string]bool // kind and qualified name, e.g. "method Command.Execute"
+}
+
+// NewState records the version and exported symbols of pkg.
+func NewState(pkg *models.Package) State {
+	s := State{Version: pkg.Version, Symbols: map[string]bool{}}
+	for _, sym := range search.PackageSymbols(pkg) {
+		s.Symbols[sym.Kind+" "+sym.Name] = true
+	}
+	return s
+}
+
+// Change summarizes how a package differs from its previous state.
+type Change struct {
+	ImportPath string
+	OldVersion string // empty for a package seen for the first time
+	Version    string
+	Added      []string // symbols new in Version, sorted
+	Removed    []string // symbols gone from Version, sorted
+	New        bool     // no previous state was known
+	URL        string   // link to the generated docs; optional
+}
+
+// Compare returns the change from prev, which is nil for a package seen for the first time, to pkg.
+func Compare(prev *State, pkg *models.Package) Change {
+	cur := NewState(pkg)
+	c := Change{ImportPath: pkg.ImportPath, Version: pkg.Version, New: prev == nil}
+	if prev == nil {
+		prev = &State{}
+	} else {
+		c.OldVersion = prev.Version
+	}
+	for sym := range cur.Symbols {
+		if !prev.Symbols[sym] {
+			c.Added = append(c.Added, sym)
+		}
+	}
+	for sym := range prev.Symbols {
+		if !cur.Symbols[sym] {
+			c.Removed = append(c.Removed, sym)
+		}
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	return c
+}
+
+// Changed reports whether the change is worth a notification: a new package, another version,
+// or a different set of symbols. Re-scrapes that found nothing new are not.
+func (c Change) Changed() bool {
+	return c.New || c.OldVersion != c.Version || len(c.Added) > 0 || len(c.Removed) > 0
+}
+
+// Text renders the one-line summary posted to the channel, e.g.
+// "github.com/spf13/cobra v1.9.1 (was v1.9.0): 3 added, 1 removed symbols https://docs.example.com/...".
+func (c Change) Text() string {
+	var b strings.Builder
+	b.WriteString(c.ImportPath)
+	if c.Version != "" {
+		b.WriteString(" " + c.Version)
+	}
+	switch {
+	case c.New:
+		fmt.Fprintf(&b, ": newly cached, %d symbols", len(c.Added))
+	default:
+		if c.OldVersion != c.Version && c.OldVersion != "" {
+			fmt.Fprintf(&b, " (was %s)", c.OldVersion)
+		}
+		fmt.Fprintf(&b, ": %d added, %d removed symbols", len(c.Added), len(c.Removed))
+	}
+	if c.URL != "" {
+		b.WriteString(" " + c.URL)
+	}
+	return b.String()
+}
+
+// Post sends the summary of c to a Slack or Discord incoming webhook; the service is told apart
+// by the URL's host, since Slack reads the message from "text" and Discord from "content".
+func Post(ctx context.Context, client *http.Client, webhookURL string, c Change) error {
+	key := "text"
+	if u, err := url.Parse(webhookURL); err == nil && isDiscord(u.Hostname()) {
+		key = "content"
+	}
+	body, err := json.Marshal(map[string]string{key: c.Text()})
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("notification webhook returned %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func isDiscord(host string) bool {
+	for _, domain := range []string{"discord.com", "discordapp.com"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestCompare(t *testing.T) {
+	old := &models.Package{
+		ImportPath: "github.com/spf13/cobra",
+		Version:    "v1.9.0",
+		Functions:  []models.Function{{Name: "Eq"}, {Name: "Gt"}},
+	}
+	cur := &models.Package{
+		ImportPath: "github.com/spf13/cobra",
+		Version:    "v1.9.1",
+		Functions:  []models.Function{{Name: "Eq"}},
+		Types:      []models.Type{{Name: "Command", Methods: []models.Function{{Name: "Execute"}}}},
+	}
+	prev := NewState(old)
+	c := Compare(&prev, cur)
+	c.URL = "https://docs.example.com/github.com/spf13/cobra.md"
+	if len(c.Added) != 2 || c.Added[0] != "method Command.Execute" || len(c.Removed) != 1 || c.Removed[0] != "func Gt" {
+		t.Errorf("Expected Command and Command.Execute added and Gt removed, got %v and %v", c.Added, c.Removed)
+	}
+	if want := "github.com/spf13/cobra v1.9.1 (was v1.9.0): 2 added, 1 removed symbols https://docs.example.com/github.com/spf13/cobra.md"; c.Text() != want {
+		t.Errorf("Expected %q, got %q", want, c.Text())
+	}
+
+	same := NewState(cur)
+	if c := Compare(&same, cur); c.Changed() {
+		t.Errorf("Expected an unchanged package not to notify, got %+v", c)
+	}
+	if c := Compare(nil, cur); !c.Changed() || c.Text() != "github.com/spf13/cobra v1.9.1: newly cached, 3 symbols" {
+		t.Errorf("Expected a new package summary, got %q", c.Text())
+	}
+}
+
+func TestPost(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := Change{ImportPath: "fmt", Version: "go1.24.3", New: true}
+	if err := Post(context.Background(), srv.Client(), srv.URL, c); err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if got["text"] != c.Text() {
+		t.Errorf("Expected a Slack payload with text, got %v", got)
+	}
+	if !isDiscord("discord.com") || !isDiscord("canary.discord.com") || isDiscord("hooks.slack.com") || isDiscord("notdiscord.com") {
+		t.Error("Expected only Discord hosts to be recognized")
+	}
+}
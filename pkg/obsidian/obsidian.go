@@ -0,0 +1,402 @@
+// Package obsidian writes packages as an Obsidian vault: one note per package with YAML
+// frontmatter that Obsidian shows as properties and tags, wikilinks from each note to its own
+// symbols, to the other packages of its module and to the symbols of other packages in the vault
+// its declarations refer to, and optionally an index note and a canvas of all packages.
+package obsidian
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+	"gopkg.in/yaml.v3"
+)
+
+// IndexNote and CanvasFile are the vault-relative names of the optional index note and canvas.
+const (
+	IndexNote  = "Go Packages.md"
+	CanvasFile = "Go Packages.canvas"
+)
+
+// Options selects the optional files Build writes next to the package notes.
+type Options struct {
+	Index  bool // an index note linking every package, grouped by module
+	Canvas bool // a canvas with a card per package, grouped by module, and an edge per reference
+}
+
+// NotePath returns the vault-relative, slash-separated path of the note for importPath. Notes
+// mirror the import path, so "[[github.com/spf13/cobra]]" links to the note of that package.
+func NotePath(importPath string) string {
+	return importPath + ".md"
+}
+
+// Build writes a note for the most recently scraped version of each package in pkgs below dir,
+// plus the index note and canvas selected by opts. It returns the number of notes written.
+func Build(dir string, pkgs []*models.Package, opts Options) (int, error) {
+	v := newVault(pkgs)
+	for _, pkg := range v.pkgs {
+		if err := writeFile(dir, NotePath(pkg.ImportPath), []byte(v.note(pkg))); err != nil {
+			return 0, err
+		}
+	}
+	if opts.Index {
+		if err := writeFile(dir, IndexNote, []byte(v.index())); err != nil {
+			return len(v.pkgs), err
+		}
+	}
+	if opts.Canvas {
+		data, err := json.MarshalIndent(v.canvas(), "", "\t")
+		if err != nil {
+			return len(v.pkgs), err
+		}
+		if err := writeFile(dir, CanvasFile, data); err != nil {
+			return len(v.pkgs), err
+		}
+	}
+	return len(v.pkgs), nil
+}
+
+func writeFile(dir, name string, data []byte) error {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// vault is the set of packages written together, which decides what links can resolve.
+type vault struct {
+	pkgs    []*models.Package            // one per import path, sorted by import path
+	byName  map[string][]*models.Package // package name → packages with that name
+	symbols map[string]map[string]bool   // import path → exported top-level identifiers
+}
+
+func newVault(pkgs []*models.Package) *vault {
+	latest := map[string]*models.Package{}
+	for _, pkg := range pkgs {
+		if pkg == nil || pkg.ImportPath == "" {
+			continue
+		}
+		if cur := latest[pkg.ImportPath]; cur == nil || pkg.ScrapedAt.After(cur.ScrapedAt) {
+			latest[pkg.ImportPath] = pkg
+		}
+	}
+	v := &vault{byName: map[string][]*models.Package{}, symbols: map[string]map[string]bool{}}
+	for _, pkg := range latest {
+		v.pkgs = append(v.pkgs, pkg)
+	}
+	sort.Slice(v.pkgs, func(i, j int) bool { return v.pkgs[i].ImportPath < v.pkgs[j].ImportPath })
+	for _, pkg := range v.pkgs {
+		v.byName[pkg.Name] = append(v.byName[pkg.Name], pkg)
+		syms := map[string]bool{}
+		for _, c := range pkg.Constants {
+			syms[c.Name] = true
+		}
+		for _, c := range pkg.Variables {
+			syms[c.Name] = true
+		}
+		for _, f := range pkg.Functions {
+			syms[f.Name] = true
+		}
+		for _, t := range pkg.Types {
+			syms[t.Name] = true
+		}
+		v.symbols[pkg.ImportPath] = syms
+	}
+	return v
+}
+
+// frontmatter holds the note properties. Tags use Obsidian's nested tag syntax.
+type frontmatter struct {
+	ImportPath string   `yaml:"import_path"`
+	Module     string   `yaml:"module,omitempty"`
+	Version    string   `yaml:"version,omitempty"`
+	GoVersion  string   `yaml:"go_version,omitempty"`
+	License    string   `yaml:"license,omitempty"`
+	Repository string   `yaml:"repository,omitempty"`
+	Synopsis   string   `yaml:"synopsis,omitempty"`
+	Aliases    []string `yaml:"aliases,omitempty"`
+	Tags       []string `yaml:"tags"`
+}
+
+// note renders the note of pkg: frontmatter, title, wikilinks to its symbols and related notes,
+// then the usual markdown documentation without its own index.
+func (v *vault) note(pkg *models.Package) string {
+	fm := frontmatter{
+		ImportPath: pkg.ImportPath, Module: pkg.Module, Version: pkg.Version, GoVersion: pkg.GoVersion,
+		License: pkg.License, Repository: pkg.Repository, Synopsis: pkg.Synopsis, Tags: tags(pkg),
+	}
+	if pkg.Name != "" && pkg.Name != pkg.ImportPath {
+		fm.Aliases = []string{pkg.Name}
+	}
+	props, _ := yaml.Marshal(fm) // a struct of strings always marshals
+
+	body := markdown.PackageToMarkdownWithOptions(pkg, markdown.Options{NoIndex: true})
+	title, rest, _ := strings.Cut(body, "\n")
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.Write(props)
+	b.WriteString("---\n\n")
+	b.WriteString(title + "\n\n")
+	writeSymbols(&b, pkg)
+	v.writeRelated(&b, pkg)
+	b.WriteString(strings.TrimLeft(rest, "\n"))
+	return b.String()
+}
+
+// tags returns the note tags: the package kind, its module, license and deprecation status.
+func tags(pkg *models.Package) []string {
+	out := []string{"go/package"}
+	if pkg.Module != "" {
+		out = append(out, "go/module/"+tagSegment(pkg.Module))
+	}
+	if pkg.License != "" {
+		out = append(out, "license/"+strings.ToLower(tagSegment(pkg.License)))
+	}
+	if pkg.ModuleDeprecated {
+		out = append(out, "go/deprecated")
+	}
+	if pkg.Retracted {
+		out = append(out, "go/retracted")
+	}
+	return out
+}
+
+var tagUnsafe = regexp.MustCompile(`[^\p{L}\p{N}_/-]+`)
+
+// tagSegment makes s usable in a tag, which may only hold letters, digits, "_", "-" and "/".
+func tagSegment(s string) string {
+	return strings.Trim(tagUnsafe.ReplaceAllString(s, "-"), "-/")
+}
+
+// writeSymbols lists every symbol as a link to its heading in the note.
+func writeSymbols(b *strings.Builder, pkg *models.Package) {
+	var names []string
+	for _, c := range pkg.Constants {
+		names = append(names, c.Name)
+	}
+	for _, c := range pkg.Variables {
+		names = append(names, c.Name)
+	}
+	for _, f := range pkg.Functions {
+		names = append(names, f.Name)
+	}
+	for _, t := range pkg.Types {
+		names = append(names, t.Name)
+		for _, m := range t.Methods {
+			// The heading of a method is qualified with its type, so repeated names stay apart.
+			if !strings.Contains(m.Name, ".") {
+				m.Name = t.Name + "." + m.Name
+			}
+			names = append(names, m.Name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	b.WriteString("## Symbols\n\n")
+	for _, name := range names {
+		fmt.Fprintf(b, "- [[#%s]]\n", name)
+	}
+	b.WriteString("\n")
+}
+
+// writeRelated links the module's other packages in the vault and the symbols of other vault
+// packages that pkg's declarations refer to.
+func (v *vault) writeRelated(b *strings.Builder, pkg *models.Package) {
+	var siblings []string
+	for _, other := range v.pkgs {
+		if other != pkg && pkg.Module != "" && other.Module == pkg.Module {
+			siblings = append(siblings, other.ImportPath)
+		}
+	}
+	refs := v.references(pkg)
+	if len(siblings) == 0 && len(refs) == 0 {
+		return
+	}
+	b.WriteString("## Related Notes\n\n")
+	if len(siblings) > 0 {
+		b.WriteString("Packages of the same module:\n\n")
+		for _, p := range siblings {
+			fmt.Fprintf(b, "- [[%s]]\n", p)
+		}
+		b.WriteString("\n")
+	}
+	if len(refs) > 0 {
+		b.WriteString("Referenced symbols:\n\n")
+		for _, r := range refs {
+			fmt.Fprintf(b, "- [[%s#%s|%s.%s]]\n", r.importPath, r.symbol, r.pkgName, r.symbol)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// reference is a qualified identifier in a declaration that resolves to a vault package.
+type reference struct {
+	importPath, pkgName, symbol string
+}
+
+var qualifiedIdent = regexp.MustCompile(`\b([a-z][a-z0-9_]*)\.([A-Z][A-Za-z0-9_]*)\b`)
+
+// references returns the symbols of other vault packages named in pkg's declarations, sorted and
+// without duplicates. A qualifier resolves when exactly one other vault package has that name and
+// declares the symbol; anything else could be a local variable or an ambiguous import.
+func (v *vault) references(pkg *models.Package) []reference {
+	var decls []string
+	for _, c := range pkg.Constants {
+		decls = append(decls, c.Value, c.Type)
+	}
+	for _, c := range pkg.Variables {
+		decls = append(decls, c.Type)
+	}
+	for _, f := range pkg.Functions {
+		decls = append(decls, f.Signature)
+	}
+	for _, t := range pkg.Types {
+		decls = append(decls, t.Definition)
+		for _, m := range t.Methods {
+			decls = append(decls, m.Signature)
+		}
+	}
+
+	seen := map[reference]bool{}
+	var refs []reference
+	for _, decl := range decls {
+		for _, m := range qualifiedIdent.FindAllStringSubmatch(decl, -1) {
+			var target *models.Package
+			for _, cand := range v.byName[m[1]] {
+				if cand != pkg && v.symbols[cand.ImportPath][m[2]] {
+					if target != nil {
+						target = nil
+						break
+					}
+					target = cand
+				}
+			}
+			if target == nil {
+				continue
+			}
+			r := reference{importPath: target.ImportPath, pkgName: m[1], symbol: m[2]}
+			if !seen[r] {
+				seen[r] = true
+				refs = append(refs, r)
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].importPath != refs[j].importPath {
+			return refs[i].importPath < refs[j].importPath
+		}
+		return refs[i].symbol < refs[j].symbol
+	})
+	return refs
+}
+
+// modules groups the vault packages by module path, in module order.
+func (v *vault) modules() ([]string, map[string][]*models.Package) {
+	byModule := map[string][]*models.Package{}
+	for _, pkg := range v.pkgs {
+		mod := pkg.Module
+		if mod == "" {
+			mod = pkg.ImportPath
+		}
+		byModule[mod] = append(byModule[mod], pkg)
+	}
+	mods := make([]string, 0, len(byModule))
+	for mod := range byModule {
+		mods = append(mods, mod)
+	}
+	sort.Strings(mods)
+	return mods, byModule
+}
+
+// index renders the index note.
+func (v *vault) index() string {
+	var b strings.Builder
+	b.WriteString("---\ntags:\n  - go/index\n---\n\n# Go Packages\n\n")
+	mods, byModule := v.modules()
+	for _, mod := range mods {
+		fmt.Fprintf(&b, "## %s\n\n", mod)
+		for _, pkg := range byModule[mod] {
+			fmt.Fprintf(&b, "- [[%s]]", pkg.ImportPath)
+			if pkg.Version != "" {
+				fmt.Fprintf(&b, " %s", pkg.Version)
+			}
+			if pkg.Synopsis != "" {
+				fmt.Fprintf(&b, " — %s", pkg.Synopsis)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Canvas layout, in canvas units: modules are columns of package cards.
+const (
+	cardWidth  = 400
+	cardHeight = 200
+	cardGap    = 40
+	groupPad   = 40
+)
+
+// canvasNode and canvasEdge follow the JSON Canvas format Obsidian reads.
+type canvasNode struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"` // "file" or "group"
+	File   string `json:"file,omitempty"`
+	Label  string `json:"label,omitempty"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type canvasEdge struct {
+	ID       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	ToNode   string `json:"toNode"`
+}
+
+type canvas struct {
+	Nodes []canvasNode `json:"nodes"`
+	Edges []canvasEdge `json:"edges"`
+}
+
+// canvas lays out a card per package inside a group per module and connects each package to the
+// packages it references.
+func (v *vault) canvas() canvas {
+	c := canvas{Nodes: []canvasNode{}, Edges: []canvasEdge{}}
+	mods, byModule := v.modules()
+	for col, mod := range mods {
+		x := col * (cardWidth + 2*groupPad + cardGap)
+		pkgs := byModule[mod]
+		c.Nodes = append(c.Nodes, canvasNode{
+			ID: "module:" + mod, Type: "group", Label: mod, X: x, Y: 0,
+			Width: cardWidth + 2*groupPad, Height: len(pkgs)*(cardHeight+cardGap) - cardGap + 2*groupPad,
+		})
+		for row, pkg := range pkgs {
+			c.Nodes = append(c.Nodes, canvasNode{
+				ID: pkg.ImportPath, Type: "file", File: NotePath(pkg.ImportPath),
+				X: x + groupPad, Y: groupPad + row*(cardHeight+cardGap), Width: cardWidth, Height: cardHeight,
+			})
+		}
+	}
+	for _, pkg := range v.pkgs {
+		linked := map[string]bool{}
+		for _, r := range v.references(pkg) {
+			if !linked[r.importPath] {
+				linked[r.importPath] = true
+				c.Edges = append(c.Edges, canvasEdge{ID: pkg.ImportPath + "->" + r.importPath, FromNode: pkg.ImportPath, ToNode: r.importPath})
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,84 @@
+package obsidian
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	cobra := &models.Package{
+		Name: "cobra", ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.9.1",
+		License: "Apache-2.0", Synopsis: "Package cobra is a commander.", ScrapedAt: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		Types: []models.Type{{
+			Name: "Command", Definition: "type Command struct{}",
+			Methods: []models.Function{{Name: "Command.Flags", Signature: "func (c *Command) Flags() *pflag.FlagSet"}},
+		}},
+	}
+	old := &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra", Version: "v1.8.0", ScrapedAt: cobra.ScrapedAt.Add(-time.Hour)}
+	doc := &models.Package{Name: "doc", ImportPath: "github.com/spf13/cobra/doc", Module: "github.com/spf13/cobra"}
+	pflag := &models.Package{
+		Name: "pflag", ImportPath: "github.com/spf13/pflag", Module: "github.com/spf13/pflag",
+		Types: []models.Type{{Name: "FlagSet", Definition: "type FlagSet struct{}"}},
+	}
+
+	dir := t.TempDir()
+	notes, err := Build(dir, []*models.Package{cobra, old, doc, pflag}, Options{Index: true, Canvas: true})
+	if err != nil || notes != 3 {
+		t.Fatalf("Expected 3 notes, got %d (%v)", notes, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "github.com", "spf13", "cobra.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := string(data)
+	for _, want := range []string{
+		"---\nimport_path: github.com/spf13/cobra\n",
+		"version: v1.9.1\n",
+		"aliases:\n    - cobra\n",
+		"tags:\n    - go/package\n    - go/module/github-com/spf13/cobra\n    - license/apache-2-0\n---\n\n# cobra package - github.com/spf13/cobra\n",
+		"- [[#Command.Flags]]\n",
+		"Packages of the same module:\n\n- [[github.com/spf13/cobra/doc]]\n",
+		"Referenced symbols:\n\n- [[github.com/spf13/pflag#FlagSet|pflag.FlagSet]]\n",
+		"###### Command.Flags\n",
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("Expected the note to contain %q, got:\n%s", want, note)
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, IndexNote))
+	if err != nil || !strings.Contains(string(index), "## github.com/spf13/cobra\n\n- [[github.com/spf13/cobra]] v1.9.1 — Package cobra is a commander.\n- [[github.com/spf13/cobra/doc]]\n") {
+		t.Errorf("Expected packages grouped by module in the index, got %q (%v)", index, err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, CanvasFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c canvas
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("Expected a JSON canvas, got %v", err)
+	}
+	if len(c.Nodes) != 5 || len(c.Edges) != 1 || c.Edges[0].FromNode != "github.com/spf13/cobra" || c.Edges[0].ToNode != "github.com/spf13/pflag" {
+		t.Errorf("Expected 2 groups, 3 cards and one edge, got %+v", c)
+	}
+}
+
+func TestReferencesSkipAmbiguousNames(t *testing.T) {
+	user := &models.Package{Name: "user", ImportPath: "example.com/user", Functions: []models.Function{{Name: "Parse", Signature: "func Parse(t template.Template)"}}}
+	text := &models.Package{Name: "template", ImportPath: "example.com/text/template", Types: []models.Type{{Name: "Template"}}}
+	html := &models.Package{Name: "template", ImportPath: "example.com/html/template", Types: []models.Type{{Name: "Template"}}}
+	if refs := newVault([]*models.Package{user, text, html}).references(user); len(refs) != 0 {
+		t.Errorf("Expected no link for a name two packages share, got %v", refs)
+	}
+	if refs := newVault([]*models.Package{user, text}).references(user); len(refs) != 1 || refs[0].importPath != text.ImportPath {
+		t.Errorf("Expected a link to %s, got %v", text.ImportPath, refs)
+	}
+}
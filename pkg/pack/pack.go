@@ -0,0 +1,278 @@
+package pack
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+)
+
+// Priority tiers; lower tiers are admitted into the budget first.
+const (
+	tierHeader      = iota
+	tierIdentifiers // compact list of every exported identifier, from the Jump to index
+	tierSignature
+	tierDescription
+	tierExample
+	tierReadme
+)
+
+// minTrimTokens is the smallest remaining budget worth spending on a trimmed README or example.
+const minTrimTokens = 64
+
+// truncationNote marks content that was cut to fit the budget.
+const truncationNote = "\n\n_[truncated to fit token budget]_\n"
+
+// item is one unit of content competing for the token budget.
+type item struct {
+	pkg       int    // package position in the document
+	tier      int    // admission priority
+	section   string // heading the item is rendered under ("" for the package header)
+	text      string
+	trimmable bool // may be shortened instead of dropped
+	included  bool
+}
+
+// Options adjusts what BuildWithOptions puts in a pack.
+type Options struct {
+	// KeepBadges keeps CI, coverage and other badge images in READMEs, which are dropped by
+	// default since they cost tokens without telling a model anything.
+	KeepBadges bool
+	// BadgePatterns match the URLs of badge images; nil uses utils.DefaultBadgePatterns.
+	BadgePatterns []*regexp.Regexp
+}
+
+// Build assembles a single markdown context document covering pkgs within an approximate token budget.
+// Packages are ordered by import path; identifier lists, signatures and short descriptions are admitted before examples and READMEs,
+// which are trimmed to whatever budget remains. The same input always produces the same output.
+func Build(pkgs []*models.Package, budget int) string {
+	return BuildWithOptions(pkgs, budget, Options{})
+}
+
+// BuildWithOptions is Build with options.
+func BuildWithOptions(pkgs []*models.Package, budget int, opts Options) string {
+	sorted := make([]*models.Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg != nil {
+			sorted = append(sorted, pkg)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ImportPath < sorted[j].ImportPath })
+
+	title := "# Go Package Documentation Pack\n\n"
+	remaining := budget - utils.EstimateTokens(title)
+
+	var items []*item
+	for i, pkg := range sorted {
+		items = append(items, packageItems(i, pkg, opts)...)
+	}
+
+	// Section headings are charged once, when the first item beneath them is admitted.
+	charged := make(map[string]bool)
+	headingCost := func(it *item) int {
+		key := fmt.Sprintf("%d/%s", it.pkg, it.section)
+		if it.section == "" || charged[key] {
+			return 0
+		}
+		return utils.EstimateTokens(sectionHeading(it.section))
+	}
+	admit := func(it *item, cost int) {
+		it.included = true
+		remaining -= cost
+		charged[fmt.Sprintf("%d/%s", it.pkg, it.section)] = true
+	}
+
+	// Admit items tier by tier so every package gets its API surface before any package gets prose.
+	for tier := tierHeader; tier <= tierReadme; tier++ {
+		var candidates []*item
+		for _, it := range items {
+			if it.tier == tier {
+				candidates = append(candidates, it)
+			}
+		}
+		for i, it := range candidates {
+			cost := utils.EstimateTokens(it.text) + headingCost(it)
+			if cost <= remaining {
+				admit(it, cost)
+				continue
+			}
+			if !it.trimmable {
+				continue
+			}
+			// Share what is left among the trimmable items still waiting in this tier.
+			share := remaining / (len(candidates) - i)
+			if share < minTrimTokens {
+				continue
+			}
+			overhead := headingCost(it) + utils.EstimateTokens(truncationNote)
+			it.text = trimToTokens(it.text, share-overhead) + truncationNote
+			admit(it, utils.EstimateTokens(it.text)+headingCost(it))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(title)
+	lastPkg, lastSection := -1, ""
+	for _, it := range items {
+		if !it.included {
+			continue
+		}
+		if it.pkg != lastPkg {
+			if lastPkg != -1 {
+				b.WriteString("\n")
+			}
+			lastPkg, lastSection = it.pkg, ""
+		}
+		if it.section != "" && it.section != lastSection {
+			b.WriteString(sectionHeading(it.section))
+			lastSection = it.section
+		}
+		b.WriteString(it.text)
+	}
+	return b.String()
+}
+
+// packageItems splits a package into prioritized items in document order.
+func packageItems(index int, pkg *models.Package, opts Options) []*item {
+	var items []*item
+	add := func(tier int, section, text string, trimmable bool) {
+		items = append(items, &item{pkg: index, tier: tier, section: section, text: text, trimmable: trimmable})
+	}
+
+	header := fmt.Sprintf("## %s\n\n", pkg.ImportPath)
+	if pkg.Version != "" {
+		header += fmt.Sprintf("Version: %s\n\n", pkg.Version)
+	}
+	if overview := firstNonEmpty(pkg.Synopsis, pkg.Description); overview != "" {
+		header += firstSentence(overview) + "\n\n"
+	}
+	add(tierHeader, "", header, false)
+	if len(pkg.Identifiers) > 0 {
+		add(tierIdentifiers, "", fmt.Sprintf("Identifiers: %s\n\n", strings.Join(pkg.Identifiers, ", ")), false)
+	}
+
+	addSymbol := func(section, sig, desc string) {
+		if sig == "" {
+			return
+		}
+		add(tierSignature, section, fmt.Sprintf("- `%s`\n", sig), false)
+		if desc != "" {
+			add(tierDescription, section, fmt.Sprintf("  %s\n", firstSentence(desc)), false)
+		}
+	}
+
+	for _, c := range pkg.Constants {
+		addSymbol("Constants", c.Name, c.Description)
+	}
+	for _, v := range pkg.Variables {
+		addSymbol("Variables", v.Name, v.Description)
+	}
+	for _, f := range pkg.Functions {
+		addSymbol("Functions", oneLine(f.Signature), f.Description)
+	}
+	for _, t := range pkg.Types {
+		addSymbol("Types", typeHeadline(t), t.Description)
+		for _, m := range t.Methods {
+			addSymbol("Types", oneLine(m.Signature), m.Description)
+		}
+	}
+
+	for _, ex := range collectExamples(pkg) {
+		if ex.Code == "" {
+			continue
+		}
+		text := "```go\n" + ex.Code + "\n```\n\n"
+		if ex.Name != "" {
+			text = fmt.Sprintf("%s:\n\n", ex.Name) + text
+		}
+		add(tierExample, "Examples", text, true)
+	}
+
+	readme := firstNonEmpty(pkg.ProcessedReadme, pkg.Readme)
+	if !opts.KeepBadges {
+		readme = utils.StripBadges(readme, opts.BadgePatterns)
+	}
+	if readme != "" {
+		add(tierReadme, "README", strings.TrimSpace(readme)+"\n\n", true)
+	}
+	return items
+}
+
+func sectionHeading(section string) string {
+	return fmt.Sprintf("### %s\n\n", section)
+}
+
+// collectExamples gathers package, function, type and method examples in document order.
+func collectExamples(pkg *models.Package) []models.Example {
+	examples := append([]models.Example{}, pkg.Examples...)
+	for _, f := range pkg.Functions {
+		examples = append(examples, f.Examples...)
+	}
+	for _, t := range pkg.Types {
+		examples = append(examples, t.Examples...)
+		for _, m := range t.Methods {
+			examples = append(examples, m.Examples...)
+		}
+	}
+	return examples
+}
+
+// typeHeadline reduces a type definition to its first line, e.g. "type Command struct".
+func typeHeadline(t models.Type) string {
+	def := strings.TrimSpace(t.Definition)
+	if def == "" {
+		return t.Name
+	}
+	line := strings.SplitN(def, "\n", 2)[0]
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "{"))
+}
+
+// oneLine collapses a multi-line signature onto a single line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// firstSentence returns the first sentence of s, or s itself when no sentence boundary is found.
+func firstSentence(s string) string {
+	s = oneLine(s)
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+// trimToTokens cuts s to roughly the given number of tokens, preferring paragraph then line boundaries.
+func trimToTokens(s string, tokens int) string {
+	limit := tokens * 4
+	if limit <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	cut := string(runes[:limit])
+	if i := strings.LastIndex(cut, "\n\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	} else if i := strings.LastIndex(cut, "\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	cut = strings.TrimSpace(cut)
+	// Close a code fence left open by the cut.
+	if strings.Count(cut, "```")%2 == 1 {
+		cut += "\n```"
+	}
+	return cut
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package pack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+)
+
+func testPackages() []*models.Package {
+	return []*models.Package{
+		{
+			Name:            "zeta",
+			ImportPath:      "example.com/zeta",
+			Synopsis:        "Package zeta does the last thing. It has more to say.",
+			ProcessedReadme: strings.Repeat("Zeta readme paragraph with plenty of words.\n\n", 200),
+			Functions: []models.Function{
+				{Name: "Run", Signature: "func Run() error", Description: "Run runs zeta. Details follow."},
+			},
+		},
+		{
+			Name:       "alpha",
+			ImportPath: "example.com/alpha",
+			Synopsis:   "Package alpha does the first thing.",
+			Types: []models.Type{
+				{
+					Name:        "Client",
+					Definition:  "type Client struct {\n\tName string\n}",
+					Description: "Client talks to alpha.",
+					Methods:     []models.Function{{Name: "Client.Do", Signature: "func (c *Client) Do() error"}},
+					Examples:    []models.Example{{Name: "ExampleClient", Code: "c := &Client{}\nc.Do()"}},
+				},
+			},
+		},
+	}
+}
+
+func TestBuild_PrioritizesSignaturesWithinBudget(t *testing.T) {
+	budget := 400
+	out := Build(testPackages(), budget)
+
+	if tokens := utils.EstimateTokens(out); tokens > budget+16 {
+		t.Errorf("Pack should fit the budget of %d tokens, got %d", budget, tokens)
+	}
+	for _, want := range []string{"func Run() error", "type Client struct", "func (c *Client) Do() error"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Pack should contain signature %q", want)
+		}
+	}
+	if !strings.Contains(out, "truncated to fit token budget") {
+		t.Error("Oversized README should be trimmed, not included whole")
+	}
+	if strings.Index(out, "example.com/alpha") > strings.Index(out, "example.com/zeta") {
+		t.Error("Packages should be ordered by import path")
+	}
+	if strings.Contains(out, "Details follow") {
+		t.Error("Descriptions should be shortened to their first sentence")
+	}
+}
+
+func TestBuild_IdentifierList(t *testing.T) {
+	pkg := &models.Package{ImportPath: "example.com/a", Identifiers: []string{"Client", "Client.Do", "Run"}}
+	if out := Build([]*models.Package{pkg}, 100); !strings.Contains(out, "Identifiers: Client, Client.Do, Run\n") {
+		t.Errorf("Pack should list the identifiers, got:\n%s", out)
+	}
+}
+
+func TestBuild_Deterministic(t *testing.T) {
+	pkgs := testPackages()
+	reversed := []*models.Package{pkgs[1], pkgs[0]}
+	if Build(pkgs, 300) != Build(reversed, 300) {
+		t.Error("Build output should not depend on input order")
+	}
+}
+
+func TestBuild_StripsBadges(t *testing.T) {
+	pkgs := []*models.Package{{
+		Name:            "alpha",
+		ImportPath:      "example.com/alpha",
+		ProcessedReadme: "[![CI](https://github.com/o/alpha/actions/workflows/ci.yml/badge.svg)](https://github.com/o/alpha/actions)\n\nAlpha does things.",
+	}}
+	if out := Build(pkgs, 1000); strings.Contains(out, "badge.svg") || !strings.Contains(out, "Alpha does things.") {
+		t.Errorf("Expected badges dropped from the README, got:\n%s", out)
+	}
+	if out := BuildWithOptions(pkgs, 1000, Options{KeepBadges: true}); !strings.Contains(out, "badge.svg") {
+		t.Errorf("Expected KeepBadges to keep them, got:\n%s", out)
+	}
+}
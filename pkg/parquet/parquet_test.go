@@ -0,0 +1,106 @@
+package parquet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// readFile opens the Parquet file in b with parquet-go's reader.
+func readFile(t *testing.T, b []byte) *pq.File {
+	t.Helper()
+	f, err := pq.OpenFile(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("Expected a valid Parquet file, got %v", err)
+	}
+	return f
+}
+
+func TestWriter(t *testing.T) {
+	pkg := &models.Package{
+		ImportPath: "github.com/spf13/cobra",
+		Version:    "v1.9.1",
+		Functions:  []models.Function{{Name: "Eq", Signature: "func Eq(a, b any) bool", Deprecated: "deprecated"}},
+		Types: []models.Type{{
+			Name:       "Command",
+			Definition: "type Command struct{ ... }",
+			Methods:    []models.Function{{Name: "Command.Execute", Signature: "func (c *Command) Execute() error", AddedIn: "v1.0.0"}},
+		}},
+	}
+	records := Records(pkg)
+	if len(records) != 3 || records[2].Kind != "method" || !records[0].Deprecated || records[2].AddedIn != "v1.0.0" {
+		t.Fatalf("Expected func, type and method records, got %+v", records)
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Write(records...); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	f := readFile(t, buf.Bytes())
+	var names []string
+	for _, col := range f.Schema().Fields() {
+		names = append(names, col.Name())
+		if col.Optional() || col.Repeated() {
+			t.Errorf("Expected column %s to be required", col.Name())
+		}
+		if col.Name() != "deprecated" && col.Type().LogicalType().UTF8 == nil {
+			t.Errorf("Expected column %s to hold UTF-8 strings, got %v", col.Name(), col.Type())
+		}
+	}
+	if want := []string{"package", "version", "kind", "name", "signature", "deprecated", "added_in"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected columns %v, got %v", want, names)
+	}
+	for _, group := range f.Metadata().RowGroups {
+		for _, chunk := range group.Columns {
+			if meta := chunk.MetaData; meta.Codec != format.Uncompressed || !reflect.DeepEqual(meta.Encoding, []format.Encoding{format.Plain}) {
+				t.Errorf("Expected %v PLAIN and uncompressed, got %v %v", meta.PathInSchema, meta.Encoding, meta.Codec)
+			}
+		}
+	}
+
+	got, err := pq.Read[Record](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("Expected the records back, got %+v", got)
+	}
+}
+
+func TestWriterRowGroups(t *testing.T) {
+	records := make([]Record, RowGroupSize+1)
+	for i := range records {
+		records[i] = Record{Package: "example.com/p", Kind: "func", Name: "F"}
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Write(records...); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	f := readFile(t, buf.Bytes())
+	if groups := f.RowGroups(); len(groups) != 2 || groups[0].NumRows() != RowGroupSize || f.NumRows() != RowGroupSize+1 {
+		t.Errorf("Expected %d rows split after %d, got %d in %d row group(s)", RowGroupSize+1, RowGroupSize, f.NumRows(), len(groups))
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if f := readFile(t, buf.Bytes()); f.NumRows() != 0 || len(f.Schema().Fields()) != 7 {
+		t.Errorf("Expected a valid file without rows, got %d row(s) and schema %v", f.NumRows(), f.Schema())
+	}
+}
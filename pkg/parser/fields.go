@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"bytes"
+	"go/ast"
+	goparser "go/parser"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// parseTypeDecl parses a type declaration as shown on pkg.go.dev and returns its type
+// expression, or nil when def is not a single type declaration that parses.
+func parseTypeDecl(def string) (*token.FileSet, ast.Expr) {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "", "package p\n"+def, goparser.ParseComments)
+	if err != nil || len(file.Decls) == 0 {
+		return nil, nil
+	}
+	gen, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok || gen.Tok != token.TYPE || len(gen.Specs) != 1 {
+		return nil, nil
+	}
+	return fset, gen.Specs[0].(*ast.TypeSpec).Type
+}
+
+// structFields parses a struct type declaration as shown on pkg.go.dev and lists its exported
+// fields with their type, tag and doc or trailing line comment. It returns nil for other types
+// and for declarations that do not parse.
+func structFields(def string) []models.Field {
+	fset, expr := parseTypeDecl(def)
+	st, ok := expr.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+
+	var fields []models.Field
+	for _, f := range st.Fields.List {
+		typ, ok := printNode(fset, f.Type)
+		if !ok {
+			continue
+		}
+		field := models.Field{Type: typ, Doc: fieldDoc(f)}
+		if f.Tag != nil {
+			field.Tag, _ = strconv.Unquote(f.Tag.Value)
+		}
+		names := f.Names
+		if len(names) == 0 { // embedded field
+			names = []*ast.Ident{ast.NewIdent(strings.TrimPrefix(field.Type[strings.LastIndex(field.Type, ".")+1:], "*"))}
+		}
+		for _, n := range names {
+			if ast.IsExported(n.Name) {
+				field.Name = n.Name
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// interfaceMethods parses an interface type declaration as shown on pkg.go.dev and lists its
+// methods, embedded interfaces and type set terms in declaration order. It returns nil for other
+// types and for declarations that do not parse.
+func interfaceMethods(def string) []models.Function {
+	fset, expr := parseTypeDecl(def)
+	it, ok := expr.(*ast.InterfaceType)
+	if !ok {
+		return nil
+	}
+
+	var methods []models.Function
+	for _, f := range it.Methods.List {
+		typ, ok := printNode(fset, f.Type)
+		if !ok {
+			continue
+		}
+		if len(f.Names) == 0 { // embedded interface or type set term
+			methods = append(methods, models.Function{Name: typ, Signature: typ, Description: fieldDoc(f)})
+			continue
+		}
+		for _, n := range f.Names {
+			methods = append(methods, models.Function{Name: n.Name, Signature: n.Name + strings.TrimPrefix(typ, "func"), Description: fieldDoc(f)})
+		}
+	}
+	return methods
+}
+
+// fieldDoc returns the doc comment of a struct field or interface method, or else its trailing
+// line comment.
+func fieldDoc(f *ast.Field) string {
+	if f.Doc != nil {
+		return strings.TrimSpace(f.Doc.Text())
+	}
+	if f.Comment != nil {
+		return strings.TrimSpace(f.Comment.Text())
+	}
+	return ""
+}
+
+// printNode prints an AST node as gofmt would.
+func printNode(fset *token.FileSet, n ast.Node) (string, bool) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, n); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
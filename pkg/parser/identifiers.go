@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/moseye/docinator/internal/models"
+)
+
+// parseIdentifiers reads the Jump to index: the exported identifiers of the package in
+// declaration order, methods as "Type.Method". Section anchors such as #pkg-overview and
+// example anchors are skipped.
+func parseIdentifiers(doc *goquery.Selection, sel *Selectors, m *matcher) []string {
+	var ids []string
+	seen := map[string]bool{}
+	m.find("identifiers", doc, sel.Identifiers).Each(func(_ int, s *goquery.Selection) {
+		id := s.AttrOr("href", s.AttrOr("value", s.AttrOr("id", "")))
+		if i := strings.LastIndex(id, "#"); i >= 0 {
+			id = id[i+1:]
+		}
+		if !isIdentifier(id) || seen[id] {
+			return
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	})
+	return ids
+}
+
+// isIdentifier reports whether id is an exported Go identifier or an exported Type.Method pair.
+func isIdentifier(id string) bool {
+	typ, method, isMethod := strings.Cut(id, ".")
+	if isMethod && !exported(method) {
+		return false
+	}
+	return exported(typ)
+}
+
+func exported(name string) bool {
+	for i, r := range name {
+		if i == 0 && !unicode.IsUpper(r) || !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// MissingIdentifiers returns the identifiers of the Jump to index that the parser found no
+// declaration for, to spot symbols the selector profile misses. Constants and variables count as
+// found when their name appears in any parsed declaration block, since grouped declarations are
+// stored as one block.
+func MissingIdentifiers(pkg *models.Package) []string {
+	found := map[string]bool{}
+	addWords := func(code string) {
+		for _, w := range strings.FieldsFunc(code, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' }) {
+			found[w] = true
+		}
+	}
+	for _, c := range pkg.Constants {
+		found[c.Name] = true
+		addWords(c.Value)
+	}
+	for _, v := range pkg.Variables {
+		found[v.Name] = true
+		addWords(v.Type)
+	}
+	for _, f := range pkg.Functions {
+		found[f.Name] = true
+	}
+	for _, t := range pkg.Types {
+		found[t.Name] = true
+		for _, m := range t.Methods {
+			found[m.Name] = true
+		}
+	}
+	var missing []string
+	for _, id := range pkg.Identifiers {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
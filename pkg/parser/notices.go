@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"cmp"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/moseye/docinator/internal/models"
+)
+
+// platformPattern matches GOOS/GOARCH pairs such as linux/amd64 and js/wasm in banner text.
+var platformPattern = regexp.MustCompile(`\b(aix|android|darwin|dragonfly|freebsd|illumos|ios|js|linux|netbsd|openbsd|plan9|solaris|wasip1|windows)/([a-z0-9]+)\b`)
+
+// parseNotices extracts the banners pkg.go.dev shows above the documentation and the Details and
+// Links panels.
+func parseNotices(doc *goquery.Selection, sel *Selectors, m *matcher, pkg *models.Package) {
+	if el := m.find("module_deprecated", doc, sel.ModuleDeprecated).First(); el.Length() > 0 {
+		pkg.ModuleDeprecated = true
+		pkg.DeprecationNotice = collapseSpace(el.Text())
+		log.Printf("Module is deprecated: %s", pkg.DeprecationNotice)
+	}
+	if el := m.find("retracted", doc, sel.Retracted).First(); el.Length() > 0 {
+		pkg.Retracted = true
+		pkg.RetractionNotice = collapseSpace(el.Text())
+		log.Printf("Version is retracted: %s", pkg.RetractionNotice)
+	}
+	pkg.Redistributable = true
+	if el := m.find("license_restricted", doc, sel.LicenseRestricted).First(); el.Length() > 0 {
+		pkg.Redistributable = false
+		pkg.RedistributionNotice = cmp.Or(collapseSpace(el.Text()), "Documentation not displayed due to license restrictions.")
+		log.Printf("Documentation withheld: %s", pkg.RedistributionNotice)
+	}
+	if el := m.find("platform_notice", doc, sel.PlatformNotice).First(); el.Length() > 0 {
+		// The build context is either a sentence naming the platforms or a dropdown of them.
+		var platforms []string
+		el.Find("option").Each(func(_ int, opt *goquery.Selection) {
+			platforms = append(platforms, platformPattern.FindAllString(opt.AttrOr("value", opt.Text()), -1)...)
+		})
+		notice := el.Clone()
+		notice.Find("select").Remove()
+		pkg.PlatformNotice = collapseSpace(notice.Text())
+		if len(platforms) == 0 {
+			platforms = platformPattern.FindAllString(pkg.PlatformNotice, -1)
+		}
+		slices.Sort(platforms)
+		pkg.Platforms = slices.Compact(platforms)
+		log.Printf("Set platform notice to: %s (%d platforms)", pkg.PlatformNotice, len(pkg.Platforms))
+	}
+	if pkg.Details = parseDetails(doc, sel, m); pkg.Details != nil {
+		log.Printf("Set details to: %+v", *pkg.Details)
+	}
+	m.find("links", doc, sel.Links).Each(func(_ int, a *goquery.Selection) {
+		href := strings.TrimSpace(a.AttrOr("href", ""))
+		if href == "" {
+			return
+		}
+		if strings.HasPrefix(href, "/") {
+			href = "https://pkg.go.dev" + href
+		}
+		pkg.Links = append(pkg.Links, models.Link{Label: cmp.Or(collapseSpace(a.Text()), href), URL: href})
+	})
+	if len(pkg.Links) > 0 {
+		log.Printf("Added %d links", len(pkg.Links))
+	}
+}
+
+// collapseSpace trims s and replaces each run of whitespace with a single space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// detailChecks maps the label prefix of each Details checklist item to its field.
+var detailChecks = map[string]func(*models.Details) *bool{
+	"valid go.mod":            func(d *models.Details) *bool { return &d.ValidGoMod },
+	"redistributable license": func(d *models.Details) *bool { return &d.RedistributableLicense },
+	"tagged version":          func(d *models.Details) *bool { return &d.TaggedVersion },
+	"stable version":          func(d *models.Details) *bool { return &d.StableVersion },
+}
+
+// parseDetails reads the Details panel checklist. An item passes when its icon is a checkmark:
+// pkg.go.dev marks it with alt="checked" and a check_circle image, failures with "unchecked"/cancel.
+func parseDetails(doc *goquery.Selection, sel *Selectors, m *matcher) *models.Details {
+	var details *models.Details
+	m.find("details", doc, sel.Details).Each(func(_ int, item *goquery.Selection) {
+		label := strings.ToLower(collapseSpace(item.Text()))
+		for prefix, field := range detailChecks {
+			if !strings.HasPrefix(label, prefix) {
+				continue
+			}
+			if details == nil {
+				details = &models.Details{}
+			}
+			icon := item.Find("img").First()
+			alt, src := strings.ToLower(icon.AttrOr("alt", "")), icon.AttrOr("src", "")
+			*field(details) = alt == "checked" || alt == "" && strings.Contains(src, "check_circle")
+		}
+	})
+	return details
+}
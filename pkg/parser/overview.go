@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/moseye/docinator/internal/utils"
+)
+
+// overviewMarkdown converts the overview section of a package page, the rendered package doc
+// comment, to markdown: paragraphs, code blocks, lists and headings in page order. Headings
+// start at level 3, below the "## Overview" heading of the markdown output. The section's own
+// title and its examples, which are parsed separately, are left out.
+func overviewMarkdown(section *goquery.Selection) string {
+	var blocks []string
+	section.Children().Each(func(_ int, el *goquery.Selection) {
+		switch goquery.NodeName(el) {
+		case "p":
+			if text := inlineMarkdown(el); text != "" {
+				blocks = append(blocks, text)
+			}
+		case "pre":
+			if code := strings.Trim(el.Text(), "\n"); strings.TrimSpace(code) != "" {
+				blocks = append(blocks, "```\n"+code+"\n```")
+			}
+		case "h3", "h4", "h5":
+			if el.HasClass("Documentation-overviewHeader") {
+				return
+			}
+			if text := strings.TrimSpace(strings.TrimSuffix(collapseSpace(el.Text()), "¶")); text != "" {
+				blocks = append(blocks, "### "+text)
+			}
+		case "ul", "ol":
+			var items []string
+			el.ChildrenFiltered("li").Each(func(i int, li *goquery.Selection) {
+				marker := "- "
+				if goquery.NodeName(el) == "ol" {
+					marker = "1. "
+				}
+				items = append(items, marker+inlineMarkdown(li))
+			})
+			if len(items) > 0 {
+				blocks = append(blocks, strings.Join(items, "\n"))
+			}
+		}
+	})
+	return strings.Join(blocks, "\n\n")
+}
+
+// inlineMarkdown converts the inline content of el (text, links, code spans, emphasis) to a
+// single line of markdown. Links relative to pkg.go.dev are made absolute.
+func inlineMarkdown(el *goquery.Selection) string {
+	html, err := el.Html()
+	if err != nil {
+		return collapseSpace(el.Text())
+	}
+	md := collapseSpace(utils.ConvertHTMLToMarkdown(html))
+	return strings.ReplaceAll(md, "](/", "](https://pkg.go.dev/")
+}
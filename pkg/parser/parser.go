@@ -1,40 +1,97 @@
 package parser
 
 import (
+	"context"
+	"io"
 	"log"
+	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
+	"github.com/moseye/docinator/internal/logging"
 	"github.com/moseye/docinator/internal/models"
 	"github.com/moseye/docinator/internal/utils"
+	"github.com/moseye/docinator/pkg/readme"
 )
 
 // Parser handles HTML parsing operations for pkg.go.dev pages
 type Parser struct {
-	// Future: Add any parser configuration here
+	sel         *Selectors
+	fingerprint string
+	profile     Profile
 }
 
-// New creates a new Parser instance
+// New creates a new Parser instance using the embedded selector profile
 func New() *Parser {
-	return &Parser{}
+	return NewWithSelectors(DefaultSelectors())
+}
+
+// NewWithSelectors creates a Parser that finds page elements with sel; nil uses the defaults.
+func NewWithSelectors(sel *Selectors) *Parser {
+	if sel == nil {
+		sel = DefaultSelectors()
+	}
+	return &Parser{sel: sel, fingerprint: fingerprint(sel)}
+}
+
+// WithProfile returns a copy of p that parses only the sections of profile.
+func (p *Parser) WithProfile(profile Profile) *Parser {
+	c := *p
+	c.profile = profile
+	return &c
 }
 
 // ParsePackagePage parses a pkg.go.dev package page and extracts structured data
 func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error) {
-	doc := e.DOM
-	pkg := &models.Package{}
+	pkg, _, err := p.ParsePackagePageWithExtraction(e)
+	return pkg, err
+}
+
+// ParsePackagePageWithExtraction is ParsePackagePage that also reports which selector of each
+// chain in the profile found its element.
+func (p *Parser) ParsePackagePageWithExtraction(e *colly.HTMLElement) (*models.Package, Extraction, error) {
+	return p.parse(e.DOM)
+}
+
+// ParseHTML parses a pkg.go.dev package page read from r, such as a saved page or a test
+// fixture, without going through the scraper.
+func (p *Parser) ParseHTML(r io.Reader) (*models.Package, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	pkg, _, err := p.parse(doc.Selection)
+	return pkg, err
+}
+
+// ParseHTMLWithExtraction is ParseHTML that also reports which selector of each chain in the
+// profile found its element.
+func (p *Parser) ParseHTMLWithExtraction(r io.Reader) (*models.Package, Extraction, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.parse(doc.Selection)
+}
+
+// parse extracts the package from the document of a package page.
+func (p *Parser) parse(doc *goquery.Selection) (*models.Package, Extraction, error) {
+	sel := p.sel
+	m := &matcher{won: Extraction{}}
+	pkg := &models.Package{SchemaVersion: models.SchemaVersion, Parser: p.fingerprint, Profile: string(p.profile)}
 
 	// Extract metadata
 	// Package Name from title heading
-	if el := doc.Find("h1.UnitHeader-titleHeading"); el.Length() > 0 {
+	if el := m.find("name", doc, sel.Name); el.Length() > 0 {
 		pkg.Name = strings.TrimSpace(el.Text())
 		log.Printf("Set package name to: %s", pkg.Name)
 	}
 
 	// Import Path from breadcrumb current
-	if el := doc.Find(".UnitHeader-breadcrumbCurrent"); el.Length() > 0 {
+	if el := m.find("import_path", doc, sel.ImportPath); el.Length() > 0 {
 		text := strings.TrimSpace(el.Text())
 		if text != "" {
 			pkg.ImportPath = text
@@ -42,39 +99,69 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 		}
 	}
 
-	// Version from aria-label (more reliable)
-	if el := doc.Find("a[aria-label^='Version: ']"); el.Length() > 0 {
-		ariaLabel := el.AttrOr("aria-label", "")
-		if strings.HasPrefix(ariaLabel, "Version: ") {
+	// Module path: the first breadcrumb link after "Discover Packages" is the module root for
+	// packages below it; on the module root page itself the current breadcrumb is the module.
+	m.find("module", doc, sel.Module).EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		if href := a.AttrOr("href", ""); href == "/" || href == "" {
+			return true
+		}
+		text := strings.TrimSpace(a.Text())
+		if text == "Standard library" {
+			text = "std"
+		}
+		pkg.Module = text
+		return false
+	})
+	if pkg.Module == "" && strings.Contains(pkg.ImportPath, ".") {
+		pkg.Module = pkg.ImportPath
+	}
+	if pkg.Module != "" {
+		log.Printf("Set module to: %s", pkg.Module)
+	}
+
+	// Version from aria-label (more reliable), else from the text of fallback elements
+	if el := m.find("package_version", doc, sel.PackageVersion).First(); el.Length() > 0 {
+		if ariaLabel := el.AttrOr("aria-label", ""); strings.HasPrefix(ariaLabel, "Version: ") {
 			pkg.Version = strings.TrimPrefix(ariaLabel, "Version: ")
+		} else if text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(el.Text()), "Version: ")); strings.HasPrefix(text, "v") || strings.HasPrefix(text, "go") {
+			pkg.Version = text
+		}
+		if pkg.Version != "" {
 			log.Printf("Set version to: %s", pkg.Version)
 		}
 	}
 
 	// IsLatest from badge (support multiple possible class names)
-	if doc.Find(".DetailsHeader-badge--latest, .UnitHeader-badge--latest, .DetailsHeader-span--latest").Length() > 0 {
-		if strings.Contains(doc.Find(".DetailsHeader-badge--latest, .UnitHeader-badge--latest, .DetailsHeader-span--latest").Text(), "Latest") {
+	if el := m.find("latest", doc, sel.Latest); el.Length() > 0 {
+		if strings.Contains(el.Text(), "Latest") {
 			pkg.IsLatest = true
 			log.Printf("Package is latest version")
 		}
 	}
 
 	// Published from span with data-test-id
-	if el := doc.Find("[data-test-id='UnitHeader-commitTime']"); el.Length() > 0 {
+	if el := m.find("published", doc, sel.Published); el.Length() > 0 {
 		text := strings.TrimSpace(el.Text())
 		if strings.HasPrefix(text, "Published: ") {
 			pkg.Published = strings.TrimSpace(strings.TrimPrefix(text, "Published: "))
 			log.Printf("Set published to: %s", pkg.Published)
+			if t, ok := utils.ParseDate(pkg.Published, time.Now()); ok {
+				pkg.PublishedAt = t
+			}
 		}
 	}
 
-	// Extract license information (set License and LicenseURL)
-	e.ForEach("a[data-test-id='UnitHeader-license'], [data-test-id='UnitHeader-licenses'] a, .UnitHeader-license a", func(_ int, el *colly.HTMLElement) {
-		licenseText := strings.TrimSpace(el.Text)
-		licenseHref := el.Attr("href")
-		if licenseText == "" && el.DOM != nil {
-			licenseText = strings.TrimSpace(el.DOM.Text())
+	// Minimum Go version from the go directive of the module's go.mod
+	if el := m.find("go_version", doc, sel.GoVersion).First(); el.Length() > 0 {
+		if pkg.GoVersion = goVersion(el.Text()); pkg.GoVersion != "" {
+			log.Printf("Set Go version to: %s", pkg.GoVersion)
 		}
+	}
+
+	// Extract license information (set License and LicenseURL)
+	m.find("license", doc, sel.License).Each(func(_ int, el *goquery.Selection) {
+		licenseText := strings.TrimSpace(el.Text())
+		licenseHref := el.AttrOr("href", "")
 		if licenseText != "" {
 			pkg.License = licenseText
 			if licenseHref != "" {
@@ -90,7 +177,7 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 	})
 
 	// Imports
-	if el := doc.Find("[data-test-id='UnitHeader-imports'] a"); el.Length() > 0 {
+	if el := m.find("imports", doc, sel.Imports); el.Length() > 0 {
 		aria := el.AttrOr("aria-label", "")
 		// Fallback to text content if aria-label missing
 		value := aria
@@ -109,7 +196,7 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 	}
 
 	// Imported By
-	if el := doc.Find("[data-test-id='UnitHeader-importedby'] a"); el.Length() > 0 {
+	if el := m.find("imported_by", doc, sel.ImportedBy); el.Length() > 0 {
 		aria := el.AttrOr("aria-label", "")
 		// Fallback to text content if aria-label missing
 		value := aria
@@ -132,28 +219,47 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 	}
 
 	// Extract repository URL
-	e.ForEach(".UnitMeta-repo a", func(_ int, el *colly.HTMLElement) {
-		pkg.Repository = strings.TrimSpace(el.Attr("href"))
+	m.find("repository", doc, sel.Repository).Each(func(_ int, el *goquery.Selection) {
+		pkg.Repository = strings.TrimSpace(el.AttrOr("href", ""))
 	})
 
 	// Synopsis / Description (prefer overview paragraph)
-	if el := doc.Find(".Documentation-overview p"); el.Length() > 0 {
+	if el := m.find("overview", doc, sel.Overview); el.Length() > 0 {
 		pkg.Description = strings.TrimSpace(el.First().Text())
 		log.Printf("Set synopsis/description to: %s", pkg.Description)
 	}
+	if !p.profile.readme() {
+		parseNotices(doc, sel, m, pkg)
+		pkg.Warnings = packageWarnings(pkg, m.won, nil)
+		return pkg, m.won, nil
+	}
+	if el := m.find("overview_section", doc, sel.OverviewSection).First(); el.Length() > 0 {
+		pkg.Overview = overviewMarkdown(el)
+	}
 
-	// README HTML
-	if el := doc.Find(".UnitReadme-content .Overview-readmeContent"); el.Length() > 0 {
+	// README HTML. The element is kept for the checks below, which would otherwise parse the
+	// README HTML again.
+	var readmeEl *goquery.Selection
+	if el := m.find("readme", doc, sel.Readme); el.Length() > 0 {
 		html, err := el.Html()
 		if err == nil {
+			readmeEl = el.First()
 			pkg.Readme = html
 			pkg.ProcessedReadme = utils.ConvertHTMLToMarkdown(html)
+			pkg.ReadmeLang = readme.DetectLang(pkg.ProcessedReadme)
+			pkg.ReadmeAlternates = readme.AlternatesIn(readmeEl)
 			log.Printf("Extracted and converted README")
 		}
 	}
 
+	if !p.profile.symbols() {
+		parseNotices(doc, sel, m, pkg)
+		pkg.Warnings = packageWarnings(pkg, m.won, readmeEl)
+		return pkg, m.won, nil
+	}
+
 	// Constants: iterate declaration blocks and extract pre + adjacent description
-	doc.Find(".Documentation-constants .Documentation-declaration").Each(func(i int, s *goquery.Selection) {
+	m.find("constants", doc, sel.Constants).Each(func(i int, s *goquery.Selection) {
 		pre := s.Find("pre").First()
 		code := strings.TrimSpace(pre.Text())
 		if code == "" {
@@ -169,7 +275,7 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 		log.Printf("Added constant block: %s", name)
 	})
 	// Variables: iterate declaration blocks and extract pre + adjacent description
-	doc.Find(".Documentation-variables .Documentation-declaration").Each(func(i int, s *goquery.Selection) {
+	m.find("variables", doc, sel.Variables).Each(func(i int, s *goquery.Selection) {
 		pre := s.Find("pre").First()
 		code := strings.TrimSpace(pre.Text())
 		if code == "" {
@@ -185,33 +291,30 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 		log.Printf("Added variable block: %s", name)
 	})
 	// Functions
-	doc.Find(".Documentation-functions .Documentation-function").Each(func(i int, s *goquery.Selection) {
+	m.find("functions", doc, sel.Functions).Each(func(i int, s *goquery.Selection) {
 
 		header := s.Find("h4").First()
 
 		id := header.AttrOr("id", "")
 
 		// Prefer signature from declaration <pre> (reliable)
-		sig := strings.TrimSpace(s.Find(".Documentation-declaration pre").First().Text())
+		sig := strings.TrimSpace(m.find("declaration", s, sel.Declaration).First().Text())
 
 		if sig != "" {
 
 			// AddedIn version (just the version token, e.g., v1.1.2, if available)
-			addedIn := strings.TrimSpace(s.Find(".Documentation-sinceVersionVersion").First().Text())
-			if addedIn == "" {
-				// fallback to entire sinceVersion text
-				addedIn = strings.TrimSpace(s.Find(".Documentation-sinceVersion").First().Text())
-			}
+			addedIn := strings.TrimSpace(m.find("since_version", s, sel.SinceVersion).First().Text())
 
 			// Description is the first paragraph under the function block
 			desc := strings.TrimSpace(s.Find("p").First().Text())
 
 			deprecated := ""
-			if s.Find(".Documentation-deprecatedTag").Length() > 0 {
+			if m.find("deprecated", s, sel.Deprecated).Length() > 0 {
 				deprecated = "deprecated"
 			}
 
 			function := models.Function{Name: id, Signature: sig, Description: desc, Deprecated: deprecated, AddedIn: addedIn}
+			function.SourceURL, function.SourceFile, function.SourceLine = sourceLocation(m.find("source_link", header, sel.SourceLink))
 
 			pkg.Functions = append(pkg.Functions, function)
 
@@ -222,35 +325,35 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 	})
 
 	// Types
-	doc.Find(".Documentation-types .Documentation-type").Each(func(i int, s *goquery.Selection) {
+	m.find("types", doc, sel.Types).Each(func(i int, s *goquery.Selection) {
 
 		header := s.Find("h4").First()
 
 		id := header.AttrOr("id", "")
 
 		// Type definition from declaration <pre>
-		def := strings.TrimSpace(s.Find(".Documentation-declaration pre").First().Text())
+		def := strings.TrimSpace(m.find("declaration", s, sel.Declaration).First().Text())
 
 		if id != "" && def != "" {
 
 			// AddedIn version
-			addedIn := strings.TrimSpace(s.Find(".Documentation-sinceVersionVersion").First().Text())
-			if addedIn == "" {
-				addedIn = strings.TrimSpace(s.Find(".Documentation-sinceVersion").First().Text())
-			}
+			addedIn := strings.TrimSpace(m.find("since_version", s, sel.SinceVersion).First().Text())
 
 			// Description: pick first paragraph in the type block (after declaration)
 			desc := strings.TrimSpace(s.Find("p").First().Text())
 
 			deprecated := ""
-			if s.Find(".Documentation-deprecatedTag").Length() > 0 {
+			if m.find("deprecated", s, sel.Deprecated).Length() > 0 {
 				deprecated = "deprecated"
 			}
 
 			typeInfo := models.Type{Name: id, Definition: def, Kind: "type", Description: desc, Deprecated: deprecated, AddedIn: addedIn}
+			typeInfo.SourceURL, typeInfo.SourceFile, typeInfo.SourceLine = sourceLocation(m.find("source_link", header, sel.SourceLink))
+			typeInfo.Fields = structFields(def)
+			typeInfo.InterfaceMethods = interfaceMethods(def)
 
 			// Methods
-			s.Find(".Documentation-typeMethod").Each(func(j int, methodSel *goquery.Selection) {
+			m.find("methods", s, sel.Methods).Each(func(j int, methodSel *goquery.Selection) {
 
 				// Method header id, e.g., Command.AddCommand
 				mh := methodSel.Find("h4").First()
@@ -260,25 +363,23 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 				}
 
 				// Signature from declaration pre
-				mSig := strings.TrimSpace(methodSel.Find(".Documentation-declaration pre").First().Text())
+				mSig := strings.TrimSpace(m.find("declaration", methodSel, sel.Declaration).First().Text())
 
 				// Description (first paragraph within the method block)
 				mDesc := strings.TrimSpace(methodSel.Find("p").First().Text())
 
 				// Since version
-				mAddedIn := strings.TrimSpace(methodSel.Find(".Documentation-sinceVersionVersion").First().Text())
-				if mAddedIn == "" {
-					mAddedIn = strings.TrimSpace(methodSel.Find(".Documentation-sinceVersion").First().Text())
-				}
+				mAddedIn := strings.TrimSpace(m.find("since_version", methodSel, sel.SinceVersion).First().Text())
 
 				// Deprecated tag
 				mDeprecated := ""
-				if methodSel.Find(".Documentation-deprecatedTag").Length() > 0 {
+				if m.find("deprecated", methodSel, sel.Deprecated).Length() > 0 {
 					mDeprecated = "deprecated"
 				}
 
 				if mSig != "" || mName != "" {
 					method := models.Function{Name: mName, Signature: mSig, Description: mDesc, Deprecated: mDeprecated, AddedIn: mAddedIn}
+					method.SourceURL, method.SourceFile, method.SourceLine = sourceLocation(m.find("source_link", mh, sel.SourceLink))
 					typeInfo.Methods = append(typeInfo.Methods, method)
 				}
 			})
@@ -291,5 +392,251 @@ func (p *Parser) ParsePackagePage(e *colly.HTMLElement) (*models.Package, error)
 
 	})
 
-	return pkg, nil
+	// Source files
+	m.find("files", doc, sel.Files).Each(func(_ int, a *goquery.Selection) {
+		name := strings.TrimSpace(a.Text())
+		if name == "" {
+			return
+		}
+		pkg.Files = append(pkg.Files, models.SourceFile{Name: name, URL: strings.TrimSpace(a.AttrOr("href", ""))})
+	})
+	if len(pkg.Files) > 0 {
+		log.Printf("Added %d source files", len(pkg.Files))
+	}
+
+	parseNotices(doc, sel, m, pkg)
+	pkg.Identifiers = parseIdentifiers(doc, sel, m)
+
+	// Examples, attached to their symbols following the Go example naming convention
+	attachExamples(pkg, parseExamples(doc, sel, m))
+
+	pkg.Warnings = packageWarnings(pkg, m.won, readmeEl)
+	traceSymbols(pkg)
+	return pkg, m.won, nil
+}
+
+// traceSymbols logs every parsed symbol at trace level (-vvv).
+func traceSymbols(pkg *models.Package) {
+	ctx := context.Background()
+	if !slog.Default().Enabled(ctx, logging.LevelTrace) {
+		return
+	}
+	for _, c := range pkg.Constants {
+		slog.Log(ctx, logging.LevelTrace, "parser: constant", "package", pkg.ImportPath, "name", c.Name)
+	}
+	for _, v := range pkg.Variables {
+		slog.Log(ctx, logging.LevelTrace, "parser: variable", "package", pkg.ImportPath, "name", v.Name)
+	}
+	for _, f := range pkg.Functions {
+		slog.Log(ctx, logging.LevelTrace, "parser: function", "package", pkg.ImportPath, "name", f.Name, "file", f.SourceFile, "line", f.SourceLine)
+	}
+	for _, t := range pkg.Types {
+		slog.Log(ctx, logging.LevelTrace, "parser: type", "package", pkg.ImportPath, "name", t.Name, "methods", len(t.Methods), "file", t.SourceFile, "line", t.SourceLine)
+		for _, m := range t.Methods {
+			slog.Log(ctx, logging.LevelTrace, "parser: method", "package", pkg.ImportPath, "type", t.Name, "name", m.Name)
+		}
+	}
+	for _, ex := range pkg.AllExamples() {
+		slog.Log(ctx, logging.LevelTrace, "parser: example", "package", pkg.ImportPath, "name", ex.Name)
+	}
+}
+
+// parseExamples extracts every example details block from the documentation.
+func parseExamples(doc *goquery.Selection, sel *Selectors, m *matcher) []models.Example {
+	var examples []models.Example
+	m.find("examples", doc, sel.Examples).Each(func(i int, s *goquery.Selection) {
+		name := strings.TrimPrefix(s.AttrOr("id", ""), "example-")
+		if name == "" {
+			name = strings.TrimSpace(m.find("example_header", s, sel.ExampleHeader).First().Text())
+		}
+
+		code := strings.TrimSpace(m.find("example_code", s, sel.ExampleCode).First().Text())
+		if code == "" {
+			return
+		}
+
+		// Expected output pane ("Output:" / "Unordered output:")
+		output := strings.Trim(m.find("example_output", s, sel.ExampleOutput).First().Text(), "\n")
+
+		example := models.Example{Name: name, Code: code, Output: output, PlaygroundURL: playgroundURL(s)}
+		examples = append(examples, example)
+		log.Printf("Added example: %s", name)
+	})
+	return examples
+}
+
+// ParseImporters extracts up to limit importing package paths from an "importedby" tab page.
+func (p *Parser) ParseImporters(e *colly.HTMLElement, limit int) []string {
+	return p.parsePathList(e, "importers", p.sel.Importers, limit)
+}
+
+// ParseImports extracts the imported package paths from an "imports" tab page, standard library
+// first, in page order.
+func (p *Parser) ParseImports(e *colly.HTMLElement) []string {
+	return p.parsePathList(e, "import_list", p.sel.ImportList, 0)
+}
+
+// parsePathList returns the distinct texts of up to limit package links (limit 0: all) matched
+// by the chain.
+func (p *Parser) parsePathList(e *colly.HTMLElement, key string, chain Chain, limit int) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	m := &matcher{won: Extraction{}}
+	m.find(key, e.DOM, chain).EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		if limit > 0 && len(paths) >= limit {
+			return false
+		}
+		href := a.AttrOr("href", "")
+		path := strings.TrimSpace(a.Text())
+		if !strings.HasPrefix(href, "/") || path == "" || seen[path] {
+			return true
+		}
+		seen[path] = true
+		paths = append(paths, path)
+		return true
+	})
+	return paths
+}
+
+// sourceLocation reads the source link of a declaration header and returns the link together
+// with the file path and line number it points at.
+func sourceLocation(link *goquery.Selection) (string, string, int) {
+	href := strings.TrimSpace(link.First().AttrOr("href", ""))
+	if href == "" {
+		return "", "", 0
+	}
+	file, line := ParseSourceURL(href)
+	return href, file, line
+}
+
+// sourceRefMarkers precede the "<ref>/<path>" part of repository file URLs on common hosts.
+var sourceRefMarkers = []string{"/-/blob/", "/blob/", "/src/", "/+/"}
+
+// ParseSourceURL extracts the file path (relative to the repository root) and line number from a
+// repository link such as https://github.com/o/r/blob/v1.0.0/dir/file.go#L42 or
+// https://cs.opensource.google/go/go/+/go1.22.0:src/net/http/client.go;l=42.
+func ParseSourceURL(href string) (string, int) {
+	rest, fragment, _ := strings.Cut(href, "#")
+	line := 0
+	switch {
+	case strings.HasPrefix(fragment, "L"):
+		line, _ = strconv.Atoi(strings.SplitN(strings.TrimPrefix(fragment, "L"), "-", 2)[0])
+	case fragment != "":
+		line, _ = strconv.Atoi(fragment)
+	}
+	if i := strings.Index(rest, ";l="); i >= 0 {
+		line, _ = strconv.Atoi(rest[i+3:])
+		rest = rest[:i]
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+
+	for _, marker := range sourceRefMarkers {
+		i := strings.Index(rest, marker)
+		if i < 0 {
+			continue
+		}
+		refAndPath := rest[i+len(marker):]
+		// Fully qualified refs (refs/tags/v1.0.0/...) contain slashes themselves.
+		for _, prefix := range []string{"refs/tags/", "refs/heads/"} {
+			refAndPath = strings.TrimPrefix(refAndPath, prefix)
+		}
+		// cs.opensource.google style: <ref>:<path>
+		if ref, path, ok := strings.Cut(refAndPath, ":"); ok && !strings.Contains(ref, "/") {
+			return path, line
+		}
+		if _, path, ok := strings.Cut(refAndPath, "/"); ok {
+			return path, line
+		}
+	}
+	return "", line
+}
+
+// attachExamples distributes examples across functions, types and methods; examples whose
+// symbol cannot be found stay in the package-level list.
+func attachExamples(pkg *models.Package, examples []models.Example) {
+	for _, ex := range examples {
+		symbol := exampleSymbol(ex.Name)
+		if symbol == "" || !attachExample(pkg, symbol, ex) {
+			pkg.Examples = append(pkg.Examples, ex)
+		}
+	}
+}
+
+// attachExample adds ex to the function, type or method named symbol and reports whether it was found.
+func attachExample(pkg *models.Package, symbol string, ex models.Example) bool {
+	for i := range pkg.Functions {
+		if pkg.Functions[i].Name == symbol {
+			pkg.Functions[i].Examples = append(pkg.Functions[i].Examples, ex)
+			return true
+		}
+	}
+	for i := range pkg.Types {
+		t := &pkg.Types[i]
+		if t.Name == symbol {
+			t.Examples = append(t.Examples, ex)
+			return true
+		}
+		for j := range t.Methods {
+			if t.Methods[j].Name == symbol {
+				t.Methods[j].Examples = append(t.Methods[j].Examples, ex)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exampleSymbol maps an example name to the symbol it documents ("F", "T" or "T.M"), or "" for
+// package examples. It accepts both Go test function names (ExampleT_M_suffix) and pkg.go.dev
+// example ids with the "example-" prefix removed (T.M-suffix, package-suffix).
+func exampleSymbol(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "Example") && !strings.Contains(name, ".") {
+		parts := strings.Split(strings.TrimPrefix(name, "Example"), "_")
+		if parts[0] == "" {
+			return ""
+		}
+		// A part starting with an upper-case letter is a method; a lower-case one starts the suffix.
+		if len(parts) > 1 && parts[1] != "" && parts[1][0] >= 'A' && parts[1][0] <= 'Z' {
+			return parts[0] + "." + parts[1]
+		}
+		return parts[0]
+	}
+
+	symbol := name
+	if i := strings.Index(symbol, "-"); i >= 0 {
+		symbol = symbol[:i]
+	}
+	if symbol == "package" {
+		return ""
+	}
+	return symbol
+}
+
+// playgroundURL returns the Go Playground link of an example block, if the page exposes one.
+func playgroundURL(s *goquery.Selection) string {
+	if href, ok := s.Find("a[href*='go.dev/play/p/'], a[href*='play.golang.org/p/']").First().Attr("href"); ok {
+		return strings.TrimSpace(href)
+	}
+	for _, attr := range []string{"data-playground-url", "data-share-url"} {
+		if v := strings.TrimSpace(s.AttrOr(attr, "")); v != "" {
+			return v
+		}
+	}
+	if id := strings.TrimSpace(s.AttrOr("data-share-id", "")); id != "" {
+		return "https://go.dev/play/p/" + id
+	}
+	return ""
+}
+
+// goVersion returns the Go version in the text of a Go version element, such as
+// "Go version: 1.21" or "go1.22.0", without the "go" prefix. It returns "" when there is none.
+func goVersion(text string) string {
+	for _, f := range strings.Fields(text) {
+		f = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(f, ":")), "go")
+		if f != "" && f[0] >= '0' && f[0] <= '9' && strings.Contains(f, ".") {
+			return f
+		}
+	}
+	return ""
 }
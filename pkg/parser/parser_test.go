@@ -0,0 +1,468 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/moseye/docinator/internal/models"
+)
+
+// element wraps an HTML document the way colly hands pages to the parser.
+func element(t *testing.T, html string) *colly.HTMLElement {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Parsing HTML failed: %v", err)
+	}
+	return colly.NewHTMLElementFromSelectionNode(&colly.Response{}, doc.Selection, doc.Nodes[0], 0)
+}
+
+func TestParseHTML(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "widget.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pkg, err := New().ParseHTML(f)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+	if pkg.Name != "gear" || pkg.ImportPath != "example.com/widget/gear" || pkg.Module != "example.com/widget" ||
+		pkg.Version != "v1.2.0" || !pkg.IsLatest || pkg.GoVersion != "1.22" || pkg.ImportedBy != 1204 {
+		t.Errorf("Unexpected metadata: %+v", pkg)
+	}
+	if pkg.Parser == "" || pkg.Parser != New().Fingerprint() {
+		t.Errorf("Expected the parser fingerprint recorded, got %q", pkg.Parser)
+	}
+	if !pkg.PublishedAt.Equal(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected Published %q parsed, got %v", pkg.Published, pkg.PublishedAt)
+	}
+	if len(pkg.Functions) != 1 || pkg.Functions[0].Signature != "func Turn(n int) error" || pkg.Functions[0].SourceLine != 12 {
+		t.Errorf("Expected function Turn, got %+v", pkg.Functions)
+	}
+	if len(pkg.Types) != 1 || pkg.Types[0].Description != "Gear is a single gear." {
+		t.Errorf("Expected type Gear, got %+v", pkg.Types)
+	}
+}
+
+func TestExampleSymbol(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Example", ""},
+		{"Example_basic", ""},
+		{"ExampleNew", "New"},
+		{"ExampleNew_withOptions", "New"},
+		{"ExampleCommand_Execute", "Command.Execute"},
+		{"ExampleCommand_Execute_subcommands", "Command.Execute"},
+		{"package", ""},
+		{"package-Basic", ""},
+		{"Command.Execute", "Command.Execute"},
+		{"Command.Execute-subcommands", "Command.Execute"},
+		{"NewClient", "NewClient"},
+	}
+	for _, tt := range tests {
+		if got := exampleSymbol(tt.name); got != tt.want {
+			t.Errorf("exampleSymbol(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttachExamples(t *testing.T) {
+	pkg := &models.Package{
+		Functions: []models.Function{{Name: "New"}},
+		Types: []models.Type{{
+			Name:    "Command",
+			Methods: []models.Function{{Name: "Command.Execute"}},
+		}},
+	}
+	attachExamples(pkg, []models.Example{
+		{Name: "ExampleCommand_Execute"},
+		{Name: "New-options"},
+		{Name: "Command"},
+		{Name: "package"},
+		{Name: "Missing"},
+	})
+
+	if len(pkg.Types[0].Methods[0].Examples) != 1 {
+		t.Error("ExampleCommand_Execute should attach to Command.Execute")
+	}
+	if len(pkg.Functions[0].Examples) != 1 {
+		t.Error("New-options should attach to New")
+	}
+	if len(pkg.Types[0].Examples) != 1 {
+		t.Error("Command should attach to the Command type")
+	}
+	if len(pkg.Examples) != 2 {
+		t.Errorf("Package and unmatched examples should stay package-level, got %d", len(pkg.Examples))
+	}
+}
+
+func TestParseSourceURL(t *testing.T) {
+	tests := []struct {
+		href string
+		file string
+		line int
+	}{
+		{"https://github.com/spf13/cobra/blob/v1.9.1/command.go#L1085", "command.go", 1085},
+		{"https://github.com/o/r/blob/main/internal/x/y.go#L10-L20", "internal/x/y.go", 10},
+		{"https://gitlab.com/o/r/-/blob/v2.0.0/a/b.go#L7", "a/b.go", 7},
+		{"https://cs.opensource.google/go/go/+/go1.22.0:src/net/http/client.go;l=123", "src/net/http/client.go", 123},
+		{"https://go.googlesource.com/net/+/refs/tags/v0.1.0/html/parse.go#42", "html/parse.go", 42},
+		{"https://example.com/unknown", "", 0},
+	}
+	for _, tt := range tests {
+		file, line := ParseSourceURL(tt.href)
+		if file != tt.file || line != tt.line {
+			t.Errorf("ParseSourceURL(%q) = (%q, %d), want (%q, %d)", tt.href, file, line, tt.file, tt.line)
+		}
+	}
+}
+
+func TestParseSelectors(t *testing.T) {
+	sel, err := ParseSelectors([]byte("version: 1\nname: h1.Title\n"))
+	if err != nil {
+		t.Fatalf("ParseSelectors failed: %v", err)
+	}
+	if len(sel.Name) != 1 || sel.Name[0] != "h1.Title" {
+		t.Errorf("Expected the overridden name selector, got %q", sel.Name)
+	}
+	if sel.ImportPath[0] != DefaultSelectors().ImportPath[0] {
+		t.Errorf("Expected keys left out to keep their defaults, got %q", sel.ImportPath)
+	}
+
+	for _, bad := range []string{"nmae: h1\n", "name: 'h1[['\n", "name: ''\n", "name: []\n", "version: 99\n"} {
+		if _, err := ParseSelectors([]byte(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+
+	html := `<html><body><h1 class="Title">cobra</h1><h1 class="UnitHeader-titleHeading">old</h1></body></html>`
+	pkg, err := NewWithSelectors(sel).ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	if pkg.Name != "cobra" {
+		t.Errorf("Expected the name found by the override, got %q", pkg.Name)
+	}
+}
+
+func TestSelectorChains(t *testing.T) {
+	sel, err := ParseSelectors([]byte("name: [h1.Current, h1.Old]\npackage_version: [\"a[aria-label^='Version: ']\", .OldVersion]\n"))
+	if err != nil {
+		t.Fatalf("ParseSelectors failed: %v", err)
+	}
+	html := `<html><body><h1 class="Old">cobra</h1><span class="OldVersion">Version: v1.9.1</span></body></html>`
+	pkg, extraction, err := NewWithSelectors(sel).ParsePackagePageWithExtraction(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	if pkg.Name != "cobra" || pkg.Version != "v1.9.1" {
+		t.Errorf("Expected the fallbacks to find name and version, got %q %q", pkg.Name, pkg.Version)
+	}
+	if got := extraction["name"]; got.Selector != "h1.Old" || got.Index != 1 {
+		t.Errorf("Expected name to be found by the second strategy, got %+v", got)
+	}
+	if _, ok := extraction["readme"]; ok {
+		t.Error("Expected no strategy for keys that matched nothing")
+	}
+	if fallbacks := extraction.Fallbacks(); len(fallbacks) != 2 {
+		t.Errorf("Expected 2 fallbacks, got %v", fallbacks)
+	}
+}
+
+func TestParseNotices(t *testing.T) {
+	html := `<html><body><h1 class="UnitHeader-titleHeading">unix</h1>
+<div class="UnitBuildContext-titleContext">This package is only available for
+  linux/amd64 and darwin/arm64.</div></body></html>`
+	pkg, err := New().ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	if pkg.PlatformNotice != "This package is only available for linux/amd64 and darwin/arm64." {
+		t.Errorf("Expected the banner text, got %q", pkg.PlatformNotice)
+	}
+	if want := []string{"darwin/arm64", "linux/amd64"}; !slices.Equal(pkg.Platforms, want) {
+		t.Errorf("Expected platforms %v, got %v", want, pkg.Platforms)
+	}
+
+	html = `<html><body><div class="Documentation-buildContext">Rendered for
+<select><option value="linux/amd64">linux/amd64</option><option value="js/wasm">js/wasm</option></select></div></body></html>`
+	if pkg, _ = New().ParsePackagePage(element(t, html)); !slices.Equal(pkg.Platforms, []string{"js/wasm", "linux/amd64"}) {
+		t.Errorf("Expected the platforms of the dropdown, got %v", pkg.Platforms)
+	}
+	if pkg.PlatformNotice != "Rendered for" {
+		t.Errorf("Expected the dropdown left out of the notice, got %q", pkg.PlatformNotice)
+	}
+
+	html = `<html><body>
+<div class="UnitHeader-banner UnitHeader-banner--deprecated">Deprecated: use github.com/new/mod instead.</div>
+<div class="UnitHeader-banner UnitHeader-banner--retracted">This version has been retracted.</div></body></html>`
+	pkg, _ = New().ParsePackagePage(element(t, html))
+	if !pkg.ModuleDeprecated || pkg.DeprecationNotice != "Deprecated: use github.com/new/mod instead." {
+		t.Errorf("Expected the module deprecation, got %v %q", pkg.ModuleDeprecated, pkg.DeprecationNotice)
+	}
+	if !pkg.Retracted || pkg.RetractionNotice != "This version has been retracted." {
+		t.Errorf("Expected the retraction, got %v %q", pkg.Retracted, pkg.RetractionNotice)
+	}
+	if !pkg.Redistributable || pkg.LicenseRestricted() {
+		t.Error("Expected packages without a license notice to be redistributable")
+	}
+
+	html = `<html><body><div data-test-id="UnitDoc-licenseRestricted">Documentation not displayed due to license restrictions.</div></body></html>`
+	pkg, _ = New().ParsePackagePage(element(t, html))
+	if pkg.Details != nil {
+		t.Errorf("Expected no details without a Details panel, got %+v", pkg.Details)
+	}
+	if pkg.Redistributable || !pkg.LicenseRestricted() || pkg.RedistributionNotice != "Documentation not displayed due to license restrictions." {
+		t.Errorf("Expected withheld documentation, got %v %q", pkg.Redistributable, pkg.RedistributionNotice)
+	}
+}
+
+func TestParseDetails(t *testing.T) {
+	html := `<html><body><ul class="UnitMeta-details">
+<li><details><summary><img src="/static/shared/icon/check_circle_gm_grey_24dp.svg" alt="checked"> Valid <a href="#">go.mod</a> file</summary></details></li>
+<li><details><summary><img src="/static/shared/icon/check_circle_gm_grey_24dp.svg" alt="checked"> Redistributable license</summary></details></li>
+<li><details><summary><img src="/static/shared/icon/check_circle_gm_grey_24dp.svg" alt="checked"> Tagged version</summary></details></li>
+<li><details><summary><img src="/static/shared/icon/cancel_gm_grey_24dp.svg" alt="unchecked"> Stable version</summary></details></li>
+</ul></body></html>`
+	pkg, err := New().ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	want := models.Details{ValidGoMod: true, RedistributableLicense: true, TaggedVersion: true}
+	if pkg.Details == nil || *pkg.Details != want {
+		t.Errorf("Expected %+v, got %+v", want, pkg.Details)
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	html := `<html><body><span data-test-id="UnitHeader-goVersion">Go version: 1.21</span></body></html>`
+	pkg, err := New().ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	if pkg.GoVersion != "1.21" {
+		t.Errorf("Expected Go version 1.21, got %q", pkg.GoVersion)
+	}
+	for text, want := range map[string]string{"go1.22.0": "1.22.0", "Requires Go 1.23rc1": "1.23rc1", "Go version": ""} {
+		if got := goVersion(text); got != want {
+			t.Errorf("goVersion(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestParseOverview(t *testing.T) {
+	html := `<html><body><section class="Documentation-overview">
+<h3 tabindex="-1" id="pkg-overview" class="Documentation-overviewHeader">Overview <a href="#pkg-overview">¶</a></h3>
+<p>Package gear turns <a href="/example.com/widget">widgets</a>
+on demand.</p>
+<p>Turn a gear with:</p>
+<pre>gear.Turn(3)
+</pre>
+<h4 id="hdr-Limits">Limits <a class="Documentation-idLink" href="#hdr-Limits">¶</a></h4>
+<ul><li>at most <code>MaxTurns</code></li><li>never backwards</li></ul>
+<details class="Documentation-exampleDetails" id="example-package"><summary>Example</summary></details>
+</section></body></html>`
+	pkg, err := New().ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	want := "Package gear turns [widgets](https://pkg.go.dev/example.com/widget) on demand.\n\nTurn a gear with:\n\n```\ngear.Turn(3)\n```\n\n### Limits\n\n- at most `MaxTurns`\n- never backwards"
+	if pkg.Overview != want {
+		t.Errorf("Expected overview\n%s\ngot\n%s", want, pkg.Overview)
+	}
+	if !strings.HasPrefix(pkg.Description, "Package gear turns widgets") {
+		t.Errorf("Expected the first paragraph as description, got %q", pkg.Description)
+	}
+}
+
+func TestStructFields(t *testing.T) {
+	def := "type Command struct {\n\t// Use is the one-line usage message.\n\tUse string `json:\"use\"`\n\n\tShort, Long string // help texts\n\tio.Writer\n\thidden bool\n\t// contains filtered or unexported fields\n}"
+	want := []models.Field{
+		{Name: "Use", Type: "string", Tag: `json:"use"`, Doc: "Use is the one-line usage message."},
+		{Name: "Short", Type: "string", Doc: "help texts"},
+		{Name: "Long", Type: "string", Doc: "help texts"},
+		{Name: "Writer", Type: "io.Writer"},
+	}
+	if got := structFields(def); !slices.Equal(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	for _, def := range []string{"type Handler interface {\n\tServe()\n}", "type ID = string", "type broken struct {"} {
+		if got := structFields(def); got != nil {
+			t.Errorf("Expected no fields for %q, got %+v", def, got)
+		}
+	}
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	def := "type ReadCloser interface {\n\tio.Reader\n\t// Close releases the stream.\n\tClose() error\n\tPeek(n int) ([]byte, error) // without advancing\n}"
+	want := []models.Function{
+		{Name: "io.Reader", Signature: "io.Reader"},
+		{Name: "Close", Signature: "Close() error", Description: "Close releases the stream."},
+		{Name: "Peek", Signature: "Peek(n int) ([]byte, error)", Description: "without advancing"},
+	}
+	got := interfaceMethods(def)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Signature != want[i].Signature || got[i].Description != want[i].Description {
+			t.Errorf("Method %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if got := interfaceMethods("type Command struct {\n\tUse string\n}"); got != nil {
+		t.Errorf("Expected no methods for a struct, got %+v", got)
+	}
+}
+
+func TestParseLinks(t *testing.T) {
+	html := `<html><body><ul class="UnitMeta-links">
+<li><a href="https://cobra.dev">  Homepage </a></li>
+<li><a href="/github.com/spf13/cobra?tab=licenses">License</a></li>
+<li><a href="">empty</a></li>
+</ul></body></html>`
+	pkg, err := New().ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	want := []models.Link{{Label: "Homepage", URL: "https://cobra.dev"}, {Label: "License", URL: "https://pkg.go.dev/github.com/spf13/cobra?tab=licenses"}}
+	if !slices.Equal(pkg.Links, want) {
+		t.Errorf("Expected %v, got %v", want, pkg.Links)
+	}
+}
+
+func TestParseImports(t *testing.T) {
+	html := `<html><body>
+<h2 class="Imports-heading">Standard library imports</h2>
+<ul class="Imports-list"><li class="Imports-listItem"><a href="/fmt">fmt</a></li><li class="Imports-listItem"><a href="/os">os</a></li></ul>
+<h2 class="Imports-heading">Imports from other modules</h2>
+<ul class="Imports-list"><li class="Imports-listItem"><a href="/github.com/spf13/pflag">github.com/spf13/pflag</a></li>
+<li class="Imports-listItem"><a href="/fmt">fmt</a></li>
+<li class="Imports-listItem"><a href="https://example.com">elsewhere</a></li></ul>
+</body></html>`
+	got := New().ParseImports(element(t, html))
+	if want := []string{"fmt", "os", "github.com/spf13/pflag"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestParseIdentifiers(t *testing.T) {
+	html := `<html><body>
+<ul class="JumpDialog-list">
+<li><a href="#pkg-overview">Overview</a></li>
+<li><a href="#EnableCommandSorting">EnableCommandSorting</a></li>
+<li><a href="#Eq">Eq</a></li>
+<li><a href="#Command">Command</a></li>
+<li><a href="#Command.Execute">Command.Execute</a></li>
+<li><a href="#example-Command">Example</a></li>
+<li><a href="#Eq">Eq</a></li>
+</ul>
+<section class="Documentation-variables"><div class="Documentation-declaration"><pre>var (
+	<span id="EnableCommandSorting" data-kind="variable">EnableCommandSorting</span> = true
+)</pre></div></section>
+<section class="Documentation-types"><div class="Documentation-type">
+<h4 id="Command">type Command</h4><div class="Documentation-declaration"><pre>type Command struct{}</pre></div>
+</div></section></body></html>`
+	pkg, err := New().ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	want := []string{"EnableCommandSorting", "Eq", "Command", "Command.Execute"}
+	if !slices.Equal(pkg.Identifiers, want) {
+		t.Errorf("Expected %v, got %v", want, pkg.Identifiers)
+	}
+	if missing := MissingIdentifiers(pkg); !slices.Equal(missing, []string{"Eq", "Command.Execute"}) {
+		t.Errorf("Expected Eq and Command.Execute to be reported missing, got %v", missing)
+	}
+}
+
+func TestPackageWarnings(t *testing.T) {
+	sel, err := ParseSelectors([]byte("package_version: [\"a[aria-label^='Version: ']\", .OldVersion]\n"))
+	if err != nil {
+		t.Fatalf("ParseSelectors failed: %v", err)
+	}
+	html := `<html><body><h1 class="UnitHeader-titleHeading">gear</h1><span class="OldVersion">Version: v1.2.0</span>
+<div class="UnitReadme-content"><div class="Overview-readmeContent"><p>Sizes:</p><table><tr><td>small</td><td>1</td></tr></table></div></div></body></html>`
+	pkg, err := NewWithSelectors(sel).ParsePackagePage(element(t, html))
+	if err != nil {
+		t.Fatalf("ParsePackagePage failed: %v", err)
+	}
+	codes := map[string]string{}
+	for _, w := range pkg.Warnings {
+		codes[w.Code] = w.Field
+	}
+	want := map[string]string{
+		models.WarningNoLicense:          "license",
+		models.WarningSelectorFallback:   "package_version",
+		models.WarningReadmeTableDropped: "readme",
+	}
+	for code, field := range want {
+		if got, ok := codes[code]; !ok || got != field {
+			t.Errorf("Expected a %s warning on %s, got %+v", code, field, pkg.Warnings)
+		}
+	}
+
+	if n := droppedTables("<table><tr><td>a</td></tr></table>", "| a |\n| --- |\n"); n != 0 {
+		t.Errorf("Expected a converted table not to count as dropped, got %d", n)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	sel, err := ParseSelectors([]byte("name: h1.Custom\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def := New().Fingerprint()
+	if !strings.HasPrefix(def, strconv.Itoa(Version)+"/") || def != NewWithSelectors(nil).Fingerprint() {
+		t.Errorf("Unexpected default fingerprint %q", def)
+	}
+	if NewWithSelectors(sel).Fingerprint() == def {
+		t.Error("Expected another selector profile to change the fingerprint")
+	}
+}
+
+func TestProfile(t *testing.T) {
+	if p, err := ParseProfile("full"); err != nil || p != ProfileFull {
+		t.Errorf("Expected full to parse as ProfileFull, got %q, %v", p, err)
+	}
+	if _, err := ParseProfile("symbols"); err == nil {
+		t.Error("Expected an unknown profile to be rejected")
+	}
+	if !ProfileFull.Covers(ProfileReadme) || ProfileMetadata.Covers(ProfileReadme) || !ProfileReadme.Covers(ProfileReadme) {
+		t.Error("Expected the profiles ordered metadata < readme < full")
+	}
+
+	f, err := os.Open(filepath.Join("testdata", "widget.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pkg, err := New().WithProfile(ProfileMetadata).ParseHTML(f)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+	if pkg.Profile != "metadata" || pkg.ImportPath != "example.com/widget/gear" || pkg.Version != "v1.2.0" {
+		t.Errorf("Expected the metadata parsed, got %+v", pkg)
+	}
+	if len(pkg.Functions) != 0 || len(pkg.Types) != 0 || pkg.Readme != "" {
+		t.Errorf("Expected no documentation parsed, got %d functions, %d types", len(pkg.Functions), len(pkg.Types))
+	}
+
+	full := &models.Package{ImportPath: "example.com/gear", Overview: "Gears.", Functions: []models.Function{{Name: "Turn"}}}
+	if ProfileFull.Trim(full) != full {
+		t.Error("Expected the full profile to keep the package as is")
+	}
+	trimmed := ProfileReadme.Trim(full)
+	if trimmed.Profile != "readme" || trimmed.Overview != "Gears." || trimmed.Functions != nil || full.Functions == nil {
+		t.Errorf("Expected a copy without symbols, got %+v", trimmed)
+	}
+}
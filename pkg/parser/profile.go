@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Profile limits which sections of a package page are parsed, for crawls that only need part of
+// it. Packages parsed with a limited profile record it in models.Package.Profile.
+type Profile string
+
+const (
+	// ProfileFull parses the whole page.
+	ProfileFull Profile = ""
+	// ProfileReadme parses the metadata, the package overview and the README, skipping the
+	// documentation of constants, variables, functions and types, the examples and the files.
+	ProfileReadme Profile = "readme"
+	// ProfileMetadata parses the header metadata, notices and synopsis only.
+	ProfileMetadata Profile = "metadata"
+)
+
+// ParseProfile returns the profile named name: "full" (or ""), "readme" or "metadata".
+func ParseProfile(name string) (Profile, error) {
+	switch name {
+	case "", "full":
+		return ProfileFull, nil
+	case string(ProfileReadme), string(ProfileMetadata):
+		return Profile(name), nil
+	}
+	return ProfileFull, fmt.Errorf("unknown profile %q; use full, readme or metadata", name)
+}
+
+// String returns the name of p as ParseProfile accepts it.
+func (p Profile) String() string {
+	if p == ProfileFull {
+		return "full"
+	}
+	return string(p)
+}
+
+// Covers reports whether a package parsed with p holds every section q parses.
+func (p Profile) Covers(q Profile) bool {
+	return p.rank() >= q.rank()
+}
+
+func (p Profile) rank() int {
+	switch p {
+	case ProfileMetadata:
+		return 0
+	case ProfileReadme:
+		return 1
+	}
+	return 2
+}
+
+func (p Profile) readme() bool  { return p.Covers(ProfileReadme) }
+func (p Profile) symbols() bool { return p == ProfileFull }
+
+// Trim returns pkg limited to the sections p parses: pkg itself when p covers its profile, else a
+// copy without the others.
+func (p Profile) Trim(pkg *models.Package) *models.Package {
+	if p.Covers(Profile(pkg.Profile)) {
+		return pkg
+	}
+	out := *pkg
+	out.Profile = string(p)
+	out.Constants, out.Variables, out.Functions, out.Types, out.Examples = nil, nil, nil, nil, nil
+	out.Files, out.Identifiers = nil, nil
+	if !p.readme() {
+		out.Overview, out.Readme, out.ProcessedReadme, out.ReadmeLang = "", "", "", ""
+		out.ReadmeAlternates, out.Guides = nil, nil
+	}
+	return &out
+}
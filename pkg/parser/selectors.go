@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// SelectorsVersion is the selector profile format this parser reads.
+const SelectorsVersion = 1
+
+//go:embed selectors.yaml
+var defaultSelectorsYAML []byte
+
+// DefaultSelectorsYAML returns the embedded default selector profile, a starting point for an
+// override file.
+func DefaultSelectorsYAML() []byte {
+	return bytes.Clone(defaultSelectorsYAML)
+}
+
+// Chain is an ordered list of selector strategies for one part of a page: the first one that
+// matches anything wins. In YAML it is a single selector or a list of them.
+type Chain []string
+
+// UnmarshalYAML accepts a single selector as a chain of one.
+func (c *Chain) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*c = Chain{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// Selectors is a selector profile: the selector chain each part of a pkg.go.dev page is found by.
+// Chains of repeated elements (constants, functions, methods, ...) match each block, and the ones
+// below them are looked up inside that block.
+type Selectors struct {
+	Version int `yaml:"version"` // profile format version; must not exceed SelectorsVersion
+
+	Name           Chain `yaml:"name"`
+	ImportPath     Chain `yaml:"import_path"`
+	Module         Chain `yaml:"module"`
+	PackageVersion Chain `yaml:"package_version"` // element whose aria-label or text reads "Version: v1.2.3"
+	Latest         Chain `yaml:"latest"`
+	Published      Chain `yaml:"published"`
+	GoVersion      Chain `yaml:"go_version"` // element reading "Go version: 1.21" or "go1.21"
+	License        Chain `yaml:"license"`
+	Imports        Chain `yaml:"imports"`
+	ImportedBy     Chain `yaml:"imported_by"`
+	Repository     Chain `yaml:"repository"`
+
+	Overview        Chain `yaml:"overview"`         // first paragraph of the package doc, the Description
+	OverviewSection Chain `yaml:"overview_section"` // whole package doc: paragraphs, code blocks, headings
+	Readme          Chain `yaml:"readme"`
+
+	Constants    Chain `yaml:"constants"`
+	Variables    Chain `yaml:"variables"`
+	Functions    Chain `yaml:"functions"`
+	Types        Chain `yaml:"types"`
+	Methods      Chain `yaml:"methods"`
+	Declaration  Chain `yaml:"declaration"`
+	SinceVersion Chain `yaml:"since_version"`
+	Deprecated   Chain `yaml:"deprecated"`
+	SourceLink   Chain `yaml:"source_link"`
+
+	Files         Chain `yaml:"files"`
+	Examples      Chain `yaml:"examples"`
+	ExampleHeader Chain `yaml:"example_header"`
+	ExampleCode   Chain `yaml:"example_code"`
+	ExampleOutput Chain `yaml:"example_output"`
+
+	ModuleDeprecated  Chain `yaml:"module_deprecated"`  // banner of a module deprecated in its go.mod
+	Retracted         Chain `yaml:"retracted"`          // warning shown on retracted versions
+	LicenseRestricted Chain `yaml:"license_restricted"` // notice shown instead of docs under a non-redistributable license
+	PlatformNotice    Chain `yaml:"platform_notice"`    // build context banner listing the supported GOOS/GOARCH pairs
+
+	Details Chain `yaml:"details"` // items of the Details checklist, each with a checked or unchecked icon
+	Links   Chain `yaml:"links"`   // anchors of the Links panel
+
+	Identifiers Chain `yaml:"identifiers"` // Jump to entries, one per exported identifier
+
+	Importers  Chain `yaml:"importers"`
+	ImportList Chain `yaml:"import_list"` // package links on the Imports tab
+}
+
+var defaultSelectors = mustParseSelectors(defaultSelectorsYAML)
+
+func mustParseSelectors(data []byte) *Selectors {
+	s := &Selectors{}
+	if err := s.merge(data); err != nil {
+		panic("parser: embedded selectors.yaml: " + err.Error())
+	}
+	return s
+}
+
+// DefaultSelectors returns a copy of the embedded selector profile.
+func DefaultSelectors() *Selectors {
+	s := *defaultSelectors
+	return &s
+}
+
+// ParseSelectors reads a YAML selector profile. Keys it leaves out keep their defaults; unknown
+// keys and selectors that do not compile are errors.
+func ParseSelectors(data []byte) (*Selectors, error) {
+	s := DefaultSelectors()
+	if err := s.merge(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadSelectors reads the YAML selector profile at path (see ParseSelectors).
+func LoadSelectors(path string) (*Selectors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseSelectors(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// merge overrides the chains set in data and validates the result.
+func (s *Selectors) merge(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil {
+		return err
+	}
+	if s.Version > SelectorsVersion {
+		return fmt.Errorf("selector profile version %d is newer than the supported version %d", s.Version, SelectorsVersion)
+	}
+	for key, chain := range s.chains() {
+		if len(chain) == 0 {
+			return fmt.Errorf("selector %s is empty", key)
+		}
+		for _, sel := range chain {
+			if sel == "" {
+				return fmt.Errorf("selector %s has an empty entry", key)
+			}
+			if _, err := cascadia.ParseGroup(sel); err != nil {
+				return fmt.Errorf("selector %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Chain returns the chain of the YAML key, or nil for unknown keys.
+func (s *Selectors) Chain(key string) Chain {
+	return s.chains()[key]
+}
+
+// chains maps each YAML key to its chain.
+func (s *Selectors) chains() map[string]Chain {
+	return map[string]Chain{
+		"name": s.Name, "import_path": s.ImportPath, "module": s.Module, "package_version": s.PackageVersion,
+		"latest": s.Latest, "published": s.Published, "go_version": s.GoVersion, "license": s.License,
+		"imports": s.Imports, "imported_by": s.ImportedBy, "repository": s.Repository, "overview": s.Overview,
+		"overview_section": s.OverviewSection, "readme": s.Readme, "constants": s.Constants, "variables": s.Variables, "functions": s.Functions, "types": s.Types,
+		"methods": s.Methods, "declaration": s.Declaration, "since_version": s.SinceVersion,
+		"deprecated": s.Deprecated, "source_link": s.SourceLink, "files": s.Files, "examples": s.Examples,
+		"example_header": s.ExampleHeader, "example_code": s.ExampleCode, "example_output": s.ExampleOutput,
+		"module_deprecated": s.ModuleDeprecated, "retracted": s.Retracted, "license_restricted": s.LicenseRestricted,
+		"platform_notice": s.PlatformNotice, "details": s.Details, "links": s.Links,
+		"identifiers": s.Identifiers, "importers": s.Importers, "import_list": s.ImportList,
+	}
+}
+
+// Strategy is the selector of a chain that found an element, with its position in the chain;
+// Index 0 is the primary selector, higher indexes are fallbacks.
+type Strategy struct {
+	Selector string
+	Index    int
+}
+
+// Extraction records the winning strategy per selector profile key for one page. Keys whose
+// chain matched nothing are absent; for keys looked up in every block (declaration,
+// since_version, ...) the first block that matched decides.
+type Extraction map[string]Strategy
+
+// Fallbacks returns the keys found by a fallback rather than their primary selector.
+func (x Extraction) Fallbacks() map[string]Strategy {
+	fallbacks := map[string]Strategy{}
+	for key, st := range x {
+		if st.Index > 0 {
+			fallbacks[key] = st
+		}
+	}
+	return fallbacks
+}
+
+// matcher looks up selector chains and records the strategies that won.
+type matcher struct {
+	won Extraction
+}
+
+// find returns the elements below s matched by the first selector of chain that matches any,
+// or an empty selection.
+func (m *matcher) find(key string, s *goquery.Selection, chain Chain) *goquery.Selection {
+	var found *goquery.Selection
+	for i, sel := range chain {
+		if found = s.Find(sel); found.Length() > 0 {
+			if _, ok := m.won[key]; !ok {
+				m.won[key] = Strategy{Selector: sel, Index: i}
+			}
+			return found
+		}
+	}
+	if found == nil {
+		found = s.Slice(0, 0)
+	}
+	return found
+}
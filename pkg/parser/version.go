@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+)
+
+// Version identifies the extraction logic. Bump it with every change that extracts something
+// different from the same page, so packages cached by an older parser are parsed again.
+const Version = 1
+
+// Fingerprint identifies the parser and its selector profile, e.g. "1/3f9a0c2b7d41": Version and
+// a hash of every selector chain. Packages record the fingerprint that extracted them; a cached
+// package with another one was parsed by another docinator or profile.
+func (p *Parser) Fingerprint() string {
+	return p.fingerprint
+}
+
+func fingerprint(sel *Selectors) string {
+	data, err := json.Marshal(sel)
+	if err != nil {
+		panic("parser: selector profile does not marshal: " + err.Error())
+	}
+	sum := sha256.Sum256(data)
+	return strconv.Itoa(Version) + "/" + hex.EncodeToString(sum[:6])
+}
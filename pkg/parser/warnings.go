@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/moseye/docinator/internal/models"
+)
+
+// markdownTableRule matches the delimiter row under the header of a markdown table.
+var markdownTableRule = regexp.MustCompile(`(?m)^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+
+// packageWarnings lists the data quality issues of pkg as parsed with the strategies in won.
+// readme is the README element of the page, nil without one.
+func packageWarnings(pkg *models.Package, won Extraction, readme *goquery.Selection) []models.Warning {
+	var warnings []models.Warning
+	if pkg.License == "" {
+		warnings = append(warnings, models.Warning{Code: models.WarningNoLicense, Field: "license", Message: "no license found"})
+	}
+
+	fallbacks := won.Fallbacks()
+	keys := make([]string, 0, len(fallbacks))
+	for key := range fallbacks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		warnings = append(warnings, models.Warning{
+			Code:    models.WarningSelectorFallback,
+			Field:   key,
+			Message: fmt.Sprintf("selector fallback %q used for %s", fallbacks[key].Selector, key),
+		})
+	}
+
+	if dropped := droppedTablesIn(readme, pkg.ProcessedReadme); dropped > 0 {
+		warnings = append(warnings, models.Warning{
+			Code:    models.WarningReadmeTableDropped,
+			Field:   "readme",
+			Message: fmt.Sprintf("README conversion dropped %d of its tables", dropped),
+		})
+	}
+
+	if missing := MissingIdentifiers(pkg); len(missing) > 0 {
+		shown := missing
+		if len(shown) > 5 {
+			shown = shown[:5]
+		}
+		warnings = append(warnings, models.Warning{
+			Code:    models.WarningMissingDeclaration,
+			Field:   "identifiers",
+			Message: fmt.Sprintf("no declaration parsed for %d of %d identifiers (%s)", len(missing), len(pkg.Identifiers), strings.Join(shown, ", ")),
+		})
+	}
+	return warnings
+}
+
+// droppedTables returns how many of the outermost tables of the README HTML have no markdown
+// table in its conversion.
+func droppedTables(html, markdown string) int {
+	if !strings.Contains(html, "<table") {
+		return 0
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return 0
+	}
+	return droppedTablesIn(doc.Selection, markdown)
+}
+
+// droppedTablesIn is droppedTables for README HTML already parsed; nil has no tables.
+func droppedTablesIn(readme *goquery.Selection, markdown string) int {
+	if readme == nil {
+		return 0
+	}
+	tables := readme.Find("table").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.ParentsFiltered("table").Length() == 0
+	}).Length()
+	return max(tables-len(markdownTableRule.FindAllString(markdown, -1)), 0)
+}
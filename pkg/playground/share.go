@@ -0,0 +1,49 @@
+package playground
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ShareURL is the Go Playground endpoint that stores a snippet and returns its share ID.
+const ShareURL = "https://play.golang.org/share"
+
+// LinkPrefix is prepended to a share ID to form a permalink.
+const LinkPrefix = "https://go.dev/play/p/"
+
+// Share uploads code to the Go Playground and returns its permalink.
+func Share(ctx context.Context, client *http.Client, code string) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return "", fmt.Errorf("code cannot be empty")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ShareURL, strings.NewReader(code))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("playground share failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read playground response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("playground returned %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	id := strings.TrimSpace(string(body))
+	if id == "" {
+		return "", fmt.Errorf("playground returned an empty share ID")
+	}
+	return LinkPrefix + id, nil
+}
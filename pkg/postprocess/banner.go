@@ -0,0 +1,59 @@
+package postprocess
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Banner returns a processor that puts header above and footer below every page, e.g. a
+// classification banner or a "generated, do not edit" notice. Both are text/template templates
+// executed with the package, so {{.ImportPath}}, {{.Version}} or {{.License}} stand for its
+// fields. An empty template leaves that end of the page alone.
+func Banner(header, footer string) (Processor, error) {
+	head, err := template.New("header").Option("missingkey=error").Parse(header)
+	if err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	foot, err := template.New("footer").Option("missingkey=error").Parse(footer)
+	if err != nil {
+		return nil, fmt.Errorf("footer: %w", err)
+	}
+	return Func(func(pkg *models.Package, md string) (string, error) {
+		if strings.TrimSpace(header) != "" {
+			var b strings.Builder
+			if err := head.Execute(&b, pkg); err != nil {
+				return "", fmt.Errorf("%s: %w", pkg.ImportPath, err)
+			}
+			md = strings.TrimRight(b.String(), "\n") + "\n\n" + md
+		}
+		if strings.TrimSpace(footer) != "" {
+			var b strings.Builder
+			if err := foot.Execute(&b, pkg); err != nil {
+				return "", fmt.Errorf("%s: %w", pkg.ImportPath, err)
+			}
+			md = strings.TrimRight(md, "\n") + "\n\n" + strings.TrimRight(b.String(), "\n") + "\n"
+		}
+		return md, nil
+	}), nil
+}
+
+// LoadBanner reads the header and footer templates of Banner from files; an empty path leaves
+// that end out.
+func LoadBanner(headerPath, footerPath string) (Processor, error) {
+	var texts [2]string
+	for i, path := range []string{headerPath, footerPath} {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		texts[i] = string(data)
+	}
+	return Banner(texts[0], texts[1])
+}
@@ -0,0 +1,145 @@
+package postprocess
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func init() {
+	Register("footer", newFooter)
+	Register("rewrite-urls", newRewriteURLs)
+	Register("word-filter", newWordFilter)
+	Register("link-check", newLinkCheck)
+}
+
+// newFooter appends text below a rule at the end of every page. {import_path}, {module} and
+// {version} in text stand for those of the package.
+func newFooter(decode func(v any) error) (Processor, error) {
+	var cfg struct {
+		Text string `yaml:"text"`
+	}
+	if err := decode(&cfg); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(cfg.Text) == "" {
+		return nil, errors.New("footer needs a text")
+	}
+	return Func(func(pkg *models.Package, md string) (string, error) {
+		text := strings.NewReplacer("{import_path}", pkg.ImportPath, "{module}", pkg.Module, "{version}", pkg.Version).Replace(cfg.Text)
+		return strings.TrimRight(md, "\n") + "\n\n---\n\n" + strings.TrimSpace(text) + "\n", nil
+	}), nil
+}
+
+// newRewriteURLs replaces the URL prefix from with to everywhere in the page, e.g. to point
+// repository links at an internal mirror.
+func newRewriteURLs(decode func(v any) error) (Processor, error) {
+	var cfg struct {
+		From string `yaml:"from"`
+		To   string `yaml:"to"`
+	}
+	if err := decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.From == "" {
+		return nil, errors.New("rewrite-urls needs a from prefix")
+	}
+	return Func(func(pkg *models.Package, md string) (string, error) {
+		return strings.ReplaceAll(md, cfg.From, cfg.To), nil
+	}), nil
+}
+
+// newWordFilter replaces whole words, matched case-insensitively, with replacement (default
+// "***"), or with fail set, fails pages containing any of them.
+func newWordFilter(decode func(v any) error) (Processor, error) {
+	var cfg struct {
+		Words       []string `yaml:"words"`
+		Replacement string   `yaml:"replacement"`
+		Fail        bool     `yaml:"fail"`
+	}
+	if err := decode(&cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Words) == 0 {
+		return nil, errors.New("word-filter needs words")
+	}
+	if cfg.Replacement == "" {
+		cfg.Replacement = "***"
+	}
+	quoted := make([]string, len(cfg.Words))
+	for i, w := range cfg.Words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	re := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	return Func(func(pkg *models.Package, md string) (string, error) {
+		if !cfg.Fail {
+			return re.ReplaceAllLiteralString(md, cfg.Replacement), nil
+		}
+		if found := re.FindAllString(md, -1); len(found) > 0 {
+			return "", fmt.Errorf("%s contains filtered words: %s", pkg.ImportPath, strings.Join(unique(found), ", "))
+		}
+		return md, nil
+	}), nil
+}
+
+// linkTarget matches the target of a markdown link or image.
+var linkTarget = regexp.MustCompile(`\]\(<?([^)\s>]+)`)
+
+// newLinkCheck fails pages with links matching one of the deny regexps or, with https_only,
+// plain http links. Links are not fetched.
+func newLinkCheck(decode func(v any) error) (Processor, error) {
+	var cfg struct {
+		Deny      []string `yaml:"deny"`
+		HTTPSOnly bool     `yaml:"https_only"`
+	}
+	if err := decode(&cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Deny) == 0 && !cfg.HTTPSOnly {
+		return nil, errors.New("link-check needs deny patterns or https_only")
+	}
+	var deny []*regexp.Regexp
+	for _, expr := range cfg.Deny {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("deny %q: %w", expr, err)
+		}
+		deny = append(deny, re)
+	}
+	return Func(func(pkg *models.Package, md string) (string, error) {
+		var bad []string
+		for _, m := range linkTarget.FindAllStringSubmatch(md, -1) {
+			link := m[1]
+			if cfg.HTTPSOnly && strings.HasPrefix(link, "http://") {
+				bad = append(bad, link+" (not https)")
+				continue
+			}
+			for _, re := range deny {
+				if re.MatchString(link) {
+					bad = append(bad, link+" (denied by "+re.String()+")")
+					break
+				}
+			}
+		}
+		if len(bad) > 0 {
+			return "", fmt.Errorf("%s has disallowed links: %s", pkg.ImportPath, strings.Join(unique(bad), ", "))
+		}
+		return md, nil
+	}), nil
+}
+
+// unique returns values without repetitions, in order of first appearance.
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+// Package postprocess runs rendered markdown through a configurable chain of processors — link
+// checks, word filters, footers, URL rewrites — so documentation conventions can be enforced
+// without changing the renderers. Processors are registered by kind and configured per run from
+// a YAML file:
+//
+//	processors:
+//	  - kind: rewrite-urls
+//	    from: https://github.com/acme/
+//	    to: https://git.acme.internal/
+//	  - kind: word-filter
+//	    words: [internal-only, TODO]
+//	  - kind: footer
+//	    text: "Mirrored from pkg.go.dev for {import_path} {version}."
+//
+// Programs embedding docinator add kinds of their own with Register.
+package postprocess
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/moseye/docinator/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Processor transforms the rendered markdown of a package. An error fails the package.
+type Processor interface {
+	Process(pkg *models.Package, md string) (string, error)
+}
+
+// Func adapts a function to Processor.
+type Func func(pkg *models.Package, md string) (string, error)
+
+// Process calls f.
+func (f Func) Process(pkg *models.Package, md string) (string, error) {
+	return f(pkg, md)
+}
+
+// Factory constructs a processor of one kind; decode reads its configuration, the YAML entry
+// naming the kind, into a struct.
+type Factory func(decode func(v any) error) (Processor, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+)
+
+// Register makes a processor kind available to Load. Registering a kind twice panics.
+func Register(kind string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := factories[kind]; dup {
+		panic("postprocess: kind registered twice: " + kind)
+	}
+	factories[kind] = f
+}
+
+// Kinds lists the registered kinds in order.
+func Kinds() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	kinds := make([]string, 0, len(factories))
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Chain applies processors in order, each to the output of the previous one.
+type Chain []Processor
+
+// Process runs md through the chain. It stops at the first error.
+func (c Chain) Process(pkg *models.Package, md string) (string, error) {
+	for _, p := range c {
+		var err error
+		if md, err = p.Process(pkg, md); err != nil {
+			return "", err
+		}
+	}
+	return md, nil
+}
+
+// LoadFile reads a chain from the YAML file at path.
+func LoadFile(path string) (Chain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return chain, nil
+}
+
+// Parse reads a chain from YAML: a processors list whose entries name a registered kind and
+// hold its configuration.
+func Parse(data []byte) (Chain, error) {
+	var file struct {
+		Processors []yaml.Node `yaml:"processors"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	if len(file.Processors) == 0 {
+		return nil, errors.New("no processors configured")
+	}
+	var chain Chain
+	for i := range file.Processors {
+		node := &file.Processors[i]
+		var head struct {
+			Kind string `yaml:"kind"`
+		}
+		if err := node.Decode(&head); err != nil {
+			return nil, fmt.Errorf("processor %d: %w", i+1, err)
+		}
+		mu.RLock()
+		f := factories[head.Kind]
+		mu.RUnlock()
+		if f == nil {
+			return nil, fmt.Errorf("processor %d: unknown kind %q (want one of %v)", i+1, head.Kind, Kinds())
+		}
+		p, err := f(node.Decode)
+		if err != nil {
+			return nil, fmt.Errorf("processor %d (%s): %w", i+1, head.Kind, err)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
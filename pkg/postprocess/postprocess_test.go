@@ -0,0 +1,104 @@
+package postprocess
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	chain, err := Parse([]byte(`processors:
+  - kind: rewrite-urls
+    from: https://github.com/acme/
+    to: https://git.acme.internal/
+  - kind: word-filter
+    words: [secret]
+  - kind: footer
+    text: "Mirrored: {import_path} {version}"
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	pkg := &models.Package{ImportPath: "github.com/acme/tool", Version: "v1.2.0"}
+	md, err := chain.Process(pkg, "# tool\n\nSee [repo](https://github.com/acme/tool). Secret sauce, secretive.\n")
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	want := "# tool\n\nSee [repo](https://git.acme.internal/tool). *** sauce, secretive.\n\n---\n\nMirrored: github.com/acme/tool v1.2.0\n"
+	if md != want {
+		t.Errorf("Unexpected output:\n%q\nwant:\n%q", md, want)
+	}
+
+	for _, bad := range []string{
+		"processors: []",
+		"processors:\n  - kind: spellcheck\n",
+		"processors:\n  - kind: footer\n",
+		"processors:\n  - kind: link-check\n    deny: ['(']\n",
+		"chain: []",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestChecks(t *testing.T) {
+	pkg := &models.Package{ImportPath: "example.com/x"}
+	chain, err := Parse([]byte("processors:\n  - kind: link-check\n    https_only: true\n    deny: ['^https://intranet\\.']\n  - kind: word-filter\n    words: [TODO]\n    fail: true\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := chain.Process(pkg, "[a](https://example.com) [b](#anchor)"); err != nil {
+		t.Errorf("Expected conforming links to pass, got %v", err)
+	}
+	if _, err := chain.Process(pkg, "[a](http://example.com) ![b](https://intranet.acme/x.png)"); err == nil || !strings.Contains(err.Error(), "not https") || !strings.Contains(err.Error(), "denied by") {
+		t.Errorf("Expected both links reported, got %v", err)
+	}
+	if _, err := chain.Process(pkg, "todo: fix"); err == nil || !strings.Contains(err.Error(), "filtered words: todo") {
+		t.Errorf("Expected the filtered word to fail the page, got %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("upper-test", func(decode func(v any) error) (Processor, error) {
+		return Func(func(pkg *models.Package, md string) (string, error) { return strings.ToUpper(md), nil }), nil
+	})
+	chain, err := Parse([]byte("processors:\n  - kind: upper-test\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if md, _ := chain.Process(&models.Package{}, "abc"); md != "ABC" {
+		t.Errorf("Expected the registered processor to run, got %q", md)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a duplicate kind to panic")
+		}
+	}()
+	Register("footer", newFooter)
+}
+
+func TestBanner(t *testing.T) {
+	p, err := Banner("> INTERNAL — {{.ImportPath}}\n", "Generated by docinator from {{.ImportPath}} {{.Version}}; do not edit.")
+	if err != nil {
+		t.Fatalf("Banner failed: %v", err)
+	}
+	pkg := &models.Package{ImportPath: "example.com/x", Version: "v1.0.0"}
+	md, err := p.Process(pkg, "# x\n")
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	want := "> INTERNAL — example.com/x\n\n# x\n\nGenerated by docinator from example.com/x v1.0.0; do not edit.\n"
+	if md != want {
+		t.Errorf("Unexpected output:\n%q\nwant:\n%q", md, want)
+	}
+
+	if _, err := Banner("{{.ImportPath", ""); err == nil {
+		t.Error("Expected a malformed template to be rejected")
+	}
+	p, _ = Banner("{{.NoSuchField}}", "")
+	if _, err := p.Process(pkg, "# x\n"); err == nil {
+		t.Error("Expected an unknown field to fail the page")
+	}
+}
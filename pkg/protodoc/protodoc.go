@@ -0,0 +1,624 @@
+// Package protodoc encodes packages in the protobuf wire format defined by docinator.proto, a
+// compact binary form for storing and exporting large corpora and for gRPC. Messages written
+// here decode with code generated from the .proto in any language, and the other way round.
+package protodoc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Marshal encodes pkg as a docinator.v1.Package message.
+func Marshal(pkg *models.Package) []byte {
+	var e encoder
+	e.int(1, int64(pkg.SchemaVersion))
+	e.string(2, pkg.Name)
+	e.string(3, pkg.Description)
+	e.string(4, pkg.Module)
+	e.string(5, pkg.Version)
+	e.bool(6, pkg.IsLatest)
+	e.string(7, pkg.Published)
+	e.string(8, pkg.Synopsis)
+	e.string(9, pkg.License)
+	e.string(10, pkg.LicenseURL)
+	e.string(11, pkg.Repository)
+	e.string(12, pkg.ImportPath)
+	e.time(13, pkg.ScrapedAt)
+	e.string(14, pkg.Readme)
+	e.string(15, pkg.ProcessedReadme)
+	e.int(16, int64(pkg.Imports))
+	e.int(17, int64(pkg.ImportedBy))
+	e.strings(18, pkg.Importers)
+	for _, f := range pkg.Functions {
+		e.message(19, func(e *encoder) { encodeFunction(e, f) })
+	}
+	for _, t := range pkg.Types {
+		e.message(20, func(e *encoder) { encodeType(e, t) })
+	}
+	for _, v := range pkg.Variables {
+		e.message(21, func(e *encoder) {
+			e.string(1, v.Name)
+			e.string(2, v.Type)
+			e.string(3, v.Description)
+		})
+	}
+	for _, c := range pkg.Constants {
+		e.message(22, func(e *encoder) {
+			e.string(1, c.Name)
+			e.string(2, c.Type)
+			e.string(3, c.Value)
+			e.string(4, c.Description)
+		})
+	}
+	encodeExamples(&e, 23, pkg.Examples)
+	for _, f := range pkg.Files {
+		e.message(24, func(e *encoder) {
+			e.string(1, f.Name)
+			e.string(2, f.URL)
+		})
+	}
+	e.bool(25, pkg.ModuleDeprecated)
+	e.string(26, pkg.DeprecationNotice)
+	e.bool(27, pkg.Retracted)
+	e.string(28, pkg.RetractionNotice)
+	e.bool(29, pkg.Redistributable)
+	e.string(30, pkg.RedistributionNotice)
+	if d := pkg.Details; d != nil {
+		e.message(31, func(e *encoder) {
+			e.bool(1, d.ValidGoMod)
+			e.bool(2, d.RedistributableLicense)
+			e.bool(3, d.TaggedVersion)
+			e.bool(4, d.StableVersion)
+		})
+	}
+	for _, l := range pkg.Links {
+		e.message(32, func(e *encoder) {
+			e.string(1, l.Label)
+			e.string(2, l.URL)
+		})
+	}
+	e.strings(33, pkg.Identifiers)
+	e.string(34, pkg.PlatformNotice)
+	e.strings(35, pkg.Platforms)
+	if s := pkg.Summary; s != nil {
+		e.message(36, func(e *encoder) {
+			e.string(1, s.Text)
+			e.string(2, s.Model)
+			e.time(3, s.GeneratedAt)
+		})
+	}
+	e.string(37, pkg.LocalSource)
+	for _, g := range pkg.Guides {
+		e.message(38, func(e *encoder) {
+			e.string(1, g.Title)
+			e.string(2, g.URL)
+			e.string(3, g.Content)
+		})
+	}
+	e.string(39, pkg.GoVersion)
+	e.string(40, pkg.Overview)
+	e.string(41, pkg.ReadmeLang)
+	for _, a := range pkg.ReadmeAlternates {
+		e.message(42, func(e *encoder) {
+			e.string(1, a.Lang)
+			e.string(2, a.URL)
+			e.string(3, a.Content)
+		})
+	}
+	for _, w := range pkg.Warnings {
+		e.message(43, func(e *encoder) {
+			e.string(1, w.Code)
+			e.string(2, w.Field)
+			e.string(3, w.Message)
+		})
+	}
+	e.time(44, pkg.PublishedAt)
+	e.string(45, pkg.Parser)
+	e.strings(46, pkg.ImportList)
+	e.string(47, pkg.Profile)
+	return e.b
+}
+
+func encodeFunction(e *encoder, f models.Function) {
+	e.string(1, f.Name)
+	e.string(2, f.Description)
+	e.string(3, f.Signature)
+	e.string(4, f.Receiver)
+	e.string(5, f.Deprecated)
+	e.string(6, f.AddedIn)
+	encodeExamples(e, 7, f.Examples)
+	e.string(8, f.SourceURL)
+	e.string(9, f.SourceFile)
+	e.int(10, int64(f.SourceLine))
+	e.string(11, f.Source)
+}
+
+func encodeType(e *encoder, t models.Type) {
+	e.string(1, t.Name)
+	e.string(2, t.Description)
+	e.string(3, t.Definition)
+	e.string(4, t.Kind)
+	e.string(5, t.Deprecated)
+	e.string(6, t.AddedIn)
+	for _, m := range t.Methods {
+		e.message(7, func(e *encoder) { encodeFunction(e, m) })
+	}
+	encodeExamples(e, 8, t.Examples)
+	e.string(9, t.SourceURL)
+	e.string(10, t.SourceFile)
+	e.int(11, int64(t.SourceLine))
+	e.string(12, t.Source)
+	for _, f := range t.Fields {
+		e.message(13, func(e *encoder) {
+			e.string(1, f.Name)
+			e.string(2, f.Type)
+			e.string(3, f.Tag)
+			e.string(4, f.Doc)
+		})
+	}
+	for _, m := range t.InterfaceMethods {
+		e.message(14, func(e *encoder) { encodeFunction(e, m) })
+	}
+}
+
+func encodeExamples(e *encoder, num protowire.Number, examples []models.Example) {
+	for _, ex := range examples {
+		e.message(num, func(e *encoder) {
+			e.string(1, ex.Name)
+			e.string(2, ex.Code)
+			e.string(3, ex.Output)
+			e.string(4, ex.PlaygroundURL)
+		})
+	}
+}
+
+// Unmarshal decodes a docinator.v1.Package message. Unknown fields are skipped, so messages
+// from newer versions of the .proto decode too.
+func Unmarshal(data []byte) (*models.Package, error) {
+	pkg := &models.Package{}
+	err := decode(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			pkg.SchemaVersion = int(f.int())
+		case 2:
+			pkg.Name = f.string()
+		case 3:
+			pkg.Description = f.string()
+		case 4:
+			pkg.Module = f.string()
+		case 5:
+			pkg.Version = f.string()
+		case 6:
+			pkg.IsLatest = f.bool()
+		case 7:
+			pkg.Published = f.string()
+		case 8:
+			pkg.Synopsis = f.string()
+		case 9:
+			pkg.License = f.string()
+		case 10:
+			pkg.LicenseURL = f.string()
+		case 11:
+			pkg.Repository = f.string()
+		case 12:
+			pkg.ImportPath = f.string()
+		case 13:
+			pkg.ScrapedAt, err = decodeTime(f.bytes)
+		case 14:
+			pkg.Readme = f.string()
+		case 15:
+			pkg.ProcessedReadme = f.string()
+		case 16:
+			pkg.Imports = int(f.int())
+		case 17:
+			pkg.ImportedBy = int(f.int())
+		case 18:
+			pkg.Importers = append(pkg.Importers, f.string())
+		case 19:
+			var fn models.Function
+			if fn, err = decodeFunction(f.bytes); err == nil {
+				pkg.Functions = append(pkg.Functions, fn)
+			}
+		case 20:
+			var t models.Type
+			if t, err = decodeType(f.bytes); err == nil {
+				pkg.Types = append(pkg.Types, t)
+			}
+		case 21:
+			var v models.Variable
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					v.Name = f.string()
+				case 2:
+					v.Type = f.string()
+				case 3:
+					v.Description = f.string()
+				}
+				return nil
+			})
+			pkg.Variables = append(pkg.Variables, v)
+		case 22:
+			var c models.Constant
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					c.Name = f.string()
+				case 2:
+					c.Type = f.string()
+				case 3:
+					c.Value = f.string()
+				case 4:
+					c.Description = f.string()
+				}
+				return nil
+			})
+			pkg.Constants = append(pkg.Constants, c)
+		case 23:
+			pkg.Examples, err = appendExample(pkg.Examples, f.bytes)
+		case 24:
+			var sf models.SourceFile
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					sf.Name = f.string()
+				case 2:
+					sf.URL = f.string()
+				}
+				return nil
+			})
+			pkg.Files = append(pkg.Files, sf)
+		case 25:
+			pkg.ModuleDeprecated = f.bool()
+		case 26:
+			pkg.DeprecationNotice = f.string()
+		case 27:
+			pkg.Retracted = f.bool()
+		case 28:
+			pkg.RetractionNotice = f.string()
+		case 29:
+			pkg.Redistributable = f.bool()
+		case 30:
+			pkg.RedistributionNotice = f.string()
+		case 31:
+			d := &models.Details{}
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					d.ValidGoMod = f.bool()
+				case 2:
+					d.RedistributableLicense = f.bool()
+				case 3:
+					d.TaggedVersion = f.bool()
+				case 4:
+					d.StableVersion = f.bool()
+				}
+				return nil
+			})
+			pkg.Details = d
+		case 32:
+			var l models.Link
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					l.Label = f.string()
+				case 2:
+					l.URL = f.string()
+				}
+				return nil
+			})
+			pkg.Links = append(pkg.Links, l)
+		case 33:
+			pkg.Identifiers = append(pkg.Identifiers, f.string())
+		case 34:
+			pkg.PlatformNotice = f.string()
+		case 35:
+			pkg.Platforms = append(pkg.Platforms, f.string())
+		case 36:
+			s := &models.GeneratedSummary{}
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				var err error
+				switch num {
+				case 1:
+					s.Text = f.string()
+				case 2:
+					s.Model = f.string()
+				case 3:
+					s.GeneratedAt, err = decodeTime(f.bytes)
+				}
+				return err
+			})
+			pkg.Summary = s
+		case 37:
+			pkg.LocalSource = f.string()
+		case 38:
+			var g models.Guide
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					g.Title = f.string()
+				case 2:
+					g.URL = f.string()
+				case 3:
+					g.Content = f.string()
+				}
+				return nil
+			})
+			pkg.Guides = append(pkg.Guides, g)
+		case 39:
+			pkg.GoVersion = f.string()
+		case 40:
+			pkg.Overview = f.string()
+		case 41:
+			pkg.ReadmeLang = f.string()
+		case 42:
+			var a models.ReadmeAlternate
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					a.Lang = f.string()
+				case 2:
+					a.URL = f.string()
+				case 3:
+					a.Content = f.string()
+				}
+				return nil
+			})
+			pkg.ReadmeAlternates = append(pkg.ReadmeAlternates, a)
+		case 43:
+			var w models.Warning
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					w.Code = f.string()
+				case 2:
+					w.Field = f.string()
+				case 3:
+					w.Message = f.string()
+				}
+				return nil
+			})
+			pkg.Warnings = append(pkg.Warnings, w)
+		case 44:
+			pkg.PublishedAt, err = decodeTime(f.bytes)
+		case 45:
+			pkg.Parser = f.string()
+		case 46:
+			pkg.ImportList = append(pkg.ImportList, f.string())
+		case 47:
+			pkg.Profile = f.string()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+func decodeFunction(data []byte) (models.Function, error) {
+	var fn models.Function
+	err := decode(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			fn.Name = f.string()
+		case 2:
+			fn.Description = f.string()
+		case 3:
+			fn.Signature = f.string()
+		case 4:
+			fn.Receiver = f.string()
+		case 5:
+			fn.Deprecated = f.string()
+		case 6:
+			fn.AddedIn = f.string()
+		case 7:
+			fn.Examples, err = appendExample(fn.Examples, f.bytes)
+		case 8:
+			fn.SourceURL = f.string()
+		case 9:
+			fn.SourceFile = f.string()
+		case 10:
+			fn.SourceLine = int(f.int())
+		case 11:
+			fn.Source = f.string()
+		}
+		return err
+	})
+	return fn, err
+}
+
+func decodeType(data []byte) (models.Type, error) {
+	var t models.Type
+	err := decode(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			t.Name = f.string()
+		case 2:
+			t.Description = f.string()
+		case 3:
+			t.Definition = f.string()
+		case 4:
+			t.Kind = f.string()
+		case 5:
+			t.Deprecated = f.string()
+		case 6:
+			t.AddedIn = f.string()
+		case 7:
+			var m models.Function
+			if m, err = decodeFunction(f.bytes); err == nil {
+				t.Methods = append(t.Methods, m)
+			}
+		case 8:
+			t.Examples, err = appendExample(t.Examples, f.bytes)
+		case 9:
+			t.SourceURL = f.string()
+		case 10:
+			t.SourceFile = f.string()
+		case 11:
+			t.SourceLine = int(f.int())
+		case 12:
+			t.Source = f.string()
+		case 13:
+			var fd models.Field
+			err = decode(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					fd.Name = f.string()
+				case 2:
+					fd.Type = f.string()
+				case 3:
+					fd.Tag = f.string()
+				case 4:
+					fd.Doc = f.string()
+				}
+				return nil
+			})
+			t.Fields = append(t.Fields, fd)
+		case 14:
+			var m models.Function
+			if m, err = decodeFunction(f.bytes); err == nil {
+				t.InterfaceMethods = append(t.InterfaceMethods, m)
+			}
+		}
+		return err
+	})
+	return t, err
+}
+
+func appendExample(examples []models.Example, data []byte) ([]models.Example, error) {
+	var ex models.Example
+	err := decode(data, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			ex.Name = f.string()
+		case 2:
+			ex.Code = f.string()
+		case 3:
+			ex.Output = f.string()
+		case 4:
+			ex.PlaygroundURL = f.string()
+		}
+		return nil
+	})
+	return append(examples, ex), err
+}
+
+// encoder appends fields to a message, leaving out zero scalars as proto3 does.
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) string(num protowire.Number, s string) {
+	if s != "" {
+		e.b = protowire.AppendTag(e.b, num, protowire.BytesType)
+		e.b = protowire.AppendString(e.b, s)
+	}
+}
+
+// strings appends a repeated string field; unlike scalars, empty elements are kept.
+func (e *encoder) strings(num protowire.Number, ss []string) {
+	for _, s := range ss {
+		e.b = protowire.AppendTag(e.b, num, protowire.BytesType)
+		e.b = protowire.AppendString(e.b, s)
+	}
+}
+
+func (e *encoder) int(num protowire.Number, v int64) {
+	if v != 0 {
+		e.b = protowire.AppendTag(e.b, num, protowire.VarintType)
+		e.b = protowire.AppendVarint(e.b, uint64(v))
+	}
+}
+
+func (e *encoder) bool(num protowire.Number, v bool) {
+	if v {
+		e.b = protowire.AppendTag(e.b, num, protowire.VarintType)
+		e.b = protowire.AppendVarint(e.b, 1)
+	}
+}
+
+// time appends a google.protobuf.Timestamp, or nothing for the zero time.
+func (e *encoder) time(num protowire.Number, t time.Time) {
+	if !t.IsZero() {
+		e.message(num, func(e *encoder) {
+			e.int(1, t.Unix())
+			e.int(2, int64(t.Nanosecond()))
+		})
+	}
+}
+
+// message appends the embedded message written by fn.
+func (e *encoder) message(num protowire.Number, fn func(*encoder)) {
+	var m encoder
+	fn(&m)
+	e.b = protowire.AppendTag(e.b, num, protowire.BytesType)
+	e.b = protowire.AppendBytes(e.b, m.b)
+}
+
+// field is the value of a decoded field: varint for numbers and bools, bytes for strings and
+// embedded messages.
+type field struct {
+	varint uint64
+	bytes  []byte
+}
+
+func (f field) string() string { return string(f.bytes) }
+func (f field) int() int64     { return int64(f.varint) }
+func (f field) bool() bool     { return f.varint != 0 }
+
+// decode calls fn for every field of the message in data. Fixed-width and group fields are
+// skipped, since no docinator message uses them.
+func decode(data []byte, fn func(protowire.Number, field) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var f field
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+				return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+			}
+			data = data[n:]
+			continue
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		data = data[n:]
+		if err := fn(num, f); err != nil {
+			return fmt.Errorf("field %d: %w", num, err)
+		}
+	}
+	return nil
+}
+
+func decodeTime(data []byte) (time.Time, error) {
+	var secs, nanos int64
+	err := decode(data, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			secs = f.int()
+		case 2:
+			nanos = f.int()
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, nanos).UTC(), nil
+}
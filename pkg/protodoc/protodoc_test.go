@@ -0,0 +1,83 @@
+package protodoc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRoundTrip(t *testing.T) {
+	example := models.Example{Name: "Example", Code: "fmt.Println(1)", Output: "1", PlaygroundURL: "https://go.dev/play/p/x"}
+	pkg := &models.Package{
+		SchemaVersion: models.SchemaVersion,
+		Name:          "cobra",
+		Module:        "github.com/spf13/cobra",
+		Version:       "v1.9.1",
+		IsLatest:      true,
+		ImportPath:    "github.com/spf13/cobra",
+		ScrapedAt:     time.Date(2025, 3, 1, 12, 30, 0, 500, time.UTC),
+		Imports:       7,
+		ImportedBy:    177680,
+		Importers:     []string{"k8s.io/kubectl", ""},
+		Functions:     []models.Function{{Name: "Eq", Signature: "func Eq(a, b any) bool", SourceLine: 42, Examples: []models.Example{example}}},
+		Types: []models.Type{{
+			Name:    "Command",
+			Kind:    "struct",
+			Methods: []models.Function{{Name: "Command.Execute", Receiver: "Command"}},
+			Fields:  []models.Field{{Name: "Use", Type: "string", Tag: `json:"use"`, Doc: "Use is the usage line."}},
+		}, {
+			Name:             "PositionalArgs",
+			Kind:             "interface",
+			InterfaceMethods: []models.Function{{Name: "Check", Signature: "Check(args []string) error", Description: "Check validates args."}},
+		}},
+		Variables:       []models.Variable{{Name: "EnablePrefixMatching", Type: "bool"}},
+		Constants:       []models.Constant{{Name: "FlagSetByCobraAnnotation", Value: `"cobra_annotation_flag_set_by_cobra"`}},
+		Examples:        []models.Example{example},
+		Files:           []models.SourceFile{{Name: "cobra.go", URL: "https://github.com/spf13/cobra/blob/v1.9.1/cobra.go"}},
+		Retracted:       true,
+		Redistributable: true,
+		Details:         &models.Details{ValidGoMod: true, TaggedVersion: true},
+		Links:           []models.Link{{Label: "Repository", URL: "https://github.com/spf13/cobra"}},
+		Identifiers:     []string{"Command", "Eq"},
+		Platforms:       []string{"linux/amd64"},
+		Summary:         &models.GeneratedSummary{Text: "CLI framework.", Model: "m", GeneratedAt: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+		GoVersion:       "1.21",
+		Overview:        "Package cobra is a commander.\n\n```\ncobra.Execute()\n```",
+		Guides:          []models.Guide{{Title: "User Guide", URL: "https://cobra.readthedocs.io/en/latest/", Content: "# User Guide"}},
+	}
+	pkg.ReadmeLang = "en"
+	pkg.ReadmeAlternates = []models.ReadmeAlternate{{Lang: "zh-CN", URL: "https://github.com/spf13/cobra/blob/main/README.zh-CN.md", Content: "# Cobra"}}
+	pkg.Warnings = []models.Warning{{Code: models.WarningNoLicense, Field: "license", Message: "no license found"}}
+	pkg.Published, pkg.PublishedAt = "Feb 27, 2025", time.Date(2025, 2, 27, 0, 0, 0, 0, time.UTC)
+	pkg.Parser = "1/3f9a0c2b7d41"
+	pkg.ImportList = []string{"fmt", "github.com/spf13/pflag"}
+	pkg.Profile = "readme"
+
+	got, err := Unmarshal(Marshal(pkg))
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, pkg) {
+		t.Errorf("Expected the package to survive a round trip\nwant %+v\ngot  %+v", pkg, got)
+	}
+}
+
+func TestUnmarshalSkipsUnknownFields(t *testing.T) {
+	data := protowire.AppendTag(nil, 99, protowire.Fixed64Type)
+	data = protowire.AppendFixed64(data, 1)
+	data = append(data, Marshal(&models.Package{Name: "cobra"})...)
+
+	pkg, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if pkg.Name != "cobra" {
+		t.Errorf("Expected name cobra, got %q", pkg.Name)
+	}
+	if _, err := Unmarshal(data[:len(data)-1]); err == nil {
+		t.Error("Expected an error for a truncated message")
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Priority orders requests waiting for the same bucket: when a token frees up, a waiting request
+// of a higher priority gets it first. Carry it on the request context with WithPriority.
+type Priority int
+
+const (
+	// Background is for bulk jobs such as warm, which only need to finish eventually.
+	Background Priority = iota - 1
+	// Normal is the priority of requests without one.
+	Normal
+	// Interactive is for lookups someone is waiting on, such as on-demand scrapes of the API.
+	Interactive
+)
+
+// FairShare is how many tokens higher priorities get in a row while a lower one waits; the next
+// goes to the lower one, so a steady stream of interactive requests slows a background job down
+// instead of stalling it.
+const FairShare = 4
+
+// levels is the number of priorities.
+const levels = int(Interactive-Background) + 1
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose requests wait for the bucket with priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority carried by ctx, Normal when it carries none.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= Background && p <= Interactive {
+		return p
+	}
+	return Normal
+}
+
+// ParsePriority reads "background", "normal" or "interactive".
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "background":
+		return Background, nil
+	case "normal", "":
+		return Normal, nil
+	case "interactive":
+		return Interactive, nil
+	}
+	return Normal, fmt.Errorf("unknown priority %q; use background, normal or interactive", s)
+}
+
+// String returns the name ParsePriority reads.
+func (p Priority) String() string {
+	switch p {
+	case Background:
+		return "background"
+	case Interactive:
+		return "interactive"
+	}
+	return "normal"
+}
+
+// index maps p to 0 (Background) .. levels-1 (Interactive).
+func (p Priority) index() int {
+	return int(p - Background)
+}
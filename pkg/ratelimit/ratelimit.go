@@ -0,0 +1,237 @@
+// Package ratelimit provides token buckets for scraper.ScrapingConfig.Limiter: one local to the
+// process and one kept in Redis, which keeps the combined request rate of several docinator
+// workers within a single limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultKey is the Redis key of the bucket shared by workers scraping pkg.go.dev.
+const DefaultKey = "docinator:ratelimit:pkg.go.dev"
+
+// Local is a token bucket for a single process.
+type Local struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	waiting [levels]int // requests waiting in Wait, by priority
+	skipped [levels]int // tokens given to higher priorities while this one waited, since it last got one
+}
+
+// NewLocal returns a bucket allowing rate requests per second on average and up to burst at once.
+func NewLocal(rate float64, burst int) *Local {
+	b := float64(max(burst, 1))
+	return &Local{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// Wait blocks until a request may be made or ctx is done. Waiting requests are served by the
+// priority their context carries (see WithPriority), with FairShare keeping lower priorities
+// moving.
+func (l *Local) Wait(ctx context.Context) error {
+	i := PriorityFrom(ctx).index()
+	l.mu.Lock()
+	l.waiting[i]++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.waiting[i]--
+		if l.waiting[i] == 0 {
+			l.skipped[i] = 0
+		}
+		l.mu.Unlock()
+	}()
+	for {
+		wait := l.takeAs(i)
+		if wait == 0 {
+			return nil
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// takeAs takes a token for a request of priority index i if one is available and it is the
+// request's turn, and otherwise returns how long to wait before trying again.
+func (l *Local) takeAs(i int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.tokens < 1 {
+		return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+	if !l.turn(i) {
+		// A waiter with precedence takes this token; try again when the next one is due.
+		return time.Duration(min(1/l.rate, 1) * float64(time.Second))
+	}
+	l.tokens--
+	l.skipped[i] = 0
+	for lower := range i {
+		if l.waiting[lower] > 0 {
+			l.skipped[lower]++
+		}
+	}
+	return 0
+}
+
+// turn reports whether priority index i may take a token: no higher priority waits or i was
+// passed over FairShare times, and no lower priority waiting was passed over that often.
+func (l *Local) turn(i int) bool {
+	for lower := range i {
+		if l.waiting[lower] > 0 && l.skipped[lower] >= FairShare {
+			return false
+		}
+	}
+	if l.skipped[i] >= FairShare {
+		return true
+	}
+	for higher := i + 1; higher < levels; higher++ {
+		if l.waiting[higher] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Allow takes a token without waiting, regardless of priorities. It returns 0 when one was
+// available, and otherwise how long until one will be, for a Retry-After header.
+func (l *Local) Allow() time.Duration {
+	return l.take()
+}
+
+// take takes a token if one is available and otherwise returns how long until one will be.
+func (l *Local) take() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// refill adds the tokens accrued since the last call. l.mu must be held.
+func (l *Local) refill() {
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// takeScript refills and takes from the bucket in KEYS[1] atomically, using the Redis clock so
+// workers with skewed clocks agree. It returns 0 when a token was taken, otherwise the number of
+// microseconds until trying again. The hash also marks the priorities with waiting requests: a
+// request leaves an available token to a marked higher priority unless it was passed over
+// ARGV[4] times since its last token, and marks its own priority while it waits. ARGV: rate per
+// second, burst, priority index (0-based), fair share, number of priorities.
+var takeScript = redis.NewScript(`
+if redis.replicate_commands then redis.replicate_commands() end
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local level = tonumber(ARGV[3])
+local fair = tonumber(ARGV[4])
+local levels = tonumber(ARGV[5])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts', 'skipped' .. level)
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+local skipped = tonumber(state[3]) or 0
+tokens = math.min(burst, tokens + math.max(now - ts, 0) * rate / 1000000)
+local wait = 0
+if tokens >= 1 then
+  local yield = false
+  if skipped < fair then
+    for i = level + 1, levels - 1 do
+      if (tonumber(redis.call('HGET', KEYS[1], 'waiting' .. i)) or 0) > now then yield = true end
+    end
+  end
+  if yield then
+    skipped = skipped + 1
+    wait = math.ceil(math.min(1 / rate, 1) * 1000000)
+  else
+    tokens = tokens - 1
+    skipped = 0
+  end
+else
+  wait = math.ceil((1 - tokens) * 1000000 / rate)
+end
+local waiting = 0
+if wait > 0 then waiting = now + wait + 1000000 end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now), 'skipped' .. level, tostring(skipped), 'waiting' .. level, tostring(waiting))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return wait
+`)
+
+// Redis is a token bucket stored in Redis and shared by every process using the same key.
+type Redis struct {
+	client redis.UniversalClient
+	key    string
+	rate   float64
+	burst  int
+}
+
+// NewRedis returns a bucket under key allowing rate requests per second, and up to burst at once,
+// across all its users.
+func NewRedis(client redis.UniversalClient, key string, rate float64, burst int) *Redis {
+	return &Redis{client: client, key: key, rate: rate, burst: max(burst, 1)}
+}
+
+// Dial connects to the Redis server at url (redis://[user:password@]host:port/db) and returns a
+// shared bucket under key. Close releases the connection.
+func Dial(ctx context.Context, url, key string, rate float64, burst int) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connect to Redis: %w", err)
+	}
+	return NewRedis(client, key, rate, burst), nil
+}
+
+// Wait blocks until a request may be made or ctx is done. Requests of every process sharing the
+// bucket are served by the priority their context carries, as with Local.
+func (r *Redis) Wait(ctx context.Context) error {
+	level := PriorityFrom(ctx).index()
+	for {
+		us, err := takeScript.Run(ctx, r.client, []string{r.key}, r.rate, r.burst, level, FairShare, levels).Int64()
+		if err != nil {
+			return fmt.Errorf("rate limit: %w", err)
+		}
+		if us == 0 {
+			return nil
+		}
+		if err := sleep(ctx, time.Duration(us)*time.Microsecond); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the Redis client.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLocal(t *testing.T) {
+	l := NewLocal(50, 2)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	// Two requests fit the burst; the other two wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("Expected the rate to be enforced, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	l = NewLocal(0.001, 1)
+	l.Wait(ctx)
+	if err := l.Wait(ctx); err == nil {
+		t.Error("Expected Wait to stop when the context is done")
+	}
+}
+
+func TestRedis_SharedBucket(t *testing.T) {
+	srv := miniredis.RunT(t)
+	ctx := context.Background()
+
+	// Two workers with their own clients share one bucket of burst 2.
+	a := NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()}), DefaultKey, 1, 2)
+	b := NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()}), DefaultKey, 1, 2)
+	defer a.Close()
+	defer b.Close()
+
+	if err := a.Wait(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The bucket is now empty for both, so a third request has to wait about a second.
+	short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := a.Wait(short); err == nil {
+		t.Error("Expected the shared bucket to be exhausted")
+	}
+	if !srv.Exists(DefaultKey) {
+		t.Error("Expected the bucket to be stored in Redis")
+	}
+}
+
+// grantOrder starts a waiter of each priority in turn, a few milliseconds apart, on an exhausted
+// bucket and returns the priorities in the order they were let through.
+func grantOrder(t *testing.T, wait func(context.Context) error, priorities ...Priority) []Priority {
+	t.Helper()
+	order := make(chan Priority, len(priorities))
+	for _, p := range priorities {
+		go func() {
+			if err := wait(WithPriority(context.Background(), p)); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			order <- p
+		}()
+		time.Sleep(5 * time.Millisecond)
+	}
+	var got []Priority
+	for range priorities {
+		got = append(got, <-order)
+	}
+	return got
+}
+
+func TestLocal_Priority(t *testing.T) {
+	l := NewLocal(20, 1)
+	l.Wait(context.Background()) // empty the bucket
+	got := grantOrder(t, l.Wait, Background, Normal, Interactive)
+	if want := []Priority{Interactive, Normal, Background}; !slices.Equal(got, want) {
+		t.Errorf("Expected waiters served by priority, got %v, want %v", got, want)
+	}
+
+	// A stream of interactive requests delays a background one by at most FairShare tokens.
+	l = NewLocal(100, 1)
+	l.Wait(context.Background())
+	priorities := []Priority{Background}
+	for range 2 * FairShare {
+		priorities = append(priorities, Interactive)
+	}
+	got = grantOrder(t, l.Wait, priorities...)
+	if i := slices.Index(got, Background); i > FairShare {
+		t.Errorf("Expected the background request served within %d tokens, got %v", FairShare+1, got)
+	}
+
+	if PriorityFrom(context.Background()) != Normal {
+		t.Error("Expected Normal without a priority")
+	}
+	if p, err := ParsePriority("Interactive"); err != nil || p != Interactive {
+		t.Errorf("ParsePriority() = %v, %v", p, err)
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("Expected an unknown priority to be rejected")
+	}
+}
+
+func TestRedis_Priority(t *testing.T) {
+	srv := miniredis.RunT(t)
+	// Separate workers: a background job and the serve API.
+	warm := NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()}), DefaultKey, 20, 1)
+	serve := NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()}), DefaultKey, 20, 1)
+	defer warm.Close()
+	defer serve.Close()
+
+	warm.Wait(context.Background())
+	order := make(chan Priority, 2)
+	go func() {
+		warm.Wait(WithPriority(context.Background(), Background))
+		order <- Background
+	}()
+	time.Sleep(5 * time.Millisecond)
+	go func() {
+		serve.Wait(WithPriority(context.Background(), Interactive))
+		order <- Interactive
+	}()
+	if first := <-order; first != Interactive {
+		t.Errorf("Expected the interactive request served first, got %v", first)
+	}
+	<-order
+}
@@ -0,0 +1,249 @@
+// Package readme detects the language of README text and finds the README translations and
+// alternate README files a README links to.
+package readme
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/source"
+)
+
+// maxReadmeSize caps the size of a fetched README.
+const maxReadmeSize = 1 << 20
+
+var (
+	codeBlock  = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	urlPattern = regexp.MustCompile(`https?://\S+`)
+)
+
+// stopwords are frequent short words of the Latin-script languages DetectLang tells apart.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "to", "of", "for", "with", "this", "that", "you"},
+	"es": {"el", "la", "los", "las", "es", "para", "con", "una", "que", "del"},
+	"fr": {"le", "la", "les", "est", "pour", "avec", "une", "des", "que", "vous"},
+	"de": {"der", "die", "das", "und", "ist", "für", "mit", "nicht", "ein", "sie"},
+	"pt": {"o", "os", "as", "é", "para", "com", "uma", "que", "não", "você"},
+	"it": {"il", "lo", "gli", "è", "per", "con", "una", "che", "non", "sono"},
+}
+
+// DetectLang returns the ISO 639-1 code of the main language of a README in markdown, judged by
+// its writing system and, for Latin script, by frequent words. Code and URLs are ignored. It
+// returns "" when the text has too few letters to tell.
+func DetectLang(markdown string) string {
+	text := urlPattern.ReplaceAllString(codeBlock.ReplaceAllString(markdown, " "), " ")
+
+	var letters, han, kana, hangul, cyrillic, ukrainian, arabic, greek, hebrew, thai, devanagari int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian++
+			}
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		}
+	}
+	if letters < 20 {
+		return ""
+	}
+
+	// CJK scripts pack a word into one or two characters, so a smaller share of the letters
+	// already means most of the prose.
+	cjk := letters * 15 / 100
+	switch {
+	case kana > 0 && kana+han >= cjk:
+		return "ja"
+	case han >= cjk:
+		return "zh"
+	case hangul >= cjk:
+		return "ko"
+	}
+	share := letters * 30 / 100
+	switch {
+	case cyrillic >= share && ukrainian > 0:
+		return "uk"
+	case cyrillic >= share:
+		return "ru"
+	case arabic >= share:
+		return "ar"
+	case greek >= share:
+		return "el"
+	case hebrew >= share:
+		return "he"
+	case thai >= share:
+		return "th"
+	case devanagari >= share:
+		return "hi"
+	}
+
+	counts := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, words := range stopwords {
+			for _, w := range words {
+				if w == word {
+					counts[lang]++
+				}
+			}
+		}
+	}
+	best := "en"
+	for _, lang := range []string{"es", "fr", "de", "pt", "it"} {
+		if counts[lang] > counts[best] {
+			best = lang
+		}
+	}
+	return best
+}
+
+// readmeFile matches README file names carrying a language tag, e.g. README.zh-CN.md or
+// README_ja.md.
+var readmeFile = regexp.MustCompile(`(?i)^readme[._-]([a-z]{2})(?:[_-]([a-z]{2}|hans|hant))?\.(?:md|markdown|rst|txt)$`)
+
+// tagAliases maps country codes often used in README file names to the language they mean.
+var tagAliases = map[string]string{"cn": "zh", "tw": "zh-TW", "jp": "ja", "kr": "ko", "ua": "uk", "br": "pt-BR"}
+
+// Alternates returns the README files linked from README HTML whose file name carries a language
+// tag, in link order and without duplicates. Content is left empty; see Fetch.
+func Alternates(html string) []models.ReadmeAlternate {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil
+	}
+	return AlternatesIn(doc.Selection)
+}
+
+// AlternatesIn is Alternates for README HTML already parsed, such as the README element of a
+// package page.
+func AlternatesIn(readme *goquery.Selection) []models.ReadmeAlternate {
+	var alts []models.ReadmeAlternate
+	seen := map[string]bool{}
+	readme.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href := a.AttrOr("href", "")
+		u, err := url.Parse(href)
+		if err != nil || seen[href] {
+			return
+		}
+		m := readmeFile.FindStringSubmatch(path.Base(u.Path))
+		if m == nil {
+			return
+		}
+		seen[href] = true
+		alts = append(alts, models.ReadmeAlternate{Lang: languageTag(m[1], m[2]), URL: href})
+	})
+	return alts
+}
+
+// languageTag normalizes the language and region parts of a README file name to a BCP 47 tag
+// such as "zh-CN".
+func languageTag(lang, region string) string {
+	lang = strings.ToLower(lang)
+	if alias, ok := tagAliases[lang]; ok && region == "" {
+		return alias
+	}
+	switch {
+	case region == "":
+		return lang
+	case len(region) == 2:
+		return lang + "-" + strings.ToUpper(region)
+	}
+	return lang + "-" + strings.ToUpper(region[:1]) + strings.ToLower(region[1:])
+}
+
+// Match reports whether a README in language tag lang satisfies the preference pref: the
+// languages must agree, and the regions too when pref names one ("zh" matches "zh-CN",
+// "zh-TW" does not).
+func Match(pref, lang string) bool {
+	if pref == "" || lang == "" {
+		return false
+	}
+	if strings.EqualFold(pref, lang) {
+		return true
+	}
+	prefLang, prefRegion, _ := strings.Cut(pref, "-")
+	primary, _, _ := strings.Cut(lang, "-")
+	return prefRegion == "" && strings.EqualFold(prefLang, primary)
+}
+
+// Fetch downloads the README at link, a repository "view file" link or a plain URL, and returns
+// its contents.
+func Fetch(ctx context.Context, client *http.Client, link string) (string, error) {
+	if raw, ok := source.RawURL(link); ok {
+		link = raw
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", link, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s: status %d", link, res.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxReadmeSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", link, err)
+	}
+	return string(data), nil
+}
+
+// Prefer makes the alternate README in language pref the package's README, fetching it with
+// client, and keeps the README it replaces as an alternate. It reports whether the README
+// changed; packages whose README already is in pref, or that link no README in pref, are left
+// as they are.
+func Prefer(ctx context.Context, client *http.Client, pkg *models.Package, pref string) (bool, error) {
+	if pref == "" || Match(pref, pkg.ReadmeLang) {
+		return false, nil
+	}
+	for i, alt := range pkg.ReadmeAlternates {
+		if !Match(pref, alt.Lang) {
+			continue
+		}
+		if alt.Content == "" {
+			content, err := Fetch(ctx, client, alt.URL)
+			if err != nil {
+				return false, err
+			}
+			alt.Content = content
+		}
+		pkg.ReadmeAlternates[i] = models.ReadmeAlternate{Lang: pkg.ReadmeLang, Content: pkg.ProcessedReadme}
+		pkg.ProcessedReadme = alt.Content
+		pkg.ReadmeLang = alt.Lang
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,87 @@
+package readme
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestDetectLang(t *testing.T) {
+	for _, tc := range []struct{ text, want string }{
+		{"Cobra is a library for creating powerful modern CLI applications. It is used in many Go projects.", "en"},
+		{"Cobra 是一个用于创建强大的现代命令行应用程序的库，被许多 Go 项目使用，例如 Kubernetes 和 Hugo。\n\n```go\nfunc main() { cobra.Execute() }\n```", "zh"},
+		{"Cobra は強力なモダン CLI アプリケーションを作成するためのライブラリです。", "ja"},
+		{"Cobra — это библиотека для создания мощных современных консольных приложений.", "ru"},
+		{"Cobra es una biblioteca para crear aplicaciones de línea de comandos con los comandos de la consola.", "es"},
+		{"Cobra ist eine Bibliothek für moderne Kommandozeilen und die Anwendungen, die sie nicht brauchen.", "de"},
+		{"`go get github.com/spf13/cobra`", ""},
+	} {
+		if got := DetectLang(tc.text); got != tc.want {
+			t.Errorf("DetectLang(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestAlternates(t *testing.T) {
+	html := `<p><a href="https://github.com/o/r/blob/main/README.md">English</a> |
+<a href="https://github.com/o/r/blob/main/README.zh-CN.md">简体中文</a> |
+<a href="https://github.com/o/r/blob/main/docs/README_ja.md">日本語</a> |
+<a href="https://github.com/o/r/blob/main/README_CN.md">中文</a> |
+<a href="https://github.com/o/r/blob/main/README.zh-CN.md">again</a> |
+<a href="https://github.com/o/r/blob/main/README-old.md">old</a></p>`
+	want := []models.ReadmeAlternate{
+		{Lang: "zh-CN", URL: "https://github.com/o/r/blob/main/README.zh-CN.md"},
+		{Lang: "ja", URL: "https://github.com/o/r/blob/main/docs/README_ja.md"},
+		{Lang: "zh", URL: "https://github.com/o/r/blob/main/README_CN.md"},
+	}
+	if got := Alternates(html); !reflect.DeepEqual(got, want) {
+		t.Errorf("Alternates() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pref, lang string
+		want       bool
+	}{
+		{"zh", "zh-CN", true},
+		{"zh-cn", "zh-CN", true},
+		{"zh-TW", "zh-CN", false},
+		{"en", "", false},
+		{"ja", "zh", false},
+	} {
+		if got := Match(tc.pref, tc.lang); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pref, tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestPrefer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# Cobra\n\n中文说明"))
+	}))
+	defer srv.Close()
+
+	pkg := &models.Package{
+		ProcessedReadme:  "# Cobra\n\nEnglish docs",
+		ReadmeLang:       "en",
+		ReadmeAlternates: []models.ReadmeAlternate{{Lang: "ja", URL: srv.URL + "/README_ja.md"}, {Lang: "zh-CN", URL: srv.URL + "/README.zh-CN.md"}},
+	}
+	if changed, err := Prefer(context.Background(), srv.Client(), pkg, "en"); changed || err != nil {
+		t.Fatalf("Expected an English README to stay, got %v, %v", changed, err)
+	}
+	changed, err := Prefer(context.Background(), srv.Client(), pkg, "zh")
+	if !changed || err != nil {
+		t.Fatalf("Expected the Chinese README to be picked, got %v, %v", changed, err)
+	}
+	if pkg.ProcessedReadme != "# Cobra\n\n中文说明" || pkg.ReadmeLang != "zh-CN" {
+		t.Errorf("Unexpected README %q in %q", pkg.ProcessedReadme, pkg.ReadmeLang)
+	}
+	if alt := pkg.ReadmeAlternates[1]; alt.Lang != "en" || alt.Content != "# Cobra\n\nEnglish docs" {
+		t.Errorf("Expected the English README to be kept as an alternate, got %+v", alt)
+	}
+}
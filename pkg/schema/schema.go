@@ -0,0 +1,126 @@
+// Package schema derives JSON Schemas (draft 2020-12) for the data model from the Go types, so
+// the schema always describes exactly what encoding/json writes for them.
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Types maps the names accepted by For to the model they describe.
+var Types = map[string]any{
+	"package":  models.Package{},
+	"document": models.Document{},
+}
+
+// For returns the JSON Schema of the model named name (see Types).
+func For(name string) (map[string]any, error) {
+	v, ok := Types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q; use package or document", name)
+	}
+	return Generate(reflect.TypeOf(v), name), nil
+}
+
+// Generate returns the schema of t: named struct types become $defs referenced by $ref, and the
+// root refers to the definition of t. The schema is versioned by models.SchemaVersion.
+func Generate(t reflect.Type, name string) map[string]any {
+	g := &generator{defs: map[string]any{}}
+	root := g.schema(t)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = fmt.Sprintf("https://github.com/moseye/docinator/schema/v%d/%s.json", models.SchemaVersion, name)
+	root["title"] = fmt.Sprintf("docinator %s (schema version %d)", name, models.SchemaVersion)
+	root["$defs"] = g.defs
+	return root
+}
+
+type generator struct {
+	defs map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of values of type t as encoding/json writes them.
+func (g *generator) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return nullable(g.schema(t.Elem()))
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return nullable(map[string]any{"type": "array", "items": g.schema(t.Elem())})
+	case reflect.Map:
+		return nullable(map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())})
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // placeholder for recursive types
+			g.defs[t.Name()] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// object returns the schema of a struct's JSON object. Fields without omitempty are always
+// written, so they are required; embedded structs contribute their fields.
+func (g *generator) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	var add func(t reflect.Type)
+	add = func(t reflect.Type) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				add(f.Type)
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = g.schema(f.Type)
+			if !strings.Contains(","+opts+",", ",omitempty,") {
+				required = append(required, name)
+			}
+		}
+	}
+	add(t)
+	s := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// nullable allows null besides the type of s: encoding/json writes nil pointers, slices and maps as null.
+func nullable(s map[string]any) map[string]any {
+	if typ, ok := s["type"].(string); ok {
+		s["type"] = []string{typ, "null"}
+		return s
+	}
+	return map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+}
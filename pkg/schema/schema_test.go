@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestFor(t *testing.T) {
+	s, err := For("package")
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+	if s["$ref"] != "#/$defs/Package" {
+		t.Errorf("Expected the root to refer to Package, got %v", s["$ref"])
+	}
+	defs := s["$defs"].(map[string]any)
+	pkgSchema := defs["Package"].(map[string]any)
+	props := pkgSchema["properties"].(map[string]any)
+
+	// Every key encoding/json writes must be described, and every required key written.
+	pkg := models.Package{
+		SchemaVersion: models.SchemaVersion,
+		ImportPath:    "github.com/spf13/cobra",
+		ScrapedAt:     time.Now(),
+		Types:         []models.Type{{Name: "Command", Fields: []models.Field{{Name: "Use", Type: "string"}}}},
+	}
+	data, _ := json.Marshal(pkg)
+	var written map[string]any
+	json.Unmarshal(data, &written)
+	for key := range written {
+		if _, ok := props[key]; !ok {
+			t.Errorf("Expected property %s in the schema", key)
+		}
+	}
+	for _, key := range pkgSchema["required"].([]string) {
+		if _, ok := written[key]; !ok {
+			t.Errorf("Required property %s is not written", key)
+		}
+	}
+
+	if got := props["ScrapedAt"].(map[string]any)["format"]; got != "date-time" {
+		t.Errorf("Expected ScrapedAt to be a date-time, got %v", got)
+	}
+	if got := props["Functions"].(map[string]any)["type"]; !slices.Equal(got.([]string), []string{"array", "null"}) {
+		t.Errorf("Expected Functions to be a nullable array, got %v", got)
+	}
+	if _, ok := defs["Field"]; !ok {
+		t.Error("Expected nested structs to get their own definition")
+	}
+	if _, err := For("chunk"); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
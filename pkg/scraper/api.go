@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/moseye/docinator/internal/models"
+)
+
+// apiPackage is a package as the pkgsite JSON API serves it at /v1/package/{path}.
+type apiPackage struct {
+	Path          string       `json:"path"`
+	ModulePath    string       `json:"modulePath"`
+	ModuleVersion string       `json:"moduleVersion"`
+	Synopsis      string       `json:"synopsis"`
+	IsLatest      bool         `json:"isLatest"`
+	Imports       []string     `json:"imports"`
+	Licenses      []apiLicense `json:"licenses"`
+}
+
+// apiLicense is a license file of a module, with the license types detected in it.
+type apiLicense struct {
+	Types    []string `json:"types"`
+	FilePath string   `json:"filePath"`
+}
+
+// apiImportedBy is the response of /v1/imported-by/{path}.
+type apiImportedBy struct {
+	ImportedBy struct {
+		Items []string `json:"items"`
+		Total int      `json:"total"`
+	} `json:"importedBy"`
+}
+
+// apiURL returns the URL of endpoint for importPath, which may be pinned to a version, on the API
+// at ScrapingConfig.API, with query parameters in params.
+func (s *Scraper) apiURL(endpoint, importPath string, params url.Values) (string, error) {
+	path, version, _ := strings.Cut(importPath, "@")
+	if version != "" {
+		v, err := CheckVersion(version)
+		if err != nil {
+			return "", err
+		}
+		if params == nil {
+			params = url.Values{}
+		}
+		params.Set("version", v)
+	}
+	u, err := url.Parse(strings.TrimSuffix(s.config.API, "/") + "/v1/" + endpoint + "/" + path)
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+// scrapeAPIPackage fetches importPath from the pkgsite JSON API.
+func (s *Scraper) scrapeAPIPackage(ctx context.Context, importPath string) (*apiPackage, error) {
+	u, err := s.apiURL("package", importPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	var pkg apiPackage
+	if err := s.fetchJSON(ctx, u, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// scrapeAPIImporters fetches up to limit importers of importPath from the pkgsite JSON API.
+func (s *Scraper) scrapeAPIImporters(ctx context.Context, importPath string, limit int) ([]string, error) {
+	u, err := s.apiURL("imported-by", importPath, url.Values{"limit": {strconv.Itoa(limit)}})
+	if err != nil {
+		return nil, err
+	}
+	var resp apiImportedBy
+	if err := s.fetchJSON(ctx, u, &resp); err != nil {
+		return nil, err
+	}
+	importers := resp.ImportedBy.Items
+	if len(importers) > limit {
+		importers = importers[:limit]
+	}
+	return importers, nil
+}
+
+// fetchJSON requests u through the scraper's collector, with its politeness limits, hooks and
+// HTTP cache, and decodes the JSON response into v.
+func (s *Scraper) fetchJSON(ctx context.Context, u string, v any) error {
+	c := s.clone()
+	if ctx != nil {
+		c.Context = ctx
+	}
+	var body []byte
+	c.OnResponse(func(r *colly.Response) {
+		body = r.Body
+	})
+	// As in visit, an error with a body is that of an attempt a retry then recovered.
+	if err := c.Request(http.MethodGet, u, nil, nil, http.Header{"Accept": {jsonMediaType}}); err != nil && body == nil {
+		return fmt.Errorf("failed to visit %s: %w", u, err)
+	}
+	c.Wait()
+	if body == nil {
+		return fmt.Errorf("no response from %s", u)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", u, err)
+	}
+	return nil
+}
+
+// mergeAPI overwrites the fields of pkg, parsed from its page, that the API covers with what it
+// served: module, version, synopsis, license and the number of imports.
+func mergeAPI(pkg *models.Package, api *apiPackage) {
+	if api.ModulePath != "" {
+		pkg.Module = api.ModulePath
+	}
+	if api.ModuleVersion != "" {
+		pkg.Version = api.ModuleVersion
+		pkg.IsLatest = api.IsLatest
+	}
+	if api.Synopsis != "" {
+		pkg.Synopsis = api.Synopsis
+	}
+	var licenses []string
+	for _, l := range api.Licenses {
+		licenses = append(licenses, l.Types...)
+	}
+	if len(licenses) > 0 {
+		pkg.License = strings.Join(licenses, ", ")
+	}
+	if api.Imports != nil {
+		pkg.Imports = len(api.Imports)
+	}
+}
+
+// apiFallback logs that the API could not serve importPath, so its page is used alone.
+func apiFallback(importPath string, err error) {
+	log.Printf("pkgsite API unavailable for %s, parsing its page instead: %v", importPath, err)
+}
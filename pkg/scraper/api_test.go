@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// apiTransport serves a package page without metadata, its tabs and, unless down, the package
+// from the pkgsite JSON API at api.example.com, recording the URLs requested.
+type apiTransport struct {
+	down bool
+
+	mu   sync.Mutex
+	urls []string
+}
+
+func (f *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.urls = append(f.urls, req.URL.String())
+	f.mu.Unlock()
+
+	status, contentType := http.StatusOK, "text/html; charset=utf-8"
+	body := `<html><body><h1 class="UnitHeader-titleHeading">widget</h1></body></html>`
+	switch {
+	case req.URL.Host == "api.example.com" && f.down:
+		status, body = http.StatusNotFound, "not found"
+	case req.URL.Path == "/v1/package/example.com/widget":
+		contentType = "application/json"
+		body = `{"path":"example.com/widget","modulePath":"example.com","moduleVersion":"` + req.URL.Query().Get("version") + `","isLatest":true,
+			"synopsis":"Package widget makes widgets.","imports":["fmt","io"],"licenses":[{"types":["MIT"],"filePath":"LICENSE"}]}`
+	case req.URL.Path == "/v1/imported-by/example.com/widget":
+		contentType = "application/json"
+		body = `{"modulePath":"example.com","importedBy":{"items":["example.com/app","example.com/cli"],"total":2}}`
+	case req.URL.Query().Get("tab") == "imports":
+		body = `<html><body><ul class="Imports-list"><a href="/os">os</a></ul></body></html>`
+	case req.URL.Query().Get("tab") == "importedby":
+		body = `<html><body><div class="ImportedBy-list"><a href="/example.com/tool">example.com/tool</a></div></body></html>`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {contentType}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestScrapePackageTabs_API(t *testing.T) {
+	transport := &apiTransport{}
+	s, err := New(&ScrapingConfig{Transport: transport, MaxConcurrency: 4, API: "https://api.example.com"})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	pkg, _, tabs, _, err := s.ScrapePackageTabs(context.Background(), "example.com/widget@v1.2.0", TabRequest{Imports: true, Importers: 5})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pkg.Name != "widget" || pkg.Module != "example.com" || pkg.Version != "v1.2.0" || !pkg.IsLatest ||
+		pkg.Synopsis != "Package widget makes widgets." || pkg.License != "MIT" || pkg.Imports != 2 {
+		t.Errorf("Expected the page's name with the API's metadata, got %+v", pkg)
+	}
+	if strings.Join(tabs.Imports, ",") != "fmt,io" || strings.Join(pkg.Importers, ",") != "example.com/app,example.com/cli" {
+		t.Errorf("Expected imports and importers from the API, got %v and %v", tabs.Imports, pkg.Importers)
+	}
+	for _, u := range transport.urls {
+		if strings.Contains(u, "?tab=") {
+			t.Errorf("Expected no tab fetched, got %s", u)
+		}
+	}
+}
+
+func TestScrapePackageTabs_APIFallback(t *testing.T) {
+	transport := &apiTransport{down: true}
+	s, err := New(&ScrapingConfig{Transport: transport, MaxConcurrency: 4, API: "https://api.example.com"})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	pkg, _, tabs, _, err := s.ScrapePackageTabs(context.Background(), "example.com/widget", TabRequest{Imports: true, Importers: 5})
+	if err != nil {
+		t.Fatalf("Expected the page despite the API failing, got %v", err)
+	}
+	if pkg.Name != "widget" || pkg.Module != "" {
+		t.Errorf("Expected the page alone, got %+v", pkg)
+	}
+	if strings.Join(tabs.Imports, ",") != "os" || strings.Join(pkg.Importers, ",") != "example.com/tool" {
+		t.Errorf("Expected imports and importers from the tabs, got %v and %v", tabs.Imports, pkg.Importers)
+	}
+}
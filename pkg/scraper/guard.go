@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+// Error classes of responses dropped before parsing, counted in ScrapingStats.ErrorsByClass.
+const (
+	ErrorTooLarge    = "too_large"
+	ErrorContentType = "content_type"
+)
+
+// DefaultMaxResponseSize caps response bodies when ScrapingConfig.MaxResponseSize is 0. The
+// largest pkg.go.dev pages are a few megabytes.
+const DefaultMaxResponseSize = 10 << 20
+
+// defaultContentTypes are the media types parsed when ScrapingConfig.ContentTypes is nil.
+var defaultContentTypes = []string{"text/html", "application/xhtml+xml"}
+
+// jsonMediaType is the media type requests to the pkgsite JSON API accept, and the only one
+// accepted in response to them.
+const jsonMediaType = "application/json"
+
+var (
+	// ErrResponseTooLarge marks a response whose body exceeded ScrapingConfig.MaxResponseSize.
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrContentType marks a successful response that is not a document the parser reads, such as
+	// a binary download or JSON from a misconfigured proxy.
+	ErrContentType = errors.New("unexpected content type")
+)
+
+// maxResponseSize returns the body size limit of config, or 0 for none.
+func maxResponseSize(config *ScrapingConfig) int64 {
+	switch {
+	case config.MaxResponseSize < 0:
+		return 0
+	case config.MaxResponseSize == 0:
+		return DefaultMaxResponseSize
+	}
+	return config.MaxResponseSize
+}
+
+// checkContentType returns ErrContentType (wrapped) when a successful response declares a media
+// type outside config.ContentTypes, or other than JSON for a request accepting only JSON.
+// Responses without a Content-Type header pass.
+func checkContentType(config *ScrapingConfig, resp *http.Response) error {
+	header := resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK || header == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrContentType, header)
+	}
+	allowed := config.ContentTypes
+	if allowed == nil {
+		allowed = defaultContentTypes
+	}
+	if resp.Request != nil && resp.Request.Header.Get("Accept") == jsonMediaType {
+		allowed = []string{jsonMediaType}
+	}
+	if !slices.Contains(allowed, mediaType) {
+		return fmt.Errorf("%w %s", ErrContentType, mediaType)
+	}
+	return nil
+}
+
+// guardResponse drops resp before it is read when its declared size or content type is not
+// acceptable, and otherwise limits how much of its body can be read. Dropped responses are
+// recorded in the scraper's stats.
+func (s *Scraper) guardResponse(resp *http.Response) (*http.Response, error) {
+	limit := maxResponseSize(s.config)
+	err := checkContentType(s.config, resp)
+	if err == nil && limit > 0 && resp.ContentLength > limit {
+		err = fmt.Errorf("%w: %d bytes, limit %d", ErrResponseTooLarge, resp.ContentLength, limit)
+	}
+	if err != nil {
+		resp.Body.Close()
+		s.recordSkipped(resp.Request.URL.String())
+		return nil, err
+	}
+	if limit > 0 {
+		resp.Body = &limitedBody{ReadCloser: resp.Body, limit: limit, onExceed: func() { s.recordSkipped(resp.Request.URL.String()) }}
+	}
+	return resp, nil
+}
+
+// limitedBody fails reads once more than limit bytes arrived, for bodies without a
+// Content-Length or with a wrong one.
+type limitedBody struct {
+	io.ReadCloser
+	limit    int64
+	n        int64
+	onExceed func()
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, ErrResponseTooLarge
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.n > b.limit {
+		b.exceeded = true
+		b.onExceed()
+		return n, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	return n, err
+}
+
+func (s *Scraper) recordSkipped(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.SkippedResponses = append(s.stats.SkippedResponses, url)
+}
@@ -0,0 +1,107 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Request is a request about to be sent, as BeforeRequestHooks see it.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header // sent with the request; hooks may set fields, e.g. Authorization
+}
+
+// BeforeRequestHook runs before every request the scraper makes: package pages, tabs, site
+// profile pages and retries. Responses served from ScrapingConfig.CacheDir are looked up after it.
+type BeforeRequestHook func(req *Request)
+
+// AfterParseHook runs on every package parsed from a page, scraped or re-parsed (see Reparse),
+// with the page's HTML. It may change pkg, e.g. to add fields from another source; an error fails
+// the package with it, which filters it out of a batch.
+type AfterParseHook func(ctx context.Context, pkg *models.Package, rawHTML string) error
+
+// BeforeStoreHook runs through BeforeStore on a document before it is written to a store. It may
+// change doc; an error keeps the document out of the store.
+type BeforeStoreHook func(ctx context.Context, doc *models.Document) error
+
+// hooks holds the hooks registered on a Scraper, in registration order.
+type hooks struct {
+	beforeRequest []BeforeRequestHook
+	afterParse    []AfterParseHook
+	beforeStore   []BeforeStoreHook
+}
+
+// OnBeforeRequest registers h to run before every request. Hooks run in the order they were
+// registered; register them before scraping starts.
+func (s *Scraper) OnBeforeRequest(h BeforeRequestHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.beforeRequest = append(s.hooks.beforeRequest, h)
+}
+
+// OnAfterParse registers h to run on every parsed package, after the scraper's own checks.
+func (s *Scraper) OnAfterParse(h AfterParseHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.afterParse = append(s.hooks.afterParse, h)
+}
+
+// OnBeforeStore registers h to run on every document passed to BeforeStore.
+func (s *Scraper) OnBeforeStore(h BeforeStoreHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.beforeStore = append(s.hooks.beforeStore, h)
+}
+
+// BeforeStore runs the BeforeStoreHooks on doc. Code that caches what the scraper returned calls
+// it before every write and skips the write when it fails; the scraper itself stores nothing.
+func (s *Scraper) BeforeStore(ctx context.Context, doc *models.Document) error {
+	s.mu.RLock()
+	hs := s.hooks.beforeStore
+	s.mu.RUnlock()
+	for _, h := range hs {
+		if err := h(ctx, doc); err != nil {
+			return fmt.Errorf("before-store hook for %s: %w", doc.ID, err)
+		}
+	}
+	return nil
+}
+
+// beforeRequest runs the BeforeRequestHooks on r.
+func (s *Scraper) beforeRequest(r *colly.Request) {
+	s.mu.RLock()
+	hs := s.hooks.beforeRequest
+	s.mu.RUnlock()
+	if len(hs) == 0 {
+		return
+	}
+	if r.Headers == nil {
+		r.Headers = &http.Header{}
+	}
+	req := &Request{Method: r.Method, URL: r.URL, Header: *r.Headers}
+	for _, h := range hs {
+		h(req)
+	}
+}
+
+// afterParse runs the AfterParseHooks on pkg, parsed from rawHTML.
+func (s *Scraper) afterParse(ctx context.Context, pkg *models.Package, rawHTML string) error {
+	s.mu.RLock()
+	hs := s.hooks.afterParse
+	s.mu.RUnlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, h := range hs {
+		if err := h(ctx, pkg, rawHTML); err != nil {
+			return fmt.Errorf("after-parse hook for %s: %w", pkg.ImportPath, err)
+		}
+	}
+	return nil
+}
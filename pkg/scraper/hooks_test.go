@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// headerTransport answers like fixtureTransport, recording the Authorization header it saw.
+type headerTransport struct {
+	fixtureTransport
+	auth string
+}
+
+func (h *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.auth = req.Header.Get("Authorization")
+	return h.fixtureTransport.RoundTrip(req)
+}
+
+func TestHooks(t *testing.T) {
+	transport := &headerTransport{fixtureTransport: fixtureTransport{page: `<html><body><h1 class="UnitHeader-titleHeading">widget</h1></body></html>`}}
+	s, err := New(&ScrapingConfig{Transport: transport})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	s.OnBeforeRequest(func(req *Request) { req.Header.Set("Authorization", "Bearer token") })
+	var parsed []string
+	s.OnAfterParse(func(ctx context.Context, pkg *models.Package, rawHTML string) error {
+		parsed = append(parsed, pkg.ImportPath)
+		pkg.Synopsis = "enriched"
+		return nil
+	})
+	pkg, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/widget")
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if transport.auth != "Bearer token" {
+		t.Errorf("Expected the hook's header sent, got %q", transport.auth)
+	}
+	if pkg.Synopsis != "enriched" || len(parsed) != 1 || parsed[0] != "example.com/widget" {
+		t.Errorf("Expected the after-parse hook to run once, got %v and %+v", parsed, pkg)
+	}
+
+	errFiltered := errors.New("filtered")
+	s.OnAfterParse(func(ctx context.Context, pkg *models.Package, rawHTML string) error { return errFiltered })
+	if _, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/gadget"); !errors.Is(err, errFiltered) {
+		t.Errorf("Expected a failing hook to fail the package, got %v", err)
+	}
+
+	s.OnBeforeStore(func(ctx context.Context, doc *models.Document) error {
+		doc.Tags = append(doc.Tags, "mirrored")
+		return nil
+	})
+	doc := &models.Document{ID: "example.com/widget"}
+	if err := s.BeforeStore(context.Background(), doc); err != nil || len(doc.Tags) != 1 {
+		t.Errorf("Expected the before-store hook to tag the document, got %v (%v)", doc.Tags, err)
+	}
+}
@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/parser"
+)
+
+// ErrorLayout counts pages rejected with ErrLayoutChanged in ScrapingStats.ErrorsByClass.
+const ErrorLayout = "layout"
+
+// ErrLayoutChanged marks a page that answered 200 with a full-size body but parsed to a package
+// missing its name, version or any documentation, which usually means pkg.go.dev changed its
+// markup and the selector profile needs updating.
+var ErrLayoutChanged = errors.New("probable pkg.go.dev layout change")
+
+// minLayoutPageSize is the smallest page checked for layout changes; shorter successful responses
+// are placeholders or error pages rather than documentation.
+const minLayoutPageSize = 4 << 10
+
+// MissingCriticalFields lists the critical fields of a parsed package that came out empty:
+// "name", "version" and "symbols". Symbols only count as missing when the package has no
+// overview either and pkg.go.dev did not withhold the docs over the license, so command, doc-only
+// and non-redistributable packages are not flagged, and only when the whole page was parsed.
+func MissingCriticalFields(pkg *models.Package) []string {
+	var missing []string
+	if pkg.Name == "" {
+		missing = append(missing, "name")
+	}
+	if pkg.Version == "" {
+		missing = append(missing, "version")
+	}
+	if pkg.Profile == "" && len(pkg.Constants)+len(pkg.Variables)+len(pkg.Functions)+len(pkg.Types)+len(pkg.Examples) == 0 && pkg.Description == "" && !pkg.LicenseRestricted() {
+		missing = append(missing, "symbols")
+	}
+	return missing
+}
+
+// checkLayout records and logs a probable layout change when a successful, non-trivial page parsed
+// to a package missing critical fields. It returns ErrLayoutChanged (wrapped) with StrictLayout set.
+func (s *Scraper) checkLayout(importPath string, status, size int, pkg *models.Package) error {
+	if status != 200 || size < minLayoutPageSize {
+		return nil
+	}
+	missing := MissingCriticalFields(pkg)
+	if len(missing) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	s.stats.LayoutWarnings = append(s.stats.LayoutWarnings, importPath)
+	s.mu.Unlock()
+	log.Printf("WARNING: %s: %v: no %s found on a %d-byte page; check the selector profile (docinator selectors)",
+		importPath, ErrLayoutChanged, strings.Join(missing, ", "), size)
+	if !s.config.StrictLayout {
+		return nil
+	}
+	s.recordError(ErrorLayout)
+	return fmt.Errorf("%s: %w (no %s)", importPath, ErrLayoutChanged, strings.Join(missing, ", "))
+}
+
+// checkIdentifiers logs the identifiers of the page's Jump to index that were not parsed, which
+// points at a selector that misses some declarations rather than a wholesale layout change.
+func checkIdentifiers(importPath string, pkg *models.Package) {
+	missing := parser.MissingIdentifiers(pkg)
+	if len(missing) == 0 {
+		return
+	}
+	shown := missing
+	if len(shown) > 5 {
+		shown = shown[:5]
+	}
+	log.Printf("WARNING: %s: parsed no declaration for %d of %d identifiers listed by Jump to (%s); check the selector profile",
+		importPath, len(missing), len(pkg.Identifiers), strings.Join(shown, ", "))
+}
+
+// recordExtraction counts the selector fallbacks used on the page of importPath and, with Debug,
+// logs the strategy that found each part of it.
+func (s *Scraper) recordExtraction(importPath string, extraction parser.Extraction) {
+	fallbacks := extraction.Fallbacks()
+	if s.config.Debug {
+		keys := make([]string, 0, len(extraction))
+		for key := range extraction {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			log.Printf("[DEBUG] %s: %s found by %q (strategy %d)", importPath, key, extraction[key].Selector, extraction[key].Index+1)
+		}
+	}
+	if len(fallbacks) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats.SelectorFallbacks == nil {
+		s.stats.SelectorFallbacks = map[string]int{}
+	}
+	for key, st := range fallbacks {
+		s.stats.SelectorFallbacks[key+"="+st.Selector]++
+	}
+}
@@ -0,0 +1,52 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/parser"
+)
+
+// ErrNoReparse is returned by Reparse when a cached package cannot be parsed again from its page.
+var ErrNoReparse = errors.New("cached page cannot be re-parsed")
+
+// ParserFingerprint returns the fingerprint recorded on the packages this scraper extracts from
+// pkg.go.dev (see parser.Parser.Fingerprint), or "" when it reads another site or returns mock
+// packages, whose fingerprint cannot be checked.
+func (s *Scraper) ParserFingerprint() string {
+	if s.config.Site != nil || s.config.TestMode {
+		return ""
+	}
+	return s.parser.Fingerprint()
+}
+
+// Reparse extracts prev again from rawHTML, the pkg.go.dev page it was cached with, using the
+// current parser and selector profile and without fetching anything. The scrape time and what
+// enrichers stored in fields of their own (importers, summary, guides) are kept from prev.
+// ErrNoReparse is returned without a page or when the scraper reads another site. The sections
+// parsed are those of prev's profile, whatever the scraper's. The AfterParseHooks run on the
+// result as on scraped packages.
+func (s *Scraper) Reparse(prev *models.Package, rawHTML string) (*models.Package, error) {
+	if s.ParserFingerprint() == "" || strings.TrimSpace(rawHTML) == "" {
+		return nil, ErrNoReparse
+	}
+	pkg, err := s.parser.WithProfile(parser.Profile(prev.Profile)).ParseHTML(strings.NewReader(rawHTML))
+	if err != nil {
+		s.recordError(ErrorParse)
+		return nil, fmt.Errorf("failed to parse cached page: %w", err)
+	}
+	pkg.ImportPath = prev.ImportPath
+	if pkg.Version == "" {
+		pkg.Version = prev.Version
+	}
+	pkg.ScrapedAt = prev.ScrapedAt
+	pkg.Importers, pkg.ImportList, pkg.Summary, pkg.Guides = prev.Importers, prev.ImportList, prev.Summary, prev.Guides
+	checkIdentifiers(pkg.ImportPath, pkg)
+	if err := s.afterParse(context.Background(), pkg, rawHTML); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
@@ -2,8 +2,11 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	neturl "net/url"
 	"strings"
 	"sync"
 	"time"
@@ -11,16 +14,61 @@ import (
 	"github.com/gocolly/colly/v2"
 	"github.com/moseye/docinator/internal/models"
 	"github.com/moseye/docinator/pkg/parser"
+	"github.com/moseye/docinator/pkg/siteprofile"
 )
 
 // ScrapingConfig holds configuration for the scraper
 type ScrapingConfig struct {
 	MaxConcurrency int           // Maximum concurrent requests
 	Delay          time.Duration // Delay between requests
+	RandomDelay    time.Duration // Up to this much extra delay, drawn per request, so requests do not arrive at a fixed interval
 	Timeout        time.Duration // Request timeout
 	UserAgent      string        // User agent string
 	Debug          bool          // Enable debug logging
 	TestMode       bool          // Enable test mode for mock data
+	CacheDir       string        // Directory caching GET responses on disk; empty disables the cache
+	MaxRetries     int           // Retries of rate-limited (429), 5xx and timed-out requests
+
+	// Transport performs the HTTP requests; nil builds one with NewTransport. Set it to record or
+	// replay traffic, add authentication, or serve fixtures in tests. Responses served from
+	// CacheDir never reach it.
+	Transport http.RoundTripper
+	// Connection pooling of the transport built when Transport is nil. One pool serves every
+	// page, tab and retry of the scraper, so a batch handshakes with each host once.
+	MaxIdleConnsPerHost int           // idle keep-alive connections kept per host; 0 uses DefaultMaxIdleConnsPerHost
+	MaxConnsPerHost     int           // connections per host, in use or idle; 0 means no limit
+	IdleConnTimeout     time.Duration // how long an idle connection stays open; 0 uses 90 seconds
+	DisableHTTP2        bool          // speak HTTP/1.1 only
+	// MaxResponseSize drops responses with larger bodies, in bytes; 0 uses
+	// DefaultMaxResponseSize and a negative value disables the limit.
+	MaxResponseSize int64
+	// ContentTypes are the media types of successful responses that are parsed; others are
+	// dropped unread. Nil accepts text/html and application/xhtml+xml.
+	ContentTypes []string
+	// Limiter paces requests that go out to the network, on top of Delay; nil disables it. Use a
+	// shared limiter (see package ratelimit) to keep several workers within one request rate.
+	Limiter RateLimiter
+	// Selectors locate the parts of pkg.go.dev pages; nil uses the parser's embedded profile.
+	Selectors *parser.Selectors
+	// Profile limits which sections of pkg.go.dev pages are parsed. Packages scraped with a limited
+	// profile come without their raw page, which is most of what a cached document weighs.
+	Profile parser.Profile
+	// StrictLayout fails packages whose page looks like a pkg.go.dev layout change (see
+	// ErrLayoutChanged) instead of returning them with a warning.
+	StrictLayout bool
+	// Site scrapes another documentation site by the rules of a site profile instead of
+	// pkg.go.dev; the arguments are then page paths or URLs on that site. Nil scrapes pkg.go.dev.
+	Site *siteprofile.Profile
+	// API is the base URL of a pkgsite JSON API, such as https://pkg.go.dev or a private pkgsite.
+	// When set, the module, version, synopsis, license and imports of a package come from it,
+	// fetched alongside the page, and the Imports and Imported By tabs are read from it; the page
+	// only supplies the documentation. Whatever the API fails to serve falls back to HTML.
+	API string
+}
+
+// RateLimiter blocks until the next request may be made or ctx is done.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
 }
 
 // DefaultConfig returns a sensible default configuration
@@ -28,10 +76,12 @@ func DefaultConfig() *ScrapingConfig {
 	return &ScrapingConfig{
 		MaxConcurrency: 2,                // Respectful concurrency
 		Delay:          2 * time.Second,  // 2 second delay between requests
+		RandomDelay:    1 * time.Second,  // plus up to 1 second of jitter
 		Timeout:        30 * time.Second, // 30 second timeout
 		UserAgent:      "docinator-scraper/1.0 (+https://github.com/moseye/docinator)",
 		Debug:          false,
 		TestMode:       false,
+		MaxRetries:     2,
 	}
 }
 
@@ -40,8 +90,15 @@ type Scraper struct {
 	config    *ScrapingConfig
 	collector *colly.Collector
 	parser    *parser.Parser
+	transport *http.Transport // built by New when config.Transport is nil; closed by Close
 	mu        sync.RWMutex
 	stats     ScrapingStats
+	hooks     hooks
+
+	responses        int              // responses of any status, including those served from the HTTP cache
+	networkResponses int              // responses that crossed the network
+	notModified      int              // network responses with status 304
+	latencies        latencyReservoir // durations of the network requests, until their body was read
 }
 
 // ScrapingStats tracks scraping statistics
@@ -49,7 +106,45 @@ type ScrapingStats struct {
 	PackagesScraped int
 	RequestsMade    int
 	Errors          int
+	ErrorsByClass   map[string]int // failed requests by class (ErrorRateLimited, ErrorTimeout, ...)
+	Retries         int
+	CacheHits       int      // responses served from the HTTP cache (CacheDir)
+	CacheMisses     int      // responses fetched over the network
+	NotModified     int      // 304 responses to conditional requests, e.g. from a revalidating Transport
+	LayoutWarnings  []string // import paths whose page looked like a pkg.go.dev layout change
+	BytesDownloaded int64    // response bodies received, excluding responses served from the HTTP cache
+	ConnsOpened     int      // network requests that dialed a new connection
+	ConnsReused     int      // network requests served over a pooled keep-alive connection
+	LatencyAvg      time.Duration
+	LatencyP50      time.Duration
+	LatencyP95      time.Duration
+	LatencyMax      time.Duration
 	StartTime       time.Time
+
+	// SelectorFallbacks counts pages on which a selector profile key was found by a fallback
+	// strategy rather than its primary selector, keyed "key=selector".
+	SelectorFallbacks map[string]int
+	// SkippedResponses lists the URLs of responses dropped for their size or content type.
+	SkippedResponses []string
+}
+
+// retriesKey counts the retries of a request in its colly context; fetchedKey marks a request
+// that got a response, possibly on a retry.
+const (
+	retriesKey = "docinator_retries"
+	fetchedKey = "docinator_fetched"
+)
+
+// visit fetches url with c and waits for it. colly's Visit returns the error of the first
+// attempt even when a retry from OnError then fetched the page, so an error is only returned
+// when no attempt got a response.
+func visit(c *colly.Collector, url string) error {
+	ctx := colly.NewContext()
+	if err := c.Request(http.MethodGet, url, nil, ctx, nil); err != nil && ctx.GetAny(fetchedKey) == nil {
+		return err
+	}
+	c.Wait()
+	return nil
 }
 
 // New creates a new Scraper instance with the given configuration
@@ -59,9 +154,17 @@ func New(config *ScrapingConfig) (*Scraper, error) {
 	}
 
 	// Create collector with proper configuration for v2
+	domains := []string{"pkg.go.dev"}
+	if config.API != "" {
+		u, err := neturl.Parse(config.API)
+		if err != nil || u.Hostname() == "" {
+			return nil, fmt.Errorf("invalid pkgsite API URL %q", config.API)
+		}
+		domains = append(domains, u.Hostname())
+	}
 	c := colly.NewCollector(
 		colly.UserAgent(config.UserAgent),
-		colly.AllowedDomains("pkg.go.dev", "go-colly.org"),
+		colly.AllowedDomains(domains...),
 	)
 
 	// Set up rate limiting
@@ -69,11 +172,20 @@ func New(config *ScrapingConfig) (*Scraper, error) {
 		DomainGlob:  "*",
 		Parallelism: config.MaxConcurrency,
 		Delay:       config.Delay,
+		RandomDelay: config.RandomDelay,
 	})
 
 	// Set timeout
 	c.SetRequestTimeout(config.Timeout)
 
+	// The transport enforces MaxResponseSize, failing the request instead of truncating the body
+	c.MaxBodySize = 0
+
+	// Serve repeated GET requests (other tabs, retried batches, later runs) from disk
+	if config.CacheDir != "" {
+		c.CacheDir = config.CacheDir
+	}
+
 	// Enable debug if requested
 	if config.Debug {
 		c.OnRequest(func(r *colly.Request) {
@@ -82,7 +194,7 @@ func New(config *ScrapingConfig) (*Scraper, error) {
 	}
 
 	// Create parser instance
-	p := parser.New()
+	p := parser.NewWithSelectors(config.Selectors).WithProfile(config.Profile)
 
 	scraper := &Scraper{
 		config:    config,
@@ -93,16 +205,32 @@ func New(config *ScrapingConfig) (*Scraper, error) {
 		},
 	}
 
+	// Meter what crosses the network, as opposed to what the HTTP cache serves
+	base := config.Transport
+	if base == nil {
+		scraper.transport = NewTransport(config)
+		base = scraper.transport
+	}
+	c.WithTransport(&meteredTransport{base: base, limiter: config.Limiter, s: scraper})
+
 	// Set up event handlers
-	scraper.setupEventHandlers()
+	scraper.setupEventHandlers(c)
 
 	return scraper, nil
 }
 
-// setupEventHandlers configures the collector's event handlers
-func (s *Scraper) setupEventHandlers() {
+// clone returns a copy of the collector for one visit, with the event handlers that count,
+// classify and retry its requests. colly does not copy callbacks to clones.
+func (s *Scraper) clone() *colly.Collector {
+	c := s.collector.Clone()
+	s.setupEventHandlers(c)
+	return c
+}
+
+// setupEventHandlers configures the event handlers of collector c
+func (s *Scraper) setupEventHandlers(c *colly.Collector) {
 	// Track requests
-	s.collector.OnRequest(func(r *colly.Request) {
+	c.OnRequest(func(r *colly.Request) {
 		s.mu.Lock()
 		s.stats.RequestsMade++
 		s.mu.Unlock()
@@ -110,84 +238,191 @@ func (s *Scraper) setupEventHandlers() {
 		if s.config.Debug {
 			log.Printf("Visiting: %s", r.URL.String())
 		}
+		s.beforeRequest(r)
 	})
 
-	// Track errors
-	s.collector.OnError(func(r *colly.Response, err error) {
-		s.mu.Lock()
-		s.stats.Errors++
-		s.mu.Unlock()
+	// Track errors, retrying the transient ones
+	c.OnError(func(r *colly.Response, err error) {
+		class := errorClass(r.StatusCode, err)
+		s.recordError(class)
+		if r.StatusCode != 0 {
+			s.mu.Lock()
+			s.responses++
+			s.mu.Unlock()
+		}
 
+		retries, _ := r.Ctx.GetAny(retriesKey).(int)
+		if retryable(class) && retries < s.config.MaxRetries {
+			r.Ctx.Put(retriesKey, retries+1)
+			s.mu.Lock()
+			s.stats.Retries++
+			s.mu.Unlock()
+			log.Printf("Request error for %s (%s): %v; retrying (%d/%d)", r.Request.URL, class, err, retries+1, s.config.MaxRetries)
+			r.Request.Retry()
+			return
+		}
 		log.Printf("Request error for %s: %v", r.Request.URL, err)
 	})
 
 	// Log successful responses
-	s.collector.OnResponse(func(r *colly.Response) {
+	c.OnResponse(func(r *colly.Response) {
+		r.Ctx.Put(fetchedKey, true)
+		s.mu.Lock()
+		s.responses++
+		s.mu.Unlock()
+
 		if s.config.Debug {
 			log.Printf("Response received from %s: %d", r.Request.URL, r.StatusCode)
 		}
 	})
 }
 
-// ScrapePackageWithRaw scrapes a Go package from pkg.go.dev and returns both structured data and raw HTML
+// Timing splits the time ScrapePackageTimed spent on one package page.
+type Timing struct {
+	Fetch time.Duration // requesting and downloading the page, including retries
+	Parse time.Duration // extracting the package from the page
+}
+
+// ScrapePackageWithRaw scrapes a Go package from pkg.go.dev and returns both structured data and raw HTML.
+// A pinned "importPath@version" scrapes that version; the version is not part of the returned ImportPath.
 func (s *Scraper) ScrapePackageWithRaw(ctx context.Context, importPath string) (*models.Package, string, error) {
+	pkg, rawHTML, _, err := s.ScrapePackageTimed(ctx, importPath)
+	return pkg, rawHTML, err
+}
+
+// ScrapePackageTimed is ScrapePackageWithRaw that also reports how long fetching and parsing took.
+func (s *Scraper) ScrapePackageTimed(ctx context.Context, importPath string) (*models.Package, string, Timing, error) {
+	pkg, rawHTML, _, timing, err := s.scrapePackage(ctx, importPath)
+	return pkg, rawHTML, timing, err
+}
+
+// scrapePackage implements ScrapePackageTimed, also returning what the pkgsite API served for the
+// package, or nil when ScrapingConfig.API is unset or failed.
+func (s *Scraper) scrapePackage(ctx context.Context, importPath string) (*models.Package, string, *apiPackage, Timing, error) {
+	var timing Timing
 	if strings.TrimSpace(importPath) == "" {
-		return nil, "", fmt.Errorf("import path cannot be empty")
+		return nil, "", nil, timing, fmt.Errorf("import path cannot be empty")
+	}
+	path, version, _ := strings.Cut(strings.TrimSpace(importPath), "@")
+	if s.config.Site == nil && version != "" {
+		v, err := CheckVersion(version)
+		if err != nil {
+			return nil, "", nil, timing, err
+		}
+		version, importPath = v, path+"@"+v
 	}
 
 	log.Printf("ScrapePackageWithRaw called for %s, TestMode: %v", importPath, s.config.TestMode)
 	if s.config.TestMode {
 		log.Printf("Returning mock package for %s", importPath)
-		mockPkg := s.mockPackage(importPath)
+		mockPkg := s.config.Profile.Trim(s.mockPackage(path))
+		if version != "" {
+			mockPkg.Version = version
+		}
 		mockHTML := fmt.Sprintf(`<!DOCTYPE html><html><head><title>%s package - Go Packages</title></head><body><h1>%s</h1><p>%s</p><p>Mock HTML content for testing</p></body></html>`, mockPkg.Name, mockPkg.Name, mockPkg.Description)
-		return mockPkg, mockHTML, nil
+		if err := s.afterParse(ctx, mockPkg, mockHTML); err != nil {
+			return nil, "", nil, timing, err
+		}
+		if s.config.Profile != parser.ProfileFull {
+			mockHTML = ""
+		}
+		return mockPkg, mockHTML, nil, timing, nil
+	}
+	if s.config.Site != nil {
+		pkg, rawHTML, timing, err := s.scrapeSite(ctx, s.config.Site, strings.TrimSpace(importPath))
+		if err == nil {
+			s.mu.Lock()
+			s.stats.PackagesScraped++
+			s.mu.Unlock()
+		}
+		return pkg, rawHTML, nil, timing, err
 	}
 
 	// Construct the URL for the package
-	url := fmt.Sprintf("https://pkg.go.dev/%s", strings.TrimSpace(importPath))
+	url, err := PackageURL(importPath)
+	if err != nil {
+		return nil, "", nil, timing, err
+	}
 
 	var pkg *models.Package
 	var rawHTML string
 	var scrapeErr error
 
 	// Set up HTML parsing for the package page
-	c := s.collector.Clone()
+	c := s.clone()
+	if ctx != nil {
+		c.Context = ctx
+	}
 
+	start := time.Now()
 	c.OnHTML("html", func(e *colly.HTMLElement) {
+		parseStart := time.Now()
+		defer func() { timing.Parse += time.Since(parseStart) }()
+
 		// Capture raw HTML content
 		rawHTML, _ = e.DOM.Html()
 
 		// Parse structured data
+		var extraction parser.Extraction
 		var err error
-		pkg, err = s.parser.ParsePackagePage(e)
+		pkg, extraction, err = s.parser.ParsePackagePageWithExtraction(e)
 		if err != nil {
 			scrapeErr = fmt.Errorf("failed to parse package page: %w", err)
+			s.recordError(ErrorParse)
 			return
 		}
 
 		// Set the import path from our parameter
-		pkg.ImportPath = importPath
+		pkg.ImportPath = path
+		if version != "" && pkg.Version == "" {
+			pkg.Version = version
+		}
 		pkg.ScrapedAt = time.Now()
+		s.recordExtraction(path, extraction)
+
+		if err := s.checkLayout(path, e.Response.StatusCode, len(e.Response.Body), pkg); err != nil {
+			scrapeErr = err
+			return
+		}
+		checkIdentifiers(path, pkg)
 
 		if s.config.Debug {
 			log.Printf("Successfully parsed package: %s", pkg.ImportPath)
 		}
 	})
 
-	// Visit the package URL
-	if err := c.Visit(url); err != nil {
-		return nil, "", fmt.Errorf("failed to visit %s: %w", url, err)
+	// Visit the package URL, and fetch the package from the API alongside
+	var api *apiPackage
+	var visitErr, apiErr error
+	fetch := func() { visitErr = visit(c, url) }
+	if s.config.API != "" {
+		concurrently(fetch, func() { api, apiErr = s.scrapeAPIPackage(ctx, importPath) })
+	} else {
+		fetch()
 	}
-
-	// Wait for the collector to finish
-	c.Wait()
+	if visitErr != nil {
+		return nil, "", nil, timing, fmt.Errorf("failed to visit %s: %w", url, visitErr)
+	}
+	timing.Fetch = time.Since(start) - timing.Parse
 
 	if scrapeErr != nil {
-		return nil, "", scrapeErr
+		return nil, "", nil, timing, scrapeErr
 	}
 
 	if pkg == nil {
-		return nil, "", fmt.Errorf("no package data found for %s", importPath)
+		return nil, "", nil, timing, fmt.Errorf("no package data found for %s", importPath)
+	}
+	if apiErr != nil {
+		apiFallback(importPath, apiErr)
+	} else if api != nil {
+		mergeAPI(pkg, api)
+	}
+	if err := s.afterParse(ctx, pkg, rawHTML); err != nil {
+		return nil, "", nil, timing, err
+	}
+	if s.config.Profile != parser.ProfileFull {
+		// The hooks have seen the page; a limited crawl does not keep it.
+		rawHTML = ""
 	}
 
 	// Update statistics
@@ -195,7 +430,97 @@ func (s *Scraper) ScrapePackageWithRaw(ctx context.Context, importPath string) (
 	s.stats.PackagesScraped++
 	s.mu.Unlock()
 
-	return pkg, rawHTML, nil
+	return pkg, rawHTML, api, timing, nil
+}
+
+// ScrapeImporters returns up to limit import paths listed on the package's "Imported By" tab, or
+// served by the pkgsite API when ScrapingConfig.API is set.
+func (s *Scraper) ScrapeImporters(ctx context.Context, importPath string, limit int) ([]string, error) {
+	if strings.TrimSpace(importPath) == "" {
+		return nil, fmt.Errorf("import path cannot be empty")
+	}
+
+	if s.config.TestMode {
+		importers := []string{"github.com/example/cli", "github.com/example/tool"}
+		if limit > 0 && len(importers) > limit {
+			importers = importers[:limit]
+		}
+		return importers, nil
+	}
+
+	if s.config.API != "" {
+		importers, err := s.scrapeAPIImporters(ctx, importPath, limit)
+		if err == nil {
+			return importers, nil
+		}
+		apiFallback(importPath, err)
+	}
+
+	importers, err := s.scrapeTab(ctx, importPath, "importedby", func(e *colly.HTMLElement) []string {
+		return s.parser.ParseImporters(e, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.config.Debug {
+		log.Printf("Found %d importers for %s", len(importers), importPath)
+	}
+	return importers, nil
+}
+
+// ScrapeImports returns the import paths listed on the package's "Imports" tab: the standard
+// library first, then packages of other modules and of its own module. With ScrapingConfig.API
+// set they come from the API, in its order.
+func (s *Scraper) ScrapeImports(ctx context.Context, importPath string) ([]string, error) {
+	if strings.TrimSpace(importPath) == "" {
+		return nil, fmt.Errorf("import path cannot be empty")
+	}
+
+	if s.config.TestMode {
+		return []string{"fmt", "os", "github.com/spf13/pflag", "github.com/inconshreveable/mousetrap"}, nil
+	}
+
+	if s.config.API != "" {
+		api, err := s.scrapeAPIPackage(ctx, importPath)
+		if err == nil && api.Imports != nil {
+			return api.Imports, nil
+		}
+		if err != nil {
+			apiFallback(importPath, err)
+		}
+	}
+
+	imports, err := s.scrapeTab(ctx, importPath, "imports", s.parser.ParseImports)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.Debug {
+		log.Printf("Found %d imports for %s", len(imports), importPath)
+	}
+	return imports, nil
+}
+
+// scrapeTab visits a tab of the package page and returns what parse extracts from it.
+func (s *Scraper) scrapeTab(ctx context.Context, importPath, tab string, parse func(*colly.HTMLElement) []string) ([]string, error) {
+	url, err := PackageURL(importPath)
+	if err != nil {
+		return nil, err
+	}
+	url += "?tab=" + tab
+
+	var paths []string
+	c := s.clone()
+	if ctx != nil {
+		c.Context = ctx
+	}
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		paths = parse(e)
+	})
+
+	if err := visit(c, url); err != nil {
+		return nil, fmt.Errorf("failed to visit %s: %w", url, err)
+	}
+	return paths, nil
 }
 
 // ScrapePackage scrapes a Go package from pkg.go.dev and returns structured data (backward compatibility)
@@ -204,108 +529,150 @@ func (s *Scraper) ScrapePackage(ctx context.Context, importPath string) (*models
 	return pkg, err
 }
 
-// ScrapePackages scrapes multiple packages concurrently
+// ScrapePackages scrapes multiple packages concurrently. It returns every package that could be
+// scraped and, when some could not, the failures joined with errors.Join: one *PathError per
+// failed import path, which PathErrors lists. Use ScrapePackagesStream to handle each package
+// and error as it completes.
 func (s *Scraper) ScrapePackages(ctx context.Context, importPaths []string) ([]*models.Package, error) {
-	if len(importPaths) == 0 {
-		return nil, fmt.Errorf("no import paths provided")
+	results, err := s.ScrapePackagesStream(ctx, importPaths)
+	if err != nil {
+		return nil, err
 	}
 
-	if s.config.TestMode {
-		// Sequential processing for tests to avoid concurrency issues
-		var packages []*models.Package
-		var errors []error
-		for _, importPath := range importPaths {
-			select {
-			case <-ctx.Done():
-				break
-			default:
-			}
-			pkg, err := s.ScrapePackage(ctx, importPath)
-			if err != nil {
-				errors = append(errors, fmt.Errorf("failed to scrape %s: %w", importPath, err))
-			} else {
-				packages = append(packages, pkg)
-			}
+	packages := make([]*models.Package, 0, len(importPaths))
+	var errs []error
+	for res := range results {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+			continue
 		}
-		if len(errors) > 0 {
-			for _, err := range errors {
-				log.Printf("Scraping error: %v", err)
-			}
-			return packages, errors[0]
+		packages = append(packages, res.Package)
+	}
+	return packages, errors.Join(errs...)
+}
+
+// PathError records the import path whose scrape failed with Err.
+type PathError struct {
+	ImportPath string
+	Err        error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("failed to scrape %s: %v", e.ImportPath, e.Err)
+}
+
+func (e *PathError) Unwrap() error { return e.Err }
+
+// PathErrors returns the PathErrors in err, which may join several of them as ScrapePackages
+// does, in order.
+func PathErrors(err error) []*PathError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []*PathError
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, PathErrors(e)...)
 		}
-		return packages, nil
+		return errs
 	}
+	var pathErr *PathError
+	if errors.As(err, &pathErr) {
+		return []*PathError{pathErr}
+	}
+	return nil
+}
 
-	packages := make([]*models.Package, 0, len(importPaths))
-	var mu sync.Mutex
-	var wg sync.WaitGroup
+// Result is one package of a batch scraped with ScrapePackagesStream.
+type Result struct {
+	ImportPath string          // as requested
+	Package    *models.Package // nil when Err is set
+	RawHTML    string
+	Err        error
+}
 
-	// Use a channel to limit concurrency
-	semaphore := make(chan struct{}, s.config.MaxConcurrency)
+// ScrapePackagesStream scrapes importPaths with up to MaxConcurrency requests in flight (one at a
+// time in test mode) and sends each result, failed or not, as soon as it completes. The channel is
+// closed after the last result. Once ctx is done, packages not yet started are skipped; callers
+// that stop reading early must cancel ctx so the workers can exit.
+func (s *Scraper) ScrapePackagesStream(ctx context.Context, importPaths []string) (<-chan Result, error) {
+	if len(importPaths) == 0 {
+		return nil, fmt.Errorf("no import paths provided")
+	}
 
-	// Collect errors
-	errors := make([]error, 0)
-	var errMu sync.Mutex
+	workers := max(s.config.MaxConcurrency, 1)
+	if s.config.TestMode {
+		// Sequential processing for tests to avoid concurrency issues
+		workers = 1
+	}
 
-	for _, importPath := range importPaths {
+	paths := make(chan string)
+	results := make(chan Result)
+	var wg sync.WaitGroup
+	for range min(workers, len(importPaths)) {
 		wg.Add(1)
-		go func(path string) {
+		go func() {
 			defer wg.Done()
-
-			// Acquire semaphore
-			select {
-			case semaphore <- struct{}{}:
-			case <-ctx.Done():
-				return
+			for path := range paths {
+				res := Result{ImportPath: path}
+				res.Package, res.RawHTML, res.Err = s.ScrapePackageWithRaw(ctx, path)
+				if res.Err != nil {
+					res.Err = &PathError{ImportPath: path, Err: res.Err}
+				}
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
 			}
-			defer func() { <-semaphore }()
+		}()
+	}
 
-			// Check context cancellation
+	go func() {
+		defer close(paths)
+		for _, path := range importPaths {
 			select {
+			case paths <- path:
 			case <-ctx.Done():
 				return
-			default:
 			}
-
-			pkg, err := s.ScrapePackage(ctx, path)
-			if err != nil {
-				errMu.Lock()
-				errors = append(errors, fmt.Errorf("failed to scrape %s: %w", path, err))
-				errMu.Unlock()
-				return
-			}
-
-			mu.Lock()
-			packages = append(packages, pkg)
-			mu.Unlock()
-		}(importPath)
-	}
-
-	wg.Wait()
-
-	if len(errors) > 0 {
-		// Return the first error, but log all errors
-		for _, err := range errors {
-			log.Printf("Scraping error: %v", err)
 		}
-		return packages, errors[0]
-	}
-
-	return packages, nil
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results, nil
 }
 
 // GetStats returns current scraping statistics
 func (s *Scraper) GetStats() ScrapingStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.stats
+	stats := s.stats
+	stats.ErrorsByClass = make(map[string]int, len(s.stats.ErrorsByClass))
+	for class, n := range s.stats.ErrorsByClass {
+		stats.ErrorsByClass[class] = n
+	}
+	stats.LayoutWarnings = append([]string(nil), s.stats.LayoutWarnings...)
+	stats.SkippedResponses = append([]string(nil), s.stats.SkippedResponses...)
+	stats.SelectorFallbacks = make(map[string]int, len(s.stats.SelectorFallbacks))
+	for key, n := range s.stats.SelectorFallbacks {
+		stats.SelectorFallbacks[key] = n
+	}
+	stats.CacheHits = max(s.responses-s.networkResponses, 0)
+	stats.CacheMisses = s.networkResponses
+	stats.NotModified = s.notModified
+	latencyStats(&stats, &s.latencies)
+	return stats
 }
 
 // Close cleans up the scraper resources
 func (s *Scraper) Close() error {
 	// Colly doesn't require explicit cleanup, but we can clear internal state
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
+	}
 	s.mu.Lock()
 	s.stats = ScrapingStats{StartTime: time.Now()}
+	s.responses, s.networkResponses, s.notModified, s.latencies = 0, 0, 0, latencyReservoir{}
 	s.mu.Unlock()
 
 	return nil
@@ -356,6 +723,9 @@ func ExtractImportPath(url string) (string, error) {
 	// Remove the base URL to get the import path
 	importPath := strings.TrimPrefix(url, "https://pkg.go.dev/")
 	importPath = strings.TrimSuffix(importPath, "/")
+	if unescaped, err := neturl.PathUnescape(importPath); err == nil {
+		importPath = unescaped
+	}
 
 	if importPath == "" {
 		return "", fmt.Errorf("no import path found in URL")
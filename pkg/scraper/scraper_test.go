@@ -2,6 +2,7 @@ package scraper
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -147,3 +148,93 @@ func TestScrapePackages_Multiple(t *testing.T) {
 	}
 	t.Logf("Successfully scraped %d packages", len(pkgs))
 }
+
+func TestScrapePackages_PathErrors(t *testing.T) {
+	s, err := New(&ScrapingConfig{TestMode: true})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	pkgs, err := s.ScrapePackages(context.Background(), []string{" ", "github.com/spf13/cobra", "\t"})
+	if len(pkgs) != 1 {
+		t.Errorf("Expected the one valid package, got %d", len(pkgs))
+	}
+	pathErrs := PathErrors(err)
+	if len(pathErrs) != 2 {
+		t.Fatalf("Expected an error per failed path, got %v", err)
+	}
+	failed := map[string]bool{pathErrs[0].ImportPath: true, pathErrs[1].ImportPath: true}
+	if !failed[" "] || !failed["\t"] || pathErrs[0].Err == nil {
+		t.Errorf("Expected errors for both blank paths, got %v", err)
+	}
+
+	if _, err := s.ScrapePackages(context.Background(), []string{"github.com/spf13/cobra"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestPackageURL(t *testing.T) {
+	tests := []struct {
+		importPath, want, err string
+	}{
+		{"github.com/spf13/cobra", "https://pkg.go.dev/github.com/spf13/cobra", ""},
+		{"github.com/spf13/cobra@V1.9.1", "https://pkg.go.dev/github.com/spf13/cobra@v1.9.1", ""},
+		{"github.com/spf13/cobra@v1.10.0-rc.1", "https://pkg.go.dev/github.com/spf13/cobra@v1.10.0-rc.1", ""},
+		{"golang.org/x/tools@v0.0.0-20240102150405-abcdef012345", "https://pkg.go.dev/golang.org/x/tools@v0.0.0-20240102150405-abcdef012345", ""},
+		{"github.com/docker/docker@v20.10.7+incompatible", "https://pkg.go.dev/github.com/docker/docker@v20.10.7+incompatible", ""},
+		{"github.com/spf13/cobra@main", "https://pkg.go.dev/github.com/spf13/cobra@main", ""},
+		{"github.com/spf13/cobra@v1.9", "", "not canonical"},
+		{"github.com/spf13/cobra@v1.x.0", "", "invalid version"},
+		{"github.com/spf13/cobra@main?tab=doc", "", "invalid version query"},
+	}
+	for _, tt := range tests {
+		got, err := PackageURL(tt.importPath)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("PackageURL(%q): expected an error containing %q, got %q, %v", tt.importPath, tt.err, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("PackageURL(%q) = %q, %v; want %q", tt.importPath, got, err, tt.want)
+		}
+	}
+}
+
+func TestScrapePackagesStream(t *testing.T) {
+	s, err := New(&ScrapingConfig{TestMode: true})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	results, err := s.ScrapePackagesStream(context.Background(), []string{"github.com/spf13/cobra", " ", "github.com/spf13/viper"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var ok, failed []string
+	for res := range results {
+		if res.Err != nil {
+			failed = append(failed, res.ImportPath)
+			continue
+		}
+		if res.Package == nil || res.RawHTML == "" {
+			t.Errorf("Expected a package and raw HTML for %s", res.ImportPath)
+		}
+		ok = append(ok, res.ImportPath)
+	}
+	if len(ok) != 2 || len(failed) != 1 || failed[0] != " " {
+		t.Errorf("Expected two packages and one failure, got %v and %v", ok, failed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, _ = s.ScrapePackagesStream(ctx, []string{"github.com/spf13/cobra", "github.com/spf13/viper"})
+	for range results {
+	}
+
+	if _, err := s.ScrapePackagesStream(context.Background(), nil); err == nil {
+		t.Error("Expected an error for an empty batch")
+	}
+}
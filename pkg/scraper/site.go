@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/siteprofile"
+)
+
+// ScrapePage scrapes the page of arg, a path or URL, on the site of the given profile regardless
+// of ScrapingConfig.Site. Enrichers use it to pull in documentation kept elsewhere.
+func (s *Scraper) ScrapePage(ctx context.Context, site *siteprofile.Profile, arg string) (*models.Package, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return nil, fmt.Errorf("page cannot be empty")
+	}
+	if s.config.TestMode {
+		return &models.Package{
+			Name:            "Mock " + site.Name + " page",
+			ImportPath:      siteprofile.ImportPath(arg),
+			ProcessedReadme: "Mock page content for testing",
+		}, nil
+	}
+	pkg, _, _, err := s.scrapeSite(ctx, site, arg)
+	return pkg, err
+}
+
+// scrapeSite scrapes the page of arg on the site of a site profile. The pkg.go.dev layout checks
+// do not apply to these pages.
+func (s *Scraper) scrapeSite(ctx context.Context, site *siteprofile.Profile, arg string) (*models.Package, string, Timing, error) {
+	var timing Timing
+	page, err := site.PageURL(arg)
+	if err != nil {
+		return nil, "", timing, err
+	}
+	u, err := url.Parse(page)
+	if err != nil {
+		return nil, "", timing, err
+	}
+
+	var pkg *models.Package
+	var rawHTML string
+	c := s.clone()
+	if ctx != nil {
+		c.Context = ctx
+	}
+	// The profile allowed the host, which may be one of many under a wildcard domain.
+	c.AllowedDomains = slices.Concat(c.AllowedDomains, []string{u.Hostname()})
+
+	start := time.Now()
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		parseStart := time.Now()
+		defer func() { timing.Parse += time.Since(parseStart) }()
+
+		rawHTML, _ = e.DOM.Html()
+		pkg = site.Extract(e.DOM, e.Request.URL.String())
+		pkg.ImportPath = siteprofile.ImportPath(arg)
+		if pkg.Name == "" {
+			pkg.Name = path.Base(pkg.ImportPath)
+		}
+		pkg.ScrapedAt = time.Now()
+		if s.config.Debug {
+			log.Printf("Successfully parsed %s with site profile %s", page, site.Name)
+		}
+	})
+
+	if err := visit(c, page); err != nil {
+		return nil, "", timing, fmt.Errorf("failed to visit %s: %w", page, err)
+	}
+	timing.Fetch = time.Since(start) - timing.Parse
+
+	if pkg == nil {
+		return nil, "", timing, fmt.Errorf("no page found for %s", arg)
+	}
+	if err := s.afterParse(ctx, pkg, rawHTML); err != nil {
+		return nil, "", timing, err
+	}
+	return pkg, rawHTML, timing, nil
+}
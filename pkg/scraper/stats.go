@@ -0,0 +1,187 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"time"
+)
+
+// Error classes counted in ScrapingStats.ErrorsByClass.
+const (
+	ErrorRateLimited = "rate_limited" // HTTP 429
+	ErrorServer      = "http_5xx"
+	ErrorClient      = "http_4xx"
+	ErrorTimeout     = "timeout"
+	ErrorCanceled    = "canceled"
+	ErrorNetwork     = "network" // DNS, connection refused and other transport failures
+	ErrorParse       = "parse"
+)
+
+// errorClass classifies a failed request by its status code (0 when no response arrived) and error.
+func errorClass(status int, err error) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorRateLimited
+	case status >= 500:
+		return ErrorServer
+	case status >= 400:
+		return ErrorClient
+	case errors.Is(err, ErrResponseTooLarge):
+		return ErrorTooLarge
+	case errors.Is(err, ErrContentType):
+		return ErrorContentType
+	case errors.Is(err, context.Canceled):
+		return ErrorCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout
+	}
+	return ErrorNetwork
+}
+
+// retryable reports whether a request failing with class may succeed when repeated.
+func retryable(class string) bool {
+	return class == ErrorRateLimited || class == ErrorServer || class == ErrorTimeout
+}
+
+// percentile returns the p-th percentile (0-100) of sorted durations, or 0 if there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p / 100)
+	return sorted[i]
+}
+
+// latencySamples bounds the request durations kept for the latency percentiles, so a long-lived
+// scraper (serve, watch) does not grow with every request it makes.
+const latencySamples = 1024
+
+// latencyReservoir records request durations in bounded memory: their count, sum and maximum
+// exactly, and a uniform random sample of at most latencySamples of them for the percentiles.
+type latencyReservoir struct {
+	count   int64
+	total   time.Duration
+	max     time.Duration
+	samples []time.Duration
+}
+
+// add records the duration of one request.
+func (r *latencyReservoir) add(d time.Duration) {
+	r.count++
+	r.total += d
+	r.max = max(r.max, d)
+	if len(r.samples) < latencySamples {
+		r.samples = append(r.samples, d)
+	} else if i := rand.Int64N(r.count); i < latencySamples {
+		r.samples[i] = d
+	}
+}
+
+// latencyStats fills in the latency fields of stats from the recorded request durations.
+func latencyStats(stats *ScrapingStats, r *latencyReservoir) {
+	if r.count == 0 {
+		return
+	}
+	sorted := append([]time.Duration(nil), r.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats.LatencyAvg = r.total / time.Duration(r.count)
+	stats.LatencyP50 = percentile(sorted, 50)
+	stats.LatencyP95 = percentile(sorted, 95)
+	stats.LatencyMax = r.max
+}
+
+// meteredTransport records what actually crosses the network, after waiting for the limiter:
+// responses served from the on-disk HTTP cache never reach it.
+type meteredTransport struct {
+	base    http.RoundTripper
+	limiter RateLimiter // optional
+	s       *Scraper
+}
+
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.limiter != nil {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { t.s.recordConn(info.Reused) },
+	}))
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		t.s.recordNotModified()
+	}
+	resp.Body = &meteredBody{ReadCloser: resp.Body, s: t.s, start: start}
+	return t.s.guardResponse(resp)
+}
+
+// meteredBody records the size and duration of a response once its body is closed.
+type meteredBody struct {
+	io.ReadCloser
+	s      *Scraper
+	start  time.Time
+	n      int64
+	closed bool
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *meteredBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.s.recordNetworkResponse(b.n, time.Since(b.start))
+	}
+	return b.ReadCloser.Close()
+}
+
+func (s *Scraper) recordNetworkResponse(bytes int64, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.BytesDownloaded += bytes
+	s.networkResponses++
+	s.latencies.add(d)
+}
+
+func (s *Scraper) recordConn(reused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reused {
+		s.stats.ConnsReused++
+	} else {
+		s.stats.ConnsOpened++
+	}
+}
+
+func (s *Scraper) recordNotModified() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notModified++
+}
+
+func (s *Scraper) recordError(class string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Errors++
+	if s.stats.ErrorsByClass == nil {
+		s.stats.ErrorsByClass = map[string]int{}
+	}
+	s.stats.ErrorsByClass[class]++
+}
@@ -0,0 +1,332 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/pkg/siteprofile"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+		want   string
+	}{
+		{http.StatusTooManyRequests, nil, ErrorRateLimited},
+		{http.StatusBadGateway, nil, ErrorServer},
+		{http.StatusNotFound, nil, ErrorClient},
+		{0, context.DeadlineExceeded, ErrorTimeout},
+		{0, fmt.Errorf("get: %w", context.Canceled), ErrorCanceled},
+		{0, fmt.Errorf("dial tcp: connection refused"), ErrorNetwork},
+	}
+	for _, tt := range tests {
+		if got := errorClass(tt.status, tt.err); got != tt.want {
+			t.Errorf("errorClass(%d, %v): expected %s, got %s", tt.status, tt.err, tt.want, got)
+		}
+	}
+	if retryable(ErrorClient) || !retryable(ErrorRateLimited) {
+		t.Error("Expected only transient classes to be retryable")
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	var latencies latencyReservoir
+	for i := 100; i >= 1; i-- {
+		latencies.add(time.Duration(i) * time.Millisecond)
+	}
+	var stats ScrapingStats
+	latencyStats(&stats, &latencies)
+	if stats.LatencyP50 != 50*time.Millisecond || stats.LatencyP95 != 95*time.Millisecond || stats.LatencyMax != 100*time.Millisecond {
+		t.Errorf("Expected p50 50ms, p95 95ms, max 100ms, got %v %v %v", stats.LatencyP50, stats.LatencyP95, stats.LatencyMax)
+	}
+	if stats.LatencyAvg != 50500*time.Microsecond {
+		t.Errorf("Expected an average of 50.5ms, got %v", stats.LatencyAvg)
+	}
+
+	// Past latencySamples requests the sample stays bounded; the average and maximum stay exact.
+	for i := 0; i < 10*latencySamples; i++ {
+		latencies.add(time.Millisecond)
+	}
+	latencies.add(time.Second)
+	latencyStats(&stats, &latencies)
+	if len(latencies.samples) != latencySamples || stats.LatencyMax != time.Second {
+		t.Errorf("Expected %d samples and a max of 1s, got %d and %v", latencySamples, len(latencies.samples), stats.LatencyMax)
+	}
+	if want := (5050*time.Millisecond + 10*latencySamples*time.Millisecond + time.Second) / time.Duration(100+10*latencySamples+1); stats.LatencyAvg != want {
+		t.Errorf("Expected an average of %v, got %v", want, stats.LatencyAvg)
+	}
+}
+
+func TestMeteredTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "hello, world")
+	}))
+	defer srv.Close()
+
+	s := &Scraper{config: DefaultConfig()}
+	client := &http.Client{Transport: &meteredTransport{base: http.DefaultTransport, s: s}}
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if i == 2 {
+			req.Header.Set("If-None-Match", `"v1"`)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	s.responses = 4 // one more served by the HTTP cache
+
+	stats := s.GetStats()
+	if stats.BytesDownloaded != 24 {
+		t.Errorf("Expected 24 bytes downloaded, got %d", stats.BytesDownloaded)
+	}
+	if stats.CacheHits != 1 || stats.CacheMisses != 3 {
+		t.Errorf("Expected 1 cache hit and 3 misses, got %d and %d", stats.CacheHits, stats.CacheMisses)
+	}
+	if stats.NotModified != 1 {
+		t.Errorf("Expected 1 not modified response, got %d", stats.NotModified)
+	}
+	if stats.LatencyMax <= 0 {
+		t.Error("Expected request latencies to be recorded")
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "hello, world")
+	}))
+	defer srv.Close()
+
+	transport := NewTransport(&ScrapingConfig{MaxConnsPerHost: 4, DisableHTTP2: true})
+	defer transport.CloseIdleConnections()
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || transport.MaxConnsPerHost != 4 || transport.ForceAttemptHTTP2 {
+		t.Errorf("Expected the configured pool limits without HTTP/2, got %d idle, %d per host, HTTP/2 %v",
+			transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost, transport.ForceAttemptHTTP2)
+	}
+
+	s := &Scraper{config: DefaultConfig()}
+	client := &http.Client{Transport: &meteredTransport{base: transport, s: s}}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if stats := s.GetStats(); stats.ConnsOpened != 1 || stats.ConnsReused != 2 {
+		t.Errorf("Expected one connection reused by the later requests, got %d opened and %d reused", stats.ConnsOpened, stats.ConnsReused)
+	}
+}
+
+// fixtureTransport answers every request with a fixed page, recording the requested URLs.
+type fixtureTransport struct {
+	page string
+	urls []string
+}
+
+func (f *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.urls = append(f.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       io.NopCloser(strings.NewReader(f.page)),
+		Request:    req,
+	}, nil
+}
+
+func TestCustomTransport(t *testing.T) {
+	transport := &fixtureTransport{page: `<html><body><h1 class="UnitHeader-titleHeading">widget</h1></body></html>`}
+	s, err := New(&ScrapingConfig{Transport: transport})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	pkg, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/widget")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pkg.Name != "widget" || pkg.ImportPath != "example.com/widget" {
+		t.Errorf("Expected the fixture page to be parsed, got %+v", pkg)
+	}
+	if len(transport.urls) != 1 || transport.urls[0] != "https://pkg.go.dev/example.com/widget" {
+		t.Errorf("Expected one request through the transport, got %v", transport.urls)
+	}
+	if stats := s.GetStats(); stats.BytesDownloaded != int64(len(transport.page)) {
+		t.Errorf("Expected injected responses to be metered, got %d bytes", stats.BytesDownloaded)
+	}
+}
+
+// flakyTransport answers the first failures requests with 503 Service Unavailable and the later
+// ones with page, recording the requested URLs.
+type flakyTransport struct {
+	page     string
+	failures int
+	urls     []string
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.urls = append(f.urls, req.URL.String())
+	status, body := http.StatusOK, f.page
+	if f.failures > 0 {
+		f.failures--
+		status, body = http.StatusServiceUnavailable, ""
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestRetriedRequestSucceeds(t *testing.T) {
+	transport := &flakyTransport{page: `<html><body><h1 class="UnitHeader-titleHeading">widget</h1></body></html>`, failures: 1}
+	s, err := New(&ScrapingConfig{MaxRetries: 2})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+	s.collector.WithTransport(&meteredTransport{base: transport, s: s})
+
+	pkg, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/widget")
+	if err != nil {
+		t.Fatalf("Expected the retry to recover the page, got %v", err)
+	}
+	if pkg.Name != "widget" {
+		t.Errorf("Expected the retried page to be parsed, got %+v", pkg)
+	}
+	if stats := s.GetStats(); stats.Retries != 1 || len(transport.urls) != 2 {
+		t.Errorf("Expected one retry and two requests, got %d retries and %v", stats.Retries, transport.urls)
+	}
+
+	// Without retries left the failure still fails the package.
+	transport.failures, transport.urls = 3, nil
+	if _, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/gadget"); err == nil {
+		t.Error("Expected a request failing every attempt to fail the package")
+	}
+}
+
+func TestResponseGuards(t *testing.T) {
+	binary := &fixtureTransport{page: "\x00\x01\x02"}
+	s, err := New(&ScrapingConfig{Transport: &contentTypeTransport{RoundTripper: binary, contentType: "application/octet-stream"}})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+	if _, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/widget"); err == nil {
+		t.Error("Expected a binary response to fail")
+	}
+	stats := s.GetStats()
+	if stats.ErrorsByClass[ErrorContentType] != 1 || len(stats.SkippedResponses) != 1 || stats.SkippedResponses[0] != "https://pkg.go.dev/example.com/widget" {
+		t.Errorf("Expected the response to be skipped for its content type, got %v %v", stats.ErrorsByClass, stats.SkippedResponses)
+	}
+
+	huge := &fixtureTransport{page: "<html><body>" + strings.Repeat("<p>text</p>", 1000) + "</body></html>"}
+	s, err = New(&ScrapingConfig{Transport: huge, MaxResponseSize: 1 << 10})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+	if _, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/widget"); err == nil {
+		t.Error("Expected a response over MaxResponseSize to fail")
+	}
+	if stats := s.GetStats(); stats.ErrorsByClass[ErrorTooLarge] != 1 || len(stats.SkippedResponses) != 1 {
+		t.Errorf("Expected the response to be skipped for its size, got %v %v", stats.ErrorsByClass, stats.SkippedResponses)
+	}
+}
+
+// contentTypeTransport overrides the Content-Type of every response.
+type contentTypeTransport struct {
+	http.RoundTripper
+	contentType string
+}
+
+func (c *contentTypeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.RoundTripper.RoundTrip(req)
+	if err == nil {
+		resp.Header.Set("Content-Type", c.contentType)
+	}
+	return resp, err
+}
+
+func TestLayoutChangeDetection(t *testing.T) {
+	// A full-size page on which none of the selectors match.
+	page := `<html><body><div class="NewHeader">widget</div>` + strings.Repeat("<p>text</p>", 1000) + `</body></html>`
+
+	s, err := New(&ScrapingConfig{Transport: &fixtureTransport{page: page}})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+	if _, _, err := s.ScrapePackageWithRaw(context.Background(), "example.com/widget"); err != nil {
+		t.Fatalf("Expected a warning only, got %v", err)
+	}
+	if got := s.GetStats().LayoutWarnings; len(got) != 1 || got[0] != "example.com/widget" {
+		t.Errorf("Expected a layout warning for example.com/widget, got %v", got)
+	}
+
+	strict, err := New(&ScrapingConfig{Transport: &fixtureTransport{page: page}, StrictLayout: true})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer strict.Close()
+	if _, _, err := strict.ScrapePackageWithRaw(context.Background(), "example.com/widget"); !errors.Is(err, ErrLayoutChanged) {
+		t.Errorf("Expected ErrLayoutChanged, got %v", err)
+	}
+	if n := strict.GetStats().ErrorsByClass[ErrorLayout]; n != 1 {
+		t.Errorf("Expected 1 layout error, got %d", n)
+	}
+
+	// Small pages are not checked.
+	small, err := New(&ScrapingConfig{Transport: &fixtureTransport{page: "<html><body></body></html>"}, StrictLayout: true})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer small.Close()
+	if _, _, err := small.ScrapePackageWithRaw(context.Background(), "example.com/widget"); err != nil {
+		t.Errorf("Expected small pages to pass, got %v", err)
+	}
+}
+
+func TestScrapeSite(t *testing.T) {
+	site, err := siteprofile.Parse([]byte("name: widgets\ndomains: [docs.example.com]\nurl: https://docs.example.com/{path}\nfields: {name: h1}"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	transport := &fixtureTransport{page: `<html><body><h1>widget</h1></body></html>`}
+	s, err := New(&ScrapingConfig{Transport: transport, Site: site, StrictLayout: true})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	pkg, _, err := s.ScrapePackageWithRaw(context.Background(), "guide/widget")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pkg.Name != "widget" || pkg.ImportPath != "guide/widget" {
+		t.Errorf("Expected the page to be parsed by the site profile, got %+v", pkg)
+	}
+	if len(transport.urls) != 1 || transport.urls[0] != "https://docs.example.com/guide/widget" {
+		t.Errorf("Expected one request to the site, got %v", transport.urls)
+	}
+}
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// TabRequest selects the tabs of a package page fetched along with it.
+type TabRequest struct {
+	Imports   bool // the Imports tab
+	Importers int  // up to this many import paths from the Imported By tab; 0 skips the tab
+}
+
+// Tabs holds what the tabs of a TabRequest list. Fields of tabs not requested, or whose fetch
+// failed, are nil.
+type Tabs struct {
+	Imports   []string
+	Importers []string
+}
+
+// ScrapeTabs fetches the requested tabs of importPath concurrently. The requests share the
+// scraper's politeness limits — Delay and MaxConcurrency per domain and the Limiter — so fetching
+// them together overlaps their waits without sending more than those limits allow. Failed tabs
+// are joined into the error; the others are still returned.
+func (s *Scraper) ScrapeTabs(ctx context.Context, importPath string, req TabRequest) (Tabs, error) {
+	var tabs Tabs
+	var importsErr, importersErr error
+	var fetches []func()
+	if req.Imports {
+		fetches = append(fetches, func() {
+			tabs.Imports, importsErr = s.ScrapeImports(ctx, importPath)
+		})
+	}
+	if req.Importers > 0 {
+		fetches = append(fetches, func() {
+			tabs.Importers, importersErr = s.ScrapeImporters(ctx, importPath, req.Importers)
+		})
+	}
+	concurrently(fetches...)
+	if importsErr != nil {
+		importsErr = fmt.Errorf("imports tab: %w", importsErr)
+	}
+	if importersErr != nil {
+		importersErr = fmt.Errorf("importedby tab: %w", importersErr)
+	}
+	return tabs, errors.Join(importsErr, importersErr)
+}
+
+// ScrapePackageTabs is ScrapePackageTimed that fetches the requested tabs concurrently with the
+// package page, instead of one request after the other, and records the imports and importers on
+// the package. Only the page can fail the call: a failed tab is logged and leaves its field nil.
+// Sites other than pkg.go.dev have no tabs; req is ignored for them. With ScrapingConfig.API set,
+// the imports come from the same API response as the package's metadata.
+func (s *Scraper) ScrapePackageTabs(ctx context.Context, importPath string, req TabRequest) (*models.Package, string, Tabs, Timing, error) {
+	var tabs Tabs
+	if s.config.Site != nil || req == (TabRequest{}) {
+		pkg, rawHTML, timing, err := s.ScrapePackageTimed(ctx, importPath)
+		return pkg, rawHTML, tabs, timing, err
+	}
+	// The API serves the imports with the package, so the tab is only fetched when it does not.
+	tabReq := req
+	if s.config.API != "" {
+		tabReq.Imports = false
+	}
+	var pkg *models.Package
+	var rawHTML string
+	var api *apiPackage
+	var timing Timing
+	var err, tabsErr error
+	concurrently(
+		func() { pkg, rawHTML, api, timing, err = s.scrapePackage(ctx, importPath) },
+		func() { tabs, tabsErr = s.ScrapeTabs(ctx, importPath, tabReq) },
+	)
+	if err != nil {
+		return nil, "", Tabs{}, timing, err
+	}
+	if req.Imports && !tabReq.Imports {
+		if api != nil && api.Imports != nil {
+			tabs.Imports = api.Imports
+		} else if tabs.Imports, err = s.scrapeTab(ctx, importPath, "imports", s.parser.ParseImports); err != nil {
+			tabsErr = errors.Join(tabsErr, fmt.Errorf("imports tab: %w", err))
+		}
+	}
+	if tabsErr != nil {
+		log.Printf("Tabs of %s incomplete: %v", importPath, tabsErr)
+	}
+	if len(tabs.Imports) > 0 {
+		pkg.ImportList = tabs.Imports
+	}
+	if len(tabs.Importers) > 0 {
+		pkg.Importers = tabs.Importers
+	}
+	return pkg, rawHTML, tabs, timing, nil
+}
+
+// concurrently runs fns on goroutines of their own and waits for all of them.
+func concurrently(fns ...func()) {
+	var wg sync.WaitGroup
+	for _, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
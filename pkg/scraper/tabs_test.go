@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tabTransport answers package pages and their tabs after a delay, tracking how many requests
+// were in flight at once.
+type tabTransport struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (f *tabTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	page := `<html><body><h1 class="UnitHeader-titleHeading">widget</h1></body></html>`
+	switch req.URL.Query().Get("tab") {
+	case "importedby":
+		page = `<html><body><div class="ImportedBy-list"><a href="/example.com/app">example.com/app</a><a href="/example.com/cli">example.com/cli</a></div></body></html>`
+	case "imports":
+		page = `<html><body><ul class="Imports-list"><a href="/fmt">fmt</a></ul></body></html>`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       io.NopCloser(strings.NewReader(page)),
+		Request:    req,
+	}, nil
+}
+
+func TestScrapePackageTabs(t *testing.T) {
+	transport := &tabTransport{}
+	s, err := New(&ScrapingConfig{Transport: transport, MaxConcurrency: 4})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	pkg, _, tabs, _, err := s.ScrapePackageTabs(context.Background(), "example.com/widget", TabRequest{Imports: true, Importers: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pkg.Name != "widget" || strings.Join(pkg.Importers, ",") != "example.com/app" {
+		t.Errorf("Expected the page with its importers merged, got %+v", pkg)
+	}
+	if strings.Join(tabs.Imports, ",") != "fmt" {
+		t.Errorf("Expected the imports tab, got %v", tabs.Imports)
+	}
+	if transport.peak != 3 {
+		t.Errorf("Expected the page and both tabs fetched at once, got %d in flight at most", transport.peak)
+	}
+}
+
+func TestScrapeTabs_Politeness(t *testing.T) {
+	transport := &tabTransport{}
+	s, err := New(&ScrapingConfig{Transport: transport, MaxConcurrency: 1})
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	defer s.Close()
+
+	tabs, err := s.ScrapeTabs(context.Background(), "example.com/widget", TabRequest{Imports: true, Importers: 5})
+	if err != nil || len(tabs.Imports) != 1 || len(tabs.Importers) != 2 {
+		t.Fatalf("Expected both tabs, got %+v, %v", tabs, err)
+	}
+	if transport.peak != 1 {
+		t.Errorf("Expected MaxConcurrency to hold for concurrent tabs, got %d in flight", transport.peak)
+	}
+}
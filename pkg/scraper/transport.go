@@ -0,0 +1,34 @@
+package scraper
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// DefaultMaxIdleConnsPerHost is how many idle keep-alive connections a transport built by
+// NewTransport keeps per host when ScrapingConfig.MaxIdleConnsPerHost is 0. It exceeds
+// MaxConcurrency comfortably, so pages, tabs and retries of a batch reuse connections instead
+// of handshaking TLS again.
+const DefaultMaxIdleConnsPerHost = 8
+
+// NewTransport returns an HTTP transport tuned by the connection knobs of config: keep-alive
+// pooling per host, HTTP/2 unless disabled, and a limit on connections per host. The scraper
+// builds one when config.Transport is nil; pass it to the other HTTP clients of a batch, e.g.
+// README and source downloads, to share its connections.
+func NewTransport(config *ScrapingConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	if config.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	t.MaxIdleConns = max(t.MaxIdleConns, 4*t.MaxIdleConnsPerHost)
+	t.MaxConnsPerHost = config.MaxConnsPerHost
+	if config.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = config.IdleConnTimeout
+	}
+	if config.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
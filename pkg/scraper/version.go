@@ -0,0 +1,53 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// PackageURL returns the pkg.go.dev URL of importPath, which may be pinned to a version as
+// "importPath@version": a release, a prerelease such as v1.2.0-rc.1, a pseudo-version such as
+// v0.0.0-20240102150405-abcdef012345, or a query pkg.go.dev resolves, such as master. Versions
+// that look like semantic versions must be canonical, since pkg.go.dev and the store key on the
+// exact string; a "v" prefix typed in upper case is accepted.
+func PackageURL(importPath string) (string, error) {
+	path, version, pinned := strings.Cut(strings.TrimSpace(importPath), "@")
+	if pinned {
+		v, err := CheckVersion(version)
+		if err != nil {
+			return "", err
+		}
+		path += "@" + v
+	}
+	u := url.URL{Scheme: "https", Host: "pkg.go.dev", Path: "/" + path}
+	return u.String(), nil
+}
+
+// CheckVersion validates the version of a pinned import path and returns it with a lower-case
+// "v". Strings not starting with v and a digit are left to pkg.go.dev to resolve.
+func CheckVersion(version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf("empty version after @")
+	}
+	if len(version) < 2 || (version[0] != 'v' && version[0] != 'V') || version[1] < '0' || version[1] > '9' {
+		if strings.ContainsAny(version, "/?#") {
+			return "", fmt.Errorf("invalid version query %q", version)
+		}
+		return version, nil
+	}
+	version = "v" + version[1:]
+	if !semver.IsValid(version) {
+		return "", fmt.Errorf("invalid version %q: want vMAJOR.MINOR.PATCH, a prerelease such as v1.2.0-rc.1 or a pseudo-version", version)
+	}
+	canonical := semver.Canonical(version)
+	if semver.Build(version) == "+incompatible" {
+		canonical += "+incompatible"
+	}
+	if version != canonical {
+		return "", fmt.Errorf("version %q is not canonical; use %s", version, canonical)
+	}
+	return version, nil
+}
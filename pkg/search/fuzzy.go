@@ -0,0 +1,140 @@
+package search
+
+import (
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Symbol is an exported identifier of a cached package.
+type Symbol struct {
+	Name       string // qualified within the package, e.g. "Command.Execute" for methods
+	Kind       string // const, var, func, type or method
+	Signature  string // declaration or signature, first line only
+	ImportPath string
+}
+
+// SymbolMatch is a symbol with its fuzzy match score.
+type SymbolMatch struct {
+	Symbol
+	Score int
+}
+
+// PackageSymbols lists the exported constants, variables, functions, types, struct fields and
+// methods of pkg. Methods include those declared by interface types, but not embedded interfaces.
+func PackageSymbols(pkg *models.Package) []Symbol {
+	var out []Symbol
+	add := func(name, kind, signature string) {
+		if name == "" {
+			return
+		}
+		// Grouped declarations are stored once per block; keep the first line.
+		if i := strings.IndexByte(signature, '\n'); i >= 0 {
+			signature = signature[:i]
+		}
+		out = append(out, Symbol{Name: name, Kind: kind, Signature: strings.TrimSpace(signature), ImportPath: pkg.ImportPath})
+	}
+	for _, c := range pkg.Constants {
+		add(c.Name, "const", strings.TrimSpace("const "+c.Name+" "+c.Type))
+	}
+	for _, v := range pkg.Variables {
+		add(v.Name, "var", strings.TrimSpace("var "+v.Name+" "+v.Type))
+	}
+	for _, f := range pkg.Functions {
+		add(f.Name, "func", f.Signature)
+	}
+	for _, t := range pkg.Types {
+		add(t.Name, "type", t.Definition)
+		for _, f := range t.Fields {
+			add(t.Name+"."+f.Name, "field", f.Name+" "+f.Type)
+		}
+		for _, m := range t.Methods {
+			add(t.Name+"."+m.Name, "method", m.Signature)
+		}
+		for _, m := range t.InterfaceMethods {
+			if token.IsIdentifier(m.Name) {
+				add(t.Name+"."+m.Name, "method", m.Signature)
+			}
+		}
+	}
+	return out
+}
+
+// FuzzyScore scores candidate against query, with characters of query matched in order and
+// case-insensitively. Matches at word boundaries, consecutive matches, and prefix or exact
+// matches score higher. ok is false when query is not a subsequence of candidate.
+func FuzzyScore(query, candidate string) (score int, ok bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, true
+	}
+	c := []rune(candidate)
+	qi, last := 0, -2
+	for ci := 0; ci < len(c) && qi < len(q); ci++ {
+		if unicode.ToLower(c[ci]) != q[qi] {
+			continue
+		}
+		score += 16
+		if ci == last+1 {
+			score += 12
+		}
+		if ci == 0 || isBoundary(c[ci-1], c[ci]) {
+			score += 10
+		}
+		if last >= 0 {
+			score -= min(ci-last-1, 8)
+		}
+		last = ci
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	lower := strings.ToLower(candidate)
+	switch {
+	case lower == string(q):
+		score += 100
+	case strings.HasPrefix(lower, string(q)):
+		score += 40
+	}
+	// Prefer shorter candidates among otherwise equal matches.
+	return score - len(c)/4, true
+}
+
+// isBoundary reports whether cur starts a word: after a separator or at a lower-to-upper case change.
+func isBoundary(prev, cur rune) bool {
+	return prev == '.' || prev == '_' || prev == ' ' || (unicode.IsLower(prev) && unicode.IsUpper(cur))
+}
+
+// FuzzyFind returns the k best matches of query among symbols, best first.
+// Both the qualified name and the method name alone are tried, so "exec" finds Command.Execute.
+func FuzzyFind(query string, symbols []Symbol, k int) []SymbolMatch {
+	var matches []SymbolMatch
+	for _, s := range symbols {
+		best, found := FuzzyScore(query, s.Name)
+		if i := strings.LastIndexByte(s.Name, '.'); i >= 0 {
+			if score, ok := FuzzyScore(query, s.Name[i+1:]); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if found {
+			matches = append(matches, SymbolMatch{Symbol: s, Score: best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].ImportPath != matches[j].ImportPath {
+			return matches[i].ImportPath < matches[j].ImportPath
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
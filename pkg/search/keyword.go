@@ -0,0 +1,101 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// stopWords are ignored when turning a natural-language query into search terms.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "can": true, "do": true, "does": true,
+	"for": true, "how": true, "i": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "the": true, "to": true, "what": true, "when": true, "with": true,
+}
+
+// Terms lowercases query and splits it into search terms, dropping stop words.
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, f := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(f) < 2 || stopWords[f] || seen[f] {
+			continue
+		}
+		seen[f] = true
+		terms = append(terms, f)
+	}
+	return terms
+}
+
+// KeywordRanker keeps the top k chunks by keyword relevance as chunks are streamed through it.
+type KeywordRanker struct {
+	terms   []string
+	k       int
+	matches []models.ChunkMatch
+}
+
+// NewKeywordRanker creates a ranker for query that retains at most k results.
+func NewKeywordRanker(query string, k int) *KeywordRanker {
+	if k <= 0 {
+		k = 10
+	}
+	return &KeywordRanker{terms: Terms(query), k: k}
+}
+
+// Add scores chunk and keeps it if it ranks among the current top k.
+func (r *KeywordRanker) Add(chunk models.Chunk) {
+	score := r.score(chunk)
+	if score <= 0 {
+		return
+	}
+	chunk.Embedding = nil
+	r.matches = append(r.matches, models.ChunkMatch{Chunk: chunk, Score: score})
+	r.sort()
+	if len(r.matches) > r.k {
+		r.matches = r.matches[:r.k]
+	}
+}
+
+// Results returns the retained matches, best first.
+func (r *KeywordRanker) Results() []models.ChunkMatch {
+	return r.matches
+}
+
+// score counts term occurrences, weighting heading hits, and dampens long chunks.
+func (r *KeywordRanker) score(chunk models.Chunk) float64 {
+	if len(r.terms) == 0 {
+		return 0
+	}
+	text := strings.ToLower(chunk.Text)
+	heading := strings.ToLower(chunk.Heading)
+	var score float64
+	matched := 0
+	for _, term := range r.terms {
+		n := strings.Count(text, term)
+		h := strings.Count(heading, term)
+		if n+h > 0 {
+			matched++
+		}
+		score += float64(n) + 3*float64(h)
+	}
+	if matched == 0 {
+		return 0
+	}
+	// Favor chunks that cover more distinct terms, then normalize by length.
+	score *= float64(matched) / float64(len(r.terms))
+	return score / (1 + math.Log1p(float64(chunk.Tokens)))
+}
+
+func (r *KeywordRanker) sort() {
+	sort.SliceStable(r.matches, func(i, j int) bool {
+		if r.matches[i].Score != r.matches[j].Score {
+			return r.matches[i].Score > r.matches[j].Score
+		}
+		return r.matches[i].ID < r.matches[j].ID
+	})
+}
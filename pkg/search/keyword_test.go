@@ -0,0 +1,69 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestKeywordRanker(t *testing.T) {
+	r := NewKeywordRanker("how do I retry failed requests", 2)
+	r.Add(models.Chunk{ID: "a", Heading: "Overview", Text: "Package a sends requests.", Tokens: 6})
+	r.Add(models.Chunk{ID: "b", Heading: "Retry", Text: "Retry failed requests with backoff.", Tokens: 8})
+	r.Add(models.Chunk{ID: "c", Heading: "Unrelated", Text: "Nothing to see here.", Tokens: 5})
+
+	got := r.Results()
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(got))
+	}
+	if got[0].ID != "b" {
+		t.Errorf("Expected the retry chunk to rank first, got %q", got[0].ID)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("How do I use the Retry-Policy?")
+	want := []string{"use", "retry", "policy"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestFuzzyFind(t *testing.T) {
+	pkg := &models.Package{
+		ImportPath: "github.com/spf13/cobra",
+		Functions:  []models.Function{{Name: "ExactArgs", Signature: "func ExactArgs(n int) PositionalArgs"}},
+		Types: []models.Type{{
+			Name:       "Command",
+			Definition: "type Command struct {\n\tUse string\n}",
+			Fields:     []models.Field{{Name: "Use", Type: "string"}},
+			Methods:    []models.Function{{Name: "Execute", Signature: "func (c *Command) Execute() error"}},
+		}},
+	}
+	symbols := PackageSymbols(pkg)
+	if len(symbols) != 4 || symbols[1].Signature != "type Command struct {" ||
+		symbols[2].Name != "Command.Use" || symbols[2].Kind != "field" || symbols[2].Signature != "Use string" {
+		t.Fatalf("Unexpected symbols %+v", symbols)
+	}
+
+	iface := &models.Package{Types: []models.Type{{Name: "ReadCloser", InterfaceMethods: []models.Function{{Name: "io.Reader"}, {Name: "Close", Signature: "Close() error"}}}}}
+	if got := PackageSymbols(iface); len(got) != 2 || got[1].Name != "ReadCloser.Close" || got[1].Kind != "method" {
+		t.Errorf("Expected the type and its interface method, got %+v", got)
+	}
+
+	matches := FuzzyFind("exec", symbols, 10)
+	if len(matches) == 0 || matches[0].Name != "Command.Execute" {
+		t.Errorf("Expected Command.Execute first for \"exec\", got %+v", matches)
+	}
+	if matches := FuzzyFind("EA", symbols, 10); len(matches) == 0 || matches[0].Name != "ExactArgs" {
+		t.Errorf("Expected word-boundary initials to find ExactArgs, got %+v", matches)
+	}
+	if matches := FuzzyFind("zzz", symbols, 10); len(matches) != 0 {
+		t.Errorf("Expected no matches, got %+v", matches)
+	}
+}
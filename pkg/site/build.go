@@ -0,0 +1,357 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+	"github.com/moseye/docinator/pkg/checksum"
+	"github.com/moseye/docinator/pkg/markdown"
+	"github.com/moseye/docinator/pkg/xref"
+)
+
+// BuildOptions configures Build.
+type BuildOptions struct {
+	Title   string // site title shown in every page header; defaults to "Go Packages"
+	BaseURL string // absolute URL the site is published at; set to write sitemap.xml and robots.txt
+}
+
+const stylesheet = `body { font-family: sans-serif; margin: 0; line-height: 1.5; }
+header { display: flex; gap: 1em; align-items: center; padding: 0.5em 1em; border-bottom: 1px solid #ddd; background: #f6f8fa; }
+header .title { font-weight: bold; text-decoration: none; color: inherit; }
+header form { flex: 1; }
+header input { width: 100%; max-width: 24em; padding: 0.25em; }
+main { max-width: 960px; margin: 0 auto; padding: 1em; }
+pre { background: #f6f8fa; padding: 0.75em; overflow-x: auto; }
+code { font-family: monospace; }
+.module ul { list-style: none; padding-left: 0; }
+.module li { margin: 0.25em 0; }
+.version, .synopsis { color: #666; }
+`
+
+var layoutTemplate = template.Must(template.New("layout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - {{.SiteTitle}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header>
+<a class="title" href="{{.Root}}index.html">{{.SiteTitle}}</a>
+<form action="{{.Root}}index.html" method="get"><input type="search" id="q" name="q" placeholder="Search packages" autocomplete="off"></form>
+{{- if .Versions}}
+<select aria-label="Version" onchange="location.href = this.value">
+{{- range .Versions}}
+<option value="{{.Href}}"{{if .Current}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+</select>
+{{- end}}
+</header>
+<main>
+{{.Body}}
+</main>
+{{- if .Script}}
+<script src="{{.Root}}search-index.js"></script>
+<script>{{.Script}}</script>
+{{- end}}
+</body>
+</html>
+`))
+
+var indexTemplate = template.Must(template.New("index").Parse(`<h1>{{.Title}}</h1>
+<p id="count">{{.Count}} packages</p>
+{{- range .Modules}}
+<section class="module">
+<h2>{{.Path}}</h2>
+<ul>
+{{- range .Packages}}
+<li data-search="{{.Search}}"><a href="{{.Href}}">{{.ImportPath}}</a>{{if .Version}} <span class="version">{{.Version}}</span>{{end}}{{if .Synopsis}} <span class="synopsis">— {{.Synopsis}}</span>{{end}}<code class="symbols"></code></li>
+{{- end}}
+</ul>
+</section>
+{{- end}}
+`))
+
+// filterScript filters the index by the terms in the search box (or the q query parameter),
+// matching package names and synopses on the page and symbol names from the search index.
+const filterScript = template.JS(`(function () {
+  var input = document.getElementById("q"), symbols = {};
+  (window.DOCINATOR_SEARCH || []).forEach(function (e) { symbols[e.url] = e.symbols || []; });
+  input.value = new URLSearchParams(location.search).get("q") || "";
+  function filter() {
+    var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean), shown = 0;
+    document.querySelectorAll(".module").forEach(function (section) {
+      var visible = 0;
+      section.querySelectorAll("li").forEach(function (li) {
+        var text = li.getAttribute("data-search");
+        var syms = symbols[li.querySelector("a").getAttribute("href")] || [];
+        var hits = terms.length ? syms.filter(function (s) {
+          return terms.some(function (t) { return s.toLowerCase().indexOf(t) >= 0; });
+        }) : [];
+        var match = terms.every(function (t) {
+          return text.indexOf(t) >= 0 || hits.some(function (s) { return s.toLowerCase().indexOf(t) >= 0; });
+        });
+        li.hidden = !match;
+        li.querySelector(".symbols").textContent = match && hits.length ? " " + hits.slice(0, 5).join(", ") : "";
+        if (match) visible++;
+      });
+      section.hidden = visible === 0;
+      shown += visible;
+    });
+    document.getElementById("count").textContent = shown + " packages";
+  }
+  input.addEventListener("input", filter);
+  input.form.addEventListener("submit", function (e) { e.preventDefault(); filter(); });
+  filter();
+})();`)
+
+type layoutData struct {
+	SiteTitle string
+	Title     string
+	Root      string // relative path from the page to the site root, e.g. "../../"
+	Versions  []versionLink
+	Body      template.HTML
+	Script    template.JS
+}
+
+type versionLink struct {
+	Label   string
+	Href    string
+	Current bool
+}
+
+type moduleEntry struct {
+	Path     string
+	Packages []packageEntry
+}
+
+type packageEntry struct {
+	ImportPath string
+	Version    string
+	Synopsis   string
+	Href       string
+	Search     string
+}
+
+// Build writes a self-contained static site for pkgs below dir: an index grouping packages by
+// module with a search box, a page per import path showing its most recently scraped version,
+// a page per cached version linked through a version switcher, and a prebuilt search index of
+// every page's titles, symbols and synopses so search works offline. A SHA256SUMS manifest of
+// every file in dir is written last. All links are relative, so the
+// site can be published under any path or opened from disk. It returns the number of pages written.
+func Build(dir string, pkgs []*models.Package, opts BuildOptions) (int, error) {
+	if opts.Title == "" {
+		opts.Title = "Go Packages"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "style.css"), []byte(stylesheet), 0644); err != nil {
+		return 0, err
+	}
+
+	pages := 0
+	modules := map[string]*moduleEntry{}
+	var index []SearchEntry
+	refs := xref.Build(pkgs)
+	for _, versions := range groupVersions(pkgs) {
+		latest := versions[0]
+		latestPage := packagePage(latest.ImportPath, "")
+		if err := writePackagePage(dir, latestPage, latest, versionLinks(latestPage, versions), refs, refs.UsedBy(latest.ImportPath), opts); err != nil {
+			return pages, err
+		}
+		index = append(index, NewSearchEntry(latest, latestPage))
+		pages++
+		for _, pkg := range versions {
+			if pkg.Version == "" {
+				continue
+			}
+			page := packagePage(pkg.ImportPath, pkg.Version)
+			if err := writePackagePage(dir, page, pkg, versionLinks(page, versions), refs, nil, opts); err != nil {
+				return pages, err
+			}
+			index = append(index, NewSearchEntry(pkg, page))
+			pages++
+		}
+
+		modPath := latest.Module
+		if modPath == "" {
+			modPath = latest.ImportPath
+		}
+		mod := modules[modPath]
+		if mod == nil {
+			mod = &moduleEntry{Path: modPath}
+			modules[modPath] = mod
+		}
+		mod.Packages = append(mod.Packages, packageEntry{
+			ImportPath: latest.ImportPath,
+			Version:    latest.Version,
+			Synopsis:   latest.Synopsis,
+			Href:       latestPage,
+			Search:     strings.ToLower(latest.ImportPath + " " + latest.Name + " " + latest.Synopsis),
+		})
+	}
+
+	if err := WriteSearchIndex(dir, index); err != nil {
+		return pages, err
+	}
+	if err := writeIndex(dir, modules, opts); err != nil {
+		return pages, err
+	}
+	pages++
+	if opts.BaseURL != "" {
+		if err := WriteSitemap(dir, opts.BaseURL); err != nil {
+			return pages, err
+		}
+	}
+	_, err := checksum.Update(dir)
+	return pages, err
+}
+
+// packagePage returns the site-relative path of the page for importPath at version, or of its
+// latest page when version is empty. Both are encoded with utils.EncodePath.
+func packagePage(importPath, version string) string {
+	if version == "" {
+		return utils.EncodePath(importPath) + "/index.html"
+	}
+	return utils.EncodePath(importPath) + "/@v/" + utils.EncodePath(version) + "/index.html"
+}
+
+// groupVersions groups pkgs by import path, sorted by import path, with the versions of each
+// ordered most recently scraped first and duplicates of a version dropped.
+func groupVersions(pkgs []*models.Package) [][]*models.Package {
+	byPath := map[string][]*models.Package{}
+	for _, pkg := range pkgs {
+		if pkg != nil && pkg.ImportPath != "" {
+			byPath[pkg.ImportPath] = append(byPath[pkg.ImportPath], pkg)
+		}
+	}
+	paths := make([]string, 0, len(byPath))
+	for p := range byPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	out := make([][]*models.Package, 0, len(paths))
+	for _, p := range paths {
+		versions := byPath[p]
+		sort.SliceStable(versions, func(i, j int) bool { return versions[i].ScrapedAt.After(versions[j].ScrapedAt) })
+		seen := map[string]bool{}
+		unique := versions[:0]
+		for _, v := range versions {
+			if !seen[v.Version] {
+				seen[v.Version] = true
+				unique = append(unique, v)
+			}
+		}
+		out = append(out, unique)
+	}
+	return out
+}
+
+// versionLinks returns the version switcher entries for page.
+func versionLinks(page string, versions []*models.Package) []versionLink {
+	if len(versions) == 1 && versions[0].Version == "" {
+		return nil
+	}
+	latestPage := packagePage(versions[0].ImportPath, "")
+	label := "latest"
+	if v := versions[0].Version; v != "" {
+		label = "latest (" + v + ")"
+	}
+	links := []versionLink{{Label: label, Href: relativeLink(page, latestPage), Current: page == latestPage}}
+	for _, v := range versions {
+		if v.Version == "" {
+			continue
+		}
+		target := packagePage(v.ImportPath, v.Version)
+		links = append(links, versionLink{Label: v.Version, Href: relativeLink(page, target), Current: page == target})
+	}
+	return links
+}
+
+// writePackagePage writes the page of pkg, its declarations linking the types of other packages
+// refs resolves to their latest pages. usedBy holds the "Used by" notes of its types, which only
+// latest pages carry, since the cross-reference is of the latest versions.
+func writePackagePage(dir, page string, pkg *models.Package, versions []versionLink, refs *xref.Index, usedBy map[string][]string, opts BuildOptions) error {
+	typeLinks := func(pkg *models.Package, qualifier, name string) string {
+		if t := refs.Resolve(pkg.ImportPath, qualifier, name); t != nil {
+			return relativeLink(page, packagePage(t.ImportPath, "")) + "#" + name
+		}
+		return ""
+	}
+	mdOpts := markdown.Options{UsedBy: usedBy, LinkTypes: true, TypeLinks: typeLinks}
+	body, err := renderMarkdown([]byte(markdown.PackageToMarkdownWithOptions(pkg, mdOpts)))
+	if err != nil {
+		return fmt.Errorf("render %s: %w", pkg.ImportPath, err)
+	}
+	title := pkg.ImportPath
+	if pkg.Version != "" {
+		title += "@" + pkg.Version
+	}
+	return writePage(dir, page, layoutData{
+		SiteTitle: opts.Title,
+		Title:     title,
+		Root:      rootOf(page),
+		Versions:  versions,
+		Body:      body,
+	})
+}
+
+func writeIndex(dir string, modules map[string]*moduleEntry, opts BuildOptions) error {
+	paths := make([]string, 0, len(modules))
+	for p := range modules {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	data := struct {
+		Title   string
+		Count   int
+		Modules []*moduleEntry
+	}{Title: opts.Title}
+	for _, p := range paths {
+		data.Modules = append(data.Modules, modules[p])
+		data.Count += len(modules[p].Packages)
+	}
+
+	var body bytes.Buffer
+	if err := indexTemplate.Execute(&body, data); err != nil {
+		return err
+	}
+	return writePage(dir, "index.html", layoutData{
+		SiteTitle: opts.Title,
+		Title:     "Index",
+		Body:      template.HTML(body.String()),
+		Script:    filterScript,
+	})
+}
+
+func writePage(dir, page string, data layoutData) error {
+	name := filepath.Join(dir, filepath.FromSlash(page))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := layoutTemplate.Execute(&out, data); err != nil {
+		return err
+	}
+	return os.WriteFile(name, out.Bytes(), 0644)
+}
+
+// rootOf returns the relative path from page back to the site root.
+func rootOf(page string) string {
+	return strings.Repeat("../", strings.Count(page, "/"))
+}
+
+// relativeLink returns the link from page to target, both site-relative.
+func relativeLink(page, target string) string {
+	return rootOf(page) + target
+}
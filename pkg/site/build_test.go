@@ -0,0 +1,80 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Now()
+	pkgs := []*models.Package{
+		{Name: "cobra", ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.8.0", ScrapedAt: now.Add(-time.Hour)},
+		{Name: "cobra", ImportPath: "github.com/spf13/cobra", Module: "github.com/spf13/cobra", Version: "v1.9.1", ScrapedAt: now, Synopsis: "Commander library",
+			Types: []models.Type{{Name: "Command"}}},
+		{Name: "doc", ImportPath: "github.com/spf13/cobra/doc", Module: "github.com/spf13/cobra", Version: "v1.9.1", ScrapedAt: now,
+			Functions: []models.Function{{Name: "GenMarkdown", Signature: "func GenMarkdown(cmd *cobra.Command, w io.Writer) error"}}},
+		{Name: "goquery", ImportPath: "github.com/PuerkitoBio/goquery", ScrapedAt: now},
+	}
+	dir := t.TempDir()
+
+	pages, err := Build(dir, pkgs, BuildOptions{Title: "Internal Docs", BaseURL: "https://docs.example.com/"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	// index, 3 latest pages, and 3 versioned pages
+	if pages != 7 {
+		t.Errorf("Expected 7 pages, got %d", pages)
+	}
+
+	read := func(rel string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("Expected %s to be written: %v", rel, err)
+		}
+		return string(b)
+	}
+
+	index := read("index.html")
+	if !strings.Contains(index, "<h2>github.com/spf13/cobra</h2>") || !strings.Contains(index, `href="github.com/spf13/cobra/doc/index.html"`) {
+		t.Errorf("Expected the index to group packages by module, got %q", index)
+	}
+	if !strings.Contains(index, `data-search="github.com/spf13/cobra cobra commander library"`) {
+		t.Error("Expected index entries to carry their search text")
+	}
+
+	latest := read("github.com/spf13/cobra/index.html")
+	if !strings.Contains(latest, "Commander library") || !strings.Contains(latest, `href="../../../style.css"`) {
+		t.Errorf("Expected the latest page to show v1.9.1 with relative links, got %q", latest)
+	}
+	if !strings.Contains(latest, `<option value="../../../github.com/spf13/cobra/@v/v1.8.0/index.html">v1.8.0</option>`) {
+		t.Errorf("Expected a version switcher entry for v1.8.0, got %q", latest)
+	}
+
+	doc := read("github.com/spf13/cobra/doc/index.html")
+	if !strings.Contains(doc, `<pre><code>func GenMarkdown(cmd *<a href="../../../../github.com/spf13/cobra/index.html#Command">cobra.Command</a>, w io.Writer) error</code></pre>`) {
+		t.Errorf("Expected cobra.Command linked to its page, got %q", doc)
+	}
+
+	old := read("github.com/spf13/cobra/@v/v1.8.0/index.html")
+	if !strings.Contains(old, `<option value="../../../../../github.com/spf13/cobra/@v/v1.8.0/index.html" selected>v1.8.0</option>`) {
+		t.Errorf("Expected v1.8.0 to be selected on its own page, got %q", old)
+	}
+
+	if idx := read(SearchIndexFile); !strings.Contains(idx, `"url":"github.com/spf13/cobra/@v/v1.8.0/index.html"`) {
+		t.Errorf("Expected the search index to cover versioned pages, got %q", idx)
+	}
+
+	if sitemap := read(SitemapFile); !strings.Contains(sitemap, "<loc>https://docs.example.com/github.com/spf13/cobra/@v/v1.8.0/</loc>") {
+		t.Errorf("Expected the sitemap to list versioned pages, got %q", sitemap)
+	}
+
+	if strings.Contains(read("github.com/!puerkito!bio/goquery/index.html"), "<select") {
+		t.Error("Expected no version switcher for an unversioned package")
+	}
+}
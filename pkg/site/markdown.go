@@ -0,0 +1,28 @@
+package site
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	// Generated markdown embeds <details> for collapsible source; its text comes from
+	// pkg.go.dev pages, which are already sanitized.
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// renderMarkdown converts package markdown to HTML.
+func renderMarkdown(src []byte) (template.HTML, error) {
+	var out bytes.Buffer
+	if err := md.Convert(src, &out); err != nil {
+		return "", err
+	}
+	return template.HTML(out.String()), nil
+}
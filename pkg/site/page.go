@@ -0,0 +1,59 @@
+package site
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/markdown"
+)
+
+var standaloneTemplate = template.Must(template.New("standalone").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+{{.Style}}</style>
+</head>
+<body>
+<main>
+{{.Body}}
+</main>
+</body>
+</html>
+`))
+
+// PackageHTML renders pkg as a single self-contained HTML page with the site stylesheet inlined,
+// for writing next to the markdown output rather than as part of a built site.
+func PackageHTML(pkg *models.Package) ([]byte, error) {
+	return RenderedPackageHTML(pkg, markdown.PackageToMarkdown(pkg))
+}
+
+// RenderedPackageHTML is PackageHTML for md, markdown already rendered from pkg — with renderer
+// options or post-processing — so a run writing both formats renders the markdown once and the
+// page shows what the markdown file does.
+func RenderedPackageHTML(pkg *models.Package, md string) ([]byte, error) {
+	title := pkg.ImportPath
+	if pkg.Version != "" {
+		title += "@" + pkg.Version
+	}
+	return MarkdownHTML(title, md)
+}
+
+// MarkdownHTML renders a markdown document, such as a handbook of several packages, as a single
+// self-contained HTML page titled title, with the site stylesheet inlined.
+func MarkdownHTML(title, md string) ([]byte, error) {
+	body, err := renderMarkdown([]byte(md))
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	err = standaloneTemplate.Execute(&out, struct {
+		Title string
+		Style template.CSS
+		Body  template.HTML
+	}{title, template.CSS(stylesheet), body})
+	return out.Bytes(), err
+}
@@ -0,0 +1,103 @@
+package site
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/search"
+)
+
+// SearchIndexFile is the name of the prebuilt search index written next to generated pages.
+const SearchIndexFile = "search-index.json"
+
+// searchIndexScript holds the same entries as a script assigning window.DOCINATOR_SEARCH, since
+// browsers refuse to fetch JSON from pages opened from disk.
+const searchIndexScript = "search-index.js"
+
+// SearchEntry is one page in the search index. The fields are flat so that lunr (with "url" as
+// the ref) or Fuse.js can index the JSON directly, e.g. with keys ["title", "import_path",
+// "symbols", "synopsis"].
+type SearchEntry struct {
+	URL        string   `json:"url"` // page relative to the index
+	Title      string   `json:"title"`
+	ImportPath string   `json:"import_path"`
+	Version    string   `json:"version,omitempty"`
+	Synopsis   string   `json:"synopsis,omitempty"`
+	Symbols    []string `json:"symbols,omitempty"` // exported names, methods as "Type.Method"
+}
+
+// NewSearchEntry returns the index entry of pkg, whose page is url.
+func NewSearchEntry(pkg *models.Package, url string) SearchEntry {
+	e := SearchEntry{
+		URL:        url,
+		Title:      pkg.Name,
+		ImportPath: pkg.ImportPath,
+		Version:    pkg.Version,
+		Synopsis:   pkg.Synopsis,
+	}
+	if e.Title == "" {
+		e.Title = pkg.ImportPath
+	}
+	for _, sym := range search.PackageSymbols(pkg) {
+		e.Symbols = append(e.Symbols, sym.Name)
+	}
+	return e
+}
+
+// WriteSearchIndex writes entries, sorted by URL, as the search index of dir.
+func WriteSearchIndex(dir string, entries []SearchEntry) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].URL < entries[j].URL })
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, SearchIndexFile), data, 0644); err != nil {
+		return err
+	}
+	script := fmt.Sprintf("window.DOCINATOR_SEARCH = %s;\n", data)
+	return os.WriteFile(filepath.Join(dir, searchIndexScript), []byte(script), 0644)
+}
+
+// UpdateSearchIndex merges entries into the search index of dir, replacing entries for the same
+// page, so that output directories filled by several runs keep one index of everything written.
+func UpdateSearchIndex(dir string, entries []SearchEntry) error {
+	existing, err := ReadSearchIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	byURL := make(map[string]SearchEntry, len(existing)+len(entries))
+	for _, e := range existing {
+		byURL[e.URL] = e
+	}
+	for _, e := range entries {
+		byURL[e.URL] = e
+	}
+	merged := make([]SearchEntry, 0, len(byURL))
+	for _, e := range byURL {
+		merged = append(merged, e)
+	}
+	return WriteSearchIndex(dir, merged)
+}
+
+// ReadSearchIndex returns the entries of the search index of dir, or none if it has no index.
+func ReadSearchIndex(dir string) ([]SearchEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SearchIndexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []SearchEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("read %s: %w", SearchIndexFile, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,53 @@
+package site
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestUpdateSearchIndex(t *testing.T) {
+	dir := t.TempDir()
+	cobra := &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra", Synopsis: "Commander library",
+		Types: []models.Type{{Name: "Command", Methods: []models.Function{{Name: "Execute", Receiver: "*Command"}}}}}
+	goquery := &models.Package{Name: "goquery", ImportPath: "github.com/PuerkitoBio/goquery"}
+
+	if err := UpdateSearchIndex(dir, []SearchEntry{NewSearchEntry(cobra, "github.com/spf13/cobra.md")}); err != nil {
+		t.Fatalf("UpdateSearchIndex failed: %v", err)
+	}
+	cobra.Synopsis = "Updated synopsis"
+	err := UpdateSearchIndex(dir, []SearchEntry{
+		NewSearchEntry(goquery, "github.com/PuerkitoBio/goquery.md"),
+		NewSearchEntry(cobra, "github.com/spf13/cobra.md"),
+	})
+	if err != nil {
+		t.Fatalf("UpdateSearchIndex failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, SearchIndexFile))
+	if err != nil {
+		t.Fatalf("Expected %s to be written: %v", SearchIndexFile, err)
+	}
+	var entries []SearchEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if len(entries) != 2 || entries[1].ImportPath != "github.com/spf13/cobra" {
+		t.Fatalf("Expected one sorted entry per page, got %+v", entries)
+	}
+	if entries[1].Synopsis != "Updated synopsis" {
+		t.Errorf("Expected the newer entry to replace the older one, got %q", entries[1].Synopsis)
+	}
+	if strings.Join(entries[1].Symbols, ",") != "Command,Command.Execute" {
+		t.Errorf("Expected type and method symbols, got %v", entries[1].Symbols)
+	}
+
+	script, err := os.ReadFile(filepath.Join(dir, searchIndexScript))
+	if err != nil || !strings.HasPrefix(string(script), "window.DOCINATOR_SEARCH = [") {
+		t.Errorf("Expected the script copy of the index, got %q (%v)", script, err)
+	}
+}
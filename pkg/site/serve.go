@@ -0,0 +1,267 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moseye/docinator/internal/utils"
+)
+
+// reloadPath is the server-sent events endpoint pages subscribe to for live reload.
+const reloadPath = "/_livereload"
+
+// pollInterval is how often the live reload endpoint checks the directory for changes.
+const pollInterval = 500 * time.Millisecond
+
+const reloadScript = `<script>new EventSource("` + reloadPath + `").onmessage = function () { location.reload(); };</script>`
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 0 auto; padding: 1em; line-height: 1.5; }
+pre { background: #f6f8fa; padding: 0.75em; overflow-x: auto; }
+code { font-family: monospace; }
+nav { border-bottom: 1px solid #ddd; margin-bottom: 1em; padding-bottom: 0.5em; }
+ul.index { list-style: none; padding-left: 0; }
+ul.index .dir { font-weight: bold; margin-top: 0.75em; }
+</style>
+</head>
+<body>
+<nav><a href="/">Index</a>{{if .Path}} / {{.Path}}{{end}}</nav>
+{{.Body}}
+{{if .LiveReload}}{{.Script}}{{end}}
+</body>
+</html>
+`))
+
+type page struct {
+	Title      string
+	Path       string
+	Body       template.HTML
+	LiveReload bool
+	Script     template.HTML
+}
+
+// Handler serves a generated output directory for local preview. Markdown files are rendered to
+// HTML, directories without an index.html get a navigation index of every page below them, and
+// other files are served as they are. With liveReload, HTML pages reload whenever a file below dir
+// changes.
+func Handler(dir string, liveReload bool) http.Handler {
+	s := &server{dir: dir, liveReload: liveReload}
+	mux := http.NewServeMux()
+	if liveReload {
+		mux.HandleFunc(reloadPath, s.reload)
+	}
+	mux.HandleFunc("/", s.serve)
+	return mux
+}
+
+type server struct {
+	dir        string
+	liveReload bool
+}
+
+func (s *server) serve(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	name := filepath.Join(s.dir, filepath.FromSlash(rel))
+
+	info, err := os.Stat(name)
+	if err != nil && !strings.HasSuffix(name, ".md") {
+		// Import paths like /github.com/PuerkitoBio/goquery resolve to the package's encoded markdown file.
+		encoded := utils.EncodePath(rel) + ".md"
+		mdName := filepath.Join(s.dir, filepath.FromSlash(encoded))
+		if mdInfo, mdErr := os.Stat(mdName); mdErr == nil {
+			name, rel, info, err = mdName, encoded, mdInfo, nil
+		}
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case info.IsDir():
+		if _, err := os.Stat(filepath.Join(name, "index.html")); err == nil {
+			s.serveHTML(w, r, filepath.Join(name, "index.html"))
+			return
+		}
+		s.serveIndex(w, r, name, rel)
+	case strings.HasSuffix(name, ".md"):
+		s.serveMarkdown(w, r, name, rel)
+	case strings.HasSuffix(name, ".html"):
+		s.serveHTML(w, r, name)
+	default:
+		http.ServeFile(w, r, name)
+	}
+}
+
+func (s *server) serveMarkdown(w http.ResponseWriter, r *http.Request, name, rel string) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body, err := renderMarkdown(src)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.render(w, page{Title: markdownTitle(src, rel), Path: rel, Body: body})
+}
+
+func (s *server) serveHTML(w http.ResponseWriter, r *http.Request, name string) {
+	if !s.liveReload {
+		http.ServeFile(w, r, name)
+		return
+	}
+	src, err := os.ReadFile(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if i := bytes.LastIndex(src, []byte("</body>")); i >= 0 {
+		src = append(src[:i:i], append([]byte(reloadScript), src[i:]...)...)
+	} else {
+		src = append(src, reloadScript...)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(src)
+}
+
+// serveIndex lists every page below dir, grouped by directory.
+func (s *server) serveIndex(w http.ResponseWriter, r *http.Request, dir, rel string) {
+	pages, err := listPages(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var b strings.Builder
+	title := "Index of /" + rel
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", template.HTMLEscapeString(title))
+	if len(pages) == 0 {
+		b.WriteString("<p>No pages yet.</p>\n")
+	}
+	b.WriteString(`<ul class="index">` + "\n")
+	lastDir := "."
+	for _, p := range pages {
+		if d := path.Dir(p); d != lastDir {
+			fmt.Fprintf(&b, "<li class=\"dir\">%s/</li>\n", template.HTMLEscapeString(d))
+			lastDir = d
+		}
+		href := path.Join("/", rel, p)
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", template.HTMLEscapeString(href), template.HTMLEscapeString(path.Base(p)))
+	}
+	b.WriteString("</ul>\n")
+	s.render(w, page{Title: title, Path: rel, Body: template.HTML(b.String())})
+}
+
+func (s *server) render(w http.ResponseWriter, p page) {
+	p.LiveReload, p.Script = s.liveReload, reloadScript
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// reload streams a "reload" event once anything below the directory changes.
+func (s *server) reload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	initial := fingerprint(s.dir)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if fingerprint(s.dir) != initial {
+				fmt.Fprint(w, "data: reload\n\n")
+				flusher.Flush()
+				return
+			}
+		}
+	}
+}
+
+// listPages returns the markdown and HTML files below dir as slash-separated relative paths,
+// sorted so that files of the same directory are adjacent.
+func listPages(dir string) ([]string, error) {
+	var pages []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !(strings.HasSuffix(p, ".md") || strings.HasSuffix(p, ".html")) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		pages = append(pages, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Slice(pages, func(i, j int) bool {
+		di, dj := path.Dir(pages[i]), path.Dir(pages[j])
+		if di != dj {
+			return di < dj
+		}
+		return pages[i] < pages[j]
+	})
+	return pages, err
+}
+
+// fingerprint summarizes the names, sizes and modification times of the files below dir.
+func fingerprint(dir string) string {
+	var files int
+	var size int64
+	var latest time.Time
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files++
+		size += info.Size()
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return fmt.Sprintf("%d/%d/%d", files, size, latest.UnixNano())
+}
+
+// markdownTitle returns the first top-level heading of src, or fallback.
+func markdownTitle(src []byte, fallback string) string {
+	for _, line := range strings.Split(string(src), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return fallback
+}
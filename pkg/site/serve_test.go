@@ -0,0 +1,67 @@
+package site
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, h http.Handler, url string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	mdPath := filepath.Join(dir, "github.com", "spf13", "cobra.md")
+	os.MkdirAll(filepath.Dir(mdPath), 0755)
+	os.WriteFile(mdPath, []byte("# cobra\n\nSome `code`.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "about.html"), []byte("<html><body>About</body></html>"), 0644)
+	os.MkdirAll(filepath.Join(dir, "github.com", "!puerkito!bio"), 0755)
+	os.WriteFile(filepath.Join(dir, "github.com", "!puerkito!bio", "goquery.md"), []byte("# goquery\n"), 0644)
+
+	h := Handler(dir, true)
+
+	code, body := get(t, h, "/")
+	if code != http.StatusOK || !strings.Contains(body, `href="/github.com/spf13/cobra.md"`) || !strings.Contains(body, "github.com/spf13/") {
+		t.Errorf("Expected the index to link the markdown page, got %d %q", code, body)
+	}
+
+	code, body = get(t, h, "/github.com/spf13/cobra")
+	if code != http.StatusOK || !strings.Contains(body, "<title>cobra</title>") || !strings.Contains(body, "<code>code</code>") {
+		t.Errorf("Expected the markdown to be rendered, got %d %q", code, body)
+	}
+	if !strings.Contains(body, reloadPath) {
+		t.Error("Expected rendered pages to subscribe to live reload")
+	}
+
+	if code, body := get(t, h, "/github.com/PuerkitoBio/goquery"); code != http.StatusOK || !strings.Contains(body, "<title>goquery</title>") {
+		t.Errorf("Expected the import path to resolve to the encoded file, got %d", code)
+	}
+
+	_, body = get(t, h, "/about.html")
+	if !strings.Contains(body, reloadScript+"</body>") {
+		t.Errorf("Expected the reload script to be injected before </body>, got %q", body)
+	}
+
+	if code, _ := get(t, h, "/missing"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing page, got %d", code)
+	}
+}
+
+func TestHandler_NoLiveReload(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("# a\n"), 0644)
+
+	_, body := get(t, Handler(dir, false), "/a.md")
+	if strings.Contains(body, reloadPath) {
+		t.Error("Expected no reload script when live reload is disabled")
+	}
+}
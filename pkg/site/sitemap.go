@@ -0,0 +1,80 @@
+package site
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SitemapFile and RobotsFile are written to the root of published output.
+const (
+	SitemapFile = "sitemap.xml"
+	RobotsFile  = "robots.txt"
+)
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// WriteSitemap writes a sitemap.xml listing every markdown and HTML page below dir, with file
+// modification times as lastmod, and a robots.txt pointing crawlers at it. Sitemaps require
+// absolute URLs, so baseURL is where dir is published, e.g. "https://docs.example.com/go/".
+func WriteSitemap(dir, baseURL string) error {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		return fmt.Errorf("base URL %q must be absolute", baseURL)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	pages, err := listPages(dir)
+	if err != nil {
+		return err
+	}
+	set := urlSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, p := range pages {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+		loc := base.String()
+		if u := pageURL(p); u != "" {
+			loc = base.JoinPath(u).String()
+		}
+		set.URLs = append(set.URLs, sitemapURL{Loc: loc, LastMod: info.ModTime().UTC().Format("2006-01-02")})
+	}
+
+	data, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if err := os.WriteFile(filepath.Join(dir, SitemapFile), data, 0644); err != nil {
+		return err
+	}
+
+	robots := fmt.Sprintf("User-agent: *\nAllow: /\n\nSitemap: %s\n", base.JoinPath(SitemapFile))
+	return os.WriteFile(filepath.Join(dir, RobotsFile), []byte(robots), 0644)
+}
+
+// pageURL returns the URL path of a page, addressing index.html files by their directory.
+func pageURL(page string) string {
+	if page == "index.html" {
+		return ""
+	}
+	if strings.HasSuffix(page, "/index.html") {
+		return strings.TrimSuffix(page, "index.html")
+	}
+	return page
+}
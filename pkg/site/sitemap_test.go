@@ -0,0 +1,43 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteSitemap(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"index.html", "github.com/spf13/cobra/index.html", "github.com/spf13/cobra.md", "style.css"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("x"), 0644)
+	}
+
+	if err := WriteSitemap(dir, "https://docs.example.com/go"); err != nil {
+		t.Fatalf("WriteSitemap failed: %v", err)
+	}
+	sitemap, _ := os.ReadFile(filepath.Join(dir, SitemapFile))
+	for _, want := range []string{
+		"<loc>https://docs.example.com/go/</loc>",
+		"<loc>https://docs.example.com/go/github.com/spf13/cobra/</loc>",
+		"<loc>https://docs.example.com/go/github.com/spf13/cobra.md</loc>",
+	} {
+		if !strings.Contains(string(sitemap), want) {
+			t.Errorf("Expected %s in the sitemap, got %s", want, sitemap)
+		}
+	}
+	if strings.Contains(string(sitemap), "style.css") {
+		t.Error("Expected only pages in the sitemap")
+	}
+
+	robots, _ := os.ReadFile(filepath.Join(dir, RobotsFile))
+	if !strings.Contains(string(robots), "Sitemap: https://docs.example.com/go/sitemap.xml") {
+		t.Errorf("Expected robots.txt to reference the sitemap, got %q", robots)
+	}
+
+	if err := WriteSitemap(dir, "/go"); err == nil {
+		t.Error("Expected a relative base URL to be rejected")
+	}
+}
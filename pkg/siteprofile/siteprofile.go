@@ -0,0 +1,406 @@
+// Package siteprofile scrapes documentation sites other than pkg.go.dev by rules: a YAML profile
+// names the domains the scraper may visit, the URLs of documentation pages and the CSS selectors
+// each part of the package model is read from. Packages extracted this way go through the same
+// render and store pipeline as pkg.go.dev packages.
+package siteprofile
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/internal/utils"
+	"github.com/moseye/docinator/pkg/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the site profile format this package reads.
+const Version = 1
+
+// Profile describes how to scrape one documentation site.
+type Profile struct {
+	Version  int          `yaml:"version"` // profile format version; must not exceed Version
+	Name     string       `yaml:"name"`
+	Domains  []string     `yaml:"domains"`  // hosts the scraper may visit, e.g. docs.example.com; "*.example.com" allows every subdomain
+	URL      string       `yaml:"url"`      // page URL with "{path}" standing for the argument, e.g. https://docs.example.com/{path}
+	Patterns []string     `yaml:"patterns"` // regular expressions page URLs must match; empty allows every URL on Domains
+	Remove   parser.Chain `yaml:"remove"`   // elements dropped before extraction, e.g. navigation or permalink markers; every selector applies
+	Fields   Fields       `yaml:"fields"`
+
+	patterns []*regexp.Regexp
+}
+
+// Fields maps parts of the package model to selector chains. Scalar chains use the first element
+// found, except Description and Readme, which join every element found.
+type Fields struct {
+	Name        parser.Chain `yaml:"name"`
+	Synopsis    parser.Chain `yaml:"synopsis"`
+	Description parser.Chain `yaml:"description"`
+	Version     parser.Chain `yaml:"version"`
+	License     parser.Chain `yaml:"license"`
+	Repository  parser.Chain `yaml:"repository"` // the href of a link, or the text of other elements
+	Module      parser.Chain `yaml:"module"`
+	Readme      parser.Chain `yaml:"readme"` // HTML converted to markdown
+
+	Constants Decls    `yaml:"constants"`
+	Variables Decls    `yaml:"variables"`
+	Functions Decls    `yaml:"functions"`
+	Types     Decls    `yaml:"types"`
+	Methods   Decls    `yaml:"methods"` // named "Type.Method" and attached to that type
+	Examples  Examples `yaml:"examples"`
+}
+
+// Decls locates repeated declarations. Item matches one element per declaration; the other chains
+// are looked up relative to it: below it by default, "+ sel" for the element right after it
+// and "~ sel" for the elements after it up to the next one with its tag, as on sites that list a
+// heading followed by the declaration's code and doc.
+type Decls struct {
+	Item parser.Chain `yaml:"item"`
+	Name parser.Chain `yaml:"name"` // when it finds nothing, the item's id attribute
+	Code parser.Chain `yaml:"code"` // declaration or signature; without it, the item's own text
+	Doc  parser.Chain `yaml:"doc"`
+}
+
+// Examples locates runnable examples, relative to each Item like Decls.
+type Examples struct {
+	Item   parser.Chain `yaml:"item"`
+	Name   parser.Chain `yaml:"name"`
+	Code   parser.Chain `yaml:"code"`
+	Output parser.Chain `yaml:"output"`
+}
+
+// Load reads the YAML site profile at path (see Parse).
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse reads a YAML site profile. Unknown keys, a missing domain or URL template, patterns and
+// selectors that do not compile are errors.
+func Parse(data []byte) (*Profile, error) {
+	p := &Profile{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+	if p.Version > Version {
+		return nil, fmt.Errorf("site profile version %d is newer than the supported version %d", p.Version, Version)
+	}
+	if len(p.Domains) == 0 {
+		return nil, fmt.Errorf("site profile lists no domains")
+	}
+	if p.URL == "" {
+		return nil, fmt.Errorf("site profile has no url")
+	}
+	for _, pattern := range p.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	chains := p.Fields.chains()
+	chains["remove"] = p.Remove
+	for key, chain := range chains {
+		for _, sel := range chain {
+			_, sel = relation(sel)
+			if sel == "" {
+				return nil, fmt.Errorf("selector %s has an empty entry", key)
+			}
+			if _, err := cascadia.ParseGroup(sel); err != nil {
+				return nil, fmt.Errorf("selector %s: %w", key, err)
+			}
+		}
+	}
+	return p, nil
+}
+
+// chains maps each YAML key of f to its chain.
+func (f *Fields) chains() map[string]parser.Chain {
+	chains := map[string]parser.Chain{
+		"name": f.Name, "synopsis": f.Synopsis, "description": f.Description, "version": f.Version,
+		"license": f.License, "repository": f.Repository, "module": f.Module, "readme": f.Readme,
+		"examples.item": f.Examples.Item, "examples.name": f.Examples.Name,
+		"examples.code": f.Examples.Code, "examples.output": f.Examples.Output,
+	}
+	for key, d := range map[string]Decls{"constants": f.Constants, "variables": f.Variables, "functions": f.Functions, "types": f.Types, "methods": f.Methods} {
+		chains[key+".item"], chains[key+".name"], chains[key+".code"], chains[key+".doc"] = d.Item, d.Name, d.Code, d.Doc
+	}
+	return chains
+}
+
+// PageURL returns the URL of the page documenting arg: arg itself when it is an http(s) URL,
+// otherwise the URL template with arg in place of "{path}". The URL must be on one of the
+// profile's domains and match one of its patterns.
+func (p *Profile) PageURL(arg string) (string, error) {
+	page := arg
+	if !strings.HasPrefix(arg, "http://") && !strings.HasPrefix(arg, "https://") {
+		page = strings.ReplaceAll(p.URL, "{path}", strings.Trim(arg, "/"))
+	}
+	u, err := url.Parse(page)
+	if err != nil {
+		return "", err
+	}
+	if !p.allowed(u.Hostname()) {
+		return "", fmt.Errorf("%s is not on a domain of site profile %s", page, p.Name)
+	}
+	if len(p.patterns) == 0 {
+		return page, nil
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(page) {
+			return page, nil
+		}
+	}
+	return "", fmt.Errorf("%s matches no pattern of site profile %s", page, p.Name)
+}
+
+// Rebase returns a copy of the profile for the same kind of site served at base, e.g.
+// http://godoc.internal:6060: the scheme and host of the URL template become those of base, with
+// its path in front of the template's, and base's host is the only domain. Patterns are kept.
+func (p *Profile) Rebase(base string) (*Profile, error) {
+	u, err := url.Parse(strings.TrimSuffix(base, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("base %q is not an http(s) URL", base)
+	}
+	_, rest, ok := strings.Cut(p.URL, "://")
+	if !ok {
+		return nil, fmt.Errorf("site profile url %q has no scheme", p.URL)
+	}
+	path := ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		path = rest[i:]
+	}
+	rebased := *p
+	rebased.URL = u.String() + path
+	rebased.Domains = []string{u.Hostname()}
+	return &rebased, nil
+}
+
+func (p *Profile) allowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, d := range p.Domains {
+		d = strings.ToLower(d)
+		if suffix, ok := strings.CutPrefix(d, "*"); (ok && strings.HasSuffix(host, suffix)) || host == d {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportPath returns the import path a package scraped for arg is stored under: arg itself, or
+// the host and path of an http(s) URL.
+func ImportPath(arg string) string {
+	if u, err := url.Parse(arg); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return strings.TrimSuffix(u.Host+u.Path, "/")
+	}
+	return strings.Trim(arg, "/")
+}
+
+// Extract reads a package from doc, the page at pageURL, after dropping the elements matched by
+// Remove from it. Fields whose chains are empty or match nothing are left empty.
+func (p *Profile) Extract(doc *goquery.Selection, pageURL string) *models.Package {
+	for _, sel := range p.Remove {
+		doc.Find(sel).Remove()
+	}
+	f := &p.Fields
+	pkg := &models.Package{
+		Name:        text(find(doc, f.Name).First()),
+		Synopsis:    text(find(doc, f.Synopsis).First()),
+		Description: joinText(find(doc, f.Description)),
+		Version:     text(find(doc, f.Version).First()),
+		License:     text(find(doc, f.License).First()),
+		Module:      text(find(doc, f.Module).First()),
+		Repository:  link(find(doc, f.Repository).First(), pageURL),
+	}
+	if readme := find(doc, f.Readme); readme.Length() > 0 {
+		var html strings.Builder
+		readme.Each(func(_ int, s *goquery.Selection) {
+			h, _ := goquery.OuterHtml(s)
+			html.WriteString(h)
+		})
+		pkg.Readme = html.String()
+		pkg.ProcessedReadme = utils.ConvertHTMLToMarkdown(pkg.Readme)
+	}
+	if pkg.Synopsis == "" {
+		first, _, _ := strings.Cut(pkg.Description, "\n\n")
+		pkg.Synopsis = strings.Join(strings.Fields(first), " ")
+	}
+
+	for _, d := range f.Constants.each(doc) {
+		pkg.Constants = append(pkg.Constants, models.Constant{Name: d.Name, Value: d.Code, Description: d.Doc})
+	}
+	for _, d := range f.Variables.each(doc) {
+		pkg.Variables = append(pkg.Variables, models.Variable{Name: d.Name, Type: d.Code, Description: d.Doc})
+	}
+	for _, d := range f.Functions.each(doc) {
+		pkg.Functions = append(pkg.Functions, models.Function{Name: d.Name, Signature: d.Code, Description: d.Doc})
+	}
+	types := map[string]int{}
+	for _, d := range f.Types.each(doc) {
+		types[d.Name] = len(pkg.Types)
+		pkg.Types = append(pkg.Types, models.Type{Name: d.Name, Definition: d.Code, Description: d.Doc})
+	}
+	for _, d := range f.Methods.each(doc) {
+		method := models.Function{Name: d.Name, Signature: d.Code, Description: d.Doc}
+		recv, _, _ := strings.Cut(d.Name, ".")
+		if i, ok := types[recv]; ok {
+			pkg.Types[i].Methods = append(pkg.Types[i].Methods, method)
+		} else {
+			pkg.Functions = append(pkg.Functions, method)
+		}
+	}
+	find(doc, f.Examples.Item).Each(func(_ int, s *goquery.Selection) {
+		ex := models.Example{
+			Name:   text(find(s, f.Examples.Name).First()),
+			Code:   text(find(s, f.Examples.Code).First()),
+			Output: text(find(s, f.Examples.Output).First()),
+		}
+		if ex.Name == "" {
+			ex.Name = s.AttrOr("id", "")
+		}
+		if ex.Code != "" {
+			pkg.Examples = append(pkg.Examples, ex)
+		}
+	})
+	return pkg
+}
+
+// decl is one declaration found by a Decls.
+type decl struct {
+	Name, Code, Doc string
+}
+
+// each returns the declarations found in doc. Items without a name are skipped.
+func (d Decls) each(doc *goquery.Selection) []decl {
+	var decls []decl
+	find(doc, d.Item).Each(func(_ int, s *goquery.Selection) {
+		name := text(find(s, d.Name).First())
+		if name == "" {
+			name = s.AttrOr("id", "")
+		}
+		if name == "" {
+			return
+		}
+		code := text(find(s, d.Code).First())
+		if len(d.Code) == 0 {
+			code = text(s)
+		}
+		decls = append(decls, decl{Name: name, Code: code, Doc: text(find(s, d.Doc).First())})
+	})
+	return decls
+}
+
+// find returns the elements matched by the first selector of chain that matches any, or an empty
+// selection. Selectors are looked up below s, except that "+ sel" matches the element right after
+// s and "~ sel" the elements after s up to the next one with the same tag as s; sites that list
+// declarations as a heading followed by its code and doc use these.
+func find(s *goquery.Selection, chain parser.Chain) *goquery.Selection {
+	for _, sel := range chain {
+		var found *goquery.Selection
+		switch rel, sel := relation(sel); rel {
+		case '+':
+			found = s.Next().Filter(sel)
+		case '~':
+			found = s.NextUntil(goquery.NodeName(s)).Filter(sel)
+		default:
+			found = s.Find(sel)
+		}
+		if found.Length() > 0 {
+			return found
+		}
+	}
+	return s.Slice(0, 0)
+}
+
+// relation splits a leading "+" or "~" off sel.
+func relation(sel string) (byte, string) {
+	sel = strings.TrimSpace(sel)
+	if strings.HasPrefix(sel, "+") || strings.HasPrefix(sel, "~") {
+		return sel[0], strings.TrimSpace(sel[1:])
+	}
+	return 0, sel
+}
+
+// text returns the content attribute of s (for <meta> tags) or its trimmed text.
+func text(s *goquery.Selection) string {
+	if s.Length() == 0 {
+		return ""
+	}
+	if content, ok := s.Attr("content"); ok {
+		return strings.TrimSpace(content)
+	}
+	return strings.TrimSpace(s.Text())
+}
+
+// joinText joins the text of every element of s with blank lines.
+func joinText(s *goquery.Selection) string {
+	var parts []string
+	s.Each(func(_ int, e *goquery.Selection) {
+		if t := text(e); t != "" {
+			parts = append(parts, t)
+		}
+	})
+	return strings.Join(parts, "\n\n")
+}
+
+// link returns the href of s resolved against base, or its text when it has none.
+func link(s *goquery.Selection, base string) string {
+	href, ok := s.Attr("href")
+	if !ok {
+		return text(s)
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return b.ResolveReference(ref).String()
+}
+
+//go:embed profiles/*.yaml
+var builtins embed.FS
+
+// Builtins returns the names of the profiles embedded in the binary.
+func Builtins() []string {
+	entries, _ := builtins.ReadDir("profiles")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	return names
+}
+
+// Builtin returns the embedded profile called name, or nil when there is none.
+func Builtin(name string) *Profile {
+	data, err := builtins.ReadFile("profiles/" + name + ".yaml")
+	if err != nil {
+		return nil
+	}
+	p, err := Parse(data)
+	if err != nil {
+		panic("siteprofile: embedded " + name + ".yaml: " + err.Error())
+	}
+	return p
+}
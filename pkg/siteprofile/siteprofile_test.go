@@ -0,0 +1,201 @@
+package siteprofile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testProfile = `
+version: 1
+name: widgets
+domains: [docs.example.com]
+url: https://docs.example.com/pkg/{path}/
+patterns: ['^https://docs\.example\.com/pkg/']
+fields:
+  name: h1
+  description: "#overview p"
+  repository: a.repo
+  functions:
+    item: h2.func
+    code: "~ pre"
+    doc: "~ p"
+  types:
+    item: h2.type
+    code: "+ pre"
+  methods:
+    item: h3.method
+    code: "~ pre"
+`
+
+const testPage = `<html><body>
+<h1>widget</h1>
+<div id="overview"><p>Package widget makes widgets.</p><p>It is small.</p></div>
+<a class="repo" href="/src/widget">source</a>
+<h2 class="func" id="New">func New</h2><pre>func New() *Widget</pre><p>New returns a widget.</p>
+<h2 class="func" id="Undocumented">func Undocumented</h2><pre>func Undocumented()</pre>
+<h2 class="type" id="Widget">type Widget</h2><pre>type Widget struct{}</pre>
+<h3 class="method" id="Widget.Spin">func (w *Widget) Spin</h3><pre>func (w *Widget) Spin()</pre>
+</body></html>`
+
+func TestExtract(t *testing.T) {
+	p, err := Parse([]byte(testProfile))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := p.Extract(doc.Selection, "https://docs.example.com/pkg/widget/")
+
+	if pkg.Name != "widget" || pkg.Synopsis != "Package widget makes widgets." {
+		t.Errorf("Expected name and synopsis, got %q and %q", pkg.Name, pkg.Synopsis)
+	}
+	if pkg.Description != "Package widget makes widgets.\n\nIt is small." {
+		t.Errorf("Expected every overview paragraph, got %q", pkg.Description)
+	}
+	if pkg.Repository != "https://docs.example.com/src/widget" {
+		t.Errorf("Expected the resolved repository link, got %q", pkg.Repository)
+	}
+	if len(pkg.Functions) != 2 || pkg.Functions[0].Signature != "func New() *Widget" || pkg.Functions[0].Description != "New returns a widget." {
+		t.Fatalf("Expected two functions with siblings as code and doc, got %+v", pkg.Functions)
+	}
+	if pkg.Functions[1].Description != "" {
+		t.Errorf("Expected the doc lookup to stop at the next heading, got %q", pkg.Functions[1].Description)
+	}
+	if len(pkg.Types) != 1 || len(pkg.Types[0].Methods) != 1 || pkg.Types[0].Methods[0].Signature != "func (w *Widget) Spin()" {
+		t.Errorf("Expected Widget with its Spin method, got %+v", pkg.Types)
+	}
+}
+
+func TestPageURL(t *testing.T) {
+	p, err := Parse([]byte(testProfile))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got, err := p.PageURL("widget"); err != nil || got != "https://docs.example.com/pkg/widget/" {
+		t.Errorf("Expected the URL template to be filled in, got %q, %v", got, err)
+	}
+	if _, err := p.PageURL("https://docs.example.com/blog/"); err == nil {
+		t.Error("Expected URLs outside the patterns to be rejected")
+	}
+	if _, err := p.PageURL("https://example.org/pkg/widget/"); err == nil {
+		t.Error("Expected URLs on other domains to be rejected")
+	}
+	if got := ImportPath("https://docs.example.com/pkg/widget/"); got != "docs.example.com/pkg/widget" {
+		t.Errorf("Expected host and path, got %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, profile := range map[string]string{
+		"unknown key":  "domains: [a]\nurl: https://a/{path}\nfields: {title: h1}",
+		"no domains":   "url: https://a/{path}",
+		"bad pattern":  "domains: [a]\nurl: https://a/{path}\npatterns: ['(']",
+		"bad selector": "domains: [a]\nurl: https://a/{path}\nfields: {functions: {item: 'h2[', code: pre}}",
+		"newer":        "version: 2\ndomains: [a]\nurl: https://a/{path}",
+	} {
+		if _, err := Parse([]byte(profile)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBuiltinReadTheDocs(t *testing.T) {
+	p := Builtin("readthedocs")
+	if p == nil {
+		t.Fatalf("Expected a built-in readthedocs profile, got %v", Builtins())
+	}
+	if _, err := p.PageURL("cobra.readthedocs.io/en/latest/"); err != nil {
+		t.Errorf("Expected ReadTheDocs subdomains to be allowed, got %v", err)
+	}
+	if _, err := p.PageURL("https://example.com/docs/"); err == nil {
+		t.Error("Expected other hosts to be rejected")
+	}
+
+	page := `<html><head><meta name="description" content="Cobra user guide"></head><body>
+<div role="navigation">Docs » User Guide</div>
+<div role="main" class="document"><div itemprop="articleBody">
+<h1>User Guide<a class="headerlink" href="#user-guide">¶</a></h1>
+<p>Cobra is built on commands.</p>
+</div></div></body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := p.Extract(doc.Selection, "https://cobra.readthedocs.io/en/latest/user_guide/")
+	if pkg.Name != "User Guide" || pkg.Synopsis != "Cobra user guide" {
+		t.Errorf("Expected the title and description, got %q and %q", pkg.Name, pkg.Synopsis)
+	}
+	if !strings.Contains(pkg.ProcessedReadme, "Cobra is built on commands.") || strings.Contains(pkg.ProcessedReadme, "¶") || strings.Contains(pkg.ProcessedReadme, "Docs »") {
+		t.Errorf("Expected the article without permalinks and navigation, got %q", pkg.ProcessedReadme)
+	}
+}
+
+// godocPage mimics the package page of a classic godoc -http server.
+const godocPage = `<html><body><div id="page"><h1>Package widget</h1>
+<div id="pkg-overview" class="toggleVisible">
+<div class="collapsed"><h2 class="toggleButton">Overview ▹</h2></div>
+<div class="expanded"><h2 class="toggleButton">Overview ▾</h2>
+<p>Package widget makes
+widgets.</p>
+<p>It is small.</p>
+</div></div>
+<h2 id="pkg-constants">Constants</h2>
+<pre>const <span id="Size">Size</span> = 3</pre>
+<p>Mode is the default mode.</p>
+<pre>const <span id="Mode">Mode</span> = "fast"</pre>
+<h2 id="pkg-variables">Variables</h2>
+<pre>var <span id="ErrBroken">ErrBroken</span> = errors.New("broken")</pre>
+<h2 id="New">func <a href="/src/widget/widget.go?s=1:2#L10">New</a> <a class="permalink" href="#New">¶</a></h2>
+<pre>func New() *<a href="#Widget">Widget</a></pre>
+<p>New returns a widget.</p>
+<div id="example_New" class="toggle"><div class="expanded"><p>Code:</p><pre class="code">w := widget.New()</pre><p>Output:</p><pre class="output">ok</pre></div></div>
+<h2 id="Widget">type <a href="/src/widget/widget.go?s=3:4#L20">Widget</a></h2>
+<p>Widget is a widget.</p>
+<pre>type Widget struct{}</pre>
+<h3 id="Make">func <a href="#">Make</a></h3>
+<pre>func Make() Widget</pre>
+<h3 id="Widget.Spin">func (*Widget) <a href="#">Spin</a></h3>
+<pre>func (w *<a href="#Widget">Widget</a>) Spin()</pre>
+<p>Spin spins.</p>
+</div></body></html>`
+
+func TestBuiltinGodoc(t *testing.T) {
+	p, err := Builtin("godoc").Rebase("http://godoc.internal:6060/")
+	if err != nil {
+		t.Fatalf("Rebase failed: %v", err)
+	}
+	if got, err := p.PageURL("example.com/widget"); err != nil || got != "http://godoc.internal:6060/pkg/example.com/widget/" {
+		t.Errorf("Expected the page on the rebased server, got %q, %v", got, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(godocPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := p.Extract(doc.Selection, "http://godoc.internal:6060/pkg/example.com/widget/")
+	if pkg.Synopsis != "Package widget makes widgets." {
+		t.Errorf("Expected the first overview paragraph as synopsis, got %q", pkg.Synopsis)
+	}
+	if len(pkg.Constants) != 2 || pkg.Constants[1].Name != "Mode" || pkg.Constants[1].Value != `const Mode = "fast"` {
+		t.Errorf("Expected the constant blocks up to the variables, got %+v", pkg.Constants)
+	}
+	if len(pkg.Variables) != 1 || pkg.Variables[0].Name != "ErrBroken" {
+		t.Errorf("Expected one variable block, got %+v", pkg.Variables)
+	}
+	if len(pkg.Functions) != 2 || pkg.Functions[0].Signature != "func New() *Widget" || pkg.Functions[0].Description != "New returns a widget." || pkg.Functions[1].Name != "Make" {
+		t.Errorf("Expected New and the constructor Make, got %+v", pkg.Functions)
+	}
+	if len(pkg.Types) != 1 || pkg.Types[0].Definition != "type Widget struct{}" || pkg.Types[0].Description != "Widget is a widget." {
+		t.Fatalf("Expected Widget with its doc, got %+v", pkg.Types)
+	}
+	if m := pkg.Types[0].Methods; len(m) != 1 || m[0].Name != "Widget.Spin" || m[0].Description != "Spin spins." {
+		t.Errorf("Expected the Spin method on Widget, got %+v", m)
+	}
+	if len(pkg.Examples) != 1 || pkg.Examples[0].Code != "w := widget.New()" || pkg.Examples[0].Output != "ok" {
+		t.Errorf("Expected the New example, got %+v", pkg.Examples)
+	}
+}
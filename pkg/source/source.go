@@ -0,0 +1,179 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// maxFileSize bounds the size of a downloaded source file.
+const maxFileSize = 4 << 20
+
+// Fetcher downloads repository source files and extracts declaration bodies from them.
+// Files are cached per raw URL, so symbols sharing a file cost a single request.
+type Fetcher struct {
+	client *http.Client
+	mu     sync.Mutex
+	files  map[string][]byte
+}
+
+// NewFetcher creates a Fetcher using client (http.DefaultClient when nil).
+func NewFetcher(client *http.Client) *Fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Fetcher{client: client, files: make(map[string][]byte)}
+}
+
+// Enrich fills in the Source field of every function, type and method that has a source link
+// but no source yet. It returns the number of declarations enriched and the first error seen.
+func (f *Fetcher) Enrich(ctx context.Context, pkg *models.Package) (int, error) {
+	count := 0
+	var firstErr error
+	fill := func(sourceURL string, line int, dst *string) {
+		if *dst != "" || sourceURL == "" || line <= 0 {
+			return
+		}
+		code, err := f.Declaration(ctx, sourceURL, line)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		*dst = code
+		count++
+	}
+
+	for i := range pkg.Functions {
+		fn := &pkg.Functions[i]
+		fill(fn.SourceURL, fn.SourceLine, &fn.Source)
+	}
+	for i := range pkg.Types {
+		t := &pkg.Types[i]
+		fill(t.SourceURL, t.SourceLine, &t.Source)
+		for j := range t.Methods {
+			m := &t.Methods[j]
+			fill(m.SourceURL, m.SourceLine, &m.Source)
+		}
+	}
+	return count, firstErr
+}
+
+// Declaration returns the source of the declaration at line in the file linked by sourceURL.
+func (f *Fetcher) Declaration(ctx context.Context, sourceURL string, line int) (string, error) {
+	raw, ok := RawURL(sourceURL)
+	if !ok {
+		return "", fmt.Errorf("unsupported source host: %s", sourceURL)
+	}
+	src, err := f.file(ctx, raw)
+	if err != nil {
+		return "", err
+	}
+	return Extract(src, line)
+}
+
+// file returns the contents of raw, downloading it on first use.
+func (f *Fetcher) file(ctx context.Context, raw string) ([]byte, error) {
+	f.mu.Lock()
+	src, ok := f.files[raw]
+	f.mu.Unlock()
+	if ok {
+		return src, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", raw, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", raw, res.StatusCode)
+	}
+	src, err = io.ReadAll(io.LimitReader(res.Body, maxFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", raw, err)
+	}
+
+	f.mu.Lock()
+	f.files[raw] = src
+	f.mu.Unlock()
+	return src, nil
+}
+
+// RawURL converts a repository "view file" link into a URL serving the plain file contents.
+// Supported hosts are GitHub, GitLab, Bitbucket and the Go standard library on cs.opensource.google.
+func RawURL(sourceURL string) (string, bool) {
+	u, _, _ := strings.Cut(sourceURL, "#")
+	u, _, _ = strings.Cut(u, "?")
+
+	switch {
+	case strings.HasPrefix(u, "https://github.com/") && strings.Contains(u, "/blob/"):
+		u = strings.Replace(u, "https://github.com/", "https://raw.githubusercontent.com/", 1)
+		return strings.Replace(u, "/blob/", "/", 1), true
+	case strings.HasPrefix(u, "https://gitlab.com/") && strings.Contains(u, "/-/blob/"):
+		return strings.Replace(u, "/-/blob/", "/-/raw/", 1), true
+	case strings.HasPrefix(u, "https://bitbucket.org/") && strings.Contains(u, "/src/"):
+		return strings.Replace(u, "/src/", "/raw/", 1), true
+	case strings.HasPrefix(u, "https://cs.opensource.google/go/go/+/"):
+		// https://cs.opensource.google/go/go/+/go1.22.0:src/net/http/client.go;l=42
+		rest := strings.TrimPrefix(u, "https://cs.opensource.google/go/go/+/")
+		rest, _, _ = strings.Cut(rest, ";")
+		ref, path, ok := strings.Cut(rest, ":")
+		if !ok {
+			return "", false
+		}
+		return "https://raw.githubusercontent.com/golang/go/" + ref + "/" + path, true
+	}
+	return "", false
+}
+
+// Extract returns the source text of the top-level declaration that starts at, or contains, line.
+// For grouped declarations (type ( ... )) only the matching spec is returned, prefixed with its keyword.
+func Extract(src []byte, line int) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse source: %w", err)
+	}
+	text := func(from, to token.Pos) string {
+		return string(bytes.TrimSpace(src[fset.Position(from).Offset:fset.Position(to).Offset]))
+	}
+	contains := func(n ast.Node) bool {
+		return fset.Position(n.Pos()).Line <= line && line <= fset.Position(n.End()).Line
+	}
+
+	for _, decl := range file.Decls {
+		if !contains(decl) {
+			continue
+		}
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			return text(d.Pos(), d.End()), nil
+		case *ast.GenDecl:
+			if !d.Lparen.IsValid() {
+				return text(d.Pos(), d.End()), nil
+			}
+			for _, spec := range d.Specs {
+				if contains(spec) {
+					return d.Tok.String() + " " + text(spec.Pos(), spec.End()), nil
+				}
+			}
+			return text(d.Pos(), d.End()), nil
+		}
+	}
+	return "", fmt.Errorf("no declaration found at line %d", line)
+}
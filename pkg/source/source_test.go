@@ -0,0 +1,63 @@
+package source
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `package sample
+
+// Thing is documented.
+type Thing struct {
+	Name string
+}
+
+type (
+	A int
+	B string
+)
+
+// Do does things.
+func (t *Thing) Do() error {
+	return nil
+}
+`
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		line int
+		want string
+	}{
+		{4, "type Thing struct {\n\tName string\n}"},
+		{10, "type B string"},
+		{14, "func (t *Thing) Do() error {\n\treturn nil\n}"},
+	}
+	for _, tt := range tests {
+		got, err := Extract([]byte(sample), tt.line)
+		if err != nil {
+			t.Fatalf("Extract(line %d) returned error: %v", tt.line, err)
+		}
+		if got != tt.want {
+			t.Errorf("Extract(line %d) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+	if _, err := Extract([]byte(sample), 2); err == nil || !strings.Contains(err.Error(), "no declaration") {
+		t.Errorf("Expected an error for a line outside any declaration, got %v", err)
+	}
+}
+
+func TestRawURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/spf13/cobra/blob/v1.9.1/command.go#L10":                 "https://raw.githubusercontent.com/spf13/cobra/v1.9.1/command.go",
+		"https://gitlab.com/o/r/-/blob/v1.0.0/x.go#L1":                              "https://gitlab.com/o/r/-/raw/v1.0.0/x.go",
+		"https://cs.opensource.google/go/go/+/go1.22.0:src/net/http/client.go;l=42": "https://raw.githubusercontent.com/golang/go/go1.22.0/src/net/http/client.go",
+	}
+	for in, want := range tests {
+		if got, ok := RawURL(in); !ok || got != want {
+			t.Errorf("RawURL(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := RawURL("https://example.com/file.go"); ok {
+		t.Error("Unknown hosts should not be supported")
+	}
+}
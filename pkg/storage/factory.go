@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/moseye/docinator/internal/models"
+	boltstore "github.com/moseye/docinator/internal/storage/bolt"
+	memstore "github.com/moseye/docinator/internal/storage/memory"
+	mongostore "github.com/moseye/docinator/internal/storage/mongo"
+	"github.com/moseye/docinator/internal/storage/retention"
+)
+
+// NamespaceEnv names the environment variable scoping documents to a namespace, such as a team or
+// project, within a shared MongoDB database or bbolt file.
+const NamespaceEnv = "MONGODB_NAMESPACE"
+
+// namespacePattern matches the namespaces CheckNamespace accepts.
+var namespacePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// CheckNamespace reports whether namespace can name a namespace: up to 64 letters, digits,
+// underscores and hyphens. The empty namespace is the default one.
+func CheckNamespace(namespace string) error {
+	if namespace != "" && !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace %q: use up to 64 letters, digits, '_' and '-'", namespace)
+	}
+	return nil
+}
+
+// Backends lists the names accepted by Open.
+var Backends = []string{"auto", "mongo", "bolt", "fs", "memory", "none"}
+
+// Canonical returns the backend Open constructs for the name backend: "bolt" for its alias "fs",
+// backend itself otherwise.
+func Canonical(backend string) string {
+	if backend == "fs" {
+		return "bolt"
+	}
+	return backend
+}
+
+// Open constructs the named backend:
+// - auto: mongo when MONGODB_URI is set, else bolt when BOLT_PATH is set, else none
+// - mongo: MongoDB configured from MONGODB_* env (MONGODB_URI required)
+// - bolt, or fs: bbolt file at BOLT_PATH (required)
+// - memory: process-local in-memory store
+// - none: a disabled store that caches nothing
+//
+// MongoDB and bbolt keep documents of the namespace in MONGODB_NAMESPACE apart from the others.
+// Every backend enforces the retention policy in DOCINATOR_KEEP_SNAPSHOTS and
+// DOCINATOR_RAW_HTML_DAYS on upsert (see retention.FromEnv).
+func Open(ctx context.Context, backend string) (Store, error) {
+	if err := CheckNamespace(os.Getenv(NamespaceEnv)); err != nil {
+		return nil, err
+	}
+	policy, err := retention.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	switch Canonical(backend) {
+	case "auto", "":
+		switch {
+		case os.Getenv("MONGODB_URI") != "":
+			return Open(ctx, "mongo")
+		case os.Getenv("BOLT_PATH") != "":
+			return Open(ctx, "bolt")
+		}
+		return Disabled(), nil
+	case "mongo":
+		if os.Getenv("MONGODB_URI") == "" {
+			return nil, errors.New("--store mongo requires MONGODB_URI")
+		}
+		s, err := mongostore.NewFromEnv(ctx)
+		if err != nil {
+			return s, err
+		}
+		return withRetention(s, policy), nil
+	case "bolt":
+		if os.Getenv("BOLT_PATH") == "" {
+			return nil, fmt.Errorf("--store %s requires BOLT_PATH", backend)
+		}
+		s, err := boltstore.NewFromEnv(ctx)
+		if err != nil {
+			return s, err
+		}
+		return withRetention(s, policy), nil
+	case "memory":
+		return withRetention(memstore.New(), policy), nil
+	case "none":
+		return Disabled(), nil
+	}
+	return nil, fmt.Errorf("unknown store backend %q (want one of %v)", backend, Backends)
+}
+
+// withRetention sets policy on s when it is an enabled backend enforcing one, and returns it.
+func withRetention(s Store, policy retention.Policy) Store {
+	if r, ok := s.(interface{ SetRetention(retention.Policy) }); ok && s.Enabled() {
+		r.SetRetention(policy)
+	}
+	return s
+}
+
+// Disabled returns a store that is never enabled and fails every operation.
+func Disabled() Store {
+	return disabled{}
+}
+
+type disabled struct{}
+
+var errDisabled = errors.New("store disabled")
+
+func (disabled) Enabled() bool                   { return false }
+func (disabled) Close(ctx context.Context) error { return nil }
+func (disabled) GetByID(ctx context.Context, id string) (*models.Document, error) {
+	return nil, errDisabled
+}
+func (disabled) Upsert(ctx context.Context, doc *models.Document) error { return errDisabled }
+func (disabled) Delete(ctx context.Context, id string) error            { return errDisabled }
+func (disabled) ForEach(ctx context.Context, fn func(*models.Document) error) error {
+	return errDisabled
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("MONGODB_URI", "")
+	t.Setenv("BOLT_PATH", "")
+
+	for backend, enabled := range map[string]bool{"auto": false, "none": false, "memory": true} {
+		store, err := Open(ctx, backend)
+		if err != nil {
+			t.Fatalf("Expected %s to open, got %v", backend, err)
+		}
+		if store.Enabled() != enabled {
+			t.Errorf("Expected %s Enabled() to be %v", backend, enabled)
+		}
+	}
+
+	for _, backend := range []string{"mongo", "bolt", "fs", "sqlite"} {
+		if _, err := Open(ctx, backend); err == nil {
+			t.Errorf("Expected %s to fail without configuration", backend)
+		}
+	}
+
+	t.Setenv("BOLT_PATH", t.TempDir()+"/docinator.db")
+	store, err := Open(ctx, "auto")
+	if err != nil || !store.Enabled() {
+		t.Fatalf("Expected auto to pick bolt from BOLT_PATH, got %v", err)
+	}
+	store.Close(ctx)
+
+	store, err = Open(ctx, "fs")
+	if err != nil || !store.Enabled() {
+		t.Fatalf("Expected fs to open the bbolt file at BOLT_PATH, got %v", err)
+	}
+	store.Close(ctx)
+}
+
+func TestCheckNamespace(t *testing.T) {
+	for namespace, valid := range map[string]bool{"": true, "team-a": true, "Proj_2": true, "a.b": false, "a/b": false, "team a": false} {
+		if err := CheckNamespace(namespace); (err == nil) != valid {
+			t.Errorf("CheckNamespace(%q) = %v, want valid=%v", namespace, err, valid)
+		}
+	}
+
+	t.Setenv(NamespaceEnv, "bad/namespace")
+	if _, err := Open(context.Background(), "memory"); err == nil {
+		t.Error("Expected Open to reject an invalid namespace")
+	}
+}
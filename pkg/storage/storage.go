@@ -1,3 +1,62 @@
 package storage
 
-// TODO: Implement storage functionality using BoltDB
+import (
+	"context"
+
+	"github.com/moseye/docinator/internal/models"
+	"github.com/moseye/docinator/pkg/symdoc"
+)
+
+// Store is the document cache shared by all storage backends.
+type Store interface {
+	// Enabled reports whether the store is active; disabled stores fail every operation.
+	Enabled() bool
+	// Close releases the backend's resources.
+	Close(ctx context.Context) error
+	// GetByID returns the document stored under an import path, or nil if there is none.
+	GetByID(ctx context.Context, id string) (*models.Document, error)
+	// Upsert replaces the document by ID or inserts it if missing.
+	Upsert(ctx context.Context, doc *models.Document) error
+	// Delete removes the document stored under an import path; deleting a missing document is not an error.
+	Delete(ctx context.Context, id string) error
+	// ForEach streams every stored document, without raw HTML, to fn until fn returns an error.
+	ForEach(ctx context.Context, fn func(*models.Document) error) error
+}
+
+// SymbolStore is implemented by stores that index the symbols of the documents they store, so a
+// single symbol is served without decoding the whole package.
+type SymbolStore interface {
+	// GetSymbol returns the symbol name ("New", "Command" or "Command.Execute") of the document
+	// stored under id, or nil if either is not indexed.
+	GetSymbol(ctx context.Context, id, name string) (*symdoc.Snippet, error)
+}
+
+// ModuleStore is implemented by stores that keep the packages of each module version together,
+// so a whole module is served in one read.
+type ModuleStore interface {
+	// GetModule returns every stored package of module at version, or nil if none is.
+	GetModule(ctx context.Context, module, version string) (*models.ModuleDocument, error)
+}
+
+// GetModule returns every package of module at version in store, from one read when store is a
+// ModuleStore and by going through all documents otherwise, or nil if none is stored.
+func GetModule(ctx context.Context, store Store, module, version string) (*models.ModuleDocument, error) {
+	if ms, ok := store.(ModuleStore); ok {
+		m, err := ms.GetModule(ctx, module, version)
+		if err != nil || m != nil {
+			return m, err
+		}
+		// Not found: documents stored before the store kept modules are not in it yet.
+	}
+	var pkgs []*models.Package
+	err := store.ForEach(ctx, func(doc *models.Document) error {
+		if pkg := doc.Package; pkg != nil && pkg.Module == module && pkg.Version == version {
+			pkgs = append(pkgs, pkg)
+		}
+		return nil
+	})
+	if err != nil || len(pkgs) == 0 {
+		return nil, err
+	}
+	return models.NewModuleDocument(module, version, pkgs), nil
+}
@@ -0,0 +1,214 @@
+// Package symdoc looks up a single symbol of a package and renders it compactly, for quick
+// lookups from editors and chat bots that do not need the whole package.
+package symdoc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// ErrNotFound is returned by Find for a symbol the package does not declare.
+var ErrNotFound = errors.New("symbol not found")
+
+// Snippet is the documentation of one symbol.
+type Snippet struct {
+	ImportPath  string           `json:"import_path"`
+	Version     string           `json:"version,omitempty"`
+	Kind        string           `json:"kind"` // const, var, func, type, method or field
+	Name        string           `json:"name"` // e.g. "Command.Execute" for a method
+	Signature   string           `json:"signature,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Deprecated  bool             `json:"deprecated,omitempty"`
+	AddedIn     string           `json:"added_in,omitempty"`
+	SourceURL   string           `json:"source_url,omitempty"`
+	Methods     []string         `json:"methods,omitempty"` // method signatures of a type
+	Examples    []models.Example `json:"examples,omitempty"`
+}
+
+// Split splits a symbol reference such as "github.com/spf13/cobra.Command.Execute",
+// "net/http.Client" or "gopkg.in/yaml.v3@v3.0.1.Node" into the import path, with its version
+// when pinned, and the symbol. The symbol starts at the first dot of the last path element that
+// is followed by an upper-case letter.
+func Split(ref string) (importPath, symbol string, ok bool) {
+	start := strings.LastIndex(ref, "/") + 1
+	for i := start; i < len(ref)-1; i++ {
+		if ref[i] == '.' && ref[i+1] >= 'A' && ref[i+1] <= 'Z' {
+			return ref[:i], ref[i+1:], i > 0
+		}
+	}
+	return "", "", false
+}
+
+// Find returns the snippet of symbol in pkg: a constant, variable, function or type name, or
+// "Type.Method" or "Type.Field".
+func Find(pkg *models.Package, symbol string) (*Snippet, error) {
+	s := &Snippet{ImportPath: pkg.ImportPath, Version: pkg.Version, Name: symbol}
+	typeName, member, isMember := strings.Cut(symbol, ".")
+	for _, c := range pkg.Constants {
+		if c.Name == symbol {
+			s.Kind, s.Signature, s.Description = "const", strings.TrimSpace("const "+c.Name+" "+c.Type), c.Description
+			if c.Value != "" {
+				s.Signature += " = " + c.Value
+			}
+			return s, nil
+		}
+	}
+	for _, v := range pkg.Variables {
+		if v.Name == symbol {
+			s.Kind, s.Signature, s.Description = "var", strings.TrimSpace("var "+v.Name+" "+v.Type), v.Description
+			return s, nil
+		}
+	}
+	for _, f := range pkg.Functions {
+		if f.Name == symbol {
+			s.Kind = "func"
+			s.fromFunction(f)
+			return s, nil
+		}
+	}
+	for _, t := range pkg.Types {
+		if t.Name != typeName {
+			continue
+		}
+		if !isMember {
+			s.Kind, s.Signature, s.Description = "type", t.Definition, t.Description
+			s.Deprecated, s.AddedIn, s.SourceURL, s.Examples = deprecated(t.Deprecated, t.Description), t.AddedIn, t.SourceURL, t.Examples
+			for _, m := range t.Methods {
+				s.Methods = append(s.Methods, m.Signature)
+			}
+			return s, nil
+		}
+		for _, m := range t.Methods {
+			if m.Name == symbol || m.Name == member {
+				s.Kind = "method"
+				s.fromFunction(m)
+				return s, nil
+			}
+		}
+		for _, m := range t.InterfaceMethods {
+			if m.Name == member {
+				s.Kind, s.Signature, s.Description = "method", m.Signature, m.Description
+				return s, nil
+			}
+		}
+		for _, f := range t.Fields {
+			if f.Name == member {
+				s.Kind, s.Signature, s.Description = "field", strings.TrimSpace(f.Name+" "+f.Type), f.Doc
+				return s, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s.%s: %w", pkg.ImportPath, symbol, ErrNotFound)
+}
+
+// All returns the snippets of every constant, variable, function, type and method of pkg, for
+// stores that index symbols. Methods are named "Type.Method".
+func All(pkg *models.Package) []Snippet {
+	var names []string
+	for _, c := range pkg.Constants {
+		names = append(names, c.Name)
+	}
+	for _, v := range pkg.Variables {
+		names = append(names, v.Name)
+	}
+	for _, f := range pkg.Functions {
+		names = append(names, f.Name)
+	}
+	for _, t := range pkg.Types {
+		names = append(names, t.Name)
+		for _, m := range t.Methods {
+			if strings.Contains(m.Name, ".") {
+				names = append(names, m.Name)
+			} else {
+				names = append(names, t.Name+"."+m.Name)
+			}
+		}
+	}
+	var out []Snippet
+	seen := map[string]bool{}
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if s, err := Find(pkg, name); err == nil {
+			out = append(out, *s)
+		}
+	}
+	return out
+}
+
+func (s *Snippet) fromFunction(f models.Function) {
+	s.Signature, s.Description, s.Deprecated = f.Signature, f.Description, deprecated(f.Deprecated, f.Description)
+	s.AddedIn, s.SourceURL, s.Examples = f.AddedIn, f.SourceURL, f.Examples
+}
+
+// Text renders the snippet as plain text: the signature, a line with the package, version and
+// source, the doc comment, the methods of a type and, with examples, its examples.
+func (s *Snippet) Text(examples bool) string {
+	var b strings.Builder
+	if s.Signature != "" {
+		b.WriteString(s.Signature + "\n")
+	} else {
+		b.WriteString(s.Kind + " " + s.Name + "\n")
+	}
+	meta := []string{s.ImportPath}
+	if s.Version != "" {
+		meta = append(meta, s.Version)
+	}
+	if s.AddedIn != "" {
+		meta = append(meta, "since "+s.AddedIn)
+	}
+	if s.Deprecated {
+		meta = append(meta, "DEPRECATED")
+	}
+	if s.SourceURL != "" {
+		meta = append(meta, s.SourceURL)
+	}
+	fmt.Fprintf(&b, "    %s\n", strings.Join(meta, " · "))
+	if s.Description != "" {
+		b.WriteString("\n" + strings.TrimSpace(s.Description) + "\n")
+	}
+	if len(s.Methods) > 0 {
+		b.WriteString("\nMethods:\n")
+		for _, m := range s.Methods {
+			b.WriteString("    " + firstLine(m) + "\n")
+		}
+	}
+	if examples {
+		for _, ex := range s.Examples {
+			fmt.Fprintf(&b, "\nExample %s:\n", ex.Name)
+			b.WriteString(indent(ex.Code))
+			if ex.Output != "" {
+				b.WriteString("  Output:\n")
+				b.WriteString(indent(ex.Output))
+			}
+		}
+	}
+	return b.String()
+}
+
+// deprecated reports whether a symbol is tagged deprecated or documented with "Deprecated:".
+func deprecated(tag, description string) bool {
+	return tag != "" || strings.HasPrefix(description, "Deprecated:") || strings.Contains(description, "\nDeprecated:")
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// indent indents every non-empty line of s by four spaces.
+func indent(s string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		if line != "" {
+			b.WriteString("    " + line)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
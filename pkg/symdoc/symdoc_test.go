@@ -0,0 +1,73 @@
+package symdoc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestSplit(t *testing.T) {
+	for ref, want := range map[string][2]string{
+		"github.com/spf13/cobra.Command.Execute": {"github.com/spf13/cobra", "Command.Execute"},
+		"net/http.Client":                        {"net/http", "Client"},
+		"fmt.Println":                            {"fmt", "Println"},
+		"gopkg.in/yaml.v3.Node":                  {"gopkg.in/yaml.v3", "Node"},
+		"github.com/spf13/cobra@v1.8.0.Command":  {"github.com/spf13/cobra@v1.8.0", "Command"},
+	} {
+		path, symbol, ok := Split(ref)
+		if !ok || path != want[0] || symbol != want[1] {
+			t.Errorf("Split(%q) = %q, %q, %v", ref, path, symbol, ok)
+		}
+	}
+	for _, ref := range []string{"github.com/spf13/cobra", ".Command", "fmt.println"} {
+		if _, _, ok := Split(ref); ok {
+			t.Errorf("Split(%q): expected no symbol", ref)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	pkg := &models.Package{
+		ImportPath: "github.com/spf13/cobra",
+		Version:    "v1.8.0",
+		Constants:  []models.Constant{{Name: "BashCompFilenameExt", Value: `"cobra_annotation_bash_completion_filename_extensions"`}},
+		Types: []models.Type{{
+			Name:       "Command",
+			Definition: "type Command struct {\n\tUse string\n}",
+			Fields:     []models.Field{{Name: "Use", Type: "string", Doc: "Use is the one-line usage message."}},
+			Methods: []models.Function{{
+				Name:        "Command.Execute",
+				Signature:   "func (c *Command) Execute() error",
+				Description: "Execute uses the args (os.Args[1:] by default).",
+				SourceURL:   "https://github.com/spf13/cobra/blob/v1.8.0/command.go#L1071",
+				Examples:    []models.Example{{Name: "Command.Execute", Code: "cmd.Execute()", Output: "done"}},
+			}},
+		}},
+	}
+
+	s, err := Find(pkg, "Command.Execute")
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	want := "func (c *Command) Execute() error\n" +
+		"    github.com/spf13/cobra · v1.8.0 · https://github.com/spf13/cobra/blob/v1.8.0/command.go#L1071\n\n" +
+		"Execute uses the args (os.Args[1:] by default).\n\n" +
+		"Example Command.Execute:\n    cmd.Execute()\n  Output:\n    done\n"
+	if got := s.Text(true); got != want {
+		t.Errorf("Text =\n%s\nwant\n%s", got, want)
+	}
+
+	if s, err := Find(pkg, "Command"); err != nil || s.Kind != "type" || len(s.Methods) != 1 {
+		t.Errorf("Expected the type with its methods, got %+v (%v)", s, err)
+	}
+	if s, err := Find(pkg, "Command.Use"); err != nil || s.Kind != "field" || s.Signature != "Use string" {
+		t.Errorf("Expected the field, got %+v (%v)", s, err)
+	}
+	if s, err := Find(pkg, "BashCompFilenameExt"); err != nil || s.Kind != "const" {
+		t.Errorf("Expected the constant, got %+v (%v)", s, err)
+	}
+	if _, err := Find(pkg, "Command.Run"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
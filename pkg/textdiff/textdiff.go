@@ -0,0 +1,162 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of an Edit.
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of a diff.
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// maxCells bounds the LCS table; larger changed regions are reported as a block replacement.
+const maxCells = 25_000_000
+
+// Lines returns the edits turning a into b, based on a longest common subsequence of lines.
+func Lines(a, b []string) []Edit {
+	// Trim the common prefix and suffix, which covers most of typical documentation changes.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var edits []Edit
+	for _, line := range a[:pre] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, middle(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, line := range a[len(a)-suf:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	return edits
+}
+
+// middle diffs the differing core of two inputs with a dynamic-programming LCS.
+func middle(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	var edits []Edit
+	if n*m > maxCells {
+		for _, line := range a {
+			edits = append(edits, Edit{Delete, line})
+		}
+		for _, line := range b {
+			edits = append(edits, Edit{Insert, line})
+		}
+		return edits
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, Edit{Equal, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, Edit{Delete, a[i]})
+			i++
+		default:
+			edits = append(edits, Edit{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		edits = append(edits, Edit{Delete, a[i]})
+	}
+	for ; j < m; j++ {
+		edits = append(edits, Edit{Insert, b[j]})
+	}
+	return edits
+}
+
+// Unified renders a line diff of a and b in unified format with the given lines of context.
+// It returns an empty string when the inputs are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	edits := Lines(strings.Split(a, "\n"), strings.Split(b, "\n"))
+
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change.
+		for start < len(edits) && edits[start].Op == Equal {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		// Extend the hunk while changes are within 2*context lines of each other.
+		end := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].Op != Equal {
+				end = k + 1
+			} else if k-end >= 2*context {
+				break
+			}
+		}
+		lo := max(start-context, 0)
+		hi := min(end+context, len(edits))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		aLine, bLine := positions(edits, lo)
+		aCount, bCount := 0, 0
+		for _, e := range edits[lo:hi] {
+			if e.Op != Insert {
+				aCount++
+			}
+			if e.Op != Delete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, e := range edits[lo:hi] {
+			out.WriteString([]string{" ", "-", "+"}[e.Op] + e.Text + "\n")
+		}
+		start = hi
+	}
+	return out.String()
+}
+
+// positions returns the 1-based line numbers in a and b at which edit index k starts.
+func positions(edits []Edit, k int) (int, int) {
+	a, b := 1, 1
+	for _, e := range edits[:k] {
+		if e.Op != Insert {
+			a++
+		}
+		if e.Op != Delete {
+			b++
+		}
+	}
+	return a, b
+}
@@ -0,0 +1,30 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"a", "c", "x", "d"}
+	var got []string
+	for _, e := range Lines(a, b) {
+		got = append(got, []string{" ", "-", "+"}[e.Op]+e.Text)
+	}
+	if strings.Join(got, ",") != " a,-b, c,+x, d" {
+		t.Errorf("Unexpected edits %v", got)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven"
+	b := "one\ntwo\nTHREE\nfour\nfive\nsix\nseven"
+	want := "--- old\n+++ new\n@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n"
+	if got := Unified("old", "new", a, b, 1); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+	if got := Unified("old", "new", a, a, 3); got != "" {
+		t.Errorf("Expected no diff for equal inputs, got %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+// Package webhook posts scraped packages as JSON to an HTTP endpoint, signed with HMAC-SHA256 so
+// the receiver can check that the payload came from docinator and was not altered.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request body, keyed with the
+// shared secret. It is only set when a secret is configured.
+const SignatureHeader = "X-Docinator-Signature"
+
+// EventHeader names the kind of payload; every request currently carries a scraped package.
+const EventHeader = "X-Docinator-Event"
+
+// EventPackage is the EventHeader value of a scraped package payload.
+const EventPackage = "package"
+
+// Sign returns the SignatureHeader value of body under secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the SignatureHeader value of body under secret, comparing
+// in constant time. Receivers written in Go can use it to authenticate requests.
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Post sends pkg as JSON, the same document scrape --format json writes, to url. The body is
+// signed with secret unless it is empty. Any 2xx status counts as delivered.
+func Post(ctx context.Context, client *http.Client, url string, secret []byte, pkg *models.Package) error {
+	body, err := json.Marshal(pkg)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", pkg.ImportPath, err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "docinator")
+	req.Header.Set(EventHeader, EventPackage)
+	if len(secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook post failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("webhook returned %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16)) // let the connection be reused
+	return nil
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestPost(t *testing.T) {
+	secret := []byte("s3cret")
+	var got models.Package
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(secret, body, r.Header.Get(SignatureHeader)) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(EventHeader) != EventPackage || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad headers", http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	pkg := &models.Package{ImportPath: "github.com/spf13/cobra", Version: "v1.9.1"}
+	if err := Post(context.Background(), srv.Client(), srv.URL, secret, pkg); err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if got.ImportPath != pkg.ImportPath || got.Version != pkg.Version {
+		t.Errorf("Expected the package to be delivered, got %+v", got)
+	}
+
+	if err := Post(context.Background(), srv.Client(), srv.URL, []byte("wrong"), pkg); err == nil {
+		t.Error("Expected an error when the receiver rejects the signature")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"ImportPath":"fmt"}`)
+	sig := Sign([]byte("k"), body)
+	if !Verify([]byte("k"), body, sig) {
+		t.Error("Expected the signature to verify")
+	}
+	if Verify([]byte("k"), []byte(`{"ImportPath":"os"}`), sig) {
+		t.Error("Expected a changed body to fail verification")
+	}
+}
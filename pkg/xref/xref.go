@@ -0,0 +1,166 @@
+// Package xref cross-references the corpus: for every exported type of a package, which other
+// packages mention it in the signatures and definitions of their own exported declarations.
+package xref
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+// Use is a declaration of one package that mentions a type of another.
+type Use struct {
+	ImportPath string `json:"import_path"`
+	Decl       string `json:"decl"` // the declaration, e.g. "NewRouter" or "Server.Handle"
+}
+
+// Type is an exported type of the corpus and the declarations of other packages using it.
+type Type struct {
+	ImportPath string `json:"import_path"`
+	Package    string `json:"package"` // package name, the qualifier other packages use
+	Name       string `json:"name"`
+	UsedBy     []Use  `json:"used_by"`
+}
+
+// Index is the cross-reference of a set of packages.
+type Index struct {
+	types  map[string]*Type    // "importPath.Name" → type
+	byName map[string][]string // package name → import paths
+}
+
+var qualifiedIdent = regexp.MustCompile(`\b([a-z][a-z0-9_]*)\.([A-Z][A-Za-z0-9_]*)\b`)
+
+// Build indexes pkgs, using the most recently scraped version of each import path. A qualifier
+// such as "cobra.Command" resolves when exactly one other package is named cobra and declares the
+// type Command; anything else could be a local variable or an ambiguous import.
+func Build(pkgs []*models.Package) *Index {
+	latest := map[string]*models.Package{}
+	for _, pkg := range pkgs {
+		if pkg == nil || pkg.ImportPath == "" {
+			continue
+		}
+		if cur := latest[pkg.ImportPath]; cur == nil || pkg.ScrapedAt.After(cur.ScrapedAt) {
+			latest[pkg.ImportPath] = pkg
+		}
+	}
+	ix := &Index{types: map[string]*Type{}, byName: map[string][]string{}}
+	for _, pkg := range latest {
+		ix.byName[pkg.Name] = append(ix.byName[pkg.Name], pkg.ImportPath)
+		for _, t := range pkg.Types {
+			ix.types[pkg.ImportPath+"."+t.Name] = &Type{ImportPath: pkg.ImportPath, Package: pkg.Name, Name: t.Name}
+		}
+	}
+
+	for _, pkg := range latest {
+		seen := map[string]bool{}
+		for _, d := range declarations(pkg) {
+			for _, m := range qualifiedIdent.FindAllStringSubmatch(d.text, -1) {
+				target := ix.Resolve(pkg.ImportPath, m[1], m[2])
+				key := m[0] + " " + d.name
+				if target == nil || seen[key] {
+					continue
+				}
+				seen[key] = true
+				target.UsedBy = append(target.UsedBy, Use{ImportPath: pkg.ImportPath, Decl: d.name})
+			}
+		}
+	}
+	for _, t := range ix.types {
+		sort.Slice(t.UsedBy, func(i, j int) bool {
+			if t.UsedBy[i].ImportPath != t.UsedBy[j].ImportPath {
+				return t.UsedBy[i].ImportPath < t.UsedBy[j].ImportPath
+			}
+			return t.UsedBy[i].Decl < t.UsedBy[j].Decl
+		})
+	}
+	return ix
+}
+
+// declaration is the text of an exported declaration and the name it is listed under.
+type declaration struct {
+	name, text string
+}
+
+// declarations returns the exported declarations of pkg that can mention other packages.
+func declarations(pkg *models.Package) []declaration {
+	var decls []declaration
+	for _, c := range pkg.Constants {
+		decls = append(decls, declaration{c.Name, c.Type})
+	}
+	for _, v := range pkg.Variables {
+		decls = append(decls, declaration{v.Name, v.Type})
+	}
+	for _, f := range pkg.Functions {
+		decls = append(decls, declaration{f.Name, f.Signature})
+	}
+	for _, t := range pkg.Types {
+		decls = append(decls, declaration{t.Name, t.Definition})
+		for _, m := range t.Methods {
+			decls = append(decls, declaration{methodName(t.Name, m.Name), m.Signature})
+		}
+	}
+	return decls
+}
+
+// methodName qualifies a method name with its type, unless the parser already did.
+func methodName(typeName, name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return typeName + "." + name
+}
+
+// Resolve returns the type the package at importPath refers to as qualifier.name: the type of
+// that name declared by the one other package named qualifier that declares it, or nil when none
+// or several do.
+func (ix *Index) Resolve(importPath, qualifier, name string) *Type {
+	var target *Type
+	for _, cand := range ix.byName[qualifier] {
+		t := ix.types[cand+"."+name]
+		if cand == importPath || t == nil {
+			continue
+		}
+		if target != nil {
+			return nil
+		}
+		target = t
+	}
+	return target
+}
+
+// UsedBy returns the import paths of the packages using each type of the package at importPath
+// that is used at all, for the "Used by" notes of its documentation.
+func (ix *Index) UsedBy(importPath string) map[string][]string {
+	out := map[string][]string{}
+	for _, t := range ix.types {
+		if t.ImportPath != importPath {
+			continue
+		}
+		for _, u := range t.UsedBy {
+			if paths := out[t.Name]; len(paths) == 0 || paths[len(paths)-1] != u.ImportPath {
+				out[t.Name] = append(paths, u.ImportPath)
+			}
+		}
+	}
+	return out
+}
+
+// Find returns the types matching symbol, sorted by import path: "Command" matches every type of
+// that name, "cobra.Command" those of packages named cobra, and "github.com/spf13/cobra.Command"
+// the one of that import path.
+func (ix *Index) Find(symbol string) []*Type {
+	qualifier, name := "", symbol
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		qualifier, name = symbol[:i], symbol[i+1:]
+	}
+	var out []*Type
+	for _, t := range ix.types {
+		if t.Name == name && (qualifier == "" || qualifier == t.Package || qualifier == t.ImportPath) {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ImportPath < out[j].ImportPath })
+	return out
+}
@@ -0,0 +1,84 @@
+package xref
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/moseye/docinator/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Now()
+	cobra := &models.Package{Name: "cobra", ImportPath: "github.com/spf13/cobra", ScrapedAt: now,
+		Types: []models.Type{
+			{Name: "Command", Methods: []models.Function{{Name: "Command.Flags", Signature: "func (c *Command) Flags() *pflag.FlagSet"}}},
+			{Name: "Unused"},
+		}}
+	pflag := &models.Package{Name: "pflag", ImportPath: "github.com/spf13/pflag", ScrapedAt: now,
+		Types: []models.Type{{Name: "FlagSet"}}}
+	doc := &models.Package{Name: "doc", ImportPath: "github.com/spf13/cobra/doc", ScrapedAt: now,
+		Functions: []models.Function{
+			{Name: "GenMarkdown", Signature: "func GenMarkdown(cmd *cobra.Command, w io.Writer) error"},
+			{Name: "GenYaml", Signature: "func GenYaml(cmd *cobra.Command, w io.Writer) error"},
+		},
+		Types: []models.Type{{Name: "GenManHeader", Definition: "type GenManHeader struct {\n\tRoot *cobra.Command\n}"}}}
+	// An older snapshot of doc must not count twice; a second package named cobra without the
+	// type leaves the qualifier unambiguous.
+	oldDoc := &models.Package{Name: "doc", ImportPath: "github.com/spf13/cobra/doc", ScrapedAt: now.Add(-time.Hour),
+		Functions: []models.Function{{Name: "Old", Signature: "func Old(cmd *cobra.Command)"}}}
+	fork := &models.Package{Name: "cobra", ImportPath: "example.com/fork/cobra", ScrapedAt: now}
+
+	ix := Build([]*models.Package{cobra, pflag, doc, oldDoc, fork})
+
+	types := ix.Find("cobra.Command")
+	if len(types) != 1 {
+		t.Fatalf("Expected one match for cobra.Command, got %d", len(types))
+	}
+	want := []Use{
+		{ImportPath: "github.com/spf13/cobra/doc", Decl: "GenManHeader"},
+		{ImportPath: "github.com/spf13/cobra/doc", Decl: "GenMarkdown"},
+		{ImportPath: "github.com/spf13/cobra/doc", Decl: "GenYaml"},
+	}
+	if !reflect.DeepEqual(types[0].UsedBy, want) {
+		t.Errorf("UsedBy = %+v, want %+v", types[0].UsedBy, want)
+	}
+	if got := ix.Find("github.com/spf13/pflag.FlagSet"); len(got) != 1 || len(got[0].UsedBy) != 1 || got[0].UsedBy[0].Decl != "Command.Flags" {
+		t.Errorf("Expected FlagSet to be used by Command.Flags, got %+v", got)
+	}
+	if got := ix.Find("Command"); len(got) != 1 {
+		t.Errorf("Expected an unqualified name to match, got %d", len(got))
+	}
+	if got := ix.Find("other.Command"); len(got) != 0 {
+		t.Errorf("Expected no match for another qualifier, got %d", len(got))
+	}
+
+	if got := ix.Resolve("github.com/spf13/cobra/doc", "cobra", "Command"); got == nil || got.ImportPath != "github.com/spf13/cobra" {
+		t.Errorf("Expected cobra.Command to resolve to github.com/spf13/cobra, got %+v", got)
+	}
+	if got := ix.Resolve("github.com/spf13/cobra", "cobra", "Unused"); got != nil {
+		t.Errorf("Expected a package not to resolve its own qualifier, got %+v", got)
+	}
+
+	usedBy := ix.UsedBy("github.com/spf13/cobra")
+	if !reflect.DeepEqual(usedBy, map[string][]string{"Command": {"github.com/spf13/cobra/doc"}}) {
+		t.Errorf("Unexpected UsedBy notes %v", usedBy)
+	}
+}
+
+func TestBuildSkipsAmbiguousQualifiers(t *testing.T) {
+	a := &models.Package{Name: "template", ImportPath: "text/template", Types: []models.Type{{Name: "Template"}}}
+	b := &models.Package{Name: "template", ImportPath: "html/template", Types: []models.Type{{Name: "Template"}}}
+	user := &models.Package{Name: "user", ImportPath: "example.com/user",
+		Functions: []models.Function{{Name: "Parse", Signature: "func Parse(t *template.Template)"}}}
+
+	ix := Build([]*models.Package{a, b, user})
+	for _, typ := range ix.Find("template.Template") {
+		if len(typ.UsedBy) != 0 {
+			t.Errorf("Expected no uses of the ambiguous %s.Template, got %+v", typ.ImportPath, typ.UsedBy)
+		}
+	}
+	if got := ix.Resolve(user.ImportPath, "template", "Template"); got != nil {
+		t.Errorf("Expected the ambiguous qualifier not to resolve, got %+v", got)
+	}
+}